@@ -0,0 +1 @@
+DROP INDEX records_user_id_idx;
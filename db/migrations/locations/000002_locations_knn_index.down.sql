@@ -0,0 +1 @@
+DROP INDEX IF EXISTS locations_point_earth_idx;
@@ -0,0 +1 @@
+CREATE INDEX locations_point_earth_idx ON locations USING gist (ll_to_earth(point[1], point[0]));
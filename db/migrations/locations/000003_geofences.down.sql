@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS update_updated_at ON geofences;
+DROP TABLE IF EXISTS geofence_events;
+DROP TABLE IF EXISTS geofences;
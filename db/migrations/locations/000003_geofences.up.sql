@@ -0,0 +1,42 @@
+CREATE TABLE geofences (
+    id SERIAL,
+    name varchar(64) NOT NULL,
+    type varchar(16) NOT NULL,
+    center POINT,
+    radius double precision,
+    polygon jsonb,
+    exterior POLYGON,
+    holes POLYGON[],
+    dwell_time INT DEFAULT 0 NOT NULL,
+    created_at timestamp DEFAULT current_timestamp NOT NULL,
+    updated_at timestamp DEFAULT current_timestamp NOT NULL,
+
+    CONSTRAINT geofences_pkey PRIMARY KEY (id),
+    CONSTRAINT geofences_name_key UNIQUE (name),
+    CONSTRAINT geofences_dwell_time_valid CHECK (dwell_time >= 0),
+    CONSTRAINT geofences_shape_valid CHECK (
+        (type = 'circle' AND center IS NOT NULL AND radius >= 0 AND exterior IS NULL) OR
+        (type = 'polygon' AND center IS NULL AND polygon IS NOT NULL AND exterior IS NOT NULL)
+    )
+);
+
+CREATE TABLE geofence_events (
+    id SERIAL,
+    geofence_id INT NOT NULL,
+    user_id INT NOT NULL,
+    type varchar(8) NOT NULL,
+    point POINT NOT NULL,
+    created_at timestamp DEFAULT current_timestamp NOT NULL,
+
+    CONSTRAINT geofence_events_pkey PRIMARY KEY (id),
+    CONSTRAINT geofence_events_geofence_id_fkey FOREIGN KEY (geofence_id) REFERENCES geofences(id) ON DELETE CASCADE,
+    CONSTRAINT geofence_events_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id),
+    CONSTRAINT geofence_events_type_valid CHECK (type IN ('ENTER', 'EXIT', 'DWELL'))
+);
+
+CREATE INDEX geofence_events_user_id_geofence_id_idx ON geofence_events (user_id, geofence_id, id);
+CREATE INDEX geofence_events_geofence_id_idx ON geofence_events (geofence_id, id);
+
+CREATE TRIGGER update_updated_at BEFORE UPDATE
+    ON geofences FOR EACH ROW EXECUTE PROCEDURE
+        update_updated_at();
@@ -0,0 +1 @@
+ALTER TABLE locations DROP COLUMN recorded_at;
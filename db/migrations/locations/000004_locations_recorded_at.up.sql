@@ -0,0 +1,7 @@
+ALTER TABLE locations ADD COLUMN recorded_at timestamp;
+
+UPDATE locations SET recorded_at = updated_at;
+
+ALTER TABLE locations
+    ALTER COLUMN recorded_at SET DEFAULT current_timestamp,
+    ALTER COLUMN recorded_at SET NOT NULL;
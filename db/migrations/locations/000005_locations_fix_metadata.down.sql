@@ -0,0 +1,7 @@
+ALTER TABLE locations
+    DROP CONSTRAINT locations_fix_metadata_valid,
+    DROP COLUMN accuracy,
+    DROP COLUMN altitude,
+    DROP COLUMN speed,
+    DROP COLUMN bearing,
+    DROP COLUMN source;
@@ -0,0 +1,12 @@
+ALTER TABLE locations
+    ADD COLUMN accuracy double precision,
+    ADD COLUMN altitude double precision,
+    ADD COLUMN speed double precision,
+    ADD COLUMN bearing double precision,
+    ADD COLUMN source varchar(16),
+    ADD CONSTRAINT locations_fix_metadata_valid CHECK (
+        (accuracy IS NULL OR accuracy >= 0) AND
+        (speed IS NULL OR speed >= 0) AND
+        (bearing IS NULL OR (bearing >= 0 AND bearing < 360)) AND
+        (source IS NULL OR source IN ('gps', 'network', 'manual'))
+    );
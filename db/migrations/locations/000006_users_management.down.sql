@@ -0,0 +1,9 @@
+DROP INDEX users_username_prefix_idx;
+
+ALTER TABLE geofence_events
+    DROP CONSTRAINT geofence_events_user_id_fkey,
+    ADD CONSTRAINT geofence_events_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
+
+ALTER TABLE locations
+    DROP CONSTRAINT locations_user_id_fkey,
+    ADD CONSTRAINT locations_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_updated_at ON erasure_jobs;
+DROP TABLE IF EXISTS erasure_jobs;
@@ -0,0 +1,21 @@
+CREATE TABLE erasure_jobs (
+    id SERIAL,
+    user_id INT NOT NULL,
+    username varchar(16) NOT NULL,
+    status varchar(16) DEFAULT 'pending' NOT NULL,
+    attempts INT DEFAULT 0 NOT NULL,
+    last_error text,
+    created_at timestamp DEFAULT current_timestamp NOT NULL,
+    updated_at timestamp DEFAULT current_timestamp NOT NULL,
+    completed_at timestamp,
+
+    CONSTRAINT erasure_jobs_pkey PRIMARY KEY (id),
+    CONSTRAINT erasure_jobs_user_id_key UNIQUE (user_id),
+    CONSTRAINT erasure_jobs_status_valid CHECK (status IN ('pending', 'completed'))
+);
+
+CREATE INDEX erasure_jobs_status_idx ON erasure_jobs (status, id);
+
+CREATE TRIGGER update_updated_at BEFORE UPDATE
+    ON erasure_jobs FOR EACH ROW EXECUTE PROCEDURE
+        update_updated_at();
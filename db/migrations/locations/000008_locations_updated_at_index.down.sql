@@ -0,0 +1 @@
+DROP INDEX IF EXISTS locations_updated_at_idx;
@@ -0,0 +1 @@
+CREATE INDEX locations_updated_at_idx ON locations (updated_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS locations_earth_idx;
+CREATE INDEX IF NOT EXISTS locations_point_earth_idx ON locations USING gist (ll_to_earth(point[1], point[0]));
+DROP TRIGGER IF EXISTS set_location_earth ON locations;
+DROP FUNCTION IF EXISTS set_location_earth();
+ALTER TABLE locations DROP COLUMN IF EXISTS earth;
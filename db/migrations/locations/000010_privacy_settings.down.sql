@@ -0,0 +1,2 @@
+DROP TRIGGER IF EXISTS update_updated_at ON privacy_settings;
+DROP TABLE IF EXISTS privacy_settings;
@@ -0,0 +1,24 @@
+-- Users without privacy settings show their exact locations.
+CREATE TABLE privacy_settings (
+    user_id INT,
+    level varchar(16) NOT NULL,
+    grid_size double precision,
+    geohash_precision INT,
+    created_at timestamp DEFAULT current_timestamp NOT NULL,
+    updated_at timestamp DEFAULT current_timestamp NOT NULL,
+
+    CONSTRAINT privacy_settings_pkey PRIMARY KEY (user_id),
+    CONSTRAINT privacy_settings_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT privacy_settings_level_valid CHECK (level IN ('exact', 'coarse', 'hidden')),
+    CONSTRAINT privacy_settings_coarsening_valid CHECK (
+        (level = 'coarse' AND (
+            (grid_size > 0 AND grid_size <= 10 AND geohash_precision IS NULL) OR
+            (grid_size IS NULL AND geohash_precision BETWEEN 1 AND 12)
+        )) OR
+        (level <> 'coarse' AND grid_size IS NULL AND geohash_precision IS NULL)
+    )
+);
+
+CREATE TRIGGER update_updated_at BEFORE UPDATE
+    ON privacy_settings FOR EACH ROW EXECUTE PROCEDURE
+        update_updated_at();
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS blocks;
+
+DROP TRIGGER IF EXISTS update_updated_at ON follows;
+DROP TABLE IF EXISTS follows;
@@ -0,0 +1,33 @@
+CREATE TABLE follows (
+    follower_id INT NOT NULL,
+    followee_id INT NOT NULL,
+    status varchar(16) DEFAULT 'pending' NOT NULL,
+    created_at timestamp DEFAULT current_timestamp NOT NULL,
+    updated_at timestamp DEFAULT current_timestamp NOT NULL,
+
+    CONSTRAINT follows_pkey PRIMARY KEY (follower_id, followee_id),
+    CONSTRAINT follows_follower_id_fkey FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT follows_followee_id_fkey FOREIGN KEY (followee_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT follows_not_self CHECK (follower_id <> followee_id),
+    CONSTRAINT follows_status_valid CHECK (status IN ('pending', 'accepted'))
+);
+
+-- Followers of a user are listed by followee.
+CREATE INDEX follows_followee_id_idx ON follows (followee_id, follower_id);
+
+CREATE TRIGGER update_updated_at BEFORE UPDATE
+    ON follows FOR EACH ROW EXECUTE PROCEDURE
+        update_updated_at();
+
+CREATE TABLE blocks (
+    blocker_id INT NOT NULL,
+    blocked_id INT NOT NULL,
+    created_at timestamp DEFAULT current_timestamp NOT NULL,
+
+    CONSTRAINT blocks_pkey PRIMARY KEY (blocker_id, blocked_id),
+    CONSTRAINT blocks_blocker_id_fkey FOREIGN KEY (blocker_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT blocks_blocked_id_fkey FOREIGN KEY (blocked_id) REFERENCES users(id) ON DELETE CASCADE,
+    CONSTRAINT blocks_not_self CHECK (blocker_id <> blocked_id)
+);
+
+CREATE INDEX blocks_blocked_id_idx ON blocks (blocked_id);
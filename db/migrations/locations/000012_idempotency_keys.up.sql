@@ -0,0 +1,15 @@
+-- Responses of location writes sent with idempotency keys, replayed to retries of the writes.
+-- Responses are NULL while the writes are in progress.
+CREATE TABLE idempotency_keys (
+    username varchar(16) NOT NULL,
+    idempotency_key varchar(255) NOT NULL,
+    request_hash varchar(64) NOT NULL,
+    response jsonb,
+    created_at timestamp DEFAULT current_timestamp NOT NULL,
+    expires_at timestamp NOT NULL,
+
+    CONSTRAINT idempotency_keys_pkey PRIMARY KEY (username, idempotency_key)
+);
+
+-- Expired keys are deleted by expiration time.
+CREATE INDEX idempotency_keys_expires_at_idx ON idempotency_keys (expires_at);
@@ -0,0 +1,18 @@
+DROP INDEX IF EXISTS locations_visible_earth_idx;
+CREATE INDEX IF NOT EXISTS locations_earth_idx ON locations USING gist (earth);
+
+DROP TRIGGER IF EXISTS update_updated_at ON locations;
+CREATE TRIGGER update_updated_at BEFORE UPDATE
+    ON locations FOR EACH ROW EXECUTE PROCEDURE
+        update_updated_at();
+
+DROP TRIGGER IF EXISTS update_location_visible_point ON privacy_settings;
+DROP FUNCTION IF EXISTS update_location_visible_point();
+DROP TRIGGER IF EXISTS set_location_visible_point ON locations;
+DROP FUNCTION IF EXISTS set_location_visible_point();
+DROP FUNCTION IF EXISTS visible_point(POINT, varchar, float8, INT);
+DROP FUNCTION IF EXISTS snap_to_grid(float8, float8, float8, float8);
+
+ALTER TABLE locations
+    DROP COLUMN IF EXISTS visible_earth,
+    DROP COLUMN IF EXISTS visible_point;
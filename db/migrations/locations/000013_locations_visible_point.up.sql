@@ -0,0 +1,96 @@
+-- The point the location is shown at according to the privacy settings of the user:
+-- the exact point, the center of its grid or geohash cell for coarse users and NULL for hidden users.
+-- Locations are searched by these points, so coarse users never reveal their exact locations.
+ALTER TABLE locations
+    ADD COLUMN visible_point POINT,
+    ADD COLUMN visible_earth cube;
+
+-- snap_to_grid returns the center of the cell of the grid between min_value and max_value the value is in.
+-- It mirrors the grid snapping of the service.
+CREATE OR REPLACE FUNCTION snap_to_grid(value float8, min_value float8, max_value float8, size float8)
+    RETURNS float8 AS $$
+    SELECT least(
+        min_value + (greatest(0, least(ceil((max_value - min_value) / size) - 1, floor((value - min_value) / size))) + 0.5) * size,
+        max_value
+    );
+$$ language 'sql' IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION visible_point(p POINT, level varchar, grid_size float8, geohash_precision INT)
+    RETURNS POINT AS $$
+DECLARE
+    lon_size float8 := grid_size;
+    lat_size float8 := grid_size;
+BEGIN
+    IF level = 'hidden' THEN
+        RETURN NULL;
+    END IF;
+    IF level IS DISTINCT FROM 'coarse' THEN
+        RETURN p;
+    END IF;
+
+    -- Geohash cells split longitudes with the first bit and bits alternate after that.
+    IF geohash_precision IS NOT NULL THEN
+        lon_size := 360 / 2 ^ ceil(5 * geohash_precision / 2.0);
+        lat_size := 180 / 2 ^ floor(5 * geohash_precision / 2.0);
+    END IF;
+
+    RETURN point(
+        TRUNC(snap_to_grid(p[0], -180, 180, lon_size)::numeric, 8),
+        TRUNC(snap_to_grid(p[1], -90, 90, lat_size)::numeric, 8)
+    );
+END;
+$$ language 'plpgsql' IMMUTABLE;
+
+CREATE OR REPLACE FUNCTION set_location_visible_point()
+    RETURNS TRIGGER AS $$
+DECLARE
+    settings privacy_settings%ROWTYPE;
+BEGIN
+    SELECT * INTO settings FROM privacy_settings WHERE user_id = NEW.user_id;
+    NEW.visible_point = visible_point(NEW.point, settings.level, settings.grid_size, settings.geohash_precision);
+    NEW.visible_earth = ll_to_earth(NEW.visible_point[1], NEW.visible_point[0]);
+RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+-- Triggers fire in alphabetical order, so the visible point is built from the truncated point.
+CREATE TRIGGER set_location_visible_point BEFORE INSERT OR UPDATE OF point
+    ON locations FOR EACH ROW EXECUTE PROCEDURE
+        set_location_visible_point();
+
+-- Privacy settings are only deleted along with their users, so deletions are not handled.
+CREATE OR REPLACE FUNCTION update_location_visible_point()
+    RETURNS TRIGGER AS $$
+DECLARE
+    p POINT;
+BEGIN
+    SELECT visible_point(l.point, NEW.level, NEW.grid_size, NEW.geohash_precision) INTO p
+    FROM locations l
+    WHERE l.user_id = NEW.user_id;
+
+    UPDATE locations SET visible_point = p, visible_earth = ll_to_earth(p[1], p[0]) WHERE user_id = NEW.user_id;
+RETURN NEW;
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER update_location_visible_point AFTER INSERT OR UPDATE
+    ON privacy_settings FOR EACH ROW EXECUTE PROCEDURE
+        update_location_visible_point();
+
+-- Changes of privacy settings do not change the time the location was updated at.
+DROP TRIGGER IF EXISTS update_updated_at ON locations;
+CREATE TRIGGER update_updated_at BEFORE UPDATE OF point, recorded_at, accuracy, altitude, speed, bearing, source
+    ON locations FOR EACH ROW EXECUTE PROCEDURE
+        update_updated_at();
+
+-- Backfill existing rows, the time their locations were updated at is kept by the trigger above.
+UPDATE locations SET visible_point = point;
+UPDATE locations l
+SET visible_point = visible_point(l.point, p.level, p.grid_size, p.geohash_precision)
+FROM privacy_settings p
+WHERE p.user_id = l.user_id;
+UPDATE locations SET visible_earth = ll_to_earth(visible_point[1], visible_point[0]);
+
+-- Locations are only searched by their visible points.
+DROP INDEX IF EXISTS locations_earth_idx;
+CREATE INDEX locations_visible_earth_idx ON locations USING gist (visible_earth);
@@ -44,7 +44,8 @@ func (h *GRPCHandler) AddRecord(ctx context.Context, req *pb.AddRecordRequest) (
 			req.B.Longitude,
 			req.B.Latitude,
 		},
-		Timestamp: req.Timestamp.AsTime(),
+		Timestamp:   req.Timestamp.AsTime(),
+		FixMetadata: fixMetadataFromPB(req.Metadata),
 	})
 
 	if err != nil {
@@ -62,9 +63,45 @@ func (h *GRPCHandler) AddRecord(ctx context.Context, req *pb.AddRecordRequest) (
 			Latitude:  res.B.Latitude(),
 		},
 		Timestamp: timestamppb.New(res.Timestamp),
+		Metadata:  fixMetadataToPB(res.FixMetadata),
 	}, status.Error(codes.OK, "")
 }
 
+// AddRecords adds all the provided records at once.
+func (h *GRPCHandler) AddRecords(ctx context.Context, req *pb.AddRecordsRequest) (*pb.AddRecordsResponse, error) {
+	records := make([]port.HistoryServiceAddRecordRequest, 0, len(req.Records))
+	for _, r := range req.Records {
+		if r.A == nil || r.B == nil || r.Timestamp == nil {
+			return nil, errpack.ErrToGRPC(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		}
+		records = append(records, port.HistoryServiceAddRecordRequest{
+			UserID:      int(r.UserId),
+			A:           geo.Point{r.A.Longitude, r.A.Latitude},
+			B:           geo.Point{r.B.Longitude, r.B.Latitude},
+			Timestamp:   r.Timestamp.AsTime(),
+			FixMetadata: fixMetadataFromPB(r.Metadata),
+		})
+	}
+
+	res, err := h.service.AddRecords(ctx, port.HistoryServiceAddRecordsRequest{Records: records})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	result := make([]*pb.AddRecordResponse, 0, len(res))
+	for _, record := range res {
+		result = append(result, &pb.AddRecordResponse{
+			UserId:    int32(record.UserID),
+			A:         &pb.Point{Longitude: record.A.Longitude(), Latitude: record.A.Latitude()},
+			B:         &pb.Point{Longitude: record.B.Longitude(), Latitude: record.B.Latitude()},
+			Timestamp: timestamppb.New(record.Timestamp),
+			Metadata:  fixMetadataToPB(record.FixMetadata),
+		})
+	}
+
+	return &pb.AddRecordsResponse{Records: result}, status.Error(codes.OK, "")
+}
+
 func (h *GRPCHandler) GetDistance(ctx context.Context, req *pb.GetDistanceRequest) (*pb.GetDistanceResponse, error) {
 	if req.From == nil || req.To == nil {
 		// TODO: specify error
@@ -82,3 +119,38 @@ func (h *GRPCHandler) GetDistance(ctx context.Context, req *pb.GetDistanceReques
 
 	return &pb.GetDistanceResponse{Distance: res.Distance}, status.Error(codes.OK, "")
 }
+
+// DeleteUserRecords deletes all the records of the user.
+func (h *GRPCHandler) DeleteUserRecords(ctx context.Context, req *pb.DeleteUserRecordsRequest) (*pb.DeleteUserRecordsResponse, error) {
+	deleted, err := h.service.DeleteUserRecords(ctx, int(req.UserId))
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return &pb.DeleteUserRecordsResponse{Deleted: int64(deleted)}, status.Error(codes.OK, "")
+}
+
+// fixMetadataFromPB converts protobuf fix metadata, nil means unknown metadata.
+func fixMetadataFromPB(m *pb.RecordMetadata) geo.FixMetadata {
+	if m == nil {
+		return geo.FixMetadata{}
+	}
+
+	return geo.FixMetadata{
+		Accuracy: m.Accuracy,
+		Altitude: m.Altitude,
+		Speed:    m.Speed,
+		Bearing:  m.Bearing,
+		Source:   geo.FixSource(m.Source),
+	}
+}
+
+func fixMetadataToPB(m geo.FixMetadata) *pb.RecordMetadata {
+	return &pb.RecordMetadata{
+		Accuracy: m.Accuracy,
+		Altitude: m.Altitude,
+		Speed:    m.Speed,
+		Bearing:  m.Bearing,
+		Source:   string(m.Source),
+	}
+}
@@ -20,6 +20,7 @@ import (
   "google.golang.org/grpc/codes"
   "google.golang.org/grpc/status"
   "google.golang.org/grpc/test/bufconn"
+  "google.golang.org/protobuf/proto"
   "google.golang.org/protobuf/types/known/timestamppb"
   "net"
   "testing"
@@ -97,6 +98,61 @@ func (s *GRPCHandlerTestSuite) TestAddRecord() {
       },
       expectedErrCode: codes.OK,
     },
+    {
+      name: "OK with fix metadata",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        metadata := geo.FixMetadata{Accuracy: proto.Float64(3), Altitude: proto.Float64(120), Source: geo.FixSourceGPS}
+        repo.EXPECT().
+          AddRecord(gomock.Any(), gomock.Eq(port.HistoryRepositoryAddRecordRequest{
+            UserID:      userID,
+            A:           truncedA,
+            B:           truncedB,
+            Timestamp:   timestamp,
+            FixMetadata: metadata,
+          })).
+          Times(1).
+          Return(domain.Record{
+            ID:          recordID,
+            UserID:      userID,
+            A:           truncedA,
+            B:           truncedB,
+            Timestamp:   timestamp,
+            FixMetadata: metadata,
+          }, nil)
+      },
+      req: &pb.AddRecordRequest{
+        UserId:    int32(userID),
+        A:         &pb.Point{Longitude: a.Longitude(), Latitude: a.Latitude()},
+        B:         &pb.Point{Longitude: b.Longitude(), Latitude: b.Latitude()},
+        Timestamp: timestamppb.New(timestamp),
+        Metadata:  &pb.RecordMetadata{Accuracy: proto.Float64(3), Altitude: proto.Float64(120), Source: "gps"},
+      },
+      expectedRes: &pb.AddRecordResponse{
+        UserId:    int32(userID),
+        A:         &pb.Point{Longitude: truncedA.Longitude(), Latitude: truncedA.Latitude()},
+        B:         &pb.Point{Longitude: truncedB.Longitude(), Latitude: truncedB.Latitude()},
+        Timestamp: timestamppb.New(timestamp),
+        Metadata:  &pb.RecordMetadata{Accuracy: proto.Float64(3), Altitude: proto.Float64(120), Source: "gps"},
+      },
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "invalid fix metadata",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().
+          AddRecord(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.AddRecordRequest{
+        UserId:    int32(userID),
+        A:         &pb.Point{Longitude: a.Longitude(), Latitude: a.Latitude()},
+        B:         &pb.Point{Longitude: b.Longitude(), Latitude: b.Latitude()},
+        Timestamp: timestamppb.New(timestamp),
+        Metadata:  &pb.RecordMetadata{Bearing: proto.Float64(400)},
+      },
+      expectedRes:     nil,
+      expectedErrCode: codes.InvalidArgument,
+    },
     {
       name: "user not found",
       buildStubs: func(repo *mock.MockHistoryRepository) {
@@ -379,6 +435,9 @@ func (s *GRPCHandlerTestSuite) TestAddRecord() {
         require.Equal(s.T(), tc.expectedRes.B.Latitude, response.B.Latitude)
 
         require.WithinDuration(s.T(), tc.expectedRes.Timestamp.AsTime(), response.Timestamp.AsTime(), time.Second)
+        if tc.expectedRes.Metadata != nil {
+          require.True(s.T(), proto.Equal(tc.expectedRes.Metadata, response.Metadata))
+        }
       }
 
       st, ok := status.FromError(err)
@@ -388,6 +447,148 @@ func (s *GRPCHandlerTestSuite) TestAddRecord() {
   }
 }
 
+func (s *GRPCHandlerTestSuite) TestAddRecords() {
+  userID := testutil.RandomInt(1, 100)
+  a := geo.Point{
+    testutil.RandomLongitude(),
+    testutil.RandomLatitude(),
+  }
+  b := geo.Point{
+    testutil.RandomLongitude(),
+    testutil.RandomLatitude(),
+  }
+  timestamp := time.Now().UTC()
+
+  pbRecord := func(a, b geo.Point) *pb.AddRecordRequest {
+    return &pb.AddRecordRequest{
+      UserId:    int32(userID),
+      A:         &pb.Point{Longitude: a.Longitude(), Latitude: a.Latitude()},
+      B:         &pb.Point{Longitude: b.Longitude(), Latitude: b.Latitude()},
+      Timestamp: timestamppb.New(timestamp),
+    }
+  }
+
+  testCases := []struct {
+    name            string
+    buildStubs      func(repo *mock.MockHistoryRepository)
+    req             *pb.AddRecordsRequest
+    expectedRecords int
+    expectedErrCode codes.Code
+  }{
+    {
+      name: "OK",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        args := []port.HistoryRepositoryAddRecordRequest{
+          {UserID: userID, A: geo.Trunc(a), B: geo.Trunc(b), Timestamp: timestamp},
+          {UserID: userID, A: geo.Trunc(b), B: geo.Trunc(a), Timestamp: timestamp},
+        }
+        repo.EXPECT().
+          AddRecords(gomock.Any(), gomock.Eq(args)).
+          Times(1).
+          Return([]domain.Record{
+            {ID: 1, UserID: userID, A: args[0].A, B: args[0].B, Timestamp: timestamp},
+            {ID: 2, UserID: userID, A: args[1].A, B: args[1].B, Timestamp: timestamp},
+          }, nil)
+      },
+      req: &pb.AddRecordsRequest{
+        Records: []*pb.AddRecordRequest{pbRecord(a, b), pbRecord(b, a)},
+      },
+      expectedRecords: 2,
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "no records",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().
+          AddRecords(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req:             &pb.AddRecordsRequest{},
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "invalid record",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().
+          AddRecords(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.AddRecordsRequest{
+        Records: []*pb.AddRecordRequest{pbRecord(a, b), pbRecord(a, geo.Point{0, 91})},
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "missing point",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().
+          AddRecords(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.AddRecordsRequest{
+        Records: []*pb.AddRecordRequest{{UserId: int32(userID), Timestamp: timestamppb.New(timestamp)}},
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "internal error",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().
+          AddRecords(gomock.Any(), gomock.Any()).
+          Times(1).
+          Return(nil, fmt.Errorf("%w", errpack.ErrInternalError))
+      },
+      req: &pb.AddRecordsRequest{
+        Records: []*pb.AddRecordRequest{pbRecord(a, b)},
+      },
+      expectedErrCode: codes.Internal,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      repo := mock.NewMockHistoryRepository(ctrl)
+      tc.buildStubs(repo)
+
+      svc := service.NewHistoryService(repo, mock.NewMockLocationClient(ctrl), log.NewTestingLogger())
+
+      listener := bufconn.Listen(1024 * 1024)
+      server := grpc.NewServer()
+      pb.RegisterHistoryServer(server, handler.NewGRPCHandler(svc))
+
+      go func() {
+        if err := server.Serve(listener); err != nil {
+          s.Fail(err.Error())
+        }
+      }()
+      defer server.Stop()
+
+      dial := func(context.Context, string) (net.Conn, error) {
+        return listener.Dial()
+      }
+
+      conn, err := grpc.DialContext(context.Background(), "", grpc.WithInsecure(), grpc.WithContextDialer(dial))
+      if err != nil {
+        s.Fail(err.Error())
+      }
+      defer conn.Close()
+
+      client := pb.NewHistoryClient(conn)
+
+      response, err := client.AddRecords(context.Background(), tc.req)
+      require.Equal(s.T(), tc.expectedErrCode, status.Code(err))
+      if tc.expectedErrCode == codes.OK {
+        require.Len(s.T(), response.Records, tc.expectedRecords)
+        require.Equal(s.T(), int32(userID), response.Records[0].UserId)
+      }
+    })
+  }
+}
+
 func (s *GRPCHandlerTestSuite) TestGetDistance() {
   userID := testutil.RandomInt(1, 100)
   from, to := testutil.RandomTimeInterval()
@@ -481,6 +682,104 @@ func (s *GRPCHandlerTestSuite) TestGetDistance() {
   }
 }
 
+func (s *GRPCHandlerTestSuite) TestDeleteUserRecords() {
+  userID := testutil.RandomInt(1, 100)
+
+  testCases := []struct {
+    name            string
+    buildStubs      func(repo *mock.MockHistoryRepository)
+    req             *pb.DeleteUserRecordsRequest
+    expectedDeleted int64
+    expectedErrCode codes.Code
+  }{
+    {
+      name: "OK",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().
+          DeleteUserRecords(gomock.Any(), gomock.Eq(userID)).
+          Times(1).
+          Return(3, nil)
+      },
+      req:             &pb.DeleteUserRecordsRequest{UserId: int32(userID)},
+      expectedDeleted: 3,
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "OK already deleted",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().
+          DeleteUserRecords(gomock.Any(), gomock.Eq(userID)).
+          Times(1).
+          Return(0, nil)
+      },
+      req:             &pb.DeleteUserRecordsRequest{UserId: int32(userID)},
+      expectedDeleted: 0,
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "invalid user id",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().DeleteUserRecords(gomock.Any(), gomock.Any()).Times(0)
+      },
+      req:             &pb.DeleteUserRecordsRequest{UserId: 0},
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "internal error",
+      buildStubs: func(repo *mock.MockHistoryRepository) {
+        repo.EXPECT().
+          DeleteUserRecords(gomock.Any(), gomock.Eq(userID)).
+          Times(1).
+          Return(0, errpack.ErrInternalError)
+      },
+      req:             &pb.DeleteUserRecordsRequest{UserId: int32(userID)},
+      expectedErrCode: codes.Internal,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      repo := mock.NewMockHistoryRepository(ctrl)
+      tc.buildStubs(repo)
+
+      svc := service.NewHistoryService(repo, mock.NewMockLocationClient(ctrl), log.NewTestingLogger())
+
+      listener := bufconn.Listen(1024 * 1024)
+      server := grpc.NewServer()
+      pb.RegisterHistoryServer(server, handler.NewGRPCHandler(svc))
+
+      go func() {
+        if err := server.Serve(listener); err != nil {
+          s.Fail(err.Error())
+        }
+      }()
+      defer server.Stop()
+
+      dial := func(context.Context, string) (net.Conn, error) {
+        return listener.Dial()
+      }
+
+      conn, err := grpc.DialContext(context.Background(), "", grpc.WithInsecure(), grpc.WithContextDialer(dial))
+      if err != nil {
+        s.Fail(err.Error())
+      }
+      defer conn.Close()
+
+      client := pb.NewHistoryClient(conn)
+
+      response, err := client.DeleteUserRecords(context.Background(), tc.req)
+      require.Equal(s.T(), tc.expectedErrCode, status.Code(err))
+      if tc.expectedErrCode == codes.OK {
+        require.Equal(s.T(), tc.expectedDeleted, response.Deleted)
+      }
+    })
+  }
+}
+
 func TestGRPCHandlerTestSuite(t *testing.T) {
   suite.Run(t, new(GRPCHandlerTestSuite))
 }
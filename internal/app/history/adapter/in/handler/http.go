@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"fmt"
 	log2 "log"
 	"net/http"
 	"time"
@@ -9,8 +10,10 @@ import (
 	middleware2 "github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 	"github.com/gorilla/schema"
+	"gitlab.com/spacewalker/geotracker/internal/app/history/core/domain"
 	"gitlab.com/spacewalker/geotracker/internal/app/history/core/port"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/middleware"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
@@ -59,7 +62,7 @@ func (h *HTTPHandler) setupRoutes() {
 		cors.Handler(cors.Options{
 			AllowedOrigins:   []string{"*"},
 			AllowedMethods:   []string{"GET", "POST"},
-			AllowedHeaders:   []string{"Accept", "Content-Type"},
+			AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization", "X-API-Key"},
 			AllowCredentials: false,
 			MaxAge:           300,
 		}),
@@ -70,6 +73,7 @@ func (h *HTTPHandler) setupRoutes() {
 	users := chi.NewRouter()
 
 	users.Method(http.MethodGet, "/{username}/distance", http.HandlerFunc(h.getDistance))
+	users.Method(http.MethodGet, "/{username}/track", http.HandlerFunc(h.getTrack))
 
 	h.router.Mount("/users", users)
 }
@@ -97,33 +101,120 @@ func (h *HTTPHandler) getDistance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fromPtr, toPtr, err := parsePeriod(dto.From, dto.To)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	var res port.HistoryServiceGetDistanceByUsernameResponse
+	res, err = h.service.GetDistanceByUsername(r.Context(), port.HistoryServiceGetDistanceByUsernameRequest{
+		Username: username,
+		From:     fromPtr,
+		To:       toPtr,
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+// parsePeriod returns bounds of the period in RFC 3339 format, empty bounds are nil.
+//
+// `ErrInvalidArgument` is returned in case any of the bounds is malformed.
+func parsePeriod(from, to string) (*time.Time, *time.Time, error) {
 	var fromPtr, toPtr *time.Time
 
-	if dto.From != "" {
-		from, err := time.Parse(time.RFC3339, dto.From)
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
 		if err != nil {
-			status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
-			util.Respond(w, status, body)
-			return
+			return nil, nil, fmt.Errorf("%w", errpack.ErrInvalidArgument)
 		}
-		fromPtr = &from
+		fromPtr = &t
 	}
 
-	if dto.To != "" {
-		to, err := time.Parse(time.RFC3339, dto.To)
+	if to != "" {
+		t, err := time.Parse(time.RFC3339, to)
 		if err != nil {
-			status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
-			util.Respond(w, status, body)
-			return
+			return nil, nil, fmt.Errorf("%w", errpack.ErrInvalidArgument)
 		}
-		toPtr = &to
+		toPtr = &t
 	}
 
-	var res port.HistoryServiceGetDistanceByUsernameResponse
-	res, err = h.service.GetDistanceByUsername(r.Context(), port.HistoryServiceGetDistanceByUsernameRequest{
+	return fromPtr, toPtr, nil
+}
+
+// trackFormatPolyline is the format of tracks encoded in the encoded polyline format.
+const trackFormatPolyline = "polyline"
+
+// getTrackDTO is a query of a track.
+//
+// `Format` is empty for tracks of points or `trackFormatPolyline`,
+// `Precision` of encoded polylines is 5 or 6 decimal places, 0 means 5.
+type getTrackDTO struct {
+	From      string `schema:"from"`
+	To        string `schema:"to"`
+	Limit     int    `schema:"limit"`
+	Format    string `schema:"format"`
+	Precision int    `schema:"precision"`
+}
+
+// trackDTO is a track of a user, every point is a location the user moved to at the timestamp.
+type trackDTO struct {
+	Username string              `json:"username"`
+	Points   []domain.TrackPoint `json:"points"`
+}
+
+// polylineTrackDTO is a track of a user encoded in the encoded polyline format
+// with timestamps of its points, the same way tracks are uploaded to the location service.
+type polylineTrackDTO struct {
+	Username   string      `json:"username"`
+	Polyline   string      `json:"polyline"`
+	Precision  int         `json:"precision"`
+	Timestamps []time.Time `json:"timestamps"`
+}
+
+// getTrack responds with the track of the user in given period.
+//
+// The track is encoded in the encoded polyline format if the format is `trackFormatPolyline`,
+// otherwise it is a GeoJSON LineString feature if the client accepts GeoJSON or a list of points.
+func (h *HTTPHandler) getTrack(w http.ResponseWriter, r *http.Request) {
+	username := chi.URLParam(r, "username")
+
+	var dto getTrackDTO
+	if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+		status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+		util.Respond(w, status, body)
+		return
+	}
+
+	precision := dto.Precision
+	if precision == 0 {
+		precision = geo.PolylinePrecision5
+	}
+	if (dto.Format != "" && dto.Format != trackFormatPolyline) ||
+		(precision != geo.PolylinePrecision5 && precision != geo.PolylinePrecision6) {
+		status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+		util.Respond(w, status, body)
+		return
+	}
+
+	from, to, err := parsePeriod(dto.From, dto.To)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.service.GetTrackByUsername(r.Context(), port.HistoryServiceGetTrackByUsernameRequest{
 		Username: username,
-		From:     fromPtr,
-		To:       toPtr,
+		From:     from,
+		To:       to,
+		Limit:    dto.Limit,
 	})
 	if err != nil {
 		status, body := errpack.ErrToHTTP(err)
@@ -131,5 +222,42 @@ func (h *HTTPHandler) getDistance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	util.Respond(w, http.StatusOK, res)
+	points := make([]geo.Point, 0, len(res.Points))
+	timestamps := make([]time.Time, 0, len(res.Points))
+	for _, point := range res.Points {
+		points = append(points, point.Point)
+		timestamps = append(timestamps, point.Timestamp)
+	}
+
+	if dto.Format == trackFormatPolyline {
+		polyline, err := geo.EncodePolyline(points, precision)
+		if err != nil {
+			status, body := errpack.ErrToHTTP(fmt.Errorf("%w: %v", errpack.ErrInternalError, err))
+			util.Respond(w, status, body)
+			return
+		}
+
+		util.Respond(w, http.StatusOK, polylineTrackDTO{
+			Username:   username,
+			Polyline:   polyline,
+			Precision:  precision,
+			Timestamps: timestamps,
+		})
+		return
+	}
+
+	w.Header().Add("Vary", "Accept")
+	if util.Accepts(r, geo.GeoJSONMediaType) {
+		w.Header().Set("Content-Type", geo.GeoJSONMediaType)
+		util.Respond(w, http.StatusOK, geo.NewFeature(nil, geo.NewLineStringGeometry(points), map[string]interface{}{
+			"username":   username,
+			"timestamps": timestamps,
+		}))
+		return
+	}
+
+	util.Respond(w, http.StatusOK, trackDTO{
+		Username: username,
+		Points:   res.Points,
+	})
 }
@@ -9,6 +9,7 @@ import (
   "net/http"
   "net/http/httptest"
   "testing"
+  "time"
 
   "gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
 
@@ -17,8 +18,10 @@ import (
   "github.com/stretchr/testify/require"
   "github.com/stretchr/testify/suite"
   "gitlab.com/spacewalker/geotracker/internal/app/history/adapter/in/handler"
+  "gitlab.com/spacewalker/geotracker/internal/app/history/core/domain"
   "gitlab.com/spacewalker/geotracker/internal/app/history/core/port"
   "gitlab.com/spacewalker/geotracker/internal/app/history/core/port/mock"
+  "gitlab.com/spacewalker/geotracker/internal/pkg/geo"
   mocklog "gitlab.com/spacewalker/geotracker/internal/pkg/log/mock"
 )
 
@@ -384,3 +387,229 @@ func (s *HistoryHTTPHandlerTestSuite) Test_GetDistance() {
     })
   }
 }
+
+func (s *HistoryHTTPHandlerTestSuite) Test_GetTrack() {
+  getUserTrackPath := "/users/{username}/track"
+  username := testutil.RandomUsername()
+  from := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+  to := from.Add(time.Hour)
+  points := []domain.TrackPoint{
+    {Point: geo.Point{-120.2, 38.5}, Timestamp: from},
+    {Point: geo.Point{-120.95, 40.7}, Timestamp: from.Add(time.Minute)},
+    {Point: geo.Point{-126.453, 43.252}, Timestamp: from.Add(2 * time.Minute)},
+  }
+  timestamps := []string{
+    "2022-01-01T00:00:00Z",
+    "2022-01-01T00:01:00Z",
+    "2022-01-01T00:02:00Z",
+  }
+  invalidArgument := map[string]interface{}{
+    "error": map[string]interface{}{
+      "code":    400,
+      "message": "invalid argument",
+      "status":  "INVALID_ARGUMENT",
+    },
+  }
+
+  testCases := []struct {
+    name                string
+    queryParams         map[string]interface{}
+    headers             map[string]string
+    buildStubs          func(service *mock.MockHistoryService)
+    expectedStatus      int
+    expectedContentType string
+    expectedResponse    interface{}
+  }{
+    {
+      name: "it responds with points of the track",
+      queryParams: map[string]interface{}{
+        "from":  from.Format(time.RFC3339),
+        "to":    to.Format(time.RFC3339),
+        "limit": 10,
+      },
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().
+          GetTrackByUsername(gomock.Any(), port.HistoryServiceGetTrackByUsernameRequest{
+            Username: username,
+            From:     &from,
+            To:       &to,
+            Limit:    10,
+          }).
+          Times(1).
+          Return(port.HistoryServiceGetTrackByUsernameResponse{Points: points}, nil)
+      },
+      expectedStatus:      http.StatusOK,
+      expectedContentType: "application/json",
+      expectedResponse: map[string]interface{}{
+        "username": username,
+        "points": []interface{}{
+          map[string]interface{}{"point": []float64{-120.2, 38.5}, "timestamp": timestamps[0]},
+          map[string]interface{}{"point": []float64{-120.95, 40.7}, "timestamp": timestamps[1]},
+          map[string]interface{}{"point": []float64{-126.453, 43.252}, "timestamp": timestamps[2]},
+        },
+      },
+    },
+    {
+      name:        "it responds with the track encoded in the encoded polyline format",
+      queryParams: map[string]interface{}{"format": "polyline"},
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().
+          GetTrackByUsername(gomock.Any(), port.HistoryServiceGetTrackByUsernameRequest{Username: username}).
+          Times(1).
+          Return(port.HistoryServiceGetTrackByUsernameResponse{Points: points}, nil)
+      },
+      expectedStatus:      http.StatusOK,
+      expectedContentType: "application/json",
+      expectedResponse: map[string]interface{}{
+        "username":   username,
+        "polyline":   "_p~iF~ps|U_ulLnnqC_mqNvxq`@",
+        "precision":  5,
+        "timestamps": timestamps,
+      },
+    },
+    {
+      name:        "it responds with the track encoded with precision 6",
+      queryParams: map[string]interface{}{"format": "polyline", "precision": 6},
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().
+          GetTrackByUsername(gomock.Any(), port.HistoryServiceGetTrackByUsernameRequest{Username: username}).
+          Times(1).
+          Return(port.HistoryServiceGetTrackByUsernameResponse{Points: points[:1]}, nil)
+      },
+      expectedStatus:      http.StatusOK,
+      expectedContentType: "application/json",
+      expectedResponse: map[string]interface{}{
+        "username":   username,
+        "polyline":   "_izlhA~rlgdF",
+        "precision":  6,
+        "timestamps": timestamps[:1],
+      },
+    },
+    {
+      name:    "it responds with a GeoJSON LineString feature if the client accepts GeoJSON",
+      headers: map[string]string{"Accept": "application/geo+json"},
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().
+          GetTrackByUsername(gomock.Any(), port.HistoryServiceGetTrackByUsernameRequest{Username: username}).
+          Times(1).
+          Return(port.HistoryServiceGetTrackByUsernameResponse{Points: points}, nil)
+      },
+      expectedStatus:      http.StatusOK,
+      expectedContentType: "application/geo+json",
+      expectedResponse: map[string]interface{}{
+        "type": "Feature",
+        "geometry": map[string]interface{}{
+          "type":        "LineString",
+          "coordinates": [][]float64{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}},
+        },
+        "properties": map[string]interface{}{
+          "username":   username,
+          "timestamps": timestamps,
+        },
+      },
+    },
+    {
+      name:    "it responds with an empty GeoJSON LineString feature if the track is empty",
+      headers: map[string]string{"Accept": "application/geo+json"},
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().
+          GetTrackByUsername(gomock.Any(), port.HistoryServiceGetTrackByUsernameRequest{Username: username}).
+          Times(1).
+          Return(port.HistoryServiceGetTrackByUsernameResponse{Points: []domain.TrackPoint{}}, nil)
+      },
+      expectedStatus:      http.StatusOK,
+      expectedContentType: "application/geo+json",
+      expectedResponse: map[string]interface{}{
+        "type":     "Feature",
+        "geometry": map[string]interface{}{"type": "LineString", "coordinates": []interface{}{}},
+        "properties": map[string]interface{}{
+          "username":   username,
+          "timestamps": []interface{}{},
+        },
+      },
+    },
+    {
+      name:        "it responds with BAD_REQUEST if the format is unsupported",
+      queryParams: map[string]interface{}{"format": "gpx"},
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().GetTrackByUsername(gomock.Any(), gomock.Any()).Times(0)
+      },
+      expectedStatus:      http.StatusBadRequest,
+      expectedContentType: "application/json",
+      expectedResponse:    invalidArgument,
+    },
+    {
+      name:        "it responds with BAD_REQUEST if the precision is unsupported",
+      queryParams: map[string]interface{}{"format": "polyline", "precision": 7},
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().GetTrackByUsername(gomock.Any(), gomock.Any()).Times(0)
+      },
+      expectedStatus:      http.StatusBadRequest,
+      expectedContentType: "application/json",
+      expectedResponse:    invalidArgument,
+    },
+    {
+      name:        "it responds with BAD_REQUEST if invalid `from` is provided",
+      queryParams: map[string]interface{}{"from": "invalid"},
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().GetTrackByUsername(gomock.Any(), gomock.Any()).Times(0)
+      },
+      expectedStatus:      http.StatusBadRequest,
+      expectedContentType: "application/json",
+      expectedResponse:    invalidArgument,
+    },
+    {
+      name:    "it responds with errors as plain JSON if the client accepts GeoJSON",
+      headers: map[string]string{"Accept": "application/geo+json"},
+      buildStubs: func(svc *mock.MockHistoryService) {
+        svc.EXPECT().
+          GetTrackByUsername(gomock.Any(), gomock.Any()).
+          Times(1).
+          Return(port.HistoryServiceGetTrackByUsernameResponse{}, fmt.Errorf("%w", errpack.ErrPermissionDenied))
+      },
+      expectedStatus:      http.StatusForbidden,
+      expectedContentType: "application/json",
+      expectedResponse: map[string]interface{}{
+        "error": map[string]interface{}{
+          "code":    403,
+          "message": errpack.ErrPermissionDenied.Error(),
+          "status":  "PERMISSION_DENIED",
+        },
+      },
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      svc := mock.NewMockHistoryService(ctrl)
+      logger := mocklog.NewMockLogger(ctrl)
+
+      logger.EXPECT().Info(gomock.Any(), gomock.Any()) // Ignore logging
+
+      tc.buildStubs(svc)
+
+      h := handler.NewHTTPHandler(svc, logger)
+
+      server := httptest.NewServer(h)
+      defer server.Close()
+
+      e := httpexpect.New(s.T(), server.URL)
+
+      req := e.GET(getUserTrackPath, username).WithHeaders(tc.headers)
+
+      for k, v := range tc.queryParams {
+        req = req.WithQuery(k, v)
+      }
+
+      res := req.Expect()
+
+      res.Header("Content-Type").Equal(tc.expectedContentType)
+      res.Status(tc.expectedStatus)
+      res.JSON(httpexpect.ContentOpts{MediaType: tc.expectedContentType}).Equal(tc.expectedResponse)
+    })
+  }
+}
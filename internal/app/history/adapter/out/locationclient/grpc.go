@@ -16,17 +16,21 @@ import (
 
 type GRPCClient struct {
 	addr   string
+	apiKey string
 	logger log.Logger
 }
 
 // NewGRPCClient TODO: add description
-func NewGRPCClient(addr string, logger log.Logger) *GRPCClient {
+//
+// apiKey authenticates requests of the client, requests are sent without credentials if it is empty.
+func NewGRPCClient(addr string, apiKey string, logger log.Logger) *GRPCClient {
 	if logger == nil {
 		log2.Panic("logger must not be nil")
 	}
 
 	return &GRPCClient{
 		addr:   addr,
+		apiKey: apiKey,
 		logger: logger,
 	}
 }
@@ -37,6 +41,7 @@ func (c *GRPCClient) GetUserIDByUsername(ctx context.Context, username string) (
 		grpc.WithInsecure(),
 		grpc.WithChainUnaryInterceptor(
 			middleware.TracingUnaryClientInterceptor(c.logger),
+			middleware.APIKeyUnaryClientInterceptor(c.apiKey),
 			middleware.LoggerUnaryClientInterceptor(c.logger),
 		),
 	}
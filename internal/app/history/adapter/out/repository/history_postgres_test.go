@@ -14,6 +14,15 @@ import (
 )
 
 func (s *PostgresTestSuite) Test_PostgresRepository_AddRecord() {
+	accuracy, altitude, speed, bearing := 4.0, -3.5, 0.0, 180.0
+	metadata := geo.FixMetadata{
+		Accuracy: &accuracy,
+		Altitude: &altitude,
+		Speed:    &speed,
+		Bearing:  &bearing,
+		Source:   geo.FixSourceGPS,
+	}
+
 	testCases := []struct {
 		name   string
 		req    port.HistoryRepositoryAddRecordRequest
@@ -37,6 +46,44 @@ func (s *PostgresTestSuite) Test_PostgresRepository_AddRecord() {
 				require.Equal(t, 1.0, rec.B.Latitude())
 			},
 		},
+		{
+			name: "OK_FixMetadata",
+			req: port.HistoryRepositoryAddRecordRequest{
+				UserID:      1,
+				A:           geo.Point{0, 0},
+				B:           geo.Point{1, 1},
+				FixMetadata: metadata,
+			},
+			assert: func(t *testing.T, rec domain.Record, err error) {
+				require.NoError(t, err)
+				require.Equal(t, metadata, rec.FixMetadata)
+			},
+		},
+		{
+			name: "OK_FixMetadataUnknown",
+			req: port.HistoryRepositoryAddRecordRequest{
+				UserID: 1,
+				A:      geo.Point{0, 0},
+				B:      geo.Point{1, 1},
+			},
+			assert: func(t *testing.T, rec domain.Record, err error) {
+				require.NoError(t, err)
+				require.Empty(t, rec.FixMetadata)
+			},
+		},
+		{
+			name: "InvalidFixMetadata",
+			req: port.HistoryRepositoryAddRecordRequest{
+				UserID:      1,
+				A:           geo.Point{0, 0},
+				B:           geo.Point{1, 1},
+				FixMetadata: geo.FixMetadata{Source: "satellite"},
+			},
+			assert: func(t *testing.T, rec domain.Record, err error) {
+				require.Empty(t, rec)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
 		{
 			name: "OK_PointsTruncatedToFixedPrecision",
 			req: port.HistoryRepositoryAddRecordRequest{
@@ -163,6 +210,37 @@ func (s *PostgresTestSuite) Test_PostgresRepository_AddRecord() {
 	}
 }
 
+func (s *PostgresTestSuite) Test_PostgresRepository_AddRecords() {
+	repo := repository.NewPostgresRepository(s.db)
+	timestamp := time.Now().UTC().Truncate(time.Microsecond)
+
+	records, err := repo.AddRecords(context.Background(), []port.HistoryRepositoryAddRecordRequest{
+		{UserID: 1, A: geo.Point{0, 0}, B: geo.Point{1, 1}, Timestamp: timestamp},
+		{UserID: 1, A: geo.Point{1, 1}, B: geo.Point{2, 2}, Timestamp: timestamp.Add(time.Second)},
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), records, 2)
+	require.Less(s.T(), records[0].ID, records[1].ID)
+	require.Equal(s.T(), geo.Point{1, 1}, records[1].A)
+	require.Equal(s.T(), geo.Point{2, 2}, records[1].B)
+
+	// Either all records are added or none.
+	records, err = repo.AddRecords(context.Background(), []port.HistoryRepositoryAddRecordRequest{
+		{UserID: 2, A: geo.Point{0, 0}, B: geo.Point{1, 1}, Timestamp: timestamp},
+		{UserID: 2, A: geo.Point{1, 1}, B: geo.Point{0, 90.1}, Timestamp: timestamp},
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+	require.Empty(s.T(), records)
+
+	distance, err := repo.GetDistance(context.Background(), port.HistoryRepositoryGetDistanceRequest{
+		UserID: 2,
+		From:   timestamp.Add(-time.Hour),
+		To:     timestamp.Add(time.Hour),
+	})
+	require.NoError(s.T(), err)
+	require.Zero(s.T(), distance)
+}
+
 func (s *PostgresTestSuite) Test_PostgresRepository_GetDistance() {
 	ref := time.Now()
 	records := []domain.Record{
@@ -259,3 +337,104 @@ func (s *PostgresTestSuite) Test_PostgresRepository_GetDistance() {
 		})
 	}
 }
+
+func (s *PostgresTestSuite) Test_PostgresRepository_GetTrack() {
+	ref := time.Now()
+	s.seedRecords([]domain.Record{
+		{UserID: 1, A: geo.Point{1, 0}, B: geo.Point{1, 1}, Timestamp: ref.Add(-4 * time.Hour)},
+		{UserID: 1, A: geo.Point{0, 0}, B: geo.Point{1, 0}, Timestamp: ref.Add(-5 * time.Hour)},
+		{UserID: 2, A: geo.Point{1, 1}, B: geo.Point{2, 1}, Timestamp: ref.Add(-3 * time.Hour)},
+		{UserID: 1, A: geo.Point{2, 1}, B: geo.Point{2, 2}, Timestamp: ref.Add(-2 * time.Hour)},
+	})
+
+	testCases := []struct {
+		name   string
+		req    port.HistoryRepositoryGetTrackRequest
+		assert func(t *testing.T, points []domain.TrackPoint, err error)
+	}{
+		{
+			name: "OK",
+			req: port.HistoryRepositoryGetTrackRequest{
+				UserID: 1,
+				From:   ref.Add(-10 * time.Hour),
+				To:     ref,
+				Limit:  10,
+			},
+			assert: func(t *testing.T, points []domain.TrackPoint, err error) {
+				require.NoError(t, err)
+				require.Len(t, points, 3)
+				require.Equal(t, geo.Point{1, 0}, points[0].Point)
+				require.WithinDuration(t, ref.Add(-5*time.Hour), points[0].Timestamp, time.Millisecond)
+				require.Equal(t, geo.Point{1, 1}, points[1].Point)
+				require.Equal(t, geo.Point{2, 2}, points[2].Point)
+			},
+		},
+		{
+			name: "OK_Limit",
+			req: port.HistoryRepositoryGetTrackRequest{
+				UserID: 1,
+				From:   ref.Add(-10 * time.Hour),
+				To:     ref,
+				Limit:  2,
+			},
+			assert: func(t *testing.T, points []domain.TrackPoint, err error) {
+				require.NoError(t, err)
+				require.Len(t, points, 2)
+				require.Equal(t, geo.Point{1, 0}, points[0].Point)
+				require.Equal(t, geo.Point{1, 1}, points[1].Point)
+			},
+		},
+		{
+			name: "OK_NoRecordsInTimeFrame",
+			req: port.HistoryRepositoryGetTrackRequest{
+				UserID: 1,
+				From:   ref.Add(-10 * time.Hour),
+				To:     ref.Add(-6 * time.Hour),
+				Limit:  10,
+			},
+			assert: func(t *testing.T, points []domain.TrackPoint, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, points)
+				require.Empty(t, points)
+			},
+		},
+	}
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			points, err := repo.GetTrack(context.Background(), tc.req)
+			tc.assert(t, points, err)
+		})
+	}
+}
+
+func (s *PostgresTestSuite) Test_PostgresRepository_DeleteUserRecords() {
+	ref := time.Now()
+	s.seedRecords([]domain.Record{
+		{UserID: 1, A: geo.Point{0, 0}, B: geo.Point{1, 0}, Timestamp: ref.Add(-2 * time.Hour)},
+		{UserID: 2, A: geo.Point{1, 0}, B: geo.Point{1, 1}, Timestamp: ref.Add(-time.Hour)},
+		{UserID: 1, A: geo.Point{1, 0}, B: geo.Point{1, 1}, Timestamp: ref.Add(-time.Hour)},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	deleted, err := repo.DeleteUserRecords(context.Background(), 1)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 2, deleted)
+
+	// Deleting the records again is not an error.
+	deleted, err = repo.DeleteUserRecords(context.Background(), 1)
+	require.NoError(s.T(), err)
+	require.Zero(s.T(), deleted)
+
+	distance, err := repo.GetDistance(context.Background(), port.HistoryRepositoryGetDistanceRequest{
+		UserID: 2,
+		From:   ref.Add(-10 * time.Hour),
+		To:     ref,
+	})
+	require.NoError(s.T(), err)
+	require.NotZero(s.T(), distance)
+}
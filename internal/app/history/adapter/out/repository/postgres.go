@@ -17,10 +17,11 @@ const (
 	// RecordsTable contains name of the records database table.
 	RecordsTable = "records"
 
-	constraintRecordsALongitudeValid = "records_a_longitude_valid"
-	constraintRecordsALatitudeValid  = "records_a_latitude_valid"
-	constraintRecordsBLongitudeValid = "records_b_longitude_valid"
-	constraintRecordsBLatitudeValid  = "records_b_latitude_valid"
+	constraintRecordsALongitudeValid  = "records_a_longitude_valid"
+	constraintRecordsALatitudeValid   = "records_a_latitude_valid"
+	constraintRecordsBLongitudeValid  = "records_b_longitude_valid"
+	constraintRecordsBLatitudeValid   = "records_b_latitude_valid"
+	constraintRecordsFixMetadataValid = "records_fix_metadata_valid"
 )
 
 type postgresRepository struct {
@@ -35,31 +36,47 @@ func NewPostgresRepository(db *sql.DB) port.HistoryRepository {
 var addRecordQuery = fmt.Sprintf(
 	`
 INSERT INTO %s
-(user_id, a, b, timestamp)
-VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, a, b, timestamp
+(user_id, a, b, timestamp, accuracy, altitude, speed, bearing, source)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, user_id, a, b, timestamp, accuracy, altitude, speed, bearing, source
 `,
 	RecordsTable,
 )
 
-// AddRecord adds a history record into records table.
-//
-// It returns added record and any error encountered.
-//
-// `ErrInvalidArgument` is returned in case any of provided geo points contains
-// invalid latitude or longitude.
-//
-// `ErrInternalError` is returned in case of any other error.
-func (r postgresRepository) AddRecord(ctx context.Context, req port.HistoryRepositoryAddRecordRequest) (domain.Record, error) {
+// queryRower is implemented by both *sql.DB and *sql.Tx.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// addRecord inserts a record using q and maps the error returned by the database.
+func addRecord(ctx context.Context, q queryRower, req port.HistoryRepositoryAddRecordRequest) (domain.Record, error) {
 	var record domain.Record
 	var a, b geo.PostgresPoint
+	var source sql.NullString
 
-	if err := r.db.QueryRowContext(ctx, addRecordQuery, req.UserID, geo.PostgresPoint(req.A), geo.PostgresPoint(req.B), req.Timestamp).Scan(
+	if err := q.QueryRowContext(
+		ctx,
+		addRecordQuery,
+		req.UserID,
+		geo.PostgresPoint(req.A),
+		geo.PostgresPoint(req.B),
+		req.Timestamp,
+		req.Accuracy,
+		req.Altitude,
+		req.Speed,
+		req.Bearing,
+		sql.NullString{String: string(req.Source), Valid: req.Source != ""},
+	).Scan(
 		&record.ID,
 		&record.UserID,
 		&a,
 		&b,
 		&record.Timestamp,
+		&record.Accuracy,
+		&record.Altitude,
+		&record.Speed,
+		&record.Bearing,
+		&source,
 	); err != nil {
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) {
@@ -71,6 +88,8 @@ func (r postgresRepository) AddRecord(ctx context.Context, req port.HistoryRepos
 			case constraintRecordsBLongitudeValid:
 				fallthrough
 			case constraintRecordsBLatitudeValid:
+				fallthrough
+			case constraintRecordsFixMetadataValid:
 				return domain.Record{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
 			}
 		}
@@ -79,10 +98,57 @@ func (r postgresRepository) AddRecord(ctx context.Context, req port.HistoryRepos
 
 	record.A = geo.Point(a)
 	record.B = geo.Point(b)
+	record.Source = geo.FixSource(source.String)
 
 	return record, nil
 }
 
+// AddRecord adds a history record into records table.
+//
+// It returns added record and any error encountered.
+//
+// `ErrInvalidArgument` is returned in case any of provided geo points contains
+// invalid latitude or longitude or fix metadata is invalid.
+//
+// `ErrInternalError` is returned in case of any other error.
+func (r postgresRepository) AddRecord(ctx context.Context, req port.HistoryRepositoryAddRecordRequest) (domain.Record, error) {
+	return addRecord(ctx, r.db, req)
+}
+
+// AddRecords adds history records into records table in the scope of the database transaction.
+//
+// It returns added records in order of `req` and any error encountered.
+// If any record fails to be added, none of them are added.
+//
+// `ErrInvalidArgument` is returned in case any of provided geo points contains
+// invalid latitude or longitude or fix metadata is invalid.
+//
+// `ErrInternalError` is returned in case of any other error.
+func (r postgresRepository) AddRecords(ctx context.Context, req []port.HistoryRepositoryAddRecordRequest) ([]domain.Record, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	records := make([]domain.Record, 0, len(req))
+	for _, arg := range req {
+		record, err := addRecord(ctx, tx, arg)
+		if err != nil {
+			if rbErr := tx.Rollback(); rbErr != nil {
+				return nil, fmt.Errorf("%w: %v: %v", errpack.ErrInternalError, err, rbErr)
+			}
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return records, nil
+}
+
 var getDistanceQuery = fmt.Sprintf(
 	`
 SELECT coalesce(SUM(a <@> b), 0.00) * 1609.344
@@ -111,3 +177,75 @@ func (r postgresRepository) GetDistance(ctx context.Context, req port.HistoryRep
 
 	return distance, nil
 }
+
+var getTrackQuery = fmt.Sprintf(
+	`
+SELECT b, timestamp
+FROM %s
+WHERE user_id = $1 AND timestamp >= $2 AND timestamp <= $3
+ORDER BY timestamp, id
+LIMIT $4
+`,
+	RecordsTable,
+)
+
+// GetTrack returns points a user with the provided ID moved to in a provided period of time,
+// ordered by time, at most `req.Limit` of them.
+//
+// It returns points of the track and any error occurred.
+//
+// If there is no user with provided ID, an empty track is returned.
+//
+// `ErrInternalError` is returned in case of any error.
+func (r postgresRepository) GetTrack(ctx context.Context, req port.HistoryRepositoryGetTrackRequest) ([]domain.TrackPoint, error) {
+	rows, err := r.db.QueryContext(ctx, getTrackQuery, req.UserID, req.From, req.To, req.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	points := make([]domain.TrackPoint, 0)
+	for rows.Next() {
+		var point domain.TrackPoint
+		var b geo.PostgresPoint
+		if err = rows.Scan(&b, &point.Timestamp); err != nil {
+			return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		point.Point = geo.Point(b)
+		points = append(points, point)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return points, nil
+}
+
+var deleteUserRecordsQuery = fmt.Sprintf(
+	`
+DELETE FROM %s
+WHERE user_id = $1
+`,
+	RecordsTable,
+)
+
+// DeleteUserRecords deletes all the records of a user with the provided ID.
+//
+// It returns the number of deleted records and any error occurred.
+//
+// If there is no records of the user, 0 is returned.
+//
+// `ErrInternalError` is returned in case of any error.
+func (r postgresRepository) DeleteUserRecords(ctx context.Context, userID int) (int, error) {
+	res, err := r.db.ExecContext(ctx, deleteUserRecordsQuery, userID)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	deleted, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return int(deleted), nil
+}
@@ -15,10 +15,12 @@ import (
 	"gitlab.com/spacewalker/geotracker/internal/app/history/adapter/out/locationclient"
 	"gitlab.com/spacewalker/geotracker/internal/app/history/adapter/out/repository"
 	"gitlab.com/spacewalker/geotracker/internal/app/history/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/config"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/middleware"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/ratelimit"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/retrier"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
 	pb "gitlab.com/spacewalker/geotracker/pkg/api/proto/v1/history"
@@ -81,13 +83,48 @@ func (a *App) Start() error {
 	})
 
 	repo := repository.NewPostgresRepository(db)
-	locationClient := locationclient.NewGRPCClient(a.config.LocationAddr, a.logger)
+	locationClient := locationclient.NewGRPCClient(a.config.LocationAddr, a.config.LocationAPIKey, a.logger)
 	proxifiedLocationClient := locationclient.NewProxy(locationClient, cb, re)
 	svc := service.NewHistoryService(repo, proxifiedLocationClient, a.logger)
 	httpHandler := handler.NewHTTPHandler(svc, a.logger)
 	grpcHandler := handler.NewGRPCHandler(svc)
 
 	rootHandler := chi.NewRouter()
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		middleware.TracingUnaryServerInterceptor(a.logger),
+		middleware.LoggerUnaryServerInterceptor(a.logger),
+	}
+
+	if a.config.MaxInFlightRequests > 0 {
+		concurrencyLimiter := ratelimit.NewConcurrencyLimiter(a.config.MaxInFlightRequests)
+		rootHandler.Use(middleware.ConcurrencyLimitMiddleware(concurrencyLimiter))
+		unaryInterceptors = append(unaryInterceptors, middleware.ConcurrencyLimitUnaryServerInterceptor(concurrencyLimiter))
+	}
+
+	if a.config.AuthEnabled {
+		authenticator, err := auth.New(a.config.Auth())
+		if err != nil {
+			return fmt.Errorf("failed to create authenticator: %v", err)
+		}
+
+		rootHandler.Use(middleware.AuthMiddleware(authenticator))
+		unaryInterceptors = append(unaryInterceptors, middleware.AuthUnaryServerInterceptor(authenticator))
+	}
+
+	if a.config.RateLimitEnabled {
+		limiter, err := ratelimit.New(a.config.RateLimit())
+		if err != nil {
+			return fmt.Errorf("failed to create rate limiter: %v", err)
+		}
+		ips, err := middleware.NewClientIPResolver(a.config.RateLimitTrustedProxies)
+		if err != nil {
+			return fmt.Errorf("failed to create client ip resolver: %v", err)
+		}
+
+		rootHandler.Use(middleware.RateLimitMiddleware(limiter, ips))
+		unaryInterceptors = append(unaryInterceptors, middleware.RateLimitUnaryServerInterceptor(limiter, ips))
+	}
+
 	rootHandler.Mount("/v1", httpHandler)
 
 	a.httpServer = util.NewHTTPServer(a.config.BindAddrHTTP, rootHandler)
@@ -96,10 +133,7 @@ func (a *App) Start() error {
 		func(server *grpc.Server) {
 			pb.RegisterHistoryServer(server, grpcHandler)
 		},
-		grpc.ChainUnaryInterceptor(
-			middleware.TracingUnaryServerInterceptor(a.logger),
-			middleware.LoggerUnaryServerInterceptor(a.logger),
-		),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
 	)
 
 	var httpErr, grpcErr error
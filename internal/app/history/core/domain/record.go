@@ -7,10 +7,13 @@ import (
 )
 
 // Record represents history record of users` movements.
+//
+// The embedded `FixMetadata` is optional metadata of the fix at `B`.
 type Record struct {
 	ID        int       `json:"id"`
 	UserID    int       `json:"user_id"`
 	A         geo.Point `json:"a"`
 	B         geo.Point `json:"b"`
 	Timestamp time.Time `json:"timestamp"`
+	geo.FixMetadata
 }
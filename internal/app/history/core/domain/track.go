@@ -0,0 +1,13 @@
+package domain
+
+import (
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// TrackPoint represents a point of a user's track, the location a user moved to at the timestamp.
+type TrackPoint struct {
+	Point     geo.Point `json:"point"`
+	Timestamp time.Time `json:"timestamp"`
+}
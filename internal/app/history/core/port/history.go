@@ -11,11 +11,19 @@ import (
 )
 
 // HistoryServiceAddRecordRequest represents request object of HistoryService AddRecord method.
+//
+// The embedded `FixMetadata` is optional metadata of the fix at `B`.
 type HistoryServiceAddRecordRequest struct {
   UserID    int       `json:"user_id" validate:"required,gt=0"`
   A         geo.Point `json:"a" validate:"validgeopoint"`
   B         geo.Point `json:"b" validate:"validgeopoint"`
   Timestamp time.Time `json:"timestamp"`
+  geo.FixMetadata
+}
+
+// HistoryServiceAddRecordsRequest represents request object of HistoryService AddRecords method.
+type HistoryServiceAddRecordsRequest struct {
+  Records []HistoryServiceAddRecordRequest `json:"records" validate:"min=1,max=1000,dive"`
 }
 
 // HistoryServiceGetDistanceRequest represents request object of HistoryService GetDistance method.
@@ -42,11 +50,32 @@ type HistoryServiceGetDistanceByUsernameResponse struct {
   Distance float64 `json:"distance"`
 }
 
+// HistoryServiceGetTrackByUsernameRequest represents request object of HistoryService GetTrackByUsername method.
+//
+// `Limit` is the maximum number of points of the track, 0 means `MaxTrackPoints`.
+type HistoryServiceGetTrackByUsernameRequest struct {
+  Username string     `json:"username" validate:"required"`
+  From     *time.Time `json:"from"`
+  To       *time.Time `json:"to"`
+  Limit    int        `json:"limit" validate:"min=0,max=10000"`
+}
+
+// HistoryServiceGetTrackByUsernameResponse represents response object of HistoryService GetTrackByUsername method.
+type HistoryServiceGetTrackByUsernameResponse struct {
+  Points []domain.TrackPoint `json:"points"`
+}
+
+// MaxTrackPoints is the maximum number of points of a track returned at once.
+const MaxTrackPoints = 10000
+
 // HistoryService represents history service.
 type HistoryService interface {
   AddRecord(ctx context.Context, req HistoryServiceAddRecordRequest) (domain.Record, error)
+  AddRecords(ctx context.Context, req HistoryServiceAddRecordsRequest) ([]domain.Record, error)
   GetDistanceByUsername(ctx context.Context, req HistoryServiceGetDistanceByUsernameRequest) (HistoryServiceGetDistanceByUsernameResponse, error)
   GetDistance(ctx context.Context, req HistoryServiceGetDistanceRequest) (HistoryServiceGetDistanceResponse, error)
+  GetTrackByUsername(ctx context.Context, req HistoryServiceGetTrackByUsernameRequest) (HistoryServiceGetTrackByUsernameResponse, error)
+  DeleteUserRecords(ctx context.Context, userID int) (int, error)
 }
 
 // HistoryRepositoryAddRecordRequest represents request object of HistoryRepository AddRecord method.
@@ -55,6 +84,7 @@ type HistoryRepositoryAddRecordRequest struct {
   A         geo.Point `json:"a"`
   B         geo.Point `json:"b"`
   Timestamp time.Time `json:"timestamp"`
+  geo.FixMetadata
 }
 
 // HistoryRepositoryGetDistanceRequest represents request object of HistoryRepository GetDistance method.
@@ -64,8 +94,19 @@ type HistoryRepositoryGetDistanceRequest struct {
   To     time.Time `json:"to"`
 }
 
+// HistoryRepositoryGetTrackRequest represents request object of HistoryRepository GetTrack method.
+type HistoryRepositoryGetTrackRequest struct {
+  UserID int       `json:"user_id"`
+  From   time.Time `json:"from"`
+  To     time.Time `json:"to"`
+  Limit  int       `json:"limit"`
+}
+
 // HistoryRepository represents history repository.
 type HistoryRepository interface {
   AddRecord(ctx context.Context, req HistoryRepositoryAddRecordRequest) (domain.Record, error)
+  AddRecords(ctx context.Context, req []HistoryRepositoryAddRecordRequest) ([]domain.Record, error)
   GetDistance(ctx context.Context, req HistoryRepositoryGetDistanceRequest) (float64, error)
+  GetTrack(ctx context.Context, req HistoryRepositoryGetTrackRequest) ([]domain.TrackPoint, error)
+  DeleteUserRecords(ctx context.Context, userID int) (int, error)
 }
@@ -8,6 +8,7 @@ import (
 
   "gitlab.com/spacewalker/geotracker/internal/app/history/core/domain"
   "gitlab.com/spacewalker/geotracker/internal/app/history/core/port"
+  "gitlab.com/spacewalker/geotracker/internal/pkg/auth"
   "gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
   "gitlab.com/spacewalker/geotracker/internal/pkg/geo"
   "gitlab.com/spacewalker/geotracker/internal/pkg/log"
@@ -49,6 +50,8 @@ func NewHistoryService(
 //
 // `ErrInvalidArgument` is returned in case of `req` validation failure.
 //
+// `ErrPermissionDenied` is returned in case the caller is neither an internal service nor an admin.
+//
 // If a call to `AddRecord` repository method fails, any returned error is propagated.
 func (s *historyService) AddRecord(ctx context.Context, req port.HistoryServiceAddRecordRequest) (domain.Record, error) {
   var err error
@@ -61,11 +64,16 @@ func (s *historyService) AddRecord(ctx context.Context, req port.HistoryServiceA
     return domain.Record{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
   }
 
+  if err = auth.AuthorizeRoles(ctx, auth.RoleService); err != nil {
+    return domain.Record{}, err
+  }
+
   record, err := s.repo.AddRecord(ctx, port.HistoryRepositoryAddRecordRequest{
-    UserID:    req.UserID,
-    A:         geo.Trunc(req.A),
-    B:         geo.Trunc(req.B),
-    Timestamp: req.Timestamp,
+    UserID:      req.UserID,
+    A:           geo.Trunc(req.A),
+    B:           geo.Trunc(req.B),
+    Timestamp:   req.Timestamp,
+    FixMetadata: req.FixMetadata,
   })
   if err != nil {
     return domain.Record{}, err
@@ -74,7 +82,51 @@ func (s *historyService) AddRecord(ctx context.Context, req port.HistoryServiceA
   return record, nil
 }
 
+// AddRecords adds history records at once.
+//
+// It returns added records in order of `req.Records` and any error occurred.
+//
+// `ErrInvalidArgument` is returned in case of `req` validation failure.
+//
+// `ErrPermissionDenied` is returned in case the caller is neither an internal service nor an admin.
+//
+// If a call to `AddRecords` repository method fails, any returned error is propagated.
+func (s *historyService) AddRecords(ctx context.Context, req port.HistoryServiceAddRecordsRequest) ([]domain.Record, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return nil, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if err = auth.AuthorizeRoles(ctx, auth.RoleService); err != nil {
+    return nil, err
+  }
+
+  args := make([]port.HistoryRepositoryAddRecordRequest, 0, len(req.Records))
+  for _, record := range req.Records {
+    args = append(args, port.HistoryRepositoryAddRecordRequest{
+      UserID:      record.UserID,
+      A:           geo.Trunc(record.A),
+      B:           geo.Trunc(record.B),
+      Timestamp:   record.Timestamp,
+      FixMetadata: record.FixMetadata,
+    })
+  }
+
+  records, err := s.repo.AddRecords(ctx, args)
+  if err != nil {
+    return nil, err
+  }
+
+  return records, nil
+}
+
 // GetDistance calculates distance that particular user got through in given time period.
+//
+// `ErrPermissionDenied` is returned in case the caller is neither an internal service nor an admin.
 func (s *historyService) GetDistance(ctx context.Context, req port.HistoryServiceGetDistanceRequest) (port.HistoryServiceGetDistanceResponse, error) {
   var err error
   defer func() {
@@ -85,6 +137,10 @@ func (s *historyService) GetDistance(ctx context.Context, req port.HistoryServic
     return port.HistoryServiceGetDistanceResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
   }
 
+  if err = auth.AuthorizeRoles(ctx, auth.RoleService); err != nil {
+    return port.HistoryServiceGetDistanceResponse{}, err
+  }
+
   distance, err := s.repo.GetDistance(ctx, port.HistoryRepositoryGetDistanceRequest(req))
   if err != nil {
     return port.HistoryServiceGetDistanceResponse{}, err
@@ -94,6 +150,8 @@ func (s *historyService) GetDistance(ctx context.Context, req port.HistoryServic
 }
 
 // GetDistanceByUsername calculates distance that particular user got through in given time period.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
 func (s *historyService) GetDistanceByUsername(ctx context.Context, req port.HistoryServiceGetDistanceByUsernameRequest) (port.HistoryServiceGetDistanceByUsernameResponse, error) {
   var err error
   defer func() {
@@ -103,20 +161,12 @@ func (s *historyService) GetDistanceByUsername(ctx context.Context, req port.His
   if err = validate.Struct(req); err != nil {
     return port.HistoryServiceGetDistanceByUsernameResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
   }
-  switch {
-  case req.To == nil && req.From == nil:
-    to := time.Now()
-    from := to.Add(-24 * time.Hour)
-    req.To = &to
-    req.From = &from
-  case req.To == nil:
-    to := req.From.Add(24 * time.Hour)
-    req.To = &to
-  case req.From == nil:
-    from := req.To.Add(-24 * time.Hour)
-    req.From = &from
+  if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+    return port.HistoryServiceGetDistanceByUsernameResponse{}, err
   }
 
+  from, to := period(req.From, req.To)
+
   userID, err := s.locationClient.GetUserIDByUsername(ctx, req.Username)
   if err != nil {
     return port.HistoryServiceGetDistanceByUsernameResponse{}, err
@@ -124,8 +174,8 @@ func (s *historyService) GetDistanceByUsername(ctx context.Context, req port.His
 
   distance, err := s.repo.GetDistance(ctx, port.HistoryRepositoryGetDistanceRequest{
     UserID: userID,
-    To:     *req.To,
-    From:   *req.From,
+    To:     to,
+    From:   from,
   })
   if err != nil {
     return port.HistoryServiceGetDistanceByUsernameResponse{}, err
@@ -135,3 +185,97 @@ func (s *historyService) GetDistanceByUsername(ctx context.Context, req port.His
     Distance: distance,
   }, nil
 }
+
+// GetTrackByUsername returns points particular user moved to in given time period, ordered by time.
+//
+// The period defaults the same way it does for `GetDistanceByUsername`.
+// At most `req.Limit` first points of the period are returned, `port.MaxTrackPoints` if it is 0.
+//
+// `ErrInvalidArgument` is returned in case of `req` validation failure.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+func (s *historyService) GetTrackByUsername(ctx context.Context, req port.HistoryServiceGetTrackByUsernameRequest) (port.HistoryServiceGetTrackByUsernameResponse, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return port.HistoryServiceGetTrackByUsernameResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+  if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+    return port.HistoryServiceGetTrackByUsernameResponse{}, err
+  }
+
+  from, to := period(req.From, req.To)
+  limit := req.Limit
+  if limit == 0 {
+    limit = port.MaxTrackPoints
+  }
+
+  userID, err := s.locationClient.GetUserIDByUsername(ctx, req.Username)
+  if err != nil {
+    return port.HistoryServiceGetTrackByUsernameResponse{}, err
+  }
+
+  points, err := s.repo.GetTrack(ctx, port.HistoryRepositoryGetTrackRequest{
+    UserID: userID,
+    From:   from,
+    To:     to,
+    Limit:  limit,
+  })
+  if err != nil {
+    return port.HistoryServiceGetTrackByUsernameResponse{}, err
+  }
+
+  return port.HistoryServiceGetTrackByUsernameResponse{
+    Points: points,
+  }, nil
+}
+
+// period returns bounds of the period from and to, missing bounds default to a 24-hour period.
+// The period ends now if both of them are missing.
+func period(from, to *time.Time) (time.Time, time.Time) {
+  switch {
+  case to == nil && from == nil:
+    end := time.Now()
+    return end.Add(-24 * time.Hour), end
+  case to == nil:
+    return *from, from.Add(24 * time.Hour)
+  case from == nil:
+    return to.Add(-24 * time.Hour), *to
+  }
+  return *from, *to
+}
+
+// DeleteUserRecords deletes all the records of the user with given ID.
+//
+// It returns the number of deleted records and any error occurred.
+// Deleting records of a user that has no records is not an error, so it is safe to retry.
+//
+// `ErrInvalidArgument` is returned in case `userID` is not positive.
+//
+// `ErrPermissionDenied` is returned in case the caller is neither an internal service nor an admin.
+//
+// If a call to `DeleteUserRecords` repository method fails, any returned error is propagated.
+func (s *historyService) DeleteUserRecords(ctx context.Context, userID int) (int, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, userID)
+  }()
+
+  if userID <= 0 {
+    return 0, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if err = auth.AuthorizeRoles(ctx, auth.RoleService); err != nil {
+    return 0, err
+  }
+
+  deleted, err := s.repo.DeleteUserRecords(ctx, userID)
+  if err != nil {
+    return 0, err
+  }
+
+  return deleted, nil
+}
@@ -25,4 +25,12 @@ func init() {
 	if err = validate.RegisterValidation("validgeopoint", validation.ValidateGeoPoint); err != nil {
 		log.Panicf("failed to register validation: %v", err)
 	}
+
+	if err = validate.RegisterValidation("validbearing", validation.ValidateBearing); err != nil {
+		log.Panicf("failed to register validation: %v", err)
+	}
+
+	if err = validate.RegisterValidation("validfixsource", validation.ValidateFixSource); err != nil {
+		log.Panicf("failed to register validation: %v", err)
+	}
 }
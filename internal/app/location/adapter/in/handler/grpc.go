@@ -2,24 +2,34 @@ package handler
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"time"
 
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
 	pb "gitlab.com/spacewalker/geotracker/pkg/api/proto/v1/location"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
-// GRPCHandler is a handler that serves request from another internal microservice.
+// GRPCHandler is a handler that serves public gRPC requests and requests from another internal microservice.
 type GRPCHandler struct {
-	service port.UserService
+	service       port.UserService
+	socialService port.SocialService
 
+	pb.UnimplementedLocationServer
 	pb.UnimplementedLocationInternalServer
 }
 
 // NewGRPCHandler creates new instance of GRPCHandler and returns its pointer.
-func NewGRPCHandler(service port.UserService) *GRPCHandler {
+func NewGRPCHandler(service port.UserService, socialService port.SocialService) *GRPCHandler {
 	return &GRPCHandler{
-		service: service,
+		service:       service,
+		socialService: socialService,
 	}
 }
 
@@ -30,10 +40,329 @@ func (h *GRPCHandler) GetUserByUsername(ctx context.Context, request *pb.GetUser
 		return nil, errpack.ErrToGRPC(err)
 	}
 
+	return userToPB(user), errpack.ErrToGRPC(nil)
+}
+
+// idempotencyKeyMetadataKey is a metadata key of an optional idempotency key of location updates.
+const idempotencyKeyMetadataKey = "idempotency-key"
+
+// SetUserLocation sets user's location by given username.
+// Retries sent with the same idempotency-key metadata get the original response.
+func (h *GRPCHandler) SetUserLocation(ctx context.Context, req *pb.SetUserLocationRequest) (*pb.SetUserLocationResponse, error) {
+	svcReq := port.UserServiceSetUserLocationRequest{
+		Username:    req.Username,
+		Latitude:    req.Latitude,
+		Longitude:   req.Longitude,
+		FixMetadata: fixMetadataFromPB(req.Metadata),
+	}
+	if req.RecordedAt != nil {
+		recordedAt := req.RecordedAt.AsTime()
+		svcReq.RecordedAt = &recordedAt
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(idempotencyKeyMetadataKey); len(values) > 0 {
+			svcReq.IdempotencyKey = values[0]
+		}
+	}
+
+	res, err := h.service.SetUserLocation(ctx, svcReq)
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return &pb.SetUserLocationResponse{
+		Longitude:  res.Longitude,
+		Latitude:   res.Latitude,
+		RecordedAt: timestamppb.New(res.RecordedAt),
+		Metadata:   fixMetadataToPB(res.FixMetadata),
+	}, errpack.ErrToGRPC(nil)
+}
+
+// SetUserLocations receives a batch of fixes and applies them when the client closes the stream.
+func (h *GRPCHandler) SetUserLocations(stream pb.Location_SetUserLocationsServer) error {
+	var locations []port.UserServiceSetUserLocationsItem
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(locations) == port.SetUserLocationsMaxBatchSize {
+			return errpack.ErrToGRPC(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		}
+
+		item := port.UserServiceSetUserLocationsItem{
+			Username:    req.Username,
+			Latitude:    req.Latitude,
+			Longitude:   req.Longitude,
+			FixMetadata: fixMetadataFromPB(req.Metadata),
+		}
+		if req.Timestamp != nil {
+			item.Timestamp = req.Timestamp.AsTime()
+		}
+		locations = append(locations, item)
+	}
+
+	res, err := h.service.SetUserLocations(stream.Context(), port.UserServiceSetUserLocationsRequest{
+		Locations: locations,
+	})
+	if err != nil {
+		return errpack.ErrToGRPC(err)
+	}
+
+	results := make([]*pb.SetUserLocationsResult, 0, len(res.Results))
+	for _, result := range res.Results {
+		st := status.Convert(errpack.ErrToGRPC(result.Err))
+		pbResult := &pb.SetUserLocationsResult{
+			Code:      int32(st.Code()),
+			Message:   st.Message(),
+			Longitude: result.Longitude,
+			Latitude:  result.Latitude,
+		}
+		if result.Err == nil {
+			pbResult.RecordedAt = timestamppb.New(result.RecordedAt)
+			pbResult.Metadata = fixMetadataToPB(result.FixMetadata)
+		}
+		results = append(results, pbResult)
+	}
+
+	return stream.SendAndClose(&pb.SetUserLocationsResponse{Results: results})
+}
+
+// ListUsersInRadius finds users by given location and radius.
+func (h *GRPCHandler) ListUsersInRadius(ctx context.Context, req *pb.ListUsersInRadiusRequest) (*pb.ListUsersInRadiusResponse, error) {
+	if len(req.Point) != 2 {
+		// Point must be provided as [longitude, latitude].
+		return nil, errpack.ErrToGRPC(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+	}
+
+	res, err := h.service.ListUsersInRadius(ctx, port.UserServiceListUsersInRadiusRequest{
+		Point:     geo.Point{req.Point[0], req.Point[1]},
+		Radius:    req.Radius,
+		PageToken: req.PageToken,
+		PageSize:  int(req.PageSize),
+		OrderBy:   port.UsersOrder(req.OrderBy),
+		MaxAge:    time.Duration(req.MaxAge) * time.Second,
+		Scope:     port.UsersScope(req.Scope),
+		Caller:    req.Caller,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return &pb.ListUsersInRadiusResponse{
+		Users:         nearbyUsersToPB(res.Users),
+		NextPageToken: res.NextPageToken,
+	}, errpack.ErrToGRPC(nil)
+}
+
+// ListNearestUsers finds users nearest to given location.
+func (h *GRPCHandler) ListNearestUsers(ctx context.Context, req *pb.ListNearestUsersRequest) (*pb.ListNearestUsersResponse, error) {
+	if len(req.Point) != 2 {
+		// Point must be provided as [longitude, latitude].
+		return nil, errpack.ErrToGRPC(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+	}
+
+	res, err := h.service.ListNearestUsers(ctx, port.UserServiceListNearestUsersRequest{
+		Point:           geo.Point{req.Point[0], req.Point[1]},
+		Limit:           int(req.Limit),
+		ExcludeUsername: req.ExcludeUsername,
+		MaxDistance:     req.MaxDistance,
+		MaxAge:          time.Duration(req.MaxAge) * time.Second,
+		Scope:           port.UsersScope(req.Scope),
+		Caller:          req.Caller,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return &pb.ListNearestUsersResponse{
+		Users: nearbyUsersToPB(res.Users),
+	}, errpack.ErrToGRPC(nil)
+}
+
+// WatchUsersInRadius streams users entering, moving inside and leaving given circle.
+func (h *GRPCHandler) WatchUsersInRadius(req *pb.WatchUsersInRadiusRequest, stream pb.Location_WatchUsersInRadiusServer) error {
+	if len(req.Point) != 2 {
+		// Point must be provided as [longitude, latitude].
+		return errpack.ErrToGRPC(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+	}
+
+	err := h.service.WatchUsersInRadius(stream.Context(), port.UserServiceWatchUsersInRadiusRequest{
+		Point:  geo.Point{req.Point[0], req.Point[1]},
+		Radius: req.Radius,
+		MaxAge: time.Duration(req.MaxAge) * time.Second,
+		Scope:  port.UsersScope(req.Scope),
+		Caller: req.Caller,
+	}, func(event domain.RadiusEvent) error {
+		return stream.Send(&pb.WatchUsersInRadiusResponse{
+			Type: string(event.Type),
+			User: nearbyUserToPB(event.User),
+		})
+	})
+	if _, ok := status.FromError(err); ok {
+		// The stream is already broken, so the error is returned as is.
+		return err
+	}
+
+	return errpack.ErrToGRPC(err)
+}
+
+// CreateUser creates a new user.
+func (h *GRPCHandler) CreateUser(ctx context.Context, req *pb.CreateUserRequest) (*pb.User, error) {
+	user, err := h.service.CreateUser(ctx, port.UserServiceCreateUserRequest{
+		Username: req.Username,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return userToPB(user), errpack.ErrToGRPC(nil)
+}
+
+// GetUser finds user by username along with its current location.
+func (h *GRPCHandler) GetUser(ctx context.Context, req *pb.GetUserRequest) (*pb.GetUserResponse, error) {
+	res, err := h.service.GetUser(ctx, req.Username)
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	result := &pb.GetUserResponse{
+		User: userToPB(res.User),
+	}
+	if res.Location != nil {
+		result.Location = &pb.UserLocation{
+			Point:      []float64{res.Location.Point.Longitude(), res.Location.Point.Latitude()},
+			RecordedAt: timestamppb.New(res.Location.RecordedAt),
+			Metadata:   fixMetadataToPB(res.Location.FixMetadata),
+			CreatedAt:  timestamppb.New(res.Location.CreatedAt),
+			UpdatedAt:  timestamppb.New(res.Location.UpdatedAt),
+		}
+	}
+	if res.LastSeenAt != nil {
+		result.LastSeenAt = timestamppb.New(*res.LastSeenAt)
+	}
+
+	return result, errpack.ErrToGRPC(nil)
+}
+
+// ListUsers lists users, optionally filtered by username prefix.
+func (h *GRPCHandler) ListUsers(ctx context.Context, req *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	res, err := h.service.ListUsers(ctx, port.UserServiceListUsersRequest{
+		Prefix:    req.Prefix,
+		PageToken: req.PageToken,
+		PageSize:  int(req.PageSize),
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	users := make([]*pb.User, 0, len(res.Users))
+	for _, user := range res.Users {
+		users = append(users, userToPB(user))
+	}
+
+	return &pb.ListUsersResponse{
+		Users:         users,
+		NextPageToken: res.NextPageToken,
+	}, errpack.ErrToGRPC(nil)
+}
+
+// RenameUser changes username of the user.
+func (h *GRPCHandler) RenameUser(ctx context.Context, req *pb.RenameUserRequest) (*pb.User, error) {
+	user, err := h.service.RenameUser(ctx, port.UserServiceRenameUserRequest{
+		Username:    req.Username,
+		NewUsername: req.NewUsername,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return userToPB(user), errpack.ErrToGRPC(nil)
+}
+
+// DeleteUser deletes user by username.
+// It returns an erasure job tracking erasure of the user's data from other services.
+func (h *GRPCHandler) DeleteUser(ctx context.Context, req *pb.DeleteUserRequest) (*pb.DeleteUserResponse, error) {
+	job, err := h.service.DeleteUser(ctx, req.Username)
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return &pb.DeleteUserResponse{Job: erasureJobToPB(job)}, errpack.ErrToGRPC(nil)
+}
+
+func erasureJobToPB(job domain.ErasureJob) *pb.ErasureJob {
+	res := &pb.ErasureJob{
+		Id:        int32(job.ID),
+		UserId:    int32(job.UserID),
+		Username:  job.Username,
+		Status:    string(job.Status),
+		Attempts:  int32(job.Attempts),
+		LastError: job.LastError,
+		CreatedAt: timestamppb.New(job.CreatedAt),
+		UpdatedAt: timestamppb.New(job.UpdatedAt),
+	}
+	if job.CompletedAt != nil {
+		res.CompletedAt = timestamppb.New(*job.CompletedAt)
+	}
+
+	return res
+}
+
+// fixMetadataFromPB converts protobuf fix metadata, nil means unknown metadata.
+func fixMetadataFromPB(m *pb.FixMetadata) geo.FixMetadata {
+	if m == nil {
+		return geo.FixMetadata{}
+	}
+
+	return geo.FixMetadata{
+		Accuracy: m.Accuracy,
+		Altitude: m.Altitude,
+		Speed:    m.Speed,
+		Bearing:  m.Bearing,
+		Source:   geo.FixSource(m.Source),
+	}
+}
+
+func fixMetadataToPB(m geo.FixMetadata) *pb.FixMetadata {
+	return &pb.FixMetadata{
+		Accuracy: m.Accuracy,
+		Altitude: m.Altitude,
+		Speed:    m.Speed,
+		Bearing:  m.Bearing,
+		Source:   string(m.Source),
+	}
+}
+
+func nearbyUsersToPB(users []domain.NearbyUser) []*pb.NearbyUser {
+	result := make([]*pb.NearbyUser, 0, len(users))
+	for _, user := range users {
+		result = append(result, nearbyUserToPB(user))
+	}
+	return result
+}
+
+func nearbyUserToPB(user domain.NearbyUser) *pb.NearbyUser {
+	return &pb.NearbyUser{
+		Id:                int32(user.ID),
+		Username:          user.Username,
+		CreatedAt:         timestamppb.New(user.CreatedAt),
+		UpdatedAt:         timestamppb.New(user.UpdatedAt),
+		Point:             []float64{user.Point.Longitude(), user.Point.Latitude()},
+		Distance:          user.Distance,
+		LocationUpdatedAt: timestamppb.New(user.LocationUpdatedAt),
+	}
+}
+
+func userToPB(user domain.User) *pb.User {
 	return &pb.User{
 		Id:        int32(user.ID),
 		Username:  user.Username,
 		CreatedAt: timestamppb.New(user.CreatedAt),
 		UpdatedAt: timestamppb.New(user.UpdatedAt),
-	}, errpack.ErrToGRPC(nil)
+	}
 }
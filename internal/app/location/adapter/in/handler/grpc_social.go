@@ -0,0 +1,153 @@
+package handler
+
+import (
+	"context"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	pb "gitlab.com/spacewalker/geotracker/pkg/api/proto/v1/location"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// FollowUser creates a pending follow request of the user to the followee.
+func (h *GRPCHandler) FollowUser(ctx context.Context, req *pb.FollowUserRequest) (*pb.Follow, error) {
+	follow, err := h.socialService.FollowUser(ctx, port.SocialServiceFollowUserRequest{
+		Username: req.Username,
+		Followee: req.Followee,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return followToPB(follow), errpack.ErrToGRPC(nil)
+}
+
+// AcceptFollower accepts the follow request of the follower to the user.
+func (h *GRPCHandler) AcceptFollower(ctx context.Context, req *pb.AcceptFollowerRequest) (*pb.Follow, error) {
+	follow, err := h.socialService.AcceptFollower(ctx, port.SocialServiceAcceptFollowerRequest{
+		Username: req.Username,
+		Follower: req.Follower,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return followToPB(follow), errpack.ErrToGRPC(nil)
+}
+
+// DeleteFollow deletes a follow request whether it is accepted or not.
+func (h *GRPCHandler) DeleteFollow(ctx context.Context, req *pb.DeleteFollowRequest) (*pb.DeleteFollowResponse, error) {
+	err := h.socialService.DeleteFollow(ctx, port.SocialServiceDeleteFollowRequest{
+		Follower: req.Follower,
+		Followee: req.Followee,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return &pb.DeleteFollowResponse{}, errpack.ErrToGRPC(nil)
+}
+
+// ListFollowing lists follow requests of the user.
+func (h *GRPCHandler) ListFollowing(ctx context.Context, req *pb.ListFollowsRequest) (*pb.ListFollowsResponse, error) {
+	return listFollows(ctx, req, h.socialService.ListFollowing)
+}
+
+// ListFollowers lists follow requests to the user.
+func (h *GRPCHandler) ListFollowers(ctx context.Context, req *pb.ListFollowsRequest) (*pb.ListFollowsResponse, error) {
+	return listFollows(ctx, req, h.socialService.ListFollowers)
+}
+
+// listFollows lists follows with given service method.
+func listFollows(
+	ctx context.Context,
+	req *pb.ListFollowsRequest,
+	list func(ctx context.Context, req port.SocialServiceListFollowsRequest) (port.SocialServiceListFollowsResponse, error),
+) (*pb.ListFollowsResponse, error) {
+	res, err := list(ctx, port.SocialServiceListFollowsRequest{
+		Username:  req.Username,
+		Status:    domain.FollowStatus(req.Status),
+		PageToken: req.PageToken,
+		PageSize:  int(req.PageSize),
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	follows := make([]*pb.Follow, 0, len(res.Follows))
+	for _, follow := range res.Follows {
+		follows = append(follows, followToPB(follow))
+	}
+
+	return &pb.ListFollowsResponse{
+		Follows:       follows,
+		NextPageToken: res.NextPageToken,
+	}, errpack.ErrToGRPC(nil)
+}
+
+// BlockUser blocks the user with `blocked` by the user.
+func (h *GRPCHandler) BlockUser(ctx context.Context, req *pb.BlockUserRequest) (*pb.Block, error) {
+	block, err := h.socialService.BlockUser(ctx, port.SocialServiceBlockUserRequest{
+		Username: req.Username,
+		Blocked:  req.Blocked,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return blockToPB(block), errpack.ErrToGRPC(nil)
+}
+
+// UnblockUser deletes the block of the user with `blocked` by the user.
+func (h *GRPCHandler) UnblockUser(ctx context.Context, req *pb.BlockUserRequest) (*pb.UnblockUserResponse, error) {
+	err := h.socialService.UnblockUser(ctx, port.SocialServiceBlockUserRequest{
+		Username: req.Username,
+		Blocked:  req.Blocked,
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	return &pb.UnblockUserResponse{}, errpack.ErrToGRPC(nil)
+}
+
+// ListBlocks lists blocks by the user.
+func (h *GRPCHandler) ListBlocks(ctx context.Context, req *pb.ListBlocksRequest) (*pb.ListBlocksResponse, error) {
+	res, err := h.socialService.ListBlocks(ctx, port.SocialServiceListBlocksRequest{
+		Username:  req.Username,
+		PageToken: req.PageToken,
+		PageSize:  int(req.PageSize),
+	})
+	if err != nil {
+		return nil, errpack.ErrToGRPC(err)
+	}
+
+	blocks := make([]*pb.Block, 0, len(res.Blocks))
+	for _, block := range res.Blocks {
+		blocks = append(blocks, blockToPB(block))
+	}
+
+	return &pb.ListBlocksResponse{
+		Blocks:        blocks,
+		NextPageToken: res.NextPageToken,
+	}, errpack.ErrToGRPC(nil)
+}
+
+func followToPB(follow domain.Follow) *pb.Follow {
+	return &pb.Follow{
+		Follower:  follow.Follower,
+		Followee:  follow.Followee,
+		Status:    string(follow.Status),
+		CreatedAt: timestamppb.New(follow.CreatedAt),
+		UpdatedAt: timestamppb.New(follow.UpdatedAt),
+	}
+}
+
+func blockToPB(block domain.Block) *pb.Block {
+	return &pb.Block{
+		Blocker:   block.Blocker,
+		Blocked:   block.Blocked,
+		CreatedAt: timestamppb.New(block.CreatedAt),
+	}
+}
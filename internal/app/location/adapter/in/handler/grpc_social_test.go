@@ -0,0 +1,121 @@
+package handler_test
+
+import (
+	"context"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+	pb "gitlab.com/spacewalker/geotracker/pkg/api/proto/v1/location"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *GRPCHandlerTestSuite) TestSocialGraph() {
+	follow := domain.Follow{FollowerID: 1, Follower: "user1", FolloweeID: 2, Followee: "user2", Status: domain.FollowAccepted}
+
+	testCases := []struct {
+		name            string
+		buildStubs      func(repo *mock.MockSocialRepository)
+		call            func(client pb.LocationClient) (interface{}, error)
+		assert          func(res interface{})
+		expectedErrCode codes.Code
+	}{
+		{
+			name: "FollowUser_Blocked",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					CreateFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+					Times(1).
+					Return(domain.Follow{}, errpack.ErrFailedPrecondition)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.FollowUser(context.Background(), &pb.FollowUserRequest{Username: "user1", Followee: "user2"})
+			},
+			expectedErrCode: codes.FailedPrecondition,
+		},
+		{
+			name: "AcceptFollower_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					AcceptFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+					Times(1).
+					Return(follow, nil)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.AcceptFollower(context.Background(), &pb.AcceptFollowerRequest{Username: "user2", Follower: "user1"})
+			},
+			assert: func(res interface{}) {
+				require.Equal(s.T(), "accepted", res.(*pb.Follow).Status)
+				require.Equal(s.T(), "user1", res.(*pb.Follow).Follower)
+			},
+			expectedErrCode: codes.OK,
+		},
+		{
+			name: "ListFollowing_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					ListFollowing(gomock.Any(), gomock.Eq(port.SocialRepositoryListFollowsRequest{Username: "user1", PageSize: 10})).
+					Times(1).
+					Return(port.SocialRepositoryListFollowsResponse{Follows: []domain.Follow{follow}}, nil)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.ListFollowing(context.Background(), &pb.ListFollowsRequest{Username: "user1", PageSize: 10})
+			},
+			assert: func(res interface{}) {
+				follows := res.(*pb.ListFollowsResponse).Follows
+				require.Len(s.T(), follows, 1)
+				require.Equal(s.T(), "user2", follows[0].Followee)
+			},
+			expectedErrCode: codes.OK,
+		},
+		{
+			name: "BlockUser_Self",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateBlock(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.BlockUser(context.Background(), &pb.BlockUserRequest{Username: "user1", Blocked: "user1"})
+			},
+			expectedErrCode: codes.InvalidArgument,
+		},
+		{
+			name: "UnblockUser_NotFound",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().DeleteBlock(gomock.Any(), gomock.Any()).Times(1).Return(errpack.ErrNotFound)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.UnblockUser(context.Background(), &pb.BlockUserRequest{Username: "user1", Blocked: "user2"})
+			},
+			expectedErrCode: codes.NotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.Run(tc.name, func() {
+			ctrl := gomock.NewController(s.T())
+			defer ctrl.Finish()
+
+			repo := mock.NewMockSocialRepository(ctrl)
+			tc.buildStubs(repo)
+
+			client, closeFn := s.startLocationServer(mock.NewMockUserService(ctrl), service.NewSocialService(repo, log.NewTestingLogger()))
+			defer closeFn()
+
+			res, err := tc.call(client)
+			if tc.expectedErrCode == codes.OK {
+				require.NoError(s.T(), err)
+				tc.assert(res)
+				return
+			}
+
+			require.Equal(s.T(), tc.expectedErrCode, status.Code(err))
+		})
+	}
+}
@@ -6,17 +6,24 @@ import (
   "github.com/stretchr/testify/require"
   "github.com/stretchr/testify/suite"
   "gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+  "gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+  "gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
   "gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+  "gitlab.com/spacewalker/geotracker/internal/pkg/geo"
   "gitlab.com/spacewalker/geotracker/internal/pkg/log"
+  "gitlab.com/spacewalker/geotracker/internal/pkg/util/pagination"
   "gitlab.com/spacewalker/geotracker/internal/pkg/util/testutil"
   pb "gitlab.com/spacewalker/geotracker/pkg/api/proto/v1/location"
   "google.golang.org/grpc"
   "google.golang.org/grpc/codes"
+  "google.golang.org/grpc/metadata"
   "google.golang.org/grpc/status"
   "google.golang.org/grpc/test/bufconn"
+  "google.golang.org/protobuf/proto"
+  "google.golang.org/protobuf/types/known/timestamppb"
   "net"
   "testing"
   "time"
@@ -88,11 +95,11 @@ func (s *GRPCHandlerTestSuite) TestGetUserByUsername() {
       hc := mock.NewMockHistoryClient(ctrl)
       l := log.NewTestingLogger()
 
-      svc := service.NewUserService(repo, hc, l)
+      svc := service.NewUserService(repo, hc, mock.NewMockGeofenceService(ctrl), broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, l)
 
       listener := bufconn.Listen(1024 * 1024)
       server := grpc.NewServer()
-      pb.RegisterLocationInternalServer(server, handler.NewGRPCHandler(svc))
+      pb.RegisterLocationInternalServer(server, handler.NewGRPCHandler(svc, mock.NewMockSocialService(ctrl)))
 
       go func() {
         if err := server.Serve(listener); err != nil {
@@ -131,6 +138,631 @@ func (s *GRPCHandlerTestSuite) TestGetUserByUsername() {
   }
 }
 
+func (s *GRPCHandlerTestSuite) TestSetUserLocation() {
+  username := testutil.RandomUsername()
+  point := geo.Trunc(geo.Point{testutil.RandomLongitude(), testutil.RandomLatitude()})
+  recordedAt := time.Now().UTC().Add(-time.Hour)
+
+  testCases := []struct {
+    name            string
+    buildStubs      func(repo *mock.MockUserRepository, hc *mock.MockHistoryClient)
+    req             *pb.SetUserLocationRequest
+    expectedRes     *pb.SetUserLocationResponse
+    expectedErrCode codes.Code
+  }{
+    {
+      name: "OK",
+      buildStubs: func(repo *mock.MockUserRepository, hc *mock.MockHistoryClient) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+            Username: username,
+            Point:    point,
+          })).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{
+            User:     domain.User{ID: 1, Username: username},
+            Location: domain.Location{UserID: 1, Point: point, RecordedAt: recordedAt},
+          }, nil)
+        hc.EXPECT().AddRecord(gomock.Any(), gomock.Any()).Times(0)
+      },
+      req: &pb.SetUserLocationRequest{
+        Username:  username,
+        Longitude: point.Longitude(),
+        Latitude:  point.Latitude(),
+      },
+      expectedRes: &pb.SetUserLocationResponse{
+        Longitude:  point.Longitude(),
+        Latitude:   point.Latitude(),
+        RecordedAt: timestamppb.New(recordedAt),
+      },
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "OK with recorded_at",
+      buildStubs: func(repo *mock.MockUserRepository, hc *mock.MockHistoryClient) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), gomock.Eq(port.UserRepositorySetUserLocationRequest{
+            Username:   username,
+            Point:      point,
+            RecordedAt: recordedAt,
+          })).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{
+            User:     domain.User{ID: 1, Username: username},
+            Location: domain.Location{UserID: 1, Point: point, RecordedAt: recordedAt},
+          }, nil)
+      },
+      req: &pb.SetUserLocationRequest{
+        Username:   username,
+        Longitude:  point.Longitude(),
+        Latitude:   point.Latitude(),
+        RecordedAt: timestamppb.New(recordedAt),
+      },
+      expectedRes: &pb.SetUserLocationResponse{
+        Longitude:  point.Longitude(),
+        Latitude:   point.Latitude(),
+        RecordedAt: timestamppb.New(recordedAt),
+      },
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "OK with fix metadata",
+      buildStubs: func(repo *mock.MockUserRepository, hc *mock.MockHistoryClient) {
+        speed := 1.5
+        metadata := geo.FixMetadata{Speed: &speed, Source: geo.FixSourceManual}
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+            Username:    username,
+            Point:       point,
+            FixMetadata: metadata,
+          })).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{
+            User:     domain.User{ID: 1, Username: username},
+            Location: domain.Location{UserID: 1, Point: point, RecordedAt: recordedAt, FixMetadata: metadata},
+          }, nil)
+      },
+      req: &pb.SetUserLocationRequest{
+        Username:  username,
+        Longitude: point.Longitude(),
+        Latitude:  point.Latitude(),
+        Metadata:  &pb.FixMetadata{Speed: proto.Float64(1.5), Source: "manual"},
+      },
+      expectedRes: &pb.SetUserLocationResponse{
+        Longitude:  point.Longitude(),
+        Latitude:   point.Latitude(),
+        RecordedAt: timestamppb.New(recordedAt),
+        Metadata:   &pb.FixMetadata{Speed: proto.Float64(1.5), Source: "manual"},
+      },
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "invalid fix metadata",
+      buildStubs: func(repo *mock.MockUserRepository, hc *mock.MockHistoryClient) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.SetUserLocationRequest{
+        Username:  username,
+        Longitude: point.Longitude(),
+        Latitude:  point.Latitude(),
+        Metadata:  &pb.FixMetadata{Accuracy: proto.Float64(-1)},
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "out of order",
+      buildStubs: func(repo *mock.MockUserRepository, hc *mock.MockHistoryClient) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), gomock.Any()).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{
+            User:       domain.User{ID: 1, Username: username},
+            Location:   domain.Location{UserID: 1, Point: point, RecordedAt: time.Now().UTC()},
+            OutOfOrder: true,
+          }, nil)
+        hc.EXPECT().AddRecord(gomock.Any(), gomock.Any()).Times(0)
+      },
+      req: &pb.SetUserLocationRequest{
+        Username:   username,
+        Longitude:  point.Longitude(),
+        Latitude:   point.Latitude(),
+        RecordedAt: timestamppb.New(recordedAt),
+      },
+      expectedErrCode: codes.FailedPrecondition,
+    },
+    {
+      name: "invalid argument",
+      buildStubs: func(repo *mock.MockUserRepository, hc *mock.MockHistoryClient) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.SetUserLocationRequest{
+        Username:  username,
+        Longitude: 180.1,
+        Latitude:  point.Latitude(),
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "internal error",
+      buildStubs: func(repo *mock.MockUserRepository, hc *mock.MockHistoryClient) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), gomock.Any()).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{}, errpack.ErrInternalError)
+      },
+      req: &pb.SetUserLocationRequest{
+        Username:  username,
+        Longitude: point.Longitude(),
+        Latitude:  point.Latitude(),
+      },
+      expectedErrCode: codes.Internal,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      repo := mock.NewMockUserRepository(ctrl)
+      hc := mock.NewMockHistoryClient(ctrl)
+      tc.buildStubs(repo, hc)
+
+      gs := mock.NewMockGeofenceService(ctrl)
+      gs.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+      svc := service.NewUserService(repo, hc, gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, log.NewTestingLogger())
+
+      client, closeFn := s.startLocationServer(svc, mock.NewMockSocialService(ctrl))
+      defer closeFn()
+
+      response, err := client.SetUserLocation(context.Background(), tc.req)
+      if tc.expectedErrCode == codes.OK {
+        require.NoError(s.T(), err)
+        require.Equal(s.T(), tc.expectedRes.Longitude, response.Longitude)
+        require.Equal(s.T(), tc.expectedRes.Latitude, response.Latitude)
+        require.True(s.T(), tc.expectedRes.RecordedAt.AsTime().Equal(response.RecordedAt.AsTime()))
+        if tc.expectedRes.Metadata != nil {
+          require.True(s.T(), proto.Equal(tc.expectedRes.Metadata, response.Metadata))
+        }
+      }
+
+      st, ok := status.FromError(err)
+      require.True(s.T(), ok)
+      require.Equal(s.T(), tc.expectedErrCode, st.Code())
+    })
+  }
+}
+
+func (s *GRPCHandlerTestSuite) TestListUsersInRadius() {
+  user := domain.User{
+    ID:        testutil.RandomInt(1, 100),
+    Username:  testutil.RandomUsername(),
+    CreatedAt: time.Now(),
+    UpdatedAt: time.Now(),
+  }
+
+  testCases := []struct {
+    name            string
+    buildStubs      func(repo *mock.MockUserRepository)
+    req             *pb.ListUsersInRadiusRequest
+    expectedRes     *pb.ListUsersInRadiusResponse
+    expectedErrCode codes.Code
+  }{
+    {
+      name: "OK",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListUsersInRadius(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInRadiusRequest{
+            Point:    geo.Point{10, 20},
+            Radius:   100,
+            PageSize: 10,
+          })).
+          Times(1).
+          Return(port.UserRepositoryListUsersInRadiusResponse{
+            Users: []domain.NearbyUser{
+              {
+                User:              user,
+                Point:             geo.Point{10.5, 20.5},
+                Distance:          42,
+                LocationUpdatedAt: time.Now(),
+              },
+            },
+            NextPageToken: user.ID,
+          }, nil)
+      },
+      req: &pb.ListUsersInRadiusRequest{
+        Point:    []float64{10, 20},
+        Radius:   100,
+        PageSize: 10,
+      },
+      expectedRes: &pb.ListUsersInRadiusResponse{
+        Users: []*pb.NearbyUser{
+          {
+            Id:       int32(user.ID),
+            Username: user.Username,
+            Point:    []float64{10.5, 20.5},
+            Distance: 42,
+          },
+        },
+        NextPageToken: pagination.EncodeCursor(user.ID, 10),
+      },
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "invalid point",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListUsersInRadius(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.ListUsersInRadiusRequest{
+        Point:    []float64{10},
+        Radius:   100,
+        PageSize: 10,
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "invalid radius",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListUsersInRadius(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.ListUsersInRadiusRequest{
+        Point:    []float64{10, 20},
+        Radius:   -1,
+        PageSize: 10,
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "internal error",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListUsersInRadius(gomock.Any(), gomock.Any()).
+          Times(1).
+          Return(port.UserRepositoryListUsersInRadiusResponse{}, errpack.ErrInternalError)
+      },
+      req: &pb.ListUsersInRadiusRequest{
+        Point:    []float64{10, 20},
+        Radius:   100,
+        PageSize: 10,
+      },
+      expectedErrCode: codes.Internal,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      repo := mock.NewMockUserRepository(ctrl)
+      tc.buildStubs(repo)
+
+      svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, log.NewTestingLogger())
+
+      client, closeFn := s.startLocationServer(svc, mock.NewMockSocialService(ctrl))
+      defer closeFn()
+
+      response, err := client.ListUsersInRadius(context.Background(), tc.req)
+      if tc.expectedErrCode == codes.OK {
+        require.NoError(s.T(), err)
+        require.Len(s.T(), response.Users, len(tc.expectedRes.Users))
+        for i, u := range tc.expectedRes.Users {
+          require.Equal(s.T(), u.Id, response.Users[i].Id)
+          require.Equal(s.T(), u.Username, response.Users[i].Username)
+          require.Equal(s.T(), u.Point, response.Users[i].Point)
+          require.Equal(s.T(), u.Distance, response.Users[i].Distance)
+        }
+        require.Equal(s.T(), tc.expectedRes.NextPageToken, response.NextPageToken)
+      }
+
+      st, ok := status.FromError(err)
+      require.True(s.T(), ok)
+      require.Equal(s.T(), tc.expectedErrCode, st.Code())
+    })
+  }
+}
+
+func (s *GRPCHandlerTestSuite) TestListNearestUsers() {
+  user := domain.User{
+    ID:        testutil.RandomInt(1, 100),
+    Username:  testutil.RandomUsername(),
+    CreatedAt: time.Now(),
+    UpdatedAt: time.Now(),
+  }
+
+  testCases := []struct {
+    name            string
+    buildStubs      func(repo *mock.MockUserRepository)
+    req             *pb.ListNearestUsersRequest
+    expectedRes     *pb.ListNearestUsersResponse
+    expectedErrCode codes.Code
+  }{
+    {
+      name: "OK",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListNearestUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListNearestUsersRequest{
+            Point:           geo.Point{10, 20},
+            Limit:           5,
+            ExcludeUsername: "user1",
+            MaxDistance:     500,
+            MaxAge:          time.Minute,
+          })).
+          Times(1).
+          Return([]domain.NearbyUser{
+            {
+              User:              user,
+              Point:             geo.Point{10.001, 20},
+              Distance:          104.5,
+              LocationUpdatedAt: time.Now(),
+            },
+          }, nil)
+      },
+      req: &pb.ListNearestUsersRequest{
+        Point:           []float64{10, 20},
+        Limit:           5,
+        ExcludeUsername: "user1",
+        MaxDistance:     500,
+        MaxAge:          60,
+      },
+      expectedRes: &pb.ListNearestUsersResponse{
+        Users: []*pb.NearbyUser{
+          {
+            Id:       int32(user.ID),
+            Username: user.Username,
+            Point:    []float64{10.001, 20},
+            Distance: 104.5,
+          },
+        },
+      },
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "invalid point",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListNearestUsers(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.ListNearestUsersRequest{
+        Point: []float64{10, 20, 30},
+        Limit: 5,
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "invalid limit",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListNearestUsers(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      req: &pb.ListNearestUsersRequest{
+        Point: []float64{10, 20},
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "internal error",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListNearestUsers(gomock.Any(), gomock.Any()).
+          Times(1).
+          Return(nil, errpack.ErrInternalError)
+      },
+      req: &pb.ListNearestUsersRequest{
+        Point: []float64{10, 20},
+        Limit: 5,
+      },
+      expectedErrCode: codes.Internal,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      repo := mock.NewMockUserRepository(ctrl)
+      tc.buildStubs(repo)
+
+      svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, log.NewTestingLogger())
+
+      client, closeFn := s.startLocationServer(svc, mock.NewMockSocialService(ctrl))
+      defer closeFn()
+
+      response, err := client.ListNearestUsers(context.Background(), tc.req)
+      if tc.expectedErrCode == codes.OK {
+        require.NoError(s.T(), err)
+        require.Len(s.T(), response.Users, len(tc.expectedRes.Users))
+        for i, u := range tc.expectedRes.Users {
+          require.Equal(s.T(), u.Id, response.Users[i].Id)
+          require.Equal(s.T(), u.Username, response.Users[i].Username)
+          require.Equal(s.T(), u.Point, response.Users[i].Point)
+          require.Equal(s.T(), u.Distance, response.Users[i].Distance)
+        }
+      }
+
+      st, ok := status.FromError(err)
+      require.True(s.T(), ok)
+      require.Equal(s.T(), tc.expectedErrCode, st.Code())
+    })
+  }
+}
+
+// startLocationServer starts Location gRPC server over an in-memory listener.
+//
+// It returns a connected client and a function that releases all resources.
+func (s *GRPCHandlerTestSuite) TestSetUserLocations() {
+  user := domain.User{ID: 1, Username: testutil.RandomUsername()}
+  point := geo.Trunc(geo.Point{testutil.RandomLongitude(), testutil.RandomLatitude()})
+  timestamp := time.Now().UTC()
+
+  testCases := []struct {
+    name            string
+    buildStubs      func(repo *mock.MockUserRepository)
+    reqs            []*pb.SetUserLocationsRequest
+    expectedCodes   []codes.Code
+    expectedErrCode codes.Code
+  }{
+    {
+      name: "OK",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocations(gomock.Any(), gomock.Eq([]port.UserRepositorySetUserLocationRequest{
+            {Username: user.Username, Point: point, RecordedAt: timestamp},
+          })).
+          Times(1).
+          Return([]port.UserRepositorySetUserLocationResponse{
+            {User: user, Location: domain.Location{UserID: user.ID, Point: point}},
+          }, nil)
+      },
+      reqs: []*pb.SetUserLocationsRequest{
+        {
+          Username:  user.Username,
+          Longitude: point.Longitude(),
+          Latitude:  point.Latitude(),
+          Timestamp: timestamppb.New(timestamp),
+        },
+        {
+          Username:  user.Username,
+          Longitude: point.Longitude(),
+          Latitude:  point.Latitude(),
+        },
+      },
+      expectedCodes:   []codes.Code{codes.OK, codes.InvalidArgument},
+      expectedErrCode: codes.OK,
+    },
+    {
+      name: "empty stream",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocations(gomock.Any(), gomock.Any()).
+          Times(0)
+      },
+      expectedErrCode: codes.InvalidArgument,
+    },
+    {
+      name: "internal error",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocations(gomock.Any(), gomock.Any()).
+          Times(1).
+          Return(nil, errpack.ErrInternalError)
+      },
+      reqs: []*pb.SetUserLocationsRequest{
+        {
+          Username:  user.Username,
+          Longitude: point.Longitude(),
+          Latitude:  point.Latitude(),
+          Timestamp: timestamppb.New(timestamp),
+        },
+      },
+      expectedErrCode: codes.Internal,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      repo := mock.NewMockUserRepository(ctrl)
+      tc.buildStubs(repo)
+
+      gs := mock.NewMockGeofenceService(ctrl)
+      gs.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+      svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, log.NewTestingLogger())
+
+      client, closeFn := s.startLocationServer(svc, mock.NewMockSocialService(ctrl))
+      defer closeFn()
+
+      stream, err := client.SetUserLocations(context.Background())
+      require.NoError(s.T(), err)
+      for _, req := range tc.reqs {
+        require.NoError(s.T(), stream.Send(req))
+      }
+
+      response, err := stream.CloseAndRecv()
+      require.Equal(s.T(), tc.expectedErrCode, status.Code(err))
+      if tc.expectedErrCode == codes.OK {
+        require.Len(s.T(), response.Results, len(tc.expectedCodes))
+        for i, code := range tc.expectedCodes {
+          require.Equal(s.T(), int32(code), response.Results[i].Code)
+        }
+        require.Equal(s.T(), point.Longitude(), response.Results[0].Longitude)
+        require.Equal(s.T(), point.Latitude(), response.Results[0].Latitude)
+      }
+    })
+  }
+}
+
+func (s *GRPCHandlerTestSuite) TestSetUserLocation_IdempotencyKey() {
+  ctrl := gomock.NewController(s.T())
+  defer ctrl.Finish()
+
+  svc := mock.NewMockUserService(ctrl)
+  svc.EXPECT().
+    SetUserLocation(gomock.Any(), gomock.Eq(port.UserServiceSetUserLocationRequest{
+      Username:       "user1",
+      Longitude:      20,
+      Latitude:       10,
+      IdempotencyKey: "key1",
+    })).
+    Times(1).
+    Return(port.UserServiceSetUserLocationResponse{Longitude: 20, Latitude: 10}, nil)
+
+  client, closeServer := s.startLocationServer(svc, mock.NewMockSocialService(ctrl))
+  defer closeServer()
+
+  ctx := metadata.AppendToOutgoingContext(context.Background(), "idempotency-key", "key1")
+  res, err := client.SetUserLocation(ctx, &pb.SetUserLocationRequest{
+    Username:  "user1",
+    Longitude: 20,
+    Latitude:  10,
+  })
+  require.NoError(s.T(), err)
+  require.Equal(s.T(), 20.0, res.Longitude)
+}
+
+func (s *GRPCHandlerTestSuite) startLocationServer(svc port.UserService, socialSvc port.SocialService) (pb.LocationClient, func()) {
+  listener := bufconn.Listen(1024 * 1024)
+  server := grpc.NewServer()
+  pb.RegisterLocationServer(server, handler.NewGRPCHandler(svc, socialSvc))
+
+  go func() {
+    _ = server.Serve(listener)
+  }()
+
+  dial := func(context.Context, string) (net.Conn, error) {
+    return listener.Dial()
+  }
+
+  conn, err := grpc.DialContext(context.Background(), "", grpc.WithInsecure(), grpc.WithContextDialer(dial))
+  if err != nil {
+    s.T().Fatal(err)
+  }
+
+  return pb.NewLocationClient(conn), func() {
+    conn.Close()
+    server.Stop()
+  }
+}
+
 func TestGRPCHandlerTestSuite(t *testing.T) {
   suite.Run(t, new(GRPCHandlerTestSuite))
 }
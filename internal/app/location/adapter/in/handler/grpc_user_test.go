@@ -0,0 +1,198 @@
+package handler_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+	pb "gitlab.com/spacewalker/geotracker/pkg/api/proto/v1/location"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func (s *GRPCHandlerTestSuite) TestUsers() {
+	user := domain.User{ID: 1, Username: "user1"}
+	location := domain.Location{
+		UserID:     user.ID,
+		Point:      geo.Point{10, 20},
+		RecordedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:  time.Date(2021, 1, 1, 0, 0, 1, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name            string
+		buildStubs      func(repo *mock.MockUserRepository)
+		call            func(client pb.LocationClient) (interface{}, error)
+		assert          func(res interface{})
+		expectedErrCode codes.Code
+	}{
+		{
+			name: "CreateUser OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					CreateUser(gomock.Any(), gomock.Eq(port.CreateUserArg{Username: user.Username})).
+					Times(1).
+					Return(user, nil)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.CreateUser(context.Background(), &pb.CreateUserRequest{Username: user.Username})
+			},
+			assert: func(res interface{}) {
+				require.Equal(s.T(), user.Username, res.(*pb.User).Username)
+			},
+			expectedErrCode: codes.OK,
+		},
+		{
+			name: "CreateUser invalid argument",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.CreateUser(context.Background(), &pb.CreateUserRequest{Username: "u"})
+			},
+			expectedErrCode: codes.InvalidArgument,
+		},
+		{
+			name: "GetUser OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetUserWithLocation(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(port.UserRepositoryGetUserWithLocationResponse{User: user, Location: &location}, nil)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.GetUser(context.Background(), &pb.GetUserRequest{Username: user.Username})
+			},
+			assert: func(res interface{}) {
+				response := res.(*pb.GetUserResponse)
+				require.Equal(s.T(), user.Username, response.User.Username)
+				require.Equal(s.T(), []float64{10, 20}, response.Location.Point)
+				require.Equal(s.T(), location.UpdatedAt, response.LastSeenAt.AsTime())
+			},
+			expectedErrCode: codes.OK,
+		},
+		{
+			name: "GetUser not found",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetUserWithLocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositoryGetUserWithLocationResponse{}, errpack.ErrNotFound)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.GetUser(context.Background(), &pb.GetUserRequest{Username: user.Username})
+			},
+			expectedErrCode: codes.NotFound,
+		},
+		{
+			name: "ListUsers OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersRequest{Prefix: "user", PageSize: 10})).
+					Times(1).
+					Return(port.UserRepositoryListUsersResponse{Users: []domain.User{user}}, nil)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.ListUsers(context.Background(), &pb.ListUsersRequest{Prefix: "user", PageSize: 10})
+			},
+			assert: func(res interface{}) {
+				response := res.(*pb.ListUsersResponse)
+				require.Len(s.T(), response.Users, 1)
+				require.Empty(s.T(), response.NextPageToken)
+			},
+			expectedErrCode: codes.OK,
+		},
+		{
+			name: "RenameUser already exists",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					RenameUser(gomock.Any(), gomock.Eq(port.UserRepositoryRenameUserRequest{Username: "user1", NewUsername: "user2"})).
+					Times(1).
+					Return(domain.User{}, errpack.ErrAlreadyExists)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.RenameUser(context.Background(), &pb.RenameUserRequest{Username: "user1", NewUsername: "user2"})
+			},
+			expectedErrCode: codes.AlreadyExists,
+		},
+		{
+			name: "DeleteUser OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					DeleteUser(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(domain.ErasureJob{ID: 1, UserID: user.ID, Username: user.Username, Status: domain.ErasureJobPending}, nil)
+			},
+			call: func(client pb.LocationClient) (interface{}, error) {
+				return client.DeleteUser(context.Background(), &pb.DeleteUserRequest{Username: user.Username})
+			},
+			assert: func(res interface{}) {
+				job := res.(*pb.DeleteUserResponse).Job
+				require.Equal(s.T(), int32(1), job.Id)
+				require.Equal(s.T(), "pending", job.Status)
+				require.Nil(s.T(), job.CompletedAt)
+			},
+			expectedErrCode: codes.OK,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.Run(tc.name, func() {
+			ctrl := gomock.NewController(s.T())
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, log.NewTestingLogger())
+
+			listener := bufconn.Listen(1024 * 1024)
+			server := grpc.NewServer()
+			pb.RegisterLocationServer(server, handler.NewGRPCHandler(svc, mock.NewMockSocialService(ctrl)))
+
+			go func() {
+				if err := server.Serve(listener); err != nil {
+					s.Fail(err.Error())
+				}
+			}()
+			defer server.Stop()
+
+			dial := func(context.Context, string) (net.Conn, error) {
+				return listener.Dial()
+			}
+
+			conn, err := grpc.DialContext(context.Background(), "", grpc.WithInsecure(), grpc.WithContextDialer(dial))
+			if err != nil {
+				s.Fail(err.Error())
+			}
+			defer conn.Close()
+
+			res, err := tc.call(pb.NewLocationClient(conn))
+			if tc.expectedErrCode == codes.OK {
+				require.NoError(s.T(), err)
+				if tc.assert != nil {
+					tc.assert(res)
+				}
+				return
+			}
+
+			st, ok := status.FromError(err)
+			require.True(s.T(), ok)
+			require.Equal(s.T(), tc.expectedErrCode, st.Code())
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package handler_test
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+	pb "gitlab.com/spacewalker/geotracker/pkg/api/proto/v1/location"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func (s *GRPCHandlerTestSuite) TestWatchUsersInRadius() {
+	center := geo.Point{10, 20}
+	inside := geo.Point{10.001, 20}
+	outside := geo.Point{11, 20}
+	user1 := domain.User{ID: 1, Username: "user1"}
+	user2 := domain.User{ID: 2, Username: "user2"}
+	updatedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	repo := mock.NewMockUserRepository(ctrl)
+	repo.EXPECT().
+		ListUsersInRadius(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositoryListUsersInRadiusResponse{
+			Users: []domain.NearbyUser{{User: user1, Point: inside, Distance: 104.53, LocationUpdatedAt: updatedAt}},
+		}, nil)
+	setUserLocation := func(user domain.User, point geo.Point, updatedAt time.Time) {
+		repo.EXPECT().
+			SetUserLocation(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(port.UserRepositorySetUserLocationResponse{
+				User:     user,
+				Location: domain.Location{UserID: user.ID, Point: point, UpdatedAt: updatedAt},
+			}, nil)
+	}
+	gs := mock.NewMockGeofenceService(ctrl)
+	gs.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+	svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, log.NewTestingLogger())
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	pb.RegisterLocationServer(server, handler.NewGRPCHandler(svc, mock.NewMockSocialService(ctrl)))
+
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			s.Fail(err.Error())
+		}
+	}()
+	defer server.Stop()
+
+	dial := func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}
+
+	conn, err := grpc.DialContext(context.Background(), "", grpc.WithInsecure(), grpc.WithContextDialer(dial))
+	require.NoError(s.T(), err)
+	defer conn.Close()
+
+	client := pb.NewLocationClient(conn)
+
+	s.Run("InvalidArgument", func() {
+		stream, err := client.WatchUsersInRadius(context.Background(), &pb.WatchUsersInRadiusRequest{
+			Point:  []float64{10},
+			Radius: 1000,
+		})
+		require.NoError(s.T(), err)
+
+		_, err = stream.Recv()
+		st, ok := status.FromError(err)
+		require.True(s.T(), ok)
+		require.Equal(s.T(), codes.InvalidArgument, st.Code())
+	})
+
+	s.Run("OK", func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		stream, err := client.WatchUsersInRadius(ctx, &pb.WatchUsersInRadiusRequest{
+			Point:  []float64{center.Longitude(), center.Latitude()},
+			Radius: 1000,
+		})
+		require.NoError(s.T(), err)
+
+		recv := func(eventType domain.RadiusEventType, user domain.User, point geo.Point) {
+			res, err := stream.Recv()
+			require.NoError(s.T(), err)
+			require.Equal(s.T(), string(eventType), res.Type)
+			require.Equal(s.T(), int32(user.ID), res.User.Id)
+			require.Equal(s.T(), []float64{point.Longitude(), point.Latitude()}, res.User.Point)
+		}
+
+		recv(domain.RadiusEventEnter, user1, inside)
+
+		setUserLocation(user2, inside, updatedAt.Add(time.Second))
+		_, err = client.SetUserLocation(ctx, &pb.SetUserLocationRequest{Username: user2.Username, Longitude: inside.Longitude(), Latitude: inside.Latitude()})
+		require.NoError(s.T(), err)
+		recv(domain.RadiusEventEnter, user2, inside)
+
+		setUserLocation(user1, center, updatedAt.Add(2*time.Second))
+		_, err = client.SetUserLocation(ctx, &pb.SetUserLocationRequest{Username: user1.Username, Longitude: center.Longitude(), Latitude: center.Latitude()})
+		require.NoError(s.T(), err)
+		recv(domain.RadiusEventMove, user1, center)
+
+		setUserLocation(user2, outside, updatedAt.Add(3*time.Second))
+		_, err = client.SetUserLocation(ctx, &pb.SetUserLocationRequest{Username: user2.Username, Longitude: outside.Longitude(), Latitude: outside.Latitude()})
+		require.NoError(s.T(), err)
+		recv(domain.RadiusEventExit, user2, outside)
+
+		cancel()
+		_, err = stream.Recv()
+		require.Equal(s.T(), codes.Canceled, status.Code(err))
+	})
+}
@@ -2,8 +2,10 @@ package handler
 
 import (
   "fmt"
+  "io"
   log2 "log"
   "net/http"
+  "time"
 
   "github.com/go-chi/chi/v5"
   middleware2 "github.com/go-chi/chi/v5/middleware"
@@ -21,28 +23,67 @@ var (
   schemaDecoder = schema.NewDecoder()
 )
 
+// HTTPHandlerConfig configures http handler.
+//
+// `FeedHeartbeatInterval` is how often keepalives are sent to idle clients of the live location feed,
+// 0 means `DefaultFeedHeartbeatInterval`.
+type HTTPHandlerConfig struct {
+  FeedHeartbeatInterval time.Duration
+}
+
+// DefaultFeedHeartbeatInterval is the default interval of keepalives of the live location feed.
+const DefaultFeedHeartbeatInterval = 15 * time.Second
+
 // HTTPHandler serves http requests.
 type HTTPHandler struct {
-  service port.UserService
-  router  *chi.Mux
-  logger  log.Logger
+  service         port.UserService
+  geofenceService port.GeofenceService
+  erasureService  port.ErasureService
+  socialService   port.SocialService
+  config          HTTPHandlerConfig
+  router          *chi.Mux
+  logger          log.Logger
 }
 
 // NewHTTPHandler creates HTTPHandler and returns its pointer.
-func NewHTTPHandler(service port.UserService, logger log.Logger) *HTTPHandler {
+func NewHTTPHandler(
+  service port.UserService,
+  geofenceService port.GeofenceService,
+  erasureService port.ErasureService,
+  socialService port.SocialService,
+  config HTTPHandlerConfig,
+  logger log.Logger,
+) *HTTPHandler {
   if logger == nil {
     log2.Panic("logger must not be nil")
   }
   if service == nil {
     logger.Panic("service must not be nil", nil)
   }
+  if geofenceService == nil {
+    logger.Panic("geofenceService must not be nil", nil)
+  }
+  if erasureService == nil {
+    logger.Panic("erasureService must not be nil", nil)
+  }
+  if socialService == nil {
+    logger.Panic("socialService must not be nil", nil)
+  }
+
+  if config.FeedHeartbeatInterval == 0 {
+    config.FeedHeartbeatInterval = DefaultFeedHeartbeatInterval
+  }
 
   router := chi.NewRouter()
 
   handler := &HTTPHandler{
-    service: service,
-    router:  router,
-    logger:  logger,
+    service:         service,
+    geofenceService: geofenceService,
+    erasureService:  erasureService,
+    socialService:   socialService,
+    config:          config,
+    router:          router,
+    logger:          logger,
   }
 
   handler.setupRoutes()
@@ -60,23 +101,66 @@ func (h *HTTPHandler) setupRoutes() {
     middleware.RecovererMiddleware(h.logger),
     cors.Handler(cors.Options{
       AllowedOrigins:   []string{"*"},
-      AllowedMethods:   []string{"GET", "PUT"},
-      AllowedHeaders:   []string{"Accept", "Content-Type"},
+      AllowedMethods:   []string{"GET", "PUT", "POST", "DELETE"},
+      AllowedHeaders:   []string{"Accept", "Content-Type", "Authorization", "X-API-Key", idempotencyKeyHeader},
+      ExposedHeaders:   []string{traceIDHeader},
       AllowCredentials: false,
       MaxAge:           300,
     }),
-    middleware2.AllowContentType("application/json"),
+    middleware2.AllowContentType("application/json", "application/geo+json"),
     middleware2.SetHeader("Content-Type", "application/json"),
   )
 
   users := chi.NewRouter()
 
+  users.Method(http.MethodPost, "/", http.HandlerFunc(h.createUser))
+  users.Method(http.MethodGet, "/", http.HandlerFunc(h.listUsers))
+  users.Method(http.MethodGet, "/{username}", http.HandlerFunc(h.getUser))
+  users.Method(http.MethodPut, "/{username}", http.HandlerFunc(h.renameUser))
+  users.Method(http.MethodDelete, "/{username}", http.HandlerFunc(h.deleteUser))
   users.Method(http.MethodPut, "/{username}/location", http.HandlerFunc(h.setUserLocation))
+  users.Method(http.MethodGet, "/{username}/privacy", http.HandlerFunc(h.getPrivacySettings))
+  users.Method(http.MethodPut, "/{username}/privacy", http.HandlerFunc(h.setPrivacySettings))
+  users.Method(http.MethodPost, "/{username}/following", http.HandlerFunc(h.followUser))
+  users.Method(http.MethodGet, "/{username}/following", http.HandlerFunc(h.listFollowing))
+  users.Method(http.MethodDelete, "/{username}/following/{followee}", http.HandlerFunc(h.unfollowUser))
+  users.Method(http.MethodGet, "/{username}/followers", http.HandlerFunc(h.listFollowers))
+  users.Method(http.MethodPost, "/{username}/followers/{follower}/accept", http.HandlerFunc(h.acceptFollower))
+  users.Method(http.MethodDelete, "/{username}/followers/{follower}", http.HandlerFunc(h.removeFollower))
+  users.Method(http.MethodGet, "/{username}/blocks", http.HandlerFunc(h.listBlocks))
+  users.Method(http.MethodPut, "/{username}/blocks/{blocked}", http.HandlerFunc(h.blockUser))
+  users.Method(http.MethodDelete, "/{username}/blocks/{blocked}", http.HandlerFunc(h.unblockUser))
+  users.Method(http.MethodPost, "/locations", http.HandlerFunc(h.setUserLocations))
   users.Method(http.MethodGet, "/radius", http.HandlerFunc(h.listUsersInRadius))
+  users.Method(http.MethodGet, "/nearest", http.HandlerFunc(h.listNearestUsers))
+  users.Method(http.MethodGet, "/area", http.HandlerFunc(h.listUsersInBBox))
+  users.Method(http.MethodPost, "/area", http.HandlerFunc(h.listUsersInPolygon))
+  users.Method(http.MethodGet, "/feed", http.HandlerFunc(h.streamLocations))
 
   h.router.Mount("/users", users)
+
+  geofences := chi.NewRouter()
+
+  geofences.Method(http.MethodPost, "/", http.HandlerFunc(h.createGeofence))
+  geofences.Method(http.MethodGet, "/", http.HandlerFunc(h.listGeofences))
+  geofences.Method(http.MethodGet, "/events", http.HandlerFunc(h.listGeofenceEvents))
+  geofences.Method(http.MethodGet, "/{id}", http.HandlerFunc(h.getGeofence))
+  geofences.Method(http.MethodPut, "/{id}", http.HandlerFunc(h.updateGeofence))
+  geofences.Method(http.MethodDelete, "/{id}", http.HandlerFunc(h.deleteGeofence))
+
+  h.router.Mount("/geofences", geofences)
+
+  erasureJobs := chi.NewRouter()
+
+  erasureJobs.Method(http.MethodGet, "/", http.HandlerFunc(h.listErasureJobs))
+  erasureJobs.Method(http.MethodGet, "/{id}", http.HandlerFunc(h.getErasureJob))
+
+  h.router.Mount("/erasure-jobs", erasureJobs)
 }
 
+// idempotencyKeyHeader is a header of an optional idempotency key of location updates.
+const idempotencyKeyHeader = "Idempotency-Key"
+
 func (h *HTTPHandler) setUserLocation(w http.ResponseWriter, r *http.Request) {
   var err error
   var dto *port.UserServiceSetUserLocationRequest
@@ -87,6 +171,7 @@ func (h *HTTPHandler) setUserLocation(w http.ResponseWriter, r *http.Request) {
     return
   }
   dto.Username = chi.URLParam(r, "username")
+  dto.IdempotencyKey = r.Header.Get(idempotencyKeyHeader)
 
   res, err := h.service.SetUserLocation(r.Context(), *dto)
   if err != nil {
@@ -98,12 +183,100 @@ func (h *HTTPHandler) setUserLocation(w http.ResponseWriter, r *http.Request) {
   util.Respond(w, http.StatusOK, res)
 }
 
+// trackDTO is a track of a user encoded in the encoded polyline format
+// along with timestamps of its points.
+//
+// `Precision` is 5 or 6 decimal places, 0 means 5.
+type trackDTO struct {
+  Username   string      `json:"username"`
+  Polyline   string      `json:"polyline"`
+  Precision  int         `json:"precision"`
+  Timestamps []time.Time `json:"timestamps"`
+}
+
+// setUserLocationsDTO is a batch of location fixes given one by one in `Locations`
+// and as encoded tracks in `Tracks`.
+type setUserLocationsDTO struct {
+  Locations []port.UserServiceSetUserLocationsItem `json:"locations"`
+  Tracks    []trackDTO                             `json:"tracks"`
+}
+
+// items returns all fixes of the batch, fixes of `Locations` go first followed by points of `Tracks` in order.
+//
+// `ErrInvalidArgument` is returned in case a track is malformed or its number of points differs
+// from the number of its timestamps.
+func (dto setUserLocationsDTO) items() ([]port.UserServiceSetUserLocationsItem, error) {
+  items := dto.Locations
+  for _, track := range dto.Tracks {
+    precision := track.Precision
+    if precision == 0 {
+      precision = geo.PolylinePrecision5
+    }
+
+    points, err := geo.DecodePolyline(track.Polyline, precision)
+    if err != nil || len(points) != len(track.Timestamps) {
+      return nil, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+    }
+
+    for i, point := range points {
+      items = append(items, port.UserServiceSetUserLocationsItem{
+        Username:  track.Username,
+        Latitude:  point.Latitude(),
+        Longitude: point.Longitude(),
+        Timestamp: track.Timestamps[i],
+      })
+    }
+  }
+  return items, nil
+}
+
+func (h *HTTPHandler) setUserLocations(w http.ResponseWriter, r *http.Request) {
+  var dto *setUserLocationsDTO
+
+  if err := util.DecodeBody(r, &dto); err != nil || dto == nil {
+    status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+    util.Respond(w, status, body)
+    return
+  }
+
+  items, err := dto.items()
+  if err != nil {
+    status, body := errpack.ErrToHTTP(err)
+    util.Respond(w, status, body)
+    return
+  }
+
+  res, err := h.service.SetUserLocations(r.Context(), port.UserServiceSetUserLocationsRequest{Locations: items})
+  if err != nil {
+    status, body := errpack.ErrToHTTP(err)
+    util.Respond(w, status, body)
+    return
+  }
+
+  // An applied item is represented by its location, a skipped one by its error.
+  results := make([]interface{}, 0, len(res.Results))
+  for _, result := range res.Results {
+    if result.Err != nil {
+      _, body := errpack.ErrToHTTP(result.Err)
+      results = append(results, body)
+      continue
+    }
+    results = append(results, result)
+  }
+
+  util.Respond(w, http.StatusOK, map[string]interface{}{"results": results})
+}
+
 type listUsersInRadiusDTO struct {
   Radius    float64 `schema:"radius"`
   Longitude float64 `schema:"longitude"`
   Latitude  float64 `schema:"latitude"`
   PageToken string  `schema:"page_token"`
   PageSize  int     `schema:"page_size"`
+  OrderBy   string  `schema:"order_by"`
+  MaxAge    int     `schema:"max_age"`
+  Scope     string  `schema:"scope"`
+  Caller    string  `schema:"caller"`
 }
 
 func (h *HTTPHandler) listUsersInRadius(w http.ResponseWriter, r *http.Request) {
@@ -134,6 +307,10 @@ func (h *HTTPHandler) listUsersInRadius(w http.ResponseWriter, r *http.Request)
     Radius:    dto.Radius,
     PageToken: dto.PageToken,
     PageSize:  dto.PageSize,
+    OrderBy:   port.UsersOrder(dto.OrderBy),
+    MaxAge:    time.Duration(dto.MaxAge) * time.Second,
+    Scope:     port.UsersScope(dto.Scope),
+    Caller:    dto.Caller,
   }
 
   res, err = h.service.ListUsersInRadius(r.Context(), req)
@@ -143,5 +320,152 @@ func (h *HTTPHandler) listUsersInRadius(w http.ResponseWriter, r *http.Request)
     return
   }
 
+  if wantsGeoJSON(w, r) {
+    respondGeoJSON(w, http.StatusOK, nearbyUsersFeatureCollection(res.Users, res.NextPageToken))
+    return
+  }
+
+  util.Respond(w, http.StatusOK, res)
+}
+
+type listNearestUsersDTO struct {
+  Longitude       float64 `schema:"longitude"`
+  Latitude        float64 `schema:"latitude"`
+  Limit           int     `schema:"limit"`
+  ExcludeUsername string  `schema:"exclude_username"`
+  MaxDistance     float64 `schema:"max_distance"`
+  MaxAge          int     `schema:"max_age"`
+  Scope           string  `schema:"scope"`
+  Caller          string  `schema:"caller"`
+}
+
+func (h *HTTPHandler) listNearestUsers(w http.ResponseWriter, r *http.Request) {
+  var dto listNearestUsersDTO
+
+  if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+    status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+    util.Respond(w, status, body)
+    return
+  }
+
+  res, err := h.service.ListNearestUsers(r.Context(), port.UserServiceListNearestUsersRequest{
+    Point: geo.Point{
+      dto.Longitude,
+      dto.Latitude,
+    },
+    Limit:           dto.Limit,
+    ExcludeUsername: dto.ExcludeUsername,
+    MaxDistance:     dto.MaxDistance,
+    MaxAge:          time.Duration(dto.MaxAge) * time.Second,
+    Scope:           port.UsersScope(dto.Scope),
+    Caller:          dto.Caller,
+  })
+  if err != nil {
+    status, body := errpack.ErrToHTTP(err)
+    util.Respond(w, status, body)
+    return
+  }
+
+  if wantsGeoJSON(w, r) {
+    respondGeoJSON(w, http.StatusOK, nearbyUsersFeatureCollection(res.Users, ""))
+    return
+  }
+
+  util.Respond(w, http.StatusOK, res)
+}
+
+type listUsersInAreaDTO struct {
+  BBox      string `schema:"bbox"`
+  PageToken string `schema:"page_token"`
+  PageSize  int    `schema:"page_size"`
+  MaxAge    int    `schema:"max_age"`
+  Scope     string `schema:"scope"`
+  Caller    string `schema:"caller"`
+}
+
+func (h *HTTPHandler) listUsersInBBox(w http.ResponseWriter, r *http.Request) {
+  var dto listUsersInAreaDTO
+
+  if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+    status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+    util.Respond(w, status, body)
+    return
+  }
+
+  bbox, err := geo.ParseBBox(dto.BBox)
+  if err != nil {
+    status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+    util.Respond(w, status, body)
+    return
+  }
+
+  res, err := h.service.ListUsersInBBox(r.Context(), port.UserServiceListUsersInBBoxRequest{
+    BBox:      bbox,
+    PageToken: dto.PageToken,
+    PageSize:  dto.PageSize,
+    MaxAge:    time.Duration(dto.MaxAge) * time.Second,
+    Scope:     port.UsersScope(dto.Scope),
+    Caller:    dto.Caller,
+  })
+  if err != nil {
+    status, body := errpack.ErrToHTTP(err)
+    util.Respond(w, status, body)
+    return
+  }
+
+  if wantsGeoJSON(w, r) {
+    respondGeoJSON(w, http.StatusOK, locatedUsersFeatureCollection(res.Users, res.NextPageToken))
+    return
+  }
+
+  util.Respond(w, http.StatusOK, res)
+}
+
+// listUsersInPolygon finds users inside a polygon given in the request body
+// as a GeoJSON geometry or a GeoJSON feature of Polygon type.
+func (h *HTTPHandler) listUsersInPolygon(w http.ResponseWriter, r *http.Request) {
+  var dto listUsersInAreaDTO
+
+  if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+    status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+    util.Respond(w, status, body)
+    return
+  }
+
+  defer r.Body.Close()
+  b, err := io.ReadAll(r.Body)
+  if err != nil {
+    status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+    util.Respond(w, status, body)
+    return
+  }
+
+  geometry, err := geo.ParseGeoJSONGeometry(b)
+  polygon, ok := geometry.Polygon()
+  if err != nil || !ok {
+    status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+    util.Respond(w, status, body)
+    return
+  }
+
+  res, err := h.service.ListUsersInPolygon(r.Context(), port.UserServiceListUsersInPolygonRequest{
+    Polygon:   polygon,
+    PageToken: dto.PageToken,
+    PageSize:  dto.PageSize,
+    MaxAge:    time.Duration(dto.MaxAge) * time.Second,
+    Scope:     port.UsersScope(dto.Scope),
+    Caller:    dto.Caller,
+  })
+  if err != nil {
+    status, body := errpack.ErrToHTTP(err)
+    util.Respond(w, status, body)
+    return
+  }
+
+  if wantsGeoJSON(w, r) {
+    respondGeoJSON(w, http.StatusOK, locatedUsersFeatureCollection(res.Users, res.NextPageToken))
+    return
+  }
+
   util.Respond(w, http.StatusOK, res)
 }
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+func (h *HTTPHandler) getErasureJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.erasureService.GetErasureJob(r.Context(), id)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+type listErasureJobsDTO struct {
+	Status    string `schema:"status"`
+	PageToken string `schema:"page_token"`
+	PageSize  int    `schema:"page_size"`
+}
+
+func (h *HTTPHandler) listErasureJobs(w http.ResponseWriter, r *http.Request) {
+	var dto listErasureJobsDTO
+
+	if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.erasureService.ListErasureJobs(r.Context(), port.ErasureServiceListErasureJobsRequest{
+		Status:    domain.ErasureJobStatus(dto.Status),
+		PageToken: dto.PageToken,
+		PageSize:  dto.PageSize,
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
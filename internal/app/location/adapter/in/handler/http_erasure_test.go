@@ -0,0 +1,132 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gavv/httpexpect"
+	"github.com/golang/mock/gomock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+)
+
+func (s *HTTPHandleTestSuite) TestErasureJobs() {
+	completedAt := time.Date(2021, 1, 1, 0, 0, 10, 0, time.UTC)
+	job := domain.ErasureJob{
+		ID:          1,
+		UserID:      2,
+		Username:    "user1",
+		Status:      domain.ErasureJobCompleted,
+		Attempts:    1,
+		CompletedAt: &completedAt,
+	}
+
+	testCases := []struct {
+		name           string
+		buildStubs     func(repo *mock.MockErasureRepository)
+		path           string
+		query          map[string]interface{}
+		expectedStatus int
+		assert         func(res *httpexpect.Response)
+	}{
+		{
+			name: "Get_OK",
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().GetErasureJob(gomock.Any(), gomock.Eq(1)).Times(1).Return(job, nil)
+			},
+			path:           "/erasure-jobs/1",
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().
+					ValueEqual("id", 1).
+					ValueEqual("user_id", 2).
+					ValueEqual("status", "completed").
+					ValueEqual("attempts", 1).
+					NotContainsKey("last_error").
+					ContainsKey("completed_at")
+			},
+		},
+		{
+			name: "Get_InvalidID",
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().GetErasureJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			path:           "/erasure-jobs/abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Get_NotFound",
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().GetErasureJob(gomock.Any(), gomock.Eq(2)).Times(1).Return(domain.ErasureJob{}, errpack.ErrNotFound)
+			},
+			path:           "/erasure-jobs/2",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "List_OK",
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().
+					ListErasureJobs(gomock.Any(), gomock.Eq(port.ErasureRepositoryListErasureJobsRequest{
+						Status:   domain.ErasureJobPending,
+						PageSize: 10,
+					})).
+					Times(1).
+					Return(port.ErasureRepositoryListErasureJobsResponse{
+						Jobs: []domain.ErasureJob{{ID: 3, UserID: 4, Username: "user2", Status: domain.ErasureJobPending, Attempts: 2, LastError: "unavailable"}},
+					}, nil)
+			},
+			path:           "/erasure-jobs",
+			query:          map[string]interface{}{"status": "pending", "page_size": 10},
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				jobs := res.JSON().Object().Value("jobs").Array()
+				jobs.Length().Equal(1)
+				jobs.Element(0).Object().ValueEqual("last_error", "unavailable")
+			},
+		},
+		{
+			name: "List_InvalidStatus",
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().ListErasureJobs(gomock.Any(), gomock.Any()).Times(0)
+			},
+			path:           "/erasure-jobs",
+			query:          map[string]interface{}{"status": "failed", "page_size": 10},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.Run(tc.name, func() {
+			ctrl := gomock.NewController(s.T())
+			defer ctrl.Finish()
+
+			logger := log.NewTestingLogger()
+
+			repo := mock.NewMockErasureRepository(ctrl)
+			tc.buildStubs(repo)
+
+			gs := mock.NewMockGeofenceService(ctrl)
+			svc := mock.NewMockUserService(ctrl)
+			es := service.NewErasureService(repo, mock.NewMockHistoryClient(ctrl), logger)
+
+			server := httptest.NewServer(handler.NewHTTPHandler(svc, gs, es, mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger))
+			defer server.Close()
+
+			e := httpexpect.New(s.T(), server.URL)
+
+			res := e.GET(tc.path).WithQueryObject(tc.query).Expect()
+
+			res.Status(tc.expectedStatus)
+			if tc.assert != nil {
+				tc.assert(res)
+			}
+		})
+	}
+}
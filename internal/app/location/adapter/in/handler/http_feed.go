@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+// traceIDHeader is a response header the trace ID of a request is sent in.
+const traceIDHeader = "X-Trace-Id"
+
+type streamLocationsDTO struct {
+	Usernames string `schema:"usernames"`
+	BBox      string `schema:"bbox"`
+	Scope     string `schema:"scope"`
+	Caller    string `schema:"caller"`
+}
+
+// streamLocations streams location updates of chosen users or of users inside an area
+// as server-sent events until the client disconnects.
+//
+// The stream starts with an `open` event carrying the trace ID, every update is sent as a `location` event
+// and heartbeat comments are sent in between. A client which falls too far behind gets an `error` event
+// and the stream ends, the client should reconnect then.
+func (h *HTTPHandler) streamLocations(w http.ResponseWriter, r *http.Request) {
+	var dto streamLocationsDTO
+
+	if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		util.Respond(w, status, body)
+		return
+	}
+
+	req := port.UserServiceSubscribeLocationsRequest{
+		Scope:  port.UsersScope(dto.Scope),
+		Caller: dto.Caller,
+	}
+	if dto.Usernames != "" {
+		for _, username := range strings.Split(dto.Usernames, ",") {
+			req.Usernames = append(req.Usernames, strings.TrimSpace(username))
+		}
+	}
+	if dto.BBox != "" {
+		bbox, err := geo.ParseBBox(dto.BBox)
+		if err != nil {
+			status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+			util.Respond(w, status, body)
+			return
+		}
+		req.BBox = &bbox
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w: streaming is not supported", errpack.ErrInternalError))
+		util.Respond(w, status, body)
+		return
+	}
+
+	ctx := r.Context()
+
+	sub, err := h.service.SubscribeLocations(ctx, req)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+	defer sub.Close()
+
+	traceID, _ := util.GetTraceIDFromCtx(ctx)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set(traceIDHeader, traceID)
+	w.WriteHeader(http.StatusOK)
+
+	// EventSource does not expose response headers, so the trace ID is sent in the stream as well.
+	if err = writeEvent(w, "open", map[string]string{"trace_id": traceID}); err != nil {
+		return
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.config.FeedHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-heartbeat.C:
+			_, err = io.WriteString(w, ": heartbeat\n\n")
+		case update, ok := <-sub.Updates():
+			if !ok {
+				// The subscription ends without an error when the client disconnects.
+				if subErr := sub.Err(); subErr != nil {
+					_, body := errpack.ErrToHTTP(subErr)
+					_ = writeEvent(w, "error", body)
+					flusher.Flush()
+				}
+				return
+			}
+			err = writeEvent(w, "location", update)
+		}
+		if err != nil {
+			// The client is gone.
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// writeEvent writes a server-sent event with JSON encoded data to w.
+func writeEvent(w io.Writer, event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, b)
+	return err
+}
@@ -0,0 +1,155 @@
+package handler_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/gavv/httpexpect"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+)
+
+// sseEvent is a server-sent event, comments are represented by events with empty names.
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readEvent reads the next event or comment from r.
+func readEvent(r *bufio.Reader) (sseEvent, error) {
+	var event sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return sseEvent{}, err
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "":
+			return event, nil
+		case strings.HasPrefix(line, ":"):
+			event.data = strings.TrimSpace(strings.TrimPrefix(line, ":"))
+		case strings.HasPrefix(line, "event: "):
+			event.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			event.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func (s *HTTPHandleTestSuite) TestStreamLocations() {
+	user1 := domain.User{ID: 1, Username: "user1"}
+	user2 := domain.User{ID: 2, Username: "user2"}
+
+	newServer := func(ctrl *gomock.Controller, repo *mock.MockUserRepository, config handler.HTTPHandlerConfig) *httptest.Server {
+		logger := log.NewTestingLogger()
+
+		gs := mock.NewMockGeofenceService(ctrl)
+		gs.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+		svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), gs, broker.NewMemoryBroker(logger), service.UserServiceConfig{}, logger)
+
+		return httptest.NewServer(handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), config, logger))
+	}
+
+	s.Run("InvalidArgument", func() {
+		ctrl := gomock.NewController(s.T())
+		defer ctrl.Finish()
+
+		server := newServer(ctrl, mock.NewMockUserRepository(ctrl), handler.HTTPHandlerConfig{})
+		defer server.Close()
+
+		e := httpexpect.New(s.T(), server.URL)
+
+		for _, query := range []map[string]interface{}{
+			{},
+			{"usernames": "user1", "bbox": "0,0,10,10"},
+			{"usernames": "user1,"},
+			{"bbox": "0,0,10"},
+		} {
+			e.GET("/users/feed").WithQueryObject(query).Expect().Status(http.StatusBadRequest)
+		}
+	})
+
+	s.Run("OK", func() {
+		ctrl := gomock.NewController(s.T())
+		defer ctrl.Finish()
+
+		repo := mock.NewMockUserRepository(ctrl)
+		server := newServer(ctrl, repo, handler.HTTPHandlerConfig{FeedHeartbeatInterval: 50 * time.Millisecond})
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/users/feed?bbox=0,0,20,20", nil)
+		require.NoError(s.T(), err)
+		res, err := http.DefaultClient.Do(req)
+		require.NoError(s.T(), err)
+		defer res.Body.Close()
+
+		require.Equal(s.T(), http.StatusOK, res.StatusCode)
+		require.Equal(s.T(), "text/event-stream", res.Header.Get("Content-Type"))
+		traceID := res.Header.Get("X-Trace-Id")
+		require.NotEmpty(s.T(), traceID)
+
+		r := bufio.NewReader(res.Body)
+
+		event, err := readEvent(r)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), sseEvent{name: "open", data: `{"trace_id":"` + traceID + `"}`}, event)
+
+		e := httpexpect.New(s.T(), server.URL)
+		setUserLocation := func(user domain.User, point geo.Point) {
+			repo.EXPECT().
+				SetUserLocation(gomock.Any(), gomock.Any()).
+				Times(1).
+				Return(port.UserRepositorySetUserLocationResponse{
+					User:     user,
+					Location: domain.Location{UserID: user.ID, Point: point},
+				}, nil)
+			e.PUT("/users/{username}/location", user.Username).
+				WithJSON(map[string]interface{}{"longitude": point.Longitude(), "latitude": point.Latitude()}).
+				Expect().
+				Status(http.StatusOK)
+		}
+
+		// Locations outside the area are not streamed.
+		setUserLocation(user1, geo.Point{30, 30})
+		setUserLocation(user2, geo.Point{10, 10})
+
+		for {
+			event, err = readEvent(r)
+			require.NoError(s.T(), err)
+			// Heartbeats may come at any moment.
+			if event.name != "" {
+				break
+			}
+			require.Equal(s.T(), "heartbeat", event.data)
+		}
+		require.Equal(s.T(), "location", event.name)
+
+		var located domain.LocatedUser
+		require.NoError(s.T(), json.Unmarshal([]byte(event.data), &located))
+		require.Equal(s.T(), user2.ID, located.ID)
+		require.Equal(s.T(), geo.Point{10, 10}, located.Point)
+
+		event, err = readEvent(r)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), sseEvent{data: "heartbeat"}, event)
+	})
+}
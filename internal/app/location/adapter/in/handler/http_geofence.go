@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+// geofenceIDParam returns geofence ID from the URL.
+func geofenceIDParam(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+	return id, nil
+}
+
+func (h *HTTPHandler) createGeofence(w http.ResponseWriter, r *http.Request) {
+	var dto *port.GeofenceServiceCreateGeofenceRequest
+
+	if err := util.DecodeBody(r, &dto); err != nil || dto == nil {
+		status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.geofenceService.CreateGeofence(r.Context(), *dto)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusCreated, res)
+}
+
+func (h *HTTPHandler) getGeofence(w http.ResponseWriter, r *http.Request) {
+	id, err := geofenceIDParam(r)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.geofenceService.GetGeofence(r.Context(), id)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+func (h *HTTPHandler) updateGeofence(w http.ResponseWriter, r *http.Request) {
+	var dto *port.GeofenceServiceUpdateGeofenceRequest
+
+	id, err := geofenceIDParam(r)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	if err = util.DecodeBody(r, &dto); err != nil || dto == nil {
+		status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+		util.Respond(w, status, body)
+		return
+	}
+	dto.ID = id
+
+	res, err := h.geofenceService.UpdateGeofence(r.Context(), *dto)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+func (h *HTTPHandler) deleteGeofence(w http.ResponseWriter, r *http.Request) {
+	id, err := geofenceIDParam(r)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	if err = h.geofenceService.DeleteGeofence(r.Context(), id); err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusNoContent, nil)
+}
+
+type listGeofencesDTO struct {
+	PageToken string `schema:"page_token"`
+	PageSize  int    `schema:"page_size"`
+}
+
+func (h *HTTPHandler) listGeofences(w http.ResponseWriter, r *http.Request) {
+	var dto listGeofencesDTO
+
+	if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.geofenceService.ListGeofences(r.Context(), port.GeofenceServiceListGeofencesRequest{
+		PageToken: dto.PageToken,
+		PageSize:  dto.PageSize,
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+type listGeofenceEventsDTO struct {
+	GeofenceID int    `schema:"geofence_id"`
+	UserID     int    `schema:"user_id"`
+	PageToken  string `schema:"page_token"`
+	PageSize   int    `schema:"page_size"`
+}
+
+func (h *HTTPHandler) listGeofenceEvents(w http.ResponseWriter, r *http.Request) {
+	var dto listGeofenceEventsDTO
+
+	if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.geofenceService.ListGeofenceEvents(r.Context(), port.GeofenceServiceListGeofenceEventsRequest{
+		GeofenceID: dto.GeofenceID,
+		UserID:     dto.UserID,
+		PageToken:  dto.PageToken,
+		PageSize:   dto.PageSize,
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
@@ -0,0 +1,193 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gavv/httpexpect"
+	"github.com/golang/mock/gomock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+)
+
+func (s *HTTPHandleTestSuite) TestGeofences() {
+	center := geo.Point{10, 20}
+	geofence := domain.Geofence{
+		ID:     1,
+		Name:   "office",
+		Type:   domain.GeofenceTypeCircle,
+		Center: &center,
+		Radius: 100,
+	}
+
+	testCases := []struct {
+		name           string
+		buildStubs     func(repo *mock.MockGeofenceRepository)
+		method         string
+		path           string
+		query          map[string]interface{}
+		body           interface{}
+		expectedStatus int
+		assert         func(res *httpexpect.Response)
+	}{
+		{
+			name: "Create_OK",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					CreateGeofence(gomock.Any(), gomock.Eq(port.GeofenceRepositoryCreateGeofenceRequest{
+						Name:   "office",
+						Type:   domain.GeofenceTypeCircle,
+						Center: &center,
+						Radius: 100,
+					})).
+					Times(1).
+					Return(geofence, nil)
+			},
+			method: http.MethodPost,
+			path:   "/geofences",
+			body: map[string]interface{}{
+				"name":   "office",
+				"type":   "circle",
+				"center": center,
+				"radius": 100,
+			},
+			expectedStatus: http.StatusCreated,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().ValueEqual("id", 1).ValueEqual("center", []float64{10, 20})
+			},
+		},
+		{
+			name: "Create_AlreadyExists",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					CreateGeofence(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(domain.Geofence{}, errpack.ErrAlreadyExists)
+			},
+			method: http.MethodPost,
+			path:   "/geofences",
+			body: map[string]interface{}{
+				"name":   "office",
+				"type":   "circle",
+				"center": center,
+				"radius": 100,
+			},
+			expectedStatus: http.StatusConflict,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Path("$.error.status").Equal("ALREADY_EXISTS")
+			},
+		},
+		{
+			name: "Create_CircleWithoutCenter",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().CreateGeofence(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method: http.MethodPost,
+			path:   "/geofences",
+			body: map[string]interface{}{
+				"name":   "office",
+				"type":   "circle",
+				"radius": 100,
+			},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Get_NotFound",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					GetGeofence(gomock.Any(), gomock.Eq(2)).
+					Times(1).
+					Return(domain.Geofence{}, errpack.ErrNotFound)
+			},
+			method:         http.MethodGet,
+			path:           "/geofences/2",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Get_InvalidID",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().GetGeofence(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodGet,
+			path:           "/geofences/abc",
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Delete_OK",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					DeleteGeofence(gomock.Any(), gomock.Eq(1)).
+					Times(1).
+					Return(nil)
+			},
+			method:         http.MethodDelete,
+			path:           "/geofences/1",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "ListEvents_OK",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					ListGeofenceEvents(gomock.Any(), gomock.Eq(port.GeofenceRepositoryListGeofenceEventsRequest{
+						GeofenceID: 1,
+						PageSize:   10,
+					})).
+					Times(1).
+					Return(port.GeofenceRepositoryListGeofenceEventsResponse{
+						Events: []domain.GeofenceEvent{
+							{ID: 3, GeofenceID: 1, UserID: 2, Type: domain.GeofenceEventExit, Point: center},
+						},
+					}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/geofences/events",
+			query:          map[string]interface{}{"geofence_id": 1, "page_size": 10},
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				events := res.JSON().Object().Value("events").Array()
+				events.Length().Equal(1)
+				events.Element(0).Object().ValueEqual("type", "EXIT")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.Run(tc.name, func() {
+			ctrl := gomock.NewController(s.T())
+			defer ctrl.Finish()
+
+			logger := log.NewTestingLogger()
+
+			repo := mock.NewMockGeofenceRepository(ctrl)
+			tc.buildStubs(repo)
+
+			gs := service.NewGeofenceService(repo, logger)
+			svc := service.NewUserService(mock.NewMockUserRepository(ctrl), mock.NewMockHistoryClient(ctrl), gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, logger)
+
+			server := httptest.NewServer(handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger))
+			defer server.Close()
+
+			e := httpexpect.New(s.T(), server.URL)
+
+			req := e.Request(tc.method, tc.path).WithQueryObject(tc.query)
+			if tc.body != nil {
+				req = req.WithJSON(tc.body)
+			}
+
+			res := req.Expect()
+
+			res.Status(tc.expectedStatus)
+			if tc.assert != nil {
+				tc.assert(res)
+			}
+		})
+	}
+}
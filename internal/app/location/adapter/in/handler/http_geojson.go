@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+// userFeatureCollection is a GeoJSON feature collection of users.
+// The next page token is a foreign member of the collection, it is omitted if there is no next page.
+type userFeatureCollection struct {
+	geo.FeatureCollection
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+// userProperties returns GeoJSON feature properties of the user.
+func userProperties(user domain.User) map[string]interface{} {
+	return map[string]interface{}{
+		"username":   user.Username,
+		"created_at": user.CreatedAt,
+		"updated_at": user.UpdatedAt,
+	}
+}
+
+// nearbyUsersFeatureCollection returns a feature collection of the users, every user is a point feature with ID of the user.
+func nearbyUsersFeatureCollection(users []domain.NearbyUser, nextPageToken string) userFeatureCollection {
+	features := make([]geo.Feature, 0, len(users))
+	for _, user := range users {
+		properties := userProperties(user.User)
+		properties["geohash"] = user.Geohash
+		properties["distance"] = user.Distance
+		properties["location_updated_at"] = user.LocationUpdatedAt
+		features = append(features, geo.NewFeature(user.ID, geo.NewPointGeometry(user.Point), properties))
+	}
+
+	return userFeatureCollection{
+		FeatureCollection: geo.NewFeatureCollection(features),
+		NextPageToken:     nextPageToken,
+	}
+}
+
+// locatedUsersFeatureCollection returns a feature collection of the users, every user is a point feature with ID of the user.
+func locatedUsersFeatureCollection(users []domain.LocatedUser, nextPageToken string) userFeatureCollection {
+	features := make([]geo.Feature, 0, len(users))
+	for _, user := range users {
+		properties := userProperties(user.User)
+		properties["geohash"] = user.Geohash
+		properties["location_updated_at"] = user.LocationUpdatedAt
+		features = append(features, geo.NewFeature(user.ID, geo.NewPointGeometry(user.Point), properties))
+	}
+
+	return userFeatureCollection{
+		FeatureCollection: geo.NewFeatureCollection(features),
+		NextPageToken:     nextPageToken,
+	}
+}
+
+// wantsGeoJSON reports whether the client asks for a GeoJSON response with the Accept header.
+// The response is marked as varying by the Accept header, so it must be called for every successful
+// response of an endpoint that supports GeoJSON.
+func wantsGeoJSON(w http.ResponseWriter, r *http.Request) bool {
+	w.Header().Add("Vary", "Accept")
+	return util.Accepts(r, geo.GeoJSONMediaType)
+}
+
+// respondGeoJSON responds with the GeoJSON document.
+// Errors are sent as plain JSON, so they must be responded with `util.Respond`.
+func respondGeoJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", geo.GeoJSONMediaType)
+	_ = util.Respond(w, status, data)
+}
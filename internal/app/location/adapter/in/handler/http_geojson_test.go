@@ -0,0 +1,219 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gavv/httpexpect"
+	"github.com/golang/mock/gomock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util/pagination"
+)
+
+func (s *HTTPHandleTestSuite) TestGeoJSON() {
+	user := domain.User{ID: 7, Username: "user1"}
+	point := geo.Point{10.5, 20.25}
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	polygon := geo.Polygon{{{0, 0}, {30, 0}, {30, 30}, {0, 30}, {0, 0}}}
+	geoJSON := httpexpect.ContentOpts{MediaType: geo.GeoJSONMediaType}
+
+	testCases := []struct {
+		name           string
+		buildStubs     func(repo *mock.MockUserRepository)
+		method         string
+		path           string
+		query          map[string]interface{}
+		accept         string
+		body           string
+		expectedStatus int
+		assert         func(res *httpexpect.Response)
+	}{
+		{
+			name: "Radius_FeatureCollection",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsersInRadius(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositoryListUsersInRadiusResponse{
+						Users: []domain.NearbyUser{
+							{User: user, Point: point, Distance: 12.5, LocationUpdatedAt: updatedAt},
+						},
+						NextPageToken: 7,
+					}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users/radius",
+			query:          map[string]interface{}{"longitude": 10, "latitude": 20, "radius": 100000, "page_size": 1},
+			accept:         "application/geo+json",
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.ContentType(geo.GeoJSONMediaType)
+				s.Require().Contains(res.Raw().Header.Values("Vary"), "Accept")
+
+				obj := res.JSON(geoJSON).Object()
+				obj.ValueEqual("type", "FeatureCollection")
+				obj.ValueEqual("next_page_token", pagination.EncodeCursor(7, 1))
+
+				feature := obj.Value("features").Array().Element(0).Object()
+				feature.ValueEqual("type", "Feature")
+				feature.ValueEqual("id", user.ID)
+				feature.ValueEqual("geometry", map[string]interface{}{"type": "Point", "coordinates": point})
+				properties := feature.Value("properties").Object()
+				properties.ValueEqual("username", user.Username)
+				properties.ValueEqual("distance", 12.5)
+				properties.ValueEqual("geohash", geo.EncodeGeohash(point, geo.MaxGeohashPrecision))
+				properties.ValueEqual("location_updated_at", updatedAt)
+			},
+		},
+		{
+			name: "Nearest_FeatureCollection",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListNearestUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users/nearest",
+			query:          map[string]interface{}{"longitude": 10, "latitude": 20, "limit": 5},
+			accept:         "application/json;q=0.5, application/geo+json",
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.ContentType(geo.GeoJSONMediaType)
+				obj := res.JSON(geoJSON).Object()
+				obj.ValueEqual("type", "FeatureCollection")
+				obj.Value("features").Array().Empty()
+				obj.NotContainsKey("next_page_token")
+			},
+		},
+		{
+			name: "BBox_JSON",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsersInBBox(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositoryListUsersInAreaResponse{
+						Users: []domain.LocatedUser{{User: user, Point: point, LocationUpdatedAt: updatedAt}},
+					}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users/area",
+			query:          map[string]interface{}{"bbox": "0,0,30,30", "page_size": 1},
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.ContentType("application/json")
+				res.JSON().Object().Value("users").Array().Element(0).Object().ValueEqual("point", point)
+			},
+		},
+		{
+			name: "Polygon_FeatureInput",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsersInPolygon(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInPolygonRequest{
+						Polygon:  polygon,
+						PageSize: 1,
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInAreaResponse{
+						Users: []domain.LocatedUser{{User: user, Point: point, LocationUpdatedAt: updatedAt}},
+					}, nil)
+			},
+			method: http.MethodPost,
+			path:   "/users/area",
+			query:  map[string]interface{}{"page_size": 1},
+			accept: "application/geo+json",
+			body: `{
+				"type": "Feature",
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [30, 0], [30, 30], [0, 30], [0, 0]]]},
+				"properties": {"name": "area"}
+			}`,
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.ContentType(geo.GeoJSONMediaType)
+				feature := res.JSON(geoJSON).Object().Value("features").Array().Element(0).Object()
+				feature.ValueEqual("id", user.ID)
+				feature.Value("properties").Object().NotContainsKey("distance")
+			},
+		},
+		{
+			name: "Polygon_UnsupportedGeometry",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInPolygon(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodPost,
+			path:           "/users/area",
+			body:           `{"type": "LineString", "coordinates": [[0, 0], [30, 0]]}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Polygon_FeatureWithPoint",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInPolygon(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodPost,
+			path:           "/users/area",
+			body:           `{"type": "Feature", "geometry": {"type": "Point", "coordinates": [0, 0]}, "properties": null}`,
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Error_JSON",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodGet,
+			path:           "/users/area",
+			query:          map[string]interface{}{"bbox": "0,30,30,0"},
+			accept:         "application/geo+json",
+			expectedStatus: http.StatusBadRequest,
+			assert: func(res *httpexpect.Response) {
+				res.ContentType("application/json")
+				res.JSON().Path("$.error.status").Equal("INVALID_ARGUMENT")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.Run(tc.name, func() {
+			ctrl := gomock.NewController(s.T())
+			defer ctrl.Finish()
+
+			logger := log.NewTestingLogger()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+
+			gs := mock.NewMockGeofenceService(ctrl)
+
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, logger)
+
+			server := httptest.NewServer(handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger))
+			defer server.Close()
+
+			e := httpexpect.New(s.T(), server.URL)
+
+			req := e.Request(tc.method, tc.path).WithQueryObject(tc.query)
+			if tc.accept != "" {
+				req = req.WithHeader("Accept", tc.accept)
+			}
+			if tc.body != "" {
+				req = req.WithHeader("Content-Type", geo.GeoJSONMediaType).WithBytes([]byte(tc.body))
+			}
+
+			res := req.Expect()
+
+			res.Status(tc.expectedStatus)
+			if tc.assert != nil {
+				tc.assert(res)
+			}
+		})
+	}
+}
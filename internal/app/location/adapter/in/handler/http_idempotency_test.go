@@ -0,0 +1,58 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gavv/httpexpect"
+	"github.com/golang/mock/gomock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+)
+
+func (s *HTTPHandleTestSuite) TestSetUserLocation_IdempotencyKey() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	logger := log.NewTestingLogger()
+
+	// Retries are replayed, so locations are set and history records are added only by the first requests.
+	hc := mock.NewMockHistoryClient(ctrl)
+	hc.EXPECT().AddRecord(gomock.Any(), gomock.Any()).Times(2)
+
+	gs := mock.NewMockGeofenceService(ctrl)
+	gs.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).Times(3)
+
+	svc := service.NewUserService(repository.NewMemoryRepository(), hc, gs, broker.NewMemoryBroker(logger), service.UserServiceConfig{}, logger)
+	h := handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger)
+
+	server := httptest.NewServer(h)
+	defer server.Close()
+
+	e := httpexpect.New(s.T(), server.URL)
+	put := func(key string, body map[string]interface{}) *httpexpect.Response {
+		return e.PUT("/users/user1/location").WithHeader("Idempotency-Key", key).WithJSON(body).Expect()
+	}
+
+	put("key1", map[string]interface{}{"latitude": 10, "longitude": 20}).
+		Status(http.StatusOK).
+		JSON().Object().ValueEqual("latitude", 10).ValueEqual("longitude", 20)
+
+	first := put("key2", map[string]interface{}{"latitude": 11, "longitude": 21}).Status(http.StatusOK).Body().Raw()
+	put("key2", map[string]interface{}{"latitude": 11, "longitude": 21}).Status(http.StatusOK).Body().Equal(first)
+
+	put("key2", map[string]interface{}{"latitude": 12, "longitude": 22}).
+		Status(http.StatusUnprocessableEntity).
+		JSON().Path("$.error.status").Equal("FAILED_PRECONDITION")
+
+	// Keys of failed requests are not remembered, so they may be sent with other requests.
+	put("key3", map[string]interface{}{"latitude": 12, "longitude": 22, "recorded_at": "2000-01-01T00:00:00Z"}).
+		Status(http.StatusUnprocessableEntity)
+	put("key3", map[string]interface{}{"latitude": 12, "longitude": 22}).
+		Status(http.StatusOK).
+		JSON().Object().ValueEqual("latitude", 12).ValueEqual("longitude", 22)
+}
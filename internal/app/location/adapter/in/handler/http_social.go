@@ -0,0 +1,176 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+func (h *HTTPHandler) followUser(w http.ResponseWriter, r *http.Request) {
+	var dto *port.SocialServiceFollowUserRequest
+
+	if err := util.DecodeBody(r, &dto); err != nil || dto == nil {
+		status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+		util.Respond(w, status, body)
+		return
+	}
+	dto.Username = chi.URLParam(r, "username")
+
+	res, err := h.socialService.FollowUser(r.Context(), *dto)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusCreated, res)
+}
+
+// unfollowUser deletes a follow request of the user whether it is accepted or not.
+func (h *HTTPHandler) unfollowUser(w http.ResponseWriter, r *http.Request) {
+	err := h.socialService.DeleteFollow(r.Context(), port.SocialServiceDeleteFollowRequest{
+		Follower: chi.URLParam(r, "username"),
+		Followee: chi.URLParam(r, "followee"),
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusNoContent, nil)
+}
+
+func (h *HTTPHandler) acceptFollower(w http.ResponseWriter, r *http.Request) {
+	res, err := h.socialService.AcceptFollower(r.Context(), port.SocialServiceAcceptFollowerRequest{
+		Username: chi.URLParam(r, "username"),
+		Follower: chi.URLParam(r, "follower"),
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+// removeFollower deletes a follow request to the user, it revokes accepted requests and declines pending ones.
+func (h *HTTPHandler) removeFollower(w http.ResponseWriter, r *http.Request) {
+	err := h.socialService.DeleteFollow(r.Context(), port.SocialServiceDeleteFollowRequest{
+		Follower: chi.URLParam(r, "follower"),
+		Followee: chi.URLParam(r, "username"),
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusNoContent, nil)
+}
+
+type listFollowsDTO struct {
+	Status    string `schema:"status"`
+	PageToken string `schema:"page_token"`
+	PageSize  int    `schema:"page_size"`
+}
+
+func (h *HTTPHandler) listFollowing(w http.ResponseWriter, r *http.Request) {
+	h.listFollows(w, r, h.socialService.ListFollowing)
+}
+
+func (h *HTTPHandler) listFollowers(w http.ResponseWriter, r *http.Request) {
+	h.listFollows(w, r, h.socialService.ListFollowers)
+}
+
+// listFollows lists follows of the user with given service method.
+func (h *HTTPHandler) listFollows(
+	w http.ResponseWriter,
+	r *http.Request,
+	list func(ctx context.Context, req port.SocialServiceListFollowsRequest) (port.SocialServiceListFollowsResponse, error),
+) {
+	var dto listFollowsDTO
+
+	if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := list(r.Context(), port.SocialServiceListFollowsRequest{
+		Username:  chi.URLParam(r, "username"),
+		Status:    domain.FollowStatus(dto.Status),
+		PageToken: dto.PageToken,
+		PageSize:  dto.PageSize,
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+func (h *HTTPHandler) blockUser(w http.ResponseWriter, r *http.Request) {
+	res, err := h.socialService.BlockUser(r.Context(), port.SocialServiceBlockUserRequest{
+		Username: chi.URLParam(r, "username"),
+		Blocked:  chi.URLParam(r, "blocked"),
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+func (h *HTTPHandler) unblockUser(w http.ResponseWriter, r *http.Request) {
+	err := h.socialService.UnblockUser(r.Context(), port.SocialServiceBlockUserRequest{
+		Username: chi.URLParam(r, "username"),
+		Blocked:  chi.URLParam(r, "blocked"),
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusNoContent, nil)
+}
+
+type listBlocksDTO struct {
+	PageToken string `schema:"page_token"`
+	PageSize  int    `schema:"page_size"`
+}
+
+func (h *HTTPHandler) listBlocks(w http.ResponseWriter, r *http.Request) {
+	var dto listBlocksDTO
+
+	if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.socialService.ListBlocks(r.Context(), port.SocialServiceListBlocksRequest{
+		Username:  chi.URLParam(r, "username"),
+		PageToken: dto.PageToken,
+		PageSize:  dto.PageSize,
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
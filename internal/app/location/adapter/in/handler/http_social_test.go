@@ -0,0 +1,225 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/gavv/httpexpect"
+	"github.com/golang/mock/gomock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+)
+
+func (s *HTTPHandleTestSuite) TestSocialGraph() {
+	pending := domain.Follow{FollowerID: 1, Follower: "user1", FolloweeID: 2, Followee: "user2", Status: domain.FollowPending}
+	accepted := pending
+	accepted.Status = domain.FollowAccepted
+
+	testCases := []struct {
+		name           string
+		buildStubs     func(repo *mock.MockSocialRepository)
+		method         string
+		path           string
+		query          map[string]interface{}
+		body           interface{}
+		expectedStatus int
+		assert         func(res *httpexpect.Response)
+	}{
+		{
+			name: "Follow_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					CreateFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+					Times(1).
+					Return(pending, nil)
+			},
+			method:         http.MethodPost,
+			path:           "/users/user1/following",
+			body:           map[string]interface{}{"followee": "user2"},
+			expectedStatus: http.StatusCreated,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().
+					ValueEqual("follower", "user1").
+					ValueEqual("followee", "user2").
+					ValueEqual("status", "pending")
+			},
+		},
+		{
+			name: "Follow_Self",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateFollow(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodPost,
+			path:           "/users/user1/following",
+			body:           map[string]interface{}{"followee": "user1"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Follow_Blocked",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateFollow(gomock.Any(), gomock.Any()).Times(1).Return(domain.Follow{}, errpack.ErrFailedPrecondition)
+			},
+			method:         http.MethodPost,
+			path:           "/users/user1/following",
+			body:           map[string]interface{}{"followee": "user2"},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name: "Accept_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					AcceptFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+					Times(1).
+					Return(accepted, nil)
+			},
+			method:         http.MethodPost,
+			path:           "/users/user2/followers/user1/accept",
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().ValueEqual("status", "accepted")
+			},
+		},
+		{
+			name: "Accept_NotFound",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().AcceptFollow(gomock.Any(), gomock.Any()).Times(1).Return(domain.Follow{}, errpack.ErrNotFound)
+			},
+			method:         http.MethodPost,
+			path:           "/users/user2/followers/user1/accept",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "Unfollow_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					DeleteFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+					Times(1).
+					Return(nil)
+			},
+			method:         http.MethodDelete,
+			path:           "/users/user1/following/user2",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "RemoveFollower_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					DeleteFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+					Times(1).
+					Return(nil)
+			},
+			method:         http.MethodDelete,
+			path:           "/users/user2/followers/user1",
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name: "ListFollowers_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					ListFollowers(gomock.Any(), gomock.Eq(port.SocialRepositoryListFollowsRequest{
+						Username: "user2",
+						Status:   domain.FollowPending,
+						PageSize: 10,
+					})).
+					Times(1).
+					Return(port.SocialRepositoryListFollowsResponse{Follows: []domain.Follow{pending}}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users/user2/followers",
+			query:          map[string]interface{}{"status": "pending", "page_size": 10},
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				follows := res.JSON().Object().Value("follows").Array()
+				follows.Length().Equal(1)
+				follows.Element(0).Object().ValueEqual("follower", "user1")
+			},
+		},
+		{
+			name: "ListFollowing_InvalidStatus",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().ListFollowing(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodGet,
+			path:           "/users/user1/following",
+			query:          map[string]interface{}{"status": "rejected", "page_size": 10},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Block_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					CreateBlock(gomock.Any(), gomock.Eq(port.SocialRepositoryBlockRequest{Blocker: "user2", Blocked: "user1"})).
+					Times(1).
+					Return(domain.Block{BlockerID: 2, Blocker: "user2", BlockedID: 1, Blocked: "user1"}, nil)
+			},
+			method:         http.MethodPut,
+			path:           "/users/user2/blocks/user1",
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().ValueEqual("blocker", "user2").ValueEqual("blocked", "user1")
+			},
+		},
+		{
+			name: "Unblock_NotFound",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().DeleteBlock(gomock.Any(), gomock.Any()).Times(1).Return(errpack.ErrNotFound)
+			},
+			method:         http.MethodDelete,
+			path:           "/users/user2/blocks/user1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "ListBlocks_OK",
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					ListBlocks(gomock.Any(), gomock.Eq(port.SocialRepositoryListBlocksRequest{Username: "user2", PageSize: 10})).
+					Times(1).
+					Return(port.SocialRepositoryListBlocksResponse{}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users/user2/blocks",
+			query:          map[string]interface{}{"page_size": 10},
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().Value("blocks").Array().Empty()
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.Run(tc.name, func() {
+			ctrl := gomock.NewController(s.T())
+			defer ctrl.Finish()
+
+			logger := log.NewTestingLogger()
+
+			repo := mock.NewMockSocialRepository(ctrl)
+			tc.buildStubs(repo)
+
+			svc := mock.NewMockUserService(ctrl)
+			ss := service.NewSocialService(repo, logger)
+
+			server := httptest.NewServer(handler.NewHTTPHandler(svc, mock.NewMockGeofenceService(ctrl), mock.NewMockErasureService(ctrl), ss, handler.HTTPHandlerConfig{}, logger))
+			defer server.Close()
+
+			e := httpexpect.New(s.T(), server.URL)
+
+			req := e.Request(tc.method, tc.path).WithQueryObject(tc.query)
+			if tc.body != nil {
+				req = req.WithJSON(tc.body)
+			}
+			res := req.Expect()
+
+			res.Status(tc.expectedStatus)
+			if tc.assert != nil {
+				tc.assert(res)
+			}
+		})
+	}
+}
@@ -8,17 +8,21 @@ import (
   "github.com/golang/mock/gomock"
   "github.com/stretchr/testify/suite"
   "gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+  "gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+  "gitlab.com/spacewalker/geotracker/internal/pkg/auth"
   "gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
   "gitlab.com/spacewalker/geotracker/internal/pkg/geo"
   "gitlab.com/spacewalker/geotracker/internal/pkg/log"
+  "gitlab.com/spacewalker/geotracker/internal/pkg/middleware"
   "gitlab.com/spacewalker/geotracker/internal/pkg/util/testutil"
   "net/http"
   "net/http/httptest"
   "testing"
+  "time"
 )
 
 type HTTPHandleTestSuite struct {
@@ -45,6 +49,8 @@ func (s *HTTPHandleTestSuite) TestSetUserLocation() {
   )
   latitude := testutil.RandomLatitude()
   longitude := testutil.RandomLongitude()
+  recordedAt := time.Now().UTC().Truncate(time.Second)
+  geohash := geo.EncodeGeohash(geo.Point{longitude, latitude}, geo.MaxGeohashPrecision)
 
   buildStubsNoCallExpected := func(repo *mock.MockUserRepository) {
     repo.EXPECT().
@@ -72,7 +78,7 @@ func (s *HTTPHandleTestSuite) TestSetUserLocation() {
       name: "OK",
       buildStubs: func(repo *mock.MockUserRepository) {
         repo.EXPECT().
-          SetUserLocation(gomock.Any(), gomock.Eq(port.UserRepositorySetUserLocationRequest{
+          SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
             Username: username,
             Point:    geo.Trunc(geo.Point{longitude, latitude}),
           })).
@@ -83,7 +89,8 @@ func (s *HTTPHandleTestSuite) TestSetUserLocation() {
             },
             PrevLocation: domain.Location{},
             Location: domain.Location{
-              Point: geo.Point{longitude, latitude},
+              Point:      geo.Point{longitude, latitude},
+              RecordedAt: recordedAt,
             },
           }, nil)
       },
@@ -93,9 +100,193 @@ func (s *HTTPHandleTestSuite) TestSetUserLocation() {
         "longitude": longitude,
       },
       expectedStatus: 200,
-      expectedResponse: map[string]interface{}{
+      expectedResponse: port.UserServiceSetUserLocationResponse{
+        Latitude:   latitude,
+        Longitude:  longitude,
+        Geohash:    geohash,
+        RecordedAt: recordedAt,
+      },
+    },
+    {
+      name: "OK with geohash",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+            Username: username,
+            Point:    geo.Point{-5.60302734, 42.60498046},
+          })).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{
+            User: domain.User{
+              Username: username,
+            },
+            Location: domain.Location{
+              Point:      geo.Point{-5.60302734, 42.60498046},
+              RecordedAt: recordedAt,
+            },
+          }, nil)
+      },
+      pathArgs: []interface{}{username},
+      body: map[string]interface{}{
+        "geohash": "ezs42",
+      },
+      expectedStatus: 200,
+      expectedResponse: port.UserServiceSetUserLocationResponse{
+        Latitude:   42.60498046,
+        Longitude:  -5.60302734,
+        Geohash:    geo.EncodeGeohash(geo.Point{-5.60302734, 42.60498046}, geo.MaxGeohashPrecision),
+        RecordedAt: recordedAt,
+      },
+    },
+    {
+      name:       "invalid geohash",
+      buildStubs: buildStubsNoCallExpected,
+      pathArgs:   []interface{}{username},
+      body: map[string]interface{}{
+        "geohash": "ezs4a",
+      },
+      expectedStatus:   http.StatusBadRequest,
+      expectedResponse: invalidArguentResponse,
+    },
+    {
+      name:       "geohash with coordinates",
+      buildStubs: buildStubsNoCallExpected,
+      pathArgs:   []interface{}{username},
+      body: map[string]interface{}{
+        "latitude":  latitude,
         "longitude": longitude,
+        "geohash":   "ezs42",
+      },
+      expectedStatus:   http.StatusBadRequest,
+      expectedResponse: invalidArguentResponse,
+    },
+    {
+      name: "OK with recorded_at",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+            Username:   username,
+            Point:      geo.Trunc(geo.Point{longitude, latitude}),
+            RecordedAt: recordedAt.Add(-time.Hour),
+          })).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{
+            User: domain.User{
+              Username: username,
+            },
+            Location: domain.Location{
+              Point:      geo.Point{longitude, latitude},
+              RecordedAt: recordedAt.Add(-time.Hour),
+            },
+          }, nil)
+      },
+      pathArgs: []interface{}{username},
+      body: map[string]interface{}{
+        "latitude":    latitude,
+        "longitude":   longitude,
+        "recorded_at": recordedAt.Add(-time.Hour),
+      },
+      expectedStatus: 200,
+      expectedResponse: port.UserServiceSetUserLocationResponse{
+        Latitude:   latitude,
+        Longitude:  longitude,
+        Geohash:    geohash,
+        RecordedAt: recordedAt.Add(-time.Hour),
+      },
+    },
+    {
+      name: "OK with fix metadata",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        accuracy, bearing := 12.5, 90.0
+        metadata := geo.FixMetadata{Accuracy: &accuracy, Bearing: &bearing, Source: geo.FixSourceNetwork}
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+            Username:    username,
+            Point:       geo.Trunc(geo.Point{longitude, latitude}),
+            FixMetadata: metadata,
+          })).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{
+            User: domain.User{
+              Username: username,
+            },
+            Location: domain.Location{
+              Point:       geo.Point{longitude, latitude},
+              RecordedAt:  recordedAt,
+              FixMetadata: metadata,
+            },
+          }, nil)
+      },
+      pathArgs: []interface{}{username},
+      body: map[string]interface{}{
         "latitude":  latitude,
+        "longitude": longitude,
+        "accuracy":  12.5,
+        "bearing":   90.0,
+        "source":    "network",
+      },
+      expectedStatus: 200,
+      expectedResponse: port.UserServiceSetUserLocationResponse{
+        Latitude:   latitude,
+        Longitude:  longitude,
+        Geohash:    geohash,
+        RecordedAt: recordedAt,
+        FixMetadata: geo.FixMetadata{
+          Accuracy: &[]float64{12.5}[0],
+          Bearing:  &[]float64{90}[0],
+          Source:   geo.FixSourceNetwork,
+        },
+      },
+    },
+    {
+      name:       "invalid fix source",
+      buildStubs: buildStubsNoCallExpected,
+      pathArgs:   []interface{}{username},
+      body: map[string]interface{}{
+        "latitude":  latitude,
+        "longitude": longitude,
+        "source":    "satellite",
+      },
+      expectedStatus:   http.StatusBadRequest,
+      expectedResponse: invalidArguentResponse,
+    },
+    {
+      name:       "recorded_at too far in the future",
+      buildStubs: buildStubsNoCallExpected,
+      pathArgs:   []interface{}{username},
+      body: map[string]interface{}{
+        "latitude":    latitude,
+        "longitude":   longitude,
+        "recorded_at": recordedAt.Add(time.Hour),
+      },
+      expectedStatus:   http.StatusBadRequest,
+      expectedResponse: invalidArguentResponse,
+    },
+    {
+      name: "out of order",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocation(gomock.Any(), gomock.Any()).
+          Times(1).
+          Return(port.UserRepositorySetUserLocationResponse{
+            User:       domain.User{Username: username},
+            Location:   domain.Location{Point: geo.Point{longitude, latitude}, RecordedAt: recordedAt},
+            OutOfOrder: true,
+          }, nil)
+      },
+      pathArgs: []interface{}{username},
+      body: map[string]interface{}{
+        "latitude":    latitude,
+        "longitude":   longitude,
+        "recorded_at": recordedAt.Add(-time.Hour),
+      },
+      expectedStatus: http.StatusUnprocessableEntity,
+      expectedResponse: map[string]interface{}{
+        "error": map[string]interface{}{
+          "code":    422,
+          "message": "failed precondition: location is recorded before the stored one",
+          "status":  "FAILED_PRECONDITION",
+        },
       },
     },
     {
@@ -240,9 +431,12 @@ func (s *HTTPHandleTestSuite) TestSetUserLocation() {
       hc := mock.NewMockHistoryClient(ctrl)
       hc.EXPECT().AddRecord(gomock.Any(), gomock.Any()).AnyTimes()
 
-      svc := service.NewUserService(repo, hc, logger)
+      gs := mock.NewMockGeofenceService(ctrl)
+      gs.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+      svc := service.NewUserService(repo, hc, gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, logger)
 
-      h := handler.NewHTTPHandler(svc, logger)
+      h := handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger)
 
       server := httptest.NewServer(h)
       defer server.Close()
@@ -275,6 +469,561 @@ func (s *HTTPHandleTestSuite) TestSetUserLocation() {
   }
 }
 
+func (s *HTTPHandleTestSuite) TestSetUserLocation_Authorization() {
+  path := "/users/{username}/location"
+  secret := []byte(testutil.RandomString(32, 32, testutil.UsernameCharacterSet))
+  token := func(subject string, roles ...string) string {
+    return testutil.SignJWT(s.T(), secret, map[string]interface{}{
+      "sub":   subject,
+      "exp":   time.Now().Add(time.Hour).Unix(),
+      "roles": roles,
+    })
+  }
+
+  testCases := []struct {
+    name           string
+    username       string
+    token          string
+    expectedCalls  int
+    expectedStatus int
+  }{
+    {
+      name:           "OK",
+      username:       "user1",
+      token:          token("user1"),
+      expectedCalls:  1,
+      expectedStatus: http.StatusOK,
+    },
+    {
+      name:           "OK admin",
+      username:       "user1",
+      token:          token("admin", "admin"),
+      expectedCalls:  1,
+      expectedStatus: http.StatusOK,
+    },
+    {
+      name:           "other user",
+      username:       "user1",
+      token:          token("user2"),
+      expectedStatus: http.StatusForbidden,
+    },
+    {
+      name:           "internal service",
+      username:       "user1",
+      token:          token("history", "internal-service"),
+      expectedStatus: http.StatusForbidden,
+    },
+    {
+      name:           "no credentials",
+      username:       "user1",
+      expectedStatus: http.StatusUnauthorized,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      logger := log.NewTestingLogger()
+
+      repo := mock.NewMockUserRepository(ctrl)
+      repo.EXPECT().
+        SetUserLocation(gomock.Any(), gomock.Any()).
+        Times(tc.expectedCalls).
+        Return(port.UserRepositorySetUserLocationResponse{
+          User:     domain.User{Username: tc.username},
+          Location: domain.Location{RecordedAt: time.Now()},
+        }, nil)
+
+      hc := mock.NewMockHistoryClient(ctrl)
+      hc.EXPECT().AddRecord(gomock.Any(), gomock.Any()).AnyTimes()
+
+      gs := mock.NewMockGeofenceService(ctrl)
+      gs.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+      svc := service.NewUserService(repo, hc, gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, logger)
+
+      h := handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger)
+
+      authenticator, err := auth.NewJWTAuthenticator(auth.JWTConfig{HS256Secret: secret})
+      s.Require().NoError(err)
+
+      server := httptest.NewServer(middleware.AuthMiddleware(authenticator)(h))
+      defer server.Close()
+
+      e := httpexpect.New(s.T(), server.URL)
+
+      req := e.PUT(path, tc.username).WithJSON(map[string]interface{}{
+        "latitude":  testutil.RandomLatitude(),
+        "longitude": testutil.RandomLongitude(),
+      })
+      if tc.token != "" {
+        req = req.WithHeader("Authorization", "Bearer "+tc.token)
+      }
+
+      res := req.Expect()
+
+      res.Status(tc.expectedStatus)
+      if tc.expectedStatus == http.StatusForbidden {
+        res.JSON().Path("$.error.status").Equal("PERMISSION_DENIED")
+      }
+    })
+  }
+}
+
+func (s *HTTPHandleTestSuite) TestCORSPreflight_Credentials() {
+  ctrl := gomock.NewController(s.T())
+  defer ctrl.Finish()
+
+  logger := log.NewTestingLogger()
+  svc := mock.NewMockUserService(ctrl)
+  gs := mock.NewMockGeofenceService(ctrl)
+  h := handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger)
+
+  for _, header := range []string{"Authorization", "X-API-Key"} {
+    req := httptest.NewRequest(http.MethodOptions, "/users/user1/location", nil)
+    req.Header.Set("Origin", "https://example.com")
+    req.Header.Set("Access-Control-Request-Method", http.MethodPut)
+    req.Header.Set("Access-Control-Request-Headers", header)
+    rec := httptest.NewRecorder()
+
+    h.ServeHTTP(rec, req)
+
+    s.Require().Equal("*", rec.Header().Get("Access-Control-Allow-Origin"), header)
+    s.Require().Equal(http.CanonicalHeaderKey(header), rec.Header().Get("Access-Control-Allow-Headers"))
+  }
+}
+
+func (s *HTTPHandleTestSuite) TestListUsersInArea() {
+  path := "/users/area"
+  user := domain.LocatedUser{
+    User: domain.User{
+      ID:       testutil.RandomInt(1, 100),
+      Username: testutil.RandomUsername(),
+    },
+    Point: geo.Point{179.5, 1},
+  }
+  polygon := geo.Polygon{{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}, {170, 0}}}
+
+  invalidArguentResponse := map[string]interface{}{
+    "error": map[string]interface{}{
+      "code":    400,
+      "message": "invalid argument",
+      "status":  "INVALID_ARGUMENT",
+    },
+  }
+
+  testCases := []struct {
+    name           string
+    buildStubs     func(repo *mock.MockUserRepository)
+    method         string
+    query          map[string]interface{}
+    body           interface{}
+    expectedStatus int
+    expectedUsers  int
+    expectedBody   interface{}
+  }{
+    {
+      name: "OK_BBox",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListUsersInBBox(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInBBoxRequest{
+            BBox:     geo.BBox{170, -10, -170, 10},
+            PageSize: 10,
+          })).
+          Times(1).
+          Return(port.UserRepositoryListUsersInAreaResponse{
+            Users: []domain.LocatedUser{user},
+          }, nil)
+      },
+      method:         http.MethodGet,
+      query:          map[string]interface{}{"bbox": "170,-10,-170,10", "page_size": 10},
+      expectedStatus: http.StatusOK,
+      expectedUsers:  1,
+    },
+    {
+      name: "OK_BBox_MaxAge",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListUsersInBBox(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInBBoxRequest{
+            BBox:     geo.BBox{170, -10, -170, 10},
+            PageSize: 10,
+            MaxAge:   10 * time.Minute,
+          })).
+          Times(1).
+          Return(port.UserRepositoryListUsersInAreaResponse{
+            Users: []domain.LocatedUser{user},
+          }, nil)
+      },
+      method:         http.MethodGet,
+      query:          map[string]interface{}{"bbox": "170,-10,-170,10", "page_size": 10, "max_age": 600},
+      expectedStatus: http.StatusOK,
+      expectedUsers:  1,
+    },
+    {
+      name: "OK_BBox_ConnectionsScope",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListUsersInBBox(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInBBoxRequest{
+            BBox:          geo.BBox{170, -10, -170, 10},
+            PageSize:      10,
+            ConnectionsOf: "user1",
+            Caller:        "user1",
+          })).
+          Times(1).
+          Return(port.UserRepositoryListUsersInAreaResponse{
+            Users: []domain.LocatedUser{user},
+          }, nil)
+      },
+      method:         http.MethodGet,
+      query:          map[string]interface{}{"bbox": "170,-10,-170,10", "page_size": 10, "scope": "connections", "caller": "user1"},
+      expectedStatus: http.StatusOK,
+      expectedUsers:  1,
+    },
+    {
+      name: "InvalidMaxAge",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+      },
+      method:         http.MethodGet,
+      query:          map[string]interface{}{"bbox": "170,-10,-170,10", "page_size": 10, "max_age": -1},
+      expectedStatus: http.StatusBadRequest,
+      expectedBody:   invalidArguentResponse,
+    },
+    {
+      name: "InvalidBBox",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+      },
+      method:         http.MethodGet,
+      query:          map[string]interface{}{"bbox": "170,10,-170,-10", "page_size": 10},
+      expectedStatus: http.StatusBadRequest,
+      expectedBody:   invalidArguentResponse,
+    },
+    {
+      name: "MissingBBox",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+      },
+      method:         http.MethodGet,
+      query:          map[string]interface{}{"page_size": 10},
+      expectedStatus: http.StatusBadRequest,
+      expectedBody:   invalidArguentResponse,
+    },
+    {
+      name: "OK_Polygon",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          ListUsersInPolygon(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInPolygonRequest{
+            Polygon:  polygon,
+            PageSize: 10,
+          })).
+          Times(1).
+          Return(port.UserRepositoryListUsersInAreaResponse{
+            Users: []domain.LocatedUser{user},
+          }, nil)
+      },
+      method: http.MethodPost,
+      query:  map[string]interface{}{"page_size": 10},
+      body: map[string]interface{}{
+        "type":        "Polygon",
+        "coordinates": polygon,
+      },
+      expectedStatus: http.StatusOK,
+      expectedUsers:  1,
+    },
+    {
+      name: "NotAPolygon",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().ListUsersInPolygon(gomock.Any(), gomock.Any()).Times(0)
+      },
+      method: http.MethodPost,
+      query:  map[string]interface{}{"page_size": 10},
+      body: map[string]interface{}{
+        "type":        "Point",
+        "coordinates": []float64{0, 0},
+      },
+      expectedStatus: http.StatusBadRequest,
+      expectedBody:   invalidArguentResponse,
+    },
+    {
+      name: "PolygonNotClosed",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().ListUsersInPolygon(gomock.Any(), gomock.Any()).Times(0)
+      },
+      method: http.MethodPost,
+      query:  map[string]interface{}{"page_size": 10},
+      body: map[string]interface{}{
+        "type":        "Polygon",
+        "coordinates": geo.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+      },
+      expectedStatus: http.StatusBadRequest,
+      expectedBody:   invalidArguentResponse,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      logger := log.NewTestingLogger()
+
+      repo := mock.NewMockUserRepository(ctrl)
+      tc.buildStubs(repo)
+
+      gs := mock.NewMockGeofenceService(ctrl)
+
+      svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, logger)
+
+      server := httptest.NewServer(handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger))
+      defer server.Close()
+
+      e := httpexpect.New(s.T(), server.URL)
+
+      req := e.Request(tc.method, path).WithQueryObject(tc.query)
+      if tc.body != nil {
+        req = req.WithJSON(tc.body)
+      }
+
+      res := req.Expect()
+
+      res.Status(tc.expectedStatus)
+      if tc.expectedBody != nil {
+        var b bytes.Buffer
+        if err := json.NewEncoder(&b).Encode(tc.expectedBody); err != nil {
+          s.T().Fatal(err)
+        }
+        res.Body().Equal(b.String())
+      } else {
+        users := res.JSON().Object().Value("users").Array()
+        users.Length().Equal(tc.expectedUsers)
+        users.Element(0).Object().ValueEqual("username", user.Username)
+        users.Element(0).Object().ValueEqual("point", []float64{179.5, 1})
+        users.Element(0).Object().ValueEqual("geohash", geo.EncodeGeohash(geo.Point{179.5, 1}, geo.MaxGeohashPrecision))
+      }
+    })
+  }
+}
+
+func (s *HTTPHandleTestSuite) TestSetUserLocations() {
+  path := "/users/locations"
+  user := domain.User{ID: 1, Username: testutil.RandomUsername()}
+  point := geo.Trunc(geo.Point{testutil.RandomLongitude(), testutil.RandomLatitude()})
+  timestamp := time.Now().UTC()
+
+  testCases := []struct {
+    name           string
+    buildStubs     func(repo *mock.MockUserRepository)
+    body           interface{}
+    expectedStatus int
+    assert         func(res *httpexpect.Response)
+  }{
+    {
+      name: "OK",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocations(gomock.Any(), gomock.Eq([]port.UserRepositorySetUserLocationRequest{
+            {Username: user.Username, Point: point, RecordedAt: timestamp},
+          })).
+          Times(1).
+          Return([]port.UserRepositorySetUserLocationResponse{
+            {User: user, Location: domain.Location{UserID: user.ID, Point: point}},
+          }, nil)
+      },
+      body: map[string]interface{}{
+        "locations": []interface{}{
+          map[string]interface{}{
+            "username":  user.Username,
+            "longitude": point.Longitude(),
+            "latitude":  point.Latitude(),
+            "timestamp": timestamp,
+          },
+          map[string]interface{}{
+            "username":  user.Username,
+            "longitude": point.Longitude(),
+            "latitude":  91,
+            "timestamp": timestamp,
+          },
+        },
+      },
+      expectedStatus: http.StatusOK,
+      assert: func(res *httpexpect.Response) {
+        results := res.JSON().Object().Value("results").Array()
+        results.Length().Equal(2)
+        results.Element(0).Object().ValueEqual("longitude", point.Longitude())
+        results.Element(1).Object().Path("$.error.status").Equal("INVALID_ARGUMENT")
+      },
+    },
+    {
+      name: "OK track",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocations(gomock.Any(), gomock.Eq([]port.UserRepositorySetUserLocationRequest{
+            {Username: user.Username, Point: geo.Trunc(geo.Point{-120.2, 38.5}), RecordedAt: timestamp.Add(-2 * time.Minute)},
+            {Username: user.Username, Point: geo.Trunc(geo.Point{-120.95, 40.7}), RecordedAt: timestamp.Add(-time.Minute)},
+            {Username: user.Username, Point: point, RecordedAt: timestamp},
+          })).
+          Times(1).
+          Return([]port.UserRepositorySetUserLocationResponse{
+            {User: user, Location: domain.Location{UserID: user.ID, Point: geo.Point{-120.2, 38.5}}},
+            {User: user, Location: domain.Location{UserID: user.ID, Point: geo.Point{-120.95, 40.7}}},
+            {User: user, Location: domain.Location{UserID: user.ID, Point: point}},
+          }, nil)
+      },
+      body: map[string]interface{}{
+        "locations": []interface{}{
+          map[string]interface{}{
+            "username":  user.Username,
+            "longitude": point.Longitude(),
+            "latitude":  point.Latitude(),
+            "timestamp": timestamp,
+          },
+        },
+        "tracks": []interface{}{
+          map[string]interface{}{
+            "username":   user.Username,
+            "polyline":   "_p~iF~ps|U_ulLnnqC",
+            "timestamps": []time.Time{timestamp.Add(-2 * time.Minute), timestamp.Add(-time.Minute)},
+          },
+        },
+      },
+      expectedStatus: http.StatusOK,
+      assert: func(res *httpexpect.Response) {
+        results := res.JSON().Object().Value("results").Array()
+        results.Length().Equal(3)
+        results.Element(1).Object().ValueEqual("longitude", -120.2).ValueEqual("latitude", 38.5)
+        results.Element(2).Object().ValueEqual("longitude", -120.95).ValueEqual("latitude", 40.7)
+      },
+    },
+    {
+      name: "OK track with precision 6",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().
+          SetUserLocations(gomock.Any(), gomock.Eq([]port.UserRepositorySetUserLocationRequest{
+            {Username: user.Username, Point: geo.Trunc(geo.Point{-120.2, 38.5}), RecordedAt: timestamp},
+          })).
+          Times(1).
+          Return([]port.UserRepositorySetUserLocationResponse{
+            {User: user, Location: domain.Location{UserID: user.ID, Point: geo.Point{-120.2, 38.5}}},
+          }, nil)
+      },
+      body: map[string]interface{}{
+        "tracks": []interface{}{
+          map[string]interface{}{
+            "username":   user.Username,
+            "polyline":   "_izlhA~rlgdF",
+            "precision":  6,
+            "timestamps": []time.Time{timestamp},
+          },
+        },
+      },
+      expectedStatus: http.StatusOK,
+      assert: func(res *httpexpect.Response) {
+        res.JSON().Object().Value("results").Array().Length().Equal(1)
+      },
+    },
+    {
+      name: "track with missing timestamps",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+      },
+      body: map[string]interface{}{
+        "tracks": []interface{}{
+          map[string]interface{}{
+            "username":   user.Username,
+            "polyline":   "_p~iF~ps|U_ulLnnqC",
+            "timestamps": []time.Time{timestamp},
+          },
+        },
+      },
+      expectedStatus: http.StatusBadRequest,
+    },
+    {
+      name: "invalid polyline",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+      },
+      body: map[string]interface{}{
+        "tracks": []interface{}{
+          map[string]interface{}{
+            "username":   user.Username,
+            "polyline":   "_p~iF~ps|",
+            "timestamps": []time.Time{timestamp},
+          },
+        },
+      },
+      expectedStatus: http.StatusBadRequest,
+    },
+    {
+      name: "unsupported polyline precision",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+      },
+      body: map[string]interface{}{
+        "tracks": []interface{}{
+          map[string]interface{}{
+            "username":   user.Username,
+            "polyline":   "_p~iF~ps|U",
+            "precision":  7,
+            "timestamps": []time.Time{timestamp},
+          },
+        },
+      },
+      expectedStatus: http.StatusBadRequest,
+    },
+    {
+      name: "empty batch",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+      },
+      body:           map[string]interface{}{"locations": []interface{}{}},
+      expectedStatus: http.StatusBadRequest,
+    },
+    {
+      name: "invalid request body",
+      buildStubs: func(repo *mock.MockUserRepository) {
+        repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+      },
+      body:           "invalid",
+      expectedStatus: http.StatusBadRequest,
+    },
+  }
+
+  for _, tc := range testCases {
+    tc := tc
+    s.Run(tc.name, func() {
+      ctrl := gomock.NewController(s.T())
+      defer ctrl.Finish()
+
+      logger := log.NewTestingLogger()
+
+      repo := mock.NewMockUserRepository(ctrl)
+      tc.buildStubs(repo)
+
+      gs := mock.NewMockGeofenceService(ctrl)
+      gs.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+      svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, logger)
+
+      server := httptest.NewServer(handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger))
+      defer server.Close()
+
+      e := httpexpect.New(s.T(), server.URL)
+
+      res := e.POST(path).WithJSON(tc.body).Expect()
+
+      res.Status(tc.expectedStatus)
+      if tc.assert != nil {
+        tc.assert(res)
+      }
+    })
+  }
+}
+
 func TestHTTPHandlerTestSuite(t *testing.T) {
   suite.Run(t, new(HTTPHandleTestSuite))
 }
@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+func (h *HTTPHandler) createUser(w http.ResponseWriter, r *http.Request) {
+	var dto *port.UserServiceCreateUserRequest
+
+	if err := util.DecodeBody(r, &dto); err != nil || dto == nil {
+		status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.service.CreateUser(r.Context(), *dto)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusCreated, res)
+}
+
+func (h *HTTPHandler) getUser(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.GetUser(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+func (h *HTTPHandler) renameUser(w http.ResponseWriter, r *http.Request) {
+	var dto *port.UserServiceRenameUserRequest
+
+	if err := util.DecodeBody(r, &dto); err != nil || dto == nil {
+		status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+		util.Respond(w, status, body)
+		return
+	}
+	dto.Username = chi.URLParam(r, "username")
+
+	res, err := h.service.RenameUser(r.Context(), *dto)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+func (h *HTTPHandler) deleteUser(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.DeleteUser(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	// History of the user is erased asynchronously, the erasure job is returned to track it.
+	util.Respond(w, http.StatusAccepted, res)
+}
+
+func (h *HTTPHandler) getPrivacySettings(w http.ResponseWriter, r *http.Request) {
+	res, err := h.service.GetPrivacySettings(r.Context(), chi.URLParam(r, "username"))
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+func (h *HTTPHandler) setPrivacySettings(w http.ResponseWriter, r *http.Request) {
+	var dto *port.UserServiceSetPrivacySettingsRequest
+
+	if err := util.DecodeBody(r, &dto); err != nil || dto == nil {
+		status, body := errpack.ErrToHTTP(errpack.ErrInvalidArgument)
+		util.Respond(w, status, body)
+		return
+	}
+	dto.Username = chi.URLParam(r, "username")
+
+	res, err := h.service.SetPrivacySettings(r.Context(), *dto)
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
+
+type listUsersDTO struct {
+	Prefix    string `schema:"prefix"`
+	PageToken string `schema:"page_token"`
+	PageSize  int    `schema:"page_size"`
+}
+
+func (h *HTTPHandler) listUsers(w http.ResponseWriter, r *http.Request) {
+	var dto listUsersDTO
+
+	if err := schemaDecoder.Decode(&dto, r.URL.Query()); err != nil {
+		status, body := errpack.ErrToHTTP(fmt.Errorf("%w", errpack.ErrInvalidArgument))
+		util.Respond(w, status, body)
+		return
+	}
+
+	res, err := h.service.ListUsers(r.Context(), port.UserServiceListUsersRequest{
+		Prefix:    dto.Prefix,
+		PageToken: dto.PageToken,
+		PageSize:  dto.PageSize,
+	})
+	if err != nil {
+		status, body := errpack.ErrToHTTP(err)
+		util.Respond(w, status, body)
+		return
+	}
+
+	util.Respond(w, http.StatusOK, res)
+}
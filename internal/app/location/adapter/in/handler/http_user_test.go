@@ -0,0 +1,274 @@
+package handler_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/gavv/httpexpect"
+	"github.com/golang/mock/gomock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+)
+
+func (s *HTTPHandleTestSuite) TestUsers() {
+	user := domain.User{ID: 1, Username: "user1"}
+	job := domain.ErasureJob{ID: 1, UserID: user.ID, Username: user.Username, Status: domain.ErasureJobPending}
+	location := domain.Location{
+		UserID:     user.ID,
+		Point:      geo.Point{10, 20},
+		RecordedAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:  time.Date(2021, 1, 1, 0, 0, 1, 0, time.UTC),
+	}
+
+	testCases := []struct {
+		name           string
+		buildStubs     func(repo *mock.MockUserRepository)
+		method         string
+		path           string
+		query          map[string]interface{}
+		body           interface{}
+		expectedStatus int
+		assert         func(res *httpexpect.Response)
+	}{
+		{
+			name: "Create_OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					CreateUser(gomock.Any(), gomock.Eq(port.CreateUserArg{Username: user.Username})).
+					Times(1).
+					Return(user, nil)
+			},
+			method:         http.MethodPost,
+			path:           "/users",
+			body:           map[string]interface{}{"username": user.Username},
+			expectedStatus: http.StatusCreated,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().ValueEqual("id", 1).ValueEqual("username", user.Username)
+			},
+		},
+		{
+			name: "Create_AlreadyExists",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(domain.User{}, errpack.ErrAlreadyExists)
+			},
+			method:         http.MethodPost,
+			path:           "/users",
+			body:           map[string]interface{}{"username": user.Username},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name: "Get_OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetUserWithLocation(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(port.UserRepositoryGetUserWithLocationResponse{User: user, Location: &location}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users/user1",
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				obj := res.JSON().Object()
+				obj.ValueEqual("username", user.Username).ValueEqual("last_seen_at", "2021-01-01T00:00:01Z")
+				obj.Path("$.location.point").Equal([]float64{10, 20})
+			},
+		},
+		{
+			name: "Get_NoLocation",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetUserWithLocation(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(port.UserRepositoryGetUserWithLocationResponse{User: user}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users/user1",
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				obj := res.JSON().Object()
+				obj.NotContainsKey("location").NotContainsKey("last_seen_at")
+			},
+		},
+		{
+			name: "Get_NotFound",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetUserWithLocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositoryGetUserWithLocationResponse{}, errpack.ErrNotFound)
+			},
+			method:         http.MethodGet,
+			path:           "/users/user1",
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name: "List_OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersRequest{
+						Prefix:   "user",
+						PageSize: 10,
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersResponse{Users: []domain.User{user}}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users",
+			query:          map[string]interface{}{"prefix": "user", "page_size": 10},
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Path("$.users").Array().Length().Equal(1)
+				res.JSON().Path("$.next_page_token").Equal("")
+			},
+		},
+		{
+			name: "List_InvalidPageSize",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodGet,
+			path:           "/users",
+			query:          map[string]interface{}{"page_size": "abc"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "GetPrivacy_OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetPrivacySettings(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(domain.PrivacySettings{UserID: user.ID, Level: domain.PrivacyLevelExact}, nil)
+			},
+			method:         http.MethodGet,
+			path:           "/users/user1/privacy",
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().ValueEqual("user_id", user.ID).ValueEqual("level", "exact").NotContainsKey("grid_size")
+			},
+		},
+		{
+			name: "SetPrivacy_OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					SetPrivacySettings(gomock.Any(), gomock.Eq(port.UserRepositorySetPrivacySettingsRequest{
+						Username:         user.Username,
+						Level:            domain.PrivacyLevelCoarse,
+						GeohashPrecision: 5,
+					})).
+					Times(1).
+					Return(domain.PrivacySettings{UserID: user.ID, Level: domain.PrivacyLevelCoarse, GeohashPrecision: 5}, nil)
+			},
+			method:         http.MethodPut,
+			path:           "/users/user1/privacy",
+			body:           map[string]interface{}{"level": "coarse", "geohash_precision": 5},
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().ValueEqual("level", "coarse").ValueEqual("geohash_precision", 5)
+			},
+		},
+		{
+			name: "SetPrivacy_InvalidSettings",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().SetPrivacySettings(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodPut,
+			path:           "/users/user1/privacy",
+			body:           map[string]interface{}{"level": "hidden", "grid_size": 1},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Rename_OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					RenameUser(gomock.Any(), gomock.Eq(port.UserRepositoryRenameUserRequest{
+						Username:    "user1",
+						NewUsername: "user2",
+					})).
+					Times(1).
+					Return(domain.User{ID: 1, Username: "user2"}, nil)
+			},
+			method:         http.MethodPut,
+			path:           "/users/user1",
+			body:           map[string]interface{}{"new_username": "user2"},
+			expectedStatus: http.StatusOK,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().ValueEqual("username", "user2")
+			},
+		},
+		{
+			name: "Rename_InvalidNewUsername",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().RenameUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			method:         http.MethodPut,
+			path:           "/users/user1",
+			body:           map[string]interface{}{"new_username": "u"},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name: "Delete_OK",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().DeleteUser(gomock.Any(), gomock.Eq("user1")).Times(1).Return(job, nil)
+			},
+			method:         http.MethodDelete,
+			path:           "/users/user1",
+			expectedStatus: http.StatusAccepted,
+			assert: func(res *httpexpect.Response) {
+				res.JSON().Object().ValueEqual("id", 1).ValueEqual("status", "pending")
+			},
+		},
+		{
+			name: "Delete_NotFound",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().DeleteUser(gomock.Any(), gomock.Eq("user1")).Times(1).Return(domain.ErasureJob{}, errpack.ErrNotFound)
+			},
+			method:         http.MethodDelete,
+			path:           "/users/user1",
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.Run(tc.name, func() {
+			ctrl := gomock.NewController(s.T())
+			defer ctrl.Finish()
+
+			logger := log.NewTestingLogger()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+
+			gs := service.NewGeofenceService(mock.NewMockGeofenceRepository(ctrl), logger)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), gs, broker.NewMemoryBroker(log.NewTestingLogger()), service.UserServiceConfig{}, logger)
+
+			server := httptest.NewServer(handler.NewHTTPHandler(svc, gs, mock.NewMockErasureService(ctrl), mock.NewMockSocialService(ctrl), handler.HTTPHandlerConfig{}, logger))
+			defer server.Close()
+
+			e := httpexpect.New(s.T(), server.URL)
+
+			req := e.Request(tc.method, tc.path).WithQueryObject(tc.query)
+			if tc.body != nil {
+				req = req.WithJSON(tc.body)
+			}
+
+			res := req.Expect()
+
+			res.Status(tc.expectedStatus)
+			if tc.assert != nil {
+				tc.assert(res)
+			}
+		})
+	}
+}
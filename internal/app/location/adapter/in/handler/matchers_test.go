@@ -0,0 +1,52 @@
+package handler_test
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+)
+
+type eqUserRepositorySetUserLocationRequestMatcher struct {
+	req port.UserRepositorySetUserLocationRequest
+}
+
+func (m eqUserRepositorySetUserLocationRequestMatcher) Matches(x interface{}) bool {
+	req, ok := x.(port.UserRepositorySetUserLocationRequest)
+	if !ok {
+		return false
+	}
+
+	if m.req.Username != req.Username || m.req.Point != req.Point || m.req.DisableUserAutoCreation != req.DisableUserAutoCreation {
+		return false
+	}
+
+	if !reflect.DeepEqual(m.req.FixMetadata, req.FixMetadata) {
+		return false
+	}
+
+	// Zero RecordedAt is expected to be the current time.
+	recordedAt := m.req.RecordedAt
+	if recordedAt.IsZero() {
+		recordedAt = time.Now()
+	}
+
+	diff := recordedAt.Sub(req.RecordedAt)
+	if diff < 0 {
+		diff = -diff
+	}
+
+	return diff <= time.Second
+}
+
+func EqUserRepositorySetUserLocationRequest(req port.UserRepositorySetUserLocationRequest) gomock.Matcher {
+	return eqUserRepositorySetUserLocationRequestMatcher{
+		req: req,
+	}
+}
+
+func (m eqUserRepositorySetUserLocationRequestMatcher) String() string {
+	return fmt.Sprintf("matches req %v", m.req)
+}
@@ -0,0 +1,102 @@
+package broker
+
+import (
+	"fmt"
+	log2 "log"
+	"sync"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+)
+
+type memoryBroker struct {
+	mu     sync.Mutex
+	subs   map[*subscription]struct{}
+	logger log.Logger
+}
+
+// NewMemoryBroker creates an in-process LocationBroker and returns it.
+//
+// A subscriber whose buffer is full is dropped rather than blocking publishers,
+// its subscription ends with `ErrResourceExhausted`.
+func NewMemoryBroker(logger log.Logger) port.LocationBroker {
+	if logger == nil {
+		log2.Panic("logger must not be nil")
+	}
+
+	return &memoryBroker{
+		subs:   make(map[*subscription]struct{}),
+		logger: logger,
+	}
+}
+
+// Publish delivers the update to every subscriber and drops subscribers that fell behind.
+func (b *memoryBroker) Publish(update domain.LocatedUser) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		select {
+		case sub.updates <- update:
+		default:
+			b.logger.Warn("subscriber fell behind location updates", log.Fields{
+				"buffer": cap(sub.updates),
+			})
+			b.remove(sub, fmt.Errorf("%w: subscriber fell behind location updates", errpack.ErrResourceExhausted))
+		}
+	}
+}
+
+// Subscribe starts a subscription whose updates are buffered up to `buffer` items.
+func (b *memoryBroker) Subscribe(buffer int) port.LocationSubscription {
+	sub := &subscription{
+		broker:  b,
+		updates: make(chan domain.LocatedUser, buffer),
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+
+	return sub
+}
+
+// remove ends the subscription with err. The caller must hold b.mu.
+func (b *memoryBroker) remove(sub *subscription, err error) {
+	if _, ok := b.subs[sub]; !ok {
+		return
+	}
+	delete(b.subs, sub)
+	sub.err = err
+	close(sub.updates)
+}
+
+type subscription struct {
+	broker  *memoryBroker
+	updates chan domain.LocatedUser
+	// err is guarded by broker.mu.
+	err error
+}
+
+// Updates returns a channel updates are delivered to. It is closed when the subscription ends.
+func (s *subscription) Updates() <-chan domain.LocatedUser {
+	return s.updates
+}
+
+// Err returns the reason the subscription ended with, if any.
+func (s *subscription) Err() error {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	return s.err
+}
+
+// Close ends the subscription.
+func (s *subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.broker.remove(s, nil)
+}
@@ -0,0 +1,98 @@
+package broker_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+)
+
+func locatedUser(id int) domain.LocatedUser {
+	return domain.LocatedUser{
+		User:  domain.User{ID: id},
+		Point: geo.Point{float64(id), float64(id)},
+	}
+}
+
+func TestMemoryBroker_FanOut(t *testing.T) {
+	b := broker.NewMemoryBroker(log.NewTestingLogger())
+
+	sub1 := b.Subscribe(10)
+	defer sub1.Close()
+	sub2 := b.Subscribe(10)
+	defer sub2.Close()
+
+	b.Publish(locatedUser(1))
+	b.Publish(locatedUser(2))
+
+	for _, sub := range []port.LocationSubscription{sub1, sub2} {
+		require.Equal(t, locatedUser(1), <-sub.Updates())
+		require.Equal(t, locatedUser(2), <-sub.Updates())
+	}
+}
+
+func TestMemoryBroker_Close(t *testing.T) {
+	b := broker.NewMemoryBroker(log.NewTestingLogger())
+
+	sub := b.Subscribe(10)
+	sub.Close()
+	sub.Close()
+
+	// Publishing to no subscribers must not block or panic.
+	b.Publish(locatedUser(1))
+
+	_, ok := <-sub.Updates()
+	require.False(t, ok)
+	require.NoError(t, sub.Err())
+}
+
+func TestMemoryBroker_SlowSubscriber(t *testing.T) {
+	b := broker.NewMemoryBroker(log.NewTestingLogger())
+
+	slow := b.Subscribe(1)
+	defer slow.Close()
+	fast := b.Subscribe(10)
+	defer fast.Close()
+
+	b.Publish(locatedUser(1))
+	b.Publish(locatedUser(2))
+	b.Publish(locatedUser(3))
+
+	// The slow subscriber gets the buffered update and is dropped then.
+	require.Equal(t, locatedUser(1), <-slow.Updates())
+	_, ok := <-slow.Updates()
+	require.False(t, ok)
+	require.True(t, errors.Is(slow.Err(), errpack.ErrResourceExhausted))
+
+	// Other subscribers are not affected.
+	for i := 1; i <= 3; i++ {
+		require.Equal(t, locatedUser(i), <-fast.Updates())
+	}
+	require.NoError(t, fast.Err())
+}
+
+func TestMemoryBroker_Concurrent(t *testing.T) {
+	b := broker.NewMemoryBroker(log.NewTestingLogger())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			b.Publish(locatedUser(i))
+		}(i)
+		go func() {
+			defer wg.Done()
+			sub := b.Subscribe(1)
+			sub.Close()
+		}()
+	}
+	wg.Wait()
+}
@@ -19,32 +19,56 @@ import (
 
 type GRPCClient struct {
 	addr   string
+	apiKey string
 	logger log.Logger
 }
 
 // NewGRPCClient TODO: add description
-func NewGRPCClient(addr string, logger log.Logger) port.HistoryClient {
+//
+// apiKey authenticates requests of the client, requests are sent without credentials if it is empty.
+func NewGRPCClient(addr string, apiKey string, logger log.Logger) port.HistoryClient {
 	if logger == nil {
 		log2.Panic("logger must not be nil")
 	}
 
 	return &GRPCClient{
 		addr:   addr,
+		apiKey: apiKey,
 		logger: logger,
 	}
 }
 
-// AddRecord TODO: add description
-func (c GRPCClient) AddRecord(ctx context.Context, req port.HistoryClientAddRecordRequest) (port.HistoryClientAddRecordResponse, error) {
+// dial connects to history service.
+func (c GRPCClient) dial() (*grpc.ClientConn, error) {
 	opts := []grpc.DialOption{
 		grpc.WithInsecure(),
 		grpc.WithChainUnaryInterceptor(
 			middleware.TracingUnaryClientInterceptor(c.logger),
+			middleware.APIKeyUnaryClientInterceptor(c.apiKey),
 			middleware.LoggerUnaryClientInterceptor(c.logger),
 		),
 	}
 
-	conn, err := grpc.Dial(c.addr, opts...)
+	return grpc.Dial(c.addr, opts...)
+}
+
+// errFromStatus converts an error returned by history service to one of errpack errors.
+func errFromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	switch st.Code() {
+	case codes.InvalidArgument:
+		return fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	default:
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+}
+
+// AddRecord TODO: add description
+func (c GRPCClient) AddRecord(ctx context.Context, req port.HistoryClientAddRecordRequest) (port.HistoryClientAddRecordResponse, error) {
+	conn, err := c.dial()
 	if err != nil {
 		return port.HistoryClientAddRecordResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
 	}
@@ -63,24 +87,103 @@ func (c GRPCClient) AddRecord(ctx context.Context, req port.HistoryClientAddReco
 			Latitude:  req.B.Latitude(),
 		},
 		Timestamp: timestamppb.New(req.Timestamp),
+		Metadata:  fixMetadataToPB(req.FixMetadata),
 	})
 	if err != nil {
-		st, ok := status.FromError(err)
-		if !ok {
-			return port.HistoryClientAddRecordResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
-		}
-		switch st.Code() {
-		case codes.InvalidArgument:
-			return port.HistoryClientAddRecordResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
-		default:
-			return port.HistoryClientAddRecordResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
-		}
+		return port.HistoryClientAddRecordResponse{}, errFromStatus(err)
 	}
 
 	return port.HistoryClientAddRecordResponse{
-		UserID:    int(res.UserId),
-		A:         geo.Point{res.A.Longitude, res.A.Latitude},
-		B:         geo.Point{res.B.Longitude, res.B.Latitude},
-		Timestamp: res.Timestamp.AsTime(),
+		UserID:      int(res.UserId),
+		A:           geo.Point{res.A.Longitude, res.A.Latitude},
+		B:           geo.Point{res.B.Longitude, res.B.Latitude},
+		Timestamp:   res.Timestamp.AsTime(),
+		FixMetadata: fixMetadataFromPB(res.Metadata),
 	}, nil
 }
+
+// AddRecords sends all the records to history service in a single call.
+func (c GRPCClient) AddRecords(ctx context.Context, req port.HistoryClientAddRecordsRequest) (port.HistoryClientAddRecordsResponse, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return port.HistoryClientAddRecordsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer conn.Close()
+
+	client := pb.NewHistoryClient(conn)
+
+	records := make([]*pb.AddRecordRequest, 0, len(req.Records))
+	for _, r := range req.Records {
+		records = append(records, &pb.AddRecordRequest{
+			UserId:    int32(r.UserID),
+			A:         &pb.Point{Longitude: r.A.Longitude(), Latitude: r.A.Latitude()},
+			B:         &pb.Point{Longitude: r.B.Longitude(), Latitude: r.B.Latitude()},
+			Timestamp: timestamppb.New(r.Timestamp),
+			Metadata:  fixMetadataToPB(r.FixMetadata),
+		})
+	}
+
+	res, err := client.AddRecords(ctx, &pb.AddRecordsRequest{Records: records})
+	if err != nil {
+		return port.HistoryClientAddRecordsResponse{}, errFromStatus(err)
+	}
+
+	result := port.HistoryClientAddRecordsResponse{
+		Records: make([]port.HistoryClientAddRecordResponse, 0, len(res.Records)),
+	}
+	for _, r := range res.Records {
+		result.Records = append(result.Records, port.HistoryClientAddRecordResponse{
+			UserID:      int(r.UserId),
+			A:           geo.Point{r.A.Longitude, r.A.Latitude},
+			B:           geo.Point{r.B.Longitude, r.B.Latitude},
+			Timestamp:   r.Timestamp.AsTime(),
+			FixMetadata: fixMetadataFromPB(r.Metadata),
+		})
+	}
+
+	return result, nil
+}
+
+// DeleteUserRecords deletes all the records of the user from history service.
+// It returns a number of deleted records.
+func (c GRPCClient) DeleteUserRecords(ctx context.Context, userID int) (int, error) {
+	conn, err := c.dial()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer conn.Close()
+
+	client := pb.NewHistoryClient(conn)
+
+	res, err := client.DeleteUserRecords(ctx, &pb.DeleteUserRecordsRequest{UserId: int32(userID)})
+	if err != nil {
+		return 0, errFromStatus(err)
+	}
+
+	return int(res.Deleted), nil
+}
+
+func fixMetadataToPB(m geo.FixMetadata) *pb.RecordMetadata {
+	return &pb.RecordMetadata{
+		Accuracy: m.Accuracy,
+		Altitude: m.Altitude,
+		Speed:    m.Speed,
+		Bearing:  m.Bearing,
+		Source:   string(m.Source),
+	}
+}
+
+// fixMetadataFromPB converts protobuf fix metadata, nil means unknown metadata.
+func fixMetadataFromPB(m *pb.RecordMetadata) geo.FixMetadata {
+	if m == nil {
+		return geo.FixMetadata{}
+	}
+
+	return geo.FixMetadata{
+		Accuracy: m.Accuracy,
+		Altitude: m.Altitude,
+		Speed:    m.Speed,
+		Bearing:  m.Bearing,
+		Source:   geo.FixSource(m.Source),
+	}
+}
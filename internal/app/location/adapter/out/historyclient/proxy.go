@@ -54,3 +54,45 @@ func (p *Proxy) AddRecord(ctx context.Context, req port.HistoryClientAddRecordRe
 
 	return res.(port.HistoryClientAddRecordResponse), nil
 }
+
+// AddRecords calls AddRecords of the wrapped client applying circuit breaker and retries.
+func (p *Proxy) AddRecords(ctx context.Context, req port.HistoryClientAddRecordsRequest) (port.HistoryClientAddRecordsResponse, error) {
+	res, err := p.retrier.Exec(ctx, func() (interface{}, error) {
+		res, err := p.breaker.Execute(func() (interface{}, error) {
+			return p.client.AddRecords(ctx, req)
+		})
+
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			return port.HistoryClientAddRecordsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+
+		return res, err
+	})
+
+	if err != nil {
+		return port.HistoryClientAddRecordsResponse{}, err
+	}
+
+	return res.(port.HistoryClientAddRecordsResponse), nil
+}
+
+// DeleteUserRecords calls DeleteUserRecords of the wrapped client applying circuit breaker and retries.
+func (p *Proxy) DeleteUserRecords(ctx context.Context, userID int) (int, error) {
+	res, err := p.retrier.Exec(ctx, func() (interface{}, error) {
+		res, err := p.breaker.Execute(func() (interface{}, error) {
+			return p.client.DeleteUserRecords(ctx, userID)
+		})
+
+		if errors.Is(err, gobreaker.ErrOpenState) || errors.Is(err, gobreaker.ErrTooManyRequests) {
+			return 0, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+
+		return res, err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return res.(int), nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+// cloneErasureJob returns a copy of the job that does not share its completion time with j.
+func cloneErasureJob(j domain.ErasureJob) domain.ErasureJob {
+	if j.CompletedAt != nil {
+		completedAt := *j.CompletedAt
+		j.CompletedAt = &completedAt
+	}
+	return j
+}
+
+// createErasureJob creates a pending erasure job of given deleted user.
+// It is meant to be called in the scope of a transaction.
+func (r *memoryRepository) createErasureJob(tx *memoryTx, user domain.User) domain.ErasureJob {
+	now := memoryNow()
+	r.lastErasureJobID++
+	job := domain.ErasureJob{
+		ID:        r.lastErasureJobID,
+		UserID:    user.ID,
+		Username:  user.Username,
+		Status:    domain.ErasureJobPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.erasureJobs[job.ID] = job
+
+	tx.onRollback(func() {
+		delete(r.erasureJobs, job.ID)
+	})
+
+	return job
+}
+
+// GetErasureJob finds an erasure job by ID.
+//
+// `ErrNotFound` is returned in case the job is not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) GetErasureJob(ctx context.Context, id int) (domain.ErasureJob, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	job, ok := r.erasureJobs[id]
+	if !ok {
+		return domain.ErasureJob{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	return cloneErasureJob(job), nil
+}
+
+// ListErasureJobs finds no more than `arg.PageSize` erasure jobs with IDs greater than `arg.PageToken`.
+// Jobs are filtered by `arg.Status` unless it is empty.
+//
+// It returns a response that consists of a job list ordered by ID and next page token.
+//
+// A job list that equals nil should be considered as empty.
+// Next page token is ID of last found job if required amount of jobs found.
+// If the next page token equal 0, there is no more pages.
+func (r *memoryRepository) ListErasureJobs(ctx context.Context, arg port.ErasureRepositoryListErasureJobsRequest) (port.ErasureRepositoryListErasureJobsResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var jobs []domain.ErasureJob
+	for _, job := range r.erasureJobs {
+		if job.ID > arg.PageToken && (arg.Status == "" || job.Status == arg.Status) {
+			jobs = append(jobs, cloneErasureJob(job))
+		}
+	}
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].ID < jobs[j].ID
+	})
+
+	result := port.ErasureRepositoryListErasureJobsResponse{
+		Jobs: jobs,
+	}
+	if len(jobs) > arg.PageSize { // Next page exists.
+		result.Jobs = jobs[:arg.PageSize]
+		result.NextPageToken = result.Jobs[len(result.Jobs)-1].ID
+	}
+
+	return result, nil
+}
+
+// UpdateErasureJob records an attempt to erase data of an erasure job.
+//
+// It sets status and last error of the job and increments its attempts.
+// Completion time is set if the job is completed.
+//
+// `ErrNotFound` is returned in case the job is not found.
+//
+// `ErrInternalError` is returned in case the status is unknown.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) UpdateErasureJob(ctx context.Context, arg port.ErasureRepositoryUpdateErasureJobRequest) (domain.ErasureJob, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	job, ok := r.erasureJobs[arg.ID]
+	if !ok {
+		return domain.ErasureJob{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+	if arg.Status != domain.ErasureJobPending && arg.Status != domain.ErasureJobCompleted {
+		return domain.ErasureJob{}, fmt.Errorf("%w: unknown erasure job status %q", errpack.ErrInternalError, arg.Status)
+	}
+
+	now := memoryNow()
+	job.Status = arg.Status
+	job.Attempts++
+	job.LastError = arg.LastError
+	job.UpdatedAt = now
+	job.CompletedAt = nil
+	if job.Status == domain.ErasureJobCompleted {
+		job.CompletedAt = &now
+	}
+	r.erasureJobs[job.ID] = job
+
+	return cloneErasureJob(job), nil
+}
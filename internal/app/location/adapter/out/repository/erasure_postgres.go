@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+const erasureJobColumns = "id, user_id, username, status, attempts, last_error, created_at, updated_at, completed_at"
+
+// scanErasureJob scans an erasure job selected with `erasureJobColumns` columns.
+func scanErasureJob(row rowScanner) (domain.ErasureJob, error) {
+	var job domain.ErasureJob
+	var lastError sql.NullString
+	var completedAt sql.NullTime
+
+	if err := row.Scan(
+		&job.ID,
+		&job.UserID,
+		&job.Username,
+		&job.Status,
+		&job.Attempts,
+		&lastError,
+		&job.CreatedAt,
+		&job.UpdatedAt,
+		&completedAt,
+	); err != nil {
+		return domain.ErasureJob{}, err
+	}
+
+	job.LastError = lastError.String
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	return job, nil
+}
+
+var createErasureJobQuery = fmt.Sprintf(
+	`
+INSERT INTO %s
+(user_id, username)
+VALUES ($1, $2)
+RETURNING %s
+`,
+	ErasureJobTable,
+	erasureJobColumns,
+)
+
+// createErasureJob creates a pending erasure job of given deleted user.
+// It is meant to be called in the scope of the database transaction.
+func (q *postgresQueries) createErasureJob(ctx context.Context, user domain.User) (domain.ErasureJob, error) {
+	job, err := scanErasureJob(q.db.QueryRowContext(ctx, createErasureJobQuery, user.ID, user.Username))
+	if err != nil {
+		return domain.ErasureJob{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return job, nil
+}
+
+var getErasureJobQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s
+WHERE id = $1
+`,
+	erasureJobColumns,
+	ErasureJobTable,
+)
+
+// GetErasureJob finds an erasure job by ID.
+//
+// `ErrNotFound` is returned in case the job is not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) GetErasureJob(ctx context.Context, id int) (domain.ErasureJob, error) {
+	job, err := scanErasureJob(q.db.QueryRowContext(ctx, getErasureJobQuery, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErasureJob{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+		return domain.ErasureJob{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return job, nil
+}
+
+var listErasureJobsQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s
+WHERE ($1 = '' OR status = $1) AND id > $2
+ORDER BY id
+LIMIT $3
+`,
+	erasureJobColumns,
+	ErasureJobTable,
+)
+
+// ListErasureJobs finds no more than `arg.PageSize` erasure jobs with IDs greater than `arg.PageToken`.
+// Jobs are filtered by `arg.Status` unless it is empty.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of a job list ordered by ID and next page token.
+//
+// A job list that equals nil should be considered as empty.
+// Next page token is ID of last found job if required amount of jobs found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListErasureJobs(ctx context.Context, arg port.ErasureRepositoryListErasureJobsRequest) (port.ErasureRepositoryListErasureJobsResponse, error) {
+	// Fetch PageSize + 1 (extra marker element)
+	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
+	rows, err := q.db.QueryContext(ctx, listErasureJobsQuery, arg.Status, arg.PageToken, arg.PageSize+1)
+	if err != nil {
+		return port.ErasureRepositoryListErasureJobsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	var jobs []domain.ErasureJob
+	hasNextPage := false
+	for rows.Next() {
+		if len(jobs) == arg.PageSize { // Next page exists.
+			hasNextPage = true
+			break // Do not scan extra marker element.
+		}
+
+		job, err := scanErasureJob(rows)
+		if err != nil {
+			return port.ErasureRepositoryListErasureJobsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err = rows.Err(); err != nil {
+		return port.ErasureRepositoryListErasureJobsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	result := port.ErasureRepositoryListErasureJobsResponse{
+		Jobs: jobs,
+	}
+	if hasNextPage {
+		result.NextPageToken = jobs[len(jobs)-1].ID
+	}
+
+	return result, nil
+}
+
+var updateErasureJobQuery = fmt.Sprintf(
+	`
+UPDATE %s
+SET status = $2,
+	attempts = attempts + 1,
+	last_error = NULLIF($3, ''),
+	completed_at = CASE WHEN $2 = '%s' THEN current_timestamp END
+WHERE id = $1
+RETURNING %s
+`,
+	ErasureJobTable,
+	domain.ErasureJobCompleted,
+	erasureJobColumns,
+)
+
+// UpdateErasureJob records an attempt to erase data of an erasure job.
+//
+// It sets status and last error of the job and increments its attempts.
+// Completion time is set if the job is completed.
+//
+// `ErrNotFound` is returned in case the job is not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) UpdateErasureJob(ctx context.Context, arg port.ErasureRepositoryUpdateErasureJobRequest) (domain.ErasureJob, error) {
+	job, err := scanErasureJob(q.db.QueryRowContext(ctx, updateErasureJobQuery, arg.ID, arg.Status, arg.LastError))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.ErasureJob{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+		return domain.ErasureJob{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,84 @@
+package repository_test
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+func (s *PostgresTestSuite) Test_PostgresQueries_ErasureJobs() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user1"},
+		{Username: "user2"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+	ctx := context.Background()
+
+	job1, err := repo.DeleteUser(ctx, "user1")
+	require.NoError(s.T(), err)
+	job2, err := repo.DeleteUser(ctx, "user2")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[1].ID, job2.UserID)
+
+	got, err := repo.GetErasureJob(ctx, job1.ID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), job1, got)
+
+	_, err = repo.GetErasureJob(ctx, job2.ID+1)
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	// A failed attempt keeps the job pending.
+	failed, err := repo.UpdateErasureJob(ctx, port.ErasureRepositoryUpdateErasureJobRequest{
+		ID:        job1.ID,
+		Status:    domain.ErasureJobPending,
+		LastError: "unavailable",
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.ErasureJobPending, failed.Status)
+	require.Equal(s.T(), 1, failed.Attempts)
+	require.Equal(s.T(), "unavailable", failed.LastError)
+	require.Nil(s.T(), failed.CompletedAt)
+
+	completed, err := repo.UpdateErasureJob(ctx, port.ErasureRepositoryUpdateErasureJobRequest{
+		ID:     job1.ID,
+		Status: domain.ErasureJobCompleted,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.ErasureJobCompleted, completed.Status)
+	require.Equal(s.T(), 2, completed.Attempts)
+	require.Empty(s.T(), completed.LastError)
+	require.NotNil(s.T(), completed.CompletedAt)
+
+	_, err = repo.UpdateErasureJob(ctx, port.ErasureRepositoryUpdateErasureJobRequest{
+		ID:     job2.ID + 1,
+		Status: domain.ErasureJobCompleted,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	pending, err := repo.ListErasureJobs(ctx, port.ErasureRepositoryListErasureJobsRequest{
+		Status:   domain.ErasureJobPending,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.ErasureJob{job2}, pending.Jobs)
+	require.Equal(s.T(), 0, pending.NextPageToken)
+
+	all, err := repo.ListErasureJobs(ctx, port.ErasureRepositoryListErasureJobsRequest{PageSize: 1})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), all.Jobs, 1)
+	require.Equal(s.T(), job1.ID, all.Jobs[0].ID)
+	require.Equal(s.T(), job1.ID, all.NextPageToken)
+
+	all, err = repo.ListErasureJobs(ctx, port.ErasureRepositoryListErasureJobsRequest{
+		PageToken: all.NextPageToken,
+		PageSize:  1,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.ErasureJob{job2}, all.Jobs)
+	require.Equal(s.T(), 0, all.NextPageToken)
+}
@@ -0,0 +1,375 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// maxGeofenceNameLength is the maximum length of geofence names in geofences table.
+const maxGeofenceNameLength = 64
+
+// newMemoryGeofence builds a geofence the way it is stored in geofences table.
+//
+// A polygon geofence has no center and radius and a circle geofence has no polygon.
+//
+// `ErrInvalidArgument` is returned in case the geofence does not satisfy constraints of geofences table.
+func newMemoryGeofence(name string, geofenceType domain.GeofenceType, center *geo.Point, radius float64, polygon geo.Polygon, dwellTime int) (domain.Geofence, error) {
+	geofence := domain.Geofence{
+		Name:      name,
+		Type:      geofenceType,
+		DwellTime: dwellTime,
+	}
+	if len(polygon) == 0 {
+		geofence.Center = center
+		geofence.Radius = radius
+	} else {
+		geofence.Polygon = polygon
+	}
+	geofence = cloneGeofence(geofence)
+
+	valid := utf8.RuneCountInString(name) <= maxGeofenceNameLength && dwellTime >= 0
+	switch geofence.Type {
+	case domain.GeofenceTypeCircle:
+		valid = valid && geofence.Center != nil && geofence.Radius >= 0 && geofence.Polygon == nil
+	case domain.GeofenceTypePolygon:
+		valid = valid && geofence.Center == nil && geofence.Polygon != nil
+	default:
+		valid = false
+	}
+	if !valid {
+		return domain.Geofence{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	return geofence, nil
+}
+
+// cloneGeofence returns a copy of the geofence that does not share its center and polygon with g.
+func cloneGeofence(g domain.Geofence) domain.Geofence {
+	if g.Center != nil {
+		center := *g.Center
+		g.Center = &center
+	}
+	if g.Polygon != nil {
+		polygon := make(geo.Polygon, 0, len(g.Polygon))
+		for _, ring := range g.Polygon {
+			polygon = append(polygon, append(geo.Ring(nil), ring...))
+		}
+		g.Polygon = polygon
+	}
+	return g
+}
+
+// geofenceContains reports whether the geofence contains the point.
+func geofenceContains(g domain.Geofence, point geo.Point) bool {
+	switch g.Type {
+	case domain.GeofenceTypeCircle:
+		return geo.Distance(*g.Center, point) <= g.Radius
+	case domain.GeofenceTypePolygon:
+		return g.Polygon.Contains(point)
+	}
+	return false
+}
+
+// CreateGeofence adds a new geofence.
+//
+// It returns the created geofence and any error encountered.
+//
+// `ErrInvalidArgument` is returned in case the geofence is invalid.
+//
+// `ErrAlreadyExists` is returned in case a geofence with given name already exists.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) CreateGeofence(ctx context.Context, arg port.GeofenceRepositoryCreateGeofenceRequest) (domain.Geofence, error) {
+	geofence, err := newMemoryGeofence(arg.Name, arg.Type, arg.Center, arg.Radius, arg.Polygon, arg.DwellTime)
+	if err != nil {
+		return domain.Geofence{}, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.geofenceIDs[geofence.Name]; ok {
+		return domain.Geofence{}, fmt.Errorf("%w", errpack.ErrAlreadyExists)
+	}
+
+	now := memoryNow()
+	r.lastGeofenceID++
+	geofence.ID = r.lastGeofenceID
+	geofence.CreatedAt = now
+	geofence.UpdatedAt = now
+
+	r.geofences[geofence.ID] = geofence
+	r.geofenceIDs[geofence.Name] = geofence.ID
+
+	return cloneGeofence(geofence), nil
+}
+
+// GetGeofence finds a geofence by ID.
+//
+// It returns the geofence and any error encountered.
+//
+// `ErrNotFound` is returned in case the geofence is not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) GetGeofence(ctx context.Context, id int) (domain.Geofence, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	geofence, ok := r.geofences[id]
+	if !ok {
+		return domain.Geofence{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	return cloneGeofence(geofence), nil
+}
+
+// UpdateGeofence replaces all fields of a geofence with given ID.
+//
+// It returns the updated geofence and any error encountered.
+//
+// `ErrNotFound` is returned in case the geofence is not found.
+//
+// `ErrInvalidArgument` is returned in case the geofence is invalid.
+//
+// `ErrAlreadyExists` is returned in case another geofence with given name already exists.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) UpdateGeofence(ctx context.Context, arg port.GeofenceRepositoryUpdateGeofenceRequest) (domain.Geofence, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	prev, ok := r.geofences[arg.ID]
+	if !ok {
+		return domain.Geofence{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	geofence, err := newMemoryGeofence(arg.Name, arg.Type, arg.Center, arg.Radius, arg.Polygon, arg.DwellTime)
+	if err != nil {
+		return domain.Geofence{}, err
+	}
+	if otherID, ok := r.geofenceIDs[geofence.Name]; ok && otherID != prev.ID {
+		return domain.Geofence{}, fmt.Errorf("%w", errpack.ErrAlreadyExists)
+	}
+
+	geofence.ID = prev.ID
+	geofence.CreatedAt = prev.CreatedAt
+	geofence.UpdatedAt = memoryNow()
+
+	delete(r.geofenceIDs, prev.Name)
+	r.geofences[geofence.ID] = geofence
+	r.geofenceIDs[geofence.Name] = geofence.ID
+
+	return cloneGeofence(geofence), nil
+}
+
+// DeleteGeofence deletes a geofence with given ID along with all its events.
+//
+// `ErrNotFound` is returned in case the geofence is not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) DeleteGeofence(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	geofence, ok := r.geofences[id]
+	if !ok {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	delete(r.geofences, id)
+	delete(r.geofenceIDs, geofence.Name)
+
+	events := r.events
+	r.events = nil
+	for _, event := range events {
+		if event.GeofenceID != id {
+			r.events = append(r.events, event)
+		}
+	}
+
+	return nil
+}
+
+// ListGeofences finds no more than `arg.PageSize` geofences with IDs greater than `arg.PageToken`.
+//
+// It returns a response that consists of a geofence list ordered by ID and next page token.
+//
+// A geofence list that equals nil should be considered as empty.
+// Next page token is ID of last found geofence if required amount of geofences found.
+// If the next page token equal 0, there is no more pages.
+func (r *memoryRepository) ListGeofences(ctx context.Context, arg port.GeofenceRepositoryListGeofencesRequest) (port.GeofenceRepositoryListGeofencesResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var geofences []domain.Geofence
+	for _, geofence := range r.geofences {
+		if geofence.ID > arg.PageToken {
+			geofences = append(geofences, cloneGeofence(geofence))
+		}
+	}
+	sort.Slice(geofences, func(i, j int) bool {
+		return geofences[i].ID < geofences[j].ID
+	})
+
+	result := port.GeofenceRepositoryListGeofencesResponse{
+		Geofences: geofences,
+	}
+	if len(geofences) > arg.PageSize { // Next page exists.
+		result.Geofences = geofences[:arg.PageSize]
+		result.NextPageToken = result.Geofences[len(result.Geofences)-1].ID
+	}
+
+	return result, nil
+}
+
+// ListGeofencesContaining finds all geofences that contain given point.
+//
+// It returns a geofence list ordered by ID and any error encountered.
+//
+// A geofence list that equals nil should be considered as empty.
+func (r *memoryRepository) ListGeofencesContaining(ctx context.Context, point geo.Point) ([]domain.Geofence, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var geofences []domain.Geofence
+	for _, geofence := range r.geofences {
+		if geofenceContains(geofence, point) {
+			geofences = append(geofences, cloneGeofence(geofence))
+		}
+	}
+	sort.Slice(geofences, func(i, j int) bool {
+		return geofences[i].ID < geofences[j].ID
+	})
+
+	return geofences, nil
+}
+
+// ListGeofencePresences finds the last ENTER event of the user for each of given geofences.
+//
+// It returns a presence list ordered by geofence ID and any error encountered.
+// Geofences the user has never entered are skipped.
+//
+// A presence list that equals nil should be considered as empty.
+func (r *memoryRepository) ListGeofencePresences(ctx context.Context, userID int, geofenceIDs []int) ([]domain.GeofencePresence, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	requested := make(map[int]bool, len(geofenceIDs))
+	for _, id := range geofenceIDs {
+		requested[id] = true
+	}
+
+	// Events are ordered by ID, so a later ENTER event replaces the earlier one.
+	presences := make(map[int]domain.GeofencePresence)
+	for _, event := range r.events {
+		if event.UserID != userID || !requested[event.GeofenceID] {
+			continue
+		}
+		switch event.Type {
+		case domain.GeofenceEventEnter:
+			presences[event.GeofenceID] = domain.GeofencePresence{
+				GeofenceID: event.GeofenceID,
+				UserID:     event.UserID,
+				EnteredAt:  event.CreatedAt,
+			}
+		case domain.GeofenceEventDwell:
+			if presence, ok := presences[event.GeofenceID]; ok {
+				presence.Dwelled = true
+				presences[event.GeofenceID] = presence
+			}
+		}
+	}
+
+	var result []domain.GeofencePresence
+	for _, presence := range presences {
+		result = append(result, presence)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GeofenceID < result[j].GeofenceID
+	})
+
+	return result, nil
+}
+
+// CreateGeofenceEvents adds all given events in the scope of a transaction.
+//
+// It returns the created events and any error encountered.
+//
+// `ErrInternalError` is returned in case the user or geofence of any event does not exist
+// or type of any event is unknown.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) CreateGeofenceEvents(ctx context.Context, events []domain.GeofenceEvent) ([]domain.GeofenceEvent, error) {
+	created := make([]domain.GeofenceEvent, 0, len(events))
+
+	err := r.execTx(func(tx *memoryTx) error {
+		for _, event := range events {
+			if _, ok := r.users[event.UserID]; !ok {
+				return fmt.Errorf("%w: user %d does not exist", errpack.ErrInternalError, event.UserID)
+			}
+			if _, ok := r.geofences[event.GeofenceID]; !ok {
+				return fmt.Errorf("%w: geofence %d does not exist", errpack.ErrInternalError, event.GeofenceID)
+			}
+			switch event.Type {
+			case domain.GeofenceEventEnter, domain.GeofenceEventExit, domain.GeofenceEventDwell:
+			default:
+				return fmt.Errorf("%w: unknown geofence event type %q", errpack.ErrInternalError, event.Type)
+			}
+
+			r.lastEventID++
+			event.ID = r.lastEventID
+			event.Point = geo.Trunc(event.Point)
+			event.CreatedAt = event.CreatedAt.UTC().Truncate(time.Microsecond)
+			created = append(created, event)
+		}
+
+		r.events = append(r.events, created...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// ListGeofenceEvents finds no more than `arg.PageSize` geofence events with IDs greater than `arg.PageToken`.
+//
+// Events are filtered by `arg.GeofenceID` and `arg.UserID` unless they equal 0.
+//
+// It returns a response that consists of an event list ordered by ID and next page token.
+//
+// An event list that equals nil should be considered as empty.
+// Next page token is ID of last found event if required amount of events found.
+// If the next page token equal 0, there is no more pages.
+func (r *memoryRepository) ListGeofenceEvents(ctx context.Context, arg port.GeofenceRepositoryListGeofenceEventsRequest) (port.GeofenceRepositoryListGeofenceEventsResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	// Events are ordered by ID, so the first events of the page can be found with a binary search.
+	start := sort.Search(len(r.events), func(i int) bool {
+		return r.events[i].ID > arg.PageToken
+	})
+
+	var result port.GeofenceRepositoryListGeofenceEventsResponse
+	for _, event := range r.events[start:] {
+		if (arg.GeofenceID != 0 && event.GeofenceID != arg.GeofenceID) || (arg.UserID != 0 && event.UserID != arg.UserID) {
+			continue
+		}
+		if len(result.Events) == arg.PageSize { // Next page exists.
+			result.NextPageToken = result.Events[len(result.Events)-1].ID
+			break
+		}
+		result.Events = append(result.Events, event)
+	}
+
+	return result, nil
+}
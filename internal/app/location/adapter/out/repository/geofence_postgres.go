@@ -0,0 +1,552 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+const geofenceColumns = "id, name, type, center, radius, polygon, dwell_time, created_at, updated_at"
+
+// rowScanner is an interface that sql.Row and sql.Rows implement.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanGeofence scans a geofence selected with `geofenceColumns` columns.
+func scanGeofence(row rowScanner) (domain.Geofence, error) {
+	var geofence domain.Geofence
+	var center *geo.PostgresPoint
+	var radius sql.NullFloat64
+	var polygon []byte
+
+	if err := row.Scan(
+		&geofence.ID,
+		&geofence.Name,
+		&geofence.Type,
+		&center,
+		&radius,
+		&polygon,
+		&geofence.DwellTime,
+		&geofence.CreatedAt,
+		&geofence.UpdatedAt,
+	); err != nil {
+		return domain.Geofence{}, err
+	}
+
+	if center != nil {
+		point := geo.Point(*center)
+		geofence.Center = &point
+	}
+	geofence.Radius = radius.Float64
+	if polygon != nil {
+		if err := json.Unmarshal(polygon, &geofence.Polygon); err != nil {
+			return domain.Geofence{}, err
+		}
+	}
+
+	return geofence, nil
+}
+
+// geofenceShapeArgs returns center, radius, polygon, exterior and holes arguments of geofence queries.
+//
+// A polygon is stored as is to be returned back to clients and also as unwrapped exterior ring and holes
+// to find geofences containing a point.
+func geofenceShapeArgs(center *geo.Point, radius float64, polygon geo.Polygon) ([]interface{}, error) {
+	if len(polygon) == 0 {
+		var pgCenter interface{}
+		if center != nil {
+			pgCenter = geo.PostgresPoint(*center)
+		}
+		return []interface{}{pgCenter, radius, nil, nil, nil}, nil
+	}
+
+	polygonJSON, err := json.Marshal(polygon)
+	if err != nil {
+		return nil, err
+	}
+
+	unwrapped := polygon.Unwrap()
+	holes := make([]geo.PostgresPolygon, 0, len(unwrapped)-1)
+	for _, hole := range unwrapped[1:] {
+		holes = append(holes, geo.PostgresPolygon(hole))
+	}
+
+	return []interface{}{nil, nil, string(polygonJSON), geo.PostgresPolygon(unwrapped[0]), pq.GenericArray{A: holes}}, nil
+}
+
+// geofenceErr converts an error returned from geofences table queries.
+func geofenceErr(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Name() {
+		case "string_data_right_truncation":
+			return fmt.Errorf("%w", errpack.ErrInvalidArgument)
+		}
+
+		switch pqErr.Constraint {
+		case ConstraintGeofencesNameKey:
+			return fmt.Errorf("%w", errpack.ErrAlreadyExists)
+		case ConstraintGeofencesShapeValid, ConstraintGeofencesDwellTimeValid:
+			return fmt.Errorf("%w", errpack.ErrInvalidArgument)
+		}
+	}
+
+	return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+}
+
+var createGeofenceQuery = fmt.Sprintf(
+	`
+INSERT INTO %s
+(name, type, dwell_time, center, radius, polygon, exterior, holes)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+RETURNING %s
+`,
+	GeofenceTable,
+	geofenceColumns,
+)
+
+// CreateGeofence adds a new geofence to the geofences table.
+//
+// It returns the created geofence and any error encountered.
+//
+// `ErrInvalidArgument` is returned in case the geofence is invalid.
+//
+// `ErrAlreadyExists` is returned in case a geofence with given name already exists.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) CreateGeofence(ctx context.Context, arg port.GeofenceRepositoryCreateGeofenceRequest) (domain.Geofence, error) {
+	shapeArgs, err := geofenceShapeArgs(arg.Center, arg.Radius, arg.Polygon)
+	if err != nil {
+		return domain.Geofence{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	args := append([]interface{}{arg.Name, arg.Type, arg.DwellTime}, shapeArgs...)
+	geofence, err := scanGeofence(q.db.QueryRowContext(ctx, createGeofenceQuery, args...))
+	if err != nil {
+		return domain.Geofence{}, geofenceErr(err)
+	}
+
+	return geofence, nil
+}
+
+var getGeofenceQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s
+WHERE id = $1
+`,
+	geofenceColumns,
+	GeofenceTable,
+)
+
+// GetGeofence finds a geofence by ID in the geofences table.
+//
+// It returns the geofence and any error encountered.
+//
+// `ErrNotFound` is returned in case the geofence is not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) GetGeofence(ctx context.Context, id int) (domain.Geofence, error) {
+	geofence, err := scanGeofence(q.db.QueryRowContext(ctx, getGeofenceQuery, id))
+	if err != nil {
+		return domain.Geofence{}, geofenceErr(err)
+	}
+
+	return geofence, nil
+}
+
+var updateGeofenceQuery = fmt.Sprintf(
+	`
+UPDATE %s
+SET name = $2, type = $3, dwell_time = $4, center = $5, radius = $6, polygon = $7, exterior = $8, holes = $9
+WHERE id = $1
+RETURNING %s
+`,
+	GeofenceTable,
+	geofenceColumns,
+)
+
+// UpdateGeofence replaces all fields of a geofence with given ID.
+//
+// It returns the updated geofence and any error encountered.
+//
+// `ErrNotFound` is returned in case the geofence is not found.
+//
+// `ErrInvalidArgument` is returned in case the geofence is invalid.
+//
+// `ErrAlreadyExists` is returned in case another geofence with given name already exists.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) UpdateGeofence(ctx context.Context, arg port.GeofenceRepositoryUpdateGeofenceRequest) (domain.Geofence, error) {
+	shapeArgs, err := geofenceShapeArgs(arg.Center, arg.Radius, arg.Polygon)
+	if err != nil {
+		return domain.Geofence{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	args := append([]interface{}{arg.ID, arg.Name, arg.Type, arg.DwellTime}, shapeArgs...)
+	geofence, err := scanGeofence(q.db.QueryRowContext(ctx, updateGeofenceQuery, args...))
+	if err != nil {
+		return domain.Geofence{}, geofenceErr(err)
+	}
+
+	return geofence, nil
+}
+
+var deleteGeofenceQuery = fmt.Sprintf(
+	`
+DELETE FROM %s
+WHERE id = $1
+`,
+	GeofenceTable,
+)
+
+// DeleteGeofence deletes a geofence with given ID along with all its events.
+//
+// `ErrNotFound` is returned in case the geofence is not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) DeleteGeofence(ctx context.Context, id int) error {
+	res, err := q.db.ExecContext(ctx, deleteGeofenceQuery, id)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	return nil
+}
+
+var listGeofencesQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`,
+	geofenceColumns,
+	GeofenceTable,
+)
+
+// ListGeofences finds no more than `arg.PageSize` geofences with IDs greater than `arg.PageToken`.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of a geofence list ordered by ID and next page token.
+//
+// A geofence list that equals nil should be considered as empty.
+// Next page token is ID of last found geofence if required amount of geofences found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListGeofences(ctx context.Context, arg port.GeofenceRepositoryListGeofencesRequest) (port.GeofenceRepositoryListGeofencesResponse, error) {
+	// Fetch PageSize + 1 (extra marker element)
+	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
+	rows, err := q.db.QueryContext(ctx, listGeofencesQuery, arg.PageToken, arg.PageSize+1)
+	if err != nil {
+		return port.GeofenceRepositoryListGeofencesResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	var geofences []domain.Geofence
+	hasNextPage := false
+	for rows.Next() {
+		if len(geofences) == arg.PageSize { // Next page exists.
+			hasNextPage = true
+			break // Do not scan extra marker element.
+		}
+
+		geofence, err := scanGeofence(rows)
+		if err != nil {
+			return port.GeofenceRepositoryListGeofencesResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		geofences = append(geofences, geofence)
+	}
+	if err = rows.Err(); err != nil {
+		return port.GeofenceRepositoryListGeofencesResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	result := port.GeofenceRepositoryListGeofencesResponse{
+		Geofences: geofences,
+	}
+	if hasNextPage {
+		result.NextPageToken = geofences[len(geofences)-1].ID
+	}
+
+	return result, nil
+}
+
+// listGeofencesContainingQuery checks a point shifted by ±360 degrees as well as the point itself
+// for polygon geofences, since their exterior rings are unwrapped and may lie beyond the antimeridian.
+var listGeofencesContainingQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s g
+WHERE (g.type = 'circle' AND (g.center <@> $1) * 1609.344 <= g.radius)
+	OR (g.type = 'polygon' AND EXISTS (
+		SELECT 1
+		FROM (VALUES
+			($1::point),
+			(point(($1::point)[0] + 360, ($1::point)[1])),
+			(point(($1::point)[0] - 360, ($1::point)[1]))
+		) AS s(p)
+		WHERE g.exterior @> s.p
+			AND NOT EXISTS (SELECT 1 FROM unnest(g.holes) AS h(hole) WHERE h.hole @> s.p)
+	))
+ORDER BY g.id
+`,
+	geofenceColumns,
+	GeofenceTable,
+)
+
+// ListGeofencesContaining finds all geofences that contain given point.
+//
+// It returns a geofence list ordered by ID and any error encountered.
+//
+// A geofence list that equals nil should be considered as empty.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListGeofencesContaining(ctx context.Context, point geo.Point) ([]domain.Geofence, error) {
+	rows, err := q.db.QueryContext(ctx, listGeofencesContainingQuery, geo.PostgresPoint(point))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	var geofences []domain.Geofence
+	for rows.Next() {
+		geofence, err := scanGeofence(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		geofences = append(geofences, geofence)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return geofences, nil
+}
+
+var listGeofencePresencesQuery = fmt.Sprintf(
+	`
+SELECT e.geofence_id, e.user_id, e.created_at,
+	EXISTS (
+		SELECT 1
+		FROM %[1]s d
+		WHERE d.user_id = e.user_id AND d.geofence_id = e.geofence_id AND d.type = 'DWELL' AND d.id > e.id
+	) AS dwelled
+FROM %[1]s e
+WHERE e.id IN (
+	SELECT max(id)
+	FROM %[1]s
+	WHERE user_id = $1 AND geofence_id = ANY($2::int[]) AND type = 'ENTER'
+	GROUP BY geofence_id
+)
+ORDER BY e.geofence_id
+`,
+	GeofenceEventTable,
+)
+
+// ListGeofencePresences finds the last ENTER event of the user for each of given geofences.
+//
+// It returns a presence list ordered by geofence ID and any error encountered.
+// Geofences the user has never entered are skipped.
+//
+// A presence list that equals nil should be considered as empty.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListGeofencePresences(ctx context.Context, userID int, geofenceIDs []int) ([]domain.GeofencePresence, error) {
+	ids := make([]int64, 0, len(geofenceIDs))
+	for _, id := range geofenceIDs {
+		ids = append(ids, int64(id))
+	}
+
+	rows, err := q.db.QueryContext(ctx, listGeofencePresencesQuery, userID, pq.Int64Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	var presences []domain.GeofencePresence
+	for rows.Next() {
+		var presence domain.GeofencePresence
+		if err = rows.Scan(
+			&presence.GeofenceID,
+			&presence.UserID,
+			&presence.EnteredAt,
+			&presence.Dwelled,
+		); err != nil {
+			return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		presences = append(presences, presence)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return presences, nil
+}
+
+var createGeofenceEventQuery = fmt.Sprintf(
+	`
+INSERT INTO %s
+(geofence_id, user_id, type, point, created_at)
+VALUES ($1, $2, $3, $4, $5)
+RETURNING id, geofence_id, user_id, type, point, created_at
+`,
+	GeofenceEventTable,
+)
+
+// createGeofenceEvent adds a new event to the geofence events table.
+func (q *postgresQueries) createGeofenceEvent(ctx context.Context, event domain.GeofenceEvent) (domain.GeofenceEvent, error) {
+	var point geo.PostgresPoint
+	if err := q.db.QueryRowContext(ctx, createGeofenceEventQuery,
+		event.GeofenceID, event.UserID, event.Type, geo.PostgresPoint(event.Point), event.CreatedAt,
+	).Scan(
+		&event.ID,
+		&event.GeofenceID,
+		&event.UserID,
+		&event.Type,
+		&point,
+		&event.CreatedAt,
+	); err != nil {
+		return domain.GeofenceEvent{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	event.Point = geo.Point(point)
+
+	return event, nil
+}
+
+// CreateGeofenceEvents adds all given events to the geofence events table
+// in the scope of the database transaction.
+//
+// It returns the created events and any error encountered.
+//
+// `ErrInternalError` is returned in case of any failure, e.g. the user or geofence of any event does not exist.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *postgresRepository) CreateGeofenceEvents(ctx context.Context, events []domain.GeofenceEvent) ([]domain.GeofenceEvent, error) {
+	created := make([]domain.GeofenceEvent, 0, len(events))
+
+	err := r.execTx(ctx, func(q *postgresQueries) error {
+		for _, event := range events {
+			event, err := q.createGeofenceEvent(ctx, event)
+			if err != nil {
+				return err
+			}
+			created = append(created, event)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+var listGeofenceEventsQuery = fmt.Sprintf(
+	`
+SELECT id, geofence_id, user_id, type, point, created_at
+FROM %s
+WHERE ($1 = 0 OR geofence_id = $1) AND ($2 = 0 OR user_id = $2) AND id > $3
+ORDER BY id
+LIMIT $4
+`,
+	GeofenceEventTable,
+)
+
+// ListGeofenceEvents finds no more than `arg.PageSize` geofence events with IDs greater than `arg.PageToken`.
+//
+// Events are filtered by `arg.GeofenceID` and `arg.UserID` unless they equal 0.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of an event list ordered by ID and next page token.
+//
+// An event list that equals nil should be considered as empty.
+// Next page token is ID of last found event if required amount of events found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListGeofenceEvents(ctx context.Context, arg port.GeofenceRepositoryListGeofenceEventsRequest) (port.GeofenceRepositoryListGeofenceEventsResponse, error) {
+	// Fetch PageSize + 1 (extra marker element)
+	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
+	rows, err := q.db.QueryContext(ctx, listGeofenceEventsQuery, arg.GeofenceID, arg.UserID, arg.PageToken, arg.PageSize+1)
+	if err != nil {
+		return port.GeofenceRepositoryListGeofenceEventsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	var events []domain.GeofenceEvent
+	hasNextPage := false
+	for rows.Next() {
+		if len(events) == arg.PageSize { // Next page exists.
+			hasNextPage = true
+			break // Do not scan extra marker element.
+		}
+
+		var event domain.GeofenceEvent
+		var point geo.PostgresPoint
+		if err = rows.Scan(
+			&event.ID,
+			&event.GeofenceID,
+			&event.UserID,
+			&event.Type,
+			&point,
+			&event.CreatedAt,
+		); err != nil {
+			return port.GeofenceRepositoryListGeofenceEventsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		event.Point = geo.Point(point)
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return port.GeofenceRepositoryListGeofenceEventsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	result := port.GeofenceRepositoryListGeofenceEventsResponse{
+		Events: events,
+	}
+	if hasNextPage {
+		result.NextPageToken = events[len(events)-1].ID
+	}
+
+	return result, nil
+}
@@ -0,0 +1,166 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func (s *PostgresTestSuite) Test_PostgresQueries_Geofence_CRUD() {
+	repo := repository.NewPostgresRepository(s.db)
+	ctx := context.Background()
+	center := geo.Point{10, 20}
+
+	circle, err := repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name:      "office",
+		Type:      domain.GeofenceTypeCircle,
+		Center:    &center,
+		Radius:    100,
+		DwellTime: 60,
+	})
+	require.NoError(s.T(), err)
+	require.NotZero(s.T(), circle.ID)
+	require.Equal(s.T(), &center, circle.Center)
+	require.Equal(s.T(), 100.0, circle.Radius)
+	require.Nil(s.T(), circle.Polygon)
+
+	_, err = repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name:   "office",
+		Type:   domain.GeofenceTypeCircle,
+		Center: &center,
+		Radius: 100,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrAlreadyExists)
+
+	polygon := geo.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}
+	updated, err := repo.UpdateGeofence(ctx, port.GeofenceRepositoryUpdateGeofenceRequest{
+		ID:      circle.ID,
+		Name:    "district",
+		Type:    domain.GeofenceTypePolygon,
+		Polygon: polygon,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), circle.ID, updated.ID)
+	require.Nil(s.T(), updated.Center)
+	require.Equal(s.T(), polygon, updated.Polygon)
+
+	got, err := repo.GetGeofence(ctx, circle.ID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), updated, got)
+
+	list, err := repo.ListGeofences(ctx, port.GeofenceRepositoryListGeofencesRequest{PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.Geofence{got}, list.Geofences)
+	require.Equal(s.T(), 0, list.NextPageToken)
+
+	require.NoError(s.T(), repo.DeleteGeofence(ctx, circle.ID))
+	require.ErrorIs(s.T(), repo.DeleteGeofence(ctx, circle.ID), errpack.ErrNotFound)
+
+	_, err = repo.GetGeofence(ctx, circle.ID)
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_ListGeofencesContaining() {
+	repo := repository.NewPostgresRepository(s.db)
+	ctx := context.Background()
+	center := geo.Point{0, 0}
+
+	circle, err := repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name:   "circle",
+		Type:   domain.GeofenceTypeCircle,
+		Center: &center,
+		Radius: 1000,
+	})
+	require.NoError(s.T(), err)
+
+	// Crosses antimeridian and has a hole.
+	polygon, err := repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name: "polygon",
+		Type: domain.GeofenceTypePolygon,
+		Polygon: geo.Polygon{
+			{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}, {170, 0}},
+			{{-177, 4}, {-174, 4}, {-174, 6}, {-177, 6}, {-177, 4}},
+		},
+	})
+	require.NoError(s.T(), err)
+
+	res, err := repo.ListGeofencesContaining(ctx, geo.Point{0.001, 0.001})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.Geofence{circle}, res)
+
+	res, err = repo.ListGeofencesContaining(ctx, geo.Point{-179.5, 5})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.Geofence{polygon}, res)
+
+	res, err = repo.ListGeofencesContaining(ctx, geo.Point{-175.5, 5})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), res)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_GeofenceEvents() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+	ctx := context.Background()
+	center := geo.Point{0, 0}
+
+	geofence, err := repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name:      "circle",
+		Type:      domain.GeofenceTypeCircle,
+		Center:    &center,
+		Radius:    1000,
+		DwellTime: 60,
+	})
+	require.NoError(s.T(), err)
+
+	enteredAt := time.Now().UTC().Truncate(time.Second)
+	events, err := repo.CreateGeofenceEvents(ctx, []domain.GeofenceEvent{
+		{GeofenceID: geofence.ID, UserID: users[0].ID, Type: domain.GeofenceEventEnter, Point: center, CreatedAt: enteredAt},
+		{GeofenceID: geofence.ID, UserID: users[1].ID, Type: domain.GeofenceEventEnter, Point: center, CreatedAt: enteredAt},
+		{GeofenceID: geofence.ID, UserID: users[1].ID, Type: domain.GeofenceEventDwell, Point: center, CreatedAt: enteredAt},
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), events, 3)
+	for _, e := range events {
+		require.NotZero(s.T(), e.ID)
+	}
+
+	presences, err := repo.ListGeofencePresences(ctx, users[0].ID, []int{geofence.ID})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), presences, 1)
+	require.False(s.T(), presences[0].Dwelled)
+	require.True(s.T(), enteredAt.Equal(presences[0].EnteredAt))
+
+	presences, err = repo.ListGeofencePresences(ctx, users[1].ID, []int{geofence.ID})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), presences, 1)
+	require.True(s.T(), presences[0].Dwelled)
+
+	res, err := repo.ListGeofenceEvents(ctx, port.GeofenceRepositoryListGeofenceEventsRequest{
+		UserID:   users[1].ID,
+		PageSize: 1,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Events, 1)
+	require.Equal(s.T(), events[1].ID, res.Events[0].ID)
+	require.Equal(s.T(), events[1].ID, res.NextPageToken)
+
+	res, err = repo.ListGeofenceEvents(ctx, port.GeofenceRepositoryListGeofenceEventsRequest{
+		UserID:    users[1].ID,
+		PageToken: res.NextPageToken,
+		PageSize:  1,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Events, 1)
+	require.Equal(s.T(), events[2].ID, res.Events[0].ID)
+	require.Equal(s.T(), 0, res.NextPageToken)
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+// idempotencyKeyID identifies an idempotency key, keys are unique per user.
+type idempotencyKeyID struct {
+	username string
+	key      string
+}
+
+// cloneIdempotencyKey returns a copy of the key that does not share its response with k.
+func cloneIdempotencyKey(k domain.IdempotencyKey) domain.IdempotencyKey {
+	if k.Response != nil {
+		k.Response = append([]byte(nil), k.Response...)
+	}
+	return k
+}
+
+// ReserveIdempotencyKey reserves an idempotency key of a user for a request.
+//
+// It returns a response and any error encountered.
+// The key is reserved if it is not reserved yet, the previous reservation is expired
+// or it is not completed within `arg.Lease`. Otherwise the response contains the key reserved before.
+func (r *memoryRepository) ReserveIdempotencyKey(ctx context.Context, arg port.UserRepositoryReserveIdempotencyKeyRequest) (port.UserRepositoryReserveIdempotencyKeyResponse, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := idempotencyKeyID{username: arg.Username, key: arg.Key}
+	now := memoryNow()
+	if key, ok := r.idempotencyKeys[id]; ok && now.Before(key.ExpiresAt) &&
+		(arg.Lease <= 0 || key.Response != nil || now.Before(key.CreatedAt.Add(arg.Lease))) {
+		return port.UserRepositoryReserveIdempotencyKeyResponse{Key: cloneIdempotencyKey(key)}, nil
+	}
+
+	key := domain.IdempotencyKey{
+		Username:    arg.Username,
+		Key:         arg.Key,
+		RequestHash: arg.RequestHash,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(arg.TTL),
+	}
+	r.idempotencyKeys[id] = key
+
+	return port.UserRepositoryReserveIdempotencyKeyResponse{Key: key, Reserved: true}, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request an idempotency key is reserved for.
+//
+// `ErrNotFound` is returned in case the key is not reserved or is completed already.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) CompleteIdempotencyKey(ctx context.Context, arg port.UserRepositoryCompleteIdempotencyKeyRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := idempotencyKeyID{username: arg.Username, key: arg.Key}
+	key, ok := r.idempotencyKeys[id]
+	if !ok || key.Response != nil {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	key.Response = append([]byte(nil), arg.Response...)
+	r.idempotencyKeys[id] = key
+
+	return nil
+}
+
+// ReleaseIdempotencyKey deletes an idempotency key reserved for a request that is not completed,
+// so that the request may be sent with the key again. Completed keys are kept.
+func (r *memoryRepository) ReleaseIdempotencyKey(ctx context.Context, username, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id := idempotencyKeyID{username: username, key: key}
+	if k, ok := r.idempotencyKeys[id]; ok && k.Response == nil {
+		delete(r.idempotencyKeys, id)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes expired idempotency keys and returns the number of deleted keys.
+func (r *memoryRepository) DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := memoryNow()
+	deleted := 0
+	for id, key := range r.idempotencyKeys {
+		if !now.Before(key.ExpiresAt) {
+			delete(r.idempotencyKeys, id)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// renameUserIdempotencyKeys moves idempotency keys of a user to its new username, so that retries sent after
+// the rename are still recognized and a user that takes the old username does not get the keys.
+// It is meant to be called in the scope of a transaction.
+func (r *memoryRepository) renameUserIdempotencyKeys(tx *memoryTx, username, newUsername string) {
+	for id, key := range r.idempotencyKeys {
+		if id.username != username {
+			continue
+		}
+
+		id, key := id, key
+		newID := idempotencyKeyID{username: newUsername, key: id.key}
+		renamed := key
+		renamed.Username = newUsername
+		delete(r.idempotencyKeys, id)
+		r.idempotencyKeys[newID] = renamed
+		tx.onRollback(func() {
+			delete(r.idempotencyKeys, newID)
+			r.idempotencyKeys[id] = key
+		})
+	}
+}
+
+// deleteUserIdempotencyKeys deletes idempotency keys of a user, since they keep responses with locations of the user.
+// It is meant to be called in the scope of a transaction.
+func (r *memoryRepository) deleteUserIdempotencyKeys(tx *memoryTx, username string) {
+	for id, key := range r.idempotencyKeys {
+		if id.username != username {
+			continue
+		}
+
+		id, key := id, key
+		delete(r.idempotencyKeys, id)
+		tx.onRollback(func() {
+			r.idempotencyKeys[id] = key
+		})
+	}
+}
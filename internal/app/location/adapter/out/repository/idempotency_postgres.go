@@ -0,0 +1,216 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+const idempotencyKeyColumns = "username, idempotency_key, request_hash, response, created_at, expires_at"
+
+// scanIdempotencyKey scans an idempotency key selected with `idempotencyKeyColumns` columns.
+func scanIdempotencyKey(row rowScanner) (domain.IdempotencyKey, error) {
+	var key domain.IdempotencyKey
+	if err := row.Scan(
+		&key.Username,
+		&key.Key,
+		&key.RequestHash,
+		&key.Response,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+	); err != nil {
+		return domain.IdempotencyKey{}, err
+	}
+
+	return key, nil
+}
+
+// reserveIdempotencyKeyQuery inserts a key or replaces an expired one or one that is not completed within the lease.
+// It returns no rows if the key is reserved and not expired.
+var reserveIdempotencyKeyQuery = fmt.Sprintf(
+	`
+INSERT INTO %[1]s
+(username, idempotency_key, request_hash, expires_at)
+VALUES ($1, $2, $3, current_timestamp + $4 * interval '1 microsecond')
+ON CONFLICT (username, idempotency_key) DO UPDATE
+SET request_hash = EXCLUDED.request_hash,
+	response = NULL,
+	created_at = current_timestamp,
+	expires_at = EXCLUDED.expires_at
+WHERE %[1]s.expires_at <= current_timestamp
+	OR ($5::bigint > 0 AND %[1]s.response IS NULL
+		AND %[1]s.created_at <= current_timestamp - $5::bigint * interval '1 microsecond')
+RETURNING %[2]s
+`,
+	IdempotencyKeyTable,
+	idempotencyKeyColumns,
+)
+
+var getIdempotencyKeyQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s
+WHERE username = $1 AND idempotency_key = $2
+`,
+	idempotencyKeyColumns,
+	IdempotencyKeyTable,
+)
+
+// ReserveIdempotencyKey reserves an idempotency key of a user for a request.
+//
+// It returns a response and any error encountered.
+// The key is reserved if it is not reserved yet, the previous reservation is expired
+// or it is not completed within `arg.Lease`. Otherwise the response contains the key reserved before.
+//
+// `ErrInternalError` is returned in case of any failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ReserveIdempotencyKey(ctx context.Context, arg port.UserRepositoryReserveIdempotencyKeyRequest) (port.UserRepositoryReserveIdempotencyKeyResponse, error) {
+	// The reserved key may be released between the queries, so the reservation is tried once again.
+	for attempt := 0; attempt < 2; attempt++ {
+		key, err := scanIdempotencyKey(q.db.QueryRowContext(
+			ctx, reserveIdempotencyKeyQuery, arg.Username, arg.Key, arg.RequestHash, arg.TTL.Microseconds(), arg.Lease.Microseconds(),
+		))
+		if err == nil {
+			return port.UserRepositoryReserveIdempotencyKeyResponse{Key: key, Reserved: true}, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return port.UserRepositoryReserveIdempotencyKeyResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+
+		key, err = scanIdempotencyKey(q.db.QueryRowContext(ctx, getIdempotencyKeyQuery, arg.Username, arg.Key))
+		if err == nil {
+			return port.UserRepositoryReserveIdempotencyKeyResponse{Key: key}, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return port.UserRepositoryReserveIdempotencyKeyResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+	}
+
+	return port.UserRepositoryReserveIdempotencyKeyResponse{}, fmt.Errorf("%w: idempotency key is released concurrently", errpack.ErrInternalError)
+}
+
+var completeIdempotencyKeyQuery = fmt.Sprintf(
+	`
+UPDATE %s
+SET response = $3
+WHERE username = $1 AND idempotency_key = $2 AND response IS NULL
+`,
+	IdempotencyKeyTable,
+)
+
+// CompleteIdempotencyKey stores the response of the request an idempotency key is reserved for.
+//
+// `ErrNotFound` is returned in case the key is not reserved or is completed already.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) CompleteIdempotencyKey(ctx context.Context, arg port.UserRepositoryCompleteIdempotencyKeyRequest) error {
+	res, err := q.db.ExecContext(ctx, completeIdempotencyKeyQuery, arg.Username, arg.Key, string(arg.Response))
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	return nil
+}
+
+var releaseIdempotencyKeyQuery = fmt.Sprintf(
+	`
+DELETE FROM %s
+WHERE username = $1 AND idempotency_key = $2 AND response IS NULL
+`,
+	IdempotencyKeyTable,
+)
+
+// ReleaseIdempotencyKey deletes an idempotency key reserved for a request that is not completed,
+// so that the request may be sent with the key again. Completed keys are kept.
+//
+// `ErrInternalError` is returned in case of any failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ReleaseIdempotencyKey(ctx context.Context, username, key string) error {
+	if _, err := q.db.ExecContext(ctx, releaseIdempotencyKeyQuery, username, key); err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return nil
+}
+
+var deleteExpiredIdempotencyKeysQuery = fmt.Sprintf(
+	`
+DELETE FROM %s
+WHERE expires_at <= current_timestamp
+`,
+	IdempotencyKeyTable,
+)
+
+// DeleteExpiredIdempotencyKeys deletes expired idempotency keys and returns the number of deleted keys.
+//
+// `ErrInternalError` is returned in case of any failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error) {
+	res, err := q.db.ExecContext(ctx, deleteExpiredIdempotencyKeysQuery)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return int(n), nil
+}
+
+var renameUserIdempotencyKeysQuery = fmt.Sprintf(
+	`
+UPDATE %s
+SET username = $2
+WHERE username = $1
+`,
+	IdempotencyKeyTable,
+)
+
+// renameUserIdempotencyKeys moves idempotency keys of a user to its new username, so that retries sent after
+// the rename are still recognized and a user that takes the old username does not get the keys.
+// It is meant to be called in the scope of the database transaction.
+func (q *postgresQueries) renameUserIdempotencyKeys(ctx context.Context, username, newUsername string) error {
+	if _, err := q.db.ExecContext(ctx, renameUserIdempotencyKeysQuery, username, newUsername); err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return nil
+}
+
+var deleteUserIdempotencyKeysQuery = fmt.Sprintf(
+	`
+DELETE FROM %s
+WHERE username = $1
+`,
+	IdempotencyKeyTable,
+)
+
+// deleteUserIdempotencyKeys deletes idempotency keys of a user, since they keep responses with locations of the user.
+// It is meant to be called in the scope of the database transaction.
+func (q *postgresQueries) deleteUserIdempotencyKeys(ctx context.Context, username string) error {
+	if _, err := q.db.ExecContext(ctx, deleteUserIdempotencyKeysQuery, username); err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return nil
+}
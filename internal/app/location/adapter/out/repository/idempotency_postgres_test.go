@@ -0,0 +1,129 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+func (s *PostgresTestSuite) Test_PostgresQueries_IdempotencyKeys() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+	arg := port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username:    users[0].Username,
+		Key:         "key1",
+		RequestHash: "hash1",
+		TTL:         time.Hour,
+	}
+
+	res, err := repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+	require.Nil(s.T(), res.Key.Response)
+	require.WithinDuration(s.T(), res.Key.CreatedAt.Add(time.Hour), res.Key.ExpiresAt, time.Second)
+
+	res, err = repo.ReserveIdempotencyKey(ctx, port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username:    users[0].Username,
+		Key:         "key1",
+		RequestHash: "hash2",
+		TTL:         time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.False(s.T(), res.Reserved)
+	require.Equal(s.T(), "hash1", res.Key.RequestHash)
+
+	err = repo.CompleteIdempotencyKey(ctx, port.UserRepositoryCompleteIdempotencyKeyRequest{
+		Username: users[0].Username,
+		Key:      "key1",
+		Response: []byte(`{"latitude":10}`),
+	})
+	require.NoError(s.T(), err)
+	err = repo.CompleteIdempotencyKey(ctx, port.UserRepositoryCompleteIdempotencyKeyRequest{
+		Username: users[0].Username,
+		Key:      "key2",
+		Response: []byte(`{"latitude":10}`),
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	// Completed keys are not released.
+	require.NoError(s.T(), repo.ReleaseIdempotencyKey(ctx, users[0].Username, "key1"))
+	res, err = repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.False(s.T(), res.Reserved)
+	require.JSONEq(s.T(), `{"latitude":10}`, string(res.Key.Response))
+
+	// Keys reserved with zero TTL are expired at once, so they are reserved again and deleted.
+	expiredArg := port.UserRepositoryReserveIdempotencyKeyRequest{Username: users[0].Username, Key: "key2"}
+	res, err = repo.ReserveIdempotencyKey(ctx, expiredArg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+	res, err = repo.ReserveIdempotencyKey(ctx, expiredArg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+
+	n, err := repo.DeleteExpiredIdempotencyKeys(ctx)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, n)
+
+	// Keys of deleted users are deleted as well.
+	_, err = repo.DeleteUser(ctx, users[0].Username)
+	require.NoError(s.T(), err)
+	res, err = repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+
+	// Keys that are not completed within the lease are reserved again.
+	time.Sleep(time.Millisecond)
+	leasedArg := arg
+	leasedArg.Lease = time.Millisecond
+	res, err = repo.ReserveIdempotencyKey(ctx, leasedArg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+}
+
+func (s *PostgresTestSuite) Test_PostgresRepository_RenameUser_IdempotencyKeys() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+	arg := port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username:    users[0].Username,
+		Key:         "key1",
+		RequestHash: "hash1",
+		TTL:         time.Hour,
+	}
+
+	_, err := repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), repo.CompleteIdempotencyKey(ctx, port.UserRepositoryCompleteIdempotencyKeyRequest{
+		Username: users[0].Username,
+		Key:      "key1",
+		Response: []byte(`{"latitude":10}`),
+	}))
+
+	_, err = repo.RenameUser(ctx, port.UserRepositoryRenameUserRequest{Username: users[0].Username, NewUsername: "renamed"})
+	require.NoError(s.T(), err)
+
+	// Keys move along with the user.
+	renamedArg := arg
+	renamedArg.Username = "renamed"
+	res, err := repo.ReserveIdempotencyKey(ctx, renamedArg)
+	require.NoError(s.T(), err)
+	require.False(s.T(), res.Reserved)
+	require.JSONEq(s.T(), `{"latitude":10}`, string(res.Key.Response))
+
+	// A user taking the old username does not get the keys.
+	res, err = repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+}
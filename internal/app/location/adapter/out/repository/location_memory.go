@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// validFixMetadata reports whether the fix metadata satisfies the constraint of locations table.
+func validFixMetadata(m geo.FixMetadata) bool {
+	return (m.Accuracy == nil || *m.Accuracy >= 0) &&
+		(m.Speed == nil || *m.Speed >= 0) &&
+		(m.Bearing == nil || geo.ValidBearing(*m.Bearing)) &&
+		(m.Source == "" || geo.ValidFixSource(m.Source))
+}
+
+// cloneLocation returns a copy of the location that does not share pointers with l.
+func cloneLocation(l domain.Location) domain.Location {
+	l.FixMetadata = cloneFixMetadata(l.FixMetadata)
+	return l
+}
+
+// SetLocation sets location of the user with `arg.UserID` the same way the postgres repository does it.
+// The point is truncated to `geo.PointPrecision` digits.
+//
+// Returns the created or updated location and `error`.
+//
+// `ErrFailedPrecondition` is returned, in case there is no user with given id.
+//
+// `ErrInvalidArgument` is returned in case given point's longitude or latitude or fix metadata is invalid.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`, so use `errors.Is()` to compare returned error.
+func (r *memoryRepository) SetLocation(ctx context.Context, arg port.LocationRepositorySetLocationRequest) (domain.Location, error) {
+	var location domain.Location
+
+	err := r.execTx(func(tx *memoryTx) error {
+		var err error
+		location, err = r.setLocation(tx, arg)
+		return err
+	})
+	if err != nil {
+		return domain.Location{}, err
+	}
+
+	return location, nil
+}
+
+// setLocation sets location of a user. It is meant to be called in the scope of a transaction.
+func (r *memoryRepository) setLocation(tx *memoryTx, arg port.LocationRepositorySetLocationRequest) (domain.Location, error) {
+	if !geo.ValidPoint(arg.Point) || !validFixMetadata(arg.FixMetadata) {
+		return domain.Location{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+	if _, ok := r.users[arg.UserID]; !ok {
+		return domain.Location{}, fmt.Errorf("%w", errpack.ErrFailedPrecondition)
+	}
+
+	now := memoryNow()
+	location := domain.Location{
+		UserID:      arg.UserID,
+		Point:       geo.Trunc(arg.Point),
+		RecordedAt:  arg.RecordedAt.UTC().Truncate(time.Microsecond),
+		FixMetadata: cloneFixMetadata(arg.FixMetadata),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if arg.RecordedAt.IsZero() {
+		location.RecordedAt = now
+	}
+
+	prev, exists := r.locations[arg.UserID]
+	if exists {
+		location.CreatedAt = prev.CreatedAt
+		r.unindexLocation(prev.UserID)
+	}
+	r.locations[location.UserID] = location
+	r.indexLocation(location.UserID)
+
+	tx.onRollback(func() {
+		r.unindexLocation(location.UserID)
+		delete(r.locations, location.UserID)
+		if exists {
+			r.locations[prev.UserID] = prev
+			r.indexLocation(prev.UserID)
+		}
+	})
+
+	return cloneLocation(location), nil
+}
+
+// GetLocation finds a location by given user id.
+//
+// It returns a found location and any error encountered.
+//
+// `ErrNotFound` is returned in case required location is not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`, so use `errors.Is()` to compare returned error.
+func (r *memoryRepository) GetLocation(ctx context.Context, userID int) (domain.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	location, ok := r.locations[userID]
+	if !ok {
+		return domain.Location{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	return cloneLocation(location), nil
+}
@@ -13,28 +13,40 @@ import (
 	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
 )
 
+// errLocationOutOfOrder is returned by SetLocation in case the stored location is recorded after the new one.
+var errLocationOutOfOrder = fmt.Errorf("%w: location is recorded before the stored one", errpack.ErrFailedPrecondition)
+
+// setLocationQuery keeps the stored location if it is recorded after the new one, so that concurrent writes
+// of out of order locations cannot overwrite more recent ones. It returns no rows in that case.
 var setLocationQuery = fmt.Sprintf(
 	`
-INSERT INTO %s
-(user_id, point)
-VALUES ($1, $2)
+INSERT INTO %[1]s
+(user_id, point, recorded_at, accuracy, altitude, speed, bearing, source)
+VALUES ($1, $2, COALESCE($3::timestamp, current_timestamp), $4, $5, $6, $7, $8)
 ON CONFLICT ON CONSTRAINT locations_pkey 
 DO
-	UPDATE SET point = EXCLUDED.point
-RETURNING user_id, point, created_at, updated_at
+	UPDATE SET point = EXCLUDED.point, recorded_at = EXCLUDED.recorded_at,
+		accuracy = EXCLUDED.accuracy, altitude = EXCLUDED.altitude, speed = EXCLUDED.speed,
+		bearing = EXCLUDED.bearing, source = EXCLUDED.source
+	WHERE $3::timestamp IS NULL OR %[1]s.recorded_at <= EXCLUDED.recorded_at
+RETURNING user_id, point, recorded_at, accuracy, altitude, speed, bearing, source, created_at, updated_at
 `,
 	LocationTable,
 )
 
-// SetLocation adds a new record to locations table with `arg.UserID` as `user_id` and
-// `arg.Point` as `point`.
-// If a record with given id already exists it only updates point.
+// SetLocation adds a new record to locations table with `arg.UserID` as `user_id`,
+// `arg.Point` as `point`, `arg.RecordedAt` as `recorded_at` and fix metadata.
+// If a record with given id already exists it only updates point, recorded_at and fix metadata,
+// unless the record is recorded after non-zero `arg.RecordedAt`.
+// The current time is used as `recorded_at` if `arg.RecordedAt` is zero,
+// unknown fix metadata is stored as NULL.
 //
 // Returns `Location` populated with data from the created or updated record and `error`.
 //
-// `ErrFailedPrecondition` is returned, in case there is no user with given id.
+// `ErrFailedPrecondition` is returned, in case there is no user with given id or the existing record
+// is recorded after `arg.RecordedAt`.
 //
-// `ErrInvalidArgument` is returned in case given point's longitude or latitude is invalid.,
+// `ErrInvalidArgument` is returned in case given point's longitude or latitude or fix metadata is invalid.
 //
 // `ErrInvalidError` is returned in case of any other failure.
 //
@@ -42,12 +54,37 @@ RETURNING user_id, point, created_at, updated_at
 func (q *postgresQueries) SetLocation(ctx context.Context, arg port.LocationRepositorySetLocationRequest) (domain.Location, error) {
 	var location domain.Location
 	var pgPoint geo.PostgresPoint
-	if err := q.db.QueryRowContext(ctx, setLocationQuery, arg.UserID, geo.PostgresPoint(arg.Point)).Scan(
+	var source sql.NullString
+
+	recordedAt := sql.NullTime{Time: arg.RecordedAt, Valid: !arg.RecordedAt.IsZero()}
+
+	if err := q.db.QueryRowContext(
+		ctx,
+		setLocationQuery,
+		arg.UserID,
+		geo.PostgresPoint(arg.Point),
+		recordedAt,
+		arg.Accuracy,
+		arg.Altitude,
+		arg.Speed,
+		arg.Bearing,
+		sql.NullString{String: string(arg.Source), Valid: arg.Source != ""},
+	).Scan(
 		&location.UserID,
 		&pgPoint,
+		&location.RecordedAt,
+		&location.Accuracy,
+		&location.Altitude,
+		&location.Speed,
+		&location.Bearing,
+		&source,
 		&location.CreatedAt,
 		&location.UpdatedAt,
 	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Location{}, errLocationOutOfOrder
+		}
+
 		var pqErr *pq.Error
 		if errors.As(err, &pqErr) {
 			switch pqErr.Constraint {
@@ -57,19 +94,22 @@ func (q *postgresQueries) SetLocation(ctx context.Context, arg port.LocationRepo
 				return domain.Location{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
 			case ConstraintLocationsLongitudeValid:
 				return domain.Location{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+			case ConstraintLocationsFixMetadataValid:
+				return domain.Location{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
 			}
 		}
 		return domain.Location{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
 	}
 
 	location.Point = geo.Point(pgPoint)
+	location.Source = geo.FixSource(source.String)
 
 	return location, nil
 }
 
 var getLocationQuery = fmt.Sprintf(
 	`
-SELECT user_id, point, created_at, updated_at
+SELECT user_id, point, recorded_at, accuracy, altitude, speed, bearing, source, created_at, updated_at
 FROM %s
 WHERE user_id = $1
 `,
@@ -88,10 +128,17 @@ WHERE user_id = $1
 func (q *postgresQueries) GetLocation(ctx context.Context, userID int) (domain.Location, error) {
 	var location domain.Location
 	var point geo.PostgresPoint
+	var source sql.NullString
 
 	if err := q.db.QueryRowContext(ctx, getLocationQuery, userID).Scan(
 		&location.UserID,
 		&point,
+		&location.RecordedAt,
+		&location.Accuracy,
+		&location.Altitude,
+		&location.Speed,
+		&location.Bearing,
+		&source,
 		&location.CreatedAt,
 		&location.UpdatedAt,
 	); err != nil {
@@ -102,6 +149,7 @@ func (q *postgresQueries) GetLocation(ctx context.Context, userID int) (domain.L
 	}
 
 	location.Point = geo.Point(point)
+	location.Source = geo.FixSource(source.String)
 
 	return location, nil
 }
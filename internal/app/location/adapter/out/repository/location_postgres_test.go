@@ -32,6 +32,14 @@ func (s *PostgresTestSuite) Test_PostgresQueries_SetLocation() {
 	}
 	locations := s.seedLocations(setLocationArgs)
 
+	accuracy, speed, bearing, invalidBearing := 8.0, 2.5, 45.0, 360.0
+	metadata := geo.FixMetadata{
+		Accuracy: &accuracy,
+		Speed:    &speed,
+		Bearing:  &bearing,
+		Source:   geo.FixSourceNetwork,
+	}
+
 	testCases := []struct {
 		name   string
 		arg    port.LocationRepositorySetLocationRequest
@@ -77,6 +85,50 @@ func (s *PostgresTestSuite) Test_PostgresQueries_SetLocation() {
 				require.WithinDuration(t, time.Now(), location.UpdatedAt, time.Second)
 			},
 		},
+		{
+			name: "OK_FixMetadata",
+			arg: port.LocationRepositorySetLocationRequest{
+				UserID:      users[1].ID,
+				Point:       geo.Point{2.0, 2.0},
+				FixMetadata: metadata,
+			},
+			hasErr: false,
+			isErr:  nil,
+			asErr:  nil,
+			assert: func(t *testing.T, location domain.Location, err error) {
+				require.Equal(t, metadata, location.FixMetadata)
+
+				stored, err := repository.NewPostgresRepository(s.db).GetLocation(context.Background(), users[1].ID)
+				require.NoError(t, err)
+				require.Equal(t, metadata, stored.FixMetadata)
+			},
+		},
+		{
+			name: "OK_FixMetadataCleared",
+			arg: port.LocationRepositorySetLocationRequest{
+				UserID: users[1].ID,
+				Point:  geo.Point{3.0, 3.0},
+			},
+			hasErr: false,
+			isErr:  nil,
+			asErr:  nil,
+			assert: func(t *testing.T, location domain.Location, err error) {
+				require.Empty(t, location.FixMetadata)
+			},
+		},
+		{
+			name: "ErrCheck_FixMetadataInvalid",
+			arg: port.LocationRepositorySetLocationRequest{
+				UserID:      users[0].ID,
+				Point:       geo.Point{1.0, 1.0},
+				FixMetadata: geo.FixMetadata{Bearing: &invalidBearing},
+			},
+			hasErr: true,
+			isErr:  errpack.ErrInvalidArgument,
+			assert: func(t *testing.T, location domain.Location, err error) {
+				require.Empty(t, location)
+			},
+		},
 		{
 			name: "ErrForeignKey_UserDoesNotExist_LocationDoesNotExist",
 			arg: port.LocationRepositorySetLocationRequest{
@@ -102,6 +154,19 @@ func (s *PostgresTestSuite) Test_PostgresQueries_SetLocation() {
 				require.Empty(t, location)
 			},
 		},
+		{
+			name: "ErrCheck_RecordedBeforeStored",
+			arg: port.LocationRepositorySetLocationRequest{
+				UserID:     users[0].ID,
+				Point:      geo.Point{1.0, 1.0},
+				RecordedAt: locations[0].RecordedAt.Add(-time.Hour),
+			},
+			hasErr: true,
+			isErr:  errpack.ErrFailedPrecondition,
+			assert: func(t *testing.T, location domain.Location, err error) {
+				require.Empty(t, location)
+			},
+		},
 		{
 			name: "ErrCheck_LattitudeGreaterThenMax",
 			arg: port.LocationRepositorySetLocationRequest{
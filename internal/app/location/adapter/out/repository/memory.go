@@ -0,0 +1,255 @@
+package repository
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// DriverMemory is a DB_DRIVER config value that selects the in-memory repository.
+const DriverMemory = "memory"
+
+// memoryGridCellSize is a size in degrees of cells of the spatial index of locations.
+const memoryGridCellSize = 0.1
+
+type memoryRepository struct {
+	mu sync.RWMutex
+
+	users       map[int]domain.User
+	userIDs     map[string]int
+	locations   map[int]domain.Location
+	grid        *gridIndex
+	geofences   map[int]domain.Geofence
+	geofenceIDs map[string]int
+	events      []domain.GeofenceEvent
+	erasureJobs map[int]domain.ErasureJob
+	privacy     map[int]domain.PrivacySettings
+	follows     map[socialPair]domain.Follow
+	blocks      map[socialPair]domain.Block
+
+	idempotencyKeys map[idempotencyKeyID]domain.IdempotencyKey
+
+	// Sequences of IDs. Like database sequences, they are not rolled back.
+	lastUserID       int
+	lastGeofenceID   int
+	lastEventID      int
+	lastErasureJobID int
+}
+
+// NewMemoryRepository returns a new instance of port.Repository that keeps all data in memory.
+//
+// It follows the same rules and returns the same errors the postgres repository does,
+// so it can be used in place of it in tests and local runs. Data is lost on restart.
+func NewMemoryRepository() port.Repository {
+	return &memoryRepository{
+		users:       make(map[int]domain.User),
+		userIDs:     make(map[string]int),
+		locations:   make(map[int]domain.Location),
+		grid:        newGridIndex(memoryGridCellSize),
+		geofences:   make(map[int]domain.Geofence),
+		geofenceIDs: make(map[string]int),
+		erasureJobs: make(map[int]domain.ErasureJob),
+		privacy:     make(map[int]domain.PrivacySettings),
+		follows:     make(map[socialPair]domain.Follow),
+		blocks:      make(map[socialPair]domain.Block),
+
+		idempotencyKeys: make(map[idempotencyKeyID]domain.IdempotencyKey),
+	}
+}
+
+// memoryTx records how to undo changes made in the scope of a transaction.
+type memoryTx struct {
+	undo []func()
+}
+
+// onRollback registers fn to be called in case the transaction is rolled back.
+func (tx *memoryTx) onRollback(fn func()) {
+	tx.undo = append(tx.undo, fn)
+}
+
+// rollback undoes all changes made in the scope of the transaction in reverse order.
+func (tx *memoryTx) rollback() {
+	for i := len(tx.undo) - 1; i >= 0; i-- {
+		tx.undo[i]()
+	}
+}
+
+// execTx executes provided callback holding the write lock of the repository.
+//
+// All changes made by the callback are rolled back if it returns an error.
+// The error is returned as is, so the callback is expected to return errors wrapped with `fmt.Errorf("%w", err)`.
+func (r *memoryRepository) execTx(fn func(*memoryTx) error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx := &memoryTx{}
+	if err := fn(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+
+	return nil
+}
+
+// memoryNow returns the current time with the precision of the database timestamps.
+func memoryNow() time.Time {
+	return time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// fresh reports whether a location updated at `updatedAt` is not older than `maxAge`.
+// Every location is fresh if `maxAge` equals 0.
+func fresh(updatedAt time.Time, maxAge time.Duration, now time.Time) bool {
+	return maxAge == 0 || !updatedAt.Before(now.Add(-maxAge))
+}
+
+// gridCell is a cell of the grid index, `x` is the column counted from the antimeridian to the east
+// and `y` is the row counted from the south pole to the north.
+type gridCell struct {
+	x, y int
+}
+
+// gridIndex is a spatial index that splits the Earth into cells of equal size in degrees.
+type gridIndex struct {
+	cellSize   float64
+	cols, rows int
+	cells      map[gridCell]map[int]struct{}
+}
+
+func newGridIndex(cellSize float64) *gridIndex {
+	return &gridIndex{
+		cellSize: cellSize,
+		cols:     int(math.Ceil(360 / cellSize)),
+		rows:     int(math.Ceil(180 / cellSize)),
+		cells:    make(map[gridCell]map[int]struct{}),
+	}
+}
+
+// col returns the column of the longitude, the longitude may be beyond the antimeridian.
+func (g *gridIndex) col(longitude float64) int {
+	x := int(math.Floor((longitude + 180) / g.cellSize))
+	return ((x % g.cols) + g.cols) % g.cols
+}
+
+// row returns the row of the latitude.
+func (g *gridIndex) row(latitude float64) int {
+	y := int(math.Floor((latitude + 90) / g.cellSize))
+	if y < 0 {
+		return 0
+	}
+	if y >= g.rows {
+		return g.rows - 1
+	}
+	return y
+}
+
+func (g *gridIndex) cell(p geo.Point) gridCell {
+	return gridCell{x: g.col(p.Longitude()), y: g.row(p.Latitude())}
+}
+
+func (g *gridIndex) insert(id int, p geo.Point) {
+	cell := g.cell(p)
+	ids, ok := g.cells[cell]
+	if !ok {
+		ids = make(map[int]struct{})
+		g.cells[cell] = ids
+	}
+	ids[id] = struct{}{}
+}
+
+func (g *gridIndex) remove(id int, p geo.Point) {
+	cell := g.cell(p)
+	ids := g.cells[cell]
+	delete(ids, id)
+	if len(ids) == 0 {
+		delete(g.cells, cell)
+	}
+}
+
+// search calls fn for every id inside cells covering the given area.
+//
+// `west` may be greater than 180 and `east` may be less than -180 to cover areas crossing the antimeridian,
+// but `west` must not be greater than `east`. Ids outside the area may be passed to fn as well,
+// so their points must be checked in addition.
+func (g *gridIndex) search(west, south, east, north float64, fn func(id int)) {
+	x0 := g.col(west)
+	width := g.cols
+	if east-west < 360 {
+		width = int(math.Floor((east+180)/g.cellSize)) - int(math.Floor((west+180)/g.cellSize)) + 1
+		if width > g.cols {
+			width = g.cols
+		}
+	}
+	y0, y1 := g.row(south), g.row(north)
+
+	// Only occupied cells are checked if there are less of them than cells covering the area.
+	if width*(y1-y0+1) > len(g.cells) {
+		for cell, ids := range g.cells {
+			if cell.y < y0 || cell.y > y1 || ((cell.x-x0)%g.cols+g.cols)%g.cols >= width {
+				continue
+			}
+			for id := range ids {
+				fn(id)
+			}
+		}
+		return
+	}
+
+	for y := y0; y <= y1; y++ {
+		for i := 0; i < width; i++ {
+			for id := range g.cells[gridCell{x: (x0 + i) % g.cols, y: y}] {
+				fn(id)
+			}
+		}
+	}
+}
+
+// searchRadius calls fn for every id inside cells covering the circle with given center and radius in meters.
+// Ids outside the circle may be passed to fn as well, so their points must be checked in addition.
+func (g *gridIndex) searchRadius(center geo.Point, radius float64, fn func(id int)) {
+	// Angular radius of the circle, slightly enlarged to tolerate rounding errors.
+	angle := radius/geo.EarthRadius + 1e-9
+	if angle >= math.Pi {
+		g.search(-180, -90, 180, 90, fn)
+		return
+	}
+
+	lat := center.Latitude() * math.Pi / 180
+	south, north := lat-angle, lat+angle
+	if south <= -math.Pi/2 || north >= math.Pi/2 {
+		// The circle contains a pole, so it covers all longitudes.
+		g.search(-180, math.Max(south, -math.Pi/2)*180/math.Pi, 180, math.Min(north, math.Pi/2)*180/math.Pi, fn)
+		return
+	}
+
+	deltaLon := math.Asin(math.Sin(angle)/math.Cos(lat)) * 180 / math.Pi
+	g.search(
+		center.Longitude()-deltaLon,
+		south*180/math.Pi,
+		center.Longitude()+deltaLon,
+		north*180/math.Pi,
+		fn,
+	)
+}
+
+// cloneFixMetadata returns a copy of the fix metadata that does not share pointers with m.
+func cloneFixMetadata(m geo.FixMetadata) geo.FixMetadata {
+	clone := func(v *float64) *float64 {
+		if v == nil {
+			return nil
+		}
+		c := *v
+		return &c
+	}
+
+	return geo.FixMetadata{
+		Accuracy: clone(m.Accuracy),
+		Altitude: clone(m.Altitude),
+		Speed:    clone(m.Speed),
+		Bearing:  clone(m.Bearing),
+		Source:   m.Source,
+	}
+}
@@ -0,0 +1,967 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+type MemoryTestSuite struct {
+	suite.Suite
+
+	repo port.Repository
+}
+
+func (s *MemoryTestSuite) SetupTest() {
+	s.repo = repository.NewMemoryRepository()
+}
+
+func TestMemoryTestSuite(t *testing.T) {
+	suite.Run(t, new(MemoryTestSuite))
+}
+
+// setUserLocations sets given locations of users named "user0", "user1", etc.
+func (s *MemoryTestSuite) setUserLocations(points []geo.Point) []domain.User {
+	users := make([]domain.User, 0, len(points))
+	for i, point := range points {
+		res, err := s.repo.SetUserLocation(context.Background(), port.UserRepositorySetUserLocationRequest{
+			Username: fmt.Sprintf("user%d", i),
+			Point:    point,
+		})
+		require.NoError(s.T(), err)
+		users = append(users, res.User)
+	}
+	return users
+}
+
+// randomPoints returns n random points, a half of them is around the center.
+func randomPoints(rnd *rand.Rand, n int, center geo.Point) []geo.Point {
+	points := make([]geo.Point, 0, n)
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			points = append(points, geo.Point{rnd.Float64()*360 - 180, rnd.Float64()*180 - 90})
+			continue
+		}
+		lon := center.Longitude() + rnd.Float64()*4 - 2
+		if lon > 180 {
+			lon -= 360
+		} else if lon < -180 {
+			lon += 360
+		}
+		lat := center.Latitude() + rnd.Float64()*4 - 2
+		if lat > 90 {
+			lat = 180 - lat
+		} else if lat < -90 {
+			lat = -180 - lat
+		}
+		points = append(points, geo.Point{lon, lat})
+	}
+	return points
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_Users() {
+	ctx := context.Background()
+
+	user, err := s.repo.CreateUser(ctx, port.CreateUserArg{Username: "user1"})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, user.ID)
+	require.False(s.T(), user.CreatedAt.IsZero())
+
+	_, err = s.repo.CreateUser(ctx, port.CreateUserArg{Username: "user1"})
+	require.ErrorIs(s.T(), err, errpack.ErrAlreadyExists)
+	_, err = s.repo.CreateUser(ctx, port.CreateUserArg{Username: "usr"})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+	_, err = s.repo.CreateUser(ctx, port.CreateUserArg{Username: "user1234567890123"})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+
+	found, err := s.repo.GetByUsername(ctx, "user1")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), user, found)
+	_, err = s.repo.GetByUsername(ctx, "user2")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	_, err = s.repo.CreateUser(ctx, port.CreateUserArg{Username: "other"})
+	require.NoError(s.T(), err)
+
+	_, err = s.repo.RenameUser(ctx, port.UserRepositoryRenameUserRequest{Username: "user2", NewUsername: "user3"})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+	_, err = s.repo.RenameUser(ctx, port.UserRepositoryRenameUserRequest{Username: "user1", NewUsername: "other"})
+	require.ErrorIs(s.T(), err, errpack.ErrAlreadyExists)
+	_, err = s.repo.RenameUser(ctx, port.UserRepositoryRenameUserRequest{Username: "user1", NewUsername: "u$er"})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+	renamed, err := s.repo.RenameUser(ctx, port.UserRepositoryRenameUserRequest{Username: "user1", NewUsername: "user3"})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), user.ID, renamed.ID)
+	require.Equal(s.T(), "user3", renamed.Username)
+	_, err = s.repo.GetByUsername(ctx, "user1")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	list, err := s.repo.ListUsers(ctx, port.UserRepositoryListUsersRequest{PageSize: 1})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.User{renamed}, list.Users)
+	require.Equal(s.T(), renamed.ID, list.NextPageToken)
+
+	list, err = s.repo.ListUsers(ctx, port.UserRepositoryListUsersRequest{PageToken: list.NextPageToken, PageSize: 1})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), list.Users, 1)
+	require.Equal(s.T(), "other", list.Users[0].Username)
+	require.Zero(s.T(), list.NextPageToken)
+
+	list, err = s.repo.ListUsers(ctx, port.UserRepositoryListUsersRequest{Prefix: "use", PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.User{renamed}, list.Users)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_SetUserLocation() {
+	ctx := context.Background()
+	recordedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	accuracy := 5.0
+
+	res, err := s.repo.SetUserLocation(ctx, port.UserRepositorySetUserLocationRequest{
+		Username:    "user1",
+		Point:       geo.Point{10.123456789, 20},
+		RecordedAt:  recordedAt,
+		FixMetadata: geo.FixMetadata{Accuracy: &accuracy, Source: geo.FixSourceGPS},
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), "user1", res.User.Username)
+	require.Zero(s.T(), res.PrevLocation.UserID)
+	require.Equal(s.T(), geo.Point{10.12345678, 20}, res.Location.Point)
+	require.Equal(s.T(), recordedAt, res.Location.RecordedAt)
+	require.Equal(s.T(), accuracy, *res.Location.Accuracy)
+	require.False(s.T(), res.OutOfOrder)
+
+	// An out-of-order location is not set.
+	outOfOrder, err := s.repo.SetUserLocation(ctx, port.UserRepositorySetUserLocationRequest{
+		Username:   "user1",
+		Point:      geo.Point{30, 40},
+		RecordedAt: recordedAt.Add(-time.Second),
+	})
+	require.NoError(s.T(), err)
+	require.True(s.T(), outOfOrder.OutOfOrder)
+	require.Equal(s.T(), res.Location, outOfOrder.Location)
+
+	next, err := s.repo.SetUserLocation(ctx, port.UserRepositorySetUserLocationRequest{
+		Username: "user1",
+		Point:    geo.Point{30, 40},
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), res.Location, next.PrevLocation)
+	require.Equal(s.T(), res.Location.CreatedAt, next.Location.CreatedAt)
+	require.False(s.T(), next.Location.RecordedAt.IsZero())
+
+	found, err := s.repo.GetUserWithLocation(ctx, "user1")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), next.Location, *found.Location)
+
+	_, err = s.repo.SetUserLocation(ctx, port.UserRepositorySetUserLocationRequest{
+		Username:                "user2",
+		Point:                   geo.Point{30, 40},
+		DisableUserAutoCreation: true,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	// The user is not created if its location is invalid.
+	_, err = s.repo.SetUserLocation(ctx, port.UserRepositorySetUserLocationRequest{
+		Username: "user2",
+		Point:    geo.Point{30, 91},
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+	_, err = s.repo.GetByUsername(ctx, "user2")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	_, err = s.repo.SetLocation(ctx, port.LocationRepositorySetLocationRequest{UserID: 100, Point: geo.Point{0, 0}})
+	require.ErrorIs(s.T(), err, errpack.ErrFailedPrecondition)
+	_, err = s.repo.GetLocation(ctx, 100)
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_SetUserLocations_Rollback() {
+	ctx := context.Background()
+
+	s.setUserLocations([]geo.Point{{10, 20}})
+
+	_, err := s.repo.SetUserLocations(ctx, []port.UserRepositorySetUserLocationRequest{
+		{Username: "user0", Point: geo.Point{11, 21}},
+		{Username: "user1", Point: geo.Point{12, 22}},
+		{Username: "user2", Point: geo.Point{200, 22}},
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+
+	found, err := s.repo.GetUserWithLocation(ctx, "user0")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), geo.Point{10, 20}, found.Location.Point)
+	_, err = s.repo.GetByUsername(ctx, "user1")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	// The previous location is still indexed.
+	res, err := s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{10, 20},
+		Radius:   1,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Users, 1)
+	res, err = s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{11, 21},
+		Radius:   1,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), res.Users)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_DeleteUser() {
+	ctx := context.Background()
+
+	users := s.setUserLocations([]geo.Point{{10, 20}})
+
+	_, err := s.repo.DeleteUser(ctx, "user1")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	job, err := s.repo.DeleteUser(ctx, users[0].Username)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[0].ID, job.UserID)
+	require.Equal(s.T(), domain.ErasureJobPending, job.Status)
+
+	_, err = s.repo.GetLocation(ctx, users[0].ID)
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+	res, err := s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{10, 20},
+		Radius:   1,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), res.Users)
+
+	updated, err := s.repo.UpdateErasureJob(ctx, port.ErasureRepositoryUpdateErasureJobRequest{
+		ID:     job.ID,
+		Status: domain.ErasureJobCompleted,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, updated.Attempts)
+	require.NotNil(s.T(), updated.CompletedAt)
+
+	jobs, err := s.repo.ListErasureJobs(ctx, port.ErasureRepositoryListErasureJobsRequest{Status: domain.ErasureJobPending, PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), jobs.Jobs)
+	_, err = s.repo.GetErasureJob(ctx, job.ID+1)
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_ListUsersInRadius() {
+	ctx := context.Background()
+	rnd := rand.New(rand.NewSource(1))
+
+	centers := []geo.Point{{10, 20}, {179.9, 0}, {-179.9, 10}, {30, 89.5}, {-30, -89.9}}
+	for _, center := range centers {
+		for _, radius := range []float64{0, 1000, 100000, 3000000, 25000000} {
+			s.Run(fmt.Sprintf("%v/%v", center, radius), func() {
+				s.SetupTest()
+				points := randomPoints(rnd, 200, center)
+				s.setUserLocations(points)
+
+				var expected []int
+				for i, point := range points {
+					if geo.Distance(center, geo.Trunc(point)) <= radius {
+						expected = append(expected, i+1)
+					}
+				}
+
+				for _, orderBy := range []port.UsersOrder{port.UsersOrderByID, port.UsersOrderByDistance} {
+					var ids []int
+					var prevDistance float64
+					arg := port.UserRepositoryListUsersInRadiusRequest{
+						Point:    center,
+						Radius:   radius,
+						PageSize: 7,
+						OrderBy:  orderBy,
+					}
+					for {
+						res, err := s.repo.ListUsersInRadius(ctx, arg)
+						require.NoError(s.T(), err)
+						for _, user := range res.Users {
+							if orderBy == port.UsersOrderByDistance {
+								require.GreaterOrEqual(s.T(), user.Distance, prevDistance)
+								prevDistance = user.Distance
+							}
+							ids = append(ids, user.ID)
+						}
+						if res.NextPageToken == 0 {
+							break
+						}
+						arg.PageToken = res.NextPageToken
+						arg.PageTokenDistance = res.NextPageTokenDistance
+					}
+
+					sort.Ints(ids)
+					require.Equal(s.T(), expected, ids)
+				}
+			})
+		}
+	}
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_ListNearestUsers() {
+	ctx := context.Background()
+	rnd := rand.New(rand.NewSource(2))
+
+	center := geo.Point{179.99, -45}
+	points := randomPoints(rnd, 500, center)
+	users := s.setUserLocations(points)
+
+	distances := make(map[int]float64, len(points))
+	expected := make([]int, 0, len(points))
+	for i, point := range points {
+		distances[users[i].ID] = geo.Distance(center, geo.Trunc(point))
+		expected = append(expected, users[i].ID)
+	}
+	sort.Slice(expected, func(i, j int) bool {
+		return distances[expected[i]] < distances[expected[j]]
+	})
+
+	for _, limit := range []int{1, 10, 500, 1000} {
+		res, err := s.repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+			Point: center,
+			Limit: limit,
+		})
+		require.NoError(s.T(), err)
+
+		ids := make([]int, 0, len(res))
+		for _, user := range res {
+			ids = append(ids, user.ID)
+		}
+		if limit > len(expected) {
+			limit = len(expected)
+		}
+		require.Equal(s.T(), expected[:limit], ids)
+	}
+
+	res, err := s.repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point:           center,
+		Limit:           10,
+		ExcludeUsername: users[expected[0]-1].Username,
+		MaxDistance:     distances[expected[3]],
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 3)
+	require.Equal(s.T(), expected[1], res[0].ID)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_ListUsersInArea() {
+	ctx := context.Background()
+
+	users := s.setUserLocations([]geo.Point{{175, 5}, {-175, 5}, {0, 5}, {-173, 3}})
+
+	bbox, err := s.repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{170, 0, -170, 10},
+		PageSize: 2,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 2)
+	require.Equal(s.T(), users[0].ID, bbox.Users[0].ID)
+	require.Equal(s.T(), users[1].ID, bbox.Users[1].ID)
+	require.Equal(s.T(), users[1].ID, bbox.NextPageToken)
+
+	bbox, err = s.repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:      geo.BBox{170, 0, -170, 10},
+		PageToken: bbox.NextPageToken,
+		PageSize:  2,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 1)
+	require.Equal(s.T(), users[3].ID, bbox.Users[0].ID)
+	require.Zero(s.T(), bbox.NextPageToken)
+
+	polygon, err := s.repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+		Polygon: geo.Polygon{
+			{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}, {170, 0}},
+			{{-174, 2}, {-172, 2}, {-172, 4}, {-174, 4}, {-174, 2}},
+		},
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), polygon.Users, 2)
+	require.Equal(s.T(), users[0].ID, polygon.Users[0].ID)
+	require.Equal(s.T(), users[1].ID, polygon.Users[1].ID)
+
+	_, err = s.repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{PageSize: 10})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_Geofences() {
+	ctx := context.Background()
+
+	users := s.setUserLocations([]geo.Point{{10, 20}})
+
+	circle, err := s.repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name:   "circle",
+		Type:   domain.GeofenceTypeCircle,
+		Center: &geo.Point{10, 20},
+		Radius: 1000,
+	})
+	require.NoError(s.T(), err)
+	polygon, err := s.repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name:    "polygon",
+		Type:    domain.GeofenceTypePolygon,
+		Polygon: geo.Polygon{{{9, 19}, {11, 19}, {11, 21}, {9, 21}, {9, 19}}},
+	})
+	require.NoError(s.T(), err)
+
+	_, err = s.repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name:   "circle",
+		Type:   domain.GeofenceTypeCircle,
+		Center: &geo.Point{10, 20},
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrAlreadyExists)
+	_, err = s.repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name: "invalid",
+		Type: domain.GeofenceTypeCircle,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+	_, err = s.repo.UpdateGeofence(ctx, port.GeofenceRepositoryUpdateGeofenceRequest{
+		ID:     polygon.ID,
+		Name:   "circle",
+		Type:   domain.GeofenceTypeCircle,
+		Center: &geo.Point{10, 20},
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrAlreadyExists)
+
+	containing, err := s.repo.ListGeofencesContaining(ctx, geo.Point{10.005, 20})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.Geofence{circle, polygon}, containing)
+	containing, err = s.repo.ListGeofencesContaining(ctx, geo.Point{10.5, 20})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.Geofence{polygon}, containing)
+
+	createdAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	events, err := s.repo.CreateGeofenceEvents(ctx, []domain.GeofenceEvent{
+		{GeofenceID: circle.ID, UserID: users[0].ID, Type: domain.GeofenceEventEnter, Point: geo.Point{10, 20}, CreatedAt: createdAt},
+		{GeofenceID: circle.ID, UserID: users[0].ID, Type: domain.GeofenceEventDwell, Point: geo.Point{10, 20}, CreatedAt: createdAt},
+		{GeofenceID: polygon.ID, UserID: users[0].ID, Type: domain.GeofenceEventEnter, Point: geo.Point{10, 20}, CreatedAt: createdAt},
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), events, 3)
+
+	_, err = s.repo.CreateGeofenceEvents(ctx, []domain.GeofenceEvent{
+		{GeofenceID: circle.ID, UserID: users[0].ID, Type: domain.GeofenceEventExit, Point: geo.Point{10, 20}, CreatedAt: createdAt},
+		{GeofenceID: circle.ID + 10, UserID: users[0].ID, Type: domain.GeofenceEventEnter, Point: geo.Point{10, 20}, CreatedAt: createdAt},
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrInternalError)
+
+	presences, err := s.repo.ListGeofencePresences(ctx, users[0].ID, []int{circle.ID, polygon.ID})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.GeofencePresence{
+		{GeofenceID: circle.ID, UserID: users[0].ID, EnteredAt: createdAt, Dwelled: true},
+		{GeofenceID: polygon.ID, UserID: users[0].ID, EnteredAt: createdAt},
+	}, presences)
+
+	require.NoError(s.T(), s.repo.DeleteGeofence(ctx, circle.ID))
+	require.ErrorIs(s.T(), s.repo.DeleteGeofence(ctx, circle.ID), errpack.ErrNotFound)
+
+	list, err := s.repo.ListGeofenceEvents(ctx, port.GeofenceRepositoryListGeofenceEventsRequest{PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), events[2:], list.Events)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_PrivacySettings() {
+	ctx := context.Background()
+
+	users := s.setUserLocations([]geo.Point{{10, 20}, {10.001, 20}, {10.002, 20}})
+
+	settings, err := s.repo.GetPrivacySettings(ctx, users[1].Username)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.PrivacySettings{UserID: users[1].ID, Level: domain.PrivacyLevelExact}, settings)
+
+	_, err = s.repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[1].Username,
+		Level:    domain.PrivacyLevelHidden,
+	})
+	require.NoError(s.T(), err)
+	coarse, err := s.repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[2].Username,
+		Level:    domain.PrivacyLevelCoarse,
+		GridSize: 1,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.PrivacySettings{UserID: users[2].ID, Level: domain.PrivacyLevelCoarse, GridSize: 1}, coarse)
+
+	_, err = s.repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[0].Username,
+		Level:    domain.PrivacyLevelHidden,
+		GridSize: 1,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+	_, err = s.repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: "user9",
+		Level:    domain.PrivacyLevelHidden,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	// Hidden users are not found by searches, coarse users are found by their coarse locations only.
+	radius, err := s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{10, 20},
+		Radius:   1000,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), radius.Users, 1)
+	require.Equal(s.T(), users[0], radius.Users[0].User)
+
+	nearest, err := s.repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point: geo.Point{10, 20},
+		Limit: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), nearest, 2)
+	require.Equal(s.T(), users[2], nearest[1].User)
+	require.Equal(s.T(), geo.Point{10.5, 20.5}, nearest[1].Point)
+	require.Equal(s.T(), geo.Distance(geo.Point{10, 20}, geo.Point{10.5, 20.5}), nearest[1].Distance)
+
+	bbox, err := s.repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{9, 19, 11, 21},
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 2)
+	require.Equal(s.T(), geo.Point{10.5, 20.5}, bbox.Users[1].Point)
+
+	hidden, err := s.repo.GetUserWithLocation(ctx, users[1].Username)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), hidden.Location)
+	require.Equal(s.T(), domain.PrivacyLevelHidden, hidden.Privacy.Level)
+
+	// Settings are removed along with the user.
+	_, err = s.repo.DeleteUser(ctx, users[2].Username)
+	require.NoError(s.T(), err)
+	_, err = s.repo.GetPrivacySettings(ctx, users[2].Username)
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_PrivacySettings_CoarseCell() {
+	ctx := context.Background()
+
+	users := s.setUserLocations([]geo.Point{{10.1, 20.1}, {10.52, 20.52}, {10.3, 20.7}})
+	_, err := s.repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[0].Username,
+		Level:    domain.PrivacyLevelCoarse,
+		GridSize: 1,
+	})
+	require.NoError(s.T(), err)
+
+	type searchResults struct {
+		radius  port.UserRepositoryListUsersInRadiusResponse
+		nearest []domain.NearbyUser
+		bbox    port.UserRepositoryListUsersInAreaResponse
+		polygon port.UserRepositoryListUsersInAreaResponse
+	}
+	search := func() searchResults {
+		var res searchResults
+		var err error
+
+		res.radius, err = s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+			Point:    geo.Point{10.505, 20.505},
+			Radius:   30000,
+			PageSize: 1,
+			OrderBy:  port.UsersOrderByDistance,
+		})
+		require.NoError(s.T(), err)
+		res.nearest, err = s.repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+			Point: geo.Point{10.505, 20.505},
+			Limit: 2,
+		})
+		require.NoError(s.T(), err)
+		res.bbox, err = s.repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+			BBox:     geo.BBox{10.4, 20.4, 10.6, 20.6},
+			PageSize: 10,
+		})
+		require.NoError(s.T(), err)
+		res.polygon, err = s.repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+			Polygon:  geo.Polygon{{{10.4, 20.4}, {10.6, 20.4}, {10.6, 20.6}, {10.4, 20.6}, {10.4, 20.4}}},
+			PageSize: 10,
+		})
+		require.NoError(s.T(), err)
+
+		// Locations are moved, so the times they were updated at are not compared.
+		for i := range res.radius.Users {
+			res.radius.Users[i].LocationUpdatedAt = time.Time{}
+		}
+		for i := range res.nearest {
+			res.nearest[i].LocationUpdatedAt = time.Time{}
+		}
+		for i := range res.bbox.Users {
+			res.bbox.Users[i].LocationUpdatedAt = time.Time{}
+		}
+		for i := range res.polygon.Users {
+			res.polygon.Users[i].LocationUpdatedAt = time.Time{}
+		}
+		return res
+	}
+
+	before := search()
+	require.Len(s.T(), before.radius.Users, 1)
+	require.Equal(s.T(), users[0].ID, before.radius.Users[0].ID)
+	require.Equal(s.T(), geo.Point{10.5, 20.5}, before.radius.Users[0].Point)
+	require.Equal(s.T(), geo.Distance(geo.Point{10.505, 20.505}, geo.Point{10.5, 20.5}), before.radius.NextPageTokenDistance)
+	require.Len(s.T(), before.nearest, 2)
+	require.Equal(s.T(), users[0].ID, before.nearest[0].ID)
+	require.Len(s.T(), before.bbox.Users, 2)
+	require.Len(s.T(), before.polygon.Users, 2)
+
+	// The exact location is moved around inside the same cell, nothing shown to others changes.
+	for _, point := range []geo.Point{{10.9, 20.9}, {10.01, 20.99}, {10.5, 20.5}} {
+		_, err = s.repo.SetLocation(ctx, port.LocationRepositorySetLocationRequest{UserID: users[0].ID, Point: point})
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), before, search())
+	}
+
+	// The location is moved to the next cell.
+	_, err = s.repo.SetLocation(ctx, port.LocationRepositorySetLocationRequest{UserID: users[0].ID, Point: geo.Point{11.1, 20.5}})
+	require.NoError(s.T(), err)
+	after := search()
+	require.Len(s.T(), after.bbox.Users, 1)
+	require.Equal(s.T(), users[1].ID, after.bbox.Users[0].ID)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_SocialGraph() {
+	ctx := context.Background()
+
+	users := s.setUserLocations([]geo.Point{{10, 20}, {10.001, 20}, {10.002, 20}, {10.003, 20}})
+
+	follow, err := s.repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[0].Username, follow.Follower)
+	require.Equal(s.T(), users[1].ID, follow.FolloweeID)
+	require.Equal(s.T(), domain.FollowPending, follow.Status)
+	_, err = s.repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrAlreadyExists)
+	_, err = s.repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: "user9"})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+	_, err = s.repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[2].Username})
+	require.NoError(s.T(), err)
+	_, err = s.repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[3].Username})
+	require.NoError(s.T(), err)
+
+	accepted, err := s.repo.AcceptFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.FollowAccepted, accepted.Status)
+	_, err = s.repo.AcceptFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[1].Username, Followee: users[0].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+	_, err = s.repo.AcceptFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[3].Username})
+	require.NoError(s.T(), err)
+
+	following, err := s.repo.ListFollowing(ctx, port.SocialRepositoryListFollowsRequest{Username: users[0].Username, PageSize: 2})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), following.Follows, 2)
+	require.Equal(s.T(), users[2].ID, following.NextPageToken)
+	following, err = s.repo.ListFollowing(ctx, port.SocialRepositoryListFollowsRequest{
+		Username:  users[0].Username,
+		Status:    domain.FollowAccepted,
+		PageToken: following.NextPageToken,
+		PageSize:  2,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), following.Follows, 1)
+	require.Equal(s.T(), users[3].Username, following.Follows[0].Followee)
+	require.Zero(s.T(), following.NextPageToken)
+
+	followers, err := s.repo.ListFollowers(ctx, port.SocialRepositoryListFollowsRequest{Username: users[1].Username, PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), followers.Follows, 1)
+	require.Equal(s.T(), users[0].Username, followers.Follows[0].Follower)
+
+	// Only accepted connections of the caller are found by scoped searches.
+	radius, err := s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:         geo.Point{10, 20},
+		Radius:        1000,
+		PageSize:      10,
+		ConnectionsOf: users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), radius.Users, 2)
+	require.Equal(s.T(), users[1], radius.Users[0].User)
+	require.Equal(s.T(), users[3], radius.Users[1].User)
+
+	nearest, err := s.repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point:         geo.Point{10, 20},
+		Limit:         10,
+		ConnectionsOf: users[1].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), nearest)
+
+	bbox, err := s.repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:          geo.BBox{9, 19, 11, 21},
+		PageSize:      10,
+		ConnectionsOf: users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 2)
+	require.Equal(s.T(), users[1], bbox.Users[0].User)
+	require.Equal(s.T(), users[3], bbox.Users[1].User)
+
+	polygon, err := s.repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+		Polygon:       geo.Polygon{{{9, 19}, {11, 19}, {11, 21}, {9, 21}, {9, 19}}},
+		PageSize:      10,
+		ConnectionsOf: users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), polygon.Users, 2)
+	require.Equal(s.T(), users[1], polygon.Users[0].User)
+	require.Equal(s.T(), users[3], polygon.Users[1].User)
+
+	connected, err := s.repo.IsConnection(ctx, users[0].Username, users[1].ID)
+	require.NoError(s.T(), err)
+	require.True(s.T(), connected)
+	connected, err = s.repo.IsConnection(ctx, users[0].Username, users[2].ID)
+	require.NoError(s.T(), err)
+	require.False(s.T(), connected)
+	connected, err = s.repo.IsConnection(ctx, users[1].Username, users[0].ID)
+	require.NoError(s.T(), err)
+	require.False(s.T(), connected)
+
+	// Blocking deletes follows in both directions and rejects new ones.
+	block, err := s.repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[0].ID, block.BlockedID)
+	_, err = s.repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[1].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+	_, err = s.repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrFailedPrecondition)
+	_, err = s.repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[1].Username, Followee: users[0].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrFailedPrecondition)
+	followers, err = s.repo.ListFollowers(ctx, port.SocialRepositoryListFollowsRequest{Username: users[1].Username, PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), followers.Follows)
+	connected, err = s.repo.IsConnection(ctx, users[0].Username, users[1].ID)
+	require.NoError(s.T(), err)
+	require.False(s.T(), connected)
+
+	blocks, err := s.repo.ListBlocks(ctx, port.SocialRepositoryListBlocksRequest{Username: users[1].Username, PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), blocks.Blocks, 1)
+	require.Equal(s.T(), users[0].Username, blocks.Blocks[0].Blocked)
+
+	require.NoError(s.T(), s.repo.DeleteBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username}))
+	require.ErrorIs(s.T(), s.repo.DeleteBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username}), errpack.ErrNotFound)
+	require.NoError(s.T(), s.repo.DeleteFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[2].Username}))
+	require.ErrorIs(s.T(), s.repo.DeleteFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[2].Username}), errpack.ErrNotFound)
+
+	// Follows are removed along with the user.
+	_, err = s.repo.DeleteUser(ctx, users[3].Username)
+	require.NoError(s.T(), err)
+	following, err = s.repo.ListFollowing(ctx, port.SocialRepositoryListFollowsRequest{Username: users[0].Username, PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), following.Follows)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_Blocks_Searches() {
+	ctx := context.Background()
+
+	users := s.setUserLocations([]geo.Point{{10, 20}, {10.001, 20}, {10.002, 20}, {10.003, 20}})
+
+	// The caller is blocked by the second user and blocks the third one.
+	_, err := s.repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username})
+	require.NoError(s.T(), err)
+	_, err = s.repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[0].Username, Blocked: users[2].Username})
+	require.NoError(s.T(), err)
+
+	for _, orderBy := range []port.UsersOrder{port.UsersOrderByID, port.UsersOrderByDistance} {
+		radius, err := s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+			Point:    geo.Point{10, 20},
+			Radius:   1000,
+			PageSize: 10,
+			OrderBy:  orderBy,
+			Caller:   users[0].Username,
+		})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), radius.Users, 2)
+		require.Equal(s.T(), users[0], radius.Users[0].User)
+		require.Equal(s.T(), users[3], radius.Users[1].User)
+	}
+
+	// Without the caller blocks are ignored.
+	radius, err := s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{10, 20},
+		Radius:   1000,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), radius.Users, 4)
+
+	nearest, err := s.repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point:  geo.Point{10, 20},
+		Limit:  10,
+		Caller: users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), nearest, 2)
+	require.Equal(s.T(), users[0], nearest[0].User)
+	require.Equal(s.T(), users[3], nearest[1].User)
+
+	bbox, err := s.repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{9, 19, 11, 21},
+		PageSize: 10,
+		Caller:   users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 2)
+	require.Equal(s.T(), users[0], bbox.Users[0].User)
+	require.Equal(s.T(), users[3], bbox.Users[1].User)
+
+	polygon, err := s.repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+		Polygon:  geo.Polygon{{{9, 19}, {11, 19}, {11, 21}, {9, 21}, {9, 19}}},
+		PageSize: 10,
+		Caller:   users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), polygon.Users, 2)
+	require.Equal(s.T(), users[0], polygon.Users[0].User)
+	require.Equal(s.T(), users[3], polygon.Users[1].User)
+
+	for _, tc := range []struct {
+		username string
+		userID   int
+		blocked  bool
+	}{
+		{users[0].Username, users[1].ID, true},
+		{users[0].Username, users[2].ID, true},
+		{users[0].Username, users[3].ID, false},
+		{users[1].Username, users[0].ID, true},
+		{users[1].Username, users[2].ID, false},
+		{"", users[1].ID, false},
+	} {
+		blocked, err := s.repo.IsBlocked(ctx, tc.username, tc.userID)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), tc.blocked, blocked)
+	}
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_IdempotencyKeys() {
+	ctx := context.Background()
+	arg := port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username:    "user0",
+		Key:         "key1",
+		RequestHash: "hash1",
+		TTL:         time.Hour,
+	}
+
+	res, err := s.repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+	require.Nil(s.T(), res.Key.Response)
+
+	res, err = s.repo.ReserveIdempotencyKey(ctx, port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username:    "user0",
+		Key:         "key1",
+		RequestHash: "hash2",
+		TTL:         time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.False(s.T(), res.Reserved)
+	require.Equal(s.T(), "hash1", res.Key.RequestHash)
+
+	// Keys are unique per user.
+	res, err = s.repo.ReserveIdempotencyKey(ctx, port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username: "user1",
+		Key:      "key1",
+		TTL:      time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+
+	err = s.repo.CompleteIdempotencyKey(ctx, port.UserRepositoryCompleteIdempotencyKeyRequest{
+		Username: "user0",
+		Key:      "key1",
+		Response: []byte(`{"latitude":10}`),
+	})
+	require.NoError(s.T(), err)
+	err = s.repo.CompleteIdempotencyKey(ctx, port.UserRepositoryCompleteIdempotencyKeyRequest{
+		Username: "user0",
+		Key:      "key1",
+		Response: []byte(`{"latitude":11}`),
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	// Completed keys are not released.
+	require.NoError(s.T(), s.repo.ReleaseIdempotencyKey(ctx, "user0", "key1"))
+	res, err = s.repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.False(s.T(), res.Reserved)
+	require.JSONEq(s.T(), `{"latitude":10}`, string(res.Key.Response))
+
+	require.NoError(s.T(), s.repo.ReleaseIdempotencyKey(ctx, "user1", "key1"))
+	res, err = s.repo.ReserveIdempotencyKey(ctx, port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username: "user1",
+		Key:      "key1",
+	})
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+
+	// The key of user1 is reserved with zero TTL, so it is expired already.
+	n, err := s.repo.DeleteExpiredIdempotencyKeys(ctx)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), 1, n)
+
+	// Keys of deleted users are deleted as well.
+	s.setUserLocations([]geo.Point{{10, 20}})
+	_, err = s.repo.DeleteUser(ctx, "user0")
+	require.NoError(s.T(), err)
+	res, err = s.repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+
+	// Keys that are not completed within the lease are reserved again.
+	time.Sleep(time.Millisecond)
+	leasedArg := arg
+	leasedArg.Lease = time.Millisecond
+	res, err = s.repo.ReserveIdempotencyKey(ctx, leasedArg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+}
+
+func (s *MemoryTestSuite) Test_MemoryRepository_RenameUser_IdempotencyKeys() {
+	ctx := context.Background()
+	s.setUserLocations([]geo.Point{{10, 20}})
+	arg := port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username:    "user0",
+		Key:         "key1",
+		RequestHash: "hash1",
+		TTL:         time.Hour,
+	}
+
+	_, err := s.repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.NoError(s.T(), s.repo.CompleteIdempotencyKey(ctx, port.UserRepositoryCompleteIdempotencyKeyRequest{
+		Username: "user0",
+		Key:      "key1",
+		Response: []byte(`{"latitude":10}`),
+	}))
+
+	_, err = s.repo.RenameUser(ctx, port.UserRepositoryRenameUserRequest{Username: "user0", NewUsername: "renamed"})
+	require.NoError(s.T(), err)
+
+	// Keys move along with the user.
+	renamedArg := arg
+	renamedArg.Username = "renamed"
+	res, err := s.repo.ReserveIdempotencyKey(ctx, renamedArg)
+	require.NoError(s.T(), err)
+	require.False(s.T(), res.Reserved)
+	require.JSONEq(s.T(), `{"latitude":10}`, string(res.Key.Response))
+
+	// A user taking the old username does not get the keys.
+	res, err = s.repo.ReserveIdempotencyKey(ctx, arg)
+	require.NoError(s.T(), err)
+	require.True(s.T(), res.Reserved)
+}
@@ -13,9 +13,19 @@ const (
 	ConstraintUsersUsernameKey   = "users_username_key"
 	ConstraintUsersUsernameValid = "users_username_valid"
 
-	ConstraintLocationsUserIdFkey     = "locations_user_id_fkey"
-	ConstraintLocationsLatitudeValid  = "locations_latitude_valid"
-	ConstraintLocationsLongitudeValid = "locations_longitude_valid"
+	ConstraintLocationsUserIdFkey       = "locations_user_id_fkey"
+	ConstraintLocationsLatitudeValid    = "locations_latitude_valid"
+	ConstraintLocationsLongitudeValid   = "locations_longitude_valid"
+	ConstraintLocationsFixMetadataValid = "locations_fix_metadata_valid"
+
+	ConstraintGeofencesNameKey        = "geofences_name_key"
+	ConstraintGeofencesShapeValid     = "geofences_shape_valid"
+	ConstraintGeofencesDwellTimeValid = "geofences_dwell_time_valid"
+
+	ConstraintPrivacySettingsLevelValid      = "privacy_settings_level_valid"
+	ConstraintPrivacySettingsCoarseningValid = "privacy_settings_coarsening_valid"
+
+	ConstraintFollowsPkey = "follows_pkey"
 )
 
 type postgresRepository struct {
@@ -34,7 +44,8 @@ func NewPostgresRepository(db *sql.DB) port.Repository {
 // execTx executes provided callback in the scope of a database transaction.
 //
 // It returns an error occurred while starting, committing or rolling back the transaction or
-// and error returned by the callback.
+// and error returned by the callback. The error returned by the callback is returned as is,
+// so the callback is expected to return errors wrapped with `fmt.Errorf("%w", err)`.
 func (r *postgresRepository) execTx(ctx context.Context, fn func(*postgresQueries) error) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -45,9 +56,9 @@ func (r *postgresRepository) execTx(ctx context.Context, fn func(*postgresQuerie
 	err = fn(q)
 	if err != nil {
 		if rbErr := tx.Rollback(); rbErr != nil {
-			return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+			return fmt.Errorf("%w: %v", errpack.ErrInternalError, rbErr)
 		}
-		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
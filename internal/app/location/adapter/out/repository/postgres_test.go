@@ -28,8 +28,11 @@ type PostgresTestSuite struct {
 }
 
 func (s *PostgresTestSuite) SetupSuite() {
-	var err error
+	s.db, s.m, s.container = setupPostgres(s.T())
+}
 
+// setupPostgres starts postgres in a docker container, connects to it and runs migrations.
+func setupPostgres(tb testing.TB) (*sql.DB, *migrate.Migrate, *testutil.Container) {
 	dbUser := testutil.RandomString(10, 10, testutil.CharacterSetAlphabet)
 	dbPassword := testutil.RandomString(10, 10, testutil.CharacterSetAlphabet)
 	dbName := testutil.RandomString(10, 10, testutil.CharacterSetAlphabet)
@@ -40,45 +43,47 @@ func (s *PostgresTestSuite) SetupSuite() {
 	// Setup postgres in a docker container.
 	cancelCtx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
 	defer cancel()
-	s.container, err = testutil.SetupPostgres(cancelCtx, testutil.PostgresConfig{
+	container, err := testutil.SetupPostgres(cancelCtx, testutil.PostgresConfig{
 		User:     dbUser,
 		Password: dbPassword,
 		DBName:   dbName,
 	})
 	if err != nil {
-		s.T().Fatal(err)
+		tb.Fatal(err)
 	}
 
 	// Connect to database.
-	s.db, err = util.OpenDB(
+	db, err := util.OpenDB(
 		"postgres",
-		s.container.URI,
+		container.URI,
 	)
 	if err != nil {
-		s.T().Fatal(err)
+		tb.Fatal(err)
 	}
 
-	if err := s.db.Ping(); err != nil {
-		s.T().Fatal(err)
+	if err := db.Ping(); err != nil {
+		tb.Fatal(err)
 	}
 
 	// Run migrations.
 	migrationsPath := "file://" + path.Join(rootDir, "db/migrations/locations")
 
-	driver, err := postgres.WithInstance(s.db, &postgres.Config{})
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
-		s.T().Fatal(err)
+		tb.Fatal(err)
 	}
 
-	s.m, err = migrate.NewWithDatabaseInstance(migrationsPath, "postgres", driver)
+	m, err := migrate.NewWithDatabaseInstance(migrationsPath, "postgres", driver)
 	if err != nil {
-		s.T().Fatal(err)
+		tb.Fatal(err)
 	}
 
-	err = s.m.Up()
+	err = m.Up()
 	if err != nil {
-		s.T().Fatal(err)
+		tb.Fatal(err)
 	}
+
+	return db, m, container
 }
 
 func (s *PostgresTestSuite) TearDownTest() {
@@ -108,21 +113,26 @@ func (s *PostgresTestSuite) TearDownTest() {
 }
 
 func (s *PostgresTestSuite) TearDownSuite() {
+	teardownPostgres(s.T(), s.db, s.m, s.container)
+}
+
+// teardownPostgres reverts migrations, disconnects from postgres and terminates its container.
+func teardownPostgres(tb testing.TB, db *sql.DB, m *migrate.Migrate, container *testutil.Container) {
 	var err error
 
-	err = s.m.Down()
+	err = m.Down()
 	if err != nil {
 		// TODO: Log err
 	}
-	err = s.db.Close()
+	err = db.Close()
 	if err != nil {
 		// TODO: Log err
 	}
 	cancelCtx, cancel := context.WithTimeout(context.Background(), time.Minute)
 	defer cancel()
-	err = s.container.Terminate(cancelCtx)
+	err = container.Terminate(cancelCtx)
 	if err != nil {
-		s.T().Fatal(err)
+		tb.Fatal(err)
 	}
 }
 
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// GetPrivacySettings finds privacy settings of a user by username.
+//
+// It returns the settings and any error encountered.
+// The default settings of the exact level are returned if the user has never changed them.
+//
+// `ErrNotFound` is returned in case user not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) GetPrivacySettings(ctx context.Context, username string) (domain.PrivacySettings, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.userIDs[username]
+	if !ok {
+		return domain.PrivacySettings{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	if settings, ok := r.privacy[id]; ok {
+		return settings, nil
+	}
+
+	return domain.PrivacySettings{UserID: id, Level: domain.PrivacyLevelExact}, nil
+}
+
+// SetPrivacySettings creates or replaces privacy settings of the user with `arg.Username`.
+//
+// It returns the stored settings and any error encountered.
+//
+// `ErrNotFound` is returned in case the user is not found.
+//
+// `ErrInvalidArgument` is returned in case the settings are invalid.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) SetPrivacySettings(ctx context.Context, arg port.UserRepositorySetPrivacySettingsRequest) (domain.PrivacySettings, error) {
+	if !validPrivacySettings(arg) {
+		return domain.PrivacySettings{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	id, ok := r.userIDs[arg.Username]
+	if !ok {
+		return domain.PrivacySettings{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	settings := domain.PrivacySettings{
+		UserID:           id,
+		Level:            arg.Level,
+		GridSize:         arg.GridSize,
+		GeohashPrecision: arg.GeohashPrecision,
+	}
+	r.unindexLocation(id)
+	r.privacy[id] = settings
+	r.indexLocation(id)
+
+	return settings, nil
+}
+
+// validPrivacySettings reports whether the settings satisfy the constraints of privacy settings table.
+func validPrivacySettings(arg port.UserRepositorySetPrivacySettingsRequest) bool {
+	switch arg.Level {
+	case domain.PrivacyLevelCoarse:
+		return (arg.GridSize > 0 && arg.GridSize <= 10 && arg.GeohashPrecision == 0) ||
+			(arg.GridSize == 0 && arg.GeohashPrecision >= 1 && arg.GeohashPrecision <= 12)
+	case domain.PrivacyLevelExact, domain.PrivacyLevelHidden:
+		return arg.GridSize == 0 && arg.GeohashPrecision == 0
+	default:
+		return false
+	}
+}
+
+// userPrivacySettings returns a copy of privacy settings of the user with given id or nil if the user has no settings.
+// It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) userPrivacySettings(id int) *domain.PrivacySettings {
+	settings, ok := r.privacy[id]
+	if !ok {
+		return nil
+	}
+	return &settings
+}
+
+// visiblePoint returns the point the location of the user with given id is shown at according to
+// privacy settings of the user and false if the user has no location or the location is hidden.
+// It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) visiblePoint(id int) (geo.Point, bool) {
+	location, ok := r.locations[id]
+	if !ok {
+		return geo.Point{}, false
+	}
+	return r.userPrivacySettings(id).VisiblePoint(location.Point)
+}
+
+// indexLocation adds the location of the user with given id to the grid index by its visible point,
+// so that locations are searched the way they are shown. Hidden locations are not indexed.
+// It is meant to be called holding the write lock of the repository.
+func (r *memoryRepository) indexLocation(id int) {
+	if point, ok := r.visiblePoint(id); ok {
+		r.grid.insert(id, point)
+	}
+}
+
+// unindexLocation removes the location of the user with given id from the grid index.
+// It is meant to be called before the location or privacy settings of the user are changed.
+func (r *memoryRepository) unindexLocation(id int) {
+	if point, ok := r.visiblePoint(id); ok {
+		r.grid.remove(id, point)
+	}
+}
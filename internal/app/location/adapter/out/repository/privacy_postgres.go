@@ -0,0 +1,175 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+// privacySettingsJoin joins privacy settings of users selected as `u`, users without settings are kept.
+var privacySettingsJoin = fmt.Sprintf("LEFT JOIN %s p ON p.user_id = u.id", PrivacySettingsTable)
+
+// notHiddenCondition matches locations selected as `l` that are not hidden.
+// Visible points of locations are kept up to date with privacy settings by database triggers.
+const notHiddenCondition = "l.visible_point IS NOT NULL"
+
+// privacySettingsColumns are columns of privacy settings joined with `privacySettingsJoin`.
+const privacySettingsColumns = "p.level, p.grid_size, p.geohash_precision"
+
+// nullPrivacySettings scans privacy settings selected with `privacySettingsColumns` columns,
+// which are NULL for users without settings.
+type nullPrivacySettings struct {
+	Level            sql.NullString
+	GridSize         sql.NullFloat64
+	GeohashPrecision sql.NullInt64
+}
+
+// settings returns privacy settings of the user with given id or nil if the user has no settings.
+func (p nullPrivacySettings) settings(userID int) *domain.PrivacySettings {
+	if !p.Level.Valid {
+		return nil
+	}
+	return &domain.PrivacySettings{
+		UserID:           userID,
+		Level:            domain.PrivacyLevel(p.Level.String),
+		GridSize:         p.GridSize.Float64,
+		GeohashPrecision: int(p.GeohashPrecision.Int64),
+	}
+}
+
+var getPrivacySettingsQuery = fmt.Sprintf(
+	`
+SELECT u.id, %s
+FROM %s u
+%s
+WHERE u.username = $1
+`,
+	privacySettingsColumns,
+	UserTable,
+	privacySettingsJoin,
+)
+
+// GetPrivacySettings finds privacy settings of a user by username.
+//
+// It returns the settings and any error encountered.
+// The default settings of the exact level are returned if the user has never changed them.
+//
+// `ErrNotFound` is returned in case user not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) GetPrivacySettings(ctx context.Context, username string) (domain.PrivacySettings, error) {
+	var userID int
+	var privacy nullPrivacySettings
+
+	if err := q.db.QueryRowContext(ctx, getPrivacySettingsQuery, username).Scan(
+		&userID,
+		&privacy.Level,
+		&privacy.GridSize,
+		&privacy.GeohashPrecision,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PrivacySettings{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+		return domain.PrivacySettings{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	if settings := privacy.settings(userID); settings != nil {
+		return *settings, nil
+	}
+
+	return domain.PrivacySettings{UserID: userID, Level: domain.PrivacyLevelExact}, nil
+}
+
+var setPrivacySettingsQuery = fmt.Sprintf(
+	`
+INSERT INTO %s
+(user_id, level, grid_size, geohash_precision)
+SELECT id, $2, $3, $4
+FROM %s
+WHERE username = $1
+ON CONFLICT (user_id) DO UPDATE
+SET level = EXCLUDED.level, grid_size = EXCLUDED.grid_size, geohash_precision = EXCLUDED.geohash_precision
+RETURNING user_id, level, grid_size, geohash_precision
+`,
+	PrivacySettingsTable,
+	UserTable,
+)
+
+// SetPrivacySettings creates or replaces privacy settings of the user with `arg.Username`.
+//
+// It returns the stored settings and any error encountered.
+//
+// `ErrNotFound` is returned in case the user is not found.
+//
+// `ErrInvalidArgument` is returned in case the settings are invalid.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) SetPrivacySettings(ctx context.Context, arg port.UserRepositorySetPrivacySettingsRequest) (domain.PrivacySettings, error) {
+	var userID int
+	var privacy nullPrivacySettings
+
+	if err := q.db.QueryRowContext(ctx, setPrivacySettingsQuery,
+		arg.Username,
+		arg.Level,
+		sql.NullFloat64{Float64: arg.GridSize, Valid: arg.GridSize != 0},
+		sql.NullInt64{Int64: int64(arg.GeohashPrecision), Valid: arg.GeohashPrecision != 0},
+	).Scan(
+		&userID,
+		&privacy.Level,
+		&privacy.GridSize,
+		&privacy.GeohashPrecision,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.PrivacySettings{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Constraint {
+			case ConstraintPrivacySettingsLevelValid, ConstraintPrivacySettingsCoarseningValid:
+				return domain.PrivacySettings{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+			}
+		}
+		return domain.PrivacySettings{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return *privacy.settings(userID), nil
+}
+
+var getUserPrivacySettingsQuery = fmt.Sprintf(
+	`
+SELECT level, grid_size, geohash_precision
+FROM %s
+WHERE user_id = $1
+`,
+	PrivacySettingsTable,
+)
+
+// getUserPrivacySettings finds privacy settings of the user with given id.
+// It returns nil if the user has no settings.
+func (q *postgresQueries) getUserPrivacySettings(ctx context.Context, userID int) (*domain.PrivacySettings, error) {
+	var privacy nullPrivacySettings
+
+	if err := q.db.QueryRowContext(ctx, getUserPrivacySettingsQuery, userID).Scan(
+		&privacy.Level,
+		&privacy.GridSize,
+		&privacy.GeohashPrecision,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return privacy.settings(userID), nil
+}
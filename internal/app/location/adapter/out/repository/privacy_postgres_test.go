@@ -0,0 +1,225 @@
+package repository_test
+
+import (
+	"context"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func (s *PostgresTestSuite) Test_PostgresQueries_PrivacySettings() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	settings, err := repo.GetPrivacySettings(ctx, users[0].Username)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.PrivacySettings{UserID: users[0].ID, Level: domain.PrivacyLevelExact}, settings)
+
+	settings, err = repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[0].Username,
+		Level:    domain.PrivacyLevelCoarse,
+		GridSize: 0.5,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.PrivacySettings{UserID: users[0].ID, Level: domain.PrivacyLevelCoarse, GridSize: 0.5}, settings)
+
+	// Replaced settings do not keep the previous cell size.
+	settings, err = repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username:         users[0].Username,
+		Level:            domain.PrivacyLevelCoarse,
+		GeohashPrecision: 5,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.PrivacySettings{UserID: users[0].ID, Level: domain.PrivacyLevelCoarse, GeohashPrecision: 5}, settings)
+
+	settings, err = repo.GetPrivacySettings(ctx, users[0].Username)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.PrivacySettings{UserID: users[0].ID, Level: domain.PrivacyLevelCoarse, GeohashPrecision: 5}, settings)
+
+	_, err = repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[0].Username,
+		Level:    domain.PrivacyLevelCoarse,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+
+	_, err = repo.GetPrivacySettings(ctx, "user1")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+	_, err = repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: "user1",
+		Level:    domain.PrivacyLevelHidden,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_PrivacySettings_Searches() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+	})
+	s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{10, 20}},
+		{UserID: users[1].ID, Point: geo.Point{10.001, 20}},
+		{UserID: users[2].ID, Point: geo.Point{10.002, 20}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	_, err := repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[1].Username,
+		Level:    domain.PrivacyLevelHidden,
+	})
+	require.NoError(s.T(), err)
+	_, err = repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[2].Username,
+		Level:    domain.PrivacyLevelCoarse,
+		GridSize: 1,
+	})
+	require.NoError(s.T(), err)
+
+	// Hidden users are not found by searches, coarse users are found by their coarse locations only.
+	radius, err := repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{10, 20},
+		Radius:   1000,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), radius.Users, 1)
+	require.Equal(s.T(), users[0], radius.Users[0].User)
+
+	nearest, err := repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point: geo.Point{10, 20},
+		Limit: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), nearest, 2)
+	require.Equal(s.T(), users[2], nearest[1].User)
+	require.Equal(s.T(), geo.Point{10.5, 20.5}, nearest[1].Point)
+	require.InDelta(s.T(), geo.Distance(geo.Point{10, 20}, geo.Point{10.5, 20.5}), nearest[1].Distance, 1)
+
+	bbox, err := repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{9, 19, 11, 21},
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 2)
+	require.Equal(s.T(), geo.Point{10.5, 20.5}, bbox.Users[1].Point)
+
+	hidden, err := repo.GetUserWithLocation(ctx, users[1].Username)
+	require.NoError(s.T(), err)
+	require.NotNil(s.T(), hidden.Location)
+	require.Equal(s.T(), domain.PrivacyLevelHidden, hidden.Privacy.Level)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_PrivacySettings_CoarseCell() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+	})
+	s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{10.1, 20.1}},
+		{UserID: users[1].ID, Point: geo.Point{10.52, 20.52}},
+		{UserID: users[2].ID, Point: geo.Point{10.3, 20.7}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	location, err := repo.GetLocation(ctx, users[0].ID)
+	require.NoError(s.T(), err)
+	_, err = repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username: users[0].Username,
+		Level:    domain.PrivacyLevelCoarse,
+		GridSize: 1,
+	})
+	require.NoError(s.T(), err)
+
+	// Privacy settings do not change the time the location was updated at.
+	coarseLocation, err := repo.GetLocation(ctx, users[0].ID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), location, coarseLocation)
+
+	type searchResults struct {
+		radius  port.UserRepositoryListUsersInRadiusResponse
+		nearest []domain.NearbyUser
+		bbox    port.UserRepositoryListUsersInAreaResponse
+		polygon port.UserRepositoryListUsersInAreaResponse
+	}
+	search := func() searchResults {
+		var res searchResults
+		var err error
+
+		res.radius, err = repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+			Point:    geo.Point{10.505, 20.505},
+			Radius:   30000,
+			PageSize: 1,
+			OrderBy:  port.UsersOrderByDistance,
+		})
+		require.NoError(s.T(), err)
+		res.nearest, err = repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+			Point: geo.Point{10.505, 20.505},
+			Limit: 2,
+		})
+		require.NoError(s.T(), err)
+		res.bbox, err = repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+			BBox:     geo.BBox{10.4, 20.4, 10.6, 20.6},
+			PageSize: 10,
+		})
+		require.NoError(s.T(), err)
+		res.polygon, err = repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+			Polygon:  geo.Polygon{{{10.4, 20.4}, {10.6, 20.4}, {10.6, 20.6}, {10.4, 20.6}, {10.4, 20.4}}},
+			PageSize: 10,
+		})
+		require.NoError(s.T(), err)
+
+		// Locations are moved, so the times they were updated at are not compared.
+		for i := range res.radius.Users {
+			res.radius.Users[i].LocationUpdatedAt = time.Time{}
+		}
+		for i := range res.nearest {
+			res.nearest[i].LocationUpdatedAt = time.Time{}
+		}
+		for i := range res.bbox.Users {
+			res.bbox.Users[i].LocationUpdatedAt = time.Time{}
+		}
+		for i := range res.polygon.Users {
+			res.polygon.Users[i].LocationUpdatedAt = time.Time{}
+		}
+		return res
+	}
+
+	before := search()
+	require.Len(s.T(), before.radius.Users, 1)
+	require.Equal(s.T(), users[0].ID, before.radius.Users[0].ID)
+	require.Equal(s.T(), geo.Point{10.5, 20.5}, before.radius.Users[0].Point)
+	require.Equal(s.T(), before.radius.Users[0].Distance, before.radius.NextPageTokenDistance)
+	require.Len(s.T(), before.nearest, 2)
+	require.Equal(s.T(), users[0].ID, before.nearest[0].ID)
+	require.Len(s.T(), before.bbox.Users, 2)
+	require.Len(s.T(), before.polygon.Users, 2)
+
+	// The exact location is moved around inside the same cell, nothing shown to others changes.
+	for _, point := range []geo.Point{{10.9, 20.9}, {10.01, 20.99}, {10.5, 20.5}} {
+		_, err = repo.SetLocation(ctx, port.LocationRepositorySetLocationRequest{UserID: users[0].ID, Point: point})
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), before, search())
+	}
+
+	// The location is moved to the next cell.
+	_, err = repo.SetLocation(ctx, port.LocationRepositorySetLocationRequest{UserID: users[0].ID, Point: geo.Point{11.1, 20.5}})
+	require.NoError(s.T(), err)
+	after := search()
+	require.Len(s.T(), after.bbox.Users, 1)
+	require.Equal(s.T(), users[1].ID, after.bbox.Users[0].ID)
+}
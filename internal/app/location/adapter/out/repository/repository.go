@@ -18,4 +18,18 @@ const (
 	UserTable = "users"
 	// LocationTable is locations table name.
 	LocationTable = "locations"
+	// GeofenceTable is geofences table name.
+	GeofenceTable = "geofences"
+	// GeofenceEventTable is geofence events table name.
+	GeofenceEventTable = "geofence_events"
+	// ErasureJobTable is erasure jobs table name.
+	ErasureJobTable = "erasure_jobs"
+	// PrivacySettingsTable is privacy settings table name.
+	PrivacySettingsTable = "privacy_settings"
+	// FollowTable is follows table name.
+	FollowTable = "follows"
+	// BlockTable is blocks table name.
+	BlockTable = "blocks"
+	// IdempotencyKeyTable is idempotency keys table name.
+	IdempotencyKeyTable = "idempotency_keys"
 )
@@ -2,6 +2,7 @@ package repository_test
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
@@ -94,3 +95,31 @@ func (s *PostgresTestSuite) seedLocations(args []port.LocationRepositorySetLocat
 
 	return locations
 }
+
+var ageLocationQuery = fmt.Sprintf(
+	`
+UPDATE %s
+SET updated_at = updated_at - make_interval(secs => $2)
+WHERE user_id = $1
+`,
+	repository.LocationTable,
+)
+
+// ageLocation moves the time the location of the user was updated at back by `age`.
+// The trigger updating `updated_at` is disabled while the location is aged.
+func (s *PostgresTestSuite) ageLocation(userID int, age time.Duration) {
+	tx, err := s.db.Begin()
+	require.NoError(s.T(), err)
+
+	defer tx.Rollback()
+
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s DISABLE TRIGGER update_updated_at", repository.LocationTable))
+	require.NoError(s.T(), err)
+	_, err = tx.Exec(ageLocationQuery, userID, age.Seconds())
+	require.NoError(s.T(), err)
+	_, err = tx.Exec(fmt.Sprintf("ALTER TABLE %s ENABLE TRIGGER update_updated_at", repository.LocationTable))
+	require.NoError(s.T(), err)
+
+	err = tx.Commit()
+	require.NoError(s.T(), err)
+}
@@ -0,0 +1,329 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+// socialPair is a key of follows and blocks, `from` is the follower or the blocker and `to` is the followee or the blocked user.
+type socialPair struct {
+	from, to int
+}
+
+// withUsernames returns the follow with current usernames of its users.
+// It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) withUsernames(follow domain.Follow) domain.Follow {
+	follow.Follower = r.users[follow.FollowerID].Username
+	follow.Followee = r.users[follow.FolloweeID].Username
+	return follow
+}
+
+// connected reports whether the user with given id accepted a follow request of the user with username `username`.
+// Every user is connected if `username` is empty. It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) connected(username string, id int) bool {
+	if username == "" {
+		return true
+	}
+	followerID, ok := r.userIDs[username]
+	return ok && r.follows[socialPair{from: followerID, to: id}].Status == domain.FollowAccepted
+}
+
+// blocked reports whether the user with given id blocked the user with username `username` or was blocked by it.
+// No user is blocked if `username` is empty. It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) blocked(username string, id int) bool {
+	if username == "" {
+		return false
+	}
+	otherID, ok := r.userIDs[username]
+	return ok && r.blockedBetween(id, otherID)
+}
+
+// blockedBetween reports whether any of the users with given ids blocked the other one.
+// It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) blockedBetween(id, otherID int) bool {
+	_, blocked := r.blocks[socialPair{from: id, to: otherID}]
+	_, blockedBack := r.blocks[socialPair{from: otherID, to: id}]
+	return blocked || blockedBack
+}
+
+// pairOfUsers finds ids of two different users by usernames.
+// It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) pairOfUsers(username, otherUsername string) (int, int, error) {
+	id, ok := r.userIDs[username]
+	if !ok {
+		return 0, 0, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+	otherID, ok := r.userIDs[otherUsername]
+	if !ok {
+		return 0, 0, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+	if id == otherID {
+		return 0, 0, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+	return id, otherID, nil
+}
+
+// CreateFollow creates a pending follow request of `arg.Follower` to `arg.Followee`.
+//
+// It returns the created follow and any error encountered.
+//
+// `ErrNotFound` is returned in case any of the users is not found.
+//
+// `ErrInvalidArgument` is returned in case the follower is the followee.
+//
+// `ErrAlreadyExists` is returned in case the follow already exists.
+//
+// `ErrFailedPrecondition` is returned in case any of the users blocked the other one.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) CreateFollow(ctx context.Context, arg port.SocialRepositoryFollowRequest) (domain.Follow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	followerID, followeeID, err := r.pairOfUsers(arg.Follower, arg.Followee)
+	if err != nil {
+		return domain.Follow{}, err
+	}
+
+	if r.blockedBetween(followerID, followeeID) {
+		return domain.Follow{}, fmt.Errorf("%w", errpack.ErrFailedPrecondition)
+	}
+
+	key := socialPair{from: followerID, to: followeeID}
+	if _, ok := r.follows[key]; ok {
+		return domain.Follow{}, fmt.Errorf("%w", errpack.ErrAlreadyExists)
+	}
+
+	now := memoryNow()
+	follow := domain.Follow{
+		FollowerID: followerID,
+		FolloweeID: followeeID,
+		Status:     domain.FollowPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	r.follows[key] = follow
+
+	return r.withUsernames(follow), nil
+}
+
+// AcceptFollow accepts the follow request of `arg.Follower` to `arg.Followee`.
+//
+// It returns the accepted follow and any error encountered.
+//
+// `ErrNotFound` is returned in case the follow is not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) AcceptFollow(ctx context.Context, arg port.SocialRepositoryFollowRequest) (domain.Follow, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := socialPair{from: r.userIDs[arg.Follower], to: r.userIDs[arg.Followee]}
+	follow, ok := r.follows[key]
+	if !ok {
+		return domain.Follow{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	follow.Status = domain.FollowAccepted
+	follow.UpdatedAt = memoryNow()
+	r.follows[key] = follow
+
+	return r.withUsernames(follow), nil
+}
+
+// DeleteFollow deletes the follow request of `arg.Follower` to `arg.Followee` whether it is accepted or not.
+//
+// `ErrNotFound` is returned in case the follow is not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) DeleteFollow(ctx context.Context, arg port.SocialRepositoryFollowRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := socialPair{from: r.userIDs[arg.Follower], to: r.userIDs[arg.Followee]}
+	if _, ok := r.follows[key]; !ok {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+	delete(r.follows, key)
+
+	return nil
+}
+
+// CreateBlock blocks `arg.Blocked` by `arg.Blocker` and deletes follows between them in both directions.
+// Blocking a blocked user changes nothing.
+//
+// It returns the block and any error encountered.
+//
+// `ErrNotFound` is returned in case any of the users is not found.
+//
+// `ErrInvalidArgument` is returned in case the blocker is the blocked user.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) CreateBlock(ctx context.Context, arg port.SocialRepositoryBlockRequest) (domain.Block, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	blockerID, blockedID, err := r.pairOfUsers(arg.Blocker, arg.Blocked)
+	if err != nil {
+		return domain.Block{}, err
+	}
+
+	delete(r.follows, socialPair{from: blockerID, to: blockedID})
+	delete(r.follows, socialPair{from: blockedID, to: blockerID})
+
+	key := socialPair{from: blockerID, to: blockedID}
+	block, ok := r.blocks[key]
+	if !ok {
+		block = domain.Block{
+			BlockerID: blockerID,
+			BlockedID: blockedID,
+			CreatedAt: memoryNow(),
+		}
+		r.blocks[key] = block
+	}
+	block.Blocker = r.users[blockerID].Username
+	block.Blocked = r.users[blockedID].Username
+
+	return block, nil
+}
+
+// DeleteBlock deletes the block of `arg.Blocked` by `arg.Blocker`.
+//
+// `ErrNotFound` is returned in case the block is not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) DeleteBlock(ctx context.Context, arg port.SocialRepositoryBlockRequest) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := socialPair{from: r.userIDs[arg.Blocker], to: r.userIDs[arg.Blocked]}
+	if _, ok := r.blocks[key]; !ok {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+	delete(r.blocks, key)
+
+	return nil
+}
+
+// ListFollowing finds no more than `arg.PageSize` follows of `arg.Username`
+// with followee IDs greater than `arg.PageToken`, filtered by `arg.Status` unless it is empty.
+//
+// Follows are ordered by followee ID and paginated the same way the postgres repository does it.
+func (r *memoryRepository) ListFollowing(ctx context.Context, arg port.SocialRepositoryListFollowsRequest) (port.SocialRepositoryListFollowsResponse, error) {
+	return r.listFollows(arg, func(follow domain.Follow) (int, int) {
+		return follow.FollowerID, follow.FolloweeID
+	}), nil
+}
+
+// ListFollowers finds no more than `arg.PageSize` follows to `arg.Username`
+// with follower IDs greater than `arg.PageToken`, filtered by `arg.Status` unless it is empty.
+//
+// Follows are ordered by follower ID and paginated the same way the postgres repository does it.
+func (r *memoryRepository) ListFollowers(ctx context.Context, arg port.SocialRepositoryListFollowsRequest) (port.SocialRepositoryListFollowsResponse, error) {
+	return r.listFollows(arg, func(follow domain.Follow) (int, int) {
+		return follow.FolloweeID, follow.FollowerID
+	}), nil
+}
+
+// listFollows lists follows of the user ordered by ID of the other user,
+// `sides` returns ids of the user and of the other user of a follow.
+func (r *memoryRepository) listFollows(arg port.SocialRepositoryListFollowsRequest, sides func(domain.Follow) (int, int)) port.SocialRepositoryListFollowsResponse {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.userIDs[arg.Username]
+	if !ok {
+		return port.SocialRepositoryListFollowsResponse{}
+	}
+
+	otherID := func(follow domain.Follow) int {
+		_, other := sides(follow)
+		return other
+	}
+
+	var follows []domain.Follow
+	for _, follow := range r.follows {
+		userID, other := sides(follow)
+		if userID != id || other <= arg.PageToken || (arg.Status != "" && follow.Status != arg.Status) {
+			continue
+		}
+		follows = append(follows, r.withUsernames(follow))
+	}
+	sort.Slice(follows, func(i, j int) bool {
+		return otherID(follows[i]) < otherID(follows[j])
+	})
+
+	result := port.SocialRepositoryListFollowsResponse{
+		Follows: follows,
+	}
+	if len(follows) > arg.PageSize { // Next page exists.
+		result.Follows = follows[:arg.PageSize]
+		result.NextPageToken = otherID(result.Follows[len(result.Follows)-1])
+	}
+
+	return result
+}
+
+// ListBlocks finds no more than `arg.PageSize` blocks by `arg.Username` with blocked user IDs greater than `arg.PageToken`.
+//
+// Blocks are ordered by blocked user ID and paginated the same way the postgres repository does it.
+func (r *memoryRepository) ListBlocks(ctx context.Context, arg port.SocialRepositoryListBlocksRequest) (port.SocialRepositoryListBlocksResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.userIDs[arg.Username]
+	if !ok {
+		return port.SocialRepositoryListBlocksResponse{}, nil
+	}
+
+	var blocks []domain.Block
+	for key, block := range r.blocks {
+		if key.from != id || key.to <= arg.PageToken {
+			continue
+		}
+		block.Blocker = r.users[block.BlockerID].Username
+		block.Blocked = r.users[block.BlockedID].Username
+		blocks = append(blocks, block)
+	}
+	sort.Slice(blocks, func(i, j int) bool {
+		return blocks[i].BlockedID < blocks[j].BlockedID
+	})
+
+	result := port.SocialRepositoryListBlocksResponse{
+		Blocks: blocks,
+	}
+	if len(blocks) > arg.PageSize { // Next page exists.
+		result.Blocks = blocks[:arg.PageSize]
+		result.NextPageToken = result.Blocks[len(result.Blocks)-1].BlockedID
+	}
+
+	return result, nil
+}
+
+// deleteSocialGraph deletes follows and blocks of the user with given id in both directions.
+// It is meant to be called in the scope of a transaction.
+func (r *memoryRepository) deleteSocialGraph(tx *memoryTx, id int) {
+	for key, follow := range r.follows {
+		if key.from == id || key.to == id {
+			key, follow := key, follow
+			delete(r.follows, key)
+			tx.onRollback(func() {
+				r.follows[key] = follow
+			})
+		}
+	}
+	for key, block := range r.blocks {
+		if key.from == id || key.to == id {
+			key, block := key, block
+			delete(r.blocks, key)
+			tx.onRollback(func() {
+				r.blocks[key] = block
+			})
+		}
+	}
+}
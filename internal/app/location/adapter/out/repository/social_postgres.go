@@ -0,0 +1,537 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+// connectionCondition matches users selected as `u` who accepted follow requests of the user with
+// username `username`, or every user if `username` is empty. `username` is an SQL expression of the username.
+func connectionCondition(username string) string {
+	return fmt.Sprintf(
+		`(%[1]s::text = '' OR EXISTS (
+		SELECT 1
+		FROM %[2]s f
+		INNER JOIN %[3]s c ON c.id = f.follower_id
+		WHERE c.username = %[1]s::text AND f.followee_id = u.id AND f.status = 'accepted'
+	))`,
+		username,
+		FollowTable,
+		UserTable,
+	)
+}
+
+// notBlockedCondition matches users selected as `u` who neither blocked the user with username `username`
+// nor were blocked by it, or every user if `username` is empty. `username` is an SQL expression of the username.
+func notBlockedCondition(username string) string {
+	return fmt.Sprintf(
+		`(%[1]s::text = '' OR NOT EXISTS (
+		SELECT 1
+		FROM %[2]s b
+		INNER JOIN %[3]s c ON c.username = %[1]s::text
+		WHERE (b.blocker_id = c.id AND b.blocked_id = u.id) OR (b.blocker_id = u.id AND b.blocked_id = c.id)
+	))`,
+		username,
+		BlockTable,
+		UserTable,
+	)
+}
+
+// followColumns are columns of follows selected as `f` joined with their followers as `fr` and followees as `fe`.
+const followColumns = "f.follower_id, fr.username, f.followee_id, fe.username, f.status, f.created_at, f.updated_at"
+
+// scanFollow scans a follow selected with `followColumns` columns.
+func scanFollow(row rowScanner) (domain.Follow, error) {
+	var follow domain.Follow
+	if err := row.Scan(
+		&follow.FollowerID,
+		&follow.Follower,
+		&follow.FolloweeID,
+		&follow.Followee,
+		&follow.Status,
+		&follow.CreatedAt,
+		&follow.UpdatedAt,
+	); err != nil {
+		return domain.Follow{}, err
+	}
+
+	return follow, nil
+}
+
+// blockColumns are columns of blocks selected as `b` joined with their blockers as `br` and blocked users as `bd`.
+const blockColumns = "b.blocker_id, br.username, b.blocked_id, bd.username, b.created_at"
+
+// scanBlock scans a block selected with `blockColumns` columns.
+func scanBlock(row rowScanner) (domain.Block, error) {
+	var block domain.Block
+	if err := row.Scan(
+		&block.BlockerID,
+		&block.Blocker,
+		&block.BlockedID,
+		&block.Blocked,
+		&block.CreatedAt,
+	); err != nil {
+		return domain.Block{}, err
+	}
+
+	return block, nil
+}
+
+// getPairOfUsers finds two different users by usernames.
+// It is meant to be called in the scope of the database transaction.
+//
+// `ErrNotFound` is returned in case any of the users is not found.
+//
+// `ErrInvalidArgument` is returned in case both usernames are of the same user.
+func (q *postgresQueries) getPairOfUsers(ctx context.Context, username, otherUsername string) (domain.User, domain.User, error) {
+	user, err := q.GetByUsername(ctx, username)
+	if err != nil {
+		return domain.User{}, domain.User{}, err
+	}
+	other, err := q.GetByUsername(ctx, otherUsername)
+	if err != nil {
+		return domain.User{}, domain.User{}, err
+	}
+	if user.ID == other.ID {
+		return domain.User{}, domain.User{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	return user, other, nil
+}
+
+var blockedQuery = fmt.Sprintf(
+	`
+SELECT EXISTS (
+	SELECT 1
+	FROM %s
+	WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+)
+`,
+	BlockTable,
+)
+
+var createFollowQuery = fmt.Sprintf(
+	`
+INSERT INTO %s
+(follower_id, followee_id)
+VALUES ($1, $2)
+RETURNING status, created_at, updated_at
+`,
+	FollowTable,
+)
+
+// CreateFollow creates a pending follow request of `arg.Follower` to `arg.Followee`.
+// The users are found and checked for blocks in the scope of the database transaction.
+//
+// It returns the created follow and any error encountered.
+//
+// `ErrNotFound` is returned in case any of the users is not found.
+//
+// `ErrInvalidArgument` is returned in case the follower is the followee.
+//
+// `ErrAlreadyExists` is returned in case the follow already exists.
+//
+// `ErrFailedPrecondition` is returned in case any of the users blocked the other one.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *postgresRepository) CreateFollow(ctx context.Context, arg port.SocialRepositoryFollowRequest) (domain.Follow, error) {
+	var follow domain.Follow
+
+	err := r.execTx(ctx, func(q *postgresQueries) error {
+		follower, followee, err := q.getPairOfUsers(ctx, arg.Follower, arg.Followee)
+		if err != nil {
+			return err
+		}
+
+		var blocked bool
+		if err = q.db.QueryRowContext(ctx, blockedQuery, follower.ID, followee.ID).Scan(&blocked); err != nil {
+			return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		if blocked {
+			return fmt.Errorf("%w", errpack.ErrFailedPrecondition)
+		}
+
+		follow = domain.Follow{
+			FollowerID: follower.ID,
+			Follower:   follower.Username,
+			FolloweeID: followee.ID,
+			Followee:   followee.Username,
+		}
+		if err = q.db.QueryRowContext(ctx, createFollowQuery, follower.ID, followee.ID).Scan(
+			&follow.Status,
+			&follow.CreatedAt,
+			&follow.UpdatedAt,
+		); err != nil {
+			var pqErr *pq.Error
+			if errors.As(err, &pqErr) && pqErr.Constraint == ConstraintFollowsPkey {
+				return fmt.Errorf("%w", errpack.ErrAlreadyExists)
+			}
+			return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Follow{}, err
+	}
+
+	return follow, nil
+}
+
+var acceptFollowQuery = fmt.Sprintf(
+	`
+UPDATE %s f
+SET status = 'accepted'
+FROM %s fr, %s fe
+WHERE fr.id = f.follower_id AND fe.id = f.followee_id AND fr.username = $1 AND fe.username = $2
+RETURNING %s
+`,
+	FollowTable,
+	UserTable,
+	UserTable,
+	followColumns,
+)
+
+// AcceptFollow accepts the follow request of `arg.Follower` to `arg.Followee`.
+//
+// It returns the accepted follow and any error encountered.
+//
+// `ErrNotFound` is returned in case the follow is not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) AcceptFollow(ctx context.Context, arg port.SocialRepositoryFollowRequest) (domain.Follow, error) {
+	follow, err := scanFollow(q.db.QueryRowContext(ctx, acceptFollowQuery, arg.Follower, arg.Followee))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.Follow{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+		return domain.Follow{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return follow, nil
+}
+
+var deleteFollowQuery = fmt.Sprintf(
+	`
+DELETE FROM %s f
+USING %s fr, %s fe
+WHERE fr.id = f.follower_id AND fe.id = f.followee_id AND fr.username = $1 AND fe.username = $2
+`,
+	FollowTable,
+	UserTable,
+	UserTable,
+)
+
+// DeleteFollow deletes the follow request of `arg.Follower` to `arg.Followee` whether it is accepted or not.
+//
+// `ErrNotFound` is returned in case the follow is not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) DeleteFollow(ctx context.Context, arg port.SocialRepositoryFollowRequest) error {
+	res, err := q.db.ExecContext(ctx, deleteFollowQuery, arg.Follower, arg.Followee)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	return nil
+}
+
+var deleteFollowsBetweenQuery = fmt.Sprintf(
+	`
+DELETE FROM %s
+WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)
+`,
+	FollowTable,
+)
+
+// createBlockQuery returns the existing block in case the user is blocked already.
+var createBlockQuery = fmt.Sprintf(
+	`
+INSERT INTO %s
+(blocker_id, blocked_id)
+VALUES ($1, $2)
+ON CONFLICT (blocker_id, blocked_id) DO UPDATE
+SET blocker_id = EXCLUDED.blocker_id
+RETURNING created_at
+`,
+	BlockTable,
+)
+
+// CreateBlock blocks `arg.Blocked` by `arg.Blocker` and deletes follows between them in both directions.
+// All of it is done in the scope of the database transaction. Blocking a blocked user changes nothing.
+//
+// It returns the block and any error encountered.
+//
+// `ErrNotFound` is returned in case any of the users is not found.
+//
+// `ErrInvalidArgument` is returned in case the blocker is the blocked user.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *postgresRepository) CreateBlock(ctx context.Context, arg port.SocialRepositoryBlockRequest) (domain.Block, error) {
+	var block domain.Block
+
+	err := r.execTx(ctx, func(q *postgresQueries) error {
+		blocker, blocked, err := q.getPairOfUsers(ctx, arg.Blocker, arg.Blocked)
+		if err != nil {
+			return err
+		}
+
+		if _, err = q.db.ExecContext(ctx, deleteFollowsBetweenQuery, blocker.ID, blocked.ID); err != nil {
+			return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+
+		block = domain.Block{
+			BlockerID: blocker.ID,
+			Blocker:   blocker.Username,
+			BlockedID: blocked.ID,
+			Blocked:   blocked.Username,
+		}
+		if err = q.db.QueryRowContext(ctx, createBlockQuery, blocker.ID, blocked.ID).Scan(&block.CreatedAt); err != nil {
+			return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.Block{}, err
+	}
+
+	return block, nil
+}
+
+var deleteBlockQuery = fmt.Sprintf(
+	`
+DELETE FROM %s b
+USING %s br, %s bd
+WHERE br.id = b.blocker_id AND bd.id = b.blocked_id AND br.username = $1 AND bd.username = $2
+`,
+	BlockTable,
+	UserTable,
+	UserTable,
+)
+
+// DeleteBlock deletes the block of `arg.Blocked` by `arg.Blocker`.
+//
+// `ErrNotFound` is returned in case the block is not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) DeleteBlock(ctx context.Context, arg port.SocialRepositoryBlockRequest) error {
+	res, err := q.db.ExecContext(ctx, deleteBlockQuery, arg.Blocker, arg.Blocked)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	if affected == 0 {
+		return fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	return nil
+}
+
+var listFollowingQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s f
+INNER JOIN %s fr ON fr.id = f.follower_id
+INNER JOIN %s fe ON fe.id = f.followee_id
+WHERE fr.username = $1 AND ($2 = '' OR f.status = $2) AND f.followee_id > $3
+ORDER BY f.followee_id
+LIMIT $4
+`,
+	followColumns,
+	FollowTable,
+	UserTable,
+	UserTable,
+)
+
+var listFollowersQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s f
+INNER JOIN %s fr ON fr.id = f.follower_id
+INNER JOIN %s fe ON fe.id = f.followee_id
+WHERE fe.username = $1 AND ($2 = '' OR f.status = $2) AND f.follower_id > $3
+ORDER BY f.follower_id
+LIMIT $4
+`,
+	followColumns,
+	FollowTable,
+	UserTable,
+	UserTable,
+)
+
+// ListFollowing finds no more than `arg.PageSize` follows of `arg.Username`
+// with followee IDs greater than `arg.PageToken`, filtered by `arg.Status` unless it is empty.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of a follow list ordered by followee ID and next page token.
+//
+// A follow list that equals nil should be considered as empty.
+// Next page token is followee ID of last found follow if required amount of follows found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListFollowing(ctx context.Context, arg port.SocialRepositoryListFollowsRequest) (port.SocialRepositoryListFollowsResponse, error) {
+	return q.listFollows(ctx, listFollowingQuery, arg, func(follow domain.Follow) int {
+		return follow.FolloweeID
+	})
+}
+
+// ListFollowers finds no more than `arg.PageSize` follows to `arg.Username`
+// with follower IDs greater than `arg.PageToken`, filtered by `arg.Status` unless it is empty.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of a follow list ordered by follower ID and next page token.
+//
+// A follow list that equals nil should be considered as empty.
+// Next page token is follower ID of last found follow if required amount of follows found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListFollowers(ctx context.Context, arg port.SocialRepositoryListFollowsRequest) (port.SocialRepositoryListFollowsResponse, error) {
+	return q.listFollows(ctx, listFollowersQuery, arg, func(follow domain.Follow) int {
+		return follow.FollowerID
+	})
+}
+
+// listFollows finds follows with given query, `pageToken` returns the page token of a follow.
+func (q *postgresQueries) listFollows(
+	ctx context.Context,
+	query string,
+	arg port.SocialRepositoryListFollowsRequest,
+	pageToken func(domain.Follow) int,
+) (port.SocialRepositoryListFollowsResponse, error) {
+	// Fetch PageSize + 1 (extra marker element)
+	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
+	rows, err := q.db.QueryContext(ctx, query, arg.Username, arg.Status, arg.PageToken, arg.PageSize+1)
+	if err != nil {
+		return port.SocialRepositoryListFollowsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	var follows []domain.Follow
+	hasNextPage := false
+	for rows.Next() {
+		if len(follows) == arg.PageSize { // Next page exists.
+			hasNextPage = true
+			break // Do not scan extra marker element.
+		}
+
+		follow, err := scanFollow(rows)
+		if err != nil {
+			return port.SocialRepositoryListFollowsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		follows = append(follows, follow)
+	}
+	if err = rows.Err(); err != nil {
+		return port.SocialRepositoryListFollowsResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	result := port.SocialRepositoryListFollowsResponse{
+		Follows: follows,
+	}
+	if hasNextPage {
+		result.NextPageToken = pageToken(follows[len(follows)-1])
+	}
+
+	return result, nil
+}
+
+var listBlocksQuery = fmt.Sprintf(
+	`
+SELECT %s
+FROM %s b
+INNER JOIN %s br ON br.id = b.blocker_id
+INNER JOIN %s bd ON bd.id = b.blocked_id
+WHERE br.username = $1 AND b.blocked_id > $2
+ORDER BY b.blocked_id
+LIMIT $3
+`,
+	blockColumns,
+	BlockTable,
+	UserTable,
+	UserTable,
+)
+
+// ListBlocks finds no more than `arg.PageSize` blocks by `arg.Username` with blocked user IDs greater than `arg.PageToken`.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of a block list ordered by blocked user ID and next page token.
+//
+// A block list that equals nil should be considered as empty.
+// Next page token is blocked user ID of last found block if required amount of blocks found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListBlocks(ctx context.Context, arg port.SocialRepositoryListBlocksRequest) (port.SocialRepositoryListBlocksResponse, error) {
+	// Fetch PageSize + 1 (extra marker element)
+	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
+	rows, err := q.db.QueryContext(ctx, listBlocksQuery, arg.Username, arg.PageToken, arg.PageSize+1)
+	if err != nil {
+		return port.SocialRepositoryListBlocksResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	var blocks []domain.Block
+	hasNextPage := false
+	for rows.Next() {
+		if len(blocks) == arg.PageSize { // Next page exists.
+			hasNextPage = true
+			break // Do not scan extra marker element.
+		}
+
+		block, err := scanBlock(rows)
+		if err != nil {
+			return port.SocialRepositoryListBlocksResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		blocks = append(blocks, block)
+	}
+	if err = rows.Err(); err != nil {
+		return port.SocialRepositoryListBlocksResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	result := port.SocialRepositoryListBlocksResponse{
+		Blocks: blocks,
+	}
+	if hasNextPage {
+		result.NextPageToken = blocks[len(blocks)-1].BlockedID
+	}
+
+	return result, nil
+}
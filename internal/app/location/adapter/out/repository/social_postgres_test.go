@@ -0,0 +1,287 @@
+package repository_test
+
+import (
+	"context"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func (s *PostgresTestSuite) Test_PostgresQueries_Follows() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	follow, err := repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[0].ID, follow.FollowerID)
+	require.Equal(s.T(), users[1].Username, follow.Followee)
+	require.Equal(s.T(), domain.FollowPending, follow.Status)
+
+	_, err = repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrAlreadyExists)
+	_, err = repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[0].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+	_, err = repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: "user9"})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	_, err = repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[2].Username})
+	require.NoError(s.T(), err)
+
+	accepted, err := repo.AcceptFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.FollowAccepted, accepted.Status)
+	_, err = repo.AcceptFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[1].Username, Followee: users[0].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	following, err := repo.ListFollowing(ctx, port.SocialRepositoryListFollowsRequest{Username: users[0].Username, PageSize: 1})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), following.Follows, 1)
+	require.Equal(s.T(), users[1].ID, following.NextPageToken)
+	following, err = repo.ListFollowing(ctx, port.SocialRepositoryListFollowsRequest{
+		Username:  users[0].Username,
+		PageToken: following.NextPageToken,
+		PageSize:  1,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), following.Follows, 1)
+	require.Equal(s.T(), users[2].Username, following.Follows[0].Followee)
+	require.Zero(s.T(), following.NextPageToken)
+
+	followers, err := repo.ListFollowers(ctx, port.SocialRepositoryListFollowsRequest{
+		Username: users[1].Username,
+		Status:   domain.FollowAccepted,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), followers.Follows, 1)
+	require.Equal(s.T(), users[0].Username, followers.Follows[0].Follower)
+
+	require.NoError(s.T(), repo.DeleteFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[2].Username}))
+	require.ErrorIs(s.T(), repo.DeleteFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[2].Username}), errpack.ErrNotFound)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_Blocks() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	_, err := repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.NoError(s.T(), err)
+
+	block, err := repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[0].ID, block.BlockedID)
+
+	// Blocking a blocked user keeps the original block.
+	again, err := repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username})
+	require.NoError(s.T(), err)
+	require.WithinDuration(s.T(), block.CreatedAt, again.CreatedAt, 0)
+
+	following, err := repo.ListFollowing(ctx, port.SocialRepositoryListFollowsRequest{Username: users[0].Username, PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), following.Follows)
+
+	_, err = repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.ErrorIs(s.T(), err, errpack.ErrFailedPrecondition)
+
+	blocks, err := repo.ListBlocks(ctx, port.SocialRepositoryListBlocksRequest{Username: users[1].Username, PageSize: 10})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), blocks.Blocks, 1)
+	require.Equal(s.T(), users[0].Username, blocks.Blocks[0].Blocked)
+
+	require.NoError(s.T(), repo.DeleteBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username}))
+	require.ErrorIs(s.T(), repo.DeleteBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username}), errpack.ErrNotFound)
+
+	_, err = repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[1].Username})
+	require.NoError(s.T(), err)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_Follows_ScopedSearches() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+	})
+	s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{10, 20}},
+		{UserID: users[1].ID, Point: geo.Point{10.001, 20}},
+		{UserID: users[2].ID, Point: geo.Point{10.002, 20}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	for _, followee := range []string{users[1].Username, users[2].Username} {
+		_, err := repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: followee})
+		require.NoError(s.T(), err)
+	}
+	_, err := repo.AcceptFollow(ctx, port.SocialRepositoryFollowRequest{Follower: users[0].Username, Followee: users[2].Username})
+	require.NoError(s.T(), err)
+
+	// Only the accepted connection is found.
+	for _, orderBy := range []port.UsersOrder{port.UsersOrderByID, port.UsersOrderByDistance} {
+		radius, err := repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+			Point:         geo.Point{10, 20},
+			Radius:        1000,
+			PageSize:      10,
+			OrderBy:       orderBy,
+			ConnectionsOf: users[0].Username,
+		})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), radius.Users, 1)
+		require.Equal(s.T(), users[2].ID, radius.Users[0].ID)
+	}
+
+	nearest, err := repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point:         geo.Point{10, 20},
+		Limit:         10,
+		ConnectionsOf: users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), nearest, 1)
+	require.Equal(s.T(), users[2].ID, nearest[0].ID)
+
+	nearest, err = repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point:         geo.Point{10, 20},
+		Limit:         10,
+		ConnectionsOf: "user9",
+	})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), nearest)
+
+	bbox, err := repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:          geo.BBox{9, 19, 11, 21},
+		PageSize:      10,
+		ConnectionsOf: users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 1)
+	require.Equal(s.T(), users[2].ID, bbox.Users[0].ID)
+
+	polygon, err := repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+		Polygon:       geo.Polygon{{{9, 19}, {11, 19}, {11, 21}, {9, 21}, {9, 19}}},
+		PageSize:      10,
+		ConnectionsOf: users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), polygon.Users, 1)
+	require.Equal(s.T(), users[2].ID, polygon.Users[0].ID)
+
+	connected, err := repo.IsConnection(ctx, users[0].Username, users[2].ID)
+	require.NoError(s.T(), err)
+	require.True(s.T(), connected)
+	connected, err = repo.IsConnection(ctx, users[0].Username, users[1].ID)
+	require.NoError(s.T(), err)
+	require.False(s.T(), connected)
+	connected, err = repo.IsConnection(ctx, "", users[1].ID)
+	require.NoError(s.T(), err)
+	require.True(s.T(), connected)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_Blocks_ScopedSearches() {
+	ctx := context.Background()
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+		{Username: "user3"},
+	})
+	s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{10, 20}},
+		{UserID: users[1].ID, Point: geo.Point{10.001, 20}},
+		{UserID: users[2].ID, Point: geo.Point{10.002, 20}},
+		{UserID: users[3].ID, Point: geo.Point{10.003, 20}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	// The caller is blocked by the second user and blocks the third one.
+	_, err := repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[1].Username, Blocked: users[0].Username})
+	require.NoError(s.T(), err)
+	_, err = repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{Blocker: users[0].Username, Blocked: users[2].Username})
+	require.NoError(s.T(), err)
+
+	for _, orderBy := range []port.UsersOrder{port.UsersOrderByID, port.UsersOrderByDistance} {
+		radius, err := repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+			Point:    geo.Point{10, 20},
+			Radius:   1000,
+			PageSize: 10,
+			OrderBy:  orderBy,
+			Caller:   users[0].Username,
+		})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), radius.Users, 2)
+		require.Equal(s.T(), users[0].ID, radius.Users[0].ID)
+		require.Equal(s.T(), users[3].ID, radius.Users[1].ID)
+	}
+
+	// Without the caller blocks are ignored.
+	radius, err := repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{10, 20},
+		Radius:   1000,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), radius.Users, 4)
+
+	nearest, err := repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point:  geo.Point{10, 20},
+		Limit:  10,
+		Caller: users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), nearest, 2)
+	require.Equal(s.T(), users[0].ID, nearest[0].ID)
+	require.Equal(s.T(), users[3].ID, nearest[1].ID)
+
+	bbox, err := repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{9, 19, 11, 21},
+		PageSize: 10,
+		Caller:   users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 2)
+	require.Equal(s.T(), users[0].ID, bbox.Users[0].ID)
+	require.Equal(s.T(), users[3].ID, bbox.Users[1].ID)
+
+	polygon, err := repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+		Polygon:  geo.Polygon{{{9, 19}, {11, 19}, {11, 21}, {9, 21}, {9, 19}}},
+		PageSize: 10,
+		Caller:   users[0].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), polygon.Users, 2)
+	require.Equal(s.T(), users[0].ID, polygon.Users[0].ID)
+	require.Equal(s.T(), users[3].ID, polygon.Users[1].ID)
+
+	for _, tc := range []struct {
+		username string
+		userID   int
+		blocked  bool
+	}{
+		{users[0].Username, users[1].ID, true},
+		{users[0].Username, users[2].ID, true},
+		{users[0].Username, users[3].ID, false},
+		{users[1].Username, users[0].ID, true},
+		{users[1].Username, users[2].ID, false},
+		{"", users[1].ID, false},
+	} {
+		blocked, err := repo.IsBlocked(ctx, tc.username, tc.userID)
+		require.NoError(s.T(), err)
+		require.Equal(s.T(), tc.blocked, blocked)
+	}
+}
@@ -0,0 +1,658 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// usernameRegexp is the constraint of usernames in users table.
+var usernameRegexp = regexp.MustCompile(`^[a-zA-Z0-9]{4,16}$`)
+
+// memoryNearestSearchRadius is a radius in meters the search of nearest users starts with.
+// The radius is enlarged until enough users are found.
+const memoryNearestSearchRadius = 10000
+
+// CreateUser adds a new user.
+//
+// It returns the created user and any error encountered.
+//
+// `ErrInvalidArgument` is returned in case username is invalid.
+//
+// `ErrAlreadyExists` is returned in case a user with given username already exists.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) CreateUser(ctx context.Context, arg port.CreateUserArg) (domain.User, error) {
+	var user domain.User
+
+	err := r.execTx(func(tx *memoryTx) error {
+		var err error
+		user, err = r.createUser(tx, arg)
+		return err
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// createUser adds a new user. It is meant to be called in the scope of a transaction.
+func (r *memoryRepository) createUser(tx *memoryTx, arg port.CreateUserArg) (domain.User, error) {
+	if !usernameRegexp.MatchString(arg.Username) {
+		return domain.User{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+	if _, ok := r.userIDs[arg.Username]; ok {
+		return domain.User{}, fmt.Errorf("%w", errpack.ErrAlreadyExists)
+	}
+
+	now := memoryNow()
+	r.lastUserID++
+	user := domain.User{
+		ID:        r.lastUserID,
+		Username:  arg.Username,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	r.users[user.ID] = user
+	r.userIDs[user.Username] = user.ID
+
+	tx.onRollback(func() {
+		delete(r.users, user.ID)
+		delete(r.userIDs, user.Username)
+	})
+
+	return user, nil
+}
+
+// GetByUsername finds a user by username.
+//
+// It returns `User` and `error`.
+//
+// `ErrNotFound` is returned in case user not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) GetByUsername(ctx context.Context, username string) (domain.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.userIDs[username]
+	if !ok {
+		return domain.User{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	return r.users[id], nil
+}
+
+// GetUserWithLocation finds a user by username along with its location and privacy settings.
+//
+// It returns a response and any error encountered.
+// `Location` of the response equals nil if the user has no location
+// and `Privacy` of the response equals nil if the user has no privacy settings.
+//
+// `ErrNotFound` is returned in case user not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) GetUserWithLocation(ctx context.Context, username string) (port.UserRepositoryGetUserWithLocationResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id, ok := r.userIDs[username]
+	if !ok {
+		return port.UserRepositoryGetUserWithLocationResponse{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	result := port.UserRepositoryGetUserWithLocationResponse{
+		User:    r.users[id],
+		Privacy: r.userPrivacySettings(id),
+	}
+	if location, ok := r.locations[id]; ok {
+		location = cloneLocation(location)
+		result.Location = &location
+	}
+
+	return result, nil
+}
+
+// ListUsers finds no more than `arg.PageSize` users whose usernames start with `arg.Prefix`.
+//
+// Users are ordered by ID and only users with IDs greater than `arg.PageToken` are returned.
+//
+// It returns a response that consists of a user list and next page token.
+// A user list that equals nil should be considered as empty.
+// Next page token is ID of last found user if required amount of users found.
+// If the next page token equal 0, there is no more pages.
+func (r *memoryRepository) ListUsers(ctx context.Context, arg port.UserRepositoryListUsersRequest) (port.UserRepositoryListUsersResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []domain.User
+	for _, user := range r.users {
+		if user.ID > arg.PageToken && strings.HasPrefix(user.Username, arg.Prefix) {
+			users = append(users, user)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	result := port.UserRepositoryListUsersResponse{
+		Users: users,
+	}
+	if len(users) > arg.PageSize { // Next page exists.
+		result.Users = users[:arg.PageSize]
+		result.NextPageToken = result.Users[len(result.Users)-1].ID
+	}
+
+	return result, nil
+}
+
+// RenameUser changes username of the user with `arg.Username` to `arg.NewUsername`
+// and moves idempotency keys of the user to the new username.
+//
+// It returns the renamed user and any error encountered.
+//
+// `ErrNotFound` is returned in case the user is not found.
+//
+// `ErrInvalidArgument` is returned in case the new username is invalid.
+//
+// `ErrAlreadyExists` is returned in case a user with the new username already exists.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) RenameUser(ctx context.Context, arg port.UserRepositoryRenameUserRequest) (domain.User, error) {
+	var user domain.User
+
+	err := r.execTx(func(tx *memoryTx) error {
+		id, ok := r.userIDs[arg.Username]
+		if !ok {
+			return fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+		if !usernameRegexp.MatchString(arg.NewUsername) {
+			return fmt.Errorf("%w", errpack.ErrInvalidArgument)
+		}
+		if otherID, ok := r.userIDs[arg.NewUsername]; ok && otherID != id {
+			return fmt.Errorf("%w", errpack.ErrAlreadyExists)
+		}
+
+		user = r.users[id]
+		user.Username = arg.NewUsername
+		user.UpdatedAt = memoryNow()
+
+		delete(r.userIDs, arg.Username)
+		r.userIDs[user.Username] = user.ID
+		r.users[user.ID] = user
+
+		if arg.NewUsername != arg.Username {
+			// Keys left by requests for the new username before the user took it are not of the user.
+			r.deleteUserIdempotencyKeys(tx, arg.NewUsername)
+			r.renameUserIdempotencyKeys(tx, arg.Username, arg.NewUsername)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// DeleteUser deletes a user with given username along with its location, geofence events and idempotency keys
+// and creates an erasure job to erase data of the user from other services.
+//
+// It returns the created erasure job and any error encountered.
+//
+// `ErrNotFound` is returned in case the user is not found.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) DeleteUser(ctx context.Context, username string) (domain.ErasureJob, error) {
+	var job domain.ErasureJob
+
+	err := r.execTx(func(tx *memoryTx) error {
+		user, err := r.deleteUser(tx, username)
+		if err != nil {
+			return err
+		}
+		r.deleteUserIdempotencyKeys(tx, username)
+		job = r.createErasureJob(tx, user)
+		return nil
+	})
+	if err != nil {
+		return domain.ErasureJob{}, err
+	}
+
+	return job, nil
+}
+
+// deleteUser deletes a user with given username along with its location, privacy settings,
+// follows, blocks and geofence events.
+// It is meant to be called in the scope of a transaction.
+func (r *memoryRepository) deleteUser(tx *memoryTx, username string) (domain.User, error) {
+	id, ok := r.userIDs[username]
+	if !ok {
+		return domain.User{}, fmt.Errorf("%w", errpack.ErrNotFound)
+	}
+
+	user := r.users[id]
+	delete(r.users, id)
+	delete(r.userIDs, username)
+
+	location, hasLocation := r.locations[id]
+	if hasLocation {
+		r.unindexLocation(id)
+		delete(r.locations, id)
+	}
+
+	privacy, hasPrivacy := r.privacy[id]
+	delete(r.privacy, id)
+
+	r.deleteSocialGraph(tx, id)
+
+	events := r.events
+	r.events = nil
+	for _, event := range events {
+		if event.UserID != id {
+			r.events = append(r.events, event)
+		}
+	}
+
+	tx.onRollback(func() {
+		r.users[id] = user
+		r.userIDs[username] = id
+		if hasPrivacy {
+			r.privacy[id] = privacy
+		}
+		if hasLocation {
+			r.locations[id] = location
+			r.indexLocation(id)
+		}
+		r.events = events
+	})
+
+	return user, nil
+}
+
+// SetUserLocation sets user's location the same way the postgres repository does it.
+//
+// It finds a user by the provided username. If the user is not found, it creates new one
+// unless `arg.DisableUserAutoCreation` is true. Then sets location of the user.
+// All of it is done in the scope of a transaction, so nothing is changed in case of an error.
+//
+// If `arg.RecordedAt` is before `RecordedAt` of the previous location, the location is not set,
+// `OutOfOrder` of the response is true and the new location equals the previous one.
+//
+//	`ErrInvalidArgument` is returned in case the username, the point or fix metadata is invalid.
+//
+//	`ErrNotFound` is returned in case the user is not found and its creation is disabled.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) SetUserLocation(ctx context.Context, arg port.UserRepositorySetUserLocationRequest) (port.UserRepositorySetUserLocationResponse, error) {
+	var res port.UserRepositorySetUserLocationResponse
+
+	err := r.execTx(func(tx *memoryTx) error {
+		var err error
+		res, err = r.setUserLocation(tx, arg)
+		return err
+	})
+	if err != nil {
+		return port.UserRepositorySetUserLocationResponse{}, err
+	}
+
+	return res, nil
+}
+
+// SetUserLocations sets locations of users one by one in order of `args`.
+//
+// Every location is set the same way `SetUserLocation` does it, but all of them are set
+// in the scope of a single transaction. If any location fails to be set, none of them are set.
+//
+// It returns responses in order of `args` and any error encountered.
+// Returned errors are the same `SetUserLocation` returns.
+func (r *memoryRepository) SetUserLocations(ctx context.Context, args []port.UserRepositorySetUserLocationRequest) ([]port.UserRepositorySetUserLocationResponse, error) {
+	result := make([]port.UserRepositorySetUserLocationResponse, 0, len(args))
+
+	err := r.execTx(func(tx *memoryTx) error {
+		for _, arg := range args {
+			res, err := r.setUserLocation(tx, arg)
+			if err != nil {
+				return err
+			}
+			result = append(result, res)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// setUserLocation finds or creates a user and sets its location.
+// It is meant to be called in the scope of a transaction.
+func (r *memoryRepository) setUserLocation(tx *memoryTx, arg port.UserRepositorySetUserLocationRequest) (port.UserRepositorySetUserLocationResponse, error) {
+	var user domain.User
+	var prevLocation domain.Location
+
+	if id, ok := r.userIDs[arg.Username]; ok {
+		user = r.users[id]
+		prevLocation = cloneLocation(r.locations[id])
+	} else {
+		if arg.DisableUserAutoCreation {
+			return port.UserRepositorySetUserLocationResponse{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+
+		var err error
+		user, err = r.createUser(tx, port.CreateUserArg{Username: arg.Username})
+		if err != nil {
+			// ErrInvalidArgument occurred.
+			return port.UserRepositorySetUserLocationResponse{}, err
+		}
+	}
+
+	if prevLocation.UserID == user.ID && !arg.RecordedAt.IsZero() && arg.RecordedAt.Before(prevLocation.RecordedAt) {
+		// The stored location is more recent, so it is kept.
+		return port.UserRepositorySetUserLocationResponse{
+			User:         user,
+			PrevLocation: prevLocation,
+			Location:     cloneLocation(prevLocation),
+			OutOfOrder:   true,
+			Privacy:      r.userPrivacySettings(user.ID),
+		}, nil
+	}
+
+	location, err := r.setLocation(tx, port.LocationRepositorySetLocationRequest{
+		UserID:      user.ID,
+		Point:       arg.Point,
+		RecordedAt:  arg.RecordedAt,
+		FixMetadata: arg.FixMetadata,
+	})
+	if err != nil {
+		// ErrInvalidArgument occurred.
+		return port.UserRepositorySetUserLocationResponse{}, err
+	}
+
+	return port.UserRepositorySetUserLocationResponse{
+		User:         user,
+		PrevLocation: prevLocation,
+		Location:     location,
+		Privacy:      r.userPrivacySettings(user.ID),
+	}, nil
+}
+
+// usersInRadius returns users whose locations are within `radius` meters from `center`
+// and were updated within `maxAge`, unless it equals 0. Locations are searched by their visible points,
+// users whose locations are hidden are skipped and only connections of the user with username `connectionsOf`
+// are returned, unless it is empty. Users who blocked the user with username `caller` or were blocked by it
+// are skipped, unless it is empty. Users are not ordered. It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) usersInRadius(center geo.Point, radius float64, maxAge time.Duration, connectionsOf, caller string) []domain.NearbyUser {
+	var users []domain.NearbyUser
+
+	now := memoryNow()
+	r.grid.searchRadius(center, radius, func(id int) {
+		location := r.locations[id]
+		point, visible := r.visiblePoint(id)
+		distance := geo.Distance(center, point)
+		if !visible || distance > radius || !fresh(location.UpdatedAt, maxAge, now) || !r.connected(connectionsOf, id) ||
+			r.blocked(caller, id) {
+			return
+		}
+		users = append(users, domain.NearbyUser{
+			User:              r.users[id],
+			Point:             point,
+			Distance:          distance,
+			LocationUpdatedAt: location.UpdatedAt,
+		})
+	})
+
+	return users
+}
+
+// sortUsersByDistance orders users by distance and then by ID.
+func sortUsersByDistance(users []domain.NearbyUser) {
+	sort.Slice(users, func(i, j int) bool {
+		if users[i].Distance != users[j].Distance {
+			return users[i].Distance < users[j].Distance
+		}
+		return users[i].ID < users[j].ID
+	})
+}
+
+// ListUsersInRadius finds no more than `arg.PageSize` users by given radius and coordinates.
+//
+// Candidates are looked up in the grid index and then their exact distances are checked.
+// Users are ordered and paginated the same way the postgres repository does it.
+// Users whose locations were updated more than `arg.MaxAge` ago are skipped unless it equals 0.
+// Users whose locations are hidden are skipped as well.
+// Locations are searched and returned as privacy settings of the users show them.
+// Only users who accepted follow requests of `arg.ConnectionsOf` are returned, if it is not empty.
+// Users who blocked `arg.Caller` or were blocked by it are not returned, if it is not empty.
+//
+// It returns a response that consists of a user list and next page token.
+// A user list that equals nil should be considered as empty.
+// If the next page token equal 0, there is no more pages.
+func (r *memoryRepository) ListUsersInRadius(ctx context.Context, arg port.UserRepositoryListUsersInRadiusRequest) (port.UserRepositoryListUsersInRadiusResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []domain.NearbyUser
+	for _, user := range r.usersInRadius(arg.Point, arg.Radius, arg.MaxAge, arg.ConnectionsOf, arg.Caller) {
+		if arg.OrderBy == port.UsersOrderByDistance {
+			if user.Distance < arg.PageTokenDistance || (user.Distance == arg.PageTokenDistance && user.ID <= arg.PageToken) {
+				continue
+			}
+		} else if user.ID <= arg.PageToken {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	if arg.OrderBy == port.UsersOrderByDistance {
+		sortUsersByDistance(users)
+	} else {
+		sort.Slice(users, func(i, j int) bool {
+			return users[i].ID < users[j].ID
+		})
+	}
+
+	result := port.UserRepositoryListUsersInRadiusResponse{
+		Users: users,
+	}
+	if len(users) > arg.PageSize { // Next page exists.
+		result.Users = users[:arg.PageSize]
+		last := result.Users[len(result.Users)-1]
+		result.NextPageToken = last.ID
+		if arg.OrderBy == port.UsersOrderByDistance {
+			result.NextPageTokenDistance = last.Distance
+		}
+	}
+
+	return result, nil
+}
+
+// ListNearestUsers finds no more than `arg.Limit` users nearest to `arg.Point`.
+//
+// The search radius is enlarged until enough users are found in it or it covers the whole Earth,
+// so that only the grid cells around the point are checked.
+// Users are ordered by distance from `arg.Point` and then by ID.
+// A user with username `arg.ExcludeUsername` is not returned, if it is set.
+// Only users within `arg.MaxDistance` meters from `arg.Point` are returned, if it is not 0.
+// Only users whose locations were updated within `arg.MaxAge` are returned, if it is not 0.
+// Users whose locations are hidden are not returned.
+// Locations are searched and returned as privacy settings of the users show them.
+// Only users who accepted follow requests of `arg.ConnectionsOf` are returned, if it is not empty.
+// Users who blocked `arg.Caller` or were blocked by it are not returned, if it is not empty.
+//
+// A user list that equals nil should be considered as empty.
+func (r *memoryRepository) ListNearestUsers(ctx context.Context, arg port.UserRepositoryListNearestUsersRequest) ([]domain.NearbyUser, error) {
+	if arg.Limit <= 0 {
+		return nil, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for radius := float64(memoryNearestSearchRadius); ; radius *= 4 {
+		last := radius >= math.Pi*geo.EarthRadius
+		if arg.MaxDistance > 0 && radius >= arg.MaxDistance {
+			radius = arg.MaxDistance
+			last = true
+		}
+
+		var users []domain.NearbyUser
+		for _, user := range r.usersInRadius(arg.Point, radius, arg.MaxAge, arg.ConnectionsOf, arg.Caller) {
+			if arg.ExcludeUsername == "" || user.Username != arg.ExcludeUsername {
+				users = append(users, user)
+			}
+		}
+
+		// Users outside the radius are farther than any user inside it.
+		if len(users) >= arg.Limit || last {
+			sortUsersByDistance(users)
+			if len(users) > arg.Limit {
+				users = users[:arg.Limit]
+			}
+			return users, nil
+		}
+	}
+}
+
+// locatedUser returns the user with given id along with the visible point of its location.
+// It is meant to be called holding the lock of the repository.
+func (r *memoryRepository) locatedUser(id int) domain.LocatedUser {
+	point, _ := r.visiblePoint(id)
+	return domain.LocatedUser{
+		User:              r.users[id],
+		Point:             point,
+		LocationUpdatedAt: r.locations[id].UpdatedAt,
+	}
+}
+
+// pageLocatedUsers orders users by ID and returns no more than `pageSize` of them along with next page token.
+func pageLocatedUsers(users []domain.LocatedUser, pageSize int) port.UserRepositoryListUsersInAreaResponse {
+	sort.Slice(users, func(i, j int) bool {
+		return users[i].ID < users[j].ID
+	})
+
+	result := port.UserRepositoryListUsersInAreaResponse{
+		Users: users,
+	}
+	if len(users) > pageSize { // Next page exists.
+		result.Users = users[:pageSize]
+		result.NextPageToken = result.Users[len(result.Users)-1].ID
+	}
+
+	return result
+}
+
+// ListUsersInBBox finds no more than `arg.PageSize` users inside `arg.BBox` bounding box.
+//
+// Users are ordered by ID and only users with IDs greater than `arg.PageToken` are returned.
+// Bounding boxes crossing the antimeridian are supported.
+// Users whose locations were updated more than `arg.MaxAge` ago are skipped unless it equals 0.
+// Users whose locations are hidden are skipped as well.
+// Locations are searched and returned as privacy settings of the users show them.
+// Only users who accepted follow requests of `arg.ConnectionsOf` are returned, if it is not empty.
+// Users who blocked `arg.Caller` or were blocked by it are not returned, if it is not empty.
+//
+// It returns a response that consists of a user list and next page token.
+// A user list that equals nil should be considered as empty.
+// If the next page token equal 0, there is no more pages.
+func (r *memoryRepository) ListUsersInBBox(ctx context.Context, arg port.UserRepositoryListUsersInBBoxRequest) (port.UserRepositoryListUsersInAreaResponse, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	east := arg.BBox.East()
+	if arg.BBox.CrossesAntimeridian() {
+		east += 360
+	}
+
+	var users []domain.LocatedUser
+	now := memoryNow()
+	r.grid.search(arg.BBox.West(), arg.BBox.South(), east, arg.BBox.North(), func(id int) {
+		location := r.locations[id]
+		point, visible := r.visiblePoint(id)
+		if visible && id > arg.PageToken && arg.BBox.Contains(point) && fresh(location.UpdatedAt, arg.MaxAge, now) &&
+			r.connected(arg.ConnectionsOf, id) && !r.blocked(arg.Caller, id) {
+			users = append(users, r.locatedUser(id))
+		}
+	})
+
+	return pageLocatedUsers(users, arg.PageSize), nil
+}
+
+// ListUsersInPolygon finds no more than `arg.PageSize` users inside `arg.Polygon` polygon.
+//
+// Users are ordered by ID and only users with IDs greater than `arg.PageToken` are returned.
+// Polygons crossing the antimeridian and polygons with holes are supported.
+// Users whose locations were updated more than `arg.MaxAge` ago are skipped unless it equals 0.
+// Users whose locations are hidden are skipped as well.
+// Locations are searched and returned as privacy settings of the users show them.
+// Only users who accepted follow requests of `arg.ConnectionsOf` are returned, if it is not empty.
+// Users who blocked `arg.Caller` or were blocked by it are not returned, if it is not empty.
+//
+// It returns a response that consists of a user list and next page token.
+// A user list that equals nil should be considered as empty.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInvalidArgument` is returned in case the polygon has no exterior ring.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *memoryRepository) ListUsersInPolygon(ctx context.Context, arg port.UserRepositoryListUsersInPolygonRequest) (port.UserRepositoryListUsersInAreaResponse, error) {
+	if len(arg.Polygon) == 0 {
+		return port.UserRepositoryListUsersInAreaResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	// Cells are looked up by the bounds of the unwrapped exterior ring.
+	west, south, east, north := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	for _, point := range arg.Polygon.Unwrap()[0] {
+		west, east = math.Min(west, point.Longitude()), math.Max(east, point.Longitude())
+		south, north = math.Min(south, point.Latitude()), math.Max(north, point.Latitude())
+	}
+	if west > east {
+		return port.UserRepositoryListUsersInAreaResponse{}, nil
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var users []domain.LocatedUser
+	now := memoryNow()
+	r.grid.search(west, south, east, north, func(id int) {
+		location := r.locations[id]
+		point, visible := r.visiblePoint(id)
+		if visible && id > arg.PageToken && fresh(location.UpdatedAt, arg.MaxAge, now) && r.connected(arg.ConnectionsOf, id) &&
+			!r.blocked(arg.Caller, id) && arg.Polygon.Contains(point) {
+			users = append(users, r.locatedUser(id))
+		}
+	})
+
+	return pageLocatedUsers(users, arg.PageSize), nil
+}
+
+// IsConnection reports whether the user with `userID` ID accepted a follow request of the user
+// with `connectionsOf` username. Every user is a connection if `connectionsOf` is empty.
+func (r *memoryRepository) IsConnection(ctx context.Context, connectionsOf string, userID int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.connected(connectionsOf, userID), nil
+}
+
+// IsBlocked reports whether the user with `userID` ID blocked the user with `username` username
+// or was blocked by it. No user is blocked if `username` is empty.
+func (r *memoryRepository) IsBlocked(ctx context.Context, username string, userID int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.blocked(username, userID), nil
+}
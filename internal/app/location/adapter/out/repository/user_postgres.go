@@ -98,9 +98,298 @@ func (q *postgresQueries) GetByUsername(ctx context.Context, username string) (d
 	return user, nil
 }
 
+var getUserWithLocationQuery = fmt.Sprintf(
+	`
+SELECT u.id, u.username, u.created_at, u.updated_at,
+	l.user_id, l.point, l.recorded_at, l.accuracy, l.altitude, l.speed, l.bearing, l.source, l.created_at, l.updated_at,
+	%s
+FROM %s u
+LEFT JOIN %s l ON l.user_id = u.id
+%s
+WHERE u.username = $1
+`,
+	privacySettingsColumns,
+	UserTable,
+	LocationTable,
+	privacySettingsJoin,
+)
+
+// GetUserWithLocation finds a user by username along with its location and privacy settings.
+//
+// It returns a response and any error encountered.
+// `Location` of the response equals nil if the user has no location
+// and `Privacy` of the response equals nil if the user has no privacy settings.
+//
+// `ErrNotFound` is returned in case user not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) GetUserWithLocation(ctx context.Context, username string) (port.UserRepositoryGetUserWithLocationResponse, error) {
+	var user domain.User
+	var location domain.Location
+	var locationUserID sql.NullInt64
+	var point *geo.PostgresPoint
+	var recordedAt, createdAt, updatedAt sql.NullTime
+	var source sql.NullString
+	var privacy nullPrivacySettings
+
+	if err := q.db.QueryRowContext(ctx, getUserWithLocationQuery, username).Scan(
+		&user.ID,
+		&user.Username,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&locationUserID,
+		&point,
+		&recordedAt,
+		&location.Accuracy,
+		&location.Altitude,
+		&location.Speed,
+		&location.Bearing,
+		&source,
+		&createdAt,
+		&updatedAt,
+		&privacy.Level,
+		&privacy.GridSize,
+		&privacy.GeohashPrecision,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return port.UserRepositoryGetUserWithLocationResponse{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+		return port.UserRepositoryGetUserWithLocationResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	result := port.UserRepositoryGetUserWithLocationResponse{
+		User:    user,
+		Privacy: privacy.settings(user.ID),
+	}
+	if locationUserID.Valid {
+		location.UserID = int(locationUserID.Int64)
+		location.Point = geo.Point(*point)
+		location.RecordedAt = recordedAt.Time
+		location.Source = geo.FixSource(source.String)
+		location.CreatedAt = createdAt.Time
+		location.UpdatedAt = updatedAt.Time
+		result.Location = &location
+	}
+
+	return result, nil
+}
+
+var listUsersQuery = fmt.Sprintf(
+	`
+SELECT id, username, created_at, updated_at
+FROM %s
+WHERE username LIKE $1 || '%%' AND id > $2
+ORDER BY id
+LIMIT $3
+`,
+	UserTable,
+)
+
+// ListUsers finds no more than `arg.PageSize` users whose usernames start with `arg.Prefix`.
+//
+// Users are ordered by ID and only users with IDs greater than `arg.PageToken` are returned.
+// `arg.Prefix` must not contain LIKE wildcards.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of a user list and next page token.
+// A user list that equals nil should be considered as empty.
+// Next page token is ID of last found user if required amount of users found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInternalError` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListUsers(ctx context.Context, arg port.UserRepositoryListUsersRequest) (port.UserRepositoryListUsersResponse, error) {
+	var users []domain.User
+
+	// Fetch PageSize + 1 (extra marker element)
+	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
+	rows, err := q.db.QueryContext(ctx, listUsersQuery, arg.Prefix, arg.PageToken, arg.PageSize+1)
+	if err != nil {
+		return port.UserRepositoryListUsersResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	hasNextPage := false
+	counter := 0
+	for rows.Next() {
+		counter++
+		if counter > arg.PageSize { // Next page exists.
+			hasNextPage = true
+			break // Do not scan extra marker element.
+		}
+
+		var user domain.User
+		if err = rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+		); err != nil {
+			return port.UserRepositoryListUsersResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return port.UserRepositoryListUsersResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	result := port.UserRepositoryListUsersResponse{
+		Users: users,
+	}
+	if hasNextPage {
+		result.NextPageToken = users[len(users)-1].ID
+	}
+
+	return result, nil
+}
+
+var renameUserQuery = fmt.Sprintf(
+	`
+UPDATE %s
+SET username = $2
+WHERE username = $1
+RETURNING id, username, created_at, updated_at
+`,
+	UserTable,
+)
+
+// RenameUser changes username of the user with `arg.Username` to `arg.NewUsername`
+// and moves idempotency keys of the user to the new username.
+// All of it is done in the scope of the database transaction.
+//
+// It returns the renamed user and any error encountered.
+//
+// `ErrNotFound` is returned in case the user is not found.
+//
+// `ErrInvalidArgument` is returned in case the new username is invalid.
+//
+// `ErrAlreadyExists` is returned in case a user with the new username already exists.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *postgresRepository) RenameUser(ctx context.Context, arg port.UserRepositoryRenameUserRequest) (domain.User, error) {
+	var user domain.User
+
+	err := r.execTx(ctx, func(q *postgresQueries) error {
+		var err error
+		if user, err = q.renameUser(ctx, arg); err != nil {
+			return err
+		}
+		if arg.NewUsername == arg.Username {
+			return nil
+		}
+		// Keys left by requests for the new username before the user took it are not of the user.
+		if err = q.deleteUserIdempotencyKeys(ctx, arg.NewUsername); err != nil {
+			return err
+		}
+		return q.renameUserIdempotencyKeys(ctx, arg.Username, arg.NewUsername)
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// renameUser changes username of the user and returns the renamed user.
+// It is meant to be called in the scope of the database transaction.
+func (q *postgresQueries) renameUser(ctx context.Context, arg port.UserRepositoryRenameUserRequest) (domain.User, error) {
+	var user domain.User
+
+	if err := q.db.QueryRowContext(ctx, renameUserQuery, arg.Username, arg.NewUsername).Scan(
+		&user.ID,
+		&user.Username,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) {
+			switch pqErr.Code.Name() {
+			case "string_data_right_truncation":
+				return domain.User{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+			}
+
+			switch pqErr.Constraint {
+			case ConstraintUsersUsernameKey:
+				return domain.User{}, fmt.Errorf("%w", errpack.ErrAlreadyExists)
+			case ConstraintUsersUsernameValid:
+				return domain.User{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+			}
+		}
+		return domain.User{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return user, nil
+}
+
+var deleteUserQuery = fmt.Sprintf(
+	`
+DELETE FROM %s
+WHERE username = $1
+RETURNING id, username
+`,
+	UserTable,
+)
+
+// DeleteUser deletes a user with given username along with its location, geofence events and idempotency keys
+// and creates an erasure job to erase data of the user from other services.
+// All of it is done in the scope of the database transaction.
+//
+// It returns the created erasure job and any error encountered.
+//
+// `ErrNotFound` is returned in case the user is not found.
+//
+// `ErrInternalError` is returned in case of any other failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (r *postgresRepository) DeleteUser(ctx context.Context, username string) (domain.ErasureJob, error) {
+	var job domain.ErasureJob
+
+	err := r.execTx(ctx, func(q *postgresQueries) error {
+		user, err := q.deleteUser(ctx, username)
+		if err != nil {
+			return err
+		}
+		if err = q.deleteUserIdempotencyKeys(ctx, username); err != nil {
+			return err
+		}
+		job, err = q.createErasureJob(ctx, user)
+		return err
+	})
+	if err != nil {
+		return domain.ErasureJob{}, err
+	}
+
+	return job, nil
+}
+
+// deleteUser deletes a user with given username and returns the deleted user.
+// It is meant to be called in the scope of the database transaction.
+func (q *postgresQueries) deleteUser(ctx context.Context, username string) (domain.User, error) {
+	var user domain.User
+	if err := q.db.QueryRowContext(ctx, deleteUserQuery, username).Scan(&user.ID, &user.Username); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domain.User{}, fmt.Errorf("%w", errpack.ErrNotFound)
+		}
+		return domain.User{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return user, nil
+}
+
 // SetUserLocation sets user's location.
 //
-// It finds a user by the provided username. If the user is not found, it creates new one.
+// It finds a user by the provided username. If the user is not found, it creates new one
+// unless `arg.DisableUserAutoCreation` is true.
 // If user was found, it finds current location of the user.
 // Then sets location of the user. All of it is done in the scope of the database transaction.
 //
@@ -110,6 +399,10 @@ func (q *postgresQueries) GetByUsername(ctx context.Context, username string) (d
 //		- found or created user
 //		- previous location of the user (should be considered as not found if its `UserID` equals 0)
 //		- new location of the user
+//		- privacy settings of the user (nil if the user has no settings)
+//
+// If `arg.RecordedAt` is before `RecordedAt` of the previous location, the location is not set,
+// `OutOfOrder` of the response is true and the new location equals the previous one.
 //
 // `ErrInternalError` is returned in following cases:
 //		- any error encountered while
@@ -120,49 +413,117 @@ func (q *postgresQueries) GetByUsername(ctx context.Context, username string) (d
 //	`ErrInvalidArgument` is returned in case `ErrInvalidArgument` is returned from
 //	`CreateUser` or `SetLocation` methods.
 //
+//	`ErrNotFound` is returned in case the user is not found and its creation is disabled.
+//
 // Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
 func (r *postgresRepository) SetUserLocation(ctx context.Context, arg port.UserRepositorySetUserLocationRequest) (port.UserRepositorySetUserLocationResponse, error) {
-	var user domain.User
-	var prevLocation domain.Location
-	var location domain.Location
+	var res port.UserRepositorySetUserLocationResponse
 
 	err := r.execTx(ctx, func(q *postgresQueries) error {
 		var err error
+		res, err = q.setUserLocation(ctx, arg)
+		return err
+	})
+	if err != nil {
+		return port.UserRepositorySetUserLocationResponse{}, err
+	}
 
-		user, err = q.GetByUsername(ctx, arg.Username)
-		if err == nil {
-			// User is found.
-			var glErr error
-			prevLocation, glErr = q.GetLocation(ctx, user.ID)
-			if glErr != nil && !errors.Is(glErr, errpack.ErrNotFound) {
-				return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
-			}
-		}
-		if errors.Is(err, errpack.ErrNotFound) {
-			// ErrNotFound occurred.
-			user, err = q.CreateUser(ctx, port.CreateUserArg{Username: arg.Username})
+	return res, nil
+}
+
+// SetUserLocations sets locations of users one by one in order of `args`.
+//
+// Every location is set the same way `SetUserLocation` does it, but all of them are set
+// in the scope of a single database transaction. If any location fails to be set, none of them are set.
+//
+// It returns responses in order of `args` and any error encountered.
+// Returned errors are the same `SetUserLocation` returns.
+func (r *postgresRepository) SetUserLocations(ctx context.Context, args []port.UserRepositorySetUserLocationRequest) ([]port.UserRepositorySetUserLocationResponse, error) {
+	result := make([]port.UserRepositorySetUserLocationResponse, 0, len(args))
+
+	err := r.execTx(ctx, func(q *postgresQueries) error {
+		for _, arg := range args {
+			res, err := q.setUserLocation(ctx, arg)
 			if err != nil {
-				// ErrInternalError or ErrInvalidArgument occurred.
 				return err
 			}
+			result = append(result, res)
 		}
-		if err != nil {
-			// ErrInternalError occurred.
-			return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// setUserLocation finds or creates a user and sets its location.
+// It is meant to be called in the scope of the database transaction.
+func (q *postgresQueries) setUserLocation(ctx context.Context, arg port.UserRepositorySetUserLocationRequest) (port.UserRepositorySetUserLocationResponse, error) {
+	var prevLocation domain.Location
+	var privacy *domain.PrivacySettings
+
+	user, err := q.GetByUsername(ctx, arg.Username)
+	if err == nil {
+		// User is found.
+		var glErr error
+		prevLocation, glErr = q.GetLocation(ctx, user.ID)
+		if glErr != nil && !errors.Is(glErr, errpack.ErrNotFound) {
+			return port.UserRepositorySetUserLocationResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
 		}
 
-		location, err = q.SetLocation(ctx, port.LocationRepositorySetLocationRequest{
-			UserID: user.ID,
-			Point:  arg.Point,
-		})
+		privacy, err = q.getUserPrivacySettings(ctx, user.ID)
 		if err != nil {
-			// ErrInvalidArgument or ErrInternalErr occurred.
-			return err
+			return port.UserRepositorySetUserLocationResponse{}, err
+		}
+	}
+	if errors.Is(err, errpack.ErrNotFound) {
+		// ErrNotFound occurred.
+		if arg.DisableUserAutoCreation {
+			return port.UserRepositorySetUserLocationResponse{}, err
 		}
+		user, err = q.CreateUser(ctx, port.CreateUserArg{Username: arg.Username})
+		if err != nil {
+			// ErrInternalError or ErrInvalidArgument occurred.
+			return port.UserRepositorySetUserLocationResponse{}, err
+		}
+	}
+	if err != nil {
+		// ErrInternalError occurred.
+		return port.UserRepositorySetUserLocationResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
 
-		return nil
+	outOfOrder := port.UserRepositorySetUserLocationResponse{
+		User:         user,
+		PrevLocation: prevLocation,
+		Location:     prevLocation,
+		OutOfOrder:   true,
+		Privacy:      privacy,
+	}
+	if prevLocation.UserID == user.ID && !arg.RecordedAt.IsZero() && arg.RecordedAt.Before(prevLocation.RecordedAt) {
+		// The stored location is more recent, so it is kept.
+		return outOfOrder, nil
+	}
+
+	// The previous location is read without a lock, so a more recent one may be set concurrently.
+	// SetLocation keeps it in that case.
+	location, err := q.SetLocation(ctx, port.LocationRepositorySetLocationRequest{
+		UserID:      user.ID,
+		Point:       arg.Point,
+		RecordedAt:  arg.RecordedAt,
+		FixMetadata: arg.FixMetadata,
 	})
+	if errors.Is(err, errLocationOutOfOrder) {
+		// The conflicting row is locked by the transaction now, so the concurrently set location is read.
+		if outOfOrder.PrevLocation, err = q.GetLocation(ctx, user.ID); err != nil {
+			return port.UserRepositorySetUserLocationResponse{}, err
+		}
+		outOfOrder.Location = outOfOrder.PrevLocation
+		return outOfOrder, nil
+	}
 	if err != nil {
+		// ErrInvalidArgument or ErrInternalErr occurred.
 		return port.UserRepositorySetUserLocationResponse{}, err
 	}
 
@@ -170,42 +531,119 @@ func (r *postgresRepository) SetUserLocation(ctx context.Context, arg port.UserR
 		User:         user,
 		PrevLocation: prevLocation,
 		Location:     location,
+		Privacy:      privacy,
 	}, nil
 }
 
+// earthDistanceRadius is the Earth radius in meters the `<@>` operator calculates distances with.
+const earthDistanceRadius = 3958.747716 * 1609.344
+
+// earthBoxCondition returns a condition matching locations whose earth cubes are inside
+// the box around `point` containing every location within `radius` meters from it.
+// `point` and `radius` are SQL expressions of the point and the radius.
+//
+// The condition can be checked with the `locations_visible_earth_idx` index, but it matches some locations
+// farther than `radius` as well, so the exact distance must be checked in addition.
+// Cubes are built on the `earth()` radius which is greater than the radius of the `<@>` operator,
+// so the radius of the box is scaled up accordingly.
+func earthBoxCondition(point, radius string) string {
+	return fmt.Sprintf(
+		"earth_box(ll_to_earth((%[1]s::point)[1], (%[1]s::point)[0]), %[2]s::float8 * earth() / %.6[3]f) @> l.visible_earth",
+		point,
+		radius,
+		earthDistanceRadius,
+	)
+}
+
 var listUsersInRadiusQuery = fmt.Sprintf(
 	`
-SELECT u.id, u.username, u.created_at, u.updated_at
-FROM %s u
-INNER JOIN %s l ON l.user_id = u.id
-WHERE ($1<@>l.point) * 1609.344 <= $2 AND u.id > $3
+SELECT id, username, created_at, updated_at, point, distance, location_updated_at
+FROM (
+	SELECT u.id, u.username, u.created_at, u.updated_at, l.visible_point AS point,
+		($1<@>l.visible_point) * 1609.344 AS distance, l.updated_at AS location_updated_at
+	FROM %s u
+	INNER JOIN %s l ON l.user_id = u.id
+	WHERE %s
+		AND %s
+		AND ($5::float8 = 0 OR l.updated_at >= localtimestamp - make_interval(secs => $5::float8))
+		AND %s
+		AND %s
+) AS t
+WHERE distance <= $2 AND id > $3
+ORDER BY id
 LIMIT $4
 `,
 	UserTable,
 	LocationTable,
+	earthBoxCondition("$1", "$2"),
+	notHiddenCondition,
+	connectionCondition("$6"),
+	notBlockedCondition("$7"),
 )
 
-// ListUsersInRadius finds no more than `arg.PageSize` users by given radius and coordinates
-// with IDs greater than `arg.PageToken` ordered by ID.
+var listUsersInRadiusOrderByDistanceQuery = fmt.Sprintf(
+	`
+SELECT id, username, created_at, updated_at, point, distance, location_updated_at
+FROM (
+	SELECT u.id, u.username, u.created_at, u.updated_at, l.visible_point AS point,
+		($1<@>l.visible_point) * 1609.344 AS distance, l.updated_at AS location_updated_at
+	FROM %s u
+	INNER JOIN %s l ON l.user_id = u.id
+	WHERE %s
+		AND %s
+		AND ($6::float8 = 0 OR l.updated_at >= localtimestamp - make_interval(secs => $6::float8))
+		AND %s
+		AND %s
+) AS t
+WHERE distance <= $2 AND (distance, id) > ($3, $4)
+ORDER BY distance, id
+LIMIT $5
+`,
+	UserTable,
+	LocationTable,
+	earthBoxCondition("$1", "$2"),
+	notHiddenCondition,
+	connectionCondition("$7"),
+	notBlockedCondition("$8"),
+)
+
+// ListUsersInRadius finds no more than `arg.PageSize` users by given radius and coordinates.
+//
+// By default, users are ordered by ID and only users with IDs greater than `arg.PageToken` are returned.
+// If `arg.OrderBy` equals `UsersOrderByDistance`, users are ordered by distance from `arg.Point`
+// and then by ID, and only users that follow the (`arg.PageTokenDistance`, `arg.PageToken`) pair are returned.
+// Users whose locations were updated more than `arg.MaxAge` ago are skipped unless it equals 0.
+// Users whose locations are hidden are skipped as well.
+// Locations are searched and returned as privacy settings of the users show them.
+// Only users who accepted follow requests of `arg.ConnectionsOf` are returned, if it is not empty.
+// Users who blocked `arg.Caller` or were blocked by it are not returned, if it is not empty.
 //
 // It returns a response and any error encountered.
 //
 // The response consists of a user list and next page token.
-// Next page token is ID of last found user if required amount of users found.
+// Every user is accompanied by its location, the distance in meters from `arg.Point`
+// and the time its location was updated at.
 //
 // A user list that equals nil should be considered as empty.
-// Next page id is an ID of the first user of the next page.
+// Next page token is ID of last found user if required amount of users found.
+// Next page token distance is the distance of that user. It is only set in case users are ordered by distance.
 // If the next page token equal 0, there is no more pages.
 //
 // `ErrInternalErr` is returned in case any error encountered.
 //
 // Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
 func (q *postgresQueries) ListUsersInRadius(ctx context.Context, arg port.UserRepositoryListUsersInRadiusRequest) (port.UserRepositoryListUsersInRadiusResponse, error) {
-	var users []domain.User
+	var users []domain.NearbyUser
 
 	// Fetch PageSize + 1 (extra marker element)
 	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
-	rows, err := q.db.QueryContext(ctx, listUsersInRadiusQuery, geo.PostgresPoint(arg.Point), arg.Radius, arg.PageToken, arg.PageSize+1)
+	var rows *sql.Rows
+	var err error
+	if arg.OrderBy == port.UsersOrderByDistance {
+		rows, err = q.db.QueryContext(ctx, listUsersInRadiusOrderByDistanceQuery, geo.PostgresPoint(arg.Point), arg.Radius, arg.PageTokenDistance, arg.PageToken, arg.PageSize+1, arg.MaxAge.Seconds(), arg.ConnectionsOf, arg.Caller)
+	} else {
+		rows, err = q.db.QueryContext(ctx, listUsersInRadiusQuery, geo.PostgresPoint(arg.Point), arg.Radius, arg.PageToken, arg.PageSize+1, arg.MaxAge.Seconds(), arg.ConnectionsOf, arg.Caller)
+	}
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return port.UserRepositoryListUsersInRadiusResponse{}, nil
@@ -223,21 +661,345 @@ func (q *postgresQueries) ListUsersInRadius(ctx context.Context, arg port.UserRe
 			break // Do not scan extra marker element.
 		}
 
-		var user domain.User
+		var user domain.NearbyUser
+		var point geo.PostgresPoint
 		if err = rows.Scan(
 			&user.ID,
 			&user.Username,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&point,
+			&user.Distance,
+			&user.LocationUpdatedAt,
 		); err != nil {
 			return port.UserRepositoryListUsersInRadiusResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
 		}
+		user.Point = geo.Point(point)
 		users = append(users, user)
 	}
 
 	result := port.UserRepositoryListUsersInRadiusResponse{
 		Users: users,
 	}
+	if hasNextPage {
+		result.NextPageToken = users[len(users)-1].ID
+		if arg.OrderBy == port.UsersOrderByDistance {
+			result.NextPageTokenDistance = users[len(users)-1].Distance
+		}
+	}
+
+	return result, nil
+}
+
+// listNearestUsersQuery orders users by the distance between earth cubes,
+// so that the KNN search can be done with the `locations_visible_earth_idx` index.
+// The distance is still calculated with the `<@>` operator to be consistent with other queries.
+var listNearestUsersQuery = fmt.Sprintf(
+	`
+SELECT u.id, u.username, u.created_at, u.updated_at, l.visible_point,
+	($1<@>l.visible_point) * 1609.344 AS distance, l.updated_at AS location_updated_at
+FROM %s u
+INNER JOIN %s l ON l.user_id = u.id
+WHERE ($2::text = '' OR u.username <> $2::text)
+	AND %s
+	AND ($3::float8 = 0 OR ($1<@>l.visible_point) * 1609.344 <= $3::float8)
+	AND ($5::float8 = 0 OR l.updated_at >= localtimestamp - make_interval(secs => $5::float8))
+	AND %s
+	AND %s
+ORDER BY l.visible_earth <-> ll_to_earth(($1::point)[1], ($1::point)[0]), u.id
+LIMIT $4
+`,
+	UserTable,
+	LocationTable,
+	notHiddenCondition,
+	connectionCondition("$6"),
+	notBlockedCondition("$7"),
+)
+
+// ListNearestUsers finds no more than `arg.Limit` users nearest to `arg.Point`.
+//
+// Users are ordered by distance from `arg.Point`.
+// A user with username `arg.ExcludeUsername` is not returned, if it is set.
+// Only users within `arg.MaxDistance` meters from `arg.Point` are returned, if it is not 0.
+// Only users whose locations were updated within `arg.MaxAge` are returned, if it is not 0.
+// Users whose locations are hidden are not returned.
+// Locations are searched and returned as privacy settings of the users show them.
+// Only users who accepted follow requests of `arg.ConnectionsOf` are returned, if it is not empty.
+// Users who blocked `arg.Caller` or were blocked by it are not returned, if it is not empty.
+//
+// It returns a user list and any error encountered.
+// Every user is accompanied by its location, the distance in meters from `arg.Point`
+// and the time its location was updated at.
+//
+// A user list that equals nil should be considered as empty.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListNearestUsers(ctx context.Context, arg port.UserRepositoryListNearestUsersRequest) ([]domain.NearbyUser, error) {
+	var users []domain.NearbyUser
+
+	rows, err := q.db.QueryContext(ctx, listNearestUsersQuery, geo.PostgresPoint(arg.Point), arg.ExcludeUsername, arg.MaxDistance, arg.Limit, arg.MaxAge.Seconds(), arg.ConnectionsOf, arg.Caller)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user domain.NearbyUser
+		var point geo.PostgresPoint
+		if err = rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&point,
+			&user.Distance,
+			&user.LocationUpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		user.Point = geo.Point(point)
+		users = append(users, user)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return users, nil
+}
+
+// listUsersInBBoxQuery handles bounding boxes crossing the antimeridian (west > east)
+// by matching longitudes on both sides of it.
+var listUsersInBBoxQuery = fmt.Sprintf(
+	`
+SELECT u.id, u.username, u.created_at, u.updated_at, l.visible_point, l.updated_at AS location_updated_at
+FROM %s u
+INNER JOIN %s l ON l.user_id = u.id
+WHERE l.visible_point[1] BETWEEN $2::float8 AND $4::float8
+	AND (
+		($1::float8 <= $3::float8 AND l.visible_point[0] BETWEEN $1::float8 AND $3::float8) OR
+		($1::float8 > $3::float8 AND (l.visible_point[0] >= $1::float8 OR l.visible_point[0] <= $3::float8))
+	)
+	AND ($7::float8 = 0 OR l.updated_at >= localtimestamp - make_interval(secs => $7::float8))
+	AND %s
+	AND %s
+	AND %s
+	AND u.id > $5
+ORDER BY u.id
+LIMIT $6
+`,
+	UserTable,
+	LocationTable,
+	notHiddenCondition,
+	connectionCondition("$8"),
+	notBlockedCondition("$9"),
+)
+
+// ListUsersInBBox finds no more than `arg.PageSize` users inside `arg.BBox` bounding box.
+//
+// Users are ordered by ID and only users with IDs greater than `arg.PageToken` are returned.
+// Bounding boxes crossing the antimeridian are supported.
+// Users whose locations were updated more than `arg.MaxAge` ago are skipped unless it equals 0.
+// Users whose locations are hidden are skipped as well.
+// Locations are searched and returned as privacy settings of the users show them.
+// Only users who accepted follow requests of `arg.ConnectionsOf` are returned, if it is not empty.
+// Users who blocked `arg.Caller` or were blocked by it are not returned, if it is not empty.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of a user list and next page token.
+// Every user is accompanied by its location and the time its location was updated at.
+//
+// A user list that equals nil should be considered as empty.
+// Next page token is ID of last found user if required amount of users found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInternalErr` is returned in case any error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListUsersInBBox(ctx context.Context, arg port.UserRepositoryListUsersInBBoxRequest) (port.UserRepositoryListUsersInAreaResponse, error) {
+	// Fetch PageSize + 1 (extra marker element)
+	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
+	rows, err := q.db.QueryContext(ctx, listUsersInBBoxQuery,
+		arg.BBox.West(), arg.BBox.South(), arg.BBox.East(), arg.BBox.North(),
+		arg.PageToken, arg.PageSize+1, arg.MaxAge.Seconds(), arg.ConnectionsOf, arg.Caller,
+	)
+	if err != nil {
+		return port.UserRepositoryListUsersInAreaResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	return scanLocatedUsers(rows, arg.PageSize)
+}
+
+// listUsersInPolygonQuery checks a point shifted by ±360 degrees as well as the point itself,
+// since the polygon is unwrapped and may lie beyond the antimeridian.
+// A point inside any of holes is not considered to be inside the polygon.
+var listUsersInPolygonQuery = fmt.Sprintf(
+	`
+SELECT u.id, u.username, u.created_at, u.updated_at, l.visible_point, l.updated_at AS location_updated_at
+FROM %s u
+INNER JOIN %s l ON l.user_id = u.id
+WHERE u.id > $3
+	AND ($5::float8 = 0 OR l.updated_at >= localtimestamp - make_interval(secs => $5::float8))
+	AND %s
+	AND %s
+	AND %s
+	AND EXISTS (
+		SELECT 1
+		FROM (VALUES
+			(l.visible_point),
+			(point(l.visible_point[0] + 360, l.visible_point[1])),
+			(point(l.visible_point[0] - 360, l.visible_point[1]))
+		) AS s(p)
+		WHERE $1::polygon @> s.p
+			AND NOT EXISTS (SELECT 1 FROM unnest($2::polygon[]) AS h(hole) WHERE h.hole @> s.p)
+	)
+ORDER BY u.id
+LIMIT $4
+`,
+	UserTable,
+	LocationTable,
+	notHiddenCondition,
+	connectionCondition("$6"),
+	notBlockedCondition("$7"),
+)
+
+// ListUsersInPolygon finds no more than `arg.PageSize` users inside `arg.Polygon` polygon.
+//
+// Users are ordered by ID and only users with IDs greater than `arg.PageToken` are returned.
+// Polygons crossing the antimeridian and polygons with holes are supported.
+// Users whose locations were updated more than `arg.MaxAge` ago are skipped unless it equals 0.
+// Users whose locations are hidden are skipped as well.
+// Locations are searched and returned as privacy settings of the users show them.
+// Only users who accepted follow requests of `arg.ConnectionsOf` are returned, if it is not empty.
+// Users who blocked `arg.Caller` or were blocked by it are not returned, if it is not empty.
+//
+// It returns a response and any error encountered.
+//
+// The response consists of a user list and next page token.
+// Every user is accompanied by its location and the time its location was updated at.
+//
+// A user list that equals nil should be considered as empty.
+// Next page token is ID of last found user if required amount of users found.
+// If the next page token equal 0, there is no more pages.
+//
+// `ErrInvalidArgument` is returned in case the polygon has no exterior ring.
+//
+// `ErrInternalErr` is returned in case any other error encountered.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) ListUsersInPolygon(ctx context.Context, arg port.UserRepositoryListUsersInPolygonRequest) (port.UserRepositoryListUsersInAreaResponse, error) {
+	if len(arg.Polygon) == 0 {
+		return port.UserRepositoryListUsersInAreaResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	polygon := arg.Polygon.Unwrap()
+	holes := make([]geo.PostgresPolygon, 0, len(polygon)-1)
+	for _, hole := range polygon[1:] {
+		holes = append(holes, geo.PostgresPolygon(hole))
+	}
+
+	// Fetch PageSize + 1 (extra marker element)
+	// If such element happens to be retrieved it means that next page can be (probably) retrieved as well.
+	rows, err := q.db.QueryContext(ctx, listUsersInPolygonQuery,
+		geo.PostgresPolygon(polygon[0]), pq.GenericArray{A: holes},
+		arg.PageToken, arg.PageSize+1, arg.MaxAge.Seconds(), arg.ConnectionsOf, arg.Caller,
+	)
+	if err != nil {
+		return port.UserRepositoryListUsersInAreaResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+	defer rows.Close()
+
+	return scanLocatedUsers(rows, arg.PageSize)
+}
+
+var isConnectionQuery = fmt.Sprintf(
+	`
+SELECT EXISTS (SELECT 1 FROM %s u WHERE u.id = $1 AND %s)
+`,
+	UserTable,
+	connectionCondition("$2"),
+)
+
+// IsConnection reports whether the user with `userID` ID accepted a follow request of the user
+// with `connectionsOf` username. Every user is a connection if `connectionsOf` is empty.
+//
+// `ErrInternalError` is returned in case of any failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) IsConnection(ctx context.Context, connectionsOf string, userID int) (bool, error) {
+	var connected bool
+	if err := q.db.QueryRowContext(ctx, isConnectionQuery, userID, connectionsOf).Scan(&connected); err != nil {
+		return false, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return connected, nil
+}
+
+var isBlockedQuery = fmt.Sprintf(
+	`
+SELECT EXISTS (SELECT 1 FROM %s u WHERE u.id = $1 AND NOT %s)
+`,
+	UserTable,
+	notBlockedCondition("$2"),
+)
+
+// IsBlocked reports whether the user with `userID` ID blocked the user with `username` username
+// or was blocked by it. No user is blocked if `username` is empty.
+//
+// `ErrInternalError` is returned in case of any failure.
+//
+// Returned error is wrapped with `fmt.Errorf("%w", err)`. Use `errors.Is()` to compare errors.
+func (q *postgresQueries) IsBlocked(ctx context.Context, username string, userID int) (bool, error) {
+	var blocked bool
+	if err := q.db.QueryRowContext(ctx, isBlockedQuery, userID, username).Scan(&blocked); err != nil {
+		return false, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	return blocked, nil
+}
+
+// scanLocatedUsers scans no more than `pageSize` users from rows
+// that were fetched with an extra marker element.
+func scanLocatedUsers(rows *sql.Rows, pageSize int) (port.UserRepositoryListUsersInAreaResponse, error) {
+	var users []domain.LocatedUser
+
+	hasNextPage := false
+	counter := 0
+	for rows.Next() {
+		counter++
+		if counter > pageSize { // Next page exists.
+			hasNextPage = true
+			break // Do not scan extra marker element.
+		}
+
+		var user domain.LocatedUser
+		var point geo.PostgresPoint
+		if err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&point,
+			&user.LocationUpdatedAt,
+		); err != nil {
+			return port.UserRepositoryListUsersInAreaResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		user.Point = geo.Point(point)
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return port.UserRepositoryListUsersInAreaResponse{}, fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	result := port.UserRepositoryListUsersInAreaResponse{
+		Users: users,
+	}
 	if hasNextPage {
 		result.NextPageToken = users[len(users)-1].ID
 	}
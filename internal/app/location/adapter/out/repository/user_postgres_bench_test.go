@@ -0,0 +1,107 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// seqScanListUsersInRadiusQuery is the radius search without the earth box condition.
+// It can not use an index and scans every location, it is only kept to compare the searches.
+var seqScanListUsersInRadiusQuery = fmt.Sprintf(
+	`
+SELECT id, username, created_at, updated_at, point, distance, location_updated_at
+FROM (
+	SELECT u.id, u.username, u.created_at, u.updated_at, l.point,
+		($1<@>l.point) * 1609.344 AS distance, l.updated_at AS location_updated_at
+	FROM %s u
+	INNER JOIN %s l ON l.user_id = u.id
+) AS t
+WHERE distance <= $2 AND id > $3
+ORDER BY id
+LIMIT $4
+`,
+	repository.UserTable,
+	repository.LocationTable,
+)
+
+// seedRandomLocationsQuery creates `$1` users located uniformly at random.
+var seedRandomLocationsQuery = fmt.Sprintf(
+	`
+WITH u AS (
+	INSERT INTO %s (username)
+	SELECT 'bench' || i FROM generate_series(1, $1::int) AS i
+	RETURNING id
+)
+INSERT INTO %s (user_id, point)
+SELECT id, point(random() * 360 - 180, random() * 180 - 90) FROM u
+`,
+	repository.UserTable,
+	repository.LocationTable,
+)
+
+// BenchmarkPostgresQueries_ListUsersInRadius compares the radius search using the earth cube index
+// with the search scanning every location.
+//
+// Run it with `go test -run=^$ -bench=ListUsersInRadius ./internal/app/location/adapter/out/repository/`.
+func BenchmarkPostgresQueries_ListUsersInRadius(b *testing.B) {
+	// Skip benchmarks when using "-short" flag.
+	if testing.Short() {
+		b.Skip("Skipping long-running benchmarks")
+	}
+
+	db, m, container := setupPostgres(b)
+	defer teardownPostgres(b, db, m, container)
+
+	ctx := context.Background()
+	repo := repository.NewPostgresRepository(db)
+	point := geo.Point{13.4, 52.5}
+	radius := 100000.0
+	pageSize := 100
+
+	for _, size := range []int{10000, 100000} {
+		if _, err := db.Exec(fmt.Sprintf("TRUNCATE TABLE %s, %s CASCADE", repository.LocationTable, repository.UserTable)); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := db.Exec(seedRandomLocationsQuery, size); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := db.Exec(fmt.Sprintf("ANALYZE %s, %s", repository.LocationTable, repository.UserTable)); err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("EarthIndex/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+					Point:    point,
+					Radius:   radius,
+					PageSize: pageSize,
+				}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("SeqScan/%d", size), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				rows, err := db.QueryContext(ctx, seqScanListUsersInRadiusQuery, geo.PostgresPoint(point), radius, 0, pageSize+1)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for rows.Next() {
+					// Rows are only fetched.
+				}
+				if err = rows.Err(); err != nil {
+					b.Fatal(err)
+				}
+				if err = rows.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -2,7 +2,9 @@ package repository_test
 
 import (
 	"context"
+	"fmt"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/util/testutil"
+	"math"
 	"testing"
 	"time"
 
@@ -196,6 +198,7 @@ func (s *PostgresTestSuite) Test_PostgresRepository_SetUserLocation() {
 		},
 	}
 	locations := s.seedLocations(setLocationArgs)
+	recordedAt := time.Now().UTC().Add(time.Minute).Truncate(time.Second)
 
 	testCases := []struct {
 		name   string
@@ -272,6 +275,38 @@ func (s *PostgresTestSuite) Test_PostgresRepository_SetUserLocation() {
 				require.Equal(t, 0.12345678, res.Location.Point.Longitude())
 			},
 		},
+		{
+			name: "OK_RecordedAt",
+			arg: port.UserRepositorySetUserLocationRequest{
+				Username:   users[1].Username,
+				Point:      geo.Point{2.0, 2.0},
+				RecordedAt: recordedAt,
+			},
+			hasErr: false,
+			isErr:  nil,
+			asErr:  nil,
+			assert: func(t *testing.T, res port.UserRepositorySetUserLocationResponse) {
+				require.False(t, res.OutOfOrder)
+				require.Equal(t, 2.0, res.Location.Point.Latitude())
+				require.WithinDuration(t, recordedAt, res.Location.RecordedAt, time.Millisecond)
+			},
+		},
+		{
+			name: "OK_OutOfOrder",
+			arg: port.UserRepositorySetUserLocationRequest{
+				Username:   users[1].Username,
+				Point:      geo.Point{3.0, 3.0},
+				RecordedAt: recordedAt.Add(-time.Hour),
+			},
+			hasErr: false,
+			isErr:  nil,
+			asErr:  nil,
+			assert: func(t *testing.T, res port.UserRepositorySetUserLocationResponse) {
+				require.True(t, res.OutOfOrder)
+				require.Equal(t, 2.0, res.Location.Point.Latitude())
+				require.WithinDuration(t, recordedAt, res.Location.RecordedAt, time.Millisecond)
+			},
+		},
 	}
 
 	repo := repository.NewPostgresRepository(s.db)
@@ -536,7 +571,12 @@ func (s PostgresTestSuite) Test_PostgresQueries_ListUsersInRadius() {
 		s.T().Run(tc.name, func(t *testing.T) {
 			res, err := repo.ListUsersInRadius(context.Background(), tc.in)
 
-			require.Equal(t, tc.expectedUsers, res.Users)
+			var resUsers []domain.User
+			for _, u := range res.Users {
+				require.LessOrEqual(t, u.Distance, tc.in.Radius)
+				resUsers = append(resUsers, u.User)
+			}
+			require.Equal(t, tc.expectedUsers, resUsers)
 			require.Equal(t, tc.expectedNextPageToken, res.NextPageToken)
 
 			if !tc.hasErr {
@@ -553,3 +593,604 @@ func (s PostgresTestSuite) Test_PostgresQueries_ListUsersInRadius() {
 		})
 	}
 }
+
+func (s PostgresTestSuite) Test_PostgresQueries_ListUsersInRadius_OrderByDistance() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+		{Username: "user3"},
+	})
+
+	locations := s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{0.0, 0.003}},
+		{UserID: users[1].ID, Point: geo.Point{0.0, 0.001}},
+		{UserID: users[2].ID, Point: geo.Point{0.0, 0.002}},
+		{UserID: users[3].ID, Point: geo.Point{0.0, 0.001}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	// The whole list.
+	res, err := repo.ListUsersInRadius(context.Background(), port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{0.0, 0.0},
+		Radius:   1000.0,
+		PageSize: 10,
+		OrderBy:  port.UsersOrderByDistance,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Users, 4)
+	require.Equal(s.T(), 0, res.NextPageToken)
+
+	expectedOrder := []int{1, 3, 2, 0}
+	for i, idx := range expectedOrder {
+		require.Equal(s.T(), users[idx], res.Users[i].User)
+		require.Equal(s.T(), locations[idx].Point, res.Users[i].Point)
+		require.Equal(s.T(), locations[idx].UpdatedAt, res.Users[i].LocationUpdatedAt)
+		require.InDelta(s.T(), locations[idx].Point.Latitude()*111195, res.Users[i].Distance, 1.0)
+	}
+
+	// Page by page.
+	var pageToken int
+	var pageTokenDistance float64
+	for i, idx := range expectedOrder {
+		res, err := repo.ListUsersInRadius(context.Background(), port.UserRepositoryListUsersInRadiusRequest{
+			Point:             geo.Point{0.0, 0.0},
+			Radius:            1000.0,
+			PageToken:         pageToken,
+			PageTokenDistance: pageTokenDistance,
+			PageSize:          1,
+			OrderBy:           port.UsersOrderByDistance,
+		})
+		require.NoError(s.T(), err)
+		require.Len(s.T(), res.Users, 1)
+		require.Equal(s.T(), users[idx], res.Users[0].User)
+
+		if i < len(expectedOrder)-1 {
+			require.Equal(s.T(), res.Users[0].ID, res.NextPageToken)
+			require.Equal(s.T(), res.Users[0].Distance, res.NextPageTokenDistance)
+		} else {
+			require.Equal(s.T(), 0, res.NextPageToken)
+		}
+
+		pageToken, pageTokenDistance = res.NextPageToken, res.NextPageTokenDistance
+	}
+}
+
+func (s PostgresTestSuite) Test_PostgresQueries_ListNearestUsers() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+		{Username: "user3"},
+	})
+
+	locations := s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{0.0, 0.003}},
+		{UserID: users[1].ID, Point: geo.Point{0.0, 0.001}},
+		{UserID: users[2].ID, Point: geo.Point{0.0, -0.002}},
+		{UserID: users[3].ID, Point: geo.Point{179.0, 0.0}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	// Nearest users go first.
+	res, err := repo.ListNearestUsers(context.Background(), port.UserRepositoryListNearestUsersRequest{
+		Point: geo.Point{0.0, 0.0},
+		Limit: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 4)
+
+	expectedOrder := []int{1, 2, 0, 3}
+	for i, idx := range expectedOrder {
+		require.Equal(s.T(), users[idx], res[i].User)
+		require.Equal(s.T(), locations[idx].Point, res[i].Point)
+		require.Equal(s.T(), locations[idx].UpdatedAt, res[i].LocationUpdatedAt)
+	}
+	require.InDelta(s.T(), 111.195, res[0].Distance, 1.0)
+
+	// Limit.
+	res, err = repo.ListNearestUsers(context.Background(), port.UserRepositoryListNearestUsersRequest{
+		Point: geo.Point{0.0, 0.0},
+		Limit: 2,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 2)
+	require.Equal(s.T(), users[1], res[0].User)
+	require.Equal(s.T(), users[2], res[1].User)
+
+	// Excluded username.
+	res, err = repo.ListNearestUsers(context.Background(), port.UserRepositoryListNearestUsersRequest{
+		Point:           geo.Point{0.0, 0.0},
+		Limit:           2,
+		ExcludeUsername: users[1].Username,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 2)
+	require.Equal(s.T(), users[2], res[0].User)
+	require.Equal(s.T(), users[0], res[1].User)
+
+	// Max distance.
+	res, err = repo.ListNearestUsers(context.Background(), port.UserRepositoryListNearestUsersRequest{
+		Point:       geo.Point{0.0, 0.0},
+		Limit:       10,
+		MaxDistance: 250,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 2)
+	require.Equal(s.T(), users[1], res[0].User)
+	require.Equal(s.T(), users[2], res[1].User)
+
+	// Nothing found.
+	res, err = repo.ListNearestUsers(context.Background(), port.UserRepositoryListNearestUsersRequest{
+		Point:       geo.Point{90.0, 45.0},
+		Limit:       10,
+		MaxDistance: 1000,
+	})
+	require.NoError(s.T(), err)
+	require.Empty(s.T(), res)
+}
+
+func (s PostgresTestSuite) Test_PostgresQueries_ListUsersInBBox() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+		{Username: "user3"},
+	})
+
+	s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{0.0, 0.0}},
+		{UserID: users[1].ID, Point: geo.Point{179.5, 1.0}},
+		{UserID: users[2].ID, Point: geo.Point{-179.5, -1.0}},
+		{UserID: users[3].ID, Point: geo.Point{5.0, 5.0}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	res, err := repo.ListUsersInBBox(context.Background(), port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{-1, -1, 1, 1},
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Users, 1)
+	require.Equal(s.T(), users[0], res.Users[0].User)
+	require.Equal(s.T(), 0, res.NextPageToken)
+
+	// Crosses antimeridian.
+	res, err = repo.ListUsersInBBox(context.Background(), port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{179, -2, -179, 2},
+		PageSize: 1,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Users, 1)
+	require.Equal(s.T(), users[1], res.Users[0].User)
+	require.Equal(s.T(), geo.Point{179.5, 1.0}, res.Users[0].Point)
+	require.Equal(s.T(), users[1].ID, res.NextPageToken)
+
+	res, err = repo.ListUsersInBBox(context.Background(), port.UserRepositoryListUsersInBBoxRequest{
+		BBox:      geo.BBox{179, -2, -179, 2},
+		PageToken: res.NextPageToken,
+		PageSize:  1,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Users, 1)
+	require.Equal(s.T(), users[2], res.Users[0].User)
+	require.Equal(s.T(), 0, res.NextPageToken)
+}
+
+func (s PostgresTestSuite) Test_PostgresQueries_ListUsersInPolygon() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+		{Username: "user2"},
+		{Username: "user3"},
+	})
+
+	s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{0.0, 0.0}},
+		{UserID: users[1].ID, Point: geo.Point{179.5, 1.0}},
+		{UserID: users[2].ID, Point: geo.Point{-179.5, 5.0}},
+		{UserID: users[3].ID, Point: geo.Point{-175.5, 5.0}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	// Crosses antimeridian and has a hole.
+	res, err := repo.ListUsersInPolygon(context.Background(), port.UserRepositoryListUsersInPolygonRequest{
+		Polygon: geo.Polygon{
+			{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}, {170, 0}},
+			{{-177, 4}, {-174, 4}, {-174, 6}, {-177, 6}, {-177, 4}},
+		},
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Users, 2)
+	require.Equal(s.T(), users[1], res.Users[0].User)
+	require.Equal(s.T(), users[2], res.Users[1].User)
+	require.Equal(s.T(), 0, res.NextPageToken)
+
+	res, err = repo.ListUsersInPolygon(context.Background(), port.UserRepositoryListUsersInPolygonRequest{
+		Polygon:  geo.Polygon{{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}, {-1, -1}}},
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res.Users, 1)
+	require.Equal(s.T(), users[0], res.Users[0].User)
+}
+
+func (s *PostgresTestSuite) Test_PostgresRepository_SetUserLocations() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	res, err := repo.SetUserLocations(context.Background(), []port.UserRepositorySetUserLocationRequest{
+		{Username: "user0", Point: geo.Point{0, 0}},
+		{Username: "user1", Point: geo.Point{1, 1}},
+		{Username: "user0", Point: geo.Point{2, 2}},
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), res, 3)
+
+	require.Equal(s.T(), users[0], res[0].User)
+	require.Zero(s.T(), res[0].PrevLocation.UserID)
+	require.Equal(s.T(), "user1", res[1].User.Username)
+	require.Equal(s.T(), users[0].ID, res[2].PrevLocation.UserID)
+	require.Equal(s.T(), geo.Point{0, 0}, res[2].PrevLocation.Point)
+	require.Equal(s.T(), geo.Point{2, 2}, res[2].Location.Point)
+
+	// Either all locations are set or none.
+	res, err = repo.SetUserLocations(context.Background(), []port.UserRepositorySetUserLocationRequest{
+		{Username: "user2", Point: geo.Point{3, 3}},
+		{Username: "user0", Point: geo.Point{0, 91}},
+	})
+	require.Error(s.T(), err)
+	require.Empty(s.T(), res)
+
+	_, err = repo.GetByUsername(context.Background(), "user2")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	location, err := repo.GetLocation(context.Background(), users[0].ID)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), geo.Point{2, 2}, location.Point)
+}
+
+func (s *PostgresTestSuite) Test_PostgresRepository_SetUserLocation_AutoCreationDisabled() {
+	repo := repository.NewPostgresRepository(s.db)
+
+	res, err := repo.SetUserLocation(context.Background(), port.UserRepositorySetUserLocationRequest{
+		Username:                "user1",
+		Point:                   geo.Point{1, 1},
+		DisableUserAutoCreation: true,
+	})
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+	require.Empty(s.T(), res)
+
+	_, err = repo.GetByUsername(context.Background(), "user1")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	users := s.seedUsers([]port.CreateUserArg{{Username: "user1"}})
+
+	res, err = repo.SetUserLocation(context.Background(), port.UserRepositorySetUserLocationRequest{
+		Username:                "user1",
+		Point:                   geo.Point{1, 1},
+		DisableUserAutoCreation: true,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[0].ID, res.Location.UserID)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_GetUserWithLocation() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user1"},
+		{Username: "user2"},
+	})
+	locations := s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{10, 20}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	res, err := repo.GetUserWithLocation(context.Background(), "user1")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[0].ID, res.User.ID)
+	require.NotNil(s.T(), res.Location)
+	require.Equal(s.T(), locations[0].Point, res.Location.Point)
+	require.WithinDuration(s.T(), locations[0].UpdatedAt, res.Location.UpdatedAt, time.Second)
+
+	res, err = repo.GetUserWithLocation(context.Background(), "user2")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users[1].ID, res.User.ID)
+	require.Nil(s.T(), res.Location)
+
+	res, err = repo.GetUserWithLocation(context.Background(), "user3")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+	require.Empty(s.T(), res)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_ListUsers() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "alice1"},
+		{Username: "bob1"},
+		{Username: "alice2"},
+		{Username: "alice3"},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	res, err := repo.ListUsers(context.Background(), port.UserRepositoryListUsersRequest{
+		Prefix:   "alice",
+		PageSize: 2,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.User{users[0], users[2]}, res.Users)
+	require.Equal(s.T(), users[2].ID, res.NextPageToken)
+
+	res, err = repo.ListUsers(context.Background(), port.UserRepositoryListUsersRequest{
+		Prefix:    "alice",
+		PageToken: res.NextPageToken,
+		PageSize:  2,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), []domain.User{users[3]}, res.Users)
+	require.Zero(s.T(), res.NextPageToken)
+
+	res, err = repo.ListUsers(context.Background(), port.UserRepositoryListUsersRequest{
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), users, res.Users)
+	require.Zero(s.T(), res.NextPageToken)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_RenameUser() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user1"},
+		{Username: "user2"},
+	})
+
+	testCases := []struct {
+		name   string
+		arg    port.UserRepositoryRenameUserRequest
+		isErr  error
+		assert func(t *testing.T, user domain.User)
+	}{
+		{
+			name: "OK",
+			arg:  port.UserRepositoryRenameUserRequest{Username: "user1", NewUsername: "user3"},
+			assert: func(t *testing.T, user domain.User) {
+				require.Equal(t, users[0].ID, user.ID)
+				require.Equal(t, "user3", user.Username)
+			},
+		},
+		{
+			name:  "NotFound",
+			arg:   port.UserRepositoryRenameUserRequest{Username: "user4", NewUsername: "user5"},
+			isErr: errpack.ErrNotFound,
+		},
+		{
+			name:  "ErrConstraint_UserAlreadyExists",
+			arg:   port.UserRepositoryRenameUserRequest{Username: "user2", NewUsername: "user3"},
+			isErr: errpack.ErrAlreadyExists,
+		},
+		{
+			name:  "ErrConstraint_InvalidUsername",
+			arg:   port.UserRepositoryRenameUserRequest{Username: "user2", NewUsername: "user2_"},
+			isErr: errpack.ErrInvalidArgument,
+		},
+	}
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			user, err := repo.RenameUser(context.Background(), tc.arg)
+			if tc.isErr != nil {
+				require.ErrorIs(t, err, tc.isErr)
+				require.Empty(t, user)
+				return
+			}
+			require.NoError(t, err)
+			tc.assert(t, user)
+		})
+	}
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_DeleteUser() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user1"},
+	})
+	s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{10, 20}},
+	})
+
+	repo := repository.NewPostgresRepository(s.db)
+
+	job, err := repo.DeleteUser(context.Background(), "user1")
+	require.NoError(s.T(), err)
+	require.NotZero(s.T(), job.ID)
+	require.Equal(s.T(), users[0].ID, job.UserID)
+	require.Equal(s.T(), "user1", job.Username)
+	require.Equal(s.T(), domain.ErasureJobPending, job.Status)
+	require.Zero(s.T(), job.Attempts)
+	require.Nil(s.T(), job.CompletedAt)
+
+	_, err = repo.GetByUsername(context.Background(), "user1")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	// The location is deleted along with the user.
+	_, err = repo.GetLocation(context.Background(), users[0].ID)
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+
+	_, err = repo.DeleteUser(context.Background(), "user1")
+	require.ErrorIs(s.T(), err, errpack.ErrNotFound)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_ListUsers_MaxAge() {
+	users := s.seedUsers([]port.CreateUserArg{
+		{Username: "user0"},
+		{Username: "user1"},
+	})
+	s.seedLocations([]port.LocationRepositorySetLocationRequest{
+		{UserID: users[0].ID, Point: geo.Point{0.0, 0.0}},
+		{UserID: users[1].ID, Point: geo.Point{0.001, 0.001}},
+	})
+	s.ageLocation(users[1].ID, 2*time.Hour)
+
+	repo := repository.NewPostgresRepository(s.db)
+	ctx := context.Background()
+
+	// Stale locations are found if age is not limited.
+	radius, err := repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{0, 0},
+		Radius:   1000,
+		PageSize: 10,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), radius.Users, 2)
+
+	radius, err = repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{0, 0},
+		Radius:   1000,
+		PageSize: 10,
+		MaxAge:   time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), radius.Users, 1)
+	require.Equal(s.T(), users[0].ID, radius.Users[0].ID)
+
+	radius, err = repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+		Point:    geo.Point{0, 0},
+		Radius:   1000,
+		PageSize: 10,
+		OrderBy:  port.UsersOrderByDistance,
+		MaxAge:   time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), radius.Users, 1)
+	require.Equal(s.T(), users[0].ID, radius.Users[0].ID)
+
+	nearest, err := repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+		Point:  geo.Point{0.001, 0.001},
+		Limit:  10,
+		MaxAge: time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), nearest, 1)
+	require.Equal(s.T(), users[0].ID, nearest[0].ID)
+
+	bbox, err := repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{-1, -1, 1, 1},
+		PageSize: 10,
+		MaxAge:   time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 1)
+	require.Equal(s.T(), users[0].ID, bbox.Users[0].ID)
+
+	polygon, err := repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+		Polygon:  geo.Polygon{{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}, {-1, -1}}},
+		PageSize: 10,
+		MaxAge:   time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), polygon.Users, 1)
+	require.Equal(s.T(), users[0].ID, polygon.Users[0].ID)
+
+	// Both locations are fresh enough.
+	bbox, err = repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+		BBox:     geo.BBox{-1, -1, 1, 1},
+		PageSize: 10,
+		MaxAge:   3 * time.Hour,
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), bbox.Users, 2)
+}
+
+func (s *PostgresTestSuite) Test_PostgresQueries_ListUsersInRadius_EarthBox() {
+	const radius = 10000.0
+	// degrees returns the arc in degrees of a great circle of the given length in meters.
+	degrees := func(meters float64) float64 {
+		return meters / geo.EarthRadius * 180 / math.Pi
+	}
+
+	testCases := []struct {
+		name    string
+		center  geo.Point
+		inside  geo.Point
+		outside geo.Point
+	}{
+		{
+			name:    "North",
+			center:  geo.Point{10, 20},
+			inside:  geo.Point{10, 20 + degrees(radius-1)},
+			outside: geo.Point{10, 20 + degrees(radius+1)},
+		},
+		{
+			name:    "EastAlongEquator",
+			center:  geo.Point{10, 0},
+			inside:  geo.Point{10 + degrees(radius-1), 0},
+			outside: geo.Point{10 + degrees(radius+1), 0},
+		},
+		{
+			name:    "CrossesAntimeridian",
+			center:  geo.Point{179.95, 0},
+			inside:  geo.Point{179.95 + degrees(radius-1) - 360, 0},
+			outside: geo.Point{179.95 + degrees(radius+1) - 360, 0},
+		},
+		{
+			name:    "CrossesPole",
+			center:  geo.Point{0, 89.95},
+			inside:  geo.Point{180, 180 - 89.95 - degrees(radius-1)},
+			outside: geo.Point{180, 180 - 89.95 - degrees(radius+1)},
+		},
+	}
+
+	repo := repository.NewPostgresRepository(s.db)
+	ctx := context.Background()
+
+	for i, tc := range testCases {
+		i, tc := i, tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			users := s.seedUsers([]port.CreateUserArg{
+				{Username: fmt.Sprintf("inside%d", i)},
+				{Username: fmt.Sprintf("outside%d", i)},
+			})
+			s.seedLocations([]port.LocationRepositorySetLocationRequest{
+				{UserID: users[0].ID, Point: tc.inside},
+				{UserID: users[1].ID, Point: tc.outside},
+			})
+
+			for _, orderBy := range []port.UsersOrder{port.UsersOrderByID, port.UsersOrderByDistance} {
+				res, err := repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+					Point:    tc.center,
+					Radius:   radius,
+					PageSize: 10,
+					OrderBy:  orderBy,
+				})
+				require.NoError(t, err)
+				require.Len(t, res.Users, 1)
+				require.Equal(t, users[0].ID, res.Users[0].ID)
+			}
+
+			// Earth cubes follow updated locations.
+			_, err := repo.SetUserLocation(ctx, port.UserRepositorySetUserLocationRequest{
+				Username: users[1].Username,
+				Point:    tc.center,
+			})
+			require.NoError(t, err)
+
+			res, err := repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+				Point:    tc.center,
+				Radius:   radius,
+				PageSize: 10,
+			})
+			require.NoError(t, err)
+			require.Len(t, res.Users, 2)
+		})
+	}
+}
@@ -14,13 +14,17 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/sony/gobreaker"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/in/handler"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/broker"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/historyclient"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/adapter/out/repository"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/config"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/middleware"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/ratelimit"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
 	pb "gitlab.com/spacewalker/geotracker/pkg/api/proto/v1/location"
 	"google.golang.org/grpc"
@@ -32,6 +36,9 @@ type App struct {
 	httpServer *util.HTTPServer
 	grpcServer *util.GRPCServer
 	logger     log.Logger
+
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
 }
 
 // NewApp creates and instance of location application and returns its pointer.
@@ -43,13 +50,9 @@ func NewApp(config config.LocationConfig) *App {
 
 // Start starts the application.
 func (a *App) Start() error {
-	dbSource := fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		a.config.DBHost, a.config.DBPort, a.config.DBUser, a.config.DBPassword, a.config.DBName, a.config.DBSSLMode,
-	)
-	db, err := util.OpenDB(a.config.DBDriver, dbSource)
+	repo, err := a.openRepository()
 	if err != nil {
-		return fmt.Errorf("failed to open db: %v", err)
+		return err
 	}
 
 	a.logger, err = log.NewZapLogger(a.config.AppEnv == "development")
@@ -81,28 +84,84 @@ func (a *App) Start() error {
 		},
 	})
 
-	repo := repository.NewPostgresRepository(db)
-	historyClient := historyclient.NewGRPCClient(a.config.HistoryAddr, a.logger)
+	historyClient := historyclient.NewGRPCClient(a.config.HistoryAddr, a.config.HistoryAPIKey, a.logger)
 	proxifiedHistoryClient := historyclient.NewProxy(historyClient, cb, re)
-	svc := service.NewUserService(repo, proxifiedHistoryClient, a.logger)
-	httpHandler := handler.NewHTTPHandler(svc, a.logger)
-	grpcHandler := handler.NewGRPCHandler(svc)
+	geofenceSvc := service.NewGeofenceService(repo, a.logger)
+	locationBroker := broker.NewMemoryBroker(a.logger)
+	svc := service.NewUserService(repo, proxifiedHistoryClient, geofenceSvc, locationBroker, service.UserServiceConfig{
+		OutOfOrderPolicy:        service.OutOfOrderPolicy(a.config.OutOfOrderPolicy),
+		MaxClockSkew:            a.config.MaxClockSkew,
+		DisableUserAutoCreation: a.config.DisableUserAutoCreation,
+		MaxLocationAge:          a.config.MaxLocationAge,
+		IdempotencyKeyTTL:       a.config.IdempotencyKeyTTL,
+		IdempotencyKeyLease:     a.config.IdempotencyKeyLease,
+	}, a.logger)
+	erasureSvc := service.NewErasureService(repo, proxifiedHistoryClient, a.logger)
+	socialSvc := service.NewSocialService(repo, a.logger)
+	httpHandler := handler.NewHTTPHandler(svc, geofenceSvc, erasureSvc, socialSvc, handler.HTTPHandlerConfig{
+		FeedHeartbeatInterval: a.config.FeedHeartbeatInterval,
+	}, a.logger)
+	grpcHandler := handler.NewGRPCHandler(svc, socialSvc)
 
 	rootHandler := chi.NewRouter()
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		middleware.TracingUnaryServerInterceptor(a.logger),
+		middleware.LoggerUnaryServerInterceptor(a.logger),
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		middleware.TracingStreamServerInterceptor(a.logger),
+		middleware.LoggerStreamServerInterceptor(a.logger),
+	}
+
+	if a.config.MaxInFlightRequests > 0 {
+		concurrencyLimiter := ratelimit.NewConcurrencyLimiter(a.config.MaxInFlightRequests)
+		// The live location feed is a long-lived event stream, so it is not counted.
+		rootHandler.Use(middleware.ConcurrencyLimitMiddleware(concurrencyLimiter, "/v1/users/feed"))
+		unaryInterceptors = append(unaryInterceptors, middleware.ConcurrencyLimitUnaryServerInterceptor(concurrencyLimiter))
+		streamInterceptors = append(streamInterceptors, middleware.ConcurrencyLimitStreamServerInterceptor(concurrencyLimiter))
+	}
+
+	if a.config.AuthEnabled {
+		authenticator, err := auth.New(a.config.Auth())
+		if err != nil {
+			return fmt.Errorf("failed to create authenticator: %v", err)
+		}
+
+		rootHandler.Use(middleware.AuthMiddleware(authenticator))
+		unaryInterceptors = append(unaryInterceptors, middleware.AuthUnaryServerInterceptor(authenticator))
+		streamInterceptors = append(streamInterceptors, middleware.AuthStreamServerInterceptor(authenticator))
+	}
+
+	if a.config.RateLimitEnabled {
+		limiter, err := ratelimit.New(a.config.RateLimit())
+		if err != nil {
+			return fmt.Errorf("failed to create rate limiter: %v", err)
+		}
+		ips, err := middleware.NewClientIPResolver(a.config.RateLimitTrustedProxies)
+		if err != nil {
+			return fmt.Errorf("failed to create client ip resolver: %v", err)
+		}
+
+		rootHandler.Use(middleware.RateLimitMiddleware(limiter, ips))
+		unaryInterceptors = append(unaryInterceptors, middleware.RateLimitUnaryServerInterceptor(limiter, ips))
+		streamInterceptors = append(streamInterceptors, middleware.RateLimitStreamServerInterceptor(limiter, ips))
+	}
+
 	rootHandler.Mount("/v1", httpHandler)
 
 	a.httpServer = util.NewHTTPServer(a.config.BindAddrHTTP, rootHandler)
 	a.grpcServer = util.NewGRPCServer(
 		a.config.BindAddrGRPC,
 		func(server *grpc.Server) {
+			pb.RegisterLocationServer(server, grpcHandler)
 			pb.RegisterLocationInternalServer(server, grpcHandler)
 		},
-		grpc.ChainUnaryInterceptor(
-			middleware.TracingUnaryServerInterceptor(a.logger),
-			middleware.LoggerUnaryServerInterceptor(a.logger),
-		),
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
 	)
 
+	a.startWorkers(svc, erasureSvc)
+
 	var httpErr, grpcErr error
 
 	var wg sync.WaitGroup
@@ -143,6 +202,25 @@ func (a *App) Start() error {
 	return nil
 }
 
+// openRepository returns the repository selected by `DBDriver`.
+// Data of the in-memory repository is lost when the application stops.
+func (a *App) openRepository() (port.Repository, error) {
+	if a.config.DBDriver == repository.DriverMemory {
+		return repository.NewMemoryRepository(), nil
+	}
+
+	dbSource := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		a.config.DBHost, a.config.DBPort, a.config.DBUser, a.config.DBPassword, a.config.DBName, a.config.DBSSLMode,
+	)
+	db, err := util.OpenDB(a.config.DBDriver, dbSource)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open db: %v", err)
+	}
+
+	return repository.NewPostgresRepository(db), nil
+}
+
 // Stop stops the application.
 func (a *App) Stop(ctx context.Context) error {
 	var wg sync.WaitGroup
@@ -166,5 +244,49 @@ func (a *App) Stop(ctx context.Context) error {
 
 	wg.Wait()
 
+	a.stopWorkers()
+	a.workers.Wait()
+
 	return nil
 }
+
+// startWorkers starts background jobs of the application, they run until the application is stopped.
+//
+// Pending erasure jobs are processed every `ErasureJobInterval`,
+// expired idempotency keys are deleted every `IdempotencyKeyCleanupInterval`.
+func (a *App) startWorkers(userSvc port.UserService, erasureSvc port.ErasureService) {
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopWorkers = cancel
+
+	a.startWorker(ctx, a.config.ErasureJobInterval, func(ctx context.Context) {
+		if err := erasureSvc.ProcessErasureJobs(ctx); err != nil {
+			a.logger.Error(fmt.Sprintf("failed to process erasure jobs: %v", err), nil)
+		}
+	})
+	a.startWorker(ctx, a.config.IdempotencyKeyCleanupInterval, func(ctx context.Context) {
+		if err := userSvc.DeleteExpiredIdempotencyKeys(ctx); err != nil {
+			a.logger.Error(fmt.Sprintf("failed to delete expired idempotency keys: %v", err), nil)
+		}
+	})
+}
+
+// startWorker calls fn every interval until ctx is done.
+func (a *App) startWorker(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	a.workers.Add(1)
+
+	go func() {
+		defer a.workers.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	}()
+}
@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// ErasureJobStatus is a status of an erasure job.
+type ErasureJobStatus string
+
+const (
+	// ErasureJobPending is a status of a job whose user data is not erased from other services yet.
+	ErasureJobPending ErasureJobStatus = "pending"
+	// ErasureJobCompleted is a status of a job whose user data is erased from all the services.
+	ErasureJobCompleted ErasureJobStatus = "completed"
+)
+
+// ErasureJob tracks erasure of data of a deleted user from other services.
+//
+// `Attempts` is a number of attempts made to erase the data,
+// `LastError` is an error of the last attempt if it failed.
+// `CompletedAt` is only set for completed jobs.
+type ErasureJob struct {
+	ID          int              `json:"id"`
+	UserID      int              `json:"user_id"`
+	Username    string           `json:"username"`
+	Status      ErasureJobStatus `json:"status"`
+	Attempts    int              `json:"attempts"`
+	LastError   string           `json:"last_error,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+}
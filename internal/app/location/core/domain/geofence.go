@@ -0,0 +1,65 @@
+package domain
+
+import (
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// GeofenceType is a shape of a geofence.
+type GeofenceType string
+
+const (
+	// GeofenceTypeCircle is a geofence defined by a center and a radius in meters.
+	GeofenceTypeCircle GeofenceType = "circle"
+	// GeofenceTypePolygon is a geofence defined by a polygon.
+	GeofenceTypePolygon GeofenceType = "polygon"
+)
+
+// Geofence represents a named geographic area which users can enter and exit.
+//
+// `Center` and `Radius` are only set for circle geofences, `Polygon` is only set for polygon geofences.
+// `DwellTime` is a time in seconds a user has to stay inside the geofence to trigger a DWELL event.
+// DWELL events are not triggered if it equals 0.
+type Geofence struct {
+	ID        int          `json:"id"`
+	Name      string       `json:"name"`
+	Type      GeofenceType `json:"type"`
+	Center    *geo.Point   `json:"center,omitempty"`
+	Radius    float64      `json:"radius,omitempty"`
+	Polygon   geo.Polygon  `json:"polygon,omitempty"`
+	DwellTime int          `json:"dwell_time"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+// GeofenceEventType is a type of a geofence event.
+type GeofenceEventType string
+
+const (
+	// GeofenceEventEnter is emitted when a user enters a geofence.
+	GeofenceEventEnter GeofenceEventType = "ENTER"
+	// GeofenceEventExit is emitted when a user exits a geofence.
+	GeofenceEventExit GeofenceEventType = "EXIT"
+	// GeofenceEventDwell is emitted once when a user stays inside a geofence for its dwell time.
+	GeofenceEventDwell GeofenceEventType = "DWELL"
+)
+
+// GeofenceEvent represents a user crossing or dwelling in a geofence.
+type GeofenceEvent struct {
+	ID         int               `json:"id"`
+	GeofenceID int               `json:"geofence_id"`
+	UserID     int               `json:"user_id"`
+	Type       GeofenceEventType `json:"type"`
+	Point      geo.Point         `json:"point"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// GeofencePresence describes the last time a user entered a geofence.
+type GeofencePresence struct {
+	GeofenceID int
+	UserID     int
+	EnteredAt  time.Time
+	// Dwelled is true if a DWELL event has been already emitted since the user entered the geofence.
+	Dwelled bool
+}
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// IdempotencyKey remembers a write request of a user sent with an idempotency key, so that retries of the request
+// get the original response instead of executing it again.
+//
+// `RequestHash` identifies the request the key was first sent with.
+// `Response` is the encoded response of the request, it is nil while the request is in progress.
+// The key may be reused after `ExpiresAt`.
+type IdempotencyKey struct {
+	Username    string
+	Key         string
+	RequestHash string
+	Response    []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// LocatedUser represents a user found in some area along with the user's location.
+//
+// `Point` is the location as privacy settings of the user show it, so users are searched by such points.
+// `Geohash` is the geohash of the location, it is filled by user service.
+type LocatedUser struct {
+	User
+	Point             geo.Point `json:"point"`
+	Geohash           string    `json:"geohash,omitempty"`
+	LocationUpdatedAt time.Time `json:"location_updated_at"`
+}
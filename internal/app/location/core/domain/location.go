@@ -7,9 +7,14 @@ import (
 )
 
 // Location represents user's geographic position.
+//
+// `RecordedAt` is the time the position was recorded at by the client.
+// The embedded `FixMetadata` is optional metadata the position was reported with.
 type Location struct {
-	UserID    int       `json:"user_id"`
-	Point     geo.Point `json:"point"`
+	UserID     int       `json:"user_id"`
+	Point      geo.Point `json:"point"`
+	RecordedAt time.Time `json:"recorded_at"`
+	geo.FixMetadata
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// NearbyUser represents a user found around some point along with the user's location.
+//
+// `Point` is the location as privacy settings of the user show it, so users are searched by such points.
+// `Geohash` is the geohash of the location, it is filled by user service.
+type NearbyUser struct {
+	User
+	Point             geo.Point `json:"point"`
+	Geohash           string    `json:"geohash,omitempty"`
+	Distance          float64   `json:"distance"`
+	LocationUpdatedAt time.Time `json:"location_updated_at"`
+}
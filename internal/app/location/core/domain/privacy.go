@@ -0,0 +1,48 @@
+package domain
+
+import "gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+
+// PrivacyLevel defines how the location of a user is shown to others.
+type PrivacyLevel string
+
+const (
+	// PrivacyLevelExact shows the location as it is. It is the default level.
+	PrivacyLevelExact PrivacyLevel = "exact"
+	// PrivacyLevelCoarse shows the location snapped to the center of a grid or geohash cell.
+	PrivacyLevelCoarse PrivacyLevel = "coarse"
+	// PrivacyLevelHidden does not show the location at all, the user is not found by location searches.
+	PrivacyLevelHidden PrivacyLevel = "hidden"
+)
+
+// PrivacySettings defines how the location of a user is shown to others.
+//
+// Coarse locations are snapped either to a grid of `GridSize` degrees or to geohash cells
+// of `GeohashPrecision` characters, so exactly one of them is set for the coarse level
+// and neither is set for other levels.
+type PrivacySettings struct {
+	UserID           int          `json:"user_id"`
+	Level            PrivacyLevel `json:"level"`
+	GridSize         float64      `json:"grid_size,omitempty"`
+	GeohashPrecision int          `json:"geohash_precision,omitempty"`
+}
+
+// VisiblePoint returns the point a location at p is shown at according to the settings
+// and false if the location is hidden. Nil settings show the exact point,
+// coarse settings snap it to the center of its grid or geohash cell.
+func (s *PrivacySettings) VisiblePoint(p geo.Point) (geo.Point, bool) {
+	if s == nil {
+		return p, true
+	}
+
+	switch s.Level {
+	case PrivacyLevelHidden:
+		return geo.Point{}, false
+	case PrivacyLevelCoarse:
+		if s.GeohashPrecision > 0 {
+			return geo.Trunc(geo.SnapToGeohash(p, s.GeohashPrecision)), true
+		}
+		return geo.Trunc(geo.SnapToGrid(p, s.GridSize)), true
+	default:
+		return p, true
+	}
+}
@@ -0,0 +1,22 @@
+package domain
+
+// RadiusEventType is a type of an event of a radius subscription.
+type RadiusEventType string
+
+const (
+	// RadiusEventEnter is emitted when a user appears inside the circle.
+	// Users found inside the circle when the subscription starts are sent as ENTER events as well.
+	RadiusEventEnter RadiusEventType = "ENTER"
+	// RadiusEventMove is emitted when a user moves inside the circle.
+	RadiusEventMove RadiusEventType = "MOVE"
+	// RadiusEventExit is emitted when a user leaves the circle.
+	RadiusEventExit RadiusEventType = "EXIT"
+)
+
+// RadiusEvent represents a change of the set of users inside a circle.
+//
+// `User` contains the location the event is caused by, so for EXIT events it is outside the circle.
+type RadiusEvent struct {
+	Type RadiusEventType `json:"type"`
+	User NearbyUser      `json:"user"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+import "time"
+
+// FollowStatus is a status of a follow request.
+type FollowStatus string
+
+const (
+	// FollowPending is a status of a follow request the followee has not accepted yet.
+	FollowPending FollowStatus = "pending"
+	// FollowAccepted is a status of a follow request accepted by the followee.
+	FollowAccepted FollowStatus = "accepted"
+)
+
+// Follow is a request of the follower to see the location of the followee.
+//
+// The followee is a connection of the follower once the request is accepted.
+type Follow struct {
+	FollowerID int          `json:"follower_id"`
+	Follower   string       `json:"follower"`
+	FolloweeID int          `json:"followee_id"`
+	Followee   string       `json:"followee"`
+	Status     FollowStatus `json:"status"`
+	CreatedAt  time.Time    `json:"created_at"`
+	UpdatedAt  time.Time    `json:"updated_at"`
+}
+
+// Block prevents follow requests between the blocker and the blocked user in both directions.
+type Block struct {
+	BlockerID int       `json:"blocker_id"`
+	Blocker   string    `json:"blocker"`
+	BlockedID int       `json:"blocked_id"`
+	Blocked   string    `json:"blocked"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,26 @@
+//go:generate mockgen -destination=mock/mock_broker.go -package=mock . LocationBroker,LocationSubscription
+
+package port
+
+import (
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+)
+
+// LocationSubscription is a subscription to location updates.
+type LocationSubscription interface {
+	// Updates returns a channel updates are delivered to. It is closed when the subscription ends.
+	Updates() <-chan domain.LocatedUser
+	// Err returns `ErrResourceExhausted` if the subscription is ended because
+	// the subscriber fell behind the updates, the error filtering of updates failed with, nil otherwise.
+	Err() error
+	// Close ends the subscription. It is safe to call it more than once.
+	Close()
+}
+
+// LocationBroker fans out location updates to subscribers.
+type LocationBroker interface {
+	// Publish delivers the update to every subscriber, it never blocks.
+	Publish(update domain.LocatedUser)
+	// Subscribe starts a subscription whose updates are buffered up to `buffer` items.
+	Subscribe(buffer int) LocationSubscription
+}
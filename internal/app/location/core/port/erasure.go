@@ -0,0 +1,60 @@
+//go:generate mockgen -destination=mock/mock_erasure.go -package=mock . ErasureRepository,ErasureService
+
+package port
+
+import (
+	"context"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+)
+
+// ErasureServiceListErasureJobsRequest is a param object of erasure service ListErasureJobs method.
+//
+// Jobs are not filtered by status if `Status` is empty.
+type ErasureServiceListErasureJobsRequest struct {
+	Status    domain.ErasureJobStatus `json:"status" validate:"omitempty,oneof=pending completed"`
+	PageToken string                  `json:"page_token" validate:"required_without=PageSize"`
+	PageSize  int                     `json:"page_size" validate:"required_without=PageToken"`
+}
+
+// ErasureServiceListErasureJobsResponse represents response from erasure service ListErasureJobs method.
+type ErasureServiceListErasureJobsResponse struct {
+	Jobs          []domain.ErasureJob `json:"jobs"`
+	NextPageToken string              `json:"next_page_token"`
+}
+
+// ErasureService represents erasure service.
+type ErasureService interface {
+	GetErasureJob(ctx context.Context, id int) (domain.ErasureJob, error)
+	ListErasureJobs(ctx context.Context, req ErasureServiceListErasureJobsRequest) (ErasureServiceListErasureJobsResponse, error)
+	ProcessErasureJobs(ctx context.Context) error
+}
+
+// ErasureRepositoryListErasureJobsRequest is a param object of erasure repository ListErasureJobs method.
+type ErasureRepositoryListErasureJobsRequest struct {
+	Status    domain.ErasureJobStatus
+	PageToken int
+	PageSize  int
+}
+
+// ErasureRepositoryListErasureJobsResponse represents response from erasure repository ListErasureJobs method.
+type ErasureRepositoryListErasureJobsResponse struct {
+	Jobs          []domain.ErasureJob
+	NextPageToken int
+}
+
+// ErasureRepositoryUpdateErasureJobRequest is a param object of erasure repository UpdateErasureJob method.
+//
+// Every update is counted as an attempt. `LastError` is empty if the attempt succeeded.
+type ErasureRepositoryUpdateErasureJobRequest struct {
+	ID        int
+	Status    domain.ErasureJobStatus
+	LastError string
+}
+
+// ErasureRepository represents erasure repository.
+type ErasureRepository interface {
+	GetErasureJob(ctx context.Context, id int) (domain.ErasureJob, error)
+	ListErasureJobs(ctx context.Context, arg ErasureRepositoryListErasureJobsRequest) (ErasureRepositoryListErasureJobsResponse, error)
+	UpdateErasureJob(ctx context.Context, arg ErasureRepositoryUpdateErasureJobRequest) (domain.ErasureJob, error)
+}
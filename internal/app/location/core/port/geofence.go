@@ -0,0 +1,145 @@
+//go:generate mockgen -destination=mock/mock_geofence.go -package=mock . GeofenceRepository,GeofenceService
+
+package port
+
+import (
+	"context"
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// GeofenceServiceCreateGeofenceRequest is a param object of geofence service CreateGeofence method.
+//
+// `Center` and `Radius` must be set for circle geofences only, `Polygon` must be set for polygon geofences only.
+type GeofenceServiceCreateGeofenceRequest struct {
+	Name      string              `json:"name" validate:"required,max=64"`
+	Type      domain.GeofenceType `json:"type" validate:"oneof=circle polygon"`
+	Center    *geo.Point          `json:"center"`
+	Radius    float64             `json:"radius" validate:"gte=0"`
+	Polygon   geo.Polygon         `json:"polygon"`
+	DwellTime int                 `json:"dwell_time" validate:"gte=0"`
+}
+
+// GeofenceServiceUpdateGeofenceRequest is a param object of geofence service UpdateGeofence method.
+//
+// All fields of the geofence are replaced.
+type GeofenceServiceUpdateGeofenceRequest struct {
+	ID        int                 `json:"id" validate:"gt=0"`
+	Name      string              `json:"name" validate:"required,max=64"`
+	Type      domain.GeofenceType `json:"type" validate:"oneof=circle polygon"`
+	Center    *geo.Point          `json:"center"`
+	Radius    float64             `json:"radius" validate:"gte=0"`
+	Polygon   geo.Polygon         `json:"polygon"`
+	DwellTime int                 `json:"dwell_time" validate:"gte=0"`
+}
+
+// GeofenceServiceListGeofencesRequest is a param object of geofence service ListGeofences method.
+type GeofenceServiceListGeofencesRequest struct {
+	PageToken string `json:"page_token" validate:"required_without=PageSize"`
+	PageSize  int    `json:"page_size" validate:"required_without=PageToken"`
+}
+
+// GeofenceServiceListGeofencesResponse represents response from geofence service ListGeofences method.
+type GeofenceServiceListGeofencesResponse struct {
+	Geofences     []domain.Geofence `json:"geofences"`
+	NextPageToken string            `json:"next_page_token"`
+}
+
+// GeofenceServiceListGeofenceEventsRequest is a param object of geofence service ListGeofenceEvents method.
+//
+// Events are not filtered by geofence or user if `GeofenceID` or `UserID` equals 0 respectively.
+type GeofenceServiceListGeofenceEventsRequest struct {
+	GeofenceID int    `json:"geofence_id" validate:"gte=0"`
+	UserID     int    `json:"user_id" validate:"gte=0"`
+	PageToken  string `json:"page_token" validate:"required_without=PageSize"`
+	PageSize   int    `json:"page_size" validate:"required_without=PageToken"`
+}
+
+// GeofenceServiceListGeofenceEventsResponse represents response from geofence service ListGeofenceEvents method.
+type GeofenceServiceListGeofenceEventsResponse struct {
+	Events        []domain.GeofenceEvent `json:"events"`
+	NextPageToken string                 `json:"next_page_token"`
+}
+
+// GeofenceServiceEvaluateLocationRequest is a param object of geofence service EvaluateLocation method.
+//
+// `PrevPoint` equals nil if the user had no location before.
+type GeofenceServiceEvaluateLocationRequest struct {
+	UserID    int
+	PrevPoint *geo.Point
+	Point     geo.Point
+	Timestamp time.Time
+}
+
+// GeofenceService represents geofence service.
+type GeofenceService interface {
+	CreateGeofence(ctx context.Context, req GeofenceServiceCreateGeofenceRequest) (domain.Geofence, error)
+	GetGeofence(ctx context.Context, id int) (domain.Geofence, error)
+	UpdateGeofence(ctx context.Context, req GeofenceServiceUpdateGeofenceRequest) (domain.Geofence, error)
+	DeleteGeofence(ctx context.Context, id int) error
+	ListGeofences(ctx context.Context, req GeofenceServiceListGeofencesRequest) (GeofenceServiceListGeofencesResponse, error)
+	ListGeofenceEvents(ctx context.Context, req GeofenceServiceListGeofenceEventsRequest) (GeofenceServiceListGeofenceEventsResponse, error)
+	EvaluateLocation(ctx context.Context, req GeofenceServiceEvaluateLocationRequest) ([]domain.GeofenceEvent, error)
+}
+
+// GeofenceRepositoryCreateGeofenceRequest is a param object of geofence repository CreateGeofence method.
+type GeofenceRepositoryCreateGeofenceRequest struct {
+	Name      string
+	Type      domain.GeofenceType
+	Center    *geo.Point
+	Radius    float64
+	Polygon   geo.Polygon
+	DwellTime int
+}
+
+// GeofenceRepositoryUpdateGeofenceRequest is a param object of geofence repository UpdateGeofence method.
+type GeofenceRepositoryUpdateGeofenceRequest struct {
+	ID        int
+	Name      string
+	Type      domain.GeofenceType
+	Center    *geo.Point
+	Radius    float64
+	Polygon   geo.Polygon
+	DwellTime int
+}
+
+// GeofenceRepositoryListGeofencesRequest is a param object of geofence repository ListGeofences method.
+type GeofenceRepositoryListGeofencesRequest struct {
+	PageToken int
+	PageSize  int
+}
+
+// GeofenceRepositoryListGeofencesResponse represents response from geofence repository ListGeofences method.
+type GeofenceRepositoryListGeofencesResponse struct {
+	Geofences     []domain.Geofence
+	NextPageToken int
+}
+
+// GeofenceRepositoryListGeofenceEventsRequest is a param object of geofence repository ListGeofenceEvents method.
+type GeofenceRepositoryListGeofenceEventsRequest struct {
+	GeofenceID int
+	UserID     int
+	PageToken  int
+	PageSize   int
+}
+
+// GeofenceRepositoryListGeofenceEventsResponse represents response from geofence repository ListGeofenceEvents method.
+type GeofenceRepositoryListGeofenceEventsResponse struct {
+	Events        []domain.GeofenceEvent
+	NextPageToken int
+}
+
+// GeofenceRepository represents geofence repository.
+type GeofenceRepository interface {
+	CreateGeofence(ctx context.Context, arg GeofenceRepositoryCreateGeofenceRequest) (domain.Geofence, error)
+	GetGeofence(ctx context.Context, id int) (domain.Geofence, error)
+	UpdateGeofence(ctx context.Context, arg GeofenceRepositoryUpdateGeofenceRequest) (domain.Geofence, error)
+	DeleteGeofence(ctx context.Context, id int) error
+	ListGeofences(ctx context.Context, arg GeofenceRepositoryListGeofencesRequest) (GeofenceRepositoryListGeofencesResponse, error)
+	ListGeofencesContaining(ctx context.Context, point geo.Point) ([]domain.Geofence, error)
+	ListGeofencePresences(ctx context.Context, userID int, geofenceIDs []int) ([]domain.GeofencePresence, error)
+	CreateGeofenceEvents(ctx context.Context, events []domain.GeofenceEvent) ([]domain.GeofenceEvent, error)
+	ListGeofenceEvents(ctx context.Context, arg GeofenceRepositoryListGeofenceEventsRequest) (GeofenceRepositoryListGeofenceEventsResponse, error)
+}
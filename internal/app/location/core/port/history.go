@@ -13,6 +13,7 @@ type HistoryClientAddRecordRequest struct {
 	A         geo.Point `json:"a"`
 	B         geo.Point `json:"b"`
 	Timestamp time.Time `json:"timestamp"`
+	geo.FixMetadata
 }
 
 type HistoryClientAddRecordResponse struct {
@@ -20,8 +21,21 @@ type HistoryClientAddRecordResponse struct {
 	A         geo.Point `json:"a"`
 	B         geo.Point `json:"b"`
 	Timestamp time.Time `json:"timestamp"`
+	geo.FixMetadata
+}
+
+// HistoryClientAddRecordsRequest is a param object of history client AddRecords method.
+type HistoryClientAddRecordsRequest struct {
+	Records []HistoryClientAddRecordRequest `json:"records"`
+}
+
+// HistoryClientAddRecordsResponse represents response from history client AddRecords method.
+type HistoryClientAddRecordsResponse struct {
+	Records []HistoryClientAddRecordResponse `json:"records"`
 }
 
 type HistoryClient interface {
 	AddRecord(ctx context.Context, req HistoryClientAddRecordRequest) (HistoryClientAddRecordResponse, error)
+	AddRecords(ctx context.Context, req HistoryClientAddRecordsRequest) (HistoryClientAddRecordsResponse, error)
+	DeleteUserRecords(ctx context.Context, userID int) (int, error)
 }
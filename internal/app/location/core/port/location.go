@@ -2,6 +2,7 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
@@ -24,9 +25,13 @@ type LocationService interface {
 }
 
 // LocationRepositorySetLocationRequest is a param object of location repository SetLocation method.
+//
+// Zero `RecordedAt` means the current time.
 type LocationRepositorySetLocationRequest struct {
-	UserID int       `json:"user_id"`
-	Point  geo.Point `json:"point"`
+	UserID     int       `json:"user_id"`
+	Point      geo.Point `json:"point"`
+	RecordedAt time.Time `json:"recorded_at"`
+	geo.FixMetadata
 }
 
 // LocationRepositoryUpdateLocationByUserIDRequest is a param object of location repository UpdateLocationByUserID method.
@@ -4,4 +4,7 @@ package port
 type Repository interface {
 	UserRepository
 	LocationRepository
+	GeofenceRepository
+	ErasureRepository
+	SocialRepository
 }
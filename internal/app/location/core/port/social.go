@@ -0,0 +1,135 @@
+//go:generate mockgen -destination=mock/mock_social.go -package=mock . SocialRepository,SocialService
+
+package port
+
+import (
+	"context"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+)
+
+// SocialServiceFollowUserRequest is a param object of social service FollowUser method.
+//
+// The user with `Username` requests to follow the user with `Followee`.
+type SocialServiceFollowUserRequest struct {
+	Username string `json:"username" validate:"required,validusername"`
+	Followee string `json:"followee" validate:"required,validusername,nefield=Username"`
+}
+
+// SocialServiceAcceptFollowerRequest is a param object of social service AcceptFollower method.
+//
+// The user with `Username` accepts the follow request of the user with `Follower`.
+type SocialServiceAcceptFollowerRequest struct {
+	Username string `json:"username" validate:"required,validusername"`
+	Follower string `json:"follower" validate:"required,validusername"`
+}
+
+// SocialServiceDeleteFollowRequest is a param object of social service DeleteFollow method.
+type SocialServiceDeleteFollowRequest struct {
+	Follower string `json:"follower" validate:"required,validusername"`
+	Followee string `json:"followee" validate:"required,validusername"`
+}
+
+// SocialServiceBlockUserRequest is a param object of social service BlockUser and UnblockUser methods.
+//
+// The user with `Username` blocks or unblocks the user with `Blocked`.
+type SocialServiceBlockUserRequest struct {
+	Username string `json:"username" validate:"required,validusername"`
+	Blocked  string `json:"blocked" validate:"required,validusername,nefield=Username"`
+}
+
+// SocialServiceListFollowsRequest is a param object of social service ListFollowing and ListFollowers methods.
+//
+// Follows are not filtered by status if `Status` is empty.
+type SocialServiceListFollowsRequest struct {
+	Username  string              `json:"username" validate:"required,validusername"`
+	Status    domain.FollowStatus `json:"status" validate:"omitempty,oneof=pending accepted"`
+	PageToken string              `json:"page_token" validate:"required_without=PageSize"`
+	PageSize  int                 `json:"page_size" validate:"required_without=PageToken"`
+}
+
+// SocialServiceListFollowsResponse represents response from social service ListFollowing and ListFollowers methods.
+type SocialServiceListFollowsResponse struct {
+	Follows       []domain.Follow `json:"follows"`
+	NextPageToken string          `json:"next_page_token"`
+}
+
+// SocialServiceListBlocksRequest is a param object of social service ListBlocks method.
+type SocialServiceListBlocksRequest struct {
+	Username  string `json:"username" validate:"required,validusername"`
+	PageToken string `json:"page_token" validate:"required_without=PageSize"`
+	PageSize  int    `json:"page_size" validate:"required_without=PageToken"`
+}
+
+// SocialServiceListBlocksResponse represents response from social service ListBlocks method.
+type SocialServiceListBlocksResponse struct {
+	Blocks        []domain.Block `json:"blocks"`
+	NextPageToken string         `json:"next_page_token"`
+}
+
+// SocialService represents social service.
+type SocialService interface {
+	FollowUser(ctx context.Context, req SocialServiceFollowUserRequest) (domain.Follow, error)
+	AcceptFollower(ctx context.Context, req SocialServiceAcceptFollowerRequest) (domain.Follow, error)
+	DeleteFollow(ctx context.Context, req SocialServiceDeleteFollowRequest) error
+	BlockUser(ctx context.Context, req SocialServiceBlockUserRequest) (domain.Block, error)
+	UnblockUser(ctx context.Context, req SocialServiceBlockUserRequest) error
+	ListFollowing(ctx context.Context, req SocialServiceListFollowsRequest) (SocialServiceListFollowsResponse, error)
+	ListFollowers(ctx context.Context, req SocialServiceListFollowsRequest) (SocialServiceListFollowsResponse, error)
+	ListBlocks(ctx context.Context, req SocialServiceListBlocksRequest) (SocialServiceListBlocksResponse, error)
+}
+
+// SocialRepositoryFollowRequest is a param object of social repository CreateFollow, AcceptFollow and DeleteFollow methods.
+type SocialRepositoryFollowRequest struct {
+	Follower string
+	Followee string
+}
+
+// SocialRepositoryBlockRequest is a param object of social repository CreateBlock and DeleteBlock methods.
+type SocialRepositoryBlockRequest struct {
+	Blocker string
+	Blocked string
+}
+
+// SocialRepositoryListFollowsRequest is a param object of social repository ListFollowing and ListFollowers methods.
+//
+// `PageToken` is ID of the other user of the last follow of the previous page.
+type SocialRepositoryListFollowsRequest struct {
+	Username  string
+	Status    domain.FollowStatus
+	PageToken int
+	PageSize  int
+}
+
+// SocialRepositoryListFollowsResponse represents response from social repository ListFollowing and ListFollowers methods.
+type SocialRepositoryListFollowsResponse struct {
+	Follows       []domain.Follow
+	NextPageToken int
+}
+
+// SocialRepositoryListBlocksRequest is a param object of social repository ListBlocks method.
+//
+// `PageToken` is ID of the blocked user of the last block of the previous page.
+type SocialRepositoryListBlocksRequest struct {
+	Username  string
+	PageToken int
+	PageSize  int
+}
+
+// SocialRepositoryListBlocksResponse represents response from social repository ListBlocks method.
+type SocialRepositoryListBlocksResponse struct {
+	Blocks        []domain.Block
+	NextPageToken int
+}
+
+// SocialRepository represents social repository.
+type SocialRepository interface {
+	CreateFollow(ctx context.Context, arg SocialRepositoryFollowRequest) (domain.Follow, error)
+	AcceptFollow(ctx context.Context, arg SocialRepositoryFollowRequest) (domain.Follow, error)
+	DeleteFollow(ctx context.Context, arg SocialRepositoryFollowRequest) error
+	CreateBlock(ctx context.Context, arg SocialRepositoryBlockRequest) (domain.Block, error)
+	DeleteBlock(ctx context.Context, arg SocialRepositoryBlockRequest) error
+	ListFollowing(ctx context.Context, arg SocialRepositoryListFollowsRequest) (SocialRepositoryListFollowsResponse, error)
+	ListFollowers(ctx context.Context, arg SocialRepositoryListFollowsRequest) (SocialRepositoryListFollowsResponse, error)
+	ListBlocks(ctx context.Context, arg SocialRepositoryListBlocksRequest) (SocialRepositoryListBlocksResponse, error)
+}
@@ -4,43 +4,276 @@ package port
 
 import (
 	"context"
+	"time"
 
 	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
 )
 
 // UserServiceSetUserLocationRequest is a param object of user service SetUserLocation method.
+//
+// The location is given either by `Latitude` and `Longitude` or by `Geohash`,
+// the center of the geohash cell is used in the latter case.
+// `RecordedAt` is the time the location was recorded at by the client, nil means the current time.
+// The embedded `FixMetadata` is optional.
+// `IdempotencyKey` is an optional client generated key, retries of the request with the same key
+// get the original response instead of setting the location again.
 type UserServiceSetUserLocationRequest struct {
-	Username  string  `json:"username" validate:"required,validusername"`
-	Latitude  float64 `json:"latitude" validate:"validlatitude"`
-	Longitude float64 `json:"longitude" validate:"validlongitude"`
+	Username   string     `json:"username" validate:"required,validusername"`
+	Latitude   float64    `json:"latitude" validate:"excluded_with=Geohash,validlatitude"`
+	Longitude  float64    `json:"longitude" validate:"excluded_with=Geohash,validlongitude"`
+	Geohash    string     `json:"geohash" validate:"omitempty,validgeohash"`
+	RecordedAt *time.Time `json:"recorded_at"`
+	geo.FixMetadata
+	IdempotencyKey string `json:"-" validate:"max=255"`
 }
 
 // UserServiceSetUserLocationResponse represents response from user service SetUserLocation method.
+//
+// It contains the stored location, which differs from the requested one
+// if an out-of-order location is ignored.
 type UserServiceSetUserLocationResponse struct {
-	Latitude  float64 `json:"latitude"`
-	Longitude float64 `json:"longitude"`
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Geohash    string    `json:"geohash"`
+	RecordedAt time.Time `json:"recorded_at"`
+	geo.FixMetadata
 }
 
+// UserServiceSetUserLocationsItem is a single location fix of user service SetUserLocations method.
+//
+// The location is given either by `Latitude` and `Longitude` or by `Geohash`,
+// the center of the geohash cell is used in the latter case.
+// The embedded `FixMetadata` is optional.
+type UserServiceSetUserLocationsItem struct {
+	Username  string    `json:"username" validate:"required,validusername"`
+	Latitude  float64   `json:"latitude" validate:"excluded_with=Geohash,validlatitude"`
+	Longitude float64   `json:"longitude" validate:"excluded_with=Geohash,validlongitude"`
+	Geohash   string    `json:"geohash" validate:"omitempty,validgeohash"`
+	Timestamp time.Time `json:"timestamp" validate:"required"`
+	geo.FixMetadata
+}
+
+// UserServiceSetUserLocationsRequest is a param object of user service SetUserLocations method.
+//
+// Items are validated one by one, so `Locations` may contain invalid items.
+type UserServiceSetUserLocationsRequest struct {
+	Locations []UserServiceSetUserLocationsItem `json:"locations" validate:"min=1,max=1000"`
+}
+
+// SetUserLocationsMaxBatchSize is the maximum number of items of user service SetUserLocations method.
+const SetUserLocationsMaxBatchSize = 1000
+
+// UserServiceSetUserLocationsResult is a status of a single item of user service SetUserLocations method.
+//
+// `Err` is nil if the item is applied.
+type UserServiceSetUserLocationsResult struct {
+	Latitude   float64   `json:"latitude"`
+	Longitude  float64   `json:"longitude"`
+	Geohash    string    `json:"geohash"`
+	RecordedAt time.Time `json:"recorded_at"`
+	geo.FixMetadata
+	Err error `json:"-"`
+}
+
+// UserServiceSetUserLocationsResponse represents response from user service SetUserLocations method.
+//
+// `Results` are in order of the request items.
+type UserServiceSetUserLocationsResponse struct {
+	Results []UserServiceSetUserLocationsResult `json:"results"`
+}
+
+// UsersOrder defines an order of user lists.
+type UsersOrder string
+
+const (
+	// UsersOrderByID orders users by ID. It is the default order.
+	UsersOrderByID UsersOrder = "id"
+	// UsersOrderByDistance orders users by distance from the search center, nearest first.
+	UsersOrderByDistance UsersOrder = "distance"
+)
+
+// UsersScope defines which users a search finds.
+type UsersScope string
+
+const (
+	// UsersScopeAll finds every user. It is the default scope.
+	UsersScopeAll UsersScope = "all"
+	// UsersScopeConnections finds only connections of the caller,
+	// i.e. users who accepted follow requests of the caller.
+	UsersScopeConnections UsersScope = "connections"
+)
+
 // UserServiceListUsersInRadiusRequest TODO: add description
+//
+// Only users whose locations were updated within `MaxAge` are found.
+// `MaxAge` equal to 0 means the default maximum age of the service.
+// `Caller` is required for the connections scope. Users who blocked `Caller` or were blocked by it are never found.
 type UserServiceListUsersInRadiusRequest struct {
-	Point     geo.Point `json:"point" validate:"validgeopoint"`
-	Radius    float64   `json:"radius" validate:"gte=0"`
-	PageToken string    `json:"page_token" validate:"required_without=PageSize"`
-	PageSize  int       `json:"page_size" validate:"required_without=PageToken"`
+	Point     geo.Point     `json:"point" validate:"validgeopoint"`
+	Radius    float64       `json:"radius" validate:"gte=0"`
+	PageToken string        `json:"page_token" validate:"required_without=PageSize"`
+	PageSize  int           `json:"page_size" validate:"required_without=PageToken"`
+	OrderBy   UsersOrder    `json:"order_by" validate:"omitempty,oneof=id distance"`
+	MaxAge    time.Duration `json:"max_age" validate:"gte=0"`
+	Scope     UsersScope    `json:"scope" validate:"omitempty,oneof=all connections"`
+	Caller    string        `json:"caller" validate:"required_if=Scope connections,omitempty,validusername"`
 }
 
 // UserServiceListUsersInRadiusResponse TODO: add description
 type UserServiceListUsersInRadiusResponse struct {
+	Users         []domain.NearbyUser `json:"users"`
+	NextPageToken string              `json:"next_page_token"`
+}
+
+// UserServiceListNearestUsersRequest is a param object of user service ListNearestUsers method.
+//
+// `MaxDistance` equal to 0 means that distance is not limited.
+// `MaxAge` equal to 0 means the default maximum age of the service.
+// `Caller` is required for the connections scope. Users who blocked `Caller` or were blocked by it are never found.
+type UserServiceListNearestUsersRequest struct {
+	Point           geo.Point     `json:"point" validate:"validgeopoint"`
+	Limit           int           `json:"limit" validate:"gt=0,lte=100"`
+	ExcludeUsername string        `json:"exclude_username" validate:"omitempty,validusername"`
+	MaxDistance     float64       `json:"max_distance" validate:"gte=0"`
+	MaxAge          time.Duration `json:"max_age" validate:"gte=0"`
+	Scope           UsersScope    `json:"scope" validate:"omitempty,oneof=all connections"`
+	Caller          string        `json:"caller" validate:"required_if=Scope connections,omitempty,validusername"`
+}
+
+// UserServiceListNearestUsersResponse represents response from user service ListNearestUsers method.
+type UserServiceListNearestUsersResponse struct {
+	Users []domain.NearbyUser `json:"users"`
+}
+
+// UserServiceListUsersInBBoxRequest is a param object of user service ListUsersInBBox method.
+//
+// `MaxAge` equal to 0 means the default maximum age of the service.
+// `Caller` is required for the connections scope. Users who blocked `Caller` or were blocked by it are never found.
+type UserServiceListUsersInBBoxRequest struct {
+	BBox      geo.BBox      `json:"bbox" validate:"validbbox"`
+	PageToken string        `json:"page_token" validate:"required_without=PageSize"`
+	PageSize  int           `json:"page_size" validate:"required_without=PageToken"`
+	MaxAge    time.Duration `json:"max_age" validate:"gte=0"`
+	Scope     UsersScope    `json:"scope" validate:"omitempty,oneof=all connections"`
+	Caller    string        `json:"caller" validate:"required_if=Scope connections,omitempty,validusername"`
+}
+
+// UserServiceListUsersInBBoxResponse represents response from user service ListUsersInBBox method.
+type UserServiceListUsersInBBoxResponse struct {
+	Users         []domain.LocatedUser `json:"users"`
+	NextPageToken string               `json:"next_page_token"`
+}
+
+// UserServiceListUsersInPolygonRequest is a param object of user service ListUsersInPolygon method.
+//
+// `MaxAge` equal to 0 means the default maximum age of the service.
+// `Caller` is required for the connections scope. Users who blocked `Caller` or were blocked by it are never found.
+type UserServiceListUsersInPolygonRequest struct {
+	Polygon   geo.Polygon   `json:"polygon" validate:"validpolygon"`
+	PageToken string        `json:"page_token" validate:"required_without=PageSize"`
+	PageSize  int           `json:"page_size" validate:"required_without=PageToken"`
+	MaxAge    time.Duration `json:"max_age" validate:"gte=0"`
+	Scope     UsersScope    `json:"scope" validate:"omitempty,oneof=all connections"`
+	Caller    string        `json:"caller" validate:"required_if=Scope connections,omitempty,validusername"`
+}
+
+// UserServiceListUsersInPolygonResponse represents response from user service ListUsersInPolygon method.
+type UserServiceListUsersInPolygonResponse struct {
+	Users         []domain.LocatedUser `json:"users"`
+	NextPageToken string               `json:"next_page_token"`
+}
+
+// UserServiceWatchUsersInRadiusRequest is a param object of user service WatchUsersInRadius method.
+//
+// `MaxAge` only limits the users found inside the circle when the subscription starts,
+// 0 means the default maximum age of the service.
+// `Caller` is required for the connections scope. Users who blocked `Caller` or were blocked by it are never watched.
+type UserServiceWatchUsersInRadiusRequest struct {
+	Point  geo.Point     `json:"point" validate:"validgeopoint"`
+	Radius float64       `json:"radius" validate:"gte=0"`
+	MaxAge time.Duration `json:"max_age" validate:"gte=0"`
+	Scope  UsersScope    `json:"scope" validate:"omitempty,oneof=all connections"`
+	Caller string        `json:"caller" validate:"required_if=Scope connections,omitempty,validusername"`
+}
+
+// UserServiceSubscribeLocationsRequest is a param object of user service SubscribeLocations method.
+//
+// Either `Usernames` or `BBox` must be set, but not both.
+// `Caller` is required for the connections scope. Updates of users who blocked `Caller` or were blocked by it are never delivered.
+type UserServiceSubscribeLocationsRequest struct {
+	Usernames []string   `json:"usernames" validate:"required_without=BBox,excluded_with=BBox,max=100,dive,validusername"`
+	BBox      *geo.BBox  `json:"bbox" validate:"omitempty,validbbox"`
+	Scope     UsersScope `json:"scope" validate:"omitempty,oneof=all connections"`
+	Caller    string     `json:"caller" validate:"required_if=Scope connections,omitempty,validusername"`
+}
+
+// UserServiceCreateUserRequest is a param object of user service CreateUser method.
+type UserServiceCreateUserRequest struct {
+	Username string `json:"username" validate:"required,validusername"`
+}
+
+// UserServiceGetUserResponse represents response from user service GetUser method.
+//
+// `Location` and `LastSeenAt` equal nil if the user has never set a location.
+// `LastSeenAt` is the time the location of the user was updated at.
+type UserServiceGetUserResponse struct {
+	domain.User
+	Location   *domain.Location `json:"location,omitempty"`
+	LastSeenAt *time.Time       `json:"last_seen_at,omitempty"`
+}
+
+// UserServiceListUsersRequest is a param object of user service ListUsers method.
+//
+// Users are not filtered by username if `Prefix` is empty.
+type UserServiceListUsersRequest struct {
+	Prefix    string `json:"prefix" validate:"omitempty,alphanum,max=16"`
+	PageToken string `json:"page_token" validate:"required_without=PageSize"`
+	PageSize  int    `json:"page_size" validate:"required_without=PageToken"`
+}
+
+// UserServiceListUsersResponse represents response from user service ListUsers method.
+type UserServiceListUsersResponse struct {
 	Users         []domain.User `json:"users"`
 	NextPageToken string        `json:"next_page_token"`
 }
 
+// UserServiceRenameUserRequest is a param object of user service RenameUser method.
+type UserServiceRenameUserRequest struct {
+	Username    string `json:"username" validate:"required,validusername"`
+	NewUsername string `json:"new_username" validate:"required,validusername"`
+}
+
+// UserServiceSetPrivacySettingsRequest is a param object of user service SetPrivacySettings method.
+//
+// Exactly one of `GridSize` in degrees and `GeohashPrecision` is required for the coarse level,
+// neither of them is allowed for other levels.
+type UserServiceSetPrivacySettingsRequest struct {
+	Username         string              `json:"username" validate:"required,validusername"`
+	Level            domain.PrivacyLevel `json:"level" validate:"required,oneof=exact coarse hidden"`
+	GridSize         float64             `json:"grid_size" validate:"gte=0,lte=10"`
+	GeohashPrecision int                 `json:"geohash_precision" validate:"gte=0,lte=12"`
+}
+
 // UserService represents user service.
 type UserService interface {
 	GetByUsername(ctx context.Context, username string) (domain.User, error)
+	CreateUser(ctx context.Context, req UserServiceCreateUserRequest) (domain.User, error)
+	GetUser(ctx context.Context, username string) (UserServiceGetUserResponse, error)
+	ListUsers(ctx context.Context, req UserServiceListUsersRequest) (UserServiceListUsersResponse, error)
+	RenameUser(ctx context.Context, req UserServiceRenameUserRequest) (domain.User, error)
+	DeleteUser(ctx context.Context, username string) (domain.ErasureJob, error)
+	GetPrivacySettings(ctx context.Context, username string) (domain.PrivacySettings, error)
+	SetPrivacySettings(ctx context.Context, req UserServiceSetPrivacySettingsRequest) (domain.PrivacySettings, error)
 	SetUserLocation(ctx context.Context, req UserServiceSetUserLocationRequest) (UserServiceSetUserLocationResponse, error)
+	SetUserLocations(ctx context.Context, req UserServiceSetUserLocationsRequest) (UserServiceSetUserLocationsResponse, error)
 	ListUsersInRadius(ctx context.Context, req UserServiceListUsersInRadiusRequest) (UserServiceListUsersInRadiusResponse, error)
+	ListNearestUsers(ctx context.Context, req UserServiceListNearestUsersRequest) (UserServiceListNearestUsersResponse, error)
+	ListUsersInBBox(ctx context.Context, req UserServiceListUsersInBBoxRequest) (UserServiceListUsersInBBoxResponse, error)
+	ListUsersInPolygon(ctx context.Context, req UserServiceListUsersInPolygonRequest) (UserServiceListUsersInPolygonResponse, error)
+	WatchUsersInRadius(ctx context.Context, req UserServiceWatchUsersInRadiusRequest, send func(domain.RadiusEvent) error) error
+	SubscribeLocations(ctx context.Context, req UserServiceSubscribeLocationsRequest) (LocationSubscription, error)
+	DeleteExpiredIdempotencyKeys(ctx context.Context) error
 }
 
 // CreateUserArg is a param object of use repository CreateUser method.
@@ -49,36 +282,190 @@ type CreateUserArg struct {
 }
 
 // UserRepositorySetUserLocationRequest is a param object of user repository SetUserLocation method.
+//
+// Zero `RecordedAt` means the current time.
+// A user that does not exist is not created if `DisableUserAutoCreation` is true.
 type UserRepositorySetUserLocationRequest struct {
-	Username string    `json:"username"`
-	Point    geo.Point `json:"point"`
+	Username   string    `json:"username"`
+	Point      geo.Point `json:"point"`
+	RecordedAt time.Time `json:"recorded_at"`
+	geo.FixMetadata
+	DisableUserAutoCreation bool `json:"-"`
 }
 
 // UserRepositoryListUsersInRadiusRequest TODO: add description
+//
+// `PageTokenDistance` is only taken into account when users are ordered by distance.
+// Users whose locations were updated more than `MaxAge` ago are skipped unless it equals 0.
+// Only connections of the user with username `ConnectionsOf` are found unless it is empty.
+// Users who blocked the user with username `Caller` or were blocked by it are skipped unless it is empty.
 type UserRepositoryListUsersInRadiusRequest struct {
-	Point     geo.Point
-	Radius    float64
-	PageToken int
-	PageSize  int
+	Point             geo.Point
+	Radius            float64
+	PageToken         int
+	PageTokenDistance float64
+	PageSize          int
+	OrderBy           UsersOrder
+	MaxAge            time.Duration
+	ConnectionsOf     string
+	Caller            string
 }
 
 // UserRepositoryListUsersInRadiusResponse TODO: add description
 type UserRepositoryListUsersInRadiusResponse struct {
+	Users                 []domain.NearbyUser
+	NextPageToken         int
+	NextPageTokenDistance float64
+}
+
+// UserRepositoryListNearestUsersRequest is a param object of user repository ListNearestUsers method.
+//
+// Users whose locations were updated more than `MaxAge` ago are skipped unless it equals 0.
+// Only connections of the user with username `ConnectionsOf` are found unless it is empty.
+// Users who blocked the user with username `Caller` or were blocked by it are skipped unless it is empty.
+type UserRepositoryListNearestUsersRequest struct {
+	Point           geo.Point
+	Limit           int
+	ExcludeUsername string
+	MaxDistance     float64
+	MaxAge          time.Duration
+	ConnectionsOf   string
+	Caller          string
+}
+
+// UserRepositoryListUsersInBBoxRequest is a param object of user repository ListUsersInBBox method.
+//
+// Users whose locations were updated more than `MaxAge` ago are skipped unless it equals 0.
+// Only connections of the user with username `ConnectionsOf` are found unless it is empty.
+// Users who blocked the user with username `Caller` or were blocked by it are skipped unless it is empty.
+type UserRepositoryListUsersInBBoxRequest struct {
+	BBox          geo.BBox
+	PageToken     int
+	PageSize      int
+	MaxAge        time.Duration
+	ConnectionsOf string
+	Caller        string
+}
+
+// UserRepositoryListUsersInPolygonRequest is a param object of user repository ListUsersInPolygon method.
+//
+// Users whose locations were updated more than `MaxAge` ago are skipped unless it equals 0.
+// Only connections of the user with username `ConnectionsOf` are found unless it is empty.
+// Users who blocked the user with username `Caller` or were blocked by it are skipped unless it is empty.
+type UserRepositoryListUsersInPolygonRequest struct {
+	Polygon       geo.Polygon
+	PageToken     int
+	PageSize      int
+	MaxAge        time.Duration
+	ConnectionsOf string
+	Caller        string
+}
+
+// UserRepositoryListUsersInAreaResponse represents response from user repository
+// ListUsersInBBox and ListUsersInPolygon methods.
+type UserRepositoryListUsersInAreaResponse struct {
+	Users         []domain.LocatedUser
+	NextPageToken int
+}
+
+// UserRepositoryGetUserWithLocationResponse represents response from user repository GetUserWithLocation method.
+//
+// `Location` equals nil if the user has never set a location.
+// `Privacy` equals nil if the user has never changed the default privacy settings.
+type UserRepositoryGetUserWithLocationResponse struct {
+	User     domain.User
+	Location *domain.Location
+	Privacy  *domain.PrivacySettings
+}
+
+// UserRepositoryListUsersRequest is a param object of user repository ListUsers method.
+type UserRepositoryListUsersRequest struct {
+	Prefix    string
+	PageToken int
+	PageSize  int
+}
+
+// UserRepositoryListUsersResponse represents response from user repository ListUsers method.
+type UserRepositoryListUsersResponse struct {
 	Users         []domain.User
 	NextPageToken int
 }
 
-// UserRepositorySetUserLocationResponse TODO: add description
+// UserRepositoryRenameUserRequest is a param object of user repository RenameUser method.
+type UserRepositoryRenameUserRequest struct {
+	Username    string
+	NewUsername string
+}
+
+// UserRepositorySetUserLocationResponse represents response from user repository SetUserLocation method.
+//
+// `OutOfOrder` is true if the location is not set because it was recorded before the stored one.
+// In that case `Location` equals `PrevLocation`.
+// `Privacy` equals nil if the user has never changed the default privacy settings.
 type UserRepositorySetUserLocationResponse struct {
 	User         domain.User
 	PrevLocation domain.Location
 	Location     domain.Location
+	OutOfOrder   bool
+	Privacy      *domain.PrivacySettings
+}
+
+// UserRepositorySetPrivacySettingsRequest is a param object of user repository SetPrivacySettings method.
+type UserRepositorySetPrivacySettingsRequest struct {
+	Username         string
+	Level            domain.PrivacyLevel
+	GridSize         float64
+	GeohashPrecision int
+}
+
+// UserRepositoryReserveIdempotencyKeyRequest is a param object of user repository ReserveIdempotencyKey method.
+//
+// The key expires `TTL` after it is reserved.
+// A key that is not completed within `Lease` after it is reserved may be reserved again,
+// 0 means that it is held until it expires.
+type UserRepositoryReserveIdempotencyKeyRequest struct {
+	Username    string
+	Key         string
+	RequestHash string
+	TTL         time.Duration
+	Lease       time.Duration
+}
+
+// UserRepositoryReserveIdempotencyKeyResponse represents response from user repository ReserveIdempotencyKey method.
+//
+// `Reserved` is true if the key is reserved by the call. Otherwise `Key` is the key reserved before.
+type UserRepositoryReserveIdempotencyKeyResponse struct {
+	Key      domain.IdempotencyKey
+	Reserved bool
+}
+
+// UserRepositoryCompleteIdempotencyKeyRequest is a param object of user repository CompleteIdempotencyKey method.
+type UserRepositoryCompleteIdempotencyKeyRequest struct {
+	Username string
+	Key      string
+	Response []byte
 }
 
 // UserRepository represents user repository.
 type UserRepository interface {
 	CreateUser(ctx context.Context, arg CreateUserArg) (domain.User, error)
 	GetByUsername(ctx context.Context, username string) (domain.User, error)
+	GetUserWithLocation(ctx context.Context, username string) (UserRepositoryGetUserWithLocationResponse, error)
+	ListUsers(ctx context.Context, arg UserRepositoryListUsersRequest) (UserRepositoryListUsersResponse, error)
+	RenameUser(ctx context.Context, arg UserRepositoryRenameUserRequest) (domain.User, error)
+	DeleteUser(ctx context.Context, username string) (domain.ErasureJob, error)
+	GetPrivacySettings(ctx context.Context, username string) (domain.PrivacySettings, error)
+	SetPrivacySettings(ctx context.Context, arg UserRepositorySetPrivacySettingsRequest) (domain.PrivacySettings, error)
 	SetUserLocation(ctx context.Context, arg UserRepositorySetUserLocationRequest) (UserRepositorySetUserLocationResponse, error)
+	SetUserLocations(ctx context.Context, args []UserRepositorySetUserLocationRequest) ([]UserRepositorySetUserLocationResponse, error)
 	ListUsersInRadius(ctx context.Context, arg UserRepositoryListUsersInRadiusRequest) (UserRepositoryListUsersInRadiusResponse, error)
+	ListNearestUsers(ctx context.Context, arg UserRepositoryListNearestUsersRequest) ([]domain.NearbyUser, error)
+	ListUsersInBBox(ctx context.Context, arg UserRepositoryListUsersInBBoxRequest) (UserRepositoryListUsersInAreaResponse, error)
+	ListUsersInPolygon(ctx context.Context, arg UserRepositoryListUsersInPolygonRequest) (UserRepositoryListUsersInAreaResponse, error)
+	IsConnection(ctx context.Context, connectionsOf string, userID int) (bool, error)
+	IsBlocked(ctx context.Context, username string, userID int) (bool, error)
+	ReserveIdempotencyKey(ctx context.Context, arg UserRepositoryReserveIdempotencyKeyRequest) (UserRepositoryReserveIdempotencyKeyResponse, error)
+	CompleteIdempotencyKey(ctx context.Context, arg UserRepositoryCompleteIdempotencyKeyRequest) error
+	ReleaseIdempotencyKey(ctx context.Context, username, key string) error
+	DeleteExpiredIdempotencyKeys(ctx context.Context) (int, error)
 }
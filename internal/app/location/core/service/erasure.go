@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	log2 "log"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+// erasureJobsBatchSize is a number of pending jobs fetched at once while processing.
+const erasureJobsBatchSize = 100
+
+type erasureService struct {
+	repo          port.ErasureRepository
+	historyClient port.HistoryClient
+	logger        log.Logger
+}
+
+// NewErasureService creates instance of ErasureService and returns its pointer.
+func NewErasureService(repo port.ErasureRepository, historyClient port.HistoryClient, logger log.Logger) port.ErasureService {
+	if logger == nil {
+		log2.Panic("logger must not be nil")
+	}
+	if repo == nil {
+		logger.Panic("repo must not be nil", nil)
+	}
+	if historyClient == nil {
+		logger.Panic("historyClient must not be nil", nil)
+	}
+
+	return &erasureService{
+		repo:          repo,
+		historyClient: historyClient,
+		logger:        logger,
+	}
+}
+
+// GetErasureJob finds an erasure job by ID.
+//
+// `ErrInvalidArgument` is returned in case ID is not positive.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user the job belongs to.
+//
+// Any other error occurred in `GetErasureJob` is returned.
+func (s *erasureService) GetErasureJob(ctx context.Context, id int) (domain.ErasureJob, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, id)
+	}()
+
+	if id <= 0 {
+		return domain.ErasureJob{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	job, err := s.repo.GetErasureJob(ctx, id)
+	if err != nil {
+		return domain.ErasureJob{}, err
+	}
+
+	if err = auth.AuthorizeUser(ctx, job.Username); err != nil {
+		return domain.ErasureJob{}, err
+	}
+
+	return job, nil
+}
+
+// ListErasureJobs lists erasure jobs ordered by ID, optionally filtered by status.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller is not an admin.
+//
+// Any other error occurred in `ListErasureJobs` is returned.
+func (s *erasureService) ListErasureJobs(ctx context.Context, req port.ErasureServiceListErasureJobsRequest) (port.ErasureServiceListErasureJobsResponse, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return port.ErasureServiceListErasureJobsResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeRoles(ctx, auth.RoleAdmin); err != nil {
+		return port.ErasureServiceListErasureJobsResponse{}, err
+	}
+
+	pageToken, pageSize, err := decodePageToken(req.PageToken, req.PageSize)
+	if err != nil {
+		return port.ErasureServiceListErasureJobsResponse{}, err
+	}
+
+	res, err := s.repo.ListErasureJobs(ctx, port.ErasureRepositoryListErasureJobsRequest{
+		Status:    req.Status,
+		PageToken: pageToken,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		return port.ErasureServiceListErasureJobsResponse{}, err
+	}
+
+	if res.Jobs == nil {
+		res.Jobs = make([]domain.ErasureJob, 0)
+	}
+
+	return port.ErasureServiceListErasureJobsResponse{
+		Jobs:          res.Jobs,
+		NextPageToken: encodePageToken(res.NextPageToken, pageSize),
+	}, nil
+}
+
+// ProcessErasureJobs makes an attempt to erase data of every pending erasure job from other services.
+//
+// Erasure is idempotent, so a job is safe to be attempted again until it is completed.
+// A job is completed if the attempt succeeded. Otherwise, it stays pending with the error recorded.
+//
+// Any error occurred while listing or updating jobs is returned.
+func (s *erasureService) ProcessErasureJobs(ctx context.Context) error {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, nil)
+	}()
+
+	pageToken := 0
+	for {
+		var res port.ErasureRepositoryListErasureJobsResponse
+		res, err = s.repo.ListErasureJobs(ctx, port.ErasureRepositoryListErasureJobsRequest{
+			Status:    domain.ErasureJobPending,
+			PageToken: pageToken,
+			PageSize:  erasureJobsBatchSize,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, job := range res.Jobs {
+			if err = s.processErasureJob(ctx, job); err != nil {
+				return err
+			}
+		}
+
+		if res.NextPageToken == 0 {
+			return nil
+		}
+		pageToken = res.NextPageToken
+	}
+}
+
+// processErasureJob erases data of a job from history service and records the attempt.
+func (s *erasureService) processErasureJob(ctx context.Context, job domain.ErasureJob) error {
+	arg := port.ErasureRepositoryUpdateErasureJobRequest{
+		ID:     job.ID,
+		Status: domain.ErasureJobCompleted,
+	}
+
+	if _, err := s.historyClient.DeleteUserRecords(ctx, job.UserID); err != nil {
+		s.logger.Warn(fmt.Sprintf("failed to erase history of deleted user: %v", err), log.Fields{
+			"erasure_job_id": job.ID,
+			"user_id":        job.UserID,
+		})
+		arg.Status = domain.ErasureJobPending
+		arg.LastError = err.Error()
+	}
+
+	if _, err := s.repo.UpdateErasureJob(ctx, arg); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,294 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+	mocklog "gitlab.com/spacewalker/geotracker/internal/pkg/log/mock"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util/pagination"
+)
+
+type ErasureSvcTestSuite struct {
+	suite.Suite
+}
+
+func TestErasureSvcTestSuite(t *testing.T) {
+	// Skip tests when using "-short" flag.
+	if testing.Short() {
+		t.Skip("Skipping long-running tests")
+	}
+
+	suite.Run(t, new(ErasureSvcTestSuite))
+}
+
+func (s *ErasureSvcTestSuite) Test_ErasureService_GetErasureJob() {
+	job := domain.ErasureJob{ID: 1, UserID: 2, Username: "user1", Status: domain.ErasureJobPending}
+
+	testCases := []struct {
+		name       string
+		id         int
+		buildStubs func(repo *mock.MockErasureRepository)
+		isError    error
+	}{
+		{
+			name: "OK",
+			id:   1,
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().GetErasureJob(gomock.Any(), gomock.Eq(1)).Times(1).Return(job, nil)
+			},
+		},
+		{
+			name: "InvalidID",
+			id:   0,
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().GetErasureJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name: "NotFound",
+			id:   1,
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().GetErasureJob(gomock.Any(), gomock.Eq(1)).Times(1).Return(domain.ErasureJob{}, errpack.ErrNotFound)
+			},
+			isError: errpack.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockErasureRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewErasureService(repo, mock.NewMockHistoryClient(ctrl), mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.GetErasureJob(context.Background(), tc.id)
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
+				require.Empty(t, res)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, job, res)
+			}
+		})
+	}
+}
+
+func (s *ErasureSvcTestSuite) Test_ErasureService_ListErasureJobs() {
+	testCases := []struct {
+		name       string
+		req        port.ErasureServiceListErasureJobsRequest
+		buildStubs func(repo *mock.MockErasureRepository)
+		assert     func(t *testing.T, res port.ErasureServiceListErasureJobsResponse, err error)
+	}{
+		{
+			name: "OK_PageSize",
+			req: port.ErasureServiceListErasureJobsRequest{
+				Status:   domain.ErasureJobPending,
+				PageSize: 1,
+			},
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().
+					ListErasureJobs(gomock.Any(), gomock.Eq(port.ErasureRepositoryListErasureJobsRequest{
+						Status:   domain.ErasureJobPending,
+						PageSize: 1,
+					})).
+					Times(1).
+					Return(port.ErasureRepositoryListErasureJobsResponse{
+						Jobs:          []domain.ErasureJob{{ID: 3, UserID: 1, Status: domain.ErasureJobPending}},
+						NextPageToken: 3,
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.ErasureServiceListErasureJobsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Jobs, 1)
+				require.Equal(t, pagination.EncodeCursor(3, 1), res.NextPageToken)
+			},
+		},
+		{
+			name: "OK_PageToken_LastPage",
+			req: port.ErasureServiceListErasureJobsRequest{
+				PageToken: pagination.EncodeCursor(3, 1),
+			},
+			buildStubs: func(repo *mock.MockErasureRepository) {
+				repo.EXPECT().
+					ListErasureJobs(gomock.Any(), gomock.Eq(port.ErasureRepositoryListErasureJobsRequest{
+						PageToken: 3,
+						PageSize:  1,
+					})).
+					Times(1).
+					Return(port.ErasureRepositoryListErasureJobsResponse{}, nil)
+			},
+			assert: func(t *testing.T, res port.ErasureServiceListErasureJobsResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res.Jobs)
+				require.Empty(t, res.Jobs)
+				require.Empty(t, res.NextPageToken)
+			},
+		},
+		{
+			name: "InvalidStatus",
+			req: port.ErasureServiceListErasureJobsRequest{
+				Status:   "unknown",
+				PageSize: 1,
+			},
+			assert: func(t *testing.T, res port.ErasureServiceListErasureJobsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "PageTokenAndPageSizeBothNotProvided",
+			req:  port.ErasureServiceListErasureJobsRequest{},
+			assert: func(t *testing.T, res port.ErasureServiceListErasureJobsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockErasureRepository(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(repo)
+			}
+			svc := service.NewErasureService(repo, mock.NewMockHistoryClient(ctrl), mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.ListErasureJobs(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *ErasureSvcTestSuite) Test_ErasureService_ProcessErasureJobs() {
+	testCases := []struct {
+		name       string
+		buildStubs func(repo *mock.MockErasureRepository, historyClient *mock.MockHistoryClient)
+		isError    error
+	}{
+		{
+			name: "OK",
+			buildStubs: func(repo *mock.MockErasureRepository, historyClient *mock.MockHistoryClient) {
+				gomock.InOrder(
+					repo.EXPECT().
+						ListErasureJobs(gomock.Any(), gomock.Eq(port.ErasureRepositoryListErasureJobsRequest{
+							Status:   domain.ErasureJobPending,
+							PageSize: 100,
+						})).
+						Times(1).
+						Return(port.ErasureRepositoryListErasureJobsResponse{
+							Jobs:          []domain.ErasureJob{{ID: 1, UserID: 10, Status: domain.ErasureJobPending}},
+							NextPageToken: 1,
+						}, nil),
+					historyClient.EXPECT().DeleteUserRecords(gomock.Any(), gomock.Eq(10)).Times(1).Return(5, nil),
+					repo.EXPECT().
+						UpdateErasureJob(gomock.Any(), gomock.Eq(port.ErasureRepositoryUpdateErasureJobRequest{
+							ID:     1,
+							Status: domain.ErasureJobCompleted,
+						})).
+						Times(1).
+						Return(domain.ErasureJob{ID: 1, UserID: 10, Status: domain.ErasureJobCompleted, Attempts: 1}, nil),
+					repo.EXPECT().
+						ListErasureJobs(gomock.Any(), gomock.Eq(port.ErasureRepositoryListErasureJobsRequest{
+							Status:    domain.ErasureJobPending,
+							PageToken: 1,
+							PageSize:  100,
+						})).
+						Times(1).
+						Return(port.ErasureRepositoryListErasureJobsResponse{
+							Jobs: []domain.ErasureJob{{ID: 2, UserID: 20, Status: domain.ErasureJobPending}},
+						}, nil),
+					historyClient.EXPECT().DeleteUserRecords(gomock.Any(), gomock.Eq(20)).Times(1).Return(0, nil),
+					repo.EXPECT().
+						UpdateErasureJob(gomock.Any(), gomock.Eq(port.ErasureRepositoryUpdateErasureJobRequest{
+							ID:     2,
+							Status: domain.ErasureJobCompleted,
+						})).
+						Times(1).
+						Return(domain.ErasureJob{ID: 2, UserID: 20, Status: domain.ErasureJobCompleted, Attempts: 1}, nil),
+				)
+			},
+		},
+		{
+			name: "HistoryUnavailable",
+			buildStubs: func(repo *mock.MockErasureRepository, historyClient *mock.MockHistoryClient) {
+				repo.EXPECT().
+					ListErasureJobs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.ErasureRepositoryListErasureJobsResponse{
+						Jobs: []domain.ErasureJob{{ID: 1, UserID: 10, Status: domain.ErasureJobPending}},
+					}, nil)
+				historyClient.EXPECT().DeleteUserRecords(gomock.Any(), gomock.Eq(10)).Times(1).Return(0, errpack.ErrInternalError)
+				// The job stays pending to be attempted again.
+				repo.EXPECT().
+					UpdateErasureJob(gomock.Any(), gomock.Eq(port.ErasureRepositoryUpdateErasureJobRequest{
+						ID:        1,
+						Status:    domain.ErasureJobPending,
+						LastError: errpack.ErrInternalError.Error(),
+					})).
+					Times(1).
+					Return(domain.ErasureJob{ID: 1, UserID: 10, Status: domain.ErasureJobPending, Attempts: 1}, nil)
+			},
+		},
+		{
+			name: "NoPendingJobs",
+			buildStubs: func(repo *mock.MockErasureRepository, historyClient *mock.MockHistoryClient) {
+				repo.EXPECT().
+					ListErasureJobs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.ErasureRepositoryListErasureJobsResponse{}, nil)
+				historyClient.EXPECT().DeleteUserRecords(gomock.Any(), gomock.Any()).Times(0)
+				repo.EXPECT().UpdateErasureJob(gomock.Any(), gomock.Any()).Times(0)
+			},
+		},
+		{
+			name: "ListInternalError",
+			buildStubs: func(repo *mock.MockErasureRepository, historyClient *mock.MockHistoryClient) {
+				repo.EXPECT().
+					ListErasureJobs(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.ErasureRepositoryListErasureJobsResponse{}, errpack.ErrInternalError)
+				historyClient.EXPECT().DeleteUserRecords(gomock.Any(), gomock.Any()).Times(0)
+			},
+			isError: errpack.ErrInternalError,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockErasureRepository(ctrl)
+			historyClient := mock.NewMockHistoryClient(ctrl)
+			tc.buildStubs(repo, historyClient)
+			svc := service.NewErasureService(repo, historyClient, log.NewTestingLogger())
+
+			err := svc.ProcessErasureJobs(context.Background())
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,341 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	log2 "log"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+type geofenceService struct {
+	repo   port.GeofenceRepository
+	logger log.Logger
+}
+
+// NewGeofenceService creates instance of GeofenceService and returns its pointer.
+func NewGeofenceService(repo port.GeofenceRepository, logger log.Logger) port.GeofenceService {
+	if logger == nil {
+		log2.Panic("logger must not be nil")
+	}
+	if repo == nil {
+		logger.Panic("repo must not be nil", nil)
+	}
+
+	return &geofenceService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// CreateGeofence creates a new geofence.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller is not an admin.
+//
+// Any other error occurred in `CreateGeofence` is returned.
+func (s *geofenceService) CreateGeofence(ctx context.Context, req port.GeofenceServiceCreateGeofenceRequest) (domain.Geofence, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return domain.Geofence{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeRoles(ctx, auth.RoleAdmin); err != nil {
+		return domain.Geofence{}, err
+	}
+
+	geofence, err := s.repo.CreateGeofence(ctx, port.GeofenceRepositoryCreateGeofenceRequest{
+		Name:      req.Name,
+		Type:      req.Type,
+		Center:    truncCenter(req.Center),
+		Radius:    req.Radius,
+		Polygon:   req.Polygon,
+		DwellTime: req.DwellTime,
+	})
+	if err != nil {
+		return domain.Geofence{}, err
+	}
+
+	return geofence, nil
+}
+
+// GetGeofence finds a geofence by ID.
+//
+// `ErrInvalidArgument` is returned in case ID is not positive.
+//
+// Any other error occurred in `GetGeofence` is returned.
+func (s *geofenceService) GetGeofence(ctx context.Context, id int) (domain.Geofence, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, id)
+	}()
+
+	if id <= 0 {
+		return domain.Geofence{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	geofence, err := s.repo.GetGeofence(ctx, id)
+	if err != nil {
+		return domain.Geofence{}, err
+	}
+
+	return geofence, nil
+}
+
+// UpdateGeofence replaces all fields of the geofence.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller is not an admin.
+//
+// Any other error occurred in `UpdateGeofence` is returned.
+func (s *geofenceService) UpdateGeofence(ctx context.Context, req port.GeofenceServiceUpdateGeofenceRequest) (domain.Geofence, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return domain.Geofence{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeRoles(ctx, auth.RoleAdmin); err != nil {
+		return domain.Geofence{}, err
+	}
+
+	geofence, err := s.repo.UpdateGeofence(ctx, port.GeofenceRepositoryUpdateGeofenceRequest{
+		ID:        req.ID,
+		Name:      req.Name,
+		Type:      req.Type,
+		Center:    truncCenter(req.Center),
+		Radius:    req.Radius,
+		Polygon:   req.Polygon,
+		DwellTime: req.DwellTime,
+	})
+	if err != nil {
+		return domain.Geofence{}, err
+	}
+
+	return geofence, nil
+}
+
+// DeleteGeofence deletes the geofence along with all its events.
+//
+// `ErrInvalidArgument` is returned in case ID is not positive.
+//
+// `ErrPermissionDenied` is returned in case the caller is not an admin.
+//
+// Any other error occurred in `DeleteGeofence` is returned.
+func (s *geofenceService) DeleteGeofence(ctx context.Context, id int) error {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, id)
+	}()
+
+	if id <= 0 {
+		return fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeRoles(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteGeofence(ctx, id)
+	return err
+}
+
+// ListGeofences lists geofences ordered by ID.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// Any other error occurred in `ListGeofences` is returned.
+func (s *geofenceService) ListGeofences(ctx context.Context, req port.GeofenceServiceListGeofencesRequest) (port.GeofenceServiceListGeofencesResponse, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return port.GeofenceServiceListGeofencesResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	pageToken, pageSize, err := decodePageToken(req.PageToken, req.PageSize)
+	if err != nil {
+		return port.GeofenceServiceListGeofencesResponse{}, err
+	}
+
+	res, err := s.repo.ListGeofences(ctx, port.GeofenceRepositoryListGeofencesRequest{
+		PageToken: pageToken,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		return port.GeofenceServiceListGeofencesResponse{}, err
+	}
+
+	if res.Geofences == nil {
+		res.Geofences = make([]domain.Geofence, 0)
+	}
+
+	return port.GeofenceServiceListGeofencesResponse{
+		Geofences:     res.Geofences,
+		NextPageToken: encodePageToken(res.NextPageToken, pageSize),
+	}, nil
+}
+
+// ListGeofenceEvents lists geofence events ordered by ID, i.e. in the order they were emitted.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// Any other error occurred in `ListGeofenceEvents` is returned.
+func (s *geofenceService) ListGeofenceEvents(ctx context.Context, req port.GeofenceServiceListGeofenceEventsRequest) (port.GeofenceServiceListGeofenceEventsResponse, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return port.GeofenceServiceListGeofenceEventsResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	pageToken, pageSize, err := decodePageToken(req.PageToken, req.PageSize)
+	if err != nil {
+		return port.GeofenceServiceListGeofenceEventsResponse{}, err
+	}
+
+	res, err := s.repo.ListGeofenceEvents(ctx, port.GeofenceRepositoryListGeofenceEventsRequest{
+		GeofenceID: req.GeofenceID,
+		UserID:     req.UserID,
+		PageToken:  pageToken,
+		PageSize:   pageSize,
+	})
+	if err != nil {
+		return port.GeofenceServiceListGeofenceEventsResponse{}, err
+	}
+
+	if res.Events == nil {
+		res.Events = make([]domain.GeofenceEvent, 0)
+	}
+
+	return port.GeofenceServiceListGeofenceEventsResponse{
+		Events:        res.Events,
+		NextPageToken: encodePageToken(res.NextPageToken, pageSize),
+	}, nil
+}
+
+// EvaluateLocation compares geofences containing the previous and the new location of the user
+// and emits an event for every geofence the user crossed.
+//
+// ENTER is emitted for geofences containing only the new location,
+// EXIT is emitted for geofences containing only the previous location.
+// DWELL is emitted once per visit for geofences containing both locations
+// if the user entered the geofence at least its dwell time ago.
+// Thus, DWELL is only emitted on a location update.
+//
+// It returns emitted events and any error encountered.
+//
+// Any error occurred in repository methods is returned.
+func (s *geofenceService) EvaluateLocation(ctx context.Context, req port.GeofenceServiceEvaluateLocationRequest) ([]domain.GeofenceEvent, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	current, err := s.repo.ListGeofencesContaining(ctx, req.Point)
+	if err != nil {
+		return nil, err
+	}
+
+	var prev []domain.Geofence
+	if req.PrevPoint != nil {
+		prev, err = s.repo.ListGeofencesContaining(ctx, *req.PrevPoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wasInside := make(map[int]bool, len(prev))
+	for _, geofence := range prev {
+		wasInside[geofence.ID] = true
+	}
+	isInside := make(map[int]bool, len(current))
+	for _, geofence := range current {
+		isInside[geofence.ID] = true
+	}
+
+	newEvent := func(geofenceID int, typ domain.GeofenceEventType) domain.GeofenceEvent {
+		return domain.GeofenceEvent{
+			GeofenceID: geofenceID,
+			UserID:     req.UserID,
+			Type:       typ,
+			Point:      req.Point,
+			CreatedAt:  req.Timestamp,
+		}
+	}
+
+	var events []domain.GeofenceEvent
+	dwellTimes := make(map[int]int)
+	for _, geofence := range current {
+		if !wasInside[geofence.ID] {
+			events = append(events, newEvent(geofence.ID, domain.GeofenceEventEnter))
+		} else if geofence.DwellTime > 0 {
+			dwellTimes[geofence.ID] = geofence.DwellTime
+		}
+	}
+	for _, geofence := range prev {
+		if !isInside[geofence.ID] {
+			events = append(events, newEvent(geofence.ID, domain.GeofenceEventExit))
+		}
+	}
+
+	if len(dwellTimes) > 0 {
+		ids := make([]int, 0, len(dwellTimes))
+		for _, geofence := range current {
+			if _, ok := dwellTimes[geofence.ID]; ok {
+				ids = append(ids, geofence.ID)
+			}
+		}
+
+		var presences []domain.GeofencePresence
+		presences, err = s.repo.ListGeofencePresences(ctx, req.UserID, ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, presence := range presences {
+			dwellTime := dwellTimes[presence.GeofenceID]
+			if !presence.Dwelled && req.Timestamp.Sub(presence.EnteredAt).Seconds() >= float64(dwellTime) {
+				events = append(events, newEvent(presence.GeofenceID, domain.GeofenceEventDwell))
+			}
+		}
+	}
+
+	if len(events) == 0 {
+		return []domain.GeofenceEvent{}, nil
+	}
+
+	events, err = s.repo.CreateGeofenceEvents(ctx, events)
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// truncCenter truncates the center of a circle geofence to fixed precision.
+func truncCenter(center *geo.Point) *geo.Point {
+	if center == nil {
+		return nil
+	}
+	truncated := geo.Trunc(*center)
+	return &truncated
+}
@@ -0,0 +1,493 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	mocklog "gitlab.com/spacewalker/geotracker/internal/pkg/log/mock"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util/pagination"
+)
+
+type GeofenceSvcTestSuite struct {
+	suite.Suite
+}
+
+func TestGeofenceSvcTestSuite(t *testing.T) {
+	// Skip tests when using "-short" flag.
+	if testing.Short() {
+		t.Skip("Skipping long-running tests")
+	}
+
+	suite.Run(t, new(GeofenceSvcTestSuite))
+}
+
+func (s *GeofenceSvcTestSuite) Test_GeofenceService_CreateGeofence() {
+	center := geo.Point{10.123456789, 20}
+	polygon := geo.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}}
+
+	testCases := []struct {
+		name       string
+		req        port.GeofenceServiceCreateGeofenceRequest
+		buildStubs func(repo *mock.MockGeofenceRepository)
+		assert     func(t *testing.T, res domain.Geofence, err error)
+	}{
+		{
+			name: "OK_Circle",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:      "office",
+				Type:      domain.GeofenceTypeCircle,
+				Center:    &center,
+				Radius:    100,
+				DwellTime: 60,
+			},
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				truncated := geo.Point{10.12345678, 20}
+				repo.EXPECT().
+					CreateGeofence(gomock.Any(), gomock.Eq(port.GeofenceRepositoryCreateGeofenceRequest{
+						Name:      "office",
+						Type:      domain.GeofenceTypeCircle,
+						Center:    &truncated,
+						Radius:    100,
+						DwellTime: 60,
+					})).
+					Times(1).
+					Return(domain.Geofence{ID: 1, Name: "office"}, nil)
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 1, res.ID)
+			},
+		},
+		{
+			name: "OK_Polygon",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:    "district",
+				Type:    domain.GeofenceTypePolygon,
+				Polygon: polygon,
+			},
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					CreateGeofence(gomock.Any(), gomock.Eq(port.GeofenceRepositoryCreateGeofenceRequest{
+						Name:    "district",
+						Type:    domain.GeofenceTypePolygon,
+						Polygon: polygon,
+					})).
+					Times(1).
+					Return(domain.Geofence{ID: 2, Name: "district"}, nil)
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.NoError(t, err)
+				require.Equal(t, 2, res.ID)
+			},
+		},
+		{
+			name: "AlreadyExists",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:    "district",
+				Type:    domain.GeofenceTypePolygon,
+				Polygon: polygon,
+			},
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					CreateGeofence(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(domain.Geofence{}, errpack.ErrAlreadyExists)
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrAlreadyExists)
+			},
+		},
+		{
+			name: "InvalidName",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Type:   domain.GeofenceTypeCircle,
+				Center: &center,
+				Radius: 100,
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidType",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:   "office",
+				Type:   "square",
+				Center: &center,
+				Radius: 100,
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "Circle_MissingCenter",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:   "office",
+				Type:   domain.GeofenceTypeCircle,
+				Radius: 100,
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "Circle_InvalidCenter",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:   "office",
+				Type:   domain.GeofenceTypeCircle,
+				Center: &geo.Point{0, 91},
+				Radius: 100,
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "Circle_ZeroRadius",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:   "office",
+				Type:   domain.GeofenceTypeCircle,
+				Center: &center,
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "Polygon_InvalidPolygon",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:    "district",
+				Type:    domain.GeofenceTypePolygon,
+				Polygon: geo.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "Polygon_WithCenter",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:    "district",
+				Type:    domain.GeofenceTypePolygon,
+				Polygon: polygon,
+				Center:  &center,
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "NegativeDwellTime",
+			req: port.GeofenceServiceCreateGeofenceRequest{
+				Name:      "district",
+				Type:      domain.GeofenceTypePolygon,
+				Polygon:   polygon,
+				DwellTime: -1,
+			},
+			assert: func(t *testing.T, res domain.Geofence, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockGeofenceRepository(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(repo)
+			}
+			svc := service.NewGeofenceService(repo, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.CreateGeofence(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *GeofenceSvcTestSuite) Test_GeofenceService_ListGeofenceEvents() {
+	testCases := []struct {
+		name       string
+		req        port.GeofenceServiceListGeofenceEventsRequest
+		buildStubs func(repo *mock.MockGeofenceRepository)
+		assert     func(t *testing.T, res port.GeofenceServiceListGeofenceEventsResponse, err error)
+	}{
+		{
+			name: "OK_PageSize",
+			req: port.GeofenceServiceListGeofenceEventsRequest{
+				GeofenceID: 3,
+				PageSize:   1,
+			},
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					ListGeofenceEvents(gomock.Any(), gomock.Eq(port.GeofenceRepositoryListGeofenceEventsRequest{
+						GeofenceID: 3,
+						PageSize:   1,
+					})).
+					Times(1).
+					Return(port.GeofenceRepositoryListGeofenceEventsResponse{
+						Events: []domain.GeofenceEvent{
+							{ID: 7, GeofenceID: 3, UserID: 1, Type: domain.GeofenceEventEnter},
+						},
+						NextPageToken: 7,
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.GeofenceServiceListGeofenceEventsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Events, 1)
+				require.Equal(t, domain.GeofenceEventEnter, res.Events[0].Type)
+				require.Equal(t, pagination.EncodeCursor(7, 1), res.NextPageToken)
+			},
+		},
+		{
+			name: "OK_PageToken_LastPage",
+			req: port.GeofenceServiceListGeofenceEventsRequest{
+				UserID:    1,
+				PageToken: pagination.EncodeCursor(7, 1),
+			},
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().
+					ListGeofenceEvents(gomock.Any(), gomock.Eq(port.GeofenceRepositoryListGeofenceEventsRequest{
+						UserID:    1,
+						PageToken: 7,
+						PageSize:  1,
+					})).
+					Times(1).
+					Return(port.GeofenceRepositoryListGeofenceEventsResponse{}, nil)
+			},
+			assert: func(t *testing.T, res port.GeofenceServiceListGeofenceEventsResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res.Events)
+				require.Empty(t, res.Events)
+				require.Empty(t, res.NextPageToken)
+			},
+		},
+		{
+			name: "PageTokenAndPageSizeBothNotProvided",
+			req:  port.GeofenceServiceListGeofenceEventsRequest{},
+			assert: func(t *testing.T, res port.GeofenceServiceListGeofenceEventsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidGeofenceID",
+			req: port.GeofenceServiceListGeofenceEventsRequest{
+				GeofenceID: -1,
+				PageSize:   1,
+			},
+			assert: func(t *testing.T, res port.GeofenceServiceListGeofenceEventsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockGeofenceRepository(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(repo)
+			}
+			svc := service.NewGeofenceService(repo, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.ListGeofenceEvents(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *GeofenceSvcTestSuite) Test_GeofenceService_EvaluateLocation() {
+	now := time.Now().UTC()
+	prevPoint := geo.Point{0, 0}
+	point := geo.Point{1, 1}
+
+	circle := domain.Geofence{ID: 1, Type: domain.GeofenceTypeCircle}
+	dwelling := domain.Geofence{ID: 2, Type: domain.GeofenceTypeCircle, DwellTime: 60}
+	polygon := domain.Geofence{ID: 3, Type: domain.GeofenceTypePolygon}
+
+	event := func(geofenceID int, typ domain.GeofenceEventType) domain.GeofenceEvent {
+		return domain.GeofenceEvent{GeofenceID: geofenceID, UserID: 5, Type: typ, Point: point, CreatedAt: now}
+	}
+
+	// returnCreated returns the events passed to CreateGeofenceEvents.
+	returnCreated := func(_ context.Context, events []domain.GeofenceEvent) ([]domain.GeofenceEvent, error) {
+		return events, nil
+	}
+
+	testCases := []struct {
+		name       string
+		prevPoint  *geo.Point
+		buildStubs func(repo *mock.MockGeofenceRepository)
+		assert     func(t *testing.T, res []domain.GeofenceEvent, err error)
+	}{
+		{
+			name:      "Enter_Exit",
+			prevPoint: &prevPoint,
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().ListGeofencesContaining(gomock.Any(), gomock.Eq(point)).
+					Return([]domain.Geofence{circle}, nil)
+				repo.EXPECT().ListGeofencesContaining(gomock.Any(), gomock.Eq(prevPoint)).
+					Return([]domain.Geofence{polygon}, nil)
+				repo.EXPECT().CreateGeofenceEvents(gomock.Any(), gomock.Eq([]domain.GeofenceEvent{
+					event(circle.ID, domain.GeofenceEventEnter),
+					event(polygon.ID, domain.GeofenceEventExit),
+				})).DoAndReturn(returnCreated)
+			},
+			assert: func(t *testing.T, res []domain.GeofenceEvent, err error) {
+				require.NoError(t, err)
+				require.Len(t, res, 2)
+			},
+		},
+		{
+			name: "FirstLocation_Enter",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().ListGeofencesContaining(gomock.Any(), gomock.Eq(point)).
+					Return([]domain.Geofence{circle, dwelling}, nil)
+				repo.EXPECT().CreateGeofenceEvents(gomock.Any(), gomock.Eq([]domain.GeofenceEvent{
+					event(circle.ID, domain.GeofenceEventEnter),
+					event(dwelling.ID, domain.GeofenceEventEnter),
+				})).DoAndReturn(returnCreated)
+			},
+			assert: func(t *testing.T, res []domain.GeofenceEvent, err error) {
+				require.NoError(t, err)
+				require.Len(t, res, 2)
+			},
+		},
+		{
+			name:      "Stay_NoEvents",
+			prevPoint: &prevPoint,
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().ListGeofencesContaining(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return([]domain.Geofence{circle, polygon}, nil)
+				repo.EXPECT().ListGeofencePresences(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				repo.EXPECT().CreateGeofenceEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res []domain.GeofenceEvent, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res)
+			},
+		},
+		{
+			name:      "Dwell",
+			prevPoint: &prevPoint,
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().ListGeofencesContaining(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return([]domain.Geofence{dwelling}, nil)
+				repo.EXPECT().ListGeofencePresences(gomock.Any(), gomock.Eq(5), gomock.Eq([]int{dwelling.ID})).
+					Return([]domain.GeofencePresence{
+						{GeofenceID: dwelling.ID, UserID: 5, EnteredAt: now.Add(-time.Minute)},
+					}, nil)
+				repo.EXPECT().CreateGeofenceEvents(gomock.Any(), gomock.Eq([]domain.GeofenceEvent{
+					event(dwelling.ID, domain.GeofenceEventDwell),
+				})).DoAndReturn(returnCreated)
+			},
+			assert: func(t *testing.T, res []domain.GeofenceEvent, err error) {
+				require.NoError(t, err)
+				require.Len(t, res, 1)
+				require.Equal(t, domain.GeofenceEventDwell, res[0].Type)
+			},
+		},
+		{
+			name:      "Dwell_TooEarly",
+			prevPoint: &prevPoint,
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().ListGeofencesContaining(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return([]domain.Geofence{dwelling}, nil)
+				repo.EXPECT().ListGeofencePresences(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]domain.GeofencePresence{
+						{GeofenceID: dwelling.ID, UserID: 5, EnteredAt: now.Add(-59 * time.Second)},
+					}, nil)
+				repo.EXPECT().CreateGeofenceEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res []domain.GeofenceEvent, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res)
+			},
+		},
+		{
+			name:      "Dwell_AlreadyDwelled",
+			prevPoint: &prevPoint,
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().ListGeofencesContaining(gomock.Any(), gomock.Any()).
+					Times(2).
+					Return([]domain.Geofence{dwelling}, nil)
+				repo.EXPECT().ListGeofencePresences(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return([]domain.GeofencePresence{
+						{GeofenceID: dwelling.ID, UserID: 5, EnteredAt: now.Add(-time.Hour), Dwelled: true},
+					}, nil)
+				repo.EXPECT().CreateGeofenceEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res []domain.GeofenceEvent, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res)
+			},
+		},
+		{
+			name: "InternalError",
+			buildStubs: func(repo *mock.MockGeofenceRepository) {
+				repo.EXPECT().ListGeofencesContaining(gomock.Any(), gomock.Any()).
+					Return(nil, errpack.ErrInternalError)
+			},
+			assert: func(t *testing.T, res []domain.GeofenceEvent, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInternalError)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockGeofenceRepository(ctrl)
+			tc.buildStubs(repo)
+			logger := mocklog.NewMockLogger(ctrl)
+			logger.EXPECT().Error(gomock.Any(), gomock.Any()).AnyTimes()
+			svc := service.NewGeofenceService(repo, logger)
+
+			res, err := svc.EvaluateLocation(context.Background(), port.GeofenceServiceEvaluateLocationRequest{
+				UserID:    5,
+				PrevPoint: tc.prevPoint,
+				Point:     point,
+				Timestamp: now,
+			})
+
+			tc.assert(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+const (
+	// DefaultIdempotencyKeyTTL is how long idempotency keys are remembered if `IdempotencyKeyTTL` is not configured.
+	DefaultIdempotencyKeyTTL = 24 * time.Hour
+	// DefaultIdempotencyKeyLease is how long requests in progress hold their keys if `IdempotencyKeyLease` is not configured.
+	DefaultIdempotencyKeyLease = time.Minute
+	// idempotencyKeyReleaseTimeout bounds releasing keys of failed requests.
+	idempotencyKeyReleaseTimeout = 5 * time.Second
+)
+
+var (
+	// errIdempotencyKeyReused is returned for requests sent with a key used with another request.
+	errIdempotencyKeyReused = fmt.Errorf("%w: idempotency key is already used with another request", errpack.ErrFailedPrecondition)
+	// errIdempotencyKeyInProgress is returned for retries of requests that are not completed yet.
+	errIdempotencyKeyInProgress = fmt.Errorf("%w: request with the idempotency key is in progress", errpack.ErrAlreadyExists)
+)
+
+// requestHash returns the hex encoded SHA-256 hash of the JSON encoded request.
+func requestHash(req interface{}) (string, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// idempotencyKeyTTL returns the configured TTL of idempotency keys or the default one if it is not configured.
+func (s *userService) idempotencyKeyTTL() time.Duration {
+	if s.config.IdempotencyKeyTTL > 0 {
+		return s.config.IdempotencyKeyTTL
+	}
+	return DefaultIdempotencyKeyTTL
+}
+
+// idempotencyKeyLease returns the configured lease of idempotency keys or the default one if it is not configured.
+func (s *userService) idempotencyKeyLease() time.Duration {
+	if s.config.IdempotencyKeyLease > 0 {
+		return s.config.IdempotencyKeyLease
+	}
+	return DefaultIdempotencyKeyLease
+}
+
+// releaseIdempotencyKey releases the key of a failed request. The release is not bound to ctx,
+// since the request may fail because its client is gone, and the key would stay reserved otherwise.
+func (s *userService) releaseIdempotencyKey(ctx context.Context, username, key string, req interface{}) {
+	releaseCtx, cancel := context.WithTimeout(context.Background(), idempotencyKeyReleaseTimeout)
+	defer cancel()
+
+	util.LogInternalError(ctx, s.logger, s.repo.ReleaseIdempotencyKey(releaseCtx, username, key), req)
+}
+
+// idempotent executes fn, which sets the value res points to, unless the user sent the request with the key before.
+// In that case the value is set to the remembered response of that request instead. fn is executed as is if the key is empty.
+//
+// The response is remembered only if fn succeeds, otherwise the key is released, so that the request may be retried.
+// Keys of requests that are not completed within the lease, e.g. because the process crashed, are reserved again by retries.
+//
+// `ErrFailedPrecondition` is returned in case the key was sent with another request.
+//
+// `ErrAlreadyExists` is returned in case the request sent with the key before is still in progress.
+func (s *userService) idempotent(ctx context.Context, username, key string, req, res interface{}, fn func() error) error {
+	if key == "" {
+		return fn()
+	}
+
+	hash, err := requestHash(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	}
+
+	reservation, err := s.repo.ReserveIdempotencyKey(ctx, port.UserRepositoryReserveIdempotencyKeyRequest{
+		Username:    username,
+		Key:         key,
+		RequestHash: hash,
+		TTL:         s.idempotencyKeyTTL(),
+		Lease:       s.idempotencyKeyLease(),
+	})
+	if err != nil {
+		return err
+	}
+
+	if !reservation.Reserved {
+		if reservation.Key.RequestHash != hash {
+			return errIdempotencyKeyReused
+		}
+		if reservation.Key.Response == nil {
+			return errIdempotencyKeyInProgress
+		}
+		if err = json.Unmarshal(reservation.Key.Response, res); err != nil {
+			return fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+		}
+		return nil
+	}
+
+	if err = fn(); err != nil {
+		s.releaseIdempotencyKey(ctx, username, key, req)
+		return err
+	}
+
+	// The request is already executed, so failing to remember the response only makes retries execute it again.
+	response, err := json.Marshal(res)
+	if err != nil {
+		err = fmt.Errorf("%w: %v", errpack.ErrInternalError, err)
+	} else {
+		err = s.repo.CompleteIdempotencyKey(ctx, port.UserRepositoryCompleteIdempotencyKeyRequest{
+			Username: username,
+			Key:      key,
+			Response: response,
+		})
+	}
+	if err != nil {
+		util.LogInternalError(ctx, s.logger, err, req)
+		s.releaseIdempotencyKey(ctx, username, key, req)
+	}
+
+	return nil
+}
+
+// DeleteExpiredIdempotencyKeys deletes idempotency keys remembered longer than their TTL.
+//
+// `ErrInternalError` is returned in case of any failure.
+func (s *userService) DeleteExpiredIdempotencyKeys(ctx context.Context) error {
+	_, err := s.repo.DeleteExpiredIdempotencyKeys(ctx)
+	return err
+}
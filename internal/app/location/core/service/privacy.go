@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+// GetPrivacySettings finds privacy settings of the user by username.
+//
+// The default settings of the exact level are returned if the user has never changed them.
+//
+// `ErrInvalidArgument` is returned in case username is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// Any other error occurred in `GetPrivacySettings` is returned.
+func (s *userService) GetPrivacySettings(ctx context.Context, username string) (domain.PrivacySettings, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, username)
+	}()
+
+	if err = validate.Var(username, "required,validusername"); err != nil {
+		return domain.PrivacySettings{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, username); err != nil {
+		return domain.PrivacySettings{}, err
+	}
+
+	settings, err := s.repo.GetPrivacySettings(ctx, username)
+	if err != nil {
+		return domain.PrivacySettings{}, err
+	}
+
+	return settings, nil
+}
+
+// SetPrivacySettings replaces privacy settings of the user.
+//
+// The settings apply to every location search, the user details and location updates
+// published after the change, the stored location itself is kept as it is.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// Any other error occurred in `SetPrivacySettings` is returned.
+func (s *userService) SetPrivacySettings(ctx context.Context, req port.UserServiceSetPrivacySettingsRequest) (domain.PrivacySettings, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return domain.PrivacySettings{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+		return domain.PrivacySettings{}, err
+	}
+
+	settings, err := s.repo.SetPrivacySettings(ctx, port.UserRepositorySetPrivacySettingsRequest{
+		Username:         req.Username,
+		Level:            req.Level,
+		GridSize:         req.GridSize,
+		GeohashPrecision: req.GeohashPrecision,
+	})
+	if err != nil {
+		return domain.PrivacySettings{}, err
+	}
+
+	return settings, nil
+}
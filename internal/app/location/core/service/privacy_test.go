@@ -0,0 +1,301 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+	mocklog "gitlab.com/spacewalker/geotracker/internal/pkg/log/mock"
+)
+
+func (s *UserSvcTestSuite) Test_UserService_SetPrivacySettings() {
+	testCases := []struct {
+		name       string
+		req        port.UserServiceSetPrivacySettingsRequest
+		buildStubs func(repo *mock.MockUserRepository, logger *mocklog.MockLogger)
+		assert     func(t *testing.T, res domain.PrivacySettings, err error)
+	}{
+		{
+			name: "OK_Coarse",
+			req: port.UserServiceSetPrivacySettingsRequest{
+				Username: "user1",
+				Level:    domain.PrivacyLevelCoarse,
+				GridSize: 0.5,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					SetPrivacySettings(gomock.Any(), gomock.Eq(port.UserRepositorySetPrivacySettingsRequest{
+						Username: "user1",
+						Level:    domain.PrivacyLevelCoarse,
+						GridSize: 0.5,
+					})).
+					Times(1).
+					Return(domain.PrivacySettings{UserID: 1, Level: domain.PrivacyLevelCoarse, GridSize: 0.5}, nil)
+			},
+			assert: func(t *testing.T, res domain.PrivacySettings, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.PrivacySettings{UserID: 1, Level: domain.PrivacyLevelCoarse, GridSize: 0.5}, res)
+			},
+		},
+		{
+			name: "OK_Hidden",
+			req: port.UserServiceSetPrivacySettingsRequest{
+				Username: "user1",
+				Level:    domain.PrivacyLevelHidden,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					SetPrivacySettings(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(domain.PrivacySettings{UserID: 1, Level: domain.PrivacyLevelHidden}, nil)
+			},
+			assert: func(t *testing.T, res domain.PrivacySettings, err error) {
+				require.NoError(t, err)
+				require.Equal(t, domain.PrivacyLevelHidden, res.Level)
+			},
+		},
+		{
+			name: "InvalidLevel",
+			req: port.UserServiceSetPrivacySettingsRequest{
+				Username: "user1",
+				Level:    "fuzzy",
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().SetPrivacySettings(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res domain.PrivacySettings, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "CoarseWithoutCells",
+			req: port.UserServiceSetPrivacySettingsRequest{
+				Username: "user1",
+				Level:    domain.PrivacyLevelCoarse,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().SetPrivacySettings(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res domain.PrivacySettings, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "CoarseWithBothCells",
+			req: port.UserServiceSetPrivacySettingsRequest{
+				Username:         "user1",
+				Level:            domain.PrivacyLevelCoarse,
+				GridSize:         0.5,
+				GeohashPrecision: 5,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().SetPrivacySettings(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res domain.PrivacySettings, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "ExactWithCells",
+			req: port.UserServiceSetPrivacySettingsRequest{
+				Username:         "user1",
+				Level:            domain.PrivacyLevelExact,
+				GeohashPrecision: 5,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().SetPrivacySettings(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res domain.PrivacySettings, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "NotFound",
+			req: port.UserServiceSetPrivacySettingsRequest{
+				Username: "user1",
+				Level:    domain.PrivacyLevelExact,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					SetPrivacySettings(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(domain.PrivacySettings{}, errpack.ErrNotFound)
+			},
+			assert: func(t *testing.T, res domain.PrivacySettings, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrNotFound)
+			},
+		},
+		{
+			name: "InternalError",
+			req: port.UserServiceSetPrivacySettingsRequest{
+				Username: "user1",
+				Level:    domain.PrivacyLevelExact,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					SetPrivacySettings(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(domain.PrivacySettings{}, errpack.ErrInternalError)
+				logger.EXPECT().Error(gomock.Any(), gomock.Any()).Times(1)
+			},
+			assert: func(t *testing.T, res domain.PrivacySettings, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInternalError)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			logger := mocklog.NewMockLogger(ctrl)
+			tc.buildStubs(repo, logger)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, logger)
+
+			res, err := svc.SetPrivacySettings(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_GetPrivacySettings() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	repo := mock.NewMockUserRepository(ctrl)
+	repo.EXPECT().
+		GetPrivacySettings(gomock.Any(), gomock.Eq("user1")).
+		Times(1).
+		Return(domain.PrivacySettings{UserID: 1, Level: domain.PrivacyLevelExact}, nil)
+	svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+	res, err := svc.GetPrivacySettings(context.Background(), "user1")
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), domain.PrivacySettings{UserID: 1, Level: domain.PrivacyLevelExact}, res)
+
+	_, err = svc.GetPrivacySettings(context.Background(), "")
+	require.ErrorIs(s.T(), err, errpack.ErrInvalidArgument)
+}
+
+func (s *UserSvcTestSuite) Test_UserService_GetUser_Privacy() {
+	user := domain.User{ID: 1, Username: "user1"}
+	location := domain.Location{
+		UserID:    user.ID,
+		Point:     geo.Point{10.123, 20.456},
+		UpdatedAt: time.Now(),
+	}
+
+	testCases := []struct {
+		name    string
+		privacy *domain.PrivacySettings
+		assert  func(t *testing.T, res port.UserServiceGetUserResponse)
+	}{
+		{
+			name:    "Exact",
+			privacy: &domain.PrivacySettings{UserID: user.ID, Level: domain.PrivacyLevelExact},
+			assert: func(t *testing.T, res port.UserServiceGetUserResponse) {
+				require.Equal(t, &location, res.Location)
+				require.NotNil(t, res.LastSeenAt)
+			},
+		},
+		{
+			name:    "CoarseGeohash",
+			privacy: &domain.PrivacySettings{UserID: user.ID, Level: domain.PrivacyLevelCoarse, GeohashPrecision: 4},
+			assert: func(t *testing.T, res port.UserServiceGetUserResponse) {
+				require.NotNil(t, res.Location)
+				require.Equal(t, geo.Trunc(geo.SnapToGeohash(location.Point, 4)), res.Location.Point)
+				require.NotEqual(t, location.Point, res.Location.Point)
+				require.Equal(t, location.UpdatedAt, res.Location.UpdatedAt)
+				require.NotNil(t, res.LastSeenAt)
+			},
+		},
+		{
+			name:    "Hidden",
+			privacy: &domain.PrivacySettings{UserID: user.ID, Level: domain.PrivacyLevelHidden},
+			assert: func(t *testing.T, res port.UserServiceGetUserResponse) {
+				require.Equal(t, user, res.User)
+				require.Nil(t, res.Location)
+				require.Nil(t, res.LastSeenAt)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			repo.EXPECT().
+				GetUserWithLocation(gomock.Any(), gomock.Eq(user.Username)).
+				Times(1).
+				Return(port.UserRepositoryGetUserWithLocationResponse{User: user, Location: &location, Privacy: tc.privacy}, nil)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.GetUser(context.Background(), user.Username)
+			require.NoError(t, err)
+
+			tc.assert(t, res)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocation_PublishPrivacy() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	user := domain.User{ID: 1, Username: "user1"}
+	location := domain.Location{UserID: user.ID, Point: geo.Point{10.3, 20.7}, UpdatedAt: time.Now()}
+
+	repo := mock.NewMockUserRepository(ctrl)
+	repo.EXPECT().
+		SetUserLocation(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositorySetUserLocationResponse{
+			User:     user,
+			Location: location,
+			Privacy:  &domain.PrivacySettings{UserID: user.ID, Level: domain.PrivacyLevelCoarse, GridSize: 1},
+		}, nil)
+	// Hidden locations are not published.
+	repo.EXPECT().
+		SetUserLocation(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositorySetUserLocationResponse{
+			User:     user,
+			Location: location,
+			Privacy:  &domain.PrivacySettings{UserID: user.ID, Level: domain.PrivacyLevelHidden},
+		}, nil)
+	geofenceService := mock.NewMockGeofenceService(ctrl)
+	geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).Times(2)
+	broker := mock.NewMockLocationBroker(ctrl)
+	broker.EXPECT().
+		Publish(gomock.Eq(domain.LocatedUser{User: user, Point: geo.Point{10.5, 20.5}, LocationUpdatedAt: location.UpdatedAt})).
+		Times(1)
+
+	svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), geofenceService, broker, service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+	req := port.UserServiceSetUserLocationRequest{Username: user.Username, Longitude: 10.3, Latitude: 20.7}
+	_, err := svc.SetUserLocation(context.Background(), req)
+	require.NoError(s.T(), err)
+	_, err = svc.SetUserLocation(context.Background(), req)
+	require.NoError(s.T(), err)
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	log2 "log"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/log"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+type socialService struct {
+	repo   port.SocialRepository
+	logger log.Logger
+}
+
+// NewSocialService creates instance of SocialService and returns its pointer.
+func NewSocialService(repo port.SocialRepository, logger log.Logger) port.SocialService {
+	if logger == nil {
+		log2.Panic("logger must not be nil")
+	}
+	if repo == nil {
+		logger.Panic("repo must not be nil", nil)
+	}
+
+	return &socialService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// FollowUser creates a pending follow request of the user to the followee.
+// The followee becomes a connection of the user once the request is accepted.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid, e.g. the user follows itself.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// `ErrNotFound` is returned in case any of the users is not found.
+//
+// `ErrAlreadyExists` is returned in case the user already follows or requested to follow the followee.
+//
+// `ErrFailedPrecondition` is returned in case any of the users blocked the other one.
+//
+// Any other error occurred in `CreateFollow` is returned.
+func (s *socialService) FollowUser(ctx context.Context, req port.SocialServiceFollowUserRequest) (domain.Follow, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return domain.Follow{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+		return domain.Follow{}, err
+	}
+
+	follow, err := s.repo.CreateFollow(ctx, port.SocialRepositoryFollowRequest{
+		Follower: req.Username,
+		Followee: req.Followee,
+	})
+	if err != nil {
+		return domain.Follow{}, err
+	}
+
+	return follow, nil
+}
+
+// AcceptFollower accepts the follow request of the follower to the user.
+// Accepting an accepted request changes nothing.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// `ErrNotFound` is returned in case the follow request is not found.
+//
+// Any other error occurred in `AcceptFollow` is returned.
+func (s *socialService) AcceptFollower(ctx context.Context, req port.SocialServiceAcceptFollowerRequest) (domain.Follow, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return domain.Follow{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+		return domain.Follow{}, err
+	}
+
+	follow, err := s.repo.AcceptFollow(ctx, port.SocialRepositoryFollowRequest{
+		Follower: req.Follower,
+		Followee: req.Username,
+	})
+	if err != nil {
+		return domain.Follow{}, err
+	}
+
+	return follow, nil
+}
+
+// DeleteFollow deletes a follow request whether it is accepted or not.
+// It is used by the follower to unfollow or cancel the request and by the followee to revoke or decline it.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may act on behalf of neither the follower nor the followee.
+//
+// `ErrNotFound` is returned in case the follow request is not found.
+//
+// Any other error occurred in `DeleteFollow` is returned.
+func (s *socialService) DeleteFollow(ctx context.Context, req port.SocialServiceDeleteFollowRequest) error {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, req.Follower); err != nil {
+		if err = auth.AuthorizeUser(ctx, req.Followee); err != nil {
+			return err
+		}
+	}
+
+	err = s.repo.DeleteFollow(ctx, port.SocialRepositoryFollowRequest{
+		Follower: req.Follower,
+		Followee: req.Followee,
+	})
+	return err
+}
+
+// BlockUser blocks the user with `Blocked` by the user, follow requests between them are deleted
+// in both directions and new ones are rejected until the block is deleted.
+// Blocking a blocked user changes nothing.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid, e.g. the user blocks itself.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// `ErrNotFound` is returned in case any of the users is not found.
+//
+// Any other error occurred in `CreateBlock` is returned.
+func (s *socialService) BlockUser(ctx context.Context, req port.SocialServiceBlockUserRequest) (domain.Block, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return domain.Block{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+		return domain.Block{}, err
+	}
+
+	block, err := s.repo.CreateBlock(ctx, port.SocialRepositoryBlockRequest{
+		Blocker: req.Username,
+		Blocked: req.Blocked,
+	})
+	if err != nil {
+		return domain.Block{}, err
+	}
+
+	return block, nil
+}
+
+// UnblockUser deletes the block of the user with `Blocked` by the user.
+// Follow requests deleted by the block are not restored.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// `ErrNotFound` is returned in case the block is not found.
+//
+// Any other error occurred in `DeleteBlock` is returned.
+func (s *socialService) UnblockUser(ctx context.Context, req port.SocialServiceBlockUserRequest) error {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+		return err
+	}
+
+	err = s.repo.DeleteBlock(ctx, port.SocialRepositoryBlockRequest{
+		Blocker: req.Username,
+		Blocked: req.Blocked,
+	})
+	return err
+}
+
+// ListFollowing lists follow requests of the user ordered by ID of the followee, optionally filtered by status.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// Any other error occurred in `ListFollowing` is returned.
+func (s *socialService) ListFollowing(ctx context.Context, req port.SocialServiceListFollowsRequest) (port.SocialServiceListFollowsResponse, error) {
+	return s.listFollows(ctx, req, s.repo.ListFollowing)
+}
+
+// ListFollowers lists follow requests to the user ordered by ID of the follower, optionally filtered by status.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// Any other error occurred in `ListFollowers` is returned.
+func (s *socialService) ListFollowers(ctx context.Context, req port.SocialServiceListFollowsRequest) (port.SocialServiceListFollowsResponse, error) {
+	return s.listFollows(ctx, req, s.repo.ListFollowers)
+}
+
+// listFollows lists follows with given repository method.
+func (s *socialService) listFollows(
+	ctx context.Context,
+	req port.SocialServiceListFollowsRequest,
+	list func(context.Context, port.SocialRepositoryListFollowsRequest) (port.SocialRepositoryListFollowsResponse, error),
+) (port.SocialServiceListFollowsResponse, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return port.SocialServiceListFollowsResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+		return port.SocialServiceListFollowsResponse{}, err
+	}
+
+	pageToken, pageSize, err := decodePageToken(req.PageToken, req.PageSize)
+	if err != nil {
+		return port.SocialServiceListFollowsResponse{}, err
+	}
+
+	res, err := list(ctx, port.SocialRepositoryListFollowsRequest{
+		Username:  req.Username,
+		Status:    req.Status,
+		PageToken: pageToken,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		return port.SocialServiceListFollowsResponse{}, err
+	}
+
+	if res.Follows == nil {
+		res.Follows = make([]domain.Follow, 0)
+	}
+
+	return port.SocialServiceListFollowsResponse{
+		Follows:       res.Follows,
+		NextPageToken: encodePageToken(res.NextPageToken, pageSize),
+	}, nil
+}
+
+// ListBlocks lists blocks by the user ordered by ID of the blocked user.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// Any other error occurred in `ListBlocks` is returned.
+func (s *socialService) ListBlocks(ctx context.Context, req port.SocialServiceListBlocksRequest) (port.SocialServiceListBlocksResponse, error) {
+	var err error
+	defer func() {
+		util.LogInternalError(ctx, s.logger, err, req)
+	}()
+
+	if err = validate.Struct(req); err != nil {
+		return port.SocialServiceListBlocksResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+	}
+
+	if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+		return port.SocialServiceListBlocksResponse{}, err
+	}
+
+	pageToken, pageSize, err := decodePageToken(req.PageToken, req.PageSize)
+	if err != nil {
+		return port.SocialServiceListBlocksResponse{}, err
+	}
+
+	res, err := s.repo.ListBlocks(ctx, port.SocialRepositoryListBlocksRequest{
+		Username:  req.Username,
+		PageToken: pageToken,
+		PageSize:  pageSize,
+	})
+	if err != nil {
+		return port.SocialServiceListBlocksResponse{}, err
+	}
+
+	if res.Blocks == nil {
+		res.Blocks = make([]domain.Block, 0)
+	}
+
+	return port.SocialServiceListBlocksResponse{
+		Blocks:        res.Blocks,
+		NextPageToken: encodePageToken(res.NextPageToken, pageSize),
+	}, nil
+}
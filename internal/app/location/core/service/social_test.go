@@ -0,0 +1,396 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	mocklog "gitlab.com/spacewalker/geotracker/internal/pkg/log/mock"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util/pagination"
+)
+
+type SocialSvcTestSuite struct {
+	suite.Suite
+}
+
+func TestSocialSvcTestSuite(t *testing.T) {
+	// Skip tests when using "-short" flag.
+	if testing.Short() {
+		t.Skip("Skipping long-running tests")
+	}
+
+	suite.Run(t, new(SocialSvcTestSuite))
+}
+
+func (s *SocialSvcTestSuite) Test_SocialService_FollowUser() {
+	follow := domain.Follow{
+		FollowerID: 1,
+		Follower:   "user1",
+		FolloweeID: 2,
+		Followee:   "user2",
+		Status:     domain.FollowPending,
+	}
+
+	testCases := []struct {
+		name       string
+		req        port.SocialServiceFollowUserRequest
+		buildStubs func(repo *mock.MockSocialRepository)
+		isError    error
+	}{
+		{
+			name: "OK",
+			req:  port.SocialServiceFollowUserRequest{Username: "user1", Followee: "user2"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					CreateFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+					Times(1).
+					Return(follow, nil)
+			},
+		},
+		{
+			name: "FollowSelf",
+			req:  port.SocialServiceFollowUserRequest{Username: "user1", Followee: "user1"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateFollow(gomock.Any(), gomock.Any()).Times(0)
+			},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name: "InvalidFollowee",
+			req:  port.SocialServiceFollowUserRequest{Username: "user1", Followee: "a"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateFollow(gomock.Any(), gomock.Any()).Times(0)
+			},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name: "AlreadyExists",
+			req:  port.SocialServiceFollowUserRequest{Username: "user1", Followee: "user2"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateFollow(gomock.Any(), gomock.Any()).Times(1).Return(domain.Follow{}, errpack.ErrAlreadyExists)
+			},
+			isError: errpack.ErrAlreadyExists,
+		},
+		{
+			name: "Blocked",
+			req:  port.SocialServiceFollowUserRequest{Username: "user1", Followee: "user2"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateFollow(gomock.Any(), gomock.Any()).Times(1).Return(domain.Follow{}, errpack.ErrFailedPrecondition)
+			},
+			isError: errpack.ErrFailedPrecondition,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockSocialRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewSocialService(repo, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.FollowUser(context.Background(), tc.req)
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
+				require.Empty(t, res)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, follow, res)
+		})
+	}
+}
+
+func (s *SocialSvcTestSuite) Test_SocialService_AcceptFollower() {
+	follow := domain.Follow{
+		FollowerID: 2,
+		Follower:   "user2",
+		FolloweeID: 1,
+		Followee:   "user1",
+		Status:     domain.FollowAccepted,
+	}
+
+	testCases := []struct {
+		name       string
+		req        port.SocialServiceAcceptFollowerRequest
+		buildStubs func(repo *mock.MockSocialRepository)
+		isError    error
+	}{
+		{
+			name: "OK",
+			req:  port.SocialServiceAcceptFollowerRequest{Username: "user1", Follower: "user2"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					AcceptFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user2", Followee: "user1"})).
+					Times(1).
+					Return(follow, nil)
+			},
+		},
+		{
+			name: "InvalidFollower",
+			req:  port.SocialServiceAcceptFollowerRequest{Username: "user1"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().AcceptFollow(gomock.Any(), gomock.Any()).Times(0)
+			},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name: "NotFound",
+			req:  port.SocialServiceAcceptFollowerRequest{Username: "user1", Follower: "user2"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().AcceptFollow(gomock.Any(), gomock.Any()).Times(1).Return(domain.Follow{}, errpack.ErrNotFound)
+			},
+			isError: errpack.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockSocialRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewSocialService(repo, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.AcceptFollower(context.Background(), tc.req)
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
+				require.Empty(t, res)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, follow, res)
+		})
+	}
+}
+
+func (s *SocialSvcTestSuite) Test_SocialService_BlockUser() {
+	block := domain.Block{BlockerID: 1, Blocker: "user1", BlockedID: 2, Blocked: "user2"}
+
+	testCases := []struct {
+		name       string
+		req        port.SocialServiceBlockUserRequest
+		buildStubs func(repo *mock.MockSocialRepository)
+		isError    error
+	}{
+		{
+			name: "OK",
+			req:  port.SocialServiceBlockUserRequest{Username: "user1", Blocked: "user2"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					CreateBlock(gomock.Any(), gomock.Eq(port.SocialRepositoryBlockRequest{Blocker: "user1", Blocked: "user2"})).
+					Times(1).
+					Return(block, nil)
+			},
+		},
+		{
+			name: "BlockSelf",
+			req:  port.SocialServiceBlockUserRequest{Username: "user1", Blocked: "user1"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateBlock(gomock.Any(), gomock.Any()).Times(0)
+			},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name: "NotFound",
+			req:  port.SocialServiceBlockUserRequest{Username: "user1", Blocked: "user2"},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().CreateBlock(gomock.Any(), gomock.Any()).Times(1).Return(domain.Block{}, errpack.ErrNotFound)
+			},
+			isError: errpack.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockSocialRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewSocialService(repo, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.BlockUser(context.Background(), tc.req)
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
+				require.Empty(t, res)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, block, res)
+		})
+	}
+}
+
+func (s *SocialSvcTestSuite) Test_SocialService_DeleteFollowAndUnblockUser() {
+	s.T().Run("DeleteFollow", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mock.NewMockSocialRepository(ctrl)
+		repo.EXPECT().
+			DeleteFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+			Times(1).
+			Return(nil)
+		repo.EXPECT().
+			DeleteFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user2", Followee: "user1"})).
+			Times(1).
+			Return(errpack.ErrNotFound)
+		svc := service.NewSocialService(repo, mocklog.NewMockLogger(ctrl))
+
+		require.NoError(t, svc.DeleteFollow(context.Background(), port.SocialServiceDeleteFollowRequest{Follower: "user1", Followee: "user2"}))
+		require.ErrorIs(t, svc.DeleteFollow(context.Background(), port.SocialServiceDeleteFollowRequest{Follower: "user2", Followee: "user1"}), errpack.ErrNotFound)
+		require.ErrorIs(t, svc.DeleteFollow(context.Background(), port.SocialServiceDeleteFollowRequest{Follower: "user1"}), errpack.ErrInvalidArgument)
+	})
+
+	s.T().Run("UnblockUser", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		repo := mock.NewMockSocialRepository(ctrl)
+		repo.EXPECT().
+			DeleteBlock(gomock.Any(), gomock.Eq(port.SocialRepositoryBlockRequest{Blocker: "user1", Blocked: "user2"})).
+			Times(1).
+			Return(nil)
+		svc := service.NewSocialService(repo, mocklog.NewMockLogger(ctrl))
+
+		require.NoError(t, svc.UnblockUser(context.Background(), port.SocialServiceBlockUserRequest{Username: "user1", Blocked: "user2"}))
+		require.ErrorIs(t, svc.UnblockUser(context.Background(), port.SocialServiceBlockUserRequest{Username: "user1", Blocked: "user1"}), errpack.ErrInvalidArgument)
+	})
+}
+
+func (s *SocialSvcTestSuite) Test_SocialService_Authorization() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	repo := mock.NewMockSocialRepository(ctrl)
+	repo.EXPECT().
+		DeleteFollow(gomock.Any(), gomock.Eq(port.SocialRepositoryFollowRequest{Follower: "user1", Followee: "user2"})).
+		Times(1).
+		Return(nil)
+	repo.EXPECT().CreateFollow(gomock.Any(), gomock.Any()).Times(0)
+	repo.EXPECT().CreateBlock(gomock.Any(), gomock.Any()).Times(0)
+	svc := service.NewSocialService(repo, mocklog.NewMockLogger(ctrl))
+
+	ctx := auth.AddPrincipalToCtx(context.Background(), auth.Principal{Subject: "user2", Roles: []auth.Role{auth.RoleUser}})
+
+	// The followee may decline the request.
+	require.NoError(s.T(), svc.DeleteFollow(ctx, port.SocialServiceDeleteFollowRequest{Follower: "user1", Followee: "user2"}))
+	require.ErrorIs(s.T(), svc.DeleteFollow(ctx, port.SocialServiceDeleteFollowRequest{Follower: "user1", Followee: "user3"}), errpack.ErrPermissionDenied)
+
+	_, err := svc.FollowUser(ctx, port.SocialServiceFollowUserRequest{Username: "user1", Followee: "user3"})
+	require.ErrorIs(s.T(), err, errpack.ErrPermissionDenied)
+
+	_, err = svc.BlockUser(ctx, port.SocialServiceBlockUserRequest{Username: "user1", Blocked: "user2"})
+	require.ErrorIs(s.T(), err, errpack.ErrPermissionDenied)
+}
+
+func (s *SocialSvcTestSuite) Test_SocialService_ListFollowing() {
+	testCases := []struct {
+		name       string
+		req        port.SocialServiceListFollowsRequest
+		buildStubs func(repo *mock.MockSocialRepository)
+		assert     func(t *testing.T, res port.SocialServiceListFollowsResponse, err error)
+	}{
+		{
+			name: "OK_PageSize",
+			req: port.SocialServiceListFollowsRequest{
+				Username: "user1",
+				Status:   domain.FollowAccepted,
+				PageSize: 1,
+			},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					ListFollowing(gomock.Any(), gomock.Eq(port.SocialRepositoryListFollowsRequest{
+						Username: "user1",
+						Status:   domain.FollowAccepted,
+						PageSize: 1,
+					})).
+					Times(1).
+					Return(port.SocialRepositoryListFollowsResponse{
+						Follows:       []domain.Follow{{FollowerID: 1, FolloweeID: 3, Status: domain.FollowAccepted}},
+						NextPageToken: 3,
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.SocialServiceListFollowsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Follows, 1)
+				require.Equal(t, pagination.EncodeCursor(3, 1), res.NextPageToken)
+			},
+		},
+		{
+			name: "OK_PageToken_LastPage",
+			req: port.SocialServiceListFollowsRequest{
+				Username:  "user1",
+				PageToken: pagination.EncodeCursor(3, 1),
+			},
+			buildStubs: func(repo *mock.MockSocialRepository) {
+				repo.EXPECT().
+					ListFollowing(gomock.Any(), gomock.Eq(port.SocialRepositoryListFollowsRequest{
+						Username:  "user1",
+						PageToken: 3,
+						PageSize:  1,
+					})).
+					Times(1).
+					Return(port.SocialRepositoryListFollowsResponse{}, nil)
+			},
+			assert: func(t *testing.T, res port.SocialServiceListFollowsResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res.Follows)
+				require.Empty(t, res.Follows)
+				require.Empty(t, res.NextPageToken)
+			},
+		},
+		{
+			name: "InvalidStatus",
+			req: port.SocialServiceListFollowsRequest{
+				Username: "user1",
+				Status:   "rejected",
+				PageSize: 1,
+			},
+			assert: func(t *testing.T, res port.SocialServiceListFollowsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "PageTokenAndPageSizeBothNotProvided",
+			req:  port.SocialServiceListFollowsRequest{Username: "user1"},
+			assert: func(t *testing.T, res port.SocialServiceListFollowsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockSocialRepository(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(repo)
+			}
+			svc := service.NewSocialService(repo, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.ListFollowing(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+)
+
+// filteredSubscription delivers only the updates of the wrapped subscription that match a filter.
+//
+// Updates are delivered one by one, so a subscriber which does not keep up
+// makes the wrapped subscription fall behind and end with its error.
+// The subscription ends with the error of the filter if it fails.
+type filteredSubscription struct {
+	sub       port.LocationSubscription
+	updates   chan domain.LocatedUser
+	done      chan struct{}
+	closeOnce sync.Once
+	// err is the error of the filter, it is set before updates are closed.
+	err error
+}
+
+// newFilteredSubscription wraps sub and starts filtering its updates until ctx is done,
+// the subscription is closed or sub ends.
+func newFilteredSubscription(
+	ctx context.Context,
+	sub port.LocationSubscription,
+	match func(context.Context, domain.LocatedUser) (bool, error),
+) *filteredSubscription {
+	f := &filteredSubscription{
+		sub:     sub,
+		updates: make(chan domain.LocatedUser),
+		done:    make(chan struct{}),
+	}
+
+	go f.run(ctx, match)
+
+	return f
+}
+
+func (f *filteredSubscription) run(ctx context.Context, match func(context.Context, domain.LocatedUser) (bool, error)) {
+	defer close(f.updates)
+	defer f.sub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.done:
+			return
+		case update, ok := <-f.sub.Updates():
+			if !ok {
+				return
+			}
+			ok, err := match(ctx, update)
+			if err != nil {
+				f.err = err
+				return
+			}
+			if !ok {
+				continue
+			}
+
+			select {
+			case f.updates <- update:
+			case <-ctx.Done():
+				return
+			case <-f.done:
+				return
+			}
+		}
+	}
+}
+
+// Updates returns a channel matching updates are delivered to. It is closed when the subscription ends.
+func (f *filteredSubscription) Updates() <-chan domain.LocatedUser {
+	return f.updates
+}
+
+// Err returns the error the filter or the wrapped subscription ended with, if any.
+func (f *filteredSubscription) Err() error {
+	if f.err != nil {
+		return f.err
+	}
+	return f.sub.Err()
+}
+
+// Close ends the subscription.
+func (f *filteredSubscription) Close() {
+	f.closeOnce.Do(func() {
+		close(f.done)
+	})
+}
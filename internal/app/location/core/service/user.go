@@ -4,10 +4,12 @@ import (
   "context"
   "fmt"
   log2 "log"
+  "sort"
   "time"
 
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
   "gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
+  "gitlab.com/spacewalker/geotracker/internal/pkg/auth"
   "gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
   "gitlab.com/spacewalker/geotracker/internal/pkg/geo"
   "gitlab.com/spacewalker/geotracker/internal/pkg/log"
@@ -15,16 +17,53 @@ import (
   "gitlab.com/spacewalker/geotracker/internal/pkg/util/pagination"
 )
 
+// OutOfOrderPolicy defines what happens to a location recorded before the stored one.
+type OutOfOrderPolicy string
+
+const (
+  // OutOfOrderPolicyReject rejects out-of-order locations with `ErrFailedPrecondition`.
+  // It is the default policy.
+  OutOfOrderPolicyReject OutOfOrderPolicy = "reject"
+  // OutOfOrderPolicyIgnore keeps the stored location and reports success.
+  OutOfOrderPolicyIgnore OutOfOrderPolicy = "ignore"
+)
+
+// UserServiceConfig configures user service.
+//
+// `MaxClockSkew` is how far in the future locations are allowed to be recorded at.
+// Locations of unknown users are rejected with `ErrNotFound` instead of creating the users
+// if `DisableUserAutoCreation` is true.
+// `MaxLocationAge` is the default maximum age of locations user searches take into account,
+// 0 means that the age is not limited.
+// `IdempotencyKeyTTL` is how long responses of requests sent with idempotency keys are remembered,
+// 0 means `DefaultIdempotencyKeyTTL`.
+// `IdempotencyKeyLease` is how long requests in progress hold their idempotency keys before retries may take them over,
+// 0 means `DefaultIdempotencyKeyLease`.
+type UserServiceConfig struct {
+  OutOfOrderPolicy        OutOfOrderPolicy
+  MaxClockSkew            time.Duration
+  DisableUserAutoCreation bool
+  MaxLocationAge          time.Duration
+  IdempotencyKeyTTL       time.Duration
+  IdempotencyKeyLease     time.Duration
+}
+
 type userService struct {
-  repo          port.UserRepository
-  historyClient port.HistoryClient
-  logger        log.Logger
+  repo            port.UserRepository
+  historyClient   port.HistoryClient
+  geofenceService port.GeofenceService
+  broker          port.LocationBroker
+  config          UserServiceConfig
+  logger          log.Logger
 }
 
 // NewUserService creates instance of UserService and returns its pointer.
 func NewUserService(
   repo port.UserRepository,
   historyClient port.HistoryClient,
+  geofenceService port.GeofenceService,
+  broker port.LocationBroker,
+  config UserServiceConfig,
   logger log.Logger,
 ) port.UserService {
   if logger == nil {
@@ -36,15 +75,133 @@ func NewUserService(
   if historyClient == nil {
     logger.Panic("historyClient must not be nil", nil)
   }
+  if geofenceService == nil {
+    logger.Panic("geofenceService must not be nil", nil)
+  }
+  if broker == nil {
+    logger.Panic("broker must not be nil", nil)
+  }
 
   return &userService{
-    repo:          repo,
-    historyClient: historyClient,
-    logger:        logger,
+    repo:            repo,
+    historyClient:   historyClient,
+    geofenceService: geofenceService,
+    broker:          broker,
+    config:          config,
+    logger:          logger,
+  }
+}
+
+// publish publishes the location of the user to subscribers of location updates.
+// The location is published the way the privacy settings of the user show it, hidden locations are not published.
+func (s *userService) publish(user domain.User, location domain.Location, privacy *domain.PrivacySettings) {
+  point, ok := privacy.VisiblePoint(location.Point)
+  if !ok {
+    return
+  }
+
+  s.broker.Publish(domain.LocatedUser{
+    User:              user,
+    Point:             point,
+    LocationUpdatedAt: location.UpdatedAt,
+  })
+}
+
+// errOutOfOrder is returned for locations recorded before the stored ones under the reject policy.
+var errOutOfOrder = fmt.Errorf("%w: location is recorded before the stored one", errpack.ErrFailedPrecondition)
+
+// tooFarInFuture reports whether t exceeds the allowed clock skew relative to now.
+func (s *userService) tooFarInFuture(t, now time.Time) bool {
+  return t.After(now.Add(s.config.MaxClockSkew))
+}
+
+// requestPoint returns the center of the geohash cell if the geohash is not empty
+// and the point given by the coordinates otherwise. The geohash must be valid.
+func requestPoint(longitude, latitude float64, geohash string) geo.Point {
+  if geohash != "" {
+    center, _ := geo.GeohashCenter(geohash)
+    return geo.Trunc(center)
+  }
+  return geo.Trunc(geo.Point{longitude, latitude})
+}
+
+// geohash returns the geohash of the point with maximal precision.
+func geohash(p geo.Point) string {
+  return geo.EncodeGeohash(p, geo.MaxGeohashPrecision)
+}
+
+// withNearbyGeohashes fills geohashes of locations of the users.
+func withNearbyGeohashes(users []domain.NearbyUser) []domain.NearbyUser {
+  for i := range users {
+    users[i].Geohash = geohash(users[i].Point)
+  }
+  return users
+}
+
+// withLocatedGeohashes fills geohashes of locations of the users.
+func withLocatedGeohashes(users []domain.LocatedUser) []domain.LocatedUser {
+  for i := range users {
+    users[i].Geohash = geohash(users[i].Point)
+  }
+  return users
+}
+
+// connectionsOf returns username of the caller whose connections are searched in the connections scope,
+// or empty string if every user is searched.
+func connectionsOf(scope port.UsersScope, caller string) string {
+  if scope == port.UsersScopeConnections {
+    return caller
+  }
+  return ""
+}
+
+// visibleTo reports whether location updates of the user with given id are delivered to the caller.
+// They are not delivered if the user blocked the caller or was blocked by it and, in case `connections`
+// is not empty, if the user is not a connection of it. Every update is delivered to anonymous callers.
+func (s *userService) visibleTo(ctx context.Context, caller, connections string, userID int) (bool, error) {
+  if caller == "" {
+    return true, nil
+  }
+
+  blocked, err := s.repo.IsBlocked(ctx, caller, userID)
+  if err != nil || blocked {
+    return false, err
+  }
+  if connections == "" {
+    return true, nil
+  }
+
+  return s.repo.IsConnection(ctx, connections, userID)
+}
+
+// maxAge returns the requested maximum age of locations or the default one if it is not requested.
+func (s *userService) maxAge(requested time.Duration) time.Duration {
+  if requested > 0 {
+    return requested
   }
+  return s.config.MaxLocationAge
 }
 
 // SetUserLocation sets user's location by given username.
+//
+// The location is recorded at `req.RecordedAt` or at the current time if it is nil.
+// `ErrInvalidArgument` is returned if it is further in the future than the allowed clock skew.
+//
+// A location recorded before the stored one is rejected with `ErrFailedPrecondition`
+// or ignored according to the out-of-order policy. The stored location is returned if it is ignored.
+//
+// The user is created if it does not exist unless auto-creation of users is disabled,
+// `ErrNotFound` is returned in that case.
+//
+// The previous and the new location are sent to history service and evaluated against geofences.
+// The new location is published to subscribers of location updates according to the privacy settings of the user.
+//
+// A request sent with an idempotency key is executed once, retries with the same key get the original response
+// until the key expires. Keys of failed requests are not remembered, so such requests are executed again.
+// `ErrFailedPrecondition` is returned in case the key was sent with another request of the user,
+// `ErrAlreadyExists` in case the original request is still in progress.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
 func (s *userService) SetUserLocation(ctx context.Context, req port.UserServiceSetUserLocationRequest) (port.UserServiceSetUserLocationResponse, error) {
   var err error
   defer func() {
@@ -56,32 +213,218 @@ func (s *userService) SetUserLocation(ctx context.Context, req port.UserServiceS
     return port.UserServiceSetUserLocationResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
   }
 
-  point := geo.Trunc(geo.Point{req.Longitude, req.Latitude})
+  if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+    return port.UserServiceSetUserLocationResponse{}, err
+  }
+
+  var res port.UserServiceSetUserLocationResponse
+  err = s.idempotent(ctx, req.Username, req.IdempotencyKey, req, &res, func() error {
+    var err error
+    res, err = s.setUserLocation(ctx, req)
+    return err
+  })
+  if err != nil {
+    return port.UserServiceSetUserLocationResponse{}, err
+  }
+
+  return res, nil
+}
+
+// setUserLocation sets the location of the validated and authorized request, see SetUserLocation.
+func (s *userService) setUserLocation(ctx context.Context, req port.UserServiceSetUserLocationRequest) (port.UserServiceSetUserLocationResponse, error) {
+  now := time.Now().UTC()
+  recordedAt := now
+  if req.RecordedAt != nil {
+    recordedAt = req.RecordedAt.UTC()
+    if s.tooFarInFuture(recordedAt, now) {
+      return port.UserServiceSetUserLocationResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+    }
+  }
+
+  point := requestPoint(req.Longitude, req.Latitude, req.Geohash)
 
   res, err := s.repo.SetUserLocation(ctx, port.UserRepositorySetUserLocationRequest{
-    Username: req.Username,
-    Point:    point,
+    Username:                req.Username,
+    Point:                   point,
+    RecordedAt:              recordedAt,
+    FixMetadata:             req.FixMetadata,
+    DisableUserAutoCreation: s.config.DisableUserAutoCreation,
   })
   if err != nil {
     return port.UserServiceSetUserLocationResponse{}, err
   }
 
-  if res.PrevLocation.UserID == res.User.ID {
-    _, _ = s.historyClient.AddRecord(ctx, port.HistoryClientAddRecordRequest{
-      UserID:    res.PrevLocation.UserID,
-      A:         res.PrevLocation.Point,
-      B:         res.Location.Point,
-      Timestamp: time.Now().UTC(),
+  if res.OutOfOrder && s.config.OutOfOrderPolicy != OutOfOrderPolicyIgnore {
+    return port.UserServiceSetUserLocationResponse{}, errOutOfOrder
+  }
+
+  if !res.OutOfOrder {
+    var prevPoint *geo.Point
+    if res.PrevLocation.UserID == res.User.ID {
+      prevPoint = &res.PrevLocation.Point
+      _, _ = s.historyClient.AddRecord(ctx, port.HistoryClientAddRecordRequest{
+        UserID:      res.PrevLocation.UserID,
+        A:           res.PrevLocation.Point,
+        B:           res.Location.Point,
+        Timestamp:   recordedAt,
+        FixMetadata: res.Location.FixMetadata,
+      })
+    }
+
+    // The location is already set, so failed geofence evaluation is only logged.
+    _, _ = s.geofenceService.EvaluateLocation(ctx, port.GeofenceServiceEvaluateLocationRequest{
+      UserID:    res.User.ID,
+      PrevPoint: prevPoint,
+      Point:     res.Location.Point,
+      Timestamp: recordedAt,
     })
+
+    s.publish(res.User, res.Location, res.Privacy)
   }
 
   return port.UserServiceSetUserLocationResponse{
-    Latitude:  res.Location.Point.Latitude(),
-    Longitude: res.Location.Point.Longitude(),
+    Latitude:    res.Location.Point.Latitude(),
+    Longitude:   res.Location.Point.Longitude(),
+    Geohash:     geohash(res.Location.Point),
+    RecordedAt:  res.Location.RecordedAt,
+    FixMetadata: res.Location.FixMetadata,
   }, nil
 }
 
+// SetUserLocations sets locations of users from a batch of timestamped fixes.
+//
+// Every item is validated separately, invalid items and items further in the future than
+// the allowed clock skew get `ErrInvalidArgument` in their results and are skipped.
+// Items of users the caller may not act on behalf of get `ErrPermissionDenied` and are skipped too.
+// Valid items are applied in timestamp order in the scope of a single repository call,
+// so either all of them are applied or none.
+// Items with equal timestamps are applied in order of the request.
+// Items recorded before the stored locations are handled according to the out-of-order policy,
+// i.e. get `ErrFailedPrecondition` in their results or the stored locations.
+//
+// History records of all the applied items are sent to history service in one call and
+// every applied item is evaluated against geofences. Failures of both are only logged.
+// Every applied item is published to subscribers of location updates.
+//
+// `ErrInvalidArgument` is returned in case the batch is empty or too large.
+//
+// `ErrNotFound` is returned in case any of the users does not exist and user auto creation is disabled.
+//
+// Any error occurred in `SetUserLocations` repository method is returned.
+func (s *userService) SetUserLocations(ctx context.Context, req port.UserServiceSetUserLocationsRequest) (port.UserServiceSetUserLocationsResponse, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return port.UserServiceSetUserLocationsResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  now := time.Now().UTC()
+  results := make([]port.UserServiceSetUserLocationsResult, len(req.Locations))
+
+  // Indexes of valid items.
+  valid := make([]int, 0, len(req.Locations))
+  for i, item := range req.Locations {
+    if vErr := validate.Struct(item); vErr != nil || s.tooFarInFuture(item.Timestamp, now) {
+      results[i].Err = fmt.Errorf("%w", errpack.ErrInvalidArgument)
+      continue
+    }
+    if aErr := auth.AuthorizeUser(ctx, item.Username); aErr != nil {
+      results[i].Err = aErr
+      continue
+    }
+    valid = append(valid, i)
+  }
+
+  if len(valid) == 0 {
+    return port.UserServiceSetUserLocationsResponse{Results: results}, nil
+  }
+
+  sort.SliceStable(valid, func(i, j int) bool {
+    return req.Locations[valid[i]].Timestamp.Before(req.Locations[valid[j]].Timestamp)
+  })
+
+  args := make([]port.UserRepositorySetUserLocationRequest, 0, len(valid))
+  for _, i := range valid {
+    item := req.Locations[i]
+    args = append(args, port.UserRepositorySetUserLocationRequest{
+      Username:                item.Username,
+      Point:                   requestPoint(item.Longitude, item.Latitude, item.Geohash),
+      RecordedAt:              item.Timestamp.UTC(),
+      FixMetadata:             item.FixMetadata,
+      DisableUserAutoCreation: s.config.DisableUserAutoCreation,
+    })
+  }
+
+  res, err := s.repo.SetUserLocations(ctx, args)
+  if err != nil {
+    return port.UserServiceSetUserLocationsResponse{}, err
+  }
+
+  records := make([]port.HistoryClientAddRecordRequest, 0, len(res))
+  for k, r := range res {
+    i := valid[k]
+    timestamp := req.Locations[i].Timestamp.UTC()
+
+    if r.OutOfOrder && s.config.OutOfOrderPolicy != OutOfOrderPolicyIgnore {
+      results[i].Err = errOutOfOrder
+      continue
+    }
+
+    results[i].Latitude = r.Location.Point.Latitude()
+    results[i].Longitude = r.Location.Point.Longitude()
+    results[i].Geohash = geohash(r.Location.Point)
+    results[i].RecordedAt = r.Location.RecordedAt
+    results[i].FixMetadata = r.Location.FixMetadata
+
+    if r.OutOfOrder {
+      continue
+    }
+
+    var prevPoint *geo.Point
+    if r.PrevLocation.UserID == r.User.ID {
+      prevPoint = &res[k].PrevLocation.Point
+      records = append(records, port.HistoryClientAddRecordRequest{
+        UserID:      r.User.ID,
+        A:           r.PrevLocation.Point,
+        B:           r.Location.Point,
+        Timestamp:   timestamp,
+        FixMetadata: r.Location.FixMetadata,
+      })
+    }
+
+    // The locations are already set, so failed geofence evaluation is only logged.
+    _, _ = s.geofenceService.EvaluateLocation(ctx, port.GeofenceServiceEvaluateLocationRequest{
+      UserID:    r.User.ID,
+      PrevPoint: prevPoint,
+      Point:     r.Location.Point,
+      Timestamp: timestamp,
+    })
+
+    s.publish(r.User, r.Location, r.Privacy)
+  }
+
+  if len(records) > 0 {
+    _, _ = s.historyClient.AddRecords(ctx, port.HistoryClientAddRecordsRequest{Records: records})
+  }
+
+  return port.UserServiceSetUserLocationsResponse{Results: results}, nil
+}
+
 // ListUsersInRadius finds users by given location and radius.
+//
+// Found users are ordered by ID unless `req.OrderBy` is `UsersOrderByDistance`.
+// In the latter case the nearest users go first and page token is a (distance, ID) keyset cursor.
+// Users whose locations are older than `req.MaxAge` or the default maximum age are skipped.
+// Only connections of `req.Caller` are found if `req.Scope` is `UsersScopeConnections`.
+// Users who blocked `req.Caller` or were blocked by it are never found.
+//
+// Locations are shown according to privacy settings of the users. Hidden users are not found,
+// coarse users are found, ordered and paged by their coarse locations only.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of `req.Caller`.
 func (s *userService) ListUsersInRadius(ctx context.Context, req port.UserServiceListUsersInRadiusRequest) (port.UserServiceListUsersInRadiusResponse, error) {
   var err error
   defer func() {
@@ -93,12 +436,23 @@ func (s *userService) ListUsersInRadius(ctx context.Context, req port.UserServic
     return port.UserServiceListUsersInRadiusResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
   }
 
+  if req.Caller != "" {
+    if err = auth.AuthorizeUser(ctx, req.Caller); err != nil {
+      return port.UserServiceListUsersInRadiusResponse{}, err
+    }
+  }
+
   req.Point = geo.Trunc(req.Point)
 
   var pageToken, pageSize int
+  var pageTokenDistance float64
   if req.PageToken != "" {
     var err error
-    pageToken, pageSize, err = pagination.DecodeCursor(req.PageToken)
+    if req.OrderBy == port.UsersOrderByDistance {
+      pageTokenDistance, pageToken, pageSize, err = pagination.DecodeDistanceCursor(req.PageToken)
+    } else {
+      pageToken, pageSize, err = pagination.DecodeCursor(req.PageToken)
+    }
     if err != nil {
       return port.UserServiceListUsersInRadiusResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
     }
@@ -107,10 +461,15 @@ func (s *userService) ListUsersInRadius(ctx context.Context, req port.UserServic
   }
 
   res, err := s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
-    Point:     req.Point,
-    Radius:    req.Radius,
-    PageToken: pageToken,
-    PageSize:  pageSize,
+    Point:             req.Point,
+    Radius:            req.Radius,
+    PageToken:         pageToken,
+    PageTokenDistance: pageTokenDistance,
+    PageSize:          pageSize,
+    OrderBy:           req.OrderBy,
+    MaxAge:            s.maxAge(req.MaxAge),
+    ConnectionsOf:     connectionsOf(req.Scope, req.Caller),
+    Caller:            req.Caller,
   })
   if err != nil {
     return port.UserServiceListUsersInRadiusResponse{}, err
@@ -118,25 +477,445 @@ func (s *userService) ListUsersInRadius(ctx context.Context, req port.UserServic
 
   nextPageToken := ""
   if res.NextPageToken > 0 {
-    nextPageToken = pagination.EncodeCursor(res.NextPageToken, pageSize)
+    if req.OrderBy == port.UsersOrderByDistance {
+      nextPageToken = pagination.EncodeDistanceCursor(res.NextPageTokenDistance, res.NextPageToken, pageSize)
+    } else {
+      nextPageToken = pagination.EncodeCursor(res.NextPageToken, pageSize)
+    }
   }
 
   if res.Users == nil {
-    res.Users = make([]domain.User, 0)
+    res.Users = make([]domain.NearbyUser, 0)
   }
 
   return port.UserServiceListUsersInRadiusResponse{
-    Users:         res.Users,
+    Users:         withNearbyGeohashes(res.Users),
     NextPageToken: nextPageToken,
   }, nil
 }
 
+// ListNearestUsers finds no more than `req.Limit` users nearest to given location.
+//
+// Found users are ordered by distance, the nearest users go first.
+// The user with `req.ExcludeUsername` username is skipped and
+// users farther than `req.MaxDistance` meters are skipped unless it equals 0.
+// Users whose locations are older than `req.MaxAge` or the default maximum age are skipped.
+// Only connections of `req.Caller` are found if `req.Scope` is `UsersScopeConnections`.
+// Users who blocked `req.Caller` or were blocked by it are never found.
+//
+// Locations are shown according to privacy settings of the users. Hidden users are not found,
+// coarse users are found and ordered by their coarse locations only.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of `req.Caller`.
+//
+// Any other error occurred in `ListNearestUsers` is returned.
+func (s *userService) ListNearestUsers(ctx context.Context, req port.UserServiceListNearestUsersRequest) (port.UserServiceListNearestUsersResponse, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return port.UserServiceListNearestUsersResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if req.Caller != "" {
+    if err = auth.AuthorizeUser(ctx, req.Caller); err != nil {
+      return port.UserServiceListNearestUsersResponse{}, err
+    }
+  }
+
+  req.Point = geo.Trunc(req.Point)
+
+  users, err := s.repo.ListNearestUsers(ctx, port.UserRepositoryListNearestUsersRequest{
+    Point:           req.Point,
+    Limit:           req.Limit,
+    ExcludeUsername: req.ExcludeUsername,
+    MaxDistance:     req.MaxDistance,
+    MaxAge:          s.maxAge(req.MaxAge),
+    ConnectionsOf:   connectionsOf(req.Scope, req.Caller),
+    Caller:          req.Caller,
+  })
+  if err != nil {
+    return port.UserServiceListNearestUsersResponse{}, err
+  }
+
+  if users == nil {
+    users = make([]domain.NearbyUser, 0)
+  }
+
+  return port.UserServiceListNearestUsersResponse{
+    Users: withNearbyGeohashes(users),
+  }, nil
+}
+
+// ListUsersInBBox finds users inside given bounding box.
+//
+// Found users are ordered by ID. Bounding boxes crossing the antimeridian are supported.
+// Users whose locations are older than `req.MaxAge` or the default maximum age are skipped.
+// Only connections of `req.Caller` are found if `req.Scope` is `UsersScopeConnections`.
+// Users who blocked `req.Caller` or were blocked by it are never found.
+//
+// Locations are shown according to privacy settings of the users. Hidden users are not found,
+// coarse users are found by their coarse locations only.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of `req.Caller`.
+//
+// Any other error occurred in `ListUsersInBBox` is returned.
+func (s *userService) ListUsersInBBox(ctx context.Context, req port.UserServiceListUsersInBBoxRequest) (port.UserServiceListUsersInBBoxResponse, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return port.UserServiceListUsersInBBoxResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if req.Caller != "" {
+    if err = auth.AuthorizeUser(ctx, req.Caller); err != nil {
+      return port.UserServiceListUsersInBBoxResponse{}, err
+    }
+  }
+
+  pageToken, pageSize, err := decodePageToken(req.PageToken, req.PageSize)
+  if err != nil {
+    return port.UserServiceListUsersInBBoxResponse{}, err
+  }
+
+  res, err := s.repo.ListUsersInBBox(ctx, port.UserRepositoryListUsersInBBoxRequest{
+    BBox:          req.BBox,
+    PageToken:     pageToken,
+    PageSize:      pageSize,
+    MaxAge:        s.maxAge(req.MaxAge),
+    ConnectionsOf: connectionsOf(req.Scope, req.Caller),
+    Caller:        req.Caller,
+  })
+  if err != nil {
+    return port.UserServiceListUsersInBBoxResponse{}, err
+  }
+
+  if res.Users == nil {
+    res.Users = make([]domain.LocatedUser, 0)
+  }
+
+  return port.UserServiceListUsersInBBoxResponse{
+    Users:         withLocatedGeohashes(res.Users),
+    NextPageToken: encodePageToken(res.NextPageToken, pageSize),
+  }, nil
+}
+
+// ListUsersInPolygon finds users inside given polygon.
+//
+// Found users are ordered by ID. Polygons crossing the antimeridian and polygons with holes are supported.
+// Users whose locations are older than `req.MaxAge` or the default maximum age are skipped.
+// Only connections of `req.Caller` are found if `req.Scope` is `UsersScopeConnections`.
+// Users who blocked `req.Caller` or were blocked by it are never found.
+//
+// Locations are shown according to privacy settings of the users. Hidden users are not found,
+// coarse users are found by their coarse locations only.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of `req.Caller`.
+//
+// Any other error occurred in `ListUsersInPolygon` is returned.
+func (s *userService) ListUsersInPolygon(ctx context.Context, req port.UserServiceListUsersInPolygonRequest) (port.UserServiceListUsersInPolygonResponse, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return port.UserServiceListUsersInPolygonResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if req.Caller != "" {
+    if err = auth.AuthorizeUser(ctx, req.Caller); err != nil {
+      return port.UserServiceListUsersInPolygonResponse{}, err
+    }
+  }
+
+  pageToken, pageSize, err := decodePageToken(req.PageToken, req.PageSize)
+  if err != nil {
+    return port.UserServiceListUsersInPolygonResponse{}, err
+  }
+
+  res, err := s.repo.ListUsersInPolygon(ctx, port.UserRepositoryListUsersInPolygonRequest{
+    Polygon:       req.Polygon,
+    PageToken:     pageToken,
+    PageSize:      pageSize,
+    MaxAge:        s.maxAge(req.MaxAge),
+    ConnectionsOf: connectionsOf(req.Scope, req.Caller),
+    Caller:        req.Caller,
+  })
+  if err != nil {
+    return port.UserServiceListUsersInPolygonResponse{}, err
+  }
+
+  if res.Users == nil {
+    res.Users = make([]domain.LocatedUser, 0)
+  }
+
+  return port.UserServiceListUsersInPolygonResponse{
+    Users:         withLocatedGeohashes(res.Users),
+    NextPageToken: encodePageToken(res.NextPageToken, pageSize),
+  }, nil
+}
+
+const (
+  // watchUsersInRadiusPageSize is a page size the initial members of a watched circle are fetched with.
+  watchUsersInRadiusPageSize = 100
+  // watchUsersInRadiusBuffer is a number of location updates buffered for a single subscriber.
+  watchUsersInRadiusBuffer = 256
+  // subscribeLocationsBuffer is a number of location updates buffered for a single subscriber.
+  subscribeLocationsBuffer = 256
+)
+
+// WatchUsersInRadius streams changes of the set of users inside the circle to `send`.
+//
+// Users found inside the circle are sent as ENTER events first, after that ENTER, MOVE and EXIT
+// events are sent as locations of users change. Updates older than the known location of
+// a user inside the circle are skipped. Locations are shown according to privacy settings of the users
+// the same way `ListUsersInRadius` shows them.
+//
+// Only connections of `req.Caller` are watched if `req.Scope` is `UsersScopeConnections`.
+// Users who blocked `req.Caller` or were blocked by it are never watched.
+// Updates of a user who is no longer a connection or is blocked are skipped and the user leaves the circle silently.
+//
+// It returns nil when ctx is done and the error returned by `send` if it fails.
+// `ErrResourceExhausted` is returned if `send` falls too far behind location updates,
+// the subscription should be started again in that case.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of `req.Caller`.
+//
+// Any error occurred in `ListUsersInRadius`, `IsBlocked` and `IsConnection` repository methods is returned.
+func (s *userService) WatchUsersInRadius(ctx context.Context, req port.UserServiceWatchUsersInRadiusRequest, send func(domain.RadiusEvent) error) error {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if req.Caller != "" {
+    if err = auth.AuthorizeUser(ctx, req.Caller); err != nil {
+      return err
+    }
+  }
+
+  req.Point = geo.Trunc(req.Point)
+  connections := connectionsOf(req.Scope, req.Caller)
+
+  // Subscribe before the members are fetched, so that no update is missed in between.
+  sub := s.broker.Subscribe(watchUsersInRadiusBuffer)
+  defer sub.Close()
+
+  // Location update times of users inside the circle.
+  members := make(map[int]time.Time)
+
+  pageToken := 0
+  for {
+    var res port.UserRepositoryListUsersInRadiusResponse
+    res, err = s.repo.ListUsersInRadius(ctx, port.UserRepositoryListUsersInRadiusRequest{
+      Point:         req.Point,
+      Radius:        req.Radius,
+      PageToken:     pageToken,
+      PageSize:      watchUsersInRadiusPageSize,
+      MaxAge:        s.maxAge(req.MaxAge),
+      ConnectionsOf: connections,
+      Caller:        req.Caller,
+    })
+    if err != nil {
+      return err
+    }
+
+    for _, user := range res.Users {
+      members[user.ID] = user.LocationUpdatedAt
+      if err = send(domain.RadiusEvent{Type: domain.RadiusEventEnter, User: user}); err != nil {
+        return err
+      }
+    }
+
+    if res.NextPageToken == 0 {
+      break
+    }
+    pageToken = res.NextPageToken
+  }
+
+  for {
+    select {
+    case <-ctx.Done():
+      return nil
+    case update, ok := <-sub.Updates():
+      if !ok {
+        err = sub.Err()
+        return err
+      }
+
+      var visible bool
+      if visible, err = s.visibleTo(ctx, req.Caller, connections, update.ID); err != nil {
+        return err
+      }
+      if !visible {
+        delete(members, update.ID)
+        continue
+      }
+
+      event, ok := radiusEvent(req.Point, req.Radius, members, update)
+      if !ok {
+        continue
+      }
+      if err = send(event); err != nil {
+        return err
+      }
+    }
+  }
+}
+
+// SubscribeLocations subscribes to location updates of users with `req.Usernames` or
+// of users inside `req.BBox`.
+// Only updates of connections of `req.Caller` are delivered if `req.Scope` is `UsersScopeConnections`.
+// Updates of users who blocked `req.Caller` or were blocked by it are never delivered.
+//
+// The subscription ends when ctx is done or it is closed.
+// Its `Err` returns `ErrResourceExhausted` if the subscriber falls too far behind location updates
+// and any error occurred in `IsBlocked` and `IsConnection` repository methods.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of `req.Caller`.
+func (s *userService) SubscribeLocations(ctx context.Context, req port.UserServiceSubscribeLocationsRequest) (port.LocationSubscription, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return nil, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if req.Caller != "" {
+    if err = auth.AuthorizeUser(ctx, req.Caller); err != nil {
+      return nil, err
+    }
+  }
+
+  var inFilter func(update domain.LocatedUser) bool
+  if req.BBox != nil {
+    bbox := *req.BBox
+    inFilter = func(update domain.LocatedUser) bool {
+      return bbox.Contains(update.Point)
+    }
+  } else {
+    usernames := make(map[string]struct{}, len(req.Usernames))
+    for _, username := range req.Usernames {
+      usernames[username] = struct{}{}
+    }
+    inFilter = func(update domain.LocatedUser) bool {
+      _, ok := usernames[update.Username]
+      return ok
+    }
+  }
+
+  connections := connectionsOf(req.Scope, req.Caller)
+  match := func(ctx context.Context, update domain.LocatedUser) (bool, error) {
+    if !inFilter(update) {
+      return false, nil
+    }
+
+    // The subscription outlives the request, so its failures are logged here.
+    visible, err := s.visibleTo(ctx, req.Caller, connections, update.ID)
+    util.LogInternalError(ctx, s.logger, err, req)
+    return visible, err
+  }
+
+  return newFilteredSubscription(ctx, s.broker.Subscribe(subscribeLocationsBuffer), match), nil
+}
+
+// radiusEvent returns an event the update causes in the circle and updates its members.
+// False is returned if the update does not cause any event.
+func radiusEvent(center geo.Point, radius float64, members map[int]time.Time, update domain.LocatedUser) (domain.RadiusEvent, bool) {
+  updatedAt, member := members[update.ID]
+  if member && update.LocationUpdatedAt.Before(updatedAt) {
+    return domain.RadiusEvent{}, false
+  }
+
+  distance := geo.Distance(center, update.Point)
+  inside := distance <= radius
+
+  var eventType domain.RadiusEventType
+  switch {
+  case inside && member:
+    eventType = domain.RadiusEventMove
+  case inside:
+    eventType = domain.RadiusEventEnter
+  case member:
+    eventType = domain.RadiusEventExit
+  default:
+    return domain.RadiusEvent{}, false
+  }
+
+  if inside {
+    members[update.ID] = update.LocationUpdatedAt
+  } else {
+    delete(members, update.ID)
+  }
+
+  return domain.RadiusEvent{
+    Type: eventType,
+    User: domain.NearbyUser{
+      User:              update.User,
+      Point:             update.Point,
+      Distance:          distance,
+      LocationUpdatedAt: update.LocationUpdatedAt,
+    },
+  }, true
+}
+
+// decodePageToken returns page token and page size decoded from the cursor,
+// or the provided page size if the cursor is empty.
+//
+// `ErrInvalidArgument` is returned in case the cursor is invalid.
+func decodePageToken(cursor string, pageSize int) (int, int, error) {
+  if cursor == "" {
+    return 0, pageSize, nil
+  }
+
+  pageToken, pageSize, err := pagination.DecodeCursor(cursor)
+  if err != nil {
+    return 0, 0, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  return pageToken, pageSize, nil
+}
+
+// encodePageToken returns a cursor of the next page, or empty string if there is no next page.
+func encodePageToken(nextPageToken, pageSize int) string {
+  if nextPageToken <= 0 {
+    return ""
+  }
+  return pagination.EncodeCursor(nextPageToken, pageSize)
+}
+
 // GetByUsername finds user by username.
 //
 // It returns a user and any error encountered.
 //
+// It is a part of the internal API, only internal services and admins may call it.
+//
 // `ErrInvalidArgument` is returned in case username is empty string.
 //
+// `ErrPermissionDenied` is returned in case the caller is neither an internal service nor an admin.
+//
 // Any other error occurred in `GetByUsername` is returned.
 func (s *userService) GetByUsername(ctx context.Context, username string) (domain.User, error) {
   var err error
@@ -148,6 +927,10 @@ func (s *userService) GetByUsername(ctx context.Context, username string) (domai
     return domain.User{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
   }
 
+  if err = auth.AuthorizeRoles(ctx, auth.RoleService); err != nil {
+    return domain.User{}, err
+  }
+
   user, err := s.repo.GetByUsername(ctx, username)
   if err != nil {
     return domain.User{}, err
@@ -155,3 +938,184 @@ func (s *userService) GetByUsername(ctx context.Context, username string) (domai
 
   return user, nil
 }
+
+// CreateUser creates a new user with given username.
+//
+// It returns the created user and any error encountered.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// Any other error occurred in `CreateUser` is returned.
+func (s *userService) CreateUser(ctx context.Context, req port.UserServiceCreateUserRequest) (domain.User, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return domain.User{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if err = auth.AuthorizeUser(ctx, req.Username); err != nil {
+    return domain.User{}, err
+  }
+
+  user, err := s.repo.CreateUser(ctx, port.CreateUserArg{Username: req.Username})
+  if err != nil {
+    return domain.User{}, err
+  }
+
+  return user, nil
+}
+
+// GetUser finds user by username along with its current location.
+//
+// The user is last seen at the time its location was updated at.
+// The location is shown according to privacy settings of the user,
+// neither the location nor the last seen time is shown if it is hidden.
+//
+// `ErrInvalidArgument` is returned in case username is invalid.
+//
+// Any other error occurred in `GetUserWithLocation` is returned.
+func (s *userService) GetUser(ctx context.Context, username string) (port.UserServiceGetUserResponse, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, username)
+  }()
+
+  if err = validate.Var(username, "required,validusername"); err != nil {
+    return port.UserServiceGetUserResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  res, err := s.repo.GetUserWithLocation(ctx, username)
+  if err != nil {
+    return port.UserServiceGetUserResponse{}, err
+  }
+
+  result := port.UserServiceGetUserResponse{
+    User: res.User,
+  }
+  if res.Location != nil {
+    if point, ok := res.Privacy.VisiblePoint(res.Location.Point); ok {
+      location := *res.Location
+      location.Point = point
+      result.Location = &location
+
+      lastSeenAt := location.UpdatedAt
+      result.LastSeenAt = &lastSeenAt
+    }
+  }
+
+  return result, nil
+}
+
+// ListUsers lists users ordered by ID.
+//
+// Only users whose usernames start with `req.Prefix` are listed if it is not empty.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// Any other error occurred in `ListUsers` is returned.
+func (s *userService) ListUsers(ctx context.Context, req port.UserServiceListUsersRequest) (port.UserServiceListUsersResponse, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return port.UserServiceListUsersResponse{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  pageToken, pageSize, err := decodePageToken(req.PageToken, req.PageSize)
+  if err != nil {
+    return port.UserServiceListUsersResponse{}, err
+  }
+
+  res, err := s.repo.ListUsers(ctx, port.UserRepositoryListUsersRequest{
+    Prefix:    req.Prefix,
+    PageToken: pageToken,
+    PageSize:  pageSize,
+  })
+  if err != nil {
+    return port.UserServiceListUsersResponse{}, err
+  }
+
+  if res.Users == nil {
+    res.Users = make([]domain.User, 0)
+  }
+
+  return port.UserServiceListUsersResponse{
+    Users:         res.Users,
+    NextPageToken: encodePageToken(res.NextPageToken, pageSize),
+  }, nil
+}
+
+// RenameUser changes username of the user.
+//
+// Only admins may rename users: principals are bound to usernames, so a token
+// issued for the old username would act on behalf of whoever takes it next.
+//
+// It returns the renamed user and any error encountered.
+//
+// `ErrInvalidArgument` is returned in case the request is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller is not an admin.
+//
+// Any other error occurred in `RenameUser` is returned.
+func (s *userService) RenameUser(ctx context.Context, req port.UserServiceRenameUserRequest) (domain.User, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, req)
+  }()
+
+  if err = validate.Struct(req); err != nil {
+    return domain.User{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if err = auth.AuthorizeRoles(ctx, auth.RoleAdmin); err != nil {
+    return domain.User{}, err
+  }
+
+  user, err := s.repo.RenameUser(ctx, port.UserRepositoryRenameUserRequest{
+    Username:    req.Username,
+    NewUsername: req.NewUsername,
+  })
+  if err != nil {
+    return domain.User{}, err
+  }
+
+  return user, nil
+}
+
+// DeleteUser deletes user by username along with its location and geofence events.
+//
+// It returns a pending erasure job that tracks erasure of the user's data from other services.
+//
+// `ErrInvalidArgument` is returned in case username is invalid.
+//
+// `ErrPermissionDenied` is returned in case the caller may not act on behalf of the user.
+//
+// Any other error occurred in `DeleteUser` is returned.
+func (s *userService) DeleteUser(ctx context.Context, username string) (domain.ErasureJob, error) {
+  var err error
+  defer func() {
+    util.LogInternalError(ctx, s.logger, err, username)
+  }()
+
+  if err = validate.Var(username, "required,validusername"); err != nil {
+    return domain.ErasureJob{}, fmt.Errorf("%w", errpack.ErrInvalidArgument)
+  }
+
+  if err = auth.AuthorizeUser(ctx, username); err != nil {
+    return domain.ErasureJob{}, err
+  }
+
+  job, err := s.repo.DeleteUser(ctx, username)
+  if err != nil {
+    return domain.ErasureJob{}, err
+  }
+
+  return job, nil
+}
@@ -3,6 +3,7 @@ package service_test
 import (
 	"context"
 	"errors"
+	"fmt"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/util/testutil"
 	"testing"
 	"time"
@@ -14,9 +15,11 @@ import (
 	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port/mock"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/core/service"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
 	mocklog "gitlab.com/spacewalker/geotracker/internal/pkg/log/mock"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util/pagination"
 )
 
 type UserSvcTestSuite struct {
@@ -38,6 +41,13 @@ func TestSvcTestSuite(t *testing.T) {
 	suite.Run(t, new(UserSvcTestSuite))
 }
 
+// newLocationBroker returns a broker mock which accepts any published updates.
+func newLocationBroker(ctrl *gomock.Controller) *mock.MockLocationBroker {
+	broker := mock.NewMockLocationBroker(ctrl)
+	broker.EXPECT().Publish(gomock.Any()).AnyTimes()
+	return broker
+}
+
 func (s *UserSvcTestSuite) Test_UserService_SetUserLocation() {
 	username := "user1"
 
@@ -64,7 +74,7 @@ func (s *UserSvcTestSuite) Test_UserService_SetUserLocation() {
 				repo.EXPECT().
 					SetUserLocation(
 						gomock.Any(),
-						gomock.Eq(port.UserRepositorySetUserLocationRequest{
+						EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
 							Username: username,
 							Point:    point,
 						}),
@@ -104,7 +114,7 @@ func (s *UserSvcTestSuite) Test_UserService_SetUserLocation() {
 				repo.EXPECT().
 					SetUserLocation(
 						gomock.Any(),
-						gomock.Eq(port.UserRepositorySetUserLocationRequest{
+						EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
 							Username: username,
 							Point:    geo.Point{-180.0, -90.0},
 						}),
@@ -144,7 +154,7 @@ func (s *UserSvcTestSuite) Test_UserService_SetUserLocation() {
 				repo.EXPECT().
 					SetUserLocation(
 						gomock.Any(),
-						gomock.Eq(port.UserRepositorySetUserLocationRequest{
+						EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
 							Username: username,
 							Point:    geo.Point{180.0, 90.0},
 						}),
@@ -184,7 +194,7 @@ func (s *UserSvcTestSuite) Test_UserService_SetUserLocation() {
 				repo.EXPECT().
 					SetUserLocation(
 						gomock.Any(),
-						gomock.Eq(port.UserRepositorySetUserLocationRequest{
+						EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
 							Username: username,
 							Point:    geo.Point{0, 0},
 						}),
@@ -363,14 +373,498 @@ func (s *UserSvcTestSuite) Test_UserService_SetUserLocation() {
 			repo := mock.NewMockUserRepository(ctrl)
 			historyClient := mock.NewMockHistoryClient(ctrl)
 			tc.buildStubs(repo, historyClient)
+			geofenceService := mock.NewMockGeofenceService(ctrl)
+			geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
 			logger := mocklog.NewMockLogger(ctrl)
-			svc := service.NewUserService(repo, historyClient, logger)
+			svc := service.NewUserService(repo, historyClient, geofenceService, newLocationBroker(ctrl), service.UserServiceConfig{}, logger)
 
 			_, _ = svc.SetUserLocation(context.Background(), tc.arg)
 		})
 	}
 }
 
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocation_RecordedAt() {
+	user := domain.User{ID: 1, Username: "user1"}
+	point := geo.Trunc(geo.Point{10, 20})
+	stored := domain.Location{UserID: user.ID, Point: geo.Point{30, 40}, RecordedAt: time.Now().UTC()}
+	recordedAt := stored.RecordedAt.Add(-time.Hour)
+
+	type mocks struct {
+		repo            *mock.MockUserRepository
+		historyClient   *mock.MockHistoryClient
+		geofenceService *mock.MockGeofenceService
+	}
+
+	outOfOrderStubs := func(m mocks) {
+		m.repo.EXPECT().
+			SetUserLocation(gomock.Any(), gomock.Eq(port.UserRepositorySetUserLocationRequest{
+				Username:   user.Username,
+				Point:      point,
+				RecordedAt: recordedAt,
+			})).
+			Times(1).
+			Return(port.UserRepositorySetUserLocationResponse{
+				User:         user,
+				PrevLocation: stored,
+				Location:     stored,
+				OutOfOrder:   true,
+			}, nil)
+		m.historyClient.EXPECT().AddRecord(gomock.Any(), gomock.Any()).Times(0)
+		m.geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).Times(0)
+	}
+
+	testCases := []struct {
+		name       string
+		config     service.UserServiceConfig
+		recordedAt time.Time
+		buildStubs func(m mocks)
+		assert     func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error)
+	}{
+		{
+			name:       "OK",
+			recordedAt: recordedAt,
+			buildStubs: func(m mocks) {
+				prev := domain.Location{UserID: user.ID, Point: geo.Point{30, 40}}
+				m.repo.EXPECT().
+					SetUserLocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositorySetUserLocationResponse{
+						User:         user,
+						PrevLocation: prev,
+						Location:     domain.Location{UserID: user.ID, Point: point, RecordedAt: recordedAt},
+					}, nil)
+				m.historyClient.EXPECT().
+					AddRecord(gomock.Any(), gomock.Eq(port.HistoryClientAddRecordRequest{
+						UserID:    user.ID,
+						A:         prev.Point,
+						B:         point,
+						Timestamp: recordedAt,
+					})).
+					Times(1)
+				m.geofenceService.EXPECT().
+					EvaluateLocation(gomock.Any(), gomock.Eq(port.GeofenceServiceEvaluateLocationRequest{
+						UserID:    user.ID,
+						PrevPoint: &prev.Point,
+						Point:     point,
+						Timestamp: recordedAt,
+					})).
+					Times(1)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, recordedAt, res.RecordedAt)
+			},
+		},
+		{
+			name:       "Err_OutOfOrder_Rejected",
+			recordedAt: recordedAt,
+			buildStubs: outOfOrderStubs,
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrFailedPrecondition)
+			},
+		},
+		{
+			name:       "OK_OutOfOrder_Ignored",
+			config:     service.UserServiceConfig{OutOfOrderPolicy: service.OutOfOrderPolicyIgnore},
+			recordedAt: recordedAt,
+			buildStubs: outOfOrderStubs,
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, stored.Point.Longitude(), res.Longitude)
+				require.Equal(t, stored.Point.Latitude(), res.Latitude)
+				require.Equal(t, stored.RecordedAt, res.RecordedAt)
+			},
+		},
+		{
+			name:       "OK_WithinClockSkew",
+			config:     service.UserServiceConfig{MaxClockSkew: time.Minute},
+			recordedAt: time.Now().UTC().Add(30 * time.Second),
+			buildStubs: func(m mocks) {
+				m.repo.EXPECT().
+					SetUserLocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositorySetUserLocationResponse{
+						User:     user,
+						Location: domain.Location{UserID: user.ID, Point: point},
+					}, nil)
+				m.geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).Times(1)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:       "Err_TooFarInFuture",
+			config:     service.UserServiceConfig{MaxClockSkew: time.Minute},
+			recordedAt: time.Now().UTC().Add(2 * time.Minute),
+			buildStubs: func(m mocks) {
+				m.repo.EXPECT().SetUserLocation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocks{
+				repo:            mock.NewMockUserRepository(ctrl),
+				historyClient:   mock.NewMockHistoryClient(ctrl),
+				geofenceService: mock.NewMockGeofenceService(ctrl),
+			}
+			tc.buildStubs(m)
+
+			svc := service.NewUserService(m.repo, m.historyClient, m.geofenceService, newLocationBroker(ctrl), tc.config, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.SetUserLocation(context.Background(), port.UserServiceSetUserLocationRequest{
+				Username:   user.Username,
+				Longitude:  point.Longitude(),
+				Latitude:   point.Latitude(),
+				RecordedAt: &tc.recordedAt,
+			})
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocation_FixMetadata() {
+	user := domain.User{ID: 1, Username: "user1"}
+	point := geo.Trunc(geo.Point{10, 20})
+	prev := domain.Location{UserID: user.ID, Point: geo.Point{30, 40}}
+
+	float := func(v float64) *float64 {
+		return &v
+	}
+
+	metadata := geo.FixMetadata{
+		Accuracy: float(5),
+		Altitude: float(-10),
+		Speed:    float(0),
+		Bearing:  float(359.9),
+		Source:   geo.FixSourceGPS,
+	}
+
+	testCases := []struct {
+		name       string
+		metadata   geo.FixMetadata
+		buildStubs func(repo *mock.MockUserRepository, historyClient *mock.MockHistoryClient)
+		assert     func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error)
+	}{
+		{
+			name:     "OK",
+			metadata: metadata,
+			buildStubs: func(repo *mock.MockUserRepository, historyClient *mock.MockHistoryClient) {
+				repo.EXPECT().
+					SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+						Username:    user.Username,
+						Point:       point,
+						FixMetadata: metadata,
+					})).
+					Times(1).
+					Return(port.UserRepositorySetUserLocationResponse{
+						User:         user,
+						PrevLocation: prev,
+						Location:     domain.Location{UserID: user.ID, Point: point, FixMetadata: metadata},
+					}, nil)
+				historyClient.EXPECT().
+					AddRecord(gomock.Any(), gomock.Any()).
+					Times(1).
+					Do(func(_ context.Context, req port.HistoryClientAddRecordRequest) {
+						require.Equal(s.T(), metadata, req.FixMetadata)
+					})
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, metadata, res.FixMetadata)
+			},
+		},
+		{
+			name:     "OK_Unknown",
+			metadata: geo.FixMetadata{},
+			buildStubs: func(repo *mock.MockUserRepository, historyClient *mock.MockHistoryClient) {
+				repo.EXPECT().
+					SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+						Username: user.Username,
+						Point:    point,
+					})).
+					Times(1).
+					Return(port.UserRepositorySetUserLocationResponse{
+						User:     user,
+						Location: domain.Location{UserID: user.ID, Point: point},
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.FixMetadata)
+			},
+		},
+		{
+			name:     "Err_NegativeAccuracy",
+			metadata: geo.FixMetadata{Accuracy: float(-1)},
+		},
+		{
+			name:     "Err_NegativeSpeed",
+			metadata: geo.FixMetadata{Speed: float(-1)},
+		},
+		{
+			name:     "Err_BearingOutOfRange",
+			metadata: geo.FixMetadata{Bearing: float(360)},
+		},
+		{
+			name:     "Err_NegativeBearing",
+			metadata: geo.FixMetadata{Bearing: float(-0.1)},
+		},
+		{
+			name:     "Err_UnknownSource",
+			metadata: geo.FixMetadata{Source: "satellite"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			historyClient := mock.NewMockHistoryClient(ctrl)
+			geofenceService := mock.NewMockGeofenceService(ctrl)
+			geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+			// Invalid metadata is rejected before reaching the repository.
+			if tc.buildStubs == nil {
+				repo.EXPECT().SetUserLocation(gomock.Any(), gomock.Any()).Times(0)
+				tc.assert = func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+					require.Empty(t, res)
+					require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+				}
+			} else {
+				tc.buildStubs(repo, historyClient)
+			}
+
+			svc := service.NewUserService(repo, historyClient, geofenceService, newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.SetUserLocation(context.Background(), port.UserServiceSetUserLocationRequest{
+				Username:    user.Username,
+				Longitude:   point.Longitude(),
+				Latitude:    point.Latitude(),
+				FixMetadata: tc.metadata,
+			})
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocations() {
+	now := time.Now().UTC()
+	user := domain.User{ID: 1, Username: "user1"}
+	a := geo.Point{10, 20}
+	b := geo.Point{10.123456789, 20}
+
+	type mocks struct {
+		repo            *mock.MockUserRepository
+		historyClient   *mock.MockHistoryClient
+		geofenceService *mock.MockGeofenceService
+		logger          *mocklog.MockLogger
+	}
+
+	testCases := []struct {
+		name       string
+		req        port.UserServiceSetUserLocationsRequest
+		buildStubs func(m mocks)
+		assert     func(t *testing.T, res port.UserServiceSetUserLocationsResponse, err error)
+	}{
+		{
+			name: "OK_AppliedInTimestampOrder",
+			req: port.UserServiceSetUserLocationsRequest{
+				Locations: []port.UserServiceSetUserLocationsItem{
+					{Username: "user1", Longitude: b.Longitude(), Latitude: b.Latitude(), Timestamp: now},
+					{Username: "user1", Longitude: 0, Latitude: 91, Timestamp: now},
+					{Username: "user1", Longitude: a.Longitude(), Latitude: a.Latitude(), Timestamp: now.Add(-time.Minute)},
+					{Username: "user1", Longitude: a.Longitude(), Latitude: a.Latitude()},
+				},
+			},
+			buildStubs: func(m mocks) {
+				truncatedB := geo.Trunc(b)
+				m.repo.EXPECT().
+					SetUserLocations(gomock.Any(), gomock.Eq([]port.UserRepositorySetUserLocationRequest{
+						{Username: "user1", Point: a, RecordedAt: now.Add(-time.Minute)},
+						{Username: "user1", Point: truncatedB, RecordedAt: now},
+					})).
+					Times(1).
+					Return([]port.UserRepositorySetUserLocationResponse{
+						{
+							User:     user,
+							Location: domain.Location{UserID: user.ID, Point: a},
+						},
+						{
+							User:         user,
+							PrevLocation: domain.Location{UserID: user.ID, Point: a},
+							Location:     domain.Location{UserID: user.ID, Point: truncatedB},
+						},
+					}, nil)
+				m.historyClient.EXPECT().
+					AddRecords(gomock.Any(), gomock.Eq(port.HistoryClientAddRecordsRequest{
+						Records: []port.HistoryClientAddRecordRequest{
+							{UserID: user.ID, A: a, B: truncatedB, Timestamp: now},
+						},
+					})).
+					Times(1)
+				gomock.InOrder(
+					m.geofenceService.EXPECT().
+						EvaluateLocation(gomock.Any(), gomock.Eq(port.GeofenceServiceEvaluateLocationRequest{
+							UserID:    user.ID,
+							Point:     a,
+							Timestamp: now.Add(-time.Minute),
+						})).
+						Times(1),
+					m.geofenceService.EXPECT().
+						EvaluateLocation(gomock.Any(), gomock.Eq(port.GeofenceServiceEvaluateLocationRequest{
+							UserID:    user.ID,
+							PrevPoint: &a,
+							Point:     truncatedB,
+							Timestamp: now,
+						})).
+						Times(1),
+				)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Results, 4)
+
+				require.NoError(t, res.Results[0].Err)
+				require.Equal(t, 10.12345678, res.Results[0].Longitude)
+				require.ErrorIs(t, res.Results[1].Err, errpack.ErrInvalidArgument)
+				require.NoError(t, res.Results[2].Err)
+				require.Equal(t, a.Longitude(), res.Results[2].Longitude)
+				require.ErrorIs(t, res.Results[3].Err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "OK_OutOfOrderRejected",
+			req: port.UserServiceSetUserLocationsRequest{
+				Locations: []port.UserServiceSetUserLocationsItem{
+					{Username: "user1", Longitude: a.Longitude(), Latitude: a.Latitude(), Timestamp: now.Add(-time.Hour)},
+					{Username: "user1", Longitude: a.Longitude(), Latitude: a.Latitude(), Timestamp: now.Add(time.Hour)},
+				},
+			},
+			buildStubs: func(m mocks) {
+				m.repo.EXPECT().
+					SetUserLocations(gomock.Any(), gomock.Eq([]port.UserRepositorySetUserLocationRequest{
+						{Username: "user1", Point: a, RecordedAt: now.Add(-time.Hour)},
+					})).
+					Times(1).
+					Return([]port.UserRepositorySetUserLocationResponse{
+						{
+							User:         user,
+							PrevLocation: domain.Location{UserID: user.ID, Point: a, RecordedAt: now},
+							Location:     domain.Location{UserID: user.ID, Point: a, RecordedAt: now},
+							OutOfOrder:   true,
+						},
+					}, nil)
+				m.historyClient.EXPECT().AddRecords(gomock.Any(), gomock.Any()).Times(0)
+				m.geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Results, 2)
+				require.ErrorIs(t, res.Results[0].Err, errpack.ErrFailedPrecondition)
+				require.ErrorIs(t, res.Results[1].Err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "OK_AllInvalid",
+			req: port.UserServiceSetUserLocationsRequest{
+				Locations: []port.UserServiceSetUserLocationsItem{
+					{Username: "u", Timestamp: now},
+				},
+			},
+			buildStubs: func(m mocks) {
+				m.repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+				m.historyClient.EXPECT().AddRecords(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationsResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Results, 1)
+				require.ErrorIs(t, res.Results[0].Err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "EmptyBatch",
+			req:  port.UserServiceSetUserLocationsRequest{},
+			buildStubs: func(m mocks) {
+				m.repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "BatchTooLarge",
+			req: port.UserServiceSetUserLocationsRequest{
+				Locations: make([]port.UserServiceSetUserLocationsItem, port.SetUserLocationsMaxBatchSize+1),
+			},
+			buildStubs: func(m mocks) {
+				m.repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InternalError",
+			req: port.UserServiceSetUserLocationsRequest{
+				Locations: []port.UserServiceSetUserLocationsItem{
+					{Username: "user1", Longitude: a.Longitude(), Latitude: a.Latitude(), Timestamp: now},
+				},
+			},
+			buildStubs: func(m mocks) {
+				m.repo.EXPECT().
+					SetUserLocations(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, errpack.ErrInternalError)
+				m.historyClient.EXPECT().AddRecords(gomock.Any(), gomock.Any()).Times(0)
+				m.geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).Times(0)
+				m.logger.EXPECT().Error(gomock.Any(), gomock.Any()).Times(1)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationsResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInternalError)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			m := mocks{
+				repo:            mock.NewMockUserRepository(ctrl),
+				historyClient:   mock.NewMockHistoryClient(ctrl),
+				geofenceService: mock.NewMockGeofenceService(ctrl),
+				logger:          mocklog.NewMockLogger(ctrl),
+			}
+			tc.buildStubs(m)
+			svc := service.NewUserService(m.repo, m.historyClient, m.geofenceService, newLocationBroker(ctrl), service.UserServiceConfig{}, m.logger)
+
+			res, err := svc.SetUserLocations(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
 func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 	testCases := []struct {
 		name       string
@@ -396,12 +890,14 @@ func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 					})).
 					Times(1).
 					Return(port.UserRepositoryListUsersInRadiusResponse{
-						Users: []domain.User{
+						Users: []domain.NearbyUser{
 							{
-								ID:        1,
-								Username:  "test",
-								CreatedAt: time.Now(),
-								UpdatedAt: time.Now(),
+								User: domain.User{
+									ID:        1,
+									Username:  "test",
+									CreatedAt: time.Now(),
+									UpdatedAt: time.Now(),
+								},
 							},
 						},
 						NextPageToken: 1,
@@ -435,12 +931,14 @@ func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 					})).
 					Times(1).
 					Return(port.UserRepositoryListUsersInRadiusResponse{
-						Users: []domain.User{
+						Users: []domain.NearbyUser{
 							{
-								ID:        1,
-								Username:  "test",
-								CreatedAt: time.Now(),
-								UpdatedAt: time.Now(),
+								User: domain.User{
+									ID:        1,
+									Username:  "test",
+									CreatedAt: time.Now(),
+									UpdatedAt: time.Now(),
+								},
 							},
 						},
 						NextPageToken: 0,
@@ -474,12 +972,14 @@ func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 					})).
 					Times(1).
 					Return(port.UserRepositoryListUsersInRadiusResponse{
-						Users: []domain.User{
+						Users: []domain.NearbyUser{
 							{
-								ID:        1,
-								Username:  "test",
-								CreatedAt: time.Now(),
-								UpdatedAt: time.Now(),
+								User: domain.User{
+									ID:        1,
+									Username:  "test",
+									CreatedAt: time.Now(),
+									UpdatedAt: time.Now(),
+								},
 							},
 						},
 						NextPageToken: 1,
@@ -495,6 +995,81 @@ func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 				require.Equal(t, "MSAxMA==", res.NextPageToken)
 			},
 		},
+		{
+			name: "OK_OrderByDistance_PageToken",
+			req: port.UserServiceListUsersInRadiusRequest{
+				Point:     geo.Point{0, 0},
+				Radius:    100,
+				PageToken: "MTIuNSA3IDEw",
+				OrderBy:   port.UsersOrderByDistance,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, historyClient *mock.MockHistoryClient) {
+				repo.EXPECT().
+					ListUsersInRadius(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInRadiusRequest{
+						Point:             geo.Point{0, 0},
+						Radius:            100,
+						PageToken:         7,
+						PageTokenDistance: 12.5,
+						PageSize:          10,
+						OrderBy:           port.UsersOrderByDistance,
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInRadiusResponse{
+						Users: []domain.NearbyUser{
+							{
+								User: domain.User{
+									ID:       3,
+									Username: "test",
+								},
+								Point:    geo.Point{0, 0.0002},
+								Distance: 22.25,
+							},
+						},
+						NextPageToken:         3,
+						NextPageTokenDistance: 22.25,
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInRadiusResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Users, 1)
+				require.Equal(t, 3, res.Users[0].ID)
+				require.Equal(t, 22.25, res.Users[0].Distance)
+				require.Equal(t, geo.Point{0, 0.0002}, res.Users[0].Point)
+				require.Equal(t, pagination.EncodeDistanceCursor(22.25, 3, 10), res.NextPageToken)
+			},
+		},
+		{
+			name: "InvalidPageToken_OrderByDistance",
+			req: port.UserServiceListUsersInRadiusRequest{
+				Point:     geo.Point{0, 0},
+				Radius:    100,
+				PageToken: "NyAxMA==",
+				OrderBy:   port.UsersOrderByDistance,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, historyClient *mock.MockHistoryClient) {
+				repo.EXPECT().ListUsersInRadius(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInRadiusResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidOrderBy",
+			req: port.UserServiceListUsersInRadiusRequest{
+				Point:    geo.Point{0, 0},
+				Radius:   100,
+				PageSize: 10,
+				OrderBy:  "username",
+			},
+			buildStubs: func(repo *mock.MockUserRepository, historyClient *mock.MockHistoryClient) {
+				repo.EXPECT().ListUsersInRadius(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInRadiusResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
 		{
 			name: "InvalidPoint_LongitudeLessThanMin",
 			req: port.UserServiceListUsersInRadiusRequest{
@@ -625,12 +1200,14 @@ func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 					})).
 					Times(1).
 					Return(port.UserRepositoryListUsersInRadiusResponse{
-						Users: []domain.User{
+						Users: []domain.NearbyUser{
 							{
-								ID:        1,
-								Username:  "test",
-								CreatedAt: time.Now(),
-								UpdatedAt: time.Now(),
+								User: domain.User{
+									ID:        1,
+									Username:  "test",
+									CreatedAt: time.Now(),
+									UpdatedAt: time.Now(),
+								},
 							},
 						},
 						NextPageToken: 1,
@@ -664,12 +1241,14 @@ func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 					})).
 					Times(1).
 					Return(port.UserRepositoryListUsersInRadiusResponse{
-						Users: []domain.User{
+						Users: []domain.NearbyUser{
 							{
-								ID:        1,
-								Username:  "test",
-								CreatedAt: time.Now(),
-								UpdatedAt: time.Now(),
+								User: domain.User{
+									ID:        1,
+									Username:  "test",
+									CreatedAt: time.Now(),
+									UpdatedAt: time.Now(),
+								},
 							},
 						},
 						NextPageToken: 1,
@@ -697,7 +1276,7 @@ func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 			historyClient := mock.NewMockHistoryClient(ctrl)
 			tc.buildStubs(repo, historyClient)
 			logger := mocklog.NewMockLogger(ctrl)
-			svc := service.NewUserService(repo, historyClient, logger)
+			svc := service.NewUserService(repo, historyClient, mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, logger)
 
 			res, err := svc.ListUsersInRadius(context.Background(), tc.req)
 
@@ -706,75 +1285,1504 @@ func (s *UserSvcTestSuite) Test_UserService_ListUsersInRadius() {
 	}
 }
 
-func (s *UserSvcTestSuite) Test_UserService_GetByUsername() {
-	users := []domain.User{
-		{
-			ID:        1,
-			Username:  "user1",
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
-		},
-	}
-	errInternal := errors.New("internal error")
-
+func (s *UserSvcTestSuite) Test_UserService_ListNearestUsers() {
 	testCases := []struct {
 		name       string
-		buildStubs func(repository *mock.MockUserRepository)
-		username   string
-		expected   domain.User
-		hasError   bool
-		isError    error
-		asError    error
+		req        port.UserServiceListNearestUsersRequest
+		buildStubs func(repo *mock.MockUserRepository, logger *mocklog.MockLogger)
+		assert     func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error)
 	}{
 		{
 			name: "OK",
+			req: port.UserServiceListNearestUsersRequest{
+				Point:           geo.Point{10.123456789, 20},
+				Limit:           2,
+				ExcludeUsername: "user1",
+				MaxDistance:     1000,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListNearestUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListNearestUsersRequest{
+						Point:           geo.Point{10.12345678, 20},
+						Limit:           2,
+						ExcludeUsername: "user1",
+						MaxDistance:     1000,
+					})).
+					Times(1).
+					Return([]domain.NearbyUser{
+						{
+							User:     domain.User{ID: 2, Username: "user2"},
+							Point:    geo.Point{10.1235, 20},
+							Distance: 4.5,
+						},
+						{
+							User:     domain.User{ID: 3, Username: "user3"},
+							Point:    geo.Point{10.124, 20},
+							Distance: 57.2,
+						},
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Users, 2)
+				require.Equal(t, "user2", res.Users[0].Username)
+				require.Equal(t, 4.5, res.Users[0].Distance)
+				require.Equal(t, "user3", res.Users[1].Username)
+				require.Equal(t, 57.2, res.Users[1].Distance)
+			},
+		},
+		{
+			name: "OK_NotFound",
+			req: port.UserServiceListNearestUsersRequest{
+				Point: geo.Point{0, 0},
+				Limit: 10,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListNearestUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res.Users)
+				require.Empty(t, res.Users)
+			},
+		},
+		{
+			name: "OK_ConnectionsScope",
+			req: port.UserServiceListNearestUsersRequest{
+				Point:  geo.Point{0, 0},
+				Limit:  10,
+				Scope:  port.UsersScopeConnections,
+				Caller: "user1",
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListNearestUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListNearestUsersRequest{
+						Point:         geo.Point{0, 0},
+						Limit:         10,
+						ConnectionsOf: "user1",
+						Caller:        "user1",
+					})).
+					Times(1).
+					Return([]domain.NearbyUser{{User: domain.User{ID: 2, Username: "user2"}}}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Users, 1)
+			},
+		},
+		{
+			name: "OK_AllScopeSkipsBlocksOnly",
+			req: port.UserServiceListNearestUsersRequest{
+				Point:  geo.Point{0, 0},
+				Limit:  10,
+				Scope:  port.UsersScopeAll,
+				Caller: "user1",
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListNearestUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListNearestUsersRequest{
+						Point:  geo.Point{0, 0},
+						Limit:  10,
+						Caller: "user1",
+					})).
+					Times(1).
+					Return(nil, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.Users)
+			},
+		},
+		{
+			name: "ConnectionsScopeWithoutCaller",
+			req: port.UserServiceListNearestUsersRequest{
+				Point: geo.Point{0, 0},
+				Limit: 10,
+				Scope: port.UsersScopeConnections,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListNearestUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidScope",
+			req: port.UserServiceListNearestUsersRequest{
+				Point: geo.Point{0, 0},
+				Limit: 10,
+				Scope: "friends",
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListNearestUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidPoint",
+			req: port.UserServiceListNearestUsersRequest{
+				Point: geo.Point{0, 90.1},
+				Limit: 10,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListNearestUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidLimit_eq_0",
+			req: port.UserServiceListNearestUsersRequest{
+				Point: geo.Point{0, 0},
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListNearestUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidLimit_TooBig",
+			req: port.UserServiceListNearestUsersRequest{
+				Point: geo.Point{0, 0},
+				Limit: 101,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListNearestUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidMaxDistance",
+			req: port.UserServiceListNearestUsersRequest{
+				Point:       geo.Point{0, 0},
+				Limit:       10,
+				MaxDistance: -1,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListNearestUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidExcludeUsername",
+			req: port.UserServiceListNearestUsersRequest{
+				Point:           geo.Point{0, 0},
+				Limit:           10,
+				ExcludeUsername: "user1_",
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListNearestUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InternalError",
+			req: port.UserServiceListNearestUsersRequest{
+				Point: geo.Point{0, 0},
+				Limit: 10,
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListNearestUsers(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, errpack.ErrInternalError)
+				logger.EXPECT().Error(gomock.Any(), gomock.Any()).Times(1)
+			},
+			assert: func(t *testing.T, res port.UserServiceListNearestUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInternalError)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			logger := mocklog.NewMockLogger(ctrl)
+			tc.buildStubs(repo, logger)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, logger)
+
+			res, err := svc.ListNearestUsers(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_ListUsersInBBox() {
+	testCases := []struct {
+		name       string
+		req        port.UserServiceListUsersInBBoxRequest
+		buildStubs func(repo *mock.MockUserRepository)
+		assert     func(t *testing.T, res port.UserServiceListUsersInBBoxResponse, err error)
+	}{
+		{
+			name: "OK_PageSize",
+			req: port.UserServiceListUsersInBBoxRequest{
+				BBox:     geo.BBox{170, -10, -170, 10},
+				PageSize: 1,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsersInBBox(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInBBoxRequest{
+						BBox:     geo.BBox{170, -10, -170, 10},
+						PageSize: 1,
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInAreaResponse{
+						Users: []domain.LocatedUser{
+							{
+								User:  domain.User{ID: 5, Username: "test"},
+								Point: geo.Point{-175, 0},
+							},
+						},
+						NextPageToken: 5,
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInBBoxResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Users, 1)
+				require.Equal(t, geo.Point{-175, 0}, res.Users[0].Point)
+				require.Equal(t, "80581b0bh2n0", res.Users[0].Geohash)
+				require.Equal(t, pagination.EncodeCursor(5, 1), res.NextPageToken)
+			},
+		},
+		{
+			name: "OK_PageToken_LastPage",
+			req: port.UserServiceListUsersInBBoxRequest{
+				BBox:      geo.BBox{-10, -10, 10, 10},
+				PageToken: pagination.EncodeCursor(5, 1),
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsersInBBox(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInBBoxRequest{
+						BBox:      geo.BBox{-10, -10, 10, 10},
+						PageToken: 5,
+						PageSize:  1,
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInAreaResponse{}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInBBoxResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res.Users)
+				require.Empty(t, res.Users)
+				require.Empty(t, res.NextPageToken)
+			},
+		},
+		{
+			name: "OK_ConnectionsScope",
+			req: port.UserServiceListUsersInBBoxRequest{
+				BBox:     geo.BBox{-10, -10, 10, 10},
+				PageSize: 10,
+				Scope:    port.UsersScopeConnections,
+				Caller:   "user1",
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsersInBBox(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInBBoxRequest{
+						BBox:          geo.BBox{-10, -10, 10, 10},
+						PageSize:      10,
+						ConnectionsOf: "user1",
+						Caller:        "user1",
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInAreaResponse{}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInBBoxResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.Users)
+			},
+		},
+		{
+			name: "ConnectionsScopeWithoutCaller",
+			req: port.UserServiceListUsersInBBoxRequest{
+				BBox:     geo.BBox{-10, -10, 10, 10},
+				PageSize: 10,
+				Scope:    port.UsersScopeConnections,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInBBoxResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidBBox",
+			req: port.UserServiceListUsersInBBoxRequest{
+				BBox:     geo.BBox{-10, 10, 10, -10},
+				PageSize: 1,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInBBoxResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "PageTokenAndPageSizeBothProvided",
+			req: port.UserServiceListUsersInBBoxRequest{
+				BBox:      geo.BBox{-10, -10, 10, 10},
+				PageToken: pagination.EncodeCursor(5, 1),
+				PageSize:  1,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInBBoxResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "InvalidPageToken",
+			req: port.UserServiceListUsersInBBoxRequest{
+				BBox:      geo.BBox{-10, -10, 10, 10},
+				PageToken: "invalid",
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInBBoxResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.ListUsersInBBox(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_ListUsersInPolygon() {
+	polygon := geo.Polygon{{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}, {170, 0}}}
+
+	testCases := []struct {
+		name       string
+		req        port.UserServiceListUsersInPolygonRequest
+		buildStubs func(repo *mock.MockUserRepository)
+		assert     func(t *testing.T, res port.UserServiceListUsersInPolygonResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: port.UserServiceListUsersInPolygonRequest{
+				Polygon:  polygon,
+				PageSize: 10,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsersInPolygon(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInPolygonRequest{
+						Polygon:  polygon,
+						PageSize: 10,
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInAreaResponse{
+						Users: []domain.LocatedUser{
+							{
+								User:  domain.User{ID: 5, Username: "test"},
+								Point: geo.Point{179, 5},
+							},
+						},
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInPolygonResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Users, 1)
+				require.Equal(t, "test", res.Users[0].Username)
+				require.Empty(t, res.NextPageToken)
+			},
+		},
+		{
+			name: "OK_ConnectionsScope",
+			req: port.UserServiceListUsersInPolygonRequest{
+				Polygon:  polygon,
+				PageSize: 10,
+				Scope:    port.UsersScopeConnections,
+				Caller:   "user1",
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsersInPolygon(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInPolygonRequest{
+						Polygon:       polygon,
+						PageSize:      10,
+						ConnectionsOf: "user1",
+						Caller:        "user1",
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInAreaResponse{}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInPolygonResponse, err error) {
+				require.NoError(t, err)
+				require.Empty(t, res.Users)
+			},
+		},
+		{
+			name: "InvalidPolygon",
+			req: port.UserServiceListUsersInPolygonRequest{
+				Polygon:  geo.Polygon{{{0, 0}, {10, 0}, {0, 0}}},
+				PageSize: 10,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInPolygon(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInPolygonResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "PageTokenAndPageSizeBothNotProvided",
+			req: port.UserServiceListUsersInPolygonRequest{
+				Polygon: polygon,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInPolygon(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersInPolygonResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.ListUsersInPolygon(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_GetByUsername() {
+	users := []domain.User{
+		{
+			ID:        1,
+			Username:  "user1",
+			CreatedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+	errInternal := errors.New("internal error")
+
+	testCases := []struct {
+		name       string
+		buildStubs func(repository *mock.MockUserRepository)
+		username   string
+		expected   domain.User
+		hasError   bool
+		isError    error
+		asError    error
+	}{
+		{
+			name: "OK",
+			buildStubs: func(repository *mock.MockUserRepository) {
+				repository.EXPECT().
+					GetByUsername(gomock.Any(), gomock.Eq(users[0].Username)).
+					Times(1).
+					Return(users[0], nil)
+			},
+			username: users[0].Username,
+			expected: users[0],
+			hasError: false,
+		},
+		{
+			name: "InvalidUsername",
+			buildStubs: func(repository *mock.MockUserRepository) {
+				repository.EXPECT().
+					GetByUsername(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			username: "",
+			expected: domain.User{},
+			hasError: true,
+			isError:  errpack.ErrInvalidArgument,
+		},
+		{
+			name: "UserNotFound",
+			buildStubs: func(repository *mock.MockUserRepository) {
+				repository.EXPECT().
+					GetByUsername(gomock.Any(), gomock.Eq(users[0].Username)).
+					Times(1).
+					Return(domain.User{}, errpack.ErrNotFound)
+			},
+			username: users[0].Username,
+			expected: domain.User{},
+			hasError: true,
+			isError:  errpack.ErrNotFound,
+		},
+		{
+			name: "InternalError",
 			buildStubs: func(repository *mock.MockUserRepository) {
 				repository.EXPECT().
 					GetByUsername(gomock.Any(), gomock.Eq(users[0].Username)).
 					Times(1).
-					Return(users[0], nil)
+					Return(domain.User{}, errInternal)
+			},
+			username: users[0].Username,
+			expected: domain.User{},
+			hasError: true,
+			isError:  errInternal,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			historyClient := mock.NewMockHistoryClient(ctrl)
+			tc.buildStubs(repo)
+			logger := mocklog.NewMockLogger(ctrl)
+			svc := service.NewUserService(repo, historyClient, mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, logger)
+
+			user, err := svc.GetByUsername(context.Background(), tc.username)
+			if tc.hasError {
+				if tc.isError != nil {
+					require.ErrorIs(t, err, tc.isError)
+				}
+				if tc.asError != nil {
+					require.ErrorAs(t, err, &tc.asError)
+				}
+			} else {
+				require.NoError(t, err)
+
+				require.Equal(t, tc.expected.ID, user.ID)
+				require.Equal(t, tc.expected.Username, user.Username)
+				require.Equal(t, tc.expected.CreatedAt, user.CreatedAt)
+				require.Equal(t, tc.expected.UpdatedAt, user.UpdatedAt)
+			}
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocation_AutoCreationDisabled() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	point := geo.Trunc(geo.Point{10, 20})
+
+	repo := mock.NewMockUserRepository(ctrl)
+	repo.EXPECT().
+		SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+			Username:                "user1",
+			Point:                   point,
+			DisableUserAutoCreation: true,
+		})).
+		Times(1).
+		Return(port.UserRepositorySetUserLocationResponse{}, errpack.ErrNotFound)
+
+	svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{
+		DisableUserAutoCreation: true,
+	}, mocklog.NewMockLogger(ctrl))
+
+	res, err := svc.SetUserLocation(context.Background(), port.UserServiceSetUserLocationRequest{
+		Username:  "user1",
+		Longitude: point.Longitude(),
+		Latitude:  point.Latitude(),
+	})
+	s.Require().Empty(res)
+	s.Require().ErrorIs(err, errpack.ErrNotFound)
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocations_AutoCreationDisabled() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	now := time.Now().UTC()
+	point := geo.Trunc(geo.Point{10, 20})
+
+	repo := mock.NewMockUserRepository(ctrl)
+	repo.EXPECT().
+		SetUserLocations(gomock.Any(), gomock.Eq([]port.UserRepositorySetUserLocationRequest{
+			{Username: "user1", Point: point, RecordedAt: now, DisableUserAutoCreation: true},
+		})).
+		Times(1).
+		Return(nil, fmt.Errorf("%w", errpack.ErrNotFound))
+
+	svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{
+		DisableUserAutoCreation: true,
+	}, mocklog.NewMockLogger(ctrl))
+
+	res, err := svc.SetUserLocations(context.Background(), port.UserServiceSetUserLocationsRequest{
+		Locations: []port.UserServiceSetUserLocationsItem{
+			{Username: "user1", Longitude: point.Longitude(), Latitude: point.Latitude(), Timestamp: now},
+		},
+	})
+	s.Require().Empty(res)
+	s.Require().ErrorIs(err, errpack.ErrNotFound)
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocation_Geohash() {
+	user := domain.User{ID: 1, Username: "user1"}
+	center := geo.Point{-5.60302734, 42.60498046}
+
+	testCases := []struct {
+		name       string
+		req        port.UserServiceSetUserLocationRequest
+		buildStubs func(repo *mock.MockUserRepository)
+		assert     func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error)
+	}{
+		{
+			name: "OK",
+			req: port.UserServiceSetUserLocationRequest{
+				Username: user.Username,
+				Geohash:  "ezs42",
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					SetUserLocation(gomock.Any(), EqUserRepositorySetUserLocationRequest(port.UserRepositorySetUserLocationRequest{
+						Username: user.Username,
+						Point:    center,
+					})).
+					Times(1).
+					Return(port.UserRepositorySetUserLocationResponse{
+						User:     user,
+						Location: domain.Location{UserID: user.ID, Point: center},
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, center.Longitude(), res.Longitude)
+				require.Equal(t, center.Latitude(), res.Latitude)
+				require.Equal(t, "ezs42", res.Geohash[:5])
+			},
+		},
+		{
+			name: "InvalidGeohash",
+			req: port.UserServiceSetUserLocationRequest{
+				Username: user.Username,
+				Geohash:  "ezs4a",
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().SetUserLocation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "GeohashWithCoordinates",
+			req: port.UserServiceSetUserLocationRequest{
+				Username:  user.Username,
+				Longitude: 10,
+				Latitude:  20,
+				Geohash:   "ezs42",
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().SetUserLocation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceSetUserLocationResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+
+			geofenceService := mock.NewMockGeofenceService(ctrl)
+			geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), geofenceService, newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.SetUserLocation(context.Background(), tc.req)
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_ListUsers_MaxAge() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	point := geo.Point{10, 20}
+	bbox := geo.BBox{-1, -1, 1, 1}
+	polygon := geo.Polygon{{{-1, -1}, {1, -1}, {1, 1}, {-1, 1}, {-1, -1}}}
+
+	repo := mock.NewMockUserRepository(ctrl)
+	// The default maximum age is used unless another one is requested.
+	repo.EXPECT().
+		ListUsersInRadius(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInRadiusRequest{
+			Point:    point,
+			Radius:   100,
+			PageSize: 10,
+			MaxAge:   time.Hour,
+		})).
+		Times(1).
+		Return(port.UserRepositoryListUsersInRadiusResponse{}, nil)
+	repo.EXPECT().
+		ListNearestUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListNearestUsersRequest{
+			Point:  point,
+			Limit:  10,
+			MaxAge: 10 * time.Minute,
+		})).
+		Times(1).
+		Return(nil, nil)
+	repo.EXPECT().
+		ListUsersInBBox(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInBBoxRequest{
+			BBox:     bbox,
+			PageSize: 10,
+			MaxAge:   time.Hour,
+		})).
+		Times(1).
+		Return(port.UserRepositoryListUsersInAreaResponse{}, nil)
+	repo.EXPECT().
+		ListUsersInPolygon(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInPolygonRequest{
+			Polygon:  polygon,
+			PageSize: 10,
+			MaxAge:   48 * time.Hour,
+		})).
+		Times(1).
+		Return(port.UserRepositoryListUsersInAreaResponse{}, nil)
+
+	svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{
+		MaxLocationAge: time.Hour,
+	}, mocklog.NewMockLogger(ctrl))
+	ctx := context.Background()
+
+	_, err := svc.ListUsersInRadius(ctx, port.UserServiceListUsersInRadiusRequest{Point: point, Radius: 100, PageSize: 10})
+	s.Require().NoError(err)
+
+	_, err = svc.ListNearestUsers(ctx, port.UserServiceListNearestUsersRequest{Point: point, Limit: 10, MaxAge: 10 * time.Minute})
+	s.Require().NoError(err)
+
+	_, err = svc.ListUsersInBBox(ctx, port.UserServiceListUsersInBBoxRequest{BBox: bbox, PageSize: 10})
+	s.Require().NoError(err)
+
+	_, err = svc.ListUsersInPolygon(ctx, port.UserServiceListUsersInPolygonRequest{Polygon: polygon, PageSize: 10, MaxAge: 48 * time.Hour})
+	s.Require().NoError(err)
+
+	_, err = svc.ListUsersInBBox(ctx, port.UserServiceListUsersInBBoxRequest{BBox: bbox, PageSize: 10, MaxAge: -time.Second})
+	s.Require().ErrorIs(err, errpack.ErrInvalidArgument)
+}
+
+func (s *UserSvcTestSuite) Test_UserService_CreateUser() {
+	user := domain.User{ID: 1, Username: "user1", CreatedAt: time.Now(), UpdatedAt: time.Now()}
+
+	testCases := []struct {
+		name       string
+		req        port.UserServiceCreateUserRequest
+		buildStubs func(repo *mock.MockUserRepository)
+		assert     func(t *testing.T, res domain.User, err error)
+	}{
+		{
+			name: "OK",
+			req:  port.UserServiceCreateUserRequest{Username: user.Username},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					CreateUser(gomock.Any(), gomock.Eq(port.CreateUserArg{Username: user.Username})).
+					Times(1).
+					Return(user, nil)
+			},
+			assert: func(t *testing.T, res domain.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user, res)
+			},
+		},
+		{
+			name: "InvalidUsername",
+			req:  port.UserServiceCreateUserRequest{Username: "user1_"},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().CreateUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res domain.User, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "AlreadyExists",
+			req:  port.UserServiceCreateUserRequest{Username: user.Username},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					CreateUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(domain.User{}, errpack.ErrAlreadyExists)
+			},
+			assert: func(t *testing.T, res domain.User, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrAlreadyExists)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.CreateUser(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_GetUser() {
+	user := domain.User{ID: 1, Username: "user1"}
+	location := domain.Location{
+		UserID:     user.ID,
+		Point:      geo.Point{10, 20},
+		RecordedAt: time.Now().Add(-time.Minute),
+		UpdatedAt:  time.Now(),
+	}
+
+	testCases := []struct {
+		name       string
+		username   string
+		buildStubs func(repo *mock.MockUserRepository)
+		assert     func(t *testing.T, res port.UserServiceGetUserResponse, err error)
+	}{
+		{
+			name:     "OK",
+			username: user.Username,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetUserWithLocation(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(port.UserRepositoryGetUserWithLocationResponse{User: user, Location: &location}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceGetUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user, res.User)
+				require.Equal(t, &location, res.Location)
+				require.NotNil(t, res.LastSeenAt)
+				require.Equal(t, location.UpdatedAt, *res.LastSeenAt)
+			},
+		},
+		{
+			name:     "OK_NoLocation",
+			username: user.Username,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetUserWithLocation(gomock.Any(), gomock.Eq(user.Username)).
+					Times(1).
+					Return(port.UserRepositoryGetUserWithLocationResponse{User: user}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceGetUserResponse, err error) {
+				require.NoError(t, err)
+				require.Equal(t, user, res.User)
+				require.Nil(t, res.Location)
+				require.Nil(t, res.LastSeenAt)
+			},
+		},
+		{
+			name:     "InvalidUsername",
+			username: "u",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().GetUserWithLocation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceGetUserResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name:     "NotFound",
+			username: user.Username,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					GetUserWithLocation(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositoryGetUserWithLocationResponse{}, errpack.ErrNotFound)
+			},
+			assert: func(t *testing.T, res port.UserServiceGetUserResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrNotFound)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.GetUser(context.Background(), tc.username)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_ListUsers() {
+	testCases := []struct {
+		name       string
+		req        port.UserServiceListUsersRequest
+		buildStubs func(repo *mock.MockUserRepository)
+		assert     func(t *testing.T, res port.UserServiceListUsersResponse, err error)
+	}{
+		{
+			name: "OK_Prefix",
+			req: port.UserServiceListUsersRequest{
+				Prefix:   "user",
+				PageSize: 1,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersRequest{
+						Prefix:   "user",
+						PageSize: 1,
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersResponse{
+						Users:         []domain.User{{ID: 3, Username: "user1"}},
+						NextPageToken: 3,
+					}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersResponse, err error) {
+				require.NoError(t, err)
+				require.Len(t, res.Users, 1)
+				require.Equal(t, pagination.EncodeCursor(3, 1), res.NextPageToken)
+			},
+		},
+		{
+			name: "OK_PageToken_Empty",
+			req: port.UserServiceListUsersRequest{
+				PageToken: pagination.EncodeCursor(3, 1),
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					ListUsers(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersRequest{
+						PageToken: 3,
+						PageSize:  1,
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersResponse{}, nil)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersResponse, err error) {
+				require.NoError(t, err)
+				require.NotNil(t, res.Users)
+				require.Empty(t, res.Users)
+				require.Empty(t, res.NextPageToken)
+			},
+		},
+		{
+			name: "InvalidPrefix",
+			req: port.UserServiceListUsersRequest{
+				Prefix:   "user%",
+				PageSize: 1,
+			},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "PageTokenAndPageSizeBothNotProvided",
+			req:  port.UserServiceListUsersRequest{},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res port.UserServiceListUsersResponse, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.ListUsers(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_RenameUser() {
+	renamed := domain.User{ID: 1, Username: "user2"}
+
+	testCases := []struct {
+		name       string
+		req        port.UserServiceRenameUserRequest
+		buildStubs func(repo *mock.MockUserRepository)
+		assert     func(t *testing.T, res domain.User, err error)
+	}{
+		{
+			name: "OK",
+			req:  port.UserServiceRenameUserRequest{Username: "user1", NewUsername: "user2"},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					RenameUser(gomock.Any(), gomock.Eq(port.UserRepositoryRenameUserRequest{
+						Username:    "user1",
+						NewUsername: "user2",
+					})).
+					Times(1).
+					Return(renamed, nil)
+			},
+			assert: func(t *testing.T, res domain.User, err error) {
+				require.NoError(t, err)
+				require.Equal(t, renamed, res)
+			},
+		},
+		{
+			name: "InvalidNewUsername",
+			req:  port.UserServiceRenameUserRequest{Username: "user1", NewUsername: "u"},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().RenameUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			assert: func(t *testing.T, res domain.User, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrInvalidArgument)
+			},
+		},
+		{
+			name: "AlreadyExists",
+			req:  port.UserServiceRenameUserRequest{Username: "user1", NewUsername: "user2"},
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					RenameUser(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(domain.User{}, errpack.ErrAlreadyExists)
+			},
+			assert: func(t *testing.T, res domain.User, err error) {
+				require.Empty(t, res)
+				require.ErrorIs(t, err, errpack.ErrAlreadyExists)
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.RenameUser(context.Background(), tc.req)
+
+			tc.assert(t, res, err)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_DeleteUser() {
+	job := domain.ErasureJob{
+		ID:       1,
+		UserID:   1,
+		Username: "user1",
+		Status:   domain.ErasureJobPending,
+	}
+
+	testCases := []struct {
+		name       string
+		username   string
+		buildStubs func(repo *mock.MockUserRepository)
+		isError    error
+	}{
+		{
+			name:     "OK",
+			username: "user1",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().DeleteUser(gomock.Any(), gomock.Eq("user1")).Times(1).Return(job, nil)
+			},
+		},
+		{
+			name:     "InvalidUsername",
+			username: "",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().DeleteUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name:     "NotFound",
+			username: "user1",
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().DeleteUser(gomock.Any(), gomock.Eq("user1")).Times(1).Return(domain.ErasureJob{}, errpack.ErrNotFound)
+			},
+			isError: errpack.ErrNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.DeleteUser(context.Background(), tc.username)
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
+				require.Empty(t, res)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, job, res)
+			}
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocation_Publish() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	user := domain.User{ID: 1, Username: "user1"}
+	location := domain.Location{UserID: user.ID, Point: geo.Point{10, 20}, UpdatedAt: time.Now()}
+
+	repo := mock.NewMockUserRepository(ctrl)
+	repo.EXPECT().
+		SetUserLocation(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositorySetUserLocationResponse{User: user, Location: location}, nil)
+	// Ignored out-of-order locations are not published.
+	repo.EXPECT().
+		SetUserLocation(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositorySetUserLocationResponse{User: user, Location: location, OutOfOrder: true}, nil)
+	geofenceService := mock.NewMockGeofenceService(ctrl)
+	geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).Times(1)
+	broker := mock.NewMockLocationBroker(ctrl)
+	broker.EXPECT().
+		Publish(gomock.Eq(domain.LocatedUser{User: user, Point: location.Point, LocationUpdatedAt: location.UpdatedAt})).
+		Times(1)
+
+	svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), geofenceService, broker, service.UserServiceConfig{
+		OutOfOrderPolicy: service.OutOfOrderPolicyIgnore,
+	}, mocklog.NewMockLogger(ctrl))
+
+	req := port.UserServiceSetUserLocationRequest{Username: user.Username, Longitude: 10, Latitude: 20}
+	_, err := svc.SetUserLocation(context.Background(), req)
+	require.NoError(s.T(), err)
+	_, err = svc.SetUserLocation(context.Background(), req)
+	require.NoError(s.T(), err)
+}
+
+func (s *UserSvcTestSuite) Test_UserService_WatchUsersInRadius() {
+	center := geo.Point{10, 20}
+	inside := geo.Point{10.001, 20}
+	outside := geo.Point{11, 20}
+	now := time.Now().UTC()
+	located := func(id int, point geo.Point, updatedAt time.Time) domain.LocatedUser {
+		return domain.LocatedUser{User: domain.User{ID: id}, Point: point, LocationUpdatedAt: updatedAt}
+	}
+	nearby := func(id int, point geo.Point, updatedAt time.Time) domain.NearbyUser {
+		return domain.NearbyUser{
+			User:              domain.User{ID: id},
+			Point:             point,
+			Distance:          geo.Distance(center, point),
+			LocationUpdatedAt: updatedAt,
+		}
+	}
+
+	testCases := []struct {
+		name       string
+		req        port.UserServiceWatchUsersInRadiusRequest
+		buildStubs func(repo *mock.MockUserRepository, logger *mocklog.MockLogger)
+		// updates are delivered to the subscription, it is closed with `subErr` after them if `closeSub` is true.
+		updates  []domain.LocatedUser
+		closeSub bool
+		subErr   error
+		sendErr  error
+		expected []domain.RadiusEvent
+		isError  error
+	}{
+		{
+			name: "OK",
+			req:  port.UserServiceWatchUsersInRadiusRequest{Point: center, Radius: 1000, MaxAge: time.Hour},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				gomock.InOrder(
+					repo.EXPECT().
+						ListUsersInRadius(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInRadiusRequest{
+							Point:    center,
+							Radius:   1000,
+							PageSize: 100,
+							MaxAge:   time.Hour,
+						})).
+						Times(1).
+						Return(port.UserRepositoryListUsersInRadiusResponse{
+							Users:         []domain.NearbyUser{nearby(1, inside, now)},
+							NextPageToken: 1,
+						}, nil),
+					repo.EXPECT().
+						ListUsersInRadius(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInRadiusRequest{
+							Point:     center,
+							Radius:    1000,
+							PageToken: 1,
+							PageSize:  100,
+							MaxAge:    time.Hour,
+						})).
+						Times(1).
+						Return(port.UserRepositoryListUsersInRadiusResponse{
+							Users: []domain.NearbyUser{nearby(2, center, now)},
+						}, nil),
+				)
+			},
+			updates: []domain.LocatedUser{
+				// Stale update of a member is skipped.
+				located(1, outside, now.Add(-time.Second)),
+				located(3, inside, now.Add(time.Second)),
+				located(1, center, now.Add(time.Second)),
+				located(2, outside, now.Add(time.Second)),
+				// Users outside the circle which are not members are skipped.
+				located(4, outside, now.Add(time.Second)),
+			},
+			expected: []domain.RadiusEvent{
+				{Type: domain.RadiusEventEnter, User: nearby(1, inside, now)},
+				{Type: domain.RadiusEventEnter, User: nearby(2, center, now)},
+				{Type: domain.RadiusEventEnter, User: nearby(3, inside, now.Add(time.Second))},
+				{Type: domain.RadiusEventMove, User: nearby(1, center, now.Add(time.Second))},
+				{Type: domain.RadiusEventExit, User: nearby(2, outside, now.Add(time.Second))},
+			},
+		},
+		{
+			name: "OK_ConnectionsScope",
+			req: port.UserServiceWatchUsersInRadiusRequest{
+				Point:  center,
+				Radius: 1000,
+				Scope:  port.UsersScopeConnections,
+				Caller: "user1",
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListUsersInRadius(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInRadiusRequest{
+						Point:         center,
+						Radius:        1000,
+						PageSize:      100,
+						ConnectionsOf: "user1",
+						Caller:        "user1",
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInRadiusResponse{
+						Users: []domain.NearbyUser{nearby(2, inside, now)},
+					}, nil)
+				repo.EXPECT().IsBlocked(gomock.Any(), "user1", gomock.Any()).AnyTimes().Return(false, nil)
+				gomock.InOrder(
+					repo.EXPECT().IsConnection(gomock.Any(), "user1", 3).Times(1).Return(false, nil),
+					repo.EXPECT().IsConnection(gomock.Any(), "user1", 2).Times(1).Return(false, nil),
+					repo.EXPECT().IsConnection(gomock.Any(), "user1", 4).Times(1).Return(true, nil),
+					repo.EXPECT().IsConnection(gomock.Any(), "user1", 2).Times(1).Return(true, nil),
+				)
+			},
+			updates: []domain.LocatedUser{
+				// Updates of users who are not connections are skipped.
+				located(3, inside, now.Add(time.Second)),
+				// A member who is no longer a connection leaves the circle silently.
+				located(2, center, now.Add(time.Second)),
+				located(4, inside, now.Add(time.Second)),
+				located(2, center, now.Add(2*time.Second)),
+			},
+			expected: []domain.RadiusEvent{
+				{Type: domain.RadiusEventEnter, User: nearby(2, inside, now)},
+				{Type: domain.RadiusEventEnter, User: nearby(4, inside, now.Add(time.Second))},
+				{Type: domain.RadiusEventEnter, User: nearby(2, center, now.Add(2*time.Second))},
 			},
-			username: users[0].Username,
-			expected: users[0],
-			hasError: false,
 		},
 		{
-			name: "InvalidUsername",
-			buildStubs: func(repository *mock.MockUserRepository) {
-				repository.EXPECT().
-					GetByUsername(gomock.Any(), gomock.Any()).
-					Times(0)
+			name: "OK_BlockedUsersSkipped",
+			req: port.UserServiceWatchUsersInRadiusRequest{
+				Point:  center,
+				Radius: 1000,
+				Caller: "user1",
 			},
-			username: "",
-			expected: domain.User{},
-			hasError: true,
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListUsersInRadius(gomock.Any(), gomock.Eq(port.UserRepositoryListUsersInRadiusRequest{
+						Point:    center,
+						Radius:   1000,
+						PageSize: 100,
+						Caller:   "user1",
+					})).
+					Times(1).
+					Return(port.UserRepositoryListUsersInRadiusResponse{
+						Users: []domain.NearbyUser{nearby(2, inside, now)},
+					}, nil)
+				repo.EXPECT().IsConnection(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+				gomock.InOrder(
+					repo.EXPECT().IsBlocked(gomock.Any(), "user1", 3).Times(1).Return(true, nil),
+					repo.EXPECT().IsBlocked(gomock.Any(), "user1", 2).Times(1).Return(true, nil),
+					repo.EXPECT().IsBlocked(gomock.Any(), "user1", 4).Times(1).Return(false, nil),
+				)
+			},
+			updates: []domain.LocatedUser{
+				// Updates of blocked users are skipped.
+				located(3, inside, now.Add(time.Second)),
+				// A member who is blocked leaves the circle silently.
+				located(2, center, now.Add(time.Second)),
+				located(4, inside, now.Add(time.Second)),
+			},
+			expected: []domain.RadiusEvent{
+				{Type: domain.RadiusEventEnter, User: nearby(2, inside, now)},
+				{Type: domain.RadiusEventEnter, User: nearby(4, inside, now.Add(time.Second))},
+			},
+		},
+		{
+			name: "ConnectionsScopeWithoutCaller",
+			req:  port.UserServiceWatchUsersInRadiusRequest{Point: center, Radius: 1000, Scope: port.UsersScopeConnections},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListUsersInRadius(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expected: []domain.RadiusEvent{},
 			isError:  errpack.ErrInvalidArgument,
 		},
 		{
-			name: "UserNotFound",
-			buildStubs: func(repository *mock.MockUserRepository) {
-				repository.EXPECT().
-					GetByUsername(gomock.Any(), gomock.Eq(users[0].Username)).
+			name: "IsConnectionInternalError",
+			req: port.UserServiceWatchUsersInRadiusRequest{
+				Point:  center,
+				Radius: 1000,
+				Scope:  port.UsersScopeConnections,
+				Caller: "user1",
+			},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListUsersInRadius(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(domain.User{}, errpack.ErrNotFound)
+					Return(port.UserRepositoryListUsersInRadiusResponse{}, nil)
+				repo.EXPECT().IsBlocked(gomock.Any(), "user1", 1).Times(1).Return(false, nil)
+				repo.EXPECT().
+					IsConnection(gomock.Any(), "user1", 1).
+					Times(1).
+					Return(false, errpack.ErrInternalError)
+				logger.EXPECT().Error(gomock.Any(), gomock.Any()).Times(1)
 			},
-			username: users[0].Username,
-			expected: domain.User{},
-			hasError: true,
-			isError:  errpack.ErrNotFound,
+			updates:  []domain.LocatedUser{located(1, inside, now)},
+			expected: []domain.RadiusEvent{},
+			isError:  errpack.ErrInternalError,
+		},
+		{
+			name: "FellBehind",
+			req:  port.UserServiceWatchUsersInRadiusRequest{Point: center, Radius: 1000},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListUsersInRadius(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositoryListUsersInRadiusResponse{}, nil)
+			},
+			updates:  []domain.LocatedUser{located(1, inside, now)},
+			closeSub: true,
+			subErr:   errpack.ErrResourceExhausted,
+			expected: []domain.RadiusEvent{
+				{Type: domain.RadiusEventEnter, User: nearby(1, inside, now)},
+			},
+			isError: errpack.ErrResourceExhausted,
+		},
+		{
+			name: "SendError",
+			req:  port.UserServiceWatchUsersInRadiusRequest{Point: center, Radius: 1000},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListUsersInRadius(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(port.UserRepositoryListUsersInRadiusResponse{
+						Users: []domain.NearbyUser{nearby(1, inside, now)},
+					}, nil)
+			},
+			sendErr:  context.Canceled,
+			expected: []domain.RadiusEvent{},
+			isError:  context.Canceled,
+		},
+		{
+			name: "InvalidPoint",
+			req:  port.UserServiceWatchUsersInRadiusRequest{Point: geo.Point{200, 20}, Radius: 1000},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().ListUsersInRadius(gomock.Any(), gomock.Any()).Times(0)
+			},
+			expected: []domain.RadiusEvent{},
+			isError:  errpack.ErrInvalidArgument,
 		},
 		{
 			name: "InternalError",
-			buildStubs: func(repository *mock.MockUserRepository) {
-				repository.EXPECT().
-					GetByUsername(gomock.Any(), gomock.Eq(users[0].Username)).
+			req:  port.UserServiceWatchUsersInRadiusRequest{Point: center, Radius: 1000},
+			buildStubs: func(repo *mock.MockUserRepository, logger *mocklog.MockLogger) {
+				repo.EXPECT().
+					ListUsersInRadius(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(domain.User{}, errInternal)
+					Return(port.UserRepositoryListUsersInRadiusResponse{}, errpack.ErrInternalError)
+				logger.EXPECT().Error(gomock.Any(), gomock.Any()).Times(1)
 			},
-			username: users[0].Username,
-			expected: domain.User{},
-			hasError: true,
-			isError:  errInternal,
+			expected: []domain.RadiusEvent{},
+			isError:  errpack.ErrInternalError,
 		},
 	}
 
@@ -785,27 +2793,646 @@ func (s *UserSvcTestSuite) Test_UserService_GetByUsername() {
 			defer ctrl.Finish()
 
 			repo := mock.NewMockUserRepository(ctrl)
-			historyClient := mock.NewMockHistoryClient(ctrl)
-			tc.buildStubs(repo)
 			logger := mocklog.NewMockLogger(ctrl)
-			svc := service.NewUserService(repo, historyClient, logger)
+			tc.buildStubs(repo, logger)
 
-			user, err := svc.GetByUsername(context.Background(), tc.username)
-			if tc.hasError {
-				if tc.isError != nil {
-					require.ErrorIs(t, err, tc.isError)
+			updates := make(chan domain.LocatedUser, len(tc.updates))
+			for _, update := range tc.updates {
+				updates <- update
+			}
+			if tc.closeSub {
+				close(updates)
+			}
+			sub := mock.NewMockLocationSubscription(ctrl)
+			sub.EXPECT().Updates().Return(updates).AnyTimes()
+			sub.EXPECT().Err().Return(tc.subErr).AnyTimes()
+			sub.EXPECT().Close().AnyTimes()
+			broker := mock.NewMockLocationBroker(ctrl)
+			broker.EXPECT().Subscribe(gomock.Any()).Return(sub).AnyTimes()
+
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker, service.UserServiceConfig{}, logger)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			events := make([]domain.RadiusEvent, 0)
+			err := svc.WatchUsersInRadius(ctx, tc.req, func(event domain.RadiusEvent) error {
+				if tc.sendErr != nil {
+					return tc.sendErr
 				}
-				if tc.asError != nil {
-					require.ErrorAs(t, err, &tc.asError)
+				events = append(events, event)
+				// The watch is stopped once all the expected events are sent unless the subscription ends first.
+				if len(events) == len(tc.expected) && !tc.closeSub {
+					cancel()
 				}
+				return nil
+			})
+
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
 			} else {
 				require.NoError(t, err)
+			}
+			require.Len(t, events, len(tc.expected))
+			for i, expected := range tc.expected {
+				require.Equal(t, expected.Type, events[i].Type)
+				require.Equal(t, expected.User.ID, events[i].User.ID)
+				require.Equal(t, expected.User.Point, events[i].User.Point)
+				require.InDelta(t, expected.User.Distance, events[i].User.Distance, 0.01)
+				require.True(t, expected.User.LocationUpdatedAt.Equal(events[i].User.LocationUpdatedAt))
+			}
+		})
+	}
+}
 
-				require.Equal(t, tc.expected.ID, user.ID)
-				require.Equal(t, tc.expected.Username, user.Username)
-				require.Equal(t, tc.expected.CreatedAt, user.CreatedAt)
-				require.Equal(t, tc.expected.UpdatedAt, user.UpdatedAt)
+func (s *UserSvcTestSuite) Test_UserService_SubscribeLocations() {
+	located := func(id int, username string, point geo.Point) domain.LocatedUser {
+		return domain.LocatedUser{User: domain.User{ID: id, Username: username}, Point: point}
+	}
+	bbox := geo.BBox{0, 0, 10, 10}
+	usernames := make([]string, 101)
+	for i := range usernames {
+		usernames[i] = fmt.Sprintf("user%d", i)
+	}
+
+	testCases := []struct {
+		name     string
+		req      port.UserServiceSubscribeLocationsRequest
+		updates  []domain.LocatedUser
+		subErr   error
+		expected []domain.LocatedUser
+		isError  error
+	}{
+		{
+			name: "OK_Usernames",
+			req:  port.UserServiceSubscribeLocationsRequest{Usernames: []string{"user1", "user3"}},
+			updates: []domain.LocatedUser{
+				located(1, "user1", geo.Point{1, 1}),
+				located(2, "user2", geo.Point{1, 1}),
+				located(3, "user3", geo.Point{50, 50}),
+			},
+			expected: []domain.LocatedUser{
+				located(1, "user1", geo.Point{1, 1}),
+				located(3, "user3", geo.Point{50, 50}),
+			},
+		},
+		{
+			name: "OK_BBox",
+			req:  port.UserServiceSubscribeLocationsRequest{BBox: &bbox},
+			updates: []domain.LocatedUser{
+				located(1, "user1", geo.Point{1, 1}),
+				located(2, "user2", geo.Point{50, 50}),
+			},
+			expected: []domain.LocatedUser{
+				located(1, "user1", geo.Point{1, 1}),
+			},
+		},
+		{
+			name:     "FellBehind",
+			req:      port.UserServiceSubscribeLocationsRequest{Usernames: []string{"user1"}},
+			updates:  []domain.LocatedUser{located(1, "user1", geo.Point{1, 1})},
+			subErr:   errpack.ErrResourceExhausted,
+			expected: []domain.LocatedUser{located(1, "user1", geo.Point{1, 1})},
+		},
+		{
+			name:    "NeitherUsernamesNorBBox",
+			req:     port.UserServiceSubscribeLocationsRequest{},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name:    "BothUsernamesAndBBox",
+			req:     port.UserServiceSubscribeLocationsRequest{Usernames: []string{"user1"}, BBox: &bbox},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name:    "InvalidUsername",
+			req:     port.UserServiceSubscribeLocationsRequest{Usernames: []string{""}},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name:    "TooManyUsernames",
+			req:     port.UserServiceSubscribeLocationsRequest{Usernames: usernames},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name:    "ConnectionsScopeWithoutCaller",
+			req:     port.UserServiceSubscribeLocationsRequest{Usernames: []string{"user1"}, Scope: port.UsersScopeConnections},
+			isError: errpack.ErrInvalidArgument,
+		},
+		{
+			name:    "InvalidBBox",
+			req:     port.UserServiceSubscribeLocationsRequest{BBox: &geo.BBox{0, 10, 10, 0}},
+			isError: errpack.ErrInvalidArgument,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			updates := make(chan domain.LocatedUser, len(tc.updates))
+			for _, update := range tc.updates {
+				updates <- update
+			}
+			// The subscription is only ended by the broker if it falls behind.
+			if tc.subErr != nil {
+				close(updates)
+			}
+			sub := mock.NewMockLocationSubscription(ctrl)
+			sub.EXPECT().Updates().Return(updates).AnyTimes()
+			sub.EXPECT().Err().Return(tc.subErr).AnyTimes()
+			broker := mock.NewMockLocationBroker(ctrl)
+			if tc.isError == nil {
+				broker.EXPECT().Subscribe(gomock.Any()).Times(1).Return(sub)
+				// The wrapped subscription must be closed once the subscription ends.
+				sub.EXPECT().Close().Times(1)
+			}
+
+			svc := service.NewUserService(mock.NewMockUserRepository(ctrl), mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker, service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			res, err := svc.SubscribeLocations(context.Background(), tc.req)
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
+				require.Nil(t, res)
+				return
+			}
+			require.NoError(t, err)
+
+			for _, expected := range tc.expected {
+				require.Equal(t, expected, <-res.Updates())
+			}
+
+			if tc.subErr == nil {
+				res.Close()
+				res.Close()
+			}
+			_, ok := <-res.Updates()
+			require.False(t, ok)
+			require.ErrorIs(t, res.Err(), tc.subErr)
+		})
+	}
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SubscribeLocations_ConnectionsScope() {
+	located := func(id int, username string) domain.LocatedUser {
+		return domain.LocatedUser{User: domain.User{ID: id, Username: username}, Point: geo.Point{1, 1}}
+	}
+	req := port.UserServiceSubscribeLocationsRequest{
+		Usernames: []string{"user2", "user3"},
+		Scope:     port.UsersScopeConnections,
+		Caller:    "user1",
+	}
+
+	s.T().Run("OK", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		// Updates of users who are not connections go first, so that they are filtered out
+		// by the time the update of the connection is delivered.
+		updates := make(chan domain.LocatedUser, 3)
+		updates <- located(4, "user4")
+		updates <- located(3, "user3")
+		updates <- located(2, "user2")
+		sub := mock.NewMockLocationSubscription(ctrl)
+		sub.EXPECT().Updates().Return(updates).AnyTimes()
+		sub.EXPECT().Err().Return(nil).AnyTimes()
+		sub.EXPECT().Close().Times(1)
+		broker := mock.NewMockLocationBroker(ctrl)
+		broker.EXPECT().Subscribe(gomock.Any()).Times(1).Return(sub)
+		repo := mock.NewMockUserRepository(ctrl)
+		repo.EXPECT().IsBlocked(gomock.Any(), "user1", gomock.Any()).AnyTimes().Return(false, nil)
+		repo.EXPECT().IsConnection(gomock.Any(), "user1", 3).Times(1).Return(false, nil)
+		repo.EXPECT().IsConnection(gomock.Any(), "user1", 2).Times(1).Return(true, nil)
+
+		svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker, service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+		res, err := svc.SubscribeLocations(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, located(2, "user2"), <-res.Updates())
+
+		res.Close()
+		_, ok := <-res.Updates()
+		require.False(t, ok)
+		require.NoError(t, res.Err())
+	})
+
+	s.T().Run("InternalError", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		updates := make(chan domain.LocatedUser, 1)
+		updates <- located(2, "user2")
+		sub := mock.NewMockLocationSubscription(ctrl)
+		sub.EXPECT().Updates().Return(updates).AnyTimes()
+		sub.EXPECT().Err().Return(nil).AnyTimes()
+		sub.EXPECT().Close().Times(1)
+		broker := mock.NewMockLocationBroker(ctrl)
+		broker.EXPECT().Subscribe(gomock.Any()).Times(1).Return(sub)
+		repo := mock.NewMockUserRepository(ctrl)
+		repo.EXPECT().IsBlocked(gomock.Any(), "user1", 2).Times(1).Return(false, nil)
+		repo.EXPECT().IsConnection(gomock.Any(), "user1", 2).Times(1).Return(false, errpack.ErrInternalError)
+		logger := mocklog.NewMockLogger(ctrl)
+		logger.EXPECT().Error(gomock.Any(), gomock.Any()).Times(1)
+
+		svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker, service.UserServiceConfig{}, logger)
+
+		res, err := svc.SubscribeLocations(context.Background(), req)
+		require.NoError(t, err)
+
+		// The subscription ends with the error of the failed filter.
+		_, ok := <-res.Updates()
+		require.False(t, ok)
+		require.ErrorIs(t, res.Err(), errpack.ErrInternalError)
+	})
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SubscribeLocations_Blocked() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	located := func(id int, username string) domain.LocatedUser {
+		return domain.LocatedUser{User: domain.User{ID: id, Username: username}, Point: geo.Point{1, 1}}
+	}
+
+	// The update of the blocked user goes first, so that it is filtered out
+	// by the time the other update is delivered.
+	updates := make(chan domain.LocatedUser, 2)
+	updates <- located(3, "user3")
+	updates <- located(2, "user2")
+	sub := mock.NewMockLocationSubscription(ctrl)
+	sub.EXPECT().Updates().Return(updates).AnyTimes()
+	sub.EXPECT().Err().Return(nil).AnyTimes()
+	sub.EXPECT().Close().Times(1)
+	broker := mock.NewMockLocationBroker(ctrl)
+	broker.EXPECT().Subscribe(gomock.Any()).Times(1).Return(sub)
+	repo := mock.NewMockUserRepository(ctrl)
+	repo.EXPECT().IsBlocked(gomock.Any(), "user1", 3).Times(1).Return(true, nil)
+	repo.EXPECT().IsBlocked(gomock.Any(), "user1", 2).Times(1).Return(false, nil)
+	repo.EXPECT().IsConnection(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker, service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+	res, err := svc.SubscribeLocations(context.Background(), port.UserServiceSubscribeLocationsRequest{
+		Usernames: []string{"user2", "user3"},
+		Caller:    "user1",
+	})
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), located(2, "user2"), <-res.Updates())
+
+	res.Close()
+	_, ok := <-res.Updates()
+	require.False(s.T(), ok)
+}
+
+func (s *UserSvcTestSuite) Test_UserService_SubscribeLocations_ContextDone() {
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	sub := mock.NewMockLocationSubscription(ctrl)
+	sub.EXPECT().Updates().Return(make(chan domain.LocatedUser)).AnyTimes()
+	sub.EXPECT().Close().Times(1)
+	broker := mock.NewMockLocationBroker(ctrl)
+	broker.EXPECT().Subscribe(gomock.Any()).Times(1).Return(sub)
+
+	svc := service.NewUserService(mock.NewMockUserRepository(ctrl), mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), broker, service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	res, err := svc.SubscribeLocations(ctx, port.UserServiceSubscribeLocationsRequest{Usernames: []string{"user1"}})
+	require.NoError(s.T(), err)
+
+	cancel()
+	_, ok := <-res.Updates()
+	require.False(s.T(), ok)
+}
+
+func (s *UserSvcTestSuite) Test_UserService_Authorization() {
+	user := auth.Principal{Subject: "user1", Method: auth.MethodJWT, Roles: []auth.Role{auth.RoleUser}}
+	admin := auth.Principal{Subject: "admin", Method: auth.MethodJWT, Roles: []auth.Role{auth.RoleAdmin}}
+	history := auth.Principal{Subject: "history", Method: auth.MethodAPIKey, Roles: []auth.Role{auth.RoleService}}
+
+	testCases := []struct {
+		name       string
+		principal  auth.Principal
+		buildStubs func(repo *mock.MockUserRepository)
+		call       func(ctx context.Context, svc port.UserService) error
+		isError    error
+	}{
+		{
+			name:      "SetUserLocation_OtherUser",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().SetUserLocation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.SetUserLocation(ctx, port.UserServiceSetUserLocationRequest{
+					Username:  "user2",
+					Latitude:  10,
+					Longitude: 10,
+				})
+				return err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+		{
+			name:      "SetUserLocation_Service",
+			principal: history,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().SetUserLocation(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.SetUserLocation(ctx, port.UserServiceSetUserLocationRequest{
+					Username:  "user1",
+					Latitude:  10,
+					Longitude: 10,
+				})
+				return err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+		{
+			name:      "SetUserLocations_OtherUser",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().SetUserLocations(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				res, err := svc.SetUserLocations(ctx, port.UserServiceSetUserLocationsRequest{
+					Locations: []port.UserServiceSetUserLocationsItem{
+						{Username: "user2", Latitude: 10, Longitude: 10, Timestamp: time.Now()},
+					},
+				})
+				if err != nil {
+					return err
+				}
+				return res.Results[0].Err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+		{
+			name:      "ListNearestUsers_OtherCaller",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListNearestUsers(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.ListNearestUsers(ctx, port.UserServiceListNearestUsersRequest{
+					Point:  geo.Point{10, 10},
+					Limit:  10,
+					Scope:  port.UsersScopeConnections,
+					Caller: "user2",
+				})
+				return err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+		{
+			name:      "ListUsersInBBox_OtherCaller",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().ListUsersInBBox(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.ListUsersInBBox(ctx, port.UserServiceListUsersInBBoxRequest{
+					BBox:     geo.BBox{0, 0, 10, 10},
+					PageSize: 10,
+					Scope:    port.UsersScopeConnections,
+					Caller:   "user2",
+				})
+				return err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+		{
+			name:      "SubscribeLocations_OtherCaller",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().IsConnection(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.SubscribeLocations(ctx, port.UserServiceSubscribeLocationsRequest{
+					Usernames: []string{"user3"},
+					Scope:     port.UsersScopeConnections,
+					Caller:    "user2",
+				})
+				return err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+		{
+			name:      "GetByUsername_User",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().GetByUsername(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.GetByUsername(ctx, "user1")
+				return err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+		{
+			name:      "GetByUsername_Service",
+			principal: history,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().GetByUsername(gomock.Any(), gomock.Eq("user1")).Times(1).Return(domain.User{ID: 1, Username: "user1"}, nil)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.GetByUsername(ctx, "user1")
+				return err
+			},
+		},
+		{
+			name:      "DeleteUser_Self",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().DeleteUser(gomock.Any(), gomock.Eq("user1")).Times(1).Return(domain.ErasureJob{ID: 1}, nil)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.DeleteUser(ctx, "user1")
+				return err
+			},
+		},
+		{
+			name:      "DeleteUser_Admin",
+			principal: admin,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().DeleteUser(gomock.Any(), gomock.Eq("user2")).Times(1).Return(domain.ErasureJob{ID: 1}, nil)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.DeleteUser(ctx, "user2")
+				return err
+			},
+		},
+		{
+			// A token with the old username as the subject would stay valid after the rename
+			// and act on behalf of whoever takes the username next.
+			name:      "RenameUser_StaleToken",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().RenameUser(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.RenameUser(ctx, port.UserServiceRenameUserRequest{Username: "user1", NewUsername: "user2"})
+				return err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+		{
+			name:      "RenameUser_Admin",
+			principal: admin,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().
+					RenameUser(gomock.Any(), gomock.Eq(port.UserRepositoryRenameUserRequest{Username: "user1", NewUsername: "user2"})).
+					Times(1).
+					Return(domain.User{ID: 1, Username: "user2"}, nil)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.RenameUser(ctx, port.UserServiceRenameUserRequest{Username: "user1", NewUsername: "user2"})
+				return err
+			},
+		},
+		{
+			name:      "SetPrivacySettings_OtherUser",
+			principal: user,
+			buildStubs: func(repo *mock.MockUserRepository) {
+				repo.EXPECT().SetPrivacySettings(gomock.Any(), gomock.Any()).Times(0)
+			},
+			call: func(ctx context.Context, svc port.UserService) error {
+				_, err := svc.SetPrivacySettings(ctx, port.UserServiceSetPrivacySettingsRequest{
+					Username: "user2",
+					Level:    domain.PrivacyLevelHidden,
+				})
+				return err
+			},
+			isError: errpack.ErrPermissionDenied,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		s.T().Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			repo := mock.NewMockUserRepository(ctrl)
+			tc.buildStubs(repo)
+			svc := service.NewUserService(repo, mock.NewMockHistoryClient(ctrl), mock.NewMockGeofenceService(ctrl), newLocationBroker(ctrl), service.UserServiceConfig{}, mocklog.NewMockLogger(ctrl))
+
+			err := tc.call(auth.AddPrincipalToCtx(context.Background(), tc.principal), svc)
+			if tc.isError != nil {
+				require.ErrorIs(t, err, tc.isError)
+			} else {
+				require.NoError(t, err)
 			}
 		})
 	}
 }
+
+func (s *UserSvcTestSuite) Test_UserService_SetUserLocation_IdempotencyKey() {
+	ctx := context.Background()
+	point := geo.Point{20, 10}
+	req := port.UserServiceSetUserLocationRequest{
+		Username:       "user1",
+		Longitude:      point.Longitude(),
+		Latitude:       point.Latitude(),
+		IdempotencyKey: "key1",
+	}
+	otherReq := req
+	otherReq.Latitude = 11
+
+	ctrl := gomock.NewController(s.T())
+	defer ctrl.Finish()
+
+	repo := mock.NewMockUserRepository(ctrl)
+	historyClient := mock.NewMockHistoryClient(ctrl)
+	geofenceService := mock.NewMockGeofenceService(ctrl)
+	geofenceService.EXPECT().EvaluateLocation(gomock.Any(), gomock.Any()).AnyTimes()
+	logger := mocklog.NewMockLogger(ctrl)
+	svc := service.NewUserService(repo, historyClient, geofenceService, newLocationBroker(ctrl), service.UserServiceConfig{
+		IdempotencyKeyTTL: time.Hour,
+	}, logger)
+
+	// The first request is executed and its response is remembered.
+	var key domain.IdempotencyKey
+	repo.EXPECT().
+		ReserveIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg port.UserRepositoryReserveIdempotencyKeyRequest) (port.UserRepositoryReserveIdempotencyKeyResponse, error) {
+			require.Equal(s.T(), "user1", arg.Username)
+			require.Equal(s.T(), "key1", arg.Key)
+			require.Equal(s.T(), time.Hour, arg.TTL)
+			require.Equal(s.T(), service.DefaultIdempotencyKeyLease, arg.Lease)
+			key = domain.IdempotencyKey{Username: arg.Username, Key: arg.Key, RequestHash: arg.RequestHash}
+			return port.UserRepositoryReserveIdempotencyKeyResponse{Key: key, Reserved: true}, nil
+		})
+	repo.EXPECT().
+		SetUserLocation(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositorySetUserLocationResponse{
+			User:     domain.User{ID: 1, Username: "user1"},
+			Location: domain.Location{UserID: 1, Point: point},
+		}, nil)
+	repo.EXPECT().
+		CompleteIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, arg port.UserRepositoryCompleteIdempotencyKeyRequest) error {
+			key.Response = arg.Response
+			return nil
+		})
+
+	res, err := svc.SetUserLocation(ctx, req)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), point.Latitude(), res.Latitude)
+
+	// Retries get the remembered response without setting the location again.
+	repo.EXPECT().
+		ReserveIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(2).
+		DoAndReturn(func(context.Context, port.UserRepositoryReserveIdempotencyKeyRequest) (port.UserRepositoryReserveIdempotencyKeyResponse, error) {
+			return port.UserRepositoryReserveIdempotencyKeyResponse{Key: key}, nil
+		})
+
+	replayed, err := svc.SetUserLocation(ctx, req)
+	require.NoError(s.T(), err)
+	require.Equal(s.T(), res, replayed)
+
+	// The key may not be used with another request.
+	_, err = svc.SetUserLocation(ctx, otherReq)
+	require.ErrorIs(s.T(), err, errpack.ErrFailedPrecondition)
+
+	// Retries of requests in progress are rejected.
+	inProgress := key
+	inProgress.Response = nil
+	repo.EXPECT().
+		ReserveIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositoryReserveIdempotencyKeyResponse{Key: inProgress}, nil)
+
+	_, err = svc.SetUserLocation(ctx, req)
+	require.ErrorIs(s.T(), err, errpack.ErrAlreadyExists)
+
+	// Keys of failed requests are released, even if the requests are canceled.
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	repo.EXPECT().
+		ReserveIdempotencyKey(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositoryReserveIdempotencyKeyResponse{Key: inProgress, Reserved: true}, nil)
+	repo.EXPECT().
+		SetUserLocation(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(port.UserRepositorySetUserLocationResponse{}, errpack.ErrInternalError)
+	repo.EXPECT().
+		ReleaseIdempotencyKey(gomock.Any(), "user1", "key1").
+		Times(1).
+		DoAndReturn(func(ctx context.Context, _, _ string) error {
+			require.NoError(s.T(), ctx.Err())
+			return nil
+		})
+	logger.EXPECT().Error(gomock.Any(), gomock.Any()).Times(1)
+
+	_, err = svc.SetUserLocation(canceledCtx, req)
+	require.ErrorIs(s.T(), err, errpack.ErrInternalError)
+}
@@ -31,9 +31,46 @@ func init() {
 		log.Panicf("failed to register validation: %v", err)
 	}
 
+	if err = validate.RegisterValidation("validbbox", validation.ValidateBBox); err != nil {
+		log.Panicf("failed to register validation: %v", err)
+	}
+
+	if err = validate.RegisterValidation("validpolygon", validation.ValidatePolygon); err != nil {
+		log.Panicf("failed to register validation: %v", err)
+	}
+
+	if err = validate.RegisterValidation("validgeohash", validation.ValidateGeohash); err != nil {
+		log.Panicf("failed to register validation: %v", err)
+	}
+
+	if err = validate.RegisterValidation("validbearing", validation.ValidateBearing); err != nil {
+		log.Panicf("failed to register validation: %v", err)
+	}
+
+	if err = validate.RegisterValidation("validfixsource", validation.ValidateFixSource); err != nil {
+		log.Panicf("failed to register validation: %v", err)
+	}
+
 	if err = validate.RegisterValidation("valid_page_token", validation.ValidatePageToken); err != nil {
 		log.Panicf("failed to register validation: %v", err)
 	}
 
-	validate.RegisterStructValidation(validation.ValidateListMethod, port.UserServiceListUsersInRadiusRequest{})
+	validate.RegisterStructValidation(
+		validation.ValidateListMethod,
+		port.UserServiceListUsersInRadiusRequest{},
+		port.UserServiceListUsersInBBoxRequest{},
+		port.UserServiceListUsersInPolygonRequest{},
+		port.UserServiceListUsersRequest{},
+		port.GeofenceServiceListGeofencesRequest{},
+		port.GeofenceServiceListGeofenceEventsRequest{},
+		port.ErasureServiceListErasureJobsRequest{},
+	)
+
+	validate.RegisterStructValidation(
+		validation.ValidateGeofence,
+		port.GeofenceServiceCreateGeofenceRequest{},
+		port.GeofenceServiceUpdateGeofenceRequest{},
+	)
+
+	validate.RegisterStructValidation(validation.ValidatePrivacySettings, port.UserServiceSetPrivacySettingsRequest{})
 }
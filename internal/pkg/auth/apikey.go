@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+// APIKeyAuthenticator authenticates callers by static API keys.
+// API keys identify internal services, principals authenticated by them have `RoleService`.
+//
+// Keys are stored as SHA-256 digests, so that lookups do not leak how much of a key matches.
+type APIKeyAuthenticator struct {
+	subjects map[[sha256.Size]byte]string
+}
+
+// NewAPIKeyAuthenticator creates APIKeyAuthenticator with given subjects by keys and returns its pointer.
+func NewAPIKeyAuthenticator(keys map[string]string) *APIKeyAuthenticator {
+	subjects := make(map[[sha256.Size]byte]string, len(keys))
+	for key, subject := range keys {
+		subjects[sha256.Sum256([]byte(key))] = subject
+	}
+
+	return &APIKeyAuthenticator{
+		subjects: subjects,
+	}
+}
+
+// Authenticate finds the subject `creds.APIKey` is issued to.
+//
+// `ErrNoCredentials` is returned in case the API key is not provided.
+//
+// Error wrapping `errpack.ErrUnauthenticated` is returned in case the API key is unknown.
+func (a *APIKeyAuthenticator) Authenticate(ctx context.Context, creds Credentials) (Principal, error) {
+	if creds.APIKey == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	subject, ok := a.subjects[sha256.Sum256([]byte(creds.APIKey))]
+	if !ok {
+		return Principal{}, fmt.Errorf("%w: invalid api key", errpack.ErrUnauthenticated)
+	}
+
+	return Principal{
+		Subject: subject,
+		Method:  MethodAPIKey,
+		Roles:   []Role{RoleService},
+	}, nil
+}
+
+// ParseAPIKeys parses API keys given in the "subject:key" format and returns subjects by keys.
+// Keys may contain colons, subjects may not.
+//
+// An error is returned in case an entry is malformed or a key is given twice.
+func ParseAPIKeys(entries []string) (map[string]string, error) {
+	keys := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+		if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+			return nil, errors.New("failed to parse api keys: entries must be in the subject:key format")
+		}
+		if _, ok := keys[parts[1]]; ok {
+			return nil, fmt.Errorf("failed to parse api keys: key of %q is given twice", parts[0])
+		}
+		keys[parts[1]] = parts[0]
+	}
+
+	return keys, nil
+}
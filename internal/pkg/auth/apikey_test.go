@@ -0,0 +1,75 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+func TestAPIKeyAuthenticator_Authenticate(t *testing.T) {
+	authenticator := auth.NewAPIKeyAuthenticator(map[string]string{
+		"history-key": "history",
+	})
+
+	principal, err := authenticator.Authenticate(context.Background(), auth.Credentials{APIKey: "history-key"})
+	require.NoError(t, err)
+	require.Equal(t, auth.Principal{Subject: "history", Method: auth.MethodAPIKey, Roles: []auth.Role{auth.RoleService}}, principal)
+
+	_, err = authenticator.Authenticate(context.Background(), auth.Credentials{APIKey: "unknown-key"})
+	require.ErrorIs(t, err, errpack.ErrUnauthenticated)
+	require.NotErrorIs(t, err, auth.ErrNoCredentials)
+
+	_, err = authenticator.Authenticate(context.Background(), auth.Credentials{BearerToken: "token"})
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	testCases := []struct {
+		name     string
+		entries  []string
+		expected map[string]string
+		isErr    bool
+	}{
+		{
+			name:     "OK",
+			entries:  []string{"history:key1", " locations:key:2 "},
+			expected: map[string]string{"key1": "history", "key:2": "locations"},
+		},
+		{
+			name:    "NoSeparator",
+			entries: []string{"key1"},
+			isErr:   true,
+		},
+		{
+			name:    "EmptySubject",
+			entries: []string{":key1"},
+			isErr:   true,
+		},
+		{
+			name:    "EmptyKey",
+			entries: []string{"history:"},
+			isErr:   true,
+		},
+		{
+			name:    "DuplicateKey",
+			entries: []string{"history:key1", "locations:key1"},
+			isErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			keys, err := auth.ParseAPIKeys(tc.entries)
+			if tc.isErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, keys)
+		})
+	}
+}
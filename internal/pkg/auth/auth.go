@@ -0,0 +1,165 @@
+// Package auth authenticates callers of the applications and authorizes their requests.
+//
+// Authorization is enforced only for requests with a principal in context.
+// Requests come without one if authentication is disabled or if they are made
+// by the application itself, e.g. by background jobs, and are allowed in that case.
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+// ErrNoCredentials is returned by an authenticator when a request has no credentials it verifies.
+// It wraps `errpack.ErrUnauthenticated`.
+var ErrNoCredentials = fmt.Errorf("%w: no credentials", errpack.ErrUnauthenticated)
+
+// Method is a method a principal is authenticated with.
+type Method string
+
+const (
+	// MethodJWT is authentication by a JWT bearer token.
+	MethodJWT Method = "jwt"
+	// MethodAPIKey is authentication by a static API key.
+	MethodAPIKey Method = "api_key"
+)
+
+// Principal is an authenticated caller.
+//
+// `Subject` is the "sub" claim of a JWT or the subject an API key is issued to.
+type Principal struct {
+	Subject string
+	Method  Method
+	Roles   []Role
+}
+
+// HasRole reports whether the principal has the role.
+func (p Principal) HasRole(role Role) bool {
+	for _, r := range p.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Credentials are credentials of a request, empty fields are not provided.
+type Credentials struct {
+	BearerToken string
+	APIKey      string
+}
+
+// Authenticator verifies credentials of requests.
+type Authenticator interface {
+	// Authenticate returns the principal the credentials belong to.
+	//
+	// `ErrNoCredentials` is returned in case the credentials the authenticator verifies are not provided.
+	//
+	// Any other error wrapping `errpack.ErrUnauthenticated` is returned in case the credentials are invalid.
+	Authenticate(ctx context.Context, creds Credentials) (Principal, error)
+}
+
+type chain []Authenticator
+
+// Chain returns an authenticator that tries given authenticators in order
+// and returns the principal of the first one that succeeds.
+//
+// If all of them fail, an error of an authenticator that rejected provided credentials is returned,
+// `ErrNoCredentials` is returned otherwise.
+func Chain(authenticators ...Authenticator) Authenticator {
+	return chain(authenticators)
+}
+
+func (c chain) Authenticate(ctx context.Context, creds Credentials) (Principal, error) {
+	err := ErrNoCredentials
+	for _, authenticator := range c {
+		principal, authErr := authenticator.Authenticate(ctx, creds)
+		if authErr == nil {
+			return principal, nil
+		}
+		if !errors.Is(authErr, ErrNoCredentials) {
+			err = authErr
+		}
+	}
+
+	return Principal{}, err
+}
+
+// Config configures authenticators built by New.
+//
+// `RS256PublicKeyFile` is a path to a PEM encoded RSA public key.
+// `APIKeys` are entries in the "subject:key" format.
+// JWT claims "iss" and "aud" are not checked if `Issuer` and `Audience` are empty.
+type Config struct {
+	HS256Secret        string
+	RS256PublicKeyFile string
+	Issuer             string
+	Audience           string
+	APIKeys            []string
+}
+
+// New builds an authenticator that accepts JWT bearer tokens in case any of the JWT keys is configured
+// and static API keys in case any of them is configured.
+//
+// An error is returned in case neither JWT keys nor API keys are configured or any of them is malformed.
+func New(config Config) (Authenticator, error) {
+	var authenticators []Authenticator
+
+	if config.HS256Secret != "" || config.RS256PublicKeyFile != "" {
+		jwtConfig := JWTConfig{
+			Issuer:   config.Issuer,
+			Audience: config.Audience,
+		}
+		if config.HS256Secret != "" {
+			jwtConfig.HS256Secret = []byte(config.HS256Secret)
+		}
+		if config.RS256PublicKeyFile != "" {
+			data, err := os.ReadFile(config.RS256PublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read public key: %v", err)
+			}
+			if jwtConfig.RS256PublicKey, err = ParseRSAPublicKey(data); err != nil {
+				return nil, err
+			}
+		}
+
+		authenticator, err := NewJWTAuthenticator(jwtConfig)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, authenticator)
+	}
+
+	if len(config.APIKeys) > 0 {
+		keys, err := ParseAPIKeys(config.APIKeys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, NewAPIKeyAuthenticator(keys))
+	}
+
+	if len(authenticators) == 0 {
+		return nil, errors.New("neither jwt keys nor api keys are configured")
+	}
+
+	return Chain(authenticators...), nil
+}
+
+type principalCtxKey struct{}
+
+// AddPrincipalToCtx adds the authenticated principal to ctx and returns it.
+func AddPrincipalToCtx(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalCtxKey{}, principal)
+}
+
+// GetPrincipalFromCtx retrieves the authenticated principal from ctx.
+//
+// It returns the principal and ok which is true if the principal exists and false otherwise.
+func GetPrincipalFromCtx(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalCtxKey{}).(Principal)
+	return principal, ok
+}
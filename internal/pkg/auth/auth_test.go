@@ -0,0 +1,73 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util/testutil"
+)
+
+func TestChain(t *testing.T) {
+	authenticator := auth.Chain(
+		auth.NewAPIKeyAuthenticator(map[string]string{"history-key": "history"}),
+		auth.NewAPIKeyAuthenticator(map[string]string{"locations-key": "locations"}),
+	)
+
+	principal, err := authenticator.Authenticate(context.Background(), auth.Credentials{APIKey: "locations-key"})
+	require.NoError(t, err)
+	require.Equal(t, "locations", principal.Subject)
+
+	_, err = authenticator.Authenticate(context.Background(), auth.Credentials{})
+	require.ErrorIs(t, err, auth.ErrNoCredentials)
+
+	_, err = authenticator.Authenticate(context.Background(), auth.Credentials{APIKey: "unknown-key"})
+	require.ErrorIs(t, err, errpack.ErrUnauthenticated)
+	require.NotErrorIs(t, err, auth.ErrNoCredentials)
+}
+
+func TestNew(t *testing.T) {
+	rsaKey := testutil.NewRSAKey(t)
+	claims := map[string]interface{}{
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	authenticator, err := auth.New(auth.Config{
+		HS256Secret:        string(testSecret),
+		RS256PublicKeyFile: testutil.WriteRSAPublicKey(t, rsaKey),
+		APIKeys:            []string{"history:history-key"},
+	})
+	require.NoError(t, err)
+
+	for _, creds := range []auth.Credentials{
+		{BearerToken: testutil.SignJWT(t, testSecret, claims)},
+		{BearerToken: testutil.SignJWT(t, rsaKey, claims)},
+		{APIKey: "history-key"},
+	} {
+		_, err = authenticator.Authenticate(context.Background(), creds)
+		require.NoError(t, err)
+	}
+
+	_, err = auth.New(auth.Config{})
+	require.Error(t, err)
+
+	_, err = auth.New(auth.Config{RS256PublicKeyFile: "/nonexistent/public.pem"})
+	require.Error(t, err)
+
+	_, err = auth.New(auth.Config{APIKeys: []string{"malformed"}})
+	require.Error(t, err)
+}
+
+func TestPrincipalCtx(t *testing.T) {
+	_, ok := auth.GetPrincipalFromCtx(context.Background())
+	require.False(t, ok)
+
+	expected := auth.Principal{Subject: "alice", Method: auth.MethodJWT}
+	principal, ok := auth.GetPrincipalFromCtx(auth.AddPrincipalToCtx(context.Background(), expected))
+	require.True(t, ok)
+	require.Equal(t, expected, principal)
+}
@@ -0,0 +1,240 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+const (
+	// jwtLeeway tolerates clock skew between the issuer and the service when time claims are checked.
+	jwtLeeway = 30 * time.Second
+
+	algHS256 = "HS256"
+	algRS256 = "RS256"
+)
+
+// JWTConfig configures JWTAuthenticator.
+//
+// Tokens signed with HS256 are accepted only if `HS256Secret` is set,
+// tokens signed with RS256 are accepted only if `RS256PublicKey` is set.
+// The "iss" claim must equal `Issuer` and the "aud" claim must contain `Audience` unless they are empty.
+type JWTConfig struct {
+	HS256Secret    []byte
+	RS256PublicKey *rsa.PublicKey
+	Issuer         string
+	Audience       string
+}
+
+// JWTAuthenticator authenticates callers by JWT bearer tokens signed with locally configured keys.
+type JWTAuthenticator struct {
+	config JWTConfig
+	now    func() time.Time
+}
+
+// NewJWTAuthenticator creates JWTAuthenticator and returns its pointer.
+//
+// An error is returned in case none of the keys is set.
+func NewJWTAuthenticator(config JWTConfig) (*JWTAuthenticator, error) {
+	if len(config.HS256Secret) == 0 && config.RS256PublicKey == nil {
+		return nil, errors.New("neither hs256 secret nor rs256 public key is set")
+	}
+
+	return &JWTAuthenticator{
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+}
+
+// audience is the "aud" claim that is either a single string or an array of strings.
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return err
+	}
+	*a = multiple
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, v := range a {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+type jwtClaims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss"`
+	Audience  audience `json:"aud"`
+	ExpiresAt float64  `json:"exp"`
+	NotBefore float64  `json:"nbf"`
+	Roles     []Role   `json:"roles"`
+}
+
+// Authenticate verifies the signature and claims of `creds.BearerToken`.
+// Tokens must have "sub" and "exp" claims.
+//
+// The principal gets roles from the "roles" claim, `RoleUser` is assigned if the claim is empty.
+//
+// `ErrNoCredentials` is returned in case the bearer token is not provided.
+//
+// Error wrapping `errpack.ErrUnauthenticated` is returned in case the token is malformed, signed
+// with an unknown algorithm or key, expired, not valid yet or issued by another issuer or for another audience.
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, creds Credentials) (Principal, error) {
+	if creds.BearerToken == "" {
+		return Principal{}, ErrNoCredentials
+	}
+
+	parts := strings.Split(creds.BearerToken, ".")
+	if len(parts) != 3 {
+		return Principal{}, fmt.Errorf("%w: malformed token", errpack.ErrUnauthenticated)
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed token", errpack.ErrUnauthenticated)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed token", errpack.ErrUnauthenticated)
+	}
+	if err = a.verify(header.Alg, parts[0]+"."+parts[1], signature); err != nil {
+		return Principal{}, err
+	}
+
+	var claims jwtClaims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return Principal{}, fmt.Errorf("%w: malformed token", errpack.ErrUnauthenticated)
+	}
+	if err = a.validate(claims); err != nil {
+		return Principal{}, err
+	}
+
+	roles := claims.Roles
+	if len(roles) == 0 {
+		roles = []Role{RoleUser}
+	}
+
+	return Principal{
+		Subject: claims.Subject,
+		Method:  MethodJWT,
+		Roles:   roles,
+	}, nil
+}
+
+// verify checks the signature of the signing input with the key of the algorithm.
+// The algorithm is accepted only if its key is configured, so that a token can not choose how it is verified.
+func (a *JWTAuthenticator) verify(alg string, signingInput string, signature []byte) error {
+	switch {
+	case alg == algHS256 && len(a.config.HS256Secret) > 0:
+		mac := hmac.New(sha256.New, a.config.HS256Secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return fmt.Errorf("%w: invalid signature", errpack.ErrUnauthenticated)
+		}
+		return nil
+	case alg == algRS256 && a.config.RS256PublicKey != nil:
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(a.config.RS256PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: invalid signature", errpack.ErrUnauthenticated)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported signing algorithm", errpack.ErrUnauthenticated)
+	}
+}
+
+func (a *JWTAuthenticator) validate(claims jwtClaims) error {
+	now := a.now()
+
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: missing subject", errpack.ErrUnauthenticated)
+	}
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: missing expiration time", errpack.ErrUnauthenticated)
+	}
+	if now.Add(-jwtLeeway).After(unixTime(claims.ExpiresAt)) {
+		return fmt.Errorf("%w: token is expired", errpack.ErrUnauthenticated)
+	}
+	if claims.NotBefore != 0 && now.Add(jwtLeeway).Before(unixTime(claims.NotBefore)) {
+		return fmt.Errorf("%w: token is not valid yet", errpack.ErrUnauthenticated)
+	}
+	if a.config.Issuer != "" && claims.Issuer != a.config.Issuer {
+		return fmt.Errorf("%w: invalid issuer", errpack.ErrUnauthenticated)
+	}
+	if a.config.Audience != "" && !claims.Audience.contains(a.config.Audience) {
+		return fmt.Errorf("%w: invalid audience", errpack.ErrUnauthenticated)
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token into v.
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// unixTime converts a JWT numeric date to time.
+func unixTime(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
+
+// ParseRSAPublicKey parses a PEM encoded RSA public key in either PKIX or PKCS #1 form.
+func ParseRSAPublicKey(data []byte) (*rsa.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("failed to decode public key: no pem block found")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		key, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %v", err)
+		}
+		rsaKey, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return nil, errors.New("failed to parse public key: not an rsa key")
+		}
+		return rsaKey, nil
+	case "RSA PUBLIC KEY":
+		key, err := x509.ParsePKCS1PublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse public key: %v", err)
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("failed to parse public key: unexpected pem block %q", block.Type)
+	}
+}
@@ -0,0 +1,196 @@
+package auth_test
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util/testutil"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestJWTAuthenticator_Authenticate(t *testing.T) {
+	rsaKey := testutil.NewRSAKey(t)
+	otherRSAKey := testutil.NewRSAKey(t)
+	now := time.Now()
+
+	validClaims := func() map[string]interface{} {
+		return map[string]interface{}{
+			"sub": "alice",
+			"iss": "geotracker",
+			"aud": "locations",
+			"exp": now.Add(time.Hour).Unix(),
+		}
+	}
+	withClaim := func(key string, value interface{}) map[string]interface{} {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	unsigned := func(claims map[string]interface{}) string {
+		token := testutil.SignJWT(t, testSecret, claims)
+		parts := strings.Split(token, ".")
+		return base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`)) + "." + parts[1] + "."
+	}
+
+	testCases := []struct {
+		name        string
+		config      auth.JWTConfig
+		token       string
+		expectedErr error
+	}{
+		{
+			name:   "OK_HS256",
+			config: auth.JWTConfig{HS256Secret: testSecret, Issuer: "geotracker", Audience: "locations"},
+			token:  testutil.SignJWT(t, testSecret, validClaims()),
+		},
+		{
+			name:   "OK_RS256",
+			config: auth.JWTConfig{RS256PublicKey: &rsaKey.PublicKey, Issuer: "geotracker", Audience: "locations"},
+			token:  testutil.SignJWT(t, rsaKey, validClaims()),
+		},
+		{
+			name:   "OK_AudienceList",
+			config: auth.JWTConfig{HS256Secret: testSecret, Audience: "locations"},
+			token:  testutil.SignJWT(t, testSecret, withClaim("aud", []string{"history", "locations"})),
+		},
+		{
+			name:   "OK_ExpiredWithinLeeway",
+			config: auth.JWTConfig{HS256Secret: testSecret},
+			token:  testutil.SignJWT(t, testSecret, withClaim("exp", now.Add(-10*time.Second).Unix())),
+		},
+		{
+			name:        "NoToken",
+			config:      auth.JWTConfig{HS256Secret: testSecret},
+			expectedErr: auth.ErrNoCredentials,
+		},
+		{
+			name:        "Malformed",
+			config:      auth.JWTConfig{HS256Secret: testSecret},
+			token:       "not.a-token",
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "InvalidSignature",
+			config:      auth.JWTConfig{HS256Secret: testSecret},
+			token:       testutil.SignJWT(t, []byte("another secret of thirty two bytes"), validClaims()),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "InvalidRSASignature",
+			config:      auth.JWTConfig{RS256PublicKey: &rsaKey.PublicKey},
+			token:       testutil.SignJWT(t, otherRSAKey, validClaims()),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "AlgNone",
+			config:      auth.JWTConfig{HS256Secret: testSecret},
+			token:       unsigned(validClaims()),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "AlgNotConfigured",
+			config:      auth.JWTConfig{RS256PublicKey: &rsaKey.PublicKey},
+			token:       testutil.SignJWT(t, testSecret, validClaims()),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "Expired",
+			config:      auth.JWTConfig{HS256Secret: testSecret},
+			token:       testutil.SignJWT(t, testSecret, withClaim("exp", now.Add(-time.Hour).Unix())),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "NoExpirationTime",
+			config:      auth.JWTConfig{HS256Secret: testSecret},
+			token:       testutil.SignJWT(t, testSecret, withClaim("exp", nil)),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "NotValidYet",
+			config:      auth.JWTConfig{HS256Secret: testSecret},
+			token:       testutil.SignJWT(t, testSecret, withClaim("nbf", now.Add(time.Hour).Unix())),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "NoSubject",
+			config:      auth.JWTConfig{HS256Secret: testSecret},
+			token:       testutil.SignJWT(t, testSecret, withClaim("sub", nil)),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "InvalidIssuer",
+			config:      auth.JWTConfig{HS256Secret: testSecret, Issuer: "geotracker"},
+			token:       testutil.SignJWT(t, testSecret, withClaim("iss", "someone")),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+		{
+			name:        "InvalidAudience",
+			config:      auth.JWTConfig{HS256Secret: testSecret, Audience: "locations"},
+			token:       testutil.SignJWT(t, testSecret, withClaim("aud", []string{"history"})),
+			expectedErr: errpack.ErrUnauthenticated,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			authenticator, err := auth.NewJWTAuthenticator(tc.config)
+			require.NoError(t, err)
+
+			principal, err := authenticator.Authenticate(context.Background(), auth.Credentials{BearerToken: tc.token})
+			if tc.expectedErr != nil {
+				require.ErrorIs(t, err, tc.expectedErr)
+				require.Empty(t, principal)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, auth.Principal{Subject: "alice", Method: auth.MethodJWT, Roles: []auth.Role{auth.RoleUser}}, principal)
+		})
+	}
+}
+
+func TestNewJWTAuthenticator_NoKeys(t *testing.T) {
+	_, err := auth.NewJWTAuthenticator(auth.JWTConfig{Issuer: "geotracker"})
+	require.Error(t, err)
+}
+
+func TestParseRSAPublicKey(t *testing.T) {
+	key := testutil.NewRSAKey(t)
+
+	data, err := os.ReadFile(testutil.WriteRSAPublicKey(t, key))
+	require.NoError(t, err)
+
+	publicKey, err := auth.ParseRSAPublicKey(data)
+	require.NoError(t, err)
+	require.True(t, key.PublicKey.Equal(publicKey))
+
+	_, err = auth.ParseRSAPublicKey([]byte("not a key"))
+	require.Error(t, err)
+}
+
+func TestJWTAuthenticator_Authenticate_Roles(t *testing.T) {
+	authenticator, err := auth.NewJWTAuthenticator(auth.JWTConfig{HS256Secret: testSecret})
+	require.NoError(t, err)
+
+	token := testutil.SignJWT(t, testSecret, map[string]interface{}{
+		"sub":   "alice",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"roles": []string{"admin"},
+	})
+
+	principal, err := authenticator.Authenticate(context.Background(), auth.Credentials{BearerToken: token})
+	require.NoError(t, err)
+	require.Equal(t, []auth.Role{auth.RoleAdmin}, principal.Roles)
+}
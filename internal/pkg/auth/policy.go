@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+// Role is a role of a principal that grants permissions.
+type Role string
+
+const (
+	// RoleUser acts on behalf of the user whose username is the subject of the principal.
+	RoleUser Role = "user"
+	// RoleAdmin acts on behalf of any user and performs any request.
+	RoleAdmin Role = "admin"
+	// RoleService is an identity of an internal service calling another one.
+	RoleService Role = "internal-service"
+)
+
+// AuthorizeUser checks that the principal from ctx may act on behalf of the users with given usernames.
+//
+// Admins may act on behalf of any user, principals with `RoleUser` only on behalf of themselves.
+//
+// Error wrapping `errpack.ErrPermissionDenied` is returned in case the principal is not allowed.
+func AuthorizeUser(ctx context.Context, usernames ...string) error {
+	principal, ok := GetPrincipalFromCtx(ctx)
+	if !ok || principal.HasRole(RoleAdmin) {
+		return nil
+	}
+
+	if principal.HasRole(RoleUser) {
+		allowed := true
+		for _, username := range usernames {
+			if username != principal.Subject {
+				allowed = false
+				break
+			}
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q may not act on behalf of other users", errpack.ErrPermissionDenied, principal.Subject)
+}
+
+// AuthorizeRoles checks that the principal from ctx has any of given roles. Admins have all of them.
+//
+// Error wrapping `errpack.ErrPermissionDenied` is returned in case the principal has none of the roles.
+func AuthorizeRoles(ctx context.Context, roles ...Role) error {
+	principal, ok := GetPrincipalFromCtx(ctx)
+	if !ok || principal.HasRole(RoleAdmin) {
+		return nil
+	}
+
+	for _, role := range roles {
+		if principal.HasRole(role) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: %q lacks a required role", errpack.ErrPermissionDenied, principal.Subject)
+}
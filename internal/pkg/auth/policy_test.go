@@ -0,0 +1,75 @@
+package auth_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+)
+
+func TestAuthorizeUser(t *testing.T) {
+	testCases := []struct {
+		name      string
+		principal *auth.Principal
+		usernames []string
+		isErr     bool
+	}{
+		{
+			name:      "OK_NoPrincipal",
+			usernames: []string{"bob"},
+		},
+		{
+			name:      "OK_Self",
+			principal: &auth.Principal{Subject: "alice", Roles: []auth.Role{auth.RoleUser}},
+			usernames: []string{"alice", "alice"},
+		},
+		{
+			name:      "OK_Admin",
+			principal: &auth.Principal{Subject: "alice", Roles: []auth.Role{auth.RoleAdmin}},
+			usernames: []string{"bob"},
+		},
+		{
+			name:      "OtherUser",
+			principal: &auth.Principal{Subject: "alice", Roles: []auth.Role{auth.RoleUser}},
+			usernames: []string{"alice", "bob"},
+			isErr:     true,
+		},
+		{
+			name:      "Service",
+			principal: &auth.Principal{Subject: "history", Roles: []auth.Role{auth.RoleService}},
+			usernames: []string{"history"},
+			isErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.principal != nil {
+				ctx = auth.AddPrincipalToCtx(ctx, *tc.principal)
+			}
+
+			err := auth.AuthorizeUser(ctx, tc.usernames...)
+			if tc.isErr {
+				require.ErrorIs(t, err, errpack.ErrPermissionDenied)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestAuthorizeRoles(t *testing.T) {
+	require.NoError(t, auth.AuthorizeRoles(context.Background(), auth.RoleService))
+
+	ctx := auth.AddPrincipalToCtx(context.Background(), auth.Principal{Subject: "history", Roles: []auth.Role{auth.RoleService}})
+	require.NoError(t, auth.AuthorizeRoles(ctx, auth.RoleService))
+
+	ctx = auth.AddPrincipalToCtx(context.Background(), auth.Principal{Subject: "alice", Roles: []auth.Role{auth.RoleAdmin}})
+	require.NoError(t, auth.AuthorizeRoles(ctx, auth.RoleService))
+
+	ctx = auth.AddPrincipalToCtx(context.Background(), auth.Principal{Subject: "alice", Roles: []auth.Role{auth.RoleUser}})
+	require.ErrorIs(t, auth.AuthorizeRoles(ctx, auth.RoleService), errpack.ErrPermissionDenied)
+}
@@ -3,10 +3,13 @@ package config
 import (
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 
 	"github.com/spf13/viper"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/ratelimit"
 )
 
 var (
@@ -22,6 +25,27 @@ var (
 		"BIND_ADDR_HTTP",
 		"BIND_ADDR_GRPC",
 		"HISTORY_ADDR",
+		"OUT_OF_ORDER_POLICY",
+		"MAX_CLOCK_SKEW",
+		"DISABLE_USER_AUTO_CREATION",
+		"ERASURE_JOB_INTERVAL",
+		"MAX_LOCATION_AGE",
+		"FEED_HEARTBEAT_INTERVAL",
+		"HISTORY_API_KEY",
+		"IDEMPOTENCY_KEY_TTL",
+		"IDEMPOTENCY_KEY_LEASE",
+		"IDEMPOTENCY_KEY_CLEANUP_INTERVAL",
+		"AUTH_ENABLED",
+		"AUTH_JWT_HS256_SECRET",
+		"AUTH_JWT_RS256_PUBLIC_KEY_FILE",
+		"AUTH_JWT_ISSUER",
+		"AUTH_JWT_AUDIENCE",
+		"AUTH_API_KEYS",
+		"RATE_LIMIT_ENABLED",
+		"RATE_LIMIT_DEFAULT",
+		"RATE_LIMIT_ROUTES",
+		"RATE_LIMIT_TRUSTED_PROXIES",
+		"MAX_IN_FLIGHT_REQUESTS",
 	}
 	historyConfigKeys = []string{
 		"APP_ENV",
@@ -35,22 +59,108 @@ var (
 		"BIND_ADDR_HTTP",
 		"BIND_ADDR_GRPC",
 		"LOCATION_ADDR",
+		"LOCATION_API_KEY",
+		"AUTH_ENABLED",
+		"AUTH_JWT_HS256_SECRET",
+		"AUTH_JWT_RS256_PUBLIC_KEY_FILE",
+		"AUTH_JWT_ISSUER",
+		"AUTH_JWT_AUDIENCE",
+		"AUTH_API_KEYS",
+		"RATE_LIMIT_ENABLED",
+		"RATE_LIMIT_DEFAULT",
+		"RATE_LIMIT_ROUTES",
+		"RATE_LIMIT_TRUSTED_PROXIES",
+		"MAX_IN_FLIGHT_REQUESTS",
 	}
 )
 
+// AuthConfig stores authentication configuration of incoming requests shared by applications.
+type AuthConfig struct {
+	// AuthEnabled turns on authentication of incoming HTTP and gRPC requests.
+	// At least one of the JWT keys or API keys must be set if it is on.
+	AuthEnabled bool `mapstructure:"AUTH_ENABLED"`
+	// AuthJWTHS256Secret is the shared secret of JWTs signed with HS256.
+	AuthJWTHS256Secret string `mapstructure:"AUTH_JWT_HS256_SECRET" validate:"omitempty,min=32"`
+	// AuthJWTRS256PublicKeyFile is a path to the PEM encoded public key of JWTs signed with RS256.
+	AuthJWTRS256PublicKeyFile string `mapstructure:"AUTH_JWT_RS256_PUBLIC_KEY_FILE"`
+	// AuthJWTIssuer and AuthJWTAudience are expected "iss" and "aud" claims of JWTs, they are not checked if empty.
+	AuthJWTIssuer   string `mapstructure:"AUTH_JWT_ISSUER"`
+	AuthJWTAudience string `mapstructure:"AUTH_JWT_AUDIENCE"`
+	// AuthAPIKeys are comma separated static API keys in the "subject:key" format.
+	AuthAPIKeys []string `mapstructure:"AUTH_API_KEYS"`
+}
+
+// Auth returns configuration of the authenticator.
+func (c AuthConfig) Auth() auth.Config {
+	return auth.Config{
+		HS256Secret:        c.AuthJWTHS256Secret,
+		RS256PublicKeyFile: c.AuthJWTRS256PublicKeyFile,
+		Issuer:             c.AuthJWTIssuer,
+		Audience:           c.AuthJWTAudience,
+		APIKeys:            c.AuthAPIKeys,
+	}
+}
+
+// RateLimitConfig stores rate limiting and load shedding configuration of incoming requests shared by applications.
+type RateLimitConfig struct {
+	// RateLimitEnabled turns on rate limiting of incoming HTTP and gRPC requests.
+	RateLimitEnabled bool `mapstructure:"RATE_LIMIT_ENABLED"`
+	// RateLimitDefault is the "rate:burst" limit of routes no rule matches, they are not limited if it is empty.
+	RateLimitDefault string `mapstructure:"RATE_LIMIT_DEFAULT"`
+	// RateLimitRoutes are comma separated rules in the "pattern=rate:burst" format, for example
+	// "PUT /v1/users/{username}/location=1:5" or "/location.Location/SetUserLocation=1:5".
+	RateLimitRoutes []string `mapstructure:"RATE_LIMIT_ROUTES"`
+	// RateLimitTrustedProxies are comma separated CIDRs of proxies, like the API gateway,
+	// whose X-Forwarded-For is trusted to find IPs of anonymous clients.
+	RateLimitTrustedProxies []string `mapstructure:"RATE_LIMIT_TRUSTED_PROXIES"`
+	// MaxInFlightRequests is the number of requests processed at once above which requests are shed, 0 means no limit.
+	MaxInFlightRequests int `mapstructure:"MAX_IN_FLIGHT_REQUESTS" validate:"gte=0"`
+}
+
+// RateLimit returns configuration of the rate limiter.
+func (c RateLimitConfig) RateLimit() ratelimit.Config {
+	return ratelimit.Config{
+		Default: c.RateLimitDefault,
+		Routes:  c.RateLimitRoutes,
+	}
+}
+
 // LocationConfig stores all configuration of user application
 type LocationConfig struct {
-	AppEnv       string `mapstructure:"APP_ENV"`
-	DBDriver     string `mapstructure:"DB_DRIVER" validate:"required"`
-	DBHost       string `mapstructure:"DB_HOST" validate:"required"`
-	DBPort       string `mapstructure:"DB_PORT" validate:"required"`
-	DBUser       string `mapstructure:"DB_USER" validate:"required"`
-	DBPassword   string `mapstructure:"DB_PASSWORD" validate:"required"`
-	DBName       string `mapstructure:"DB_NAME" validate:"required"`
-	DBSSLMode    string `mapstructure:"DB_SSLMODE" validate:"required"`
+	AppEnv string `mapstructure:"APP_ENV"`
+	// DBDriver is either "postgres" or "memory". Other DB settings are not required for the in-memory storage.
+	DBDriver     string `mapstructure:"DB_DRIVER" validate:"required,oneof=postgres memory"`
+	DBHost       string `mapstructure:"DB_HOST" validate:"required_unless=DBDriver memory"`
+	DBPort       string `mapstructure:"DB_PORT" validate:"required_unless=DBDriver memory"`
+	DBUser       string `mapstructure:"DB_USER" validate:"required_unless=DBDriver memory"`
+	DBPassword   string `mapstructure:"DB_PASSWORD" validate:"required_unless=DBDriver memory"`
+	DBName       string `mapstructure:"DB_NAME" validate:"required_unless=DBDriver memory"`
+	DBSSLMode    string `mapstructure:"DB_SSLMODE" validate:"required_unless=DBDriver memory"`
 	BindAddrHTTP string `mapstructure:"BIND_ADDR_HTTP" validate:"required"`
 	BindAddrGRPC string `mapstructure:"BIND_ADDR_GRPC" validate:"required"`
 	HistoryAddr  string `mapstructure:"HISTORY_ADDR" validate:"required"`
+	// OutOfOrderPolicy is either "reject" or "ignore".
+	OutOfOrderPolicy string        `mapstructure:"OUT_OF_ORDER_POLICY" validate:"oneof=reject ignore"`
+	MaxClockSkew     time.Duration `mapstructure:"MAX_CLOCK_SKEW" validate:"gte=0"`
+	// DisableUserAutoCreation turns off creation of unknown users on location updates.
+	DisableUserAutoCreation bool `mapstructure:"DISABLE_USER_AUTO_CREATION"`
+	// ErasureJobInterval is how often pending erasure jobs of deleted users are processed.
+	ErasureJobInterval time.Duration `mapstructure:"ERASURE_JOB_INTERVAL" validate:"gt=0"`
+	// MaxLocationAge is the default maximum age of locations user searches take into account, 0 means no limit.
+	MaxLocationAge time.Duration `mapstructure:"MAX_LOCATION_AGE" validate:"gte=0"`
+	// FeedHeartbeatInterval is how often keepalives are sent to idle clients of the live location feed.
+	FeedHeartbeatInterval time.Duration `mapstructure:"FEED_HEARTBEAT_INTERVAL" validate:"gt=0"`
+	// IdempotencyKeyTTL is how long responses of location updates sent with idempotency keys are remembered.
+	IdempotencyKeyTTL time.Duration `mapstructure:"IDEMPOTENCY_KEY_TTL" validate:"gt=0"`
+	// IdempotencyKeyLease is how long location updates in progress hold their idempotency keys.
+	// Retries take over keys of updates that are not completed within the lease, e.g. because the process crashed.
+	IdempotencyKeyLease time.Duration `mapstructure:"IDEMPOTENCY_KEY_LEASE" validate:"gt=0"`
+	// IdempotencyKeyCleanupInterval is how often expired idempotency keys are deleted.
+	IdempotencyKeyCleanupInterval time.Duration `mapstructure:"IDEMPOTENCY_KEY_CLEANUP_INTERVAL" validate:"gt=0"`
+	// HistoryAPIKey authenticates requests to history service.
+	HistoryAPIKey   string `mapstructure:"HISTORY_API_KEY"`
+	AuthConfig      `mapstructure:",squash"`
+	RateLimitConfig `mapstructure:",squash"`
 }
 
 // HistoryConfig stores all configuration of user application
@@ -66,6 +176,10 @@ type HistoryConfig struct {
 	BindAddrHTTP string `mapstructure:"BIND_ADDR_HTTP" validate:"required"`
 	BindAddrGRPC string `mapstructure:"BIND_ADDR_GRPC" validate:"required"`
 	LocationAddr string `mapstructure:"LOCATION_ADDR" validate:"required"`
+	// LocationAPIKey authenticates requests to location service.
+	LocationAPIKey  string `mapstructure:"LOCATION_API_KEY"`
+	AuthConfig      `mapstructure:",squash"`
+	RateLimitConfig `mapstructure:",squash"`
 }
 
 // LoadConfig parses configuration and stores the result in
@@ -134,6 +248,14 @@ func LoadLocationConfig(name string, path string) (LocationConfig, error) {
 		return LocationConfig{}, err
 	}
 
+	v.SetDefault("OUT_OF_ORDER_POLICY", "reject")
+	v.SetDefault("MAX_CLOCK_SKEW", "1m")
+	v.SetDefault("IDEMPOTENCY_KEY_TTL", "24h")
+	v.SetDefault("IDEMPOTENCY_KEY_LEASE", "1m")
+	v.SetDefault("IDEMPOTENCY_KEY_CLEANUP_INTERVAL", "10m")
+	v.SetDefault("ERASURE_JOB_INTERVAL", "1m")
+	v.SetDefault("FEED_HEARTBEAT_INTERVAL", "15s")
+
 	err = LoadConfig(v, name, path, &cfg)
 	if err != nil {
 		return LocationConfig{}, err
@@ -17,6 +17,16 @@ var (
 	// ErrFailedPrecondition is returned when request can not be processed.
 	ErrFailedPrecondition = errors.New("failed precondition")
 
+	// ErrResourceExhausted is returned when a client runs out of some resource,
+	// e.g. falls too far behind a stream of updates.
+	ErrResourceExhausted = errors.New("resource exhausted")
+
+	// ErrUnauthenticated is returned when a caller does not provide valid credentials.
+	ErrUnauthenticated = errors.New("unauthenticated")
+
+	// ErrPermissionDenied is returned when an authenticated caller is not allowed to perform a request.
+	ErrPermissionDenied = errors.New("permission denied")
+
 	// ErrInternalError is returned when internal failure happens.
 	ErrInternalError = errors.New("internal error")
 )
@@ -17,8 +17,16 @@ func ErrToGRPC(err error) error {
 		return status.Error(codes.InvalidArgument, err.Error())
 	case errors.Is(err, ErrNotFound):
 		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
 	case errors.Is(err, ErrFailedPrecondition):
 		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrResourceExhausted):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
 	default:
 		return status.Error(codes.Unknown, "unknown error")
 	}
@@ -67,6 +75,38 @@ func ErrToHTTP(err error) (int, map[string]interface{}) {
 				"status":  "NOT_FOUND",
 			},
 		}
+	case errors.Is(err, ErrAlreadyExists):
+		return 409, map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    409,
+				"message": err.Error(),
+				"status":  "ALREADY_EXISTS",
+			},
+		}
+	case errors.Is(err, ErrResourceExhausted):
+		return 429, map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    429,
+				"message": err.Error(),
+				"status":  "RESOURCE_EXHAUSTED",
+			},
+		}
+	case errors.Is(err, ErrUnauthenticated):
+		return 401, map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    401,
+				"message": err.Error(),
+				"status":  "UNAUTHENTICATED",
+			},
+		}
+	case errors.Is(err, ErrPermissionDenied):
+		return 403, map[string]interface{}{
+			"error": map[string]interface{}{
+				"code":    403,
+				"message": err.Error(),
+				"status":  "PERMISSION_DENIED",
+			},
+		}
 	default:
 		return 500, map[string]interface{}{
 			"error": map[string]interface{}{
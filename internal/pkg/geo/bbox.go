@@ -0,0 +1,97 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidBBox is returned in case a bounding box is invalid.
+var ErrInvalidBBox = errors.New("invalid bounding box")
+
+// BBox represents a bounding box [west, south, east, north] as defined by GeoJSON.
+//
+// A bounding box which west edge is greater than its east edge crosses the antimeridian.
+type BBox [4]float64
+
+// West returns the west edge (minimal longitude) of the bounding box.
+func (b BBox) West() float64 {
+	return b[0]
+}
+
+// South returns the south edge (minimal latitude) of the bounding box.
+func (b BBox) South() float64 {
+	return b[1]
+}
+
+// East returns the east edge (maximal longitude) of the bounding box.
+func (b BBox) East() float64 {
+	return b[2]
+}
+
+// North returns the north edge (maximal latitude) of the bounding box.
+func (b BBox) North() float64 {
+	return b[3]
+}
+
+// CrossesAntimeridian reports whether the bounding box crosses the antimeridian.
+func (b BBox) CrossesAntimeridian() bool {
+	return b.West() > b.East()
+}
+
+// Contains reports whether the point lies inside the bounding box or on its edge.
+func (b BBox) Contains(p Point) bool {
+	if p.Latitude() < b.South() || p.Latitude() > b.North() {
+		return false
+	}
+	if b.CrossesAntimeridian() {
+		return p.Longitude() >= b.West() || p.Longitude() <= b.East()
+	}
+	return p.Longitude() >= b.West() && p.Longitude() <= b.East()
+}
+
+// Validate checks that all edges of the bounding box are valid coordinates
+// and its south edge is not greater than its north edge.
+//
+// `ErrInvalidBBox` is returned in case the bounding box is invalid.
+func (b BBox) Validate() error {
+	if !ValidPoint(Point{b.West(), b.South()}) || !ValidPoint(Point{b.East(), b.North()}) {
+		return fmt.Errorf("%w: coordinates are out of range", ErrInvalidBBox)
+	}
+	if b.South() > b.North() {
+		return fmt.Errorf("%w: south is greater than north", ErrInvalidBBox)
+	}
+	return nil
+}
+
+// ParseBBox parses a bounding box from "west,south,east,north" string and validates it.
+//
+// `ErrInvalidBBox` is returned in case the string can not be parsed or the bounding box is invalid.
+func ParseBBox(s string) (BBox, error) {
+	var b BBox
+
+	parts := strings.Split(s, ",")
+	if len(parts) != len(b) {
+		return BBox{}, fmt.Errorf("%w: 4 comma separated numbers expected", ErrInvalidBBox)
+	}
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return BBox{}, fmt.Errorf("%w: %v", ErrInvalidBBox, err)
+		}
+		b[i] = v
+	}
+
+	if err := b.Validate(); err != nil {
+		return BBox{}, err
+	}
+
+	return b, nil
+}
+
+// ValidPoint reports whether longitude and latitude of the point are within valid ranges.
+func ValidPoint(p Point) bool {
+	return p.Longitude() >= -180 && p.Longitude() <= 180 &&
+		p.Latitude() >= -90 && p.Latitude() <= 90
+}
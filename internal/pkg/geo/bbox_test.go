@@ -0,0 +1,93 @@
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func TestParseBBox(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		expected geo.BBox
+		isErr    bool
+	}{
+		{
+			name:     "OK",
+			input:    "-10.5,20,30,40.25",
+			expected: geo.BBox{-10.5, 20, 30, 40.25},
+		},
+		{
+			name:     "OK_Spaces",
+			input:    " -10.5, 20 ,30,40.25",
+			expected: geo.BBox{-10.5, 20, 30, 40.25},
+		},
+		{
+			name:     "OK_CrossesAntimeridian",
+			input:    "170,-10,-170,10",
+			expected: geo.BBox{170, -10, -170, 10},
+		},
+		{
+			name:  "TooFewNumbers",
+			input: "1,2,3",
+			isErr: true,
+		},
+		{
+			name:  "NotANumber",
+			input: "1,2,3,a",
+			isErr: true,
+		},
+		{
+			name:  "LongitudeOutOfRange",
+			input: "-180.1,0,10,10",
+			isErr: true,
+		},
+		{
+			name:  "LatitudeOutOfRange",
+			input: "0,0,10,90.1",
+			isErr: true,
+		},
+		{
+			name:  "SouthGreaterThanNorth",
+			input: "0,10,10,0",
+			isErr: true,
+		},
+		{
+			name:  "NaN",
+			input: "NaN,0,10,10",
+			isErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			bbox, err := geo.ParseBBox(tc.input)
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrInvalidBBox)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, bbox)
+		})
+	}
+}
+
+func TestBBox_Contains(t *testing.T) {
+	bbox := geo.BBox{-10, -20, 10, 20}
+	require.False(t, bbox.CrossesAntimeridian())
+	require.True(t, bbox.Contains(geo.Point{0, 0}))
+	require.True(t, bbox.Contains(geo.Point{10, 20}))
+	require.False(t, bbox.Contains(geo.Point{10.1, 0}))
+	require.False(t, bbox.Contains(geo.Point{0, -20.1}))
+
+	bbox = geo.BBox{170, -10, -170, 10}
+	require.True(t, bbox.CrossesAntimeridian())
+	require.True(t, bbox.Contains(geo.Point{180, 0}))
+	require.True(t, bbox.Contains(geo.Point{-175, 5}))
+	require.True(t, bbox.Contains(geo.Point{175, -5}))
+	require.False(t, bbox.Contains(geo.Point{0, 0}))
+	require.False(t, bbox.Contains(geo.Point{175, 11}))
+}
@@ -0,0 +1,35 @@
+package geo
+
+import "math"
+
+// SnapToGrid returns the center of the cell of the grid the point is in.
+//
+// The grid starts at the antimeridian and the south pole and its cells are `size` degrees wide and high.
+// Cells that do not fit the Earth are cut by the antimeridian and the north pole,
+// centers of such cells are clamped to the valid coordinates.
+func SnapToGrid(p Point, size float64) Point {
+	return Point{
+		snapToGrid(p.Longitude(), -180, 180, size),
+		snapToGrid(p.Latitude(), -90, 90, size),
+	}
+}
+
+// snapToGrid returns the center of the cell of the grid between min and max the value is in.
+func snapToGrid(v, min, max, size float64) float64 {
+	cells := math.Ceil((max - min) / size)
+	cell := math.Floor((v - min) / size)
+	if cell >= cells {
+		cell = cells - 1
+	}
+	if cell < 0 {
+		cell = 0
+	}
+	return math.Min(min+(cell+0.5)*size, max)
+}
+
+// SnapToGeohash returns the center of the geohash cell of given precision the point is in.
+// Precision out of [1, MaxGeohashPrecision] range is clamped to it.
+func SnapToGeohash(p Point, precision int) Point {
+	center, _ := GeohashCenter(EncodeGeohash(p, precision))
+	return center
+}
@@ -0,0 +1,73 @@
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func TestSnapToGrid(t *testing.T) {
+	testCases := []struct {
+		name     string
+		point    geo.Point
+		size     float64
+		expected geo.Point
+	}{
+		{
+			name:     "OK",
+			point:    geo.Point{10.123, 20.987},
+			size:     0.5,
+			expected: geo.Point{10.25, 20.75},
+		},
+		{
+			name:     "OK_Negative",
+			point:    geo.Point{-10.123, -20.987},
+			size:     1,
+			expected: geo.Point{-10.5, -20.5},
+		},
+		{
+			name:     "CellBorder",
+			point:    geo.Point{10, 20},
+			size:     1,
+			expected: geo.Point{10.5, 20.5},
+		},
+		{
+			name:     "Antimeridian",
+			point:    geo.Point{180, -180},
+			size:     1,
+			expected: geo.Point{179.5, -89.5},
+		},
+		{
+			name:     "NorthPole",
+			point:    geo.Point{0, 90},
+			size:     1,
+			expected: geo.Point{0.5, 89.5},
+		},
+		{
+			name:     "CutCell",
+			point:    geo.Point{179, 89},
+			size:     7,
+			expected: geo.Point{180, 88.5},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			p := geo.SnapToGrid(tc.point, tc.size)
+			require.InDeltaSlice(t, tc.expected[:], p[:], 1e-9)
+			require.True(t, geo.ValidPoint(p))
+		})
+	}
+}
+
+func TestSnapToGeohash(t *testing.T) {
+	p := geo.SnapToGeohash(geo.Point{10.40744, 57.64911}, 5)
+	require.Equal(t, "u4pru", geo.EncodeGeohash(p, 5))
+
+	cell, err := geo.DecodeGeohash("u4pru")
+	require.NoError(t, err)
+	require.InDelta(t, (cell.West()+cell.East())/2, p.Longitude(), 1e-9)
+	require.InDelta(t, (cell.South()+cell.North())/2, p.Latitude(), 1e-9)
+}
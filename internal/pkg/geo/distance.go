@@ -0,0 +1,134 @@
+package geo
+
+import "math"
+
+// EarthRadius is a radius of the Earth in meters.
+//
+// It equals the radius used by the `<@>` operator of the Postgres earthdistance extension
+// (3958.747716 miles), so that distances calculated in Go and in SQL agree.
+const EarthRadius = 3958.747716 * 1609.344
+
+// Functions of this file use a spherical model of the Earth with `EarthRadius` radius.
+// Use Vincenty functions for distances on the WGS84 ellipsoid.
+
+// radians converts degrees to radians.
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// degrees converts radians to degrees.
+func degrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
+
+// normalizeLongitude returns the longitude in [-180, 180) range.
+func normalizeLongitude(lon float64) float64 {
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+	return lon - 180
+}
+
+// normalizeBearing returns the bearing in [0, 360) range.
+func normalizeBearing(bearing float64) float64 {
+	bearing = math.Mod(bearing, 360)
+	if bearing < 0 {
+		bearing += 360
+	}
+	return bearing
+}
+
+// Distance returns the great circle distance between a and b in meters.
+// It is calculated with the haversine formula.
+func Distance(a, b Point) float64 {
+	lat1 := a[1] * math.Pi / 180
+	lat2 := b[1] * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b[0] - a[0]) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// InitialBearing returns the bearing in degrees clockwise from the north, in [0, 360) range,
+// to follow from a along the great circle path to b.
+// It equals 0 if the points coincide.
+func InitialBearing(a, b Point) float64 {
+	lat1, lat2 := radians(a.Latitude()), radians(b.Latitude())
+	dLon := radians(b.Longitude() - a.Longitude())
+
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+
+	return normalizeBearing(degrees(math.Atan2(y, x)))
+}
+
+// FinalBearing returns the bearing in degrees clockwise from the north, in [0, 360) range,
+// the great circle path from a arrives at b with.
+func FinalBearing(a, b Point) float64 {
+	return normalizeBearing(InitialBearing(b, a) + 180)
+}
+
+// Destination returns the point reached by following the great circle path from p
+// with given initial bearing in degrees for given distance in meters.
+func Destination(p Point, bearing, distance float64) Point {
+	lat1, lon1 := radians(p.Latitude()), radians(p.Longitude())
+	theta := radians(bearing)
+	delta := distance / EarthRadius
+
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(delta) + math.Cos(lat1)*math.Sin(delta)*math.Cos(theta))
+	lon2 := lon1 + math.Atan2(
+		math.Sin(theta)*math.Sin(delta)*math.Cos(lat1),
+		math.Cos(delta)-math.Sin(lat1)*math.Sin(lat2),
+	)
+
+	return Point{normalizeLongitude(degrees(lon2)), degrees(lat2)}
+}
+
+// Midpoint returns the point half-way along the great circle path between a and b.
+func Midpoint(a, b Point) Point {
+	lat1, lon1 := radians(a.Latitude()), radians(a.Longitude())
+	lat2 := radians(b.Latitude())
+	dLon := radians(b.Longitude() - a.Longitude())
+
+	bx := math.Cos(lat2) * math.Cos(dLon)
+	by := math.Cos(lat2) * math.Sin(dLon)
+
+	lat := math.Atan2(math.Sin(lat1)+math.Sin(lat2), math.Hypot(math.Cos(lat1)+bx, by))
+	lon := lon1 + math.Atan2(by, math.Cos(lat1)+bx)
+
+	return Point{normalizeLongitude(degrees(lon)), degrees(lat)}
+}
+
+// RadiusBBox returns the smallest bounding box containing the circle with given center and radius in meters.
+//
+// The bounding box crosses the antimeridian if the circle does.
+// It covers all longitudes if the circle contains a pole, and the whole Earth
+// if the radius is not less than half of the Earth circumference.
+func RadiusBBox(center Point, radius float64) BBox {
+	angle := radius / EarthRadius
+	if angle >= math.Pi {
+		return BBox{-180, -90, 180, 90}
+	}
+
+	south := center.Latitude() - degrees(angle)
+	north := center.Latitude() + degrees(angle)
+	if south <= -90 || north >= 90 {
+		// The circle contains a pole, so it covers all longitudes.
+		return BBox{-180, math.Max(south, -90), 180, math.Min(north, 90)}
+	}
+
+	deltaLon := degrees(math.Asin(math.Sin(angle) / math.Cos(radians(center.Latitude()))))
+
+	west, east := center.Longitude()-deltaLon, center.Longitude()+deltaLon
+	if west < -180 {
+		west += 360
+	}
+	if east > 180 {
+		east -= 360
+	}
+
+	return BBox{west, south, east, north}
+}
@@ -0,0 +1,236 @@
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func TestDistance(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        geo.Point
+		b        geo.Point
+		expected float64
+	}{
+		{
+			name:     "SamePoint",
+			a:        geo.Point{10, 20},
+			b:        geo.Point{10, 20},
+			expected: 0,
+		},
+		{
+			name:     "OneDegreeOfLatitude",
+			a:        geo.Point{0, 0},
+			b:        geo.Point{0, 1},
+			expected: 111194.70,
+		},
+		{
+			name:     "CrossesAntimeridian",
+			a:        geo.Point{179.5, 0},
+			b:        geo.Point{-179.5, 0},
+			expected: 111194.70,
+		},
+		{
+			name:     "Antipodes",
+			a:        geo.Point{0, 0},
+			b:        geo.Point{180, 0},
+			expected: 20015045.59,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.expected, geo.Distance(tc.a, tc.b), 0.01)
+			require.InDelta(t, tc.expected, geo.Distance(tc.b, tc.a), 0.01)
+		})
+	}
+}
+
+// Land's End and John o' Groats, a worked example of spherical formulae
+// by Chris Veness (https://www.movable-type.co.uk/scripts/latlong.html).
+// Its angles are rounded to arc seconds, so they are compared with `arcSecond` tolerance.
+const arcSecond = 1.0 / 3600
+
+var (
+	landsEnd     = geo.Point{-5.714722222, 50.066388889}
+	johnOGroats  = geo.Point{-3.07, 58.643888889}
+	landsEndTrip = 968.9e3
+)
+
+func TestDistance_LandsEndToJohnOGroats(t *testing.T) {
+	// The example uses the mean radius of 6371 km, which differs from `EarthRadius` by 3 meters.
+	require.InDelta(t, landsEndTrip, geo.Distance(landsEnd, johnOGroats), 100)
+}
+
+func TestBearing(t *testing.T) {
+	testCases := []struct {
+		name            string
+		a               geo.Point
+		b               geo.Point
+		expectedInitial float64
+		expectedFinal   float64
+	}{
+		{
+			name:            "LandsEndToJohnOGroats",
+			a:               landsEnd,
+			b:               johnOGroats,
+			expectedInitial: 9.119722,
+			expectedFinal:   11.275278,
+		},
+		{
+			name:            "East",
+			a:               geo.Point{0, 0},
+			b:               geo.Point{10, 0},
+			expectedInitial: 90,
+			expectedFinal:   90,
+		},
+		{
+			name:            "CrossesAntimeridian",
+			a:               geo.Point{179.5, 0},
+			b:               geo.Point{-179.5, 0},
+			expectedInitial: 90,
+			expectedFinal:   90,
+		},
+		{
+			name:            "South",
+			a:               geo.Point{10, 20},
+			b:               geo.Point{10, -20},
+			expectedInitial: 180,
+			expectedFinal:   180,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.InDelta(t, tc.expectedInitial, geo.InitialBearing(tc.a, tc.b), arcSecond)
+			require.InDelta(t, tc.expectedFinal, geo.FinalBearing(tc.a, tc.b), arcSecond)
+		})
+	}
+}
+
+func TestDestination(t *testing.T) {
+	testCases := []struct {
+		name     string
+		p        geo.Point
+		bearing  float64
+		distance float64
+		expected geo.Point
+	}{
+		{
+			// A worked example by Chris Veness (https://www.movable-type.co.uk/scripts/latlong.html).
+			name:     "OK",
+			p:        geo.Point{-1.729722, 53.320556},
+			bearing:  96.021667,
+			distance: 124.8e3,
+			expected: geo.Point{0.133333, 53.188333},
+		},
+		{
+			name:     "OneDegreeNorth",
+			p:        geo.Point{0, 0},
+			bearing:  0,
+			distance: 111194.70,
+			expected: geo.Point{0, 1},
+		},
+		{
+			name:     "CrossesAntimeridian",
+			p:        geo.Point{179.5, 0},
+			bearing:  90,
+			distance: 111194.70,
+			expected: geo.Point{-179.5, 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			dest := geo.Destination(tc.p, tc.bearing, tc.distance)
+			require.InDelta(t, tc.expected.Longitude(), dest.Longitude(), 1e-4)
+			require.InDelta(t, tc.expected.Latitude(), dest.Latitude(), 1e-4)
+		})
+	}
+}
+
+func TestMidpoint(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        geo.Point
+		b        geo.Point
+		expected geo.Point
+	}{
+		{
+			name:     "LandsEndToJohnOGroats",
+			a:        landsEnd,
+			b:        johnOGroats,
+			expected: geo.Point{-4.530556, 54.362222},
+		},
+		{
+			name:     "Equator",
+			a:        geo.Point{10, 0},
+			b:        geo.Point{20, 0},
+			expected: geo.Point{15, 0},
+		},
+		{
+			name:     "CrossesAntimeridian",
+			a:        geo.Point{170, 0},
+			b:        geo.Point{-170, 0},
+			expected: geo.Point{-180, 0},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			mid := geo.Midpoint(tc.a, tc.b)
+			require.InDelta(t, tc.expected.Longitude(), mid.Longitude(), arcSecond)
+			require.InDelta(t, tc.expected.Latitude(), mid.Latitude(), arcSecond)
+			require.InDelta(t, geo.Distance(tc.a, mid), geo.Distance(mid, tc.b), 1e-3)
+		})
+	}
+}
+
+func TestRadiusBBox(t *testing.T) {
+	testCases := []struct {
+		name     string
+		center   geo.Point
+		radius   float64
+		expected geo.BBox
+	}{
+		{
+			name:     "Equator",
+			center:   geo.Point{10, 0},
+			radius:   111194.70,
+			expected: geo.BBox{9, -1, 11, 1},
+		},
+		{
+			name:     "CrossesAntimeridian",
+			center:   geo.Point{179.5, 0},
+			radius:   111194.70,
+			expected: geo.BBox{178.5, -1, -179.5, 1},
+		},
+		{
+			name:     "ContainsPole",
+			center:   geo.Point{10, 89.5},
+			radius:   111194.70,
+			expected: geo.BBox{-180, 88.5, 180, 90},
+		},
+		{
+			name:     "WholeEarth",
+			center:   geo.Point{10, 20},
+			radius:   20015045.59,
+			expected: geo.BBox{-180, -90, 180, 90},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			bbox := geo.RadiusBBox(tc.center, tc.radius)
+			require.InDeltaSlice(t, tc.expected[:], bbox[:], 1e-6)
+			require.NoError(t, bbox.Validate())
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package geo
+
+// FixSource is a source a location fix is obtained from.
+type FixSource string
+
+const (
+	FixSourceGPS     FixSource = "gps"
+	FixSourceNetwork FixSource = "network"
+	FixSourceManual  FixSource = "manual"
+)
+
+// ValidFixSource reports whether s is one of the known fix sources.
+func ValidFixSource(s FixSource) bool {
+	switch s {
+	case FixSourceGPS, FixSourceNetwork, FixSourceManual:
+		return true
+	}
+	return false
+}
+
+// ValidBearing reports whether b is a bearing in degrees in the range [0, 360).
+func ValidBearing(b float64) bool {
+	return 0 <= b && b < 360
+}
+
+// FixMetadata is optional metadata of a location fix, nil or empty fields are unknown.
+//
+// `Accuracy` is horizontal accuracy in meters, `Altitude` is in meters above sea level,
+// `Speed` is in meters per second and `Bearing` is in degrees clockwise from true north.
+type FixMetadata struct {
+	Accuracy *float64  `json:"accuracy,omitempty" validate:"omitempty,gte=0"`
+	Altitude *float64  `json:"altitude,omitempty"`
+	Speed    *float64  `json:"speed,omitempty" validate:"omitempty,gte=0"`
+	Bearing  *float64  `json:"bearing,omitempty" validate:"omitempty,validbearing"`
+	Source   FixSource `json:"source,omitempty" validate:"omitempty,validfixsource"`
+}
@@ -0,0 +1,338 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	// ErrInvalidGeohash is returned in case a geohash is invalid.
+	ErrInvalidGeohash = errors.New("invalid geohash")
+	// ErrGeohashCoverTooLarge is returned in case an area requires too many geohashes to be covered.
+	ErrGeohashCoverTooLarge = errors.New("geohash cover is too large")
+)
+
+const (
+	// MaxGeohashPrecision is a maximal number of characters of a geohash.
+	MaxGeohashPrecision = 12
+	// MaxGeohashCoverLength is a maximal number of geohashes an area can be covered with.
+	MaxGeohashCoverLength = 1024
+)
+
+// geohashAlphabet is the base32 alphabet of geohashes.
+const geohashAlphabet = "0123456789bcdefghjkmnpqrstuvwxyz"
+
+// GeohashDirection is a direction of a neighbouring geohash cell.
+type GeohashDirection int
+
+const (
+	GeohashNorth GeohashDirection = iota
+	GeohashNorthEast
+	GeohashEast
+	GeohashSouthEast
+	GeohashSouth
+	GeohashSouthWest
+	GeohashWest
+	GeohashNorthWest
+)
+
+// geohashOffsets are column and row offsets of neighbouring cells in order of directions.
+var geohashOffsets = [...][2]int{
+	GeohashNorth:     {0, 1},
+	GeohashNorthEast: {1, 1},
+	GeohashEast:      {1, 0},
+	GeohashSouthEast: {1, -1},
+	GeohashSouth:     {0, -1},
+	GeohashSouthWest: {-1, -1},
+	GeohashWest:      {-1, 0},
+	GeohashNorthWest: {-1, 1},
+}
+
+// geohashGrid is a grid of geohash cells of a single precision.
+//
+// A geohash interleaves bits of the column and the row of its cell starting with the column,
+// so cells are split into `cols` columns from the antimeridian to the east and `rows` rows
+// from the south pole to the north.
+type geohashGrid struct {
+	precision        int
+	colBits, rowBits uint
+	cols, rows       int
+}
+
+func newGeohashGrid(precision int) geohashGrid {
+	bits := uint(5 * precision)
+	colBits, rowBits := (bits+1)/2, bits/2
+	return geohashGrid{
+		precision: precision,
+		colBits:   colBits,
+		rowBits:   rowBits,
+		cols:      1 << colBits,
+		rows:      1 << rowBits,
+	}
+}
+
+// index returns an index of the interval out of 2^bits equal intervals of [min, max] that contains value.
+// It is found by bisection the same way geohashes are encoded.
+func geohashIndex(value, min, max float64, bits uint) int {
+	index := 0
+	for i := uint(0); i < bits; i++ {
+		mid := (min + max) / 2
+		index <<= 1
+		if value >= mid {
+			index |= 1
+			min = mid
+		} else {
+			max = mid
+		}
+	}
+	return index
+}
+
+// col returns the column of the longitude, the longitude must be in [-180, 180] range.
+func (g geohashGrid) col(longitude float64) int {
+	return geohashIndex(longitude, -180, 180, g.colBits)
+}
+
+// row returns the row of the latitude, the latitude must be in [-90, 90] range.
+func (g geohashGrid) row(latitude float64) int {
+	return geohashIndex(latitude, -90, 90, g.rowBits)
+}
+
+// encode returns the geohash of the cell.
+func (g geohashGrid) encode(col, row int) string {
+	var sb strings.Builder
+	sb.Grow(g.precision)
+
+	colBit, rowBit := g.colBits, g.rowBits
+	for i := 0; i < g.precision; i++ {
+		c := 0
+		for j := 0; j < 5; j++ {
+			c <<= 1
+			// Even bits are taken from the column, odd ones from the row.
+			if (i*5+j)%2 == 0 {
+				colBit--
+				c |= (col >> colBit) & 1
+			} else {
+				rowBit--
+				c |= (row >> rowBit) & 1
+			}
+		}
+		sb.WriteByte(geohashAlphabet[c])
+	}
+
+	return sb.String()
+}
+
+// bbox returns the bounding box of the cell.
+func (g geohashGrid) bbox(col, row int) BBox {
+	width, height := 360/float64(g.cols), 180/float64(g.rows)
+	return BBox{
+		-180 + float64(col)*width,
+		-90 + float64(row)*height,
+		-180 + float64(col+1)*width,
+		-90 + float64(row+1)*height,
+	}
+}
+
+// decodeGeohash returns the grid of the geohash precision and the column and the row of its cell.
+//
+// `ErrInvalidGeohash` is returned in case the geohash is empty, too long or contains characters out of the alphabet.
+func decodeGeohash(hash string) (geohashGrid, int, int, error) {
+	if len(hash) == 0 || len(hash) > MaxGeohashPrecision {
+		return geohashGrid{}, 0, 0, fmt.Errorf("%w: length must be from 1 to %d", ErrInvalidGeohash, MaxGeohashPrecision)
+	}
+
+	g := newGeohashGrid(len(hash))
+	col, row := 0, 0
+	for i, r := range strings.ToLower(hash) {
+		c := strings.IndexRune(geohashAlphabet, r)
+		if c < 0 {
+			return geohashGrid{}, 0, 0, fmt.Errorf("%w: unexpected character %q", ErrInvalidGeohash, r)
+		}
+		for j := 4; j >= 0; j-- {
+			bit := (c >> uint(j)) & 1
+			if (i*5+4-j)%2 == 0 {
+				col = col<<1 | bit
+			} else {
+				row = row<<1 | bit
+			}
+		}
+	}
+
+	return g, col, row, nil
+}
+
+// clampGeohashPrecision returns the precision limited to [1, MaxGeohashPrecision] range.
+func clampGeohashPrecision(precision int) int {
+	if precision < 1 {
+		return 1
+	}
+	if precision > MaxGeohashPrecision {
+		return MaxGeohashPrecision
+	}
+	return precision
+}
+
+// EncodeGeohash returns the geohash of the point with given number of characters.
+//
+// Precision out of [1, MaxGeohashPrecision] range is clamped to it.
+// The point must be valid.
+func EncodeGeohash(p Point, precision int) string {
+	g := newGeohashGrid(clampGeohashPrecision(precision))
+	return g.encode(g.col(p.Longitude()), g.row(p.Latitude()))
+}
+
+// DecodeGeohash returns the bounding box of the geohash cell.
+// Geohashes are case-insensitive.
+//
+// `ErrInvalidGeohash` is returned in case the geohash is invalid.
+func DecodeGeohash(hash string) (BBox, error) {
+	g, col, row, err := decodeGeohash(hash)
+	if err != nil {
+		return BBox{}, err
+	}
+
+	return g.bbox(col, row), nil
+}
+
+// GeohashCenter returns the center of the geohash cell.
+//
+// `ErrInvalidGeohash` is returned in case the geohash is invalid.
+func GeohashCenter(hash string) (Point, error) {
+	b, err := DecodeGeohash(hash)
+	if err != nil {
+		return Point{}, err
+	}
+
+	return Point{(b.West() + b.East()) / 2, (b.South() + b.North()) / 2}, nil
+}
+
+// ValidGeohash reports whether the geohash can be decoded.
+func ValidGeohash(hash string) bool {
+	_, _, _, err := decodeGeohash(hash)
+	return err == nil
+}
+
+// GeohashNeighbor returns the geohash of the same precision next to the given one in given direction.
+// Neighbours across the antimeridian are supported, but there are no neighbours beyond the poles,
+// so an empty string is returned in that case.
+//
+// `ErrInvalidGeohash` is returned in case the geohash is invalid.
+func GeohashNeighbor(hash string, direction GeohashDirection) (string, error) {
+	g, col, row, err := decodeGeohash(hash)
+	if err != nil {
+		return "", err
+	}
+	if direction < GeohashNorth || direction > GeohashNorthWest {
+		return "", fmt.Errorf("%w: unknown direction %d", ErrInvalidGeohash, direction)
+	}
+
+	offset := geohashOffsets[direction]
+	row += offset[1]
+	if row < 0 || row >= g.rows {
+		return "", nil
+	}
+	col = ((col+offset[0])%g.cols + g.cols) % g.cols
+
+	return g.encode(col, row), nil
+}
+
+// GeohashNeighbors returns all neighbours of the geohash in order of directions starting from the north clockwise.
+// Neighbours beyond the poles are skipped.
+//
+// `ErrInvalidGeohash` is returned in case the geohash is invalid.
+func GeohashNeighbors(hash string) ([]string, error) {
+	neighbors := make([]string, 0, len(geohashOffsets))
+	for direction := range geohashOffsets {
+		neighbor, err := GeohashNeighbor(hash, GeohashDirection(direction))
+		if err != nil {
+			return nil, err
+		}
+		if neighbor != "" {
+			neighbors = append(neighbors, neighbor)
+		}
+	}
+	return neighbors, nil
+}
+
+// GeohashCoverBBox returns geohashes of given precision whose cells together cover the bounding box.
+// Every point inside the bounding box has a geohash starting with one of them.
+// Bounding boxes crossing the antimeridian are supported.
+//
+// Precision out of [1, MaxGeohashPrecision] range is clamped to it.
+//
+// `ErrGeohashCoverTooLarge` is returned in case more than `MaxGeohashCoverLength` geohashes are required.
+func GeohashCoverBBox(b BBox, precision int) ([]string, error) {
+	return geohashCover(newGeohashGrid(clampGeohashPrecision(precision)), b, nil)
+}
+
+// GeohashCoverCircle returns geohashes of given precision whose cells together cover the circle
+// with given center and radius in meters. Cells that do not intersect the circle are skipped.
+//
+// Precision out of [1, MaxGeohashPrecision] range is clamped to it.
+//
+// `ErrGeohashCoverTooLarge` is returned in case more than `MaxGeohashCoverLength` geohashes are required.
+func GeohashCoverCircle(center Point, radius float64, precision int) ([]string, error) {
+	g := newGeohashGrid(clampGeohashPrecision(precision))
+	return geohashCover(g, RadiusBBox(center, radius), func(cell BBox) bool {
+		return bboxDistance(center, cell) <= radius
+	})
+}
+
+// geohashCover returns geohashes of cells of the grid inside the bounding box
+// that match the filter, unless it is nil.
+func geohashCover(g geohashGrid, b BBox, filter func(cell BBox) bool) ([]string, error) {
+	col0, col1 := g.col(b.West()), g.col(b.East())
+	row0, row1 := g.row(b.South()), g.row(b.North())
+
+	width := col1 - col0 + 1
+	if b.CrossesAntimeridian() {
+		width += g.cols
+	}
+	if width > g.cols {
+		width = g.cols
+	}
+	if width*(row1-row0+1) > MaxGeohashCoverLength {
+		return nil, fmt.Errorf("%w: %d geohashes required", ErrGeohashCoverTooLarge, width*(row1-row0+1))
+	}
+
+	var hashes []string
+	for row := row0; row <= row1; row++ {
+		for i := 0; i < width; i++ {
+			col := (col0 + i) % g.cols
+			if filter == nil || filter(g.bbox(col, row)) {
+				hashes = append(hashes, g.encode(col, row))
+			}
+		}
+	}
+
+	return hashes, nil
+}
+
+// bboxDistance returns the great circle distance in meters between the point and the nearest point
+// of the bounding box, it equals 0 if the point is inside the bounding box.
+func bboxDistance(p Point, b BBox) float64 {
+	lat := math.Max(b.South(), math.Min(b.North(), p.Latitude()))
+	if b.Contains(Point{p.Longitude(), lat}) {
+		// The nearest point lies on the same meridian.
+		return Distance(p, Point{p.Longitude(), lat})
+	}
+
+	// The nearest point lies on the nearest of the west and east edges.
+	edge := b.West()
+	delta := math.Mod(b.West()-p.Longitude()+720, 360)
+	if d := math.Mod(p.Longitude()-b.East()+720, 360); d < delta {
+		edge, delta = b.East(), d
+	}
+
+	// The nearest point of the whole meridian of the edge, unless it is on the other side of a pole.
+	deltaRad := delta * math.Pi / 180
+	if math.Cos(deltaRad) <= 0 {
+		return math.Min(Distance(p, Point{edge, b.South()}), Distance(p, Point{edge, b.North()}))
+	}
+	nearest := math.Atan(math.Tan(p.Latitude()*math.Pi/180)/math.Cos(deltaRad)) * 180 / math.Pi
+
+	return Distance(p, Point{edge, math.Max(b.South(), math.Min(b.North(), nearest))})
+}
@@ -0,0 +1,317 @@
+package geo_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func TestEncodeGeohash(t *testing.T) {
+	testCases := []struct {
+		name      string
+		point     geo.Point
+		precision int
+		expected  string
+	}{
+		{
+			name:      "Jutland",
+			point:     geo.Point{10.40744, 57.64911},
+			precision: 11,
+			expected:  "u4pruydqqvj",
+		},
+		{
+			name:      "Spain",
+			point:     geo.Point{-5.6, 42.6},
+			precision: 5,
+			expected:  "ezs42",
+		},
+		{
+			name:      "Origin",
+			point:     geo.Point{0, 0},
+			precision: 6,
+			expected:  "s00000",
+		},
+		{
+			name:      "SouthWestCorner",
+			point:     geo.Point{-180, -90},
+			precision: 4,
+			expected:  "0000",
+		},
+		{
+			name:      "NorthEastCorner",
+			point:     geo.Point{180, 90},
+			precision: 4,
+			expected:  "zzzz",
+		},
+		{
+			name:      "PrecisionClampedToMin",
+			point:     geo.Point{10.40744, 57.64911},
+			precision: 0,
+			expected:  "u",
+		},
+		{
+			name:      "PrecisionClampedToMax",
+			point:     geo.Point{10.40744, 57.64911},
+			precision: 20,
+			expected:  "u4pruydqqvj8",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, geo.EncodeGeohash(tc.point, tc.precision))
+		})
+	}
+}
+
+func TestDecodeGeohash(t *testing.T) {
+	testCases := []struct {
+		name     string
+		hash     string
+		expected geo.BBox
+		isErr    bool
+	}{
+		{
+			name:     "OK",
+			hash:     "ezs42",
+			expected: geo.BBox{-5.625, 42.5830078125, -5.5810546875, 42.626953125},
+		},
+		{
+			name:     "OK_UpperCase",
+			hash:     "EZS42",
+			expected: geo.BBox{-5.625, 42.5830078125, -5.5810546875, 42.626953125},
+		},
+		{
+			name:     "OK_SingleCharacter",
+			hash:     "s",
+			expected: geo.BBox{0, 0, 45, 45},
+		},
+		{
+			name:  "Empty",
+			hash:  "",
+			isErr: true,
+		},
+		{
+			name:  "TooLong",
+			hash:  "u4pruydqqvj8s",
+			isErr: true,
+		},
+		{
+			name:  "UnexpectedCharacter",
+			hash:  "ezs4a",
+			isErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			bbox, err := geo.DecodeGeohash(tc.hash)
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrInvalidGeohash)
+				require.False(t, geo.ValidGeohash(tc.hash))
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, geo.ValidGeohash(tc.hash))
+			require.InDeltaSlice(t, tc.expected[:], bbox[:], 1e-9)
+		})
+	}
+}
+
+func TestGeohashCenter(t *testing.T) {
+	center, err := geo.GeohashCenter("u4pruydqqvj")
+	require.NoError(t, err)
+	require.InDelta(t, 10.40744, center.Longitude(), 1e-5)
+	require.InDelta(t, 57.64911, center.Latitude(), 1e-5)
+}
+
+func TestGeohash_RoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		point := geo.Point{rnd.Float64()*360 - 180, rnd.Float64()*180 - 90}
+		for precision := 1; precision <= geo.MaxGeohashPrecision; precision++ {
+			hash := geo.EncodeGeohash(point, precision)
+			require.Len(t, hash, precision)
+
+			bbox, err := geo.DecodeGeohash(hash)
+			require.NoError(t, err)
+			require.True(t, bbox.Contains(point), "%v is outside of %s", point, hash)
+
+			center, err := geo.GeohashCenter(hash)
+			require.NoError(t, err)
+			require.Equal(t, hash, geo.EncodeGeohash(center, precision))
+		}
+	}
+}
+
+func TestGeohashNeighbors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		hash     string
+		expected []string
+		isErr    bool
+	}{
+		{
+			name:     "OK",
+			hash:     "gbsuv",
+			expected: []string{"gbsvj", "gbsvn", "gbsuy", "gbsuw", "gbsut", "gbsus", "gbsuu", "gbsvh"},
+		},
+		{
+			name:     "CrossesAntimeridian",
+			hash:     "2",
+			expected: []string{"8", "9", "3", "1", "0", "p", "r", "x"},
+		},
+		{
+			name:     "NorthPole",
+			hash:     "z",
+			expected: []string{"b", "8", "x", "w", "y"},
+		},
+		{
+			name:  "Invalid",
+			hash:  "a",
+			isErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			neighbors, err := geo.GeohashNeighbors(tc.hash)
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrInvalidGeohash)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, neighbors)
+		})
+	}
+}
+
+func TestGeohashNeighbor(t *testing.T) {
+	neighbor, err := geo.GeohashNeighbor("z", geo.GeohashNorth)
+	require.NoError(t, err)
+	require.Empty(t, neighbor)
+
+	neighbor, err = geo.GeohashNeighbor("ezs42", geo.GeohashEast)
+	require.NoError(t, err)
+	require.Equal(t, "ezs43", neighbor)
+}
+
+func TestGeohashCoverBBox(t *testing.T) {
+	testCases := []struct {
+		name      string
+		bbox      geo.BBox
+		precision int
+		expected  []string
+		isErr     bool
+	}{
+		{
+			name:      "SingleCell",
+			bbox:      geo.BBox{-5.62, 42.59, -5.59, 42.62},
+			precision: 5,
+			expected:  []string{"ezs42"},
+		},
+		{
+			name:      "FourCells",
+			bbox:      geo.BBox{-1, -1, 1, 1},
+			precision: 1,
+			expected:  []string{"7", "k", "e", "s"},
+		},
+		{
+			name:      "CrossesAntimeridian",
+			bbox:      geo.BBox{170, 10, -170, 20},
+			precision: 1,
+			expected:  []string{"x", "8"},
+		},
+		{
+			name:      "World",
+			bbox:      geo.BBox{-180, -90, 180, 90},
+			precision: 1,
+			expected: []string{
+				"0", "1", "4", "5", "h", "j", "n", "p",
+				"2", "3", "6", "7", "k", "m", "q", "r",
+				"8", "9", "d", "e", "s", "t", "w", "x",
+				"b", "c", "f", "g", "u", "v", "y", "z",
+			},
+		},
+		{
+			name:      "TooLarge",
+			bbox:      geo.BBox{-180, -90, 180, 90},
+			precision: 3,
+			isErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hashes, err := geo.GeohashCoverBBox(tc.bbox, tc.precision)
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrGeohashCoverTooLarge)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, hashes)
+		})
+	}
+}
+
+func TestGeohashCoverCircle(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	testCases := []struct {
+		name      string
+		center    geo.Point
+		radius    float64
+		precision int
+	}{
+		{name: "Small", center: geo.Point{10.40744, 57.64911}, radius: 500, precision: 6},
+		{name: "CrossesAntimeridian", center: geo.Point{179.9, -30}, radius: 50000, precision: 4},
+		{name: "NorthPole", center: geo.Point{45, 89.5}, radius: 100000, precision: 3},
+		{name: "Large", center: geo.Point{-70, 10}, radius: 3000000, precision: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			hashes, err := geo.GeohashCoverCircle(tc.center, tc.radius, tc.precision)
+			require.NoError(t, err)
+
+			covered := make(map[string]bool, len(hashes))
+			for _, hash := range hashes {
+				covered[hash] = true
+
+				// Every cell of the cover intersects the circle.
+				bbox, err := geo.DecodeGeohash(hash)
+				require.NoError(t, err)
+				near := false
+				for i := 0; i <= 10 && !near; i++ {
+					for j := 0; j <= 10 && !near; j++ {
+						p := geo.Point{
+							bbox.West() + (bbox.East()-bbox.West())*float64(i)/10,
+							bbox.South() + (bbox.North()-bbox.South())*float64(j)/10,
+						}
+						near = geo.Distance(tc.center, p) <= tc.radius+geo.Distance(geo.Point{bbox.West(), bbox.South()}, geo.Point{bbox.East(), bbox.North()})/10
+					}
+				}
+				require.True(t, near, "%s does not intersect the circle", hash)
+			}
+
+			// Every point inside the circle has a geohash starting with one of the cover.
+			for i := 0; i < 1000; i++ {
+				p := geo.Point{
+					tc.center.Longitude() + (rnd.Float64()*2-1)*10,
+					tc.center.Latitude() + (rnd.Float64()*2-1)*10,
+				}
+				if p.Longitude() > 180 {
+					p[0] -= 360
+				}
+				if p.Longitude() < -180 {
+					p[0] += 360
+				}
+				if !geo.ValidPoint(p) || geo.Distance(tc.center, p) > tc.radius {
+					continue
+				}
+				require.True(t, covered[geo.EncodeGeohash(p, tc.precision)], "%v is not covered", p)
+			}
+		})
+	}
+}
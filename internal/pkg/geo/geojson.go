@@ -0,0 +1,175 @@
+package geo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidGeoJSON is returned in case a GeoJSON object is malformed or of an unsupported type.
+var ErrInvalidGeoJSON = errors.New("invalid GeoJSON")
+
+// GeoJSONMediaType is the media type of GeoJSON documents.
+const GeoJSONMediaType = "application/geo+json"
+
+// Types of GeoJSON objects.
+const (
+	GeoJSONTypePoint             = "Point"
+	GeoJSONTypeLineString        = "LineString"
+	GeoJSONTypePolygon           = "Polygon"
+	GeoJSONTypeFeature           = "Feature"
+	GeoJSONTypeFeatureCollection = "FeatureCollection"
+)
+
+// Geometry is a GeoJSON geometry object, see RFC 7946.
+//
+// `Coordinates` is a `Point` for geometries of `GeoJSONTypePoint` type, a `[]Point` for geometries
+// of `GeoJSONTypeLineString` type and a `Polygon` for geometries of `GeoJSONTypePolygon` type,
+// other types are not supported.
+type Geometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// NewPointGeometry returns a geometry of the point.
+func NewPointGeometry(p Point) Geometry {
+	return Geometry{Type: GeoJSONTypePoint, Coordinates: p}
+}
+
+// NewLineStringGeometry returns a geometry of the line through the points.
+// Points equal to nil are encoded as an empty array.
+func NewLineStringGeometry(points []Point) Geometry {
+	if points == nil {
+		points = make([]Point, 0)
+	}
+	return Geometry{Type: GeoJSONTypeLineString, Coordinates: points}
+}
+
+// NewPolygonGeometry returns a geometry of the polygon.
+func NewPolygonGeometry(p Polygon) Geometry {
+	return Geometry{Type: GeoJSONTypePolygon, Coordinates: p}
+}
+
+// Point returns coordinates of the geometry and true if it is a point.
+func (g Geometry) Point() (Point, bool) {
+	p, ok := g.Coordinates.(Point)
+	return p, ok
+}
+
+// LineString returns coordinates of the geometry and true if it is a line string.
+func (g Geometry) LineString() ([]Point, bool) {
+	p, ok := g.Coordinates.([]Point)
+	return p, ok
+}
+
+// Polygon returns coordinates of the geometry and true if it is a polygon.
+func (g Geometry) Polygon() (Polygon, bool) {
+	p, ok := g.Coordinates.(Polygon)
+	return p, ok
+}
+
+// UnmarshalJSON decodes a geometry of a supported type.
+// Coordinates are not validated, use `ValidPoint` or `Polygon.Validate` for that.
+//
+// `ErrInvalidGeoJSON` is returned in case the geometry is malformed or of an unsupported type.
+func (g *Geometry) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Type        string          `json:"type"`
+		Coordinates json.RawMessage `json:"coordinates"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGeoJSON, err)
+	}
+	if len(raw.Coordinates) == 0 {
+		return fmt.Errorf("%w: coordinates are missing", ErrInvalidGeoJSON)
+	}
+
+	var err error
+	switch raw.Type {
+	case GeoJSONTypePoint:
+		var p Point
+		err = json.Unmarshal(raw.Coordinates, &p)
+		g.Coordinates = p
+	case GeoJSONTypeLineString:
+		var p []Point
+		err = json.Unmarshal(raw.Coordinates, &p)
+		g.Coordinates = p
+	case GeoJSONTypePolygon:
+		var p Polygon
+		err = json.Unmarshal(raw.Coordinates, &p)
+		g.Coordinates = p
+	default:
+		return fmt.Errorf("%w: unsupported geometry type %q", ErrInvalidGeoJSON, raw.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGeoJSON, err)
+	}
+
+	g.Type = raw.Type
+	return nil
+}
+
+// Feature is a GeoJSON feature object.
+//
+// `ID` is omitted if it is nil, `Geometry` is null for unlocated features.
+type Feature struct {
+	Type       string                 `json:"type"`
+	ID         interface{}            `json:"id,omitempty"`
+	Geometry   *Geometry              `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// NewFeature returns a feature with given ID, geometry and properties.
+func NewFeature(id interface{}, geometry Geometry, properties map[string]interface{}) Feature {
+	return Feature{
+		Type:       GeoJSONTypeFeature,
+		ID:         id,
+		Geometry:   &geometry,
+		Properties: properties,
+	}
+}
+
+// FeatureCollection is a GeoJSON feature collection object.
+type FeatureCollection struct {
+	Type     string    `json:"type"`
+	Features []Feature `json:"features"`
+}
+
+// NewFeatureCollection returns a collection of the features.
+// Features equal to nil are encoded as an empty array.
+func NewFeatureCollection(features []Feature) FeatureCollection {
+	if features == nil {
+		features = make([]Feature, 0)
+	}
+	return FeatureCollection{
+		Type:     GeoJSONTypeFeatureCollection,
+		Features: features,
+	}
+}
+
+// ParseGeoJSONGeometry returns the geometry of GeoJSON geometry or feature object.
+//
+// `ErrInvalidGeoJSON` is returned in case the object is malformed, of another type,
+// has an unsupported geometry or no geometry at all.
+func ParseGeoJSONGeometry(data []byte) (Geometry, error) {
+	var object struct {
+		Type     string          `json:"type"`
+		Geometry json.RawMessage `json:"geometry"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return Geometry{}, fmt.Errorf("%w: %v", ErrInvalidGeoJSON, err)
+	}
+
+	if object.Type == GeoJSONTypeFeature {
+		if len(object.Geometry) == 0 || string(object.Geometry) == "null" {
+			return Geometry{}, fmt.Errorf("%w: feature has no geometry", ErrInvalidGeoJSON)
+		}
+		data = object.Geometry
+	}
+
+	var g Geometry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return Geometry{}, err
+	}
+	return g, nil
+}
@@ -0,0 +1,152 @@
+package geo_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func TestFeatureCollection_Marshal(t *testing.T) {
+	collection := geo.NewFeatureCollection([]geo.Feature{
+		geo.NewFeature(1, geo.NewPointGeometry(geo.Point{10.5, -20}), map[string]interface{}{"username": "user1"}),
+		geo.NewFeature("track", geo.NewLineStringGeometry([]geo.Point{{0, 0}, {1, 1}}), nil),
+		geo.NewFeature(nil, geo.NewPolygonGeometry(geo.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}}), nil),
+	})
+
+	b, err := json.Marshal(collection)
+	require.NoError(t, err)
+	require.JSONEq(t, `{
+		"type": "FeatureCollection",
+		"features": [
+			{
+				"type": "Feature",
+				"id": 1,
+				"geometry": {"type": "Point", "coordinates": [10.5, -20]},
+				"properties": {"username": "user1"}
+			},
+			{
+				"type": "Feature",
+				"id": "track",
+				"geometry": {"type": "LineString", "coordinates": [[0, 0], [1, 1]]},
+				"properties": null
+			},
+			{
+				"type": "Feature",
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [1, 0], [1, 1], [0, 0]]]},
+				"properties": null
+			}
+		]
+	}`, string(b))
+
+	b, err = json.Marshal(geo.NewFeatureCollection(nil))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "FeatureCollection", "features": []}`, string(b))
+
+	b, err = json.Marshal(geo.NewLineStringGeometry(nil))
+	require.NoError(t, err)
+	require.JSONEq(t, `{"type": "LineString", "coordinates": []}`, string(b))
+}
+
+func TestParseGeoJSONGeometry(t *testing.T) {
+	polygon := geo.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}}
+
+	testCases := []struct {
+		name     string
+		input    string
+		expected geo.Geometry
+		isErr    bool
+	}{
+		{
+			name:     "Point",
+			input:    `{"type": "Point", "coordinates": [10.5, -20]}`,
+			expected: geo.NewPointGeometry(geo.Point{10.5, -20}),
+		},
+		{
+			name:     "LineString",
+			input:    `{"type": "LineString", "coordinates": [[0, 0], [10, 0]]}`,
+			expected: geo.NewLineStringGeometry([]geo.Point{{0, 0}, {10, 0}}),
+		},
+		{
+			name:     "Polygon",
+			input:    `{"type": "Polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 0]]]}`,
+			expected: geo.NewPolygonGeometry(polygon),
+		},
+		{
+			name: "Feature",
+			input: `{
+				"type": "Feature",
+				"geometry": {"type": "Polygon", "coordinates": [[[0, 0], [10, 0], [10, 10], [0, 0]]]},
+				"properties": {"name": "area"}
+			}`,
+			expected: geo.NewPolygonGeometry(polygon),
+		},
+		{
+			name:  "FeatureWithoutGeometry",
+			input: `{"type": "Feature", "geometry": null, "properties": {}}`,
+			isErr: true,
+		},
+		{
+			name:  "UnsupportedType",
+			input: `{"type": "MultiPoint", "coordinates": [[0, 0], [10, 0]]}`,
+			isErr: true,
+		},
+		{
+			name:  "FeatureCollection",
+			input: `{"type": "FeatureCollection", "features": []}`,
+			isErr: true,
+		},
+		{
+			name:  "MissingCoordinates",
+			input: `{"type": "Point"}`,
+			isErr: true,
+		},
+		{
+			name:  "MalformedCoordinates",
+			input: `{"type": "Polygon", "coordinates": [0, 0]}`,
+			isErr: true,
+		},
+		{
+			name:  "NotJSON",
+			input: `Polygon`,
+			isErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := geo.ParseGeoJSONGeometry([]byte(tc.input))
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrInvalidGeoJSON)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, g)
+		})
+	}
+}
+
+func TestGeometry_Accessors(t *testing.T) {
+	g := geo.NewPointGeometry(geo.Point{1, 2})
+	p, ok := g.Point()
+	require.True(t, ok)
+	require.Equal(t, geo.Point{1, 2}, p)
+	_, ok = g.Polygon()
+	require.False(t, ok)
+
+	g = geo.NewPolygonGeometry(geo.Polygon{{{0, 0}, {1, 0}, {1, 1}, {0, 0}}})
+	_, ok = g.Point()
+	require.False(t, ok)
+	polygon, ok := g.Polygon()
+	require.True(t, ok)
+	require.Len(t, polygon, 1)
+
+	g = geo.NewLineStringGeometry([]geo.Point{{0, 0}, {1, 1}})
+	_, ok = g.Polygon()
+	require.False(t, ok)
+	line, ok := g.LineString()
+	require.True(t, ok)
+	require.Equal(t, []geo.Point{{0, 0}, {1, 1}}, line)
+}
@@ -0,0 +1,155 @@
+package geo
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrInvalidPolygon is returned in case a polygon is invalid.
+var ErrInvalidPolygon = errors.New("invalid polygon")
+
+const (
+	// MinRingLength is a minimal number of positions in a linear ring.
+	MinRingLength = 4
+	// MaxPolygonLength is a maximal total number of positions in a polygon.
+	MaxPolygonLength = 1000
+)
+
+// Ring represents a closed linear ring, its first and last positions are equal.
+type Ring []Point
+
+// Polygon represents a polygon as defined by GeoJSON.
+//
+// The first ring is the exterior ring, others are holes.
+// An edge which longitudes differ by more than 180 degrees crosses the antimeridian.
+type Polygon []Ring
+
+// Validate checks that the polygon has an exterior ring,
+// all rings are closed and have at least `MinRingLength` valid positions,
+// and the polygon has no more than `MaxPolygonLength` positions.
+//
+// `ErrInvalidPolygon` is returned in case the polygon is invalid.
+func (p Polygon) Validate() error {
+	if len(p) == 0 {
+		return fmt.Errorf("%w: exterior ring is missing", ErrInvalidPolygon)
+	}
+
+	total := 0
+	for _, ring := range p {
+		if len(ring) < MinRingLength {
+			return fmt.Errorf("%w: ring must have at least %d positions", ErrInvalidPolygon, MinRingLength)
+		}
+		if ring[0] != ring[len(ring)-1] {
+			return fmt.Errorf("%w: ring is not closed", ErrInvalidPolygon)
+		}
+		for _, point := range ring {
+			if !ValidPoint(point) {
+				return fmt.Errorf("%w: coordinates are out of range", ErrInvalidPolygon)
+			}
+		}
+		total += len(ring)
+	}
+	if total > MaxPolygonLength {
+		return fmt.Errorf("%w: polygon must have at most %d positions", ErrInvalidPolygon, MaxPolygonLength)
+	}
+
+	return nil
+}
+
+// Unwrap returns a copy of the polygon which rings do not jump over the antimeridian.
+//
+// Longitudes of every ring are shifted by 360 degrees where needed so that
+// longitudes of neighbouring positions differ by no more than 180 degrees.
+// Holes are shifted to the same side of the antimeridian as the exterior ring.
+// Thus, the result may contain longitudes out of [-180, 180] range,
+// and a point is inside the polygon if the point or the point shifted by ±360 degrees is inside it.
+func (p Polygon) Unwrap() Polygon {
+	result := make(Polygon, 0, len(p))
+	for i, ring := range p {
+		unwrapped := make(Ring, len(ring))
+		for j, point := range ring {
+			if j > 0 {
+				prev := unwrapped[j-1].Longitude()
+				point[0] += 360 * math.Round((prev-point.Longitude())/360)
+			}
+			unwrapped[j] = point
+		}
+		if i > 0 && len(unwrapped) > 0 && len(result[0]) > 0 {
+			shift := 360 * math.Round((result[0][0].Longitude()-unwrapped[0].Longitude())/360)
+			for j := range unwrapped {
+				unwrapped[j][0] += shift
+			}
+		}
+		result = append(result, unwrapped)
+	}
+	return result
+}
+
+// Contains reports whether the point lies inside the ring or on its edge.
+//
+// Longitudes are compared as is, so the ring must not jump over the antimeridian.
+func (r Ring) Contains(p Point) bool {
+	inside := false
+	for i := 1; i < len(r); i++ {
+		a, b := r[i-1], r[i]
+
+		// The point lies on the edge.
+		cross := (b[0]-a[0])*(p[1]-a[1]) - (b[1]-a[1])*(p[0]-a[0])
+		if cross == 0 &&
+			math.Min(a[0], b[0]) <= p[0] && p[0] <= math.Max(a[0], b[0]) &&
+			math.Min(a[1], b[1]) <= p[1] && p[1] <= math.Max(a[1], b[1]) {
+			return true
+		}
+
+		// The ray cast from the point to the east crosses the edge.
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < a[0]+(p[1]-a[1])*(b[0]-a[0])/(b[1]-a[1]) {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// Contains reports whether the point lies inside the polygon or on its exterior ring
+// and does not lie inside any of its holes.
+//
+// Polygons crossing the antimeridian are supported.
+func (p Polygon) Contains(point Point) bool {
+	if len(p) == 0 {
+		return false
+	}
+
+	unwrapped := p.Unwrap()
+	for _, shift := range []float64{0, 360, -360} {
+		shifted := Point{point[0] + shift, point[1]}
+		if !unwrapped[0].Contains(shifted) {
+			continue
+		}
+
+		inHole := false
+		for _, hole := range unwrapped[1:] {
+			if hole.Contains(shifted) {
+				inHole = true
+				break
+			}
+		}
+		if !inHole {
+			return true
+		}
+	}
+	return false
+}
+
+// PostgresPolygon is a postgresql representation of a Ring.
+type PostgresPolygon Ring
+
+// Value returns value in format that satisfies driver.Driver interface.
+func (p PostgresPolygon) Value() (driver.Value, error) {
+	points := make([]string, 0, len(p))
+	for _, point := range p {
+		points = append(points, fmt.Sprintf("(%v,%v)", point[0], point[1]))
+	}
+	return "(" + strings.Join(points, ",") + ")", nil
+}
@@ -0,0 +1,139 @@
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func TestPolygon_Validate(t *testing.T) {
+	square := geo.Ring{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}
+
+	testCases := []struct {
+		name    string
+		polygon geo.Polygon
+		isErr   bool
+	}{
+		{
+			name:    "OK",
+			polygon: geo.Polygon{square},
+		},
+		{
+			name:    "OK_Hole",
+			polygon: geo.Polygon{square, {{2, 2}, {4, 2}, {4, 4}, {2, 2}}},
+		},
+		{
+			name:    "NoRings",
+			polygon: geo.Polygon{},
+			isErr:   true,
+		},
+		{
+			name:    "RingTooShort",
+			polygon: geo.Polygon{{{0, 0}, {10, 0}, {0, 0}}},
+			isErr:   true,
+		},
+		{
+			name:    "RingNotClosed",
+			polygon: geo.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+			isErr:   true,
+		},
+		{
+			name:    "HoleNotClosed",
+			polygon: geo.Polygon{square, {{2, 2}, {4, 2}, {4, 4}, {2, 3}}},
+			isErr:   true,
+		},
+		{
+			name:    "InvalidCoordinates",
+			polygon: geo.Polygon{{{0, 0}, {10, 0}, {10, 91}, {0, 0}}},
+			isErr:   true,
+		},
+		{
+			name:    "TooManyPositions",
+			polygon: geo.Polygon{make(geo.Ring, geo.MaxPolygonLength+1)},
+			isErr:   true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.polygon.Validate()
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrInvalidPolygon)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPolygon_Unwrap(t *testing.T) {
+	testCases := []struct {
+		name     string
+		polygon  geo.Polygon
+		expected geo.Polygon
+	}{
+		{
+			name:     "DoesNotCrossAntimeridian",
+			polygon:  geo.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}},
+			expected: geo.Polygon{{{0, 0}, {10, 0}, {10, 10}, {0, 0}}},
+		},
+		{
+			name:     "CrossesAntimeridian",
+			polygon:  geo.Polygon{{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}, {170, 0}}},
+			expected: geo.Polygon{{{170, 0}, {190, 0}, {190, 10}, {170, 10}, {170, 0}}},
+		},
+		{
+			name: "HoleOnOtherSide",
+			polygon: geo.Polygon{
+				{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}, {170, 0}},
+				{{-175, 2}, {-172, 2}, {-172, 4}, {-175, 2}},
+			},
+			expected: geo.Polygon{
+				{{170, 0}, {190, 0}, {190, 10}, {170, 10}, {170, 0}},
+				{{185, 2}, {188, 2}, {188, 4}, {185, 2}},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.polygon.Unwrap())
+		})
+	}
+}
+
+func TestPolygon_Contains(t *testing.T) {
+	polygon := geo.Polygon{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{2, 2}, {4, 2}, {4, 4}, {2, 4}, {2, 2}},
+	}
+	require.True(t, polygon.Contains(geo.Point{5, 5}))
+	require.True(t, polygon.Contains(geo.Point{10, 5}))
+	require.True(t, polygon.Contains(geo.Point{0, 0}))
+	require.False(t, polygon.Contains(geo.Point{3, 3}))
+	require.False(t, polygon.Contains(geo.Point{10.1, 5}))
+	require.False(t, polygon.Contains(geo.Point{-5, -5}))
+
+	polygon = geo.Polygon{
+		{{170, 0}, {-170, 0}, {-170, 10}, {170, 10}, {170, 0}},
+		{{-175, 2}, {-172, 2}, {-172, 4}, {-175, 4}, {-175, 2}},
+	}
+	require.True(t, polygon.Contains(geo.Point{180, 5}))
+	require.True(t, polygon.Contains(geo.Point{-180, 5}))
+	require.True(t, polygon.Contains(geo.Point{175, 5}))
+	require.True(t, polygon.Contains(geo.Point{-171, 5}))
+	require.False(t, polygon.Contains(geo.Point{-173, 3}))
+	require.False(t, polygon.Contains(geo.Point{0, 5}))
+	require.False(t, polygon.Contains(geo.Point{175, 11}))
+
+	require.False(t, geo.Polygon{}.Contains(geo.Point{0, 0}))
+}
+
+func TestPostgresPolygon_Value(t *testing.T) {
+	value, err := geo.PostgresPolygon{{0, 0}, {10.5, 0}, {10, -10}, {0, 0}}.Value()
+	require.NoError(t, err)
+	require.Equal(t, "((0,0),(10.5,0),(10,-10),(0,0))", value)
+}
@@ -0,0 +1,134 @@
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ErrInvalidPolyline is returned in case an encoded polyline or its precision is invalid.
+var ErrInvalidPolyline = errors.New("invalid polyline")
+
+const (
+	// PolylinePrecision5 is the precision of the Google encoded polyline format, 5 decimal places.
+	PolylinePrecision5 = 5
+	// PolylinePrecision6 is the precision of the encoded polyline format with 6 decimal places used by OSRM and Valhalla.
+	PolylinePrecision6 = 6
+)
+
+// polylineFactor returns the factor coordinates are multiplied by with given precision.
+//
+// `ErrInvalidPolyline` is returned in case the precision is neither 5 nor 6.
+func polylineFactor(precision int) (float64, error) {
+	switch precision {
+	case PolylinePrecision5:
+		return 1e5, nil
+	case PolylinePrecision6:
+		return 1e6, nil
+	default:
+		return 0, fmt.Errorf("%w: unsupported precision %d", ErrInvalidPolyline, precision)
+	}
+}
+
+// EncodePolyline returns points encoded in the encoded polyline format with given precision.
+// Coordinates are rounded to the precision.
+//
+// `ErrInvalidPolyline` is returned in case the precision is neither 5 nor 6.
+func EncodePolyline(points []Point, precision int) (string, error) {
+	factor, err := polylineFactor(precision)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	var prevLat, prevLon int64
+	for _, p := range points {
+		lat := int64(math.Round(p.Latitude() * factor))
+		lon := int64(math.Round(p.Longitude() * factor))
+		writePolylineValue(&sb, lat-prevLat)
+		writePolylineValue(&sb, lon-prevLon)
+		prevLat, prevLon = lat, lon
+	}
+
+	return sb.String(), nil
+}
+
+// writePolylineValue writes a single signed value to sb in chunks of 5 bits.
+func writePolylineValue(sb *strings.Builder, v int64) {
+	u := uint64(v) << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		sb.WriteByte(byte(0x20|(u&0x1f)) + 63)
+		u >>= 5
+	}
+	sb.WriteByte(byte(u) + 63)
+}
+
+// DecodePolyline returns points decoded from the encoded polyline format with given precision.
+// An empty polyline has no points.
+//
+// `ErrInvalidPolyline` is returned in case the precision is neither 5 nor 6, the polyline is malformed
+// or contains invalid coordinates.
+func DecodePolyline(s string, precision int) ([]Point, error) {
+	factor, err := polylineFactor(precision)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []Point
+	var lat, lon int64
+	for i := 0; i < len(s); {
+		var dLat, dLon int64
+		if dLat, i, err = readPolylineValue(s, i); err != nil {
+			return nil, err
+		}
+		if dLon, i, err = readPolylineValue(s, i); err != nil {
+			return nil, err
+		}
+		lat += dLat
+		lon += dLon
+
+		p := Point{float64(lon) / factor, float64(lat) / factor}
+		if !ValidPoint(p) {
+			return nil, fmt.Errorf("%w: coordinates of point %d are out of range", ErrInvalidPolyline, len(points))
+		}
+		points = append(points, p)
+	}
+
+	return points, nil
+}
+
+// readPolylineValue reads a single signed value from s starting at i and returns it along with
+// the index of the next value.
+func readPolylineValue(s string, i int) (int64, int, error) {
+	var u uint64
+	var shift uint
+	for {
+		if i >= len(s) {
+			return 0, i, fmt.Errorf("%w: unexpected end", ErrInvalidPolyline)
+		}
+		c := s[i]
+		if c < 63 || c > 63+0x3f {
+			return 0, i, fmt.Errorf("%w: unexpected character %q", ErrInvalidPolyline, c)
+		}
+		if shift > 60 {
+			return 0, i, fmt.Errorf("%w: value is too long", ErrInvalidPolyline)
+		}
+		chunk := uint64(c - 63)
+		u |= (chunk & 0x1f) << shift
+		shift += 5
+		i++
+		if chunk < 0x20 {
+			break
+		}
+	}
+
+	v := int64(u >> 1)
+	if u&1 != 0 {
+		v = ^v
+	}
+	return v, i, nil
+}
@@ -0,0 +1,154 @@
+package geo_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+func TestEncodePolyline(t *testing.T) {
+	testCases := []struct {
+		name      string
+		points    []geo.Point
+		precision int
+		expected  string
+		isErr     bool
+	}{
+		{
+			// The example of the format documentation by Google.
+			name:      "OK",
+			points:    []geo.Point{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}},
+			precision: geo.PolylinePrecision5,
+			expected:  "_p~iF~ps|U_ulLnnqC_mqNvxq`@",
+		},
+		{
+			name:      "OK_Precision6",
+			points:    []geo.Point{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}},
+			precision: geo.PolylinePrecision6,
+			expected:  "_izlhA~rlgdF_{geC~ywl@_kwzCn`{nI",
+		},
+		{
+			name:      "OK_Empty",
+			points:    nil,
+			precision: geo.PolylinePrecision5,
+			expected:  "",
+		},
+		{
+			name:      "UnsupportedPrecision",
+			points:    []geo.Point{{-120.2, 38.5}},
+			precision: 7,
+			isErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			s, err := geo.EncodePolyline(tc.points, tc.precision)
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrInvalidPolyline)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, s)
+		})
+	}
+}
+
+func TestDecodePolyline(t *testing.T) {
+	testCases := []struct {
+		name      string
+		polyline  string
+		precision int
+		expected  []geo.Point
+		isErr     bool
+	}{
+		{
+			name:      "OK",
+			polyline:  "_p~iF~ps|U_ulLnnqC_mqNvxq`@",
+			precision: geo.PolylinePrecision5,
+			expected:  []geo.Point{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}},
+		},
+		{
+			name:      "OK_Precision6",
+			polyline:  "_izlhA~rlgdF_{geC~ywl@_kwzCn`{nI",
+			precision: geo.PolylinePrecision6,
+			expected:  []geo.Point{{-120.2, 38.5}, {-120.95, 40.7}, {-126.453, 43.252}},
+		},
+		{
+			name:      "OK_Empty",
+			polyline:  "",
+			precision: geo.PolylinePrecision5,
+		},
+		{
+			name:      "UnsupportedPrecision",
+			polyline:  "_p~iF~ps|U",
+			precision: 4,
+			isErr:     true,
+		},
+		{
+			name:      "UnexpectedEnd",
+			polyline:  "_p~iF~ps|",
+			precision: geo.PolylinePrecision5,
+			isErr:     true,
+		},
+		{
+			name:      "MissingLongitude",
+			polyline:  "_p~iF",
+			precision: geo.PolylinePrecision5,
+			isErr:     true,
+		},
+		{
+			name:      "UnexpectedCharacter",
+			polyline:  "_p~iF ps|U",
+			precision: geo.PolylinePrecision5,
+			isErr:     true,
+		},
+		{
+			// A precision 6 polyline decoded with precision 5 has coordinates out of range.
+			name:      "OutOfRange",
+			polyline:  "_izlhA~rlgdF",
+			precision: geo.PolylinePrecision5,
+			isErr:     true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			points, err := geo.DecodePolyline(tc.polyline, tc.precision)
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrInvalidPolyline)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, points, len(tc.expected))
+			for i := range tc.expected {
+				require.InDeltaSlice(t, tc.expected[i][:], points[i][:], 1e-9)
+			}
+		})
+	}
+}
+
+func TestPolyline_RoundTrip(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for _, precision := range []int{geo.PolylinePrecision5, geo.PolylinePrecision6} {
+		points := make([]geo.Point, 100)
+		for i := range points {
+			points[i] = geo.Point{rnd.Float64()*360 - 180, rnd.Float64()*180 - 90}
+		}
+
+		s, err := geo.EncodePolyline(points, precision)
+		require.NoError(t, err)
+
+		decoded, err := geo.DecodePolyline(s, precision)
+		require.NoError(t, err)
+		require.Len(t, decoded, len(points))
+		for i := range points {
+			require.InDeltaSlice(t, points[i][:], decoded[i][:], 0.5/math.Pow10(precision)+1e-12)
+		}
+	}
+}
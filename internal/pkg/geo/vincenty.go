@@ -0,0 +1,161 @@
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// ErrNoConvergence is returned in case an iterative formula fails to converge,
+// which happens to Vincenty's inverse formula for nearly antipodal points.
+var ErrNoConvergence = errors.New("formula failed to converge")
+
+const (
+	// WGS84SemiMajorAxis is the equatorial radius of the WGS84 ellipsoid in meters.
+	WGS84SemiMajorAxis = 6378137.0
+	// WGS84Flattening is the flattening of the WGS84 ellipsoid.
+	WGS84Flattening = 1 / 298.257223563
+	// WGS84SemiMinorAxis is the polar radius of the WGS84 ellipsoid in meters.
+	WGS84SemiMinorAxis = WGS84SemiMajorAxis * (1 - WGS84Flattening)
+)
+
+const (
+	// vincentyMaxIterations is a number of iterations after which Vincenty's formulae give up.
+	vincentyMaxIterations = 200
+	// vincentyPrecision is a change of an angle in radians at which Vincenty's formulae stop iterating,
+	// it corresponds to less than a millimeter.
+	vincentyPrecision = 1e-12
+)
+
+// Geodesic is the shortest path between two points on the WGS84 ellipsoid.
+//
+// `Distance` is in meters, `InitialBearing` and `FinalBearing` are in degrees clockwise from the north
+// in [0, 360) range.
+type Geodesic struct {
+	Distance       float64
+	InitialBearing float64
+	FinalBearing   float64
+}
+
+// vincentyCoefficients returns A and B coefficients of Vincenty's formulae for given cos²α.
+func vincentyCoefficients(cosSqAlpha float64) (float64, float64) {
+	uSq := cosSqAlpha * (WGS84SemiMajorAxis*WGS84SemiMajorAxis - WGS84SemiMinorAxis*WGS84SemiMinorAxis) /
+		(WGS84SemiMinorAxis * WGS84SemiMinorAxis)
+	a := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	b := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	return a, b
+}
+
+// vincentyDeltaSigma returns Δσ of Vincenty's formulae.
+func vincentyDeltaSigma(b, sinSigma, cosSigma, cos2SigmaM float64) float64 {
+	return b * sinSigma * (cos2SigmaM + b/4*(cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)-
+		b/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*cos2SigmaM*cos2SigmaM)))
+}
+
+// VincentyInverse returns the geodesic between a and b on the WGS84 ellipsoid calculated with Vincenty's inverse formula.
+// It is accurate to within a millimeter. Bearings equal 0 if the points coincide.
+//
+// `ErrNoConvergence` is returned in case the formula fails to converge for nearly antipodal points,
+// `Distance` may be used as a fallback in that case.
+func VincentyInverse(a, b Point) (Geodesic, error) {
+	const f = WGS84Flattening
+
+	l := radians(b.Longitude() - a.Longitude())
+	u1 := math.Atan((1 - f) * math.Tan(radians(a.Latitude())))
+	u2 := math.Atan((1 - f) * math.Tan(radians(b.Latitude())))
+	sinU1, cosU1 := math.Sincos(u1)
+	sinU2, cosU2 := math.Sincos(u2)
+
+	lambda := l
+	var sinLambda, cosLambda, sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	converged := false
+	for i := 0; i < vincentyMaxIterations; i++ {
+		sinLambda, cosLambda = math.Sincos(lambda)
+		sinSigma = math.Hypot(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda)
+		if sinSigma == 0 {
+			// The points coincide.
+			return Geodesic{}, nil
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0 // The geodesic lies along the equator.
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		c := f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+
+		prev := lambda
+		lambda = l + (1-c)*f*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda) > math.Pi+math.Abs(l) {
+			break
+		}
+		if math.Abs(lambda-prev) < vincentyPrecision {
+			converged = true
+			break
+		}
+	}
+	if !converged {
+		return Geodesic{}, ErrNoConvergence
+	}
+
+	coefA, coefB := vincentyCoefficients(cosSqAlpha)
+	deltaSigma := vincentyDeltaSigma(coefB, sinSigma, cosSigma, cos2SigmaM)
+
+	return Geodesic{
+		Distance:       WGS84SemiMinorAxis * coefA * (sigma - deltaSigma),
+		InitialBearing: normalizeBearing(degrees(math.Atan2(cosU2*sinLambda, cosU1*sinU2-sinU1*cosU2*cosLambda))),
+		FinalBearing:   normalizeBearing(degrees(math.Atan2(cosU1*sinLambda, -sinU1*cosU2+cosU1*sinU2*cosLambda))),
+	}, nil
+}
+
+// VincentyDistance returns the distance in meters between a and b on the WGS84 ellipsoid.
+//
+// `ErrNoConvergence` is returned in case the formula fails to converge for nearly antipodal points.
+func VincentyDistance(a, b Point) (float64, error) {
+	g, err := VincentyInverse(a, b)
+	if err != nil {
+		return 0, err
+	}
+	return g.Distance, nil
+}
+
+// VincentyDirect returns the point reached by following the geodesic from p on the WGS84 ellipsoid
+// with given initial bearing in degrees for given distance in meters, and the final bearing at that point.
+// It is calculated with Vincenty's direct formula, which converges for any input.
+func VincentyDirect(p Point, bearing, distance float64) (Point, float64) {
+	const f = WGS84Flattening
+
+	sinAlpha1, cosAlpha1 := math.Sincos(radians(bearing))
+	tanU1 := (1 - f) * math.Tan(radians(p.Latitude()))
+	cosU1 := 1 / math.Sqrt(1+tanU1*tanU1)
+	sinU1 := tanU1 * cosU1
+
+	sigma1 := math.Atan2(tanU1, cosAlpha1)
+	sinAlpha := cosU1 * sinAlpha1
+	cosSqAlpha := 1 - sinAlpha*sinAlpha
+	coefA, coefB := vincentyCoefficients(cosSqAlpha)
+
+	sigma := distance / (WGS84SemiMinorAxis * coefA)
+	var sinSigma, cosSigma, cos2SigmaM float64
+	for i := 0; i < vincentyMaxIterations; i++ {
+		cos2SigmaM = math.Cos(2*sigma1 + sigma)
+		sinSigma, cosSigma = math.Sincos(sigma)
+		prev := sigma
+		sigma = distance/(WGS84SemiMinorAxis*coefA) + vincentyDeltaSigma(coefB, sinSigma, cosSigma, cos2SigmaM)
+		if math.Abs(sigma-prev) < vincentyPrecision {
+			break
+		}
+	}
+	cos2SigmaM = math.Cos(2*sigma1 + sigma)
+	sinSigma, cosSigma = math.Sincos(sigma)
+
+	x := sinU1*sinSigma - cosU1*cosSigma*cosAlpha1
+	lat := math.Atan2(sinU1*cosSigma+cosU1*sinSigma*cosAlpha1, (1-f)*math.Hypot(sinAlpha, x))
+	lambda := math.Atan2(sinSigma*sinAlpha1, cosU1*cosSigma-sinU1*sinSigma*cosAlpha1)
+	c := f / 16 * cosSqAlpha * (4 + f*(4-3*cosSqAlpha))
+	l := lambda - (1-c)*f*sinAlpha*(sigma+c*sinSigma*(cos2SigmaM+c*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+
+	dest := Point{normalizeLongitude(p.Longitude() + degrees(l)), degrees(lat)}
+	return dest, normalizeBearing(degrees(math.Atan2(sinAlpha, -x)))
+}
@@ -0,0 +1,107 @@
+package geo_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
+)
+
+// Flinders Peak and Buninyong, the worked example of Vincenty's formulae
+// published by Geoscience Australia.
+var (
+	flindersPeak = geo.Point{144.424867889, -37.951033417}
+	buninyong    = geo.Point{143.926495528, -37.652821139}
+)
+
+func TestVincentyInverse(t *testing.T) {
+	testCases := []struct {
+		name     string
+		a        geo.Point
+		b        geo.Point
+		expected geo.Geodesic
+		isErr    bool
+	}{
+		{
+			name: "FlindersPeakToBuninyong",
+			a:    flindersPeak,
+			b:    buninyong,
+			expected: geo.Geodesic{
+				Distance:       54972.271,
+				InitialBearing: 306.868158,
+				FinalBearing:   307.173631,
+			},
+		},
+		{
+			name: "OneDegreeOfEquator",
+			a:    geo.Point{0, 0},
+			b:    geo.Point{1, 0},
+			expected: geo.Geodesic{
+				Distance:       111319.491,
+				InitialBearing: 90,
+				FinalBearing:   90,
+			},
+		},
+		{
+			name: "MeridianQuadrant",
+			a:    geo.Point{0, 0},
+			b:    geo.Point{0, 90},
+			expected: geo.Geodesic{
+				Distance: 10001965.729,
+			},
+		},
+		{
+			name: "CrossesAntimeridian",
+			a:    geo.Point{179.5, 0},
+			b:    geo.Point{-179.5, 0},
+			expected: geo.Geodesic{
+				Distance:       111319.491,
+				InitialBearing: 90,
+				FinalBearing:   90,
+			},
+		},
+		{
+			name:     "SamePoint",
+			a:        flindersPeak,
+			b:        flindersPeak,
+			expected: geo.Geodesic{},
+		},
+		{
+			name:  "NearlyAntipodal",
+			a:     geo.Point{0, 0},
+			b:     geo.Point{179.7, 0.5},
+			isErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			g, err := geo.VincentyInverse(tc.a, tc.b)
+			if tc.isErr {
+				require.ErrorIs(t, err, geo.ErrNoConvergence)
+				return
+			}
+			require.NoError(t, err)
+			require.InDelta(t, tc.expected.Distance, g.Distance, 1e-3)
+			require.InDelta(t, tc.expected.InitialBearing, g.InitialBearing, 1e-5)
+			require.InDelta(t, tc.expected.FinalBearing, g.FinalBearing, 1e-5)
+
+			distance, err := geo.VincentyDistance(tc.b, tc.a)
+			require.NoError(t, err)
+			require.InDelta(t, tc.expected.Distance, distance, 1e-3)
+		})
+	}
+}
+
+func TestVincentyDirect(t *testing.T) {
+	dest, finalBearing := geo.VincentyDirect(flindersPeak, 306.868158, 54972.271)
+	require.InDelta(t, buninyong.Longitude(), dest.Longitude(), 1e-6)
+	require.InDelta(t, buninyong.Latitude(), dest.Latitude(), 1e-6)
+	require.InDelta(t, 307.173631, finalBearing, 1e-5)
+
+	dest, finalBearing = geo.VincentyDirect(geo.Point{179.5, 0}, 90, 111319.491)
+	require.InDelta(t, -179.5, dest.Longitude(), 1e-6)
+	require.InDelta(t, 0, dest.Latitude(), 1e-6)
+	require.InDelta(t, 90, finalBearing, 1e-5)
+}
@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	apiKeyHeader = "X-API-Key"
+
+	authorizationMetadataKey = "authorization"
+	apiKeyMetadataKey        = "x-api-key"
+
+	bearerPrefix = "Bearer "
+)
+
+// AuthMiddleware authenticates incoming requests by a bearer token from the Authorization header
+// or an API key from the X-API-Key header and adds the authenticated principal to context of the request.
+//
+// Requests that fail authentication are responded with 401. CORS preflight requests are passed as they are.
+func AuthMiddleware(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			principal, err := authenticator.Authenticate(r.Context(), auth.Credentials{
+				BearerToken: bearerToken(r.Header.Get("Authorization")),
+				APIKey:      r.Header.Get(apiKeyHeader),
+			})
+			if err != nil {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				status, body := errpack.ErrToHTTP(err)
+				util.Respond(w, status, body)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.AddPrincipalToCtx(r.Context(), principal)))
+		})
+	}
+}
+
+// AuthUnaryServerInterceptor authenticates incoming requests by a bearer token from the authorization metadata
+// or an API key from the x-api-key metadata and adds the authenticated principal to context of the request.
+func AuthUnaryServerInterceptor(authenticator auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		authCtx, err := authenticateGRPC(ctx, authenticator)
+		if err != nil {
+			return nil, errpack.ErrToGRPC(err)
+		}
+
+		return handler(authCtx, req)
+	}
+}
+
+// AuthStreamServerInterceptor authenticates incoming streams the same way AuthUnaryServerInterceptor does
+// and adds the authenticated principal to context of the stream.
+func AuthStreamServerInterceptor(authenticator auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		authCtx, err := authenticateGRPC(ss.Context(), authenticator)
+		if err != nil {
+			return errpack.ErrToGRPC(err)
+		}
+
+		return handler(srv, &contextServerStream{
+			ServerStream: ss,
+			ctx:          authCtx,
+		})
+	}
+}
+
+// APIKeyUnaryClientInterceptor adds the API key to outgoing metadata of requests.
+// Requests are sent as they are if the API key is empty.
+func APIKeyUnaryClientInterceptor(apiKey string) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req interface{},
+		reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		if apiKey != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, apiKeyMetadataKey, apiKey)
+		}
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+// authenticateGRPC authenticates credentials from incoming metadata and returns ctx with the authenticated principal.
+func authenticateGRPC(ctx context.Context, authenticator auth.Authenticator) (context.Context, error) {
+	var creds auth.Credentials
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(authorizationMetadataKey); len(values) > 0 {
+			creds.BearerToken = bearerToken(values[0])
+		}
+		if values := md.Get(apiKeyMetadataKey); len(values) > 0 {
+			creds.APIKey = values[0]
+		}
+	}
+
+	principal, err := authenticator.Authenticate(ctx, creds)
+	if err != nil {
+		return nil, err
+	}
+
+	return auth.AddPrincipalToCtx(ctx, principal), nil
+}
+
+// bearerToken returns the token of a bearer authorization value or an empty string if the scheme is not bearer.
+func bearerToken(authorization string) string {
+	if len(authorization) < len(bearerPrefix) || !strings.EqualFold(authorization[:len(bearerPrefix)], bearerPrefix) {
+		return ""
+	}
+	return strings.TrimSpace(authorization[len(bearerPrefix):])
+}
@@ -0,0 +1,191 @@
+package middleware_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/middleware"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util/testutil"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestAuthenticator(t *testing.T) auth.Authenticator {
+	authenticator, err := auth.New(auth.Config{
+		HS256Secret: string(testSecret),
+		APIKeys:     []string{"history:history-key"},
+	})
+	require.NoError(t, err)
+	return authenticator
+}
+
+func newTestToken(t *testing.T) string {
+	return testutil.SignJWT(t, testSecret, map[string]interface{}{
+		"sub": "alice",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	token := newTestToken(t)
+
+	testCases := []struct {
+		name              string
+		method            string
+		headers           map[string]string
+		expectedStatus    int
+		expectedPrincipal auth.Principal
+	}{
+		{
+			name:              "OK_Bearer",
+			method:            http.MethodGet,
+			headers:           map[string]string{"Authorization": "Bearer " + token},
+			expectedStatus:    http.StatusOK,
+			expectedPrincipal: auth.Principal{Subject: "alice", Method: auth.MethodJWT, Roles: []auth.Role{auth.RoleUser}},
+		},
+		{
+			name:              "OK_APIKey",
+			method:            http.MethodGet,
+			headers:           map[string]string{"X-API-Key": "history-key"},
+			expectedStatus:    http.StatusOK,
+			expectedPrincipal: auth.Principal{Subject: "history", Method: auth.MethodAPIKey, Roles: []auth.Role{auth.RoleService}},
+		},
+		{
+			name:           "OK_Preflight",
+			method:         http.MethodOptions,
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "NoCredentials",
+			method:         http.MethodGet,
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "BasicScheme",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Basic " + token},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "InvalidToken",
+			method:         http.MethodGet,
+			headers:        map[string]string{"Authorization": "Bearer " + token + "x"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+		{
+			name:           "InvalidAPIKey",
+			method:         http.MethodGet,
+			headers:        map[string]string{"X-API-Key": "unknown-key"},
+			expectedStatus: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var principal auth.Principal
+			handler := middleware.AuthMiddleware(newTestAuthenticator(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				principal, _ = auth.GetPrincipalFromCtx(r.Context())
+			}))
+
+			req := httptest.NewRequest(tc.method, "/v1/users", nil)
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+
+			handler.ServeHTTP(rec, req)
+
+			require.Equal(t, tc.expectedStatus, rec.Code)
+			require.Equal(t, tc.expectedPrincipal, principal)
+			if tc.expectedStatus == http.StatusUnauthorized {
+				require.Equal(t, "Bearer", rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAuthUnaryServerInterceptor(t *testing.T) {
+	interceptor := middleware.AuthUnaryServerInterceptor(newTestAuthenticator(t))
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		principal, _ := auth.GetPrincipalFromCtx(ctx)
+		return principal, nil
+	}
+
+	res, err := interceptor(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+newTestToken(t))),
+		nil,
+		&grpc.UnaryServerInfo{},
+		handler,
+	)
+	require.NoError(t, err)
+	require.Equal(t, auth.Principal{Subject: "alice", Method: auth.MethodJWT, Roles: []auth.Role{auth.RoleUser}}, res)
+
+	res, err = interceptor(
+		metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "history-key")),
+		nil,
+		&grpc.UnaryServerInfo{},
+		handler,
+	)
+	require.NoError(t, err)
+	require.Equal(t, auth.Principal{Subject: "history", Method: auth.MethodAPIKey, Roles: []auth.Role{auth.RoleService}}, res)
+
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *testServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestAuthStreamServerInterceptor(t *testing.T) {
+	interceptor := middleware.AuthStreamServerInterceptor(newTestAuthenticator(t))
+
+	var principal auth.Principal
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		principal, _ = auth.GetPrincipalFromCtx(ss.Context())
+		return nil
+	}
+
+	err := interceptor(nil, &testServerStream{
+		ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "history-key")),
+	}, &grpc.StreamServerInfo{}, handler)
+	require.NoError(t, err)
+	require.Equal(t, auth.Principal{Subject: "history", Method: auth.MethodAPIKey, Roles: []auth.Role{auth.RoleService}}, principal)
+
+	err = interceptor(nil, &testServerStream{
+		ctx: metadata.NewIncomingContext(context.Background(), metadata.Pairs("x-api-key", "unknown-key")),
+	}, &grpc.StreamServerInfo{}, handler)
+	require.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestAPIKeyUnaryClientInterceptor(t *testing.T) {
+	var md metadata.MD
+	invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		md, _ = metadata.FromOutgoingContext(ctx)
+		return nil
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "trace-id", "trace")
+	err := middleware.APIKeyUnaryClientInterceptor("history-key")(ctx, "/method", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	require.Equal(t, []string{"history-key"}, md.Get("x-api-key"))
+	require.Equal(t, []string{"trace"}, md.Get("trace-id"))
+
+	err = middleware.APIKeyUnaryClientInterceptor("")(context.Background(), "/method", nil, nil, nil, invoker)
+	require.NoError(t, err)
+	require.Empty(t, md.Get("x-api-key"))
+}
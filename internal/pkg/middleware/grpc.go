@@ -73,6 +73,70 @@ func TracingUnaryServerInterceptor(logger log.Logger) func(
 	}
 }
 
+// LoggerStreamServerInterceptor logs completed incoming streaming requests.
+func LoggerStreamServerInterceptor(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		start := time.Now()
+		fullMethod := info.FullMethod
+
+		traceID, ok := util.GetTraceIDFromCtx(ss.Context())
+		if !ok {
+			logger.Warn("trace id is not set", log.Fields{
+				"method": fullMethod,
+			})
+		}
+
+		err := handler(srv, ss)
+
+		st, _ := status.FromError(err)
+
+		logger.Info("incoming grpc stream complete", log.Fields{
+			"method":   fullMethod,
+			"duration": time.Since(start),
+			"code":     st.Code(),
+			"trace-id": traceID,
+		})
+
+		return err
+	}
+}
+
+// contextServerStream overrides context of the wrapped stream.
+type contextServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the overridden context of the stream.
+func (s *contextServerStream) Context() context.Context {
+	return s.ctx
+}
+
+// TracingStreamServerInterceptor adds trace id from incoming metadata or a new one to context of the stream.
+func TracingStreamServerInterceptor(logger log.Logger) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		traceID, ok := util.GetTraceIDFromMetadata(ss.Context())
+		if !ok {
+			traceID = util.GenerateTraceID()
+		}
+
+		return handler(srv, &contextServerStream{
+			ServerStream: ss,
+			ctx:          util.AddTraceIDToCtx(ss.Context(), traceID),
+		})
+	}
+}
+
 // LoggerUnaryClientInterceptor TODO: description
 func LoggerUnaryClientInterceptor(logger log.Logger) func(
 	ctx context.Context,
@@ -30,6 +30,13 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode
 }
 
+// Flush sends buffered data to the client, so that streaming responses work through the middleware.
+func (r *loggingResponseWriter) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
 // LoggerMiddleware TODO: description
 func LoggerMiddleware(logger log.Logger) func(handler http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -0,0 +1,302 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/errpack"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/ratelimit"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	retryAfterHeader      = "Retry-After"
+	retryAfterMetadataKey = "retry-after"
+
+	forwardedForHeader      = "X-Forwarded-For"
+	forwardedForMetadataKey = "x-forwarded-for"
+
+	// overloadRetryAfter is how long clients of overloaded servers are asked to wait before retrying.
+	overloadRetryAfter = time.Second
+)
+
+var (
+	errRateLimitExceeded = fmt.Errorf("%w: rate limit exceeded", errpack.ErrResourceExhausted)
+	errServerOverloaded  = fmt.Errorf("%w: too many requests in flight", errpack.ErrResourceExhausted)
+)
+
+// ClientIPResolver resolves IPs of clients of requests, including requests forwarded by trusted proxies.
+type ClientIPResolver struct {
+	trustedProxies []*net.IPNet
+}
+
+// NewClientIPResolver returns a pointer to new instance of ClientIPResolver that trusts X-Forwarded-For
+// of requests sent by proxies from the trustedProxies CIDRs, for example "10.0.0.0/8".
+// Bare IPs are accepted as single address CIDRs.
+func NewClientIPResolver(trustedProxies []string) (*ClientIPResolver, error) {
+	r := &ClientIPResolver{}
+	for _, s := range trustedProxies {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			if ip := net.ParseIP(s); ip != nil && ip.To4() != nil {
+				s += "/32"
+			} else {
+				s += "/128"
+			}
+		}
+
+		_, network, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("trusted proxy %q is not a valid CIDR: %v", s, err)
+		}
+		r.trustedProxies = append(r.trustedProxies, network)
+	}
+
+	return r, nil
+}
+
+// isTrusted reports whether ip belongs to a trusted proxy.
+func (r *ClientIPResolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve returns the IP of the client that sent a request from addr with forwardedFor values of X-Forwarded-For.
+//
+// Forwarded addresses are taken into account only if addr is a trusted proxy. They are walked from the right,
+// since proxies append addresses they receive requests from, and the first address that is not a trusted proxy
+// is the client. Addresses to the left of it are set by the client and may be forged.
+func (r *ClientIPResolver) resolve(addr string, forwardedFor []string) string {
+	client := addr
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		client = host
+	}
+
+	ip := net.ParseIP(client)
+	if ip == nil || !r.isTrusted(ip) {
+		return client
+	}
+
+	var forwarded []string
+	for _, value := range forwardedFor {
+		forwarded = append(forwarded, strings.Split(value, ",")...)
+	}
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		ip = net.ParseIP(strings.TrimSpace(forwarded[i]))
+		if ip == nil {
+			break
+		}
+		client = ip.String()
+		if !r.isTrusted(ip) {
+			break
+		}
+	}
+
+	return client
+}
+
+// FromRequest returns the IP of the client of the HTTP request.
+func (r *ClientIPResolver) FromRequest(req *http.Request) string {
+	return r.resolve(req.RemoteAddr, req.Header.Values(forwardedForHeader))
+}
+
+// FromContext returns the IP of the client of the gRPC request with ctx or an empty string if the peer is unknown.
+func (r *ClientIPResolver) FromContext(ctx context.Context) string {
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	return r.resolve(addr, md.Get(forwardedForMetadataKey))
+}
+
+// RateLimitMiddleware limits rates of requests with the limiter.
+// Routes are "METHOD /path" strings, for example "PUT /v1/users/alice/location".
+//
+// Requests are keyed by the authenticated principal, anonymous requests are keyed by the client IP resolved with ips.
+// Requests of principals to routes of rules capturing a "{username}" parameter are keyed by the username as well.
+// Requests over the limit are responded with 429 and the Retry-After header.
+func RateLimitMiddleware(limiter *ratelimit.Limiter, ips *ClientIPResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ok, retryAfter := limiter.Allow(r.Method+" "+r.URL.Path, func(params map[string]string) string {
+				return rateLimitKey(r.Context(), ips.FromRequest(r), params)
+			})
+			if !ok {
+				w.Header().Set(retryAfterHeader, retryAfterSeconds(retryAfter))
+				status, body := errpack.ErrToHTTP(errRateLimitExceeded)
+				util.Respond(w, status, body)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RateLimitUnaryServerInterceptor limits rates of requests with the limiter. Routes are full method names.
+//
+// Requests are keyed by the authenticated principal, anonymous requests are keyed by the client IP resolved with ips.
+// Requests over the limit fail with `codes.ResourceExhausted`, the retry-after metadata and `errdetails.RetryInfo`.
+func RateLimitUnaryServerInterceptor(limiter *ratelimit.Limiter, ips *ClientIPResolver) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		ok, retryAfter := limiter.Allow(info.FullMethod, func(params map[string]string) string {
+			return rateLimitKey(ctx, ips.FromContext(ctx), params)
+		})
+		if !ok {
+			return nil, resourceExhaustedGRPCError(ctx, errRateLimitExceeded, retryAfter)
+		}
+
+		return handler(ctx, req)
+	}
+}
+
+// RateLimitStreamServerInterceptor limits rates of opening streams the same way RateLimitUnaryServerInterceptor does.
+// Messages of streams are not limited.
+func RateLimitStreamServerInterceptor(limiter *ratelimit.Limiter, ips *ClientIPResolver) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		ctx := ss.Context()
+		ok, retryAfter := limiter.Allow(info.FullMethod, func(params map[string]string) string {
+			return rateLimitKey(ctx, ips.FromContext(ctx), params)
+		})
+		if !ok {
+			return resourceExhaustedGRPCError(ctx, errRateLimitExceeded, retryAfter)
+		}
+
+		return handler(srv, ss)
+	}
+}
+
+// ConcurrencyLimitMiddleware sheds load by responding with 429 when the limiter has no free slots.
+// Requests to exemptPaths, such as long-lived streams of server-sent events, are neither limited nor counted.
+func ConcurrencyLimitMiddleware(limiter *ratelimit.ConcurrencyLimiter, exemptPaths ...string) func(http.Handler) http.Handler {
+	exempt := make(map[string]bool, len(exemptPaths))
+	for _, path := range exemptPaths {
+		exempt[path] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if exempt[r.URL.Path] {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !limiter.Acquire() {
+				w.Header().Set(retryAfterHeader, retryAfterSeconds(overloadRetryAfter))
+				status, body := errpack.ErrToHTTP(errServerOverloaded)
+				util.Respond(w, status, body)
+				return
+			}
+			defer limiter.Release()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ConcurrencyLimitUnaryServerInterceptor sheds load by failing requests with `codes.ResourceExhausted`,
+// the retry-after metadata and `errdetails.RetryInfo` when the limiter has no free slots.
+func ConcurrencyLimitUnaryServerInterceptor(limiter *ratelimit.ConcurrencyLimiter) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error) {
+		if !limiter.Acquire() {
+			return nil, resourceExhaustedGRPCError(ctx, errServerOverloaded, overloadRetryAfter)
+		}
+		defer limiter.Release()
+
+		return handler(ctx, req)
+	}
+}
+
+// ConcurrencyLimitStreamServerInterceptor sheds load of client-streaming requests, such as batch writes,
+// the same way ConcurrencyLimitUnaryServerInterceptor does. Server-streaming requests are long-lived,
+// so they are neither limited nor counted.
+func ConcurrencyLimitStreamServerInterceptor(limiter *ratelimit.ConcurrencyLimiter) grpc.StreamServerInterceptor {
+	return func(
+		srv interface{},
+		ss grpc.ServerStream,
+		info *grpc.StreamServerInfo,
+		handler grpc.StreamHandler,
+	) error {
+		if !info.IsClientStream || info.IsServerStream {
+			return handler(srv, ss)
+		}
+
+		if !limiter.Acquire() {
+			return resourceExhaustedGRPCError(ss.Context(), errServerOverloaded, overloadRetryAfter)
+		}
+		defer limiter.Release()
+
+		return handler(srv, ss)
+	}
+}
+
+// rateLimitKey returns the key of the principal from ctx or the key of the client IP if the request is anonymous.
+//
+// Keys of principals are scoped by the username the matching rule captured from the route, if any,
+// so that a principal acting on behalf of many users, like a backend service, gets a bucket per user.
+// Anonymous requests are not keyed by usernames they carry, since clients may choose any of them.
+func rateLimitKey(ctx context.Context, clientIP string, params map[string]string) string {
+	principal, ok := auth.GetPrincipalFromCtx(ctx)
+	if !ok {
+		return "ip:" + clientIP
+	}
+	if username, ok := params["username"]; ok {
+		return "principal:" + principal.Subject + "/user:" + username
+	}
+	return "principal:" + principal.Subject
+}
+
+// retryAfterSeconds returns the delay rounded up to whole seconds, as clients may not retry sooner.
+func retryAfterSeconds(retryAfter time.Duration) string {
+	return strconv.Itoa(int(math.Max(1, math.Ceil(retryAfter.Seconds()))))
+}
+
+// resourceExhaustedGRPCError sets the retry-after header of the response and returns the status error of err
+// with the retry delay.
+func resourceExhaustedGRPCError(ctx context.Context, err error, retryAfter time.Duration) error {
+	// The header is not set for callers outside of gRPC servers, like tests, so the error is ignored.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(retryAfterMetadataKey, retryAfterSeconds(retryAfter)))
+
+	st := status.Convert(errpack.ErrToGRPC(err))
+	if detailed, err := st.WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(retryAfter)}); err == nil {
+		st = detailed
+	}
+	return st.Err()
+}
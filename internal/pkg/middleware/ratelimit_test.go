@@ -0,0 +1,245 @@
+package middleware_test
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/auth"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/middleware"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/ratelimit"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+func newTestLimiter(t *testing.T) *ratelimit.Limiter {
+	limiter, err := ratelimit.New(ratelimit.Config{
+		Default: "1:1",
+		Routes: []string{
+			"PUT /v1/users/{username}/location=1:2",
+			"/location.Location/SetUserLocation=1:2",
+		},
+	})
+	require.NoError(t, err)
+	return limiter
+}
+
+func newTestClientIPResolver(t *testing.T) *middleware.ClientIPResolver {
+	ips, err := middleware.NewClientIPResolver([]string{"172.16.0.0/12", "10.0.0.100"})
+	require.NoError(t, err)
+	return ips
+}
+
+func TestClientIPResolver(t *testing.T) {
+	ips := newTestClientIPResolver(t)
+	fromRequest := func(remoteAddr string, forwardedFor ...string) string {
+		req := httptest.NewRequest(http.MethodGet, "/v1/users", nil)
+		req.RemoteAddr = remoteAddr
+		for _, value := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", value)
+		}
+		return ips.FromRequest(req)
+	}
+
+	// X-Forwarded-For of untrusted clients is ignored.
+	require.Equal(t, "10.0.0.1", fromRequest("10.0.0.1:1000", "1.1.1.1"))
+	// The rightmost address that is not a trusted proxy is the client.
+	require.Equal(t, "2.2.2.2", fromRequest("172.17.0.2:1000", "1.1.1.1, 2.2.2.2"))
+	require.Equal(t, "2.2.2.2", fromRequest("172.17.0.2:1000", "1.1.1.1", "2.2.2.2, 10.0.0.100"))
+	// Requests of trusted proxies without forwarded addresses are keyed by the proxy.
+	require.Equal(t, "172.17.0.2", fromRequest("172.17.0.2:1000"))
+
+	_, err := middleware.NewClientIPResolver([]string{"not a cidr"})
+	require.Error(t, err)
+
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(172, 17, 0, 2), Port: 1000}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-forwarded-for", "3.3.3.3"))
+	require.Equal(t, "3.3.3.3", ips.FromContext(ctx))
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	handler := middleware.RateLimitMiddleware(newTestLimiter(t), newTestClientIPResolver(t))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	serve := func(method, target, remoteAddr, forwardedFor string, principal *auth.Principal) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.RemoteAddr = remoteAddr
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		if principal != nil {
+			req = req.WithContext(auth.AddPrincipalToCtx(req.Context(), *principal))
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Anonymous location updates are keyed by the client IP, whatever usernames they are sent for.
+	require.Equal(t, http.StatusOK, serve(http.MethodPut, "/v1/users/alice/location", "10.0.0.1:1000", "", nil).Code)
+	require.Equal(t, http.StatusOK, serve(http.MethodPut, "/v1/users/bob/location", "10.0.0.1:1000", "", nil).Code)
+	rec := serve(http.MethodPut, "/v1/users/carol/location", "10.0.0.1:2000", "", nil)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+	require.JSONEq(t, `{"error":{"code":429,"message":"resource exhausted: rate limit exceeded","status":"RESOURCE_EXHAUSTED"}}`, rec.Body.String())
+	// Other clients do not share the bucket, even if they update the same user.
+	require.Equal(t, http.StatusOK, serve(http.MethodPut, "/v1/users/alice/location", "10.0.0.2:1000", "", nil).Code)
+
+	// Clients behind the trusted proxy are keyed by their forwarded addresses.
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/v1/users", "172.17.0.2:1000", "1.1.1.1", nil).Code)
+	require.Equal(t, http.StatusTooManyRequests, serve(http.MethodGet, "/v1/geofences", "172.17.0.2:2000", "1.1.1.1", nil).Code)
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/v1/users", "172.17.0.2:1000", "2.2.2.2", nil).Code)
+
+	// The principal takes precedence over the client IP.
+	principal := &auth.Principal{Subject: "carol"}
+	require.Equal(t, http.StatusOK, serve(http.MethodGet, "/v1/users", "10.0.0.3:1000", "", principal).Code)
+	require.Equal(t, http.StatusTooManyRequests, serve(http.MethodGet, "/v1/users", "10.0.0.4:1000", "", principal).Code)
+
+	// Principals are keyed by usernames captured from routes as well.
+	service := &auth.Principal{Subject: "service"}
+	require.Equal(t, http.StatusOK, serve(http.MethodPut, "/v1/users/alice/location", "10.0.0.5:1000", "", service).Code)
+	require.Equal(t, http.StatusOK, serve(http.MethodPut, "/v1/users/alice/location", "10.0.0.5:1000", "", service).Code)
+	require.Equal(t, http.StatusTooManyRequests, serve(http.MethodPut, "/v1/users/alice/location", "10.0.0.5:1000", "", service).Code)
+	require.Equal(t, http.StatusOK, serve(http.MethodPut, "/v1/users/bob/location", "10.0.0.5:1000", "", service).Code)
+	// Buckets of the same user are not shared between principals.
+	require.Equal(t, http.StatusOK, serve(http.MethodPut, "/v1/users/alice/location", "10.0.0.5:1000", "", principal).Code)
+}
+
+func TestRateLimitUnaryServerInterceptor(t *testing.T) {
+	interceptor := middleware.RateLimitUnaryServerInterceptor(newTestLimiter(t), newTestClientIPResolver(t))
+	info := &grpc.UnaryServerInfo{FullMethod: "/location.Location/SetUserLocation"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return req, nil
+	}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1000}})
+
+	for i := 0; i < 2; i++ {
+		_, err := interceptor(ctx, nil, info, handler)
+		require.NoError(t, err)
+	}
+
+	_, err := interceptor(ctx, nil, info, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	require.InDelta(t, time.Second, details[0].(*errdetails.RetryInfo).RetryDelay.AsDuration(), float64(10*time.Millisecond))
+
+	// Requests of other peers are keyed by their IPs.
+	otherCtx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 1000}})
+	_, err = interceptor(otherCtx, nil, info, handler)
+	require.NoError(t, err)
+
+	// Other methods share the default limit.
+	info = &grpc.UnaryServerInfo{FullMethod: "/location.Location/ListUsersInRadius"}
+	_, err = interceptor(ctx, nil, info, handler)
+	require.NoError(t, err)
+	_, err = interceptor(ctx, nil, info, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestRateLimitStreamServerInterceptor(t *testing.T) {
+	interceptor := middleware.RateLimitStreamServerInterceptor(newTestLimiter(t), newTestClientIPResolver(t))
+	info := &grpc.StreamServerInfo{FullMethod: "/location.Location/SetUserLocations"}
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	}
+	stream := &testServerStream{
+		ctx: auth.AddPrincipalToCtx(context.Background(), auth.Principal{Subject: "alice"}),
+	}
+
+	err := interceptor(nil, stream, info, handler)
+	require.NoError(t, err)
+
+	err = interceptor(nil, stream, info, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestConcurrencyLimitMiddleware(t *testing.T) {
+	limiter := ratelimit.NewConcurrencyLimiter(1)
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := middleware.ConcurrencyLimitMiddleware(limiter, "/v1/users/feed")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/slow" {
+			close(started)
+			<-release
+		}
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/v1/slow", nil))
+		close(done)
+	}()
+	<-started
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+	require.Equal(t, "1", rec.Header().Get("Retry-After"))
+
+	// Exempt paths are not limited.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/users/feed", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Other requests are limited whatever they accept.
+	req := httptest.NewRequest(http.MethodPut, "/v1/users/alice/location", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+	close(release)
+	<-done
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/users", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestConcurrencyLimitUnaryServerInterceptor(t *testing.T) {
+	limiter := ratelimit.NewConcurrencyLimiter(1)
+	interceptor := middleware.ConcurrencyLimitUnaryServerInterceptor(limiter)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	require.True(t, limiter.Acquire())
+	_, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	details := status.Convert(err).Details()
+	require.Len(t, details, 1)
+	require.Equal(t, time.Second, details[0].(*errdetails.RetryInfo).RetryDelay.AsDuration())
+
+	limiter.Release()
+	_, err = interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+	require.NoError(t, err)
+}
+
+func TestConcurrencyLimitStreamServerInterceptor(t *testing.T) {
+	limiter := ratelimit.NewConcurrencyLimiter(1)
+	interceptor := middleware.ConcurrencyLimitStreamServerInterceptor(limiter)
+	handler := func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	}
+	stream := &testServerStream{ctx: context.Background()}
+	clientStream := &grpc.StreamServerInfo{FullMethod: "/location.Location/SetUserLocations", IsClientStream: true}
+	serverStream := &grpc.StreamServerInfo{FullMethod: "/location.Location/WatchUsersInRadius", IsServerStream: true}
+
+	require.True(t, limiter.Acquire())
+	err := interceptor(nil, stream, clientStream, handler)
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	require.Len(t, status.Convert(err).Details(), 1)
+
+	// Server streams are long-lived, so they are not limited.
+	require.NoError(t, interceptor(nil, stream, serverStream, handler))
+
+	limiter.Release()
+	require.NoError(t, interceptor(nil, stream, clientStream, handler))
+}
@@ -0,0 +1,29 @@
+package ratelimit
+
+// ConcurrencyLimiter limits the number of requests processed at the same time.
+type ConcurrencyLimiter struct {
+	slots chan struct{}
+}
+
+// NewConcurrencyLimiter returns a pointer to new instance of ConcurrencyLimiter that allows max requests at once.
+func NewConcurrencyLimiter(max int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		slots: make(chan struct{}, max),
+	}
+}
+
+// Acquire takes a slot without waiting and reports whether it succeeded.
+// Every successful call must be followed by Release.
+func (l *ConcurrencyLimiter) Acquire() bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Release returns a slot taken by Acquire.
+func (l *ConcurrencyLimiter) Release() {
+	<-l.slots
+}
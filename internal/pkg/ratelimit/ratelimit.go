@@ -0,0 +1,257 @@
+// Package ratelimit provides in-memory token bucket rate limiting and concurrency limiting.
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// sweepInterval is how often buckets that are full again are removed.
+	sweepInterval = time.Minute
+
+	// defaultRule is the rule index of buckets of the default limit.
+	defaultRule = -1
+)
+
+// Limit is a token bucket limit, Rate tokens per second are added up to Burst tokens.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// IsZero reports whether the limit is not set.
+func (l Limit) IsZero() bool {
+	return l == Limit{}
+}
+
+// ParseLimit parses a limit in the "rate:burst" format, for example "10:20" or "0.5:1".
+// An empty string is parsed as the zero limit.
+func ParseLimit(s string) (Limit, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Limit{}, nil
+	}
+
+	parts := strings.Split(s, ":")
+	if len(parts) != 2 {
+		return Limit{}, fmt.Errorf("limit %q is not in the rate:burst format", s)
+	}
+
+	rate, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || rate <= 0 || math.IsInf(rate, 0) {
+		return Limit{}, fmt.Errorf("rate of limit %q must be a positive number", s)
+	}
+
+	burst, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || burst < 1 {
+		return Limit{}, fmt.Errorf("burst of limit %q must be a positive integer", s)
+	}
+
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// Rule is a limit of routes matching the pattern.
+//
+// Routes and patterns are compared segment by segment split by slashes, for example
+// "PUT /v1/users/{username}/location" or "/location.Location/SetUserLocation".
+// A "{name}" segment matches any segment and captures it as the name parameter, a "*" segment matches any segment.
+type Rule struct {
+	Pattern  string
+	Limit    Limit
+	segments []string
+}
+
+// NewRule returns a rule of the pattern with the limit.
+func NewRule(pattern string, limit Limit) Rule {
+	return Rule{
+		Pattern:  pattern,
+		Limit:    limit,
+		segments: strings.Split(pattern, "/"),
+	}
+}
+
+// match reports whether the route matches the rule and returns parameters captured from the route.
+func (r Rule) match(route string) (map[string]string, bool) {
+	segments := strings.Split(route, "/")
+	if len(segments) != len(r.segments) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, segment := range r.segments {
+		switch {
+		case segment == "*":
+		case len(segment) > 2 && strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}"):
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[segment[1:len(segment)-1]] = segments[i]
+		case segment != segments[i]:
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// ParseRules parses rules in the "pattern=rate:burst" format.
+func ParseRules(entries []string) ([]Rule, error) {
+	rules := make([]Rule, 0, len(entries))
+	for _, entry := range entries {
+		i := strings.LastIndex(entry, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("rule %q is not in the pattern=rate:burst format", entry)
+		}
+
+		pattern := strings.TrimSpace(entry[:i])
+		if pattern == "" {
+			return nil, fmt.Errorf("pattern of rule %q is empty", entry)
+		}
+
+		limit, err := ParseLimit(entry[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		if limit.IsZero() {
+			return nil, fmt.Errorf("limit of rule %q is empty", entry)
+		}
+
+		rules = append(rules, NewRule(pattern, limit))
+	}
+
+	return rules, nil
+}
+
+// Config is a limiter configuration structure.
+type Config struct {
+	// Default is the "rate:burst" limit of routes no rule matches, they are not limited if it is empty.
+	Default string
+	// Routes are rules in the "pattern=rate:burst" format, the first matching rule applies.
+	Routes []string
+}
+
+// New returns a pointer to new instance of Limiter configured by cfg.
+func New(cfg Config) (*Limiter, error) {
+	defaultLimit, err := ParseLimit(cfg.Default)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ParseRules(cfg.Routes)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewLimiter(rules, defaultLimit), nil
+}
+
+type bucketKey struct {
+	rule int
+	key  string
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// Limiter limits rates of requests to routes by keys with token buckets stored in memory.
+// Requests of a key share one bucket per matching rule, requests no rule matches share one bucket of the default limit.
+type Limiter struct {
+	rules        []Rule
+	defaultLimit Limit
+	now          func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[bucketKey]*bucket
+	lastSweep time.Time
+}
+
+// NewLimiter returns a pointer to new instance of Limiter.
+// Routes no rule matches are not limited if defaultLimit is zero.
+func NewLimiter(rules []Rule, defaultLimit Limit) *Limiter {
+	return newLimiter(rules, defaultLimit, time.Now)
+}
+
+func newLimiter(rules []Rule, defaultLimit Limit, now func() time.Time) *Limiter {
+	return &Limiter{
+		rules:        rules,
+		defaultLimit: defaultLimit,
+		now:          now,
+		buckets:      make(map[bucketKey]*bucket),
+		lastSweep:    now(),
+	}
+}
+
+// Allow takes a token for the route from the bucket of the key keyFn returns.
+// keyFn gets parameters the matching rule captured from the route, they are nil for the default limit.
+//
+// It returns true if a token is taken or the route is not limited,
+// otherwise it returns false and the time until a token is available.
+func (l *Limiter) Allow(route string, keyFn func(params map[string]string) string) (bool, time.Duration) {
+	rule, limit, params := defaultRule, l.defaultLimit, map[string]string(nil)
+	for i, r := range l.rules {
+		if p, ok := r.match(route); ok {
+			rule, limit, params = i, r.Limit, p
+			break
+		}
+	}
+	if limit.IsZero() {
+		return true, 0
+	}
+
+	key := bucketKey{rule: rule, key: keyFn(params)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		l.buckets[key] = b
+	}
+	b.refill(limit, now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return true, 0
+	}
+
+	return false, time.Duration((1 - b.tokens) / limit.Rate * float64(time.Second))
+}
+
+// refill adds tokens accumulated since the last refill.
+func (b *bucket) refill(limit Limit, now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed.Seconds()*limit.Rate)
+		b.last = now
+	}
+}
+
+// sweep removes buckets that are full again, since they are indistinguishable from new ones.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		limit := l.defaultLimit
+		if key.rule != defaultRule {
+			limit = l.rules[key.rule].Limit
+		}
+
+		b.refill(limit, now)
+		if b.tokens >= float64(limit.Burst) {
+			delete(l.buckets, key)
+		}
+	}
+}
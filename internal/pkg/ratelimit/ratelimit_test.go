@@ -0,0 +1,181 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testClock struct {
+	now time.Time
+}
+
+func (c *testClock) Now() time.Time {
+	return c.now
+}
+
+func usernameKey(params map[string]string) string {
+	return params["username"]
+}
+
+func TestParseLimit(t *testing.T) {
+	testCases := []struct {
+		name     string
+		value    string
+		expected Limit
+		isErr    bool
+	}{
+		{name: "OK", value: "10:20", expected: Limit{Rate: 10, Burst: 20}},
+		{name: "OK_Fraction", value: " 0.5 : 1 ", expected: Limit{Rate: 0.5, Burst: 1}},
+		{name: "OK_Empty", value: ""},
+		{name: "NoBurst", value: "10", isErr: true},
+		{name: "ZeroRate", value: "0:1", isErr: true},
+		{name: "ZeroBurst", value: "1:0", isErr: true},
+		{name: "InvalidRate", value: "fast:1", isErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			limit, err := ParseLimit(tc.value)
+			if tc.isErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, limit)
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules, err := ParseRules([]string{"PUT /v1/users/{username}/location=1:2", "/location.Location/*=5:5"})
+	require.NoError(t, err)
+	require.Equal(t, []Rule{
+		NewRule("PUT /v1/users/{username}/location", Limit{Rate: 1, Burst: 2}),
+		NewRule("/location.Location/*", Limit{Rate: 5, Burst: 5}),
+	}, rules)
+
+	for _, entry := range []string{"PUT /v1/users", "=1:2", "PUT /v1/users=", "PUT /v1/users=1"} {
+		_, err = ParseRules([]string{entry})
+		require.Error(t, err, entry)
+	}
+}
+
+func TestRule_match(t *testing.T) {
+	rule := NewRule("PUT /v1/users/{username}/location", Limit{Rate: 1, Burst: 1})
+
+	params, ok := rule.match("PUT /v1/users/alice/location")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"username": "alice"}, params)
+
+	for _, route := range []string{
+		"GET /v1/users/alice/location",
+		"PUT /v1/users/alice",
+		"PUT /v1/users/alice/location/extra",
+	} {
+		_, ok = rule.match(route)
+		require.False(t, ok, route)
+	}
+
+	_, ok = NewRule("/location.Location/*", Limit{Rate: 1, Burst: 1}).match("/location.Location/SetUserLocation")
+	require.True(t, ok)
+}
+
+func TestLimiter_Allow(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	limiter := newLimiter([]Rule{
+		NewRule("PUT /v1/users/{username}/location", Limit{Rate: 1, Burst: 2}),
+	}, Limit{}, clock.Now)
+
+	route := "PUT /v1/users/alice/location"
+	for i := 0; i < 2; i++ {
+		ok, _ := limiter.Allow(route, usernameKey)
+		require.True(t, ok)
+	}
+
+	ok, retryAfter := limiter.Allow(route, usernameKey)
+	require.False(t, ok)
+	require.Equal(t, time.Second, retryAfter)
+
+	// Buckets are kept per key.
+	ok, _ = limiter.Allow("PUT /v1/users/bob/location", usernameKey)
+	require.True(t, ok)
+
+	// Routes no rule matches are not limited without the default limit.
+	ok, _ = limiter.Allow("GET /v1/users/alice", usernameKey)
+	require.True(t, ok)
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	ok, retryAfter = limiter.Allow(route, usernameKey)
+	require.False(t, ok)
+	require.Equal(t, 500*time.Millisecond, retryAfter)
+
+	clock.now = clock.now.Add(500 * time.Millisecond)
+	ok, _ = limiter.Allow(route, usernameKey)
+	require.True(t, ok)
+}
+
+func TestLimiter_Allow_Default(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	limiter := newLimiter(nil, Limit{Rate: 1, Burst: 1}, clock.Now)
+	keyFn := func(params map[string]string) string {
+		require.Nil(t, params)
+		return "127.0.0.1"
+	}
+
+	ok, _ := limiter.Allow("GET /v1/users", keyFn)
+	require.True(t, ok)
+
+	// Routes no rule matches share the bucket of the default limit.
+	ok, _ = limiter.Allow("GET /v1/geofences", keyFn)
+	require.False(t, ok)
+}
+
+func TestLimiter_sweep(t *testing.T) {
+	clock := &testClock{now: time.Now()}
+	limiter := newLimiter(nil, Limit{Rate: 1, Burst: 10}, clock.Now)
+	keyFn := func(key string) func(map[string]string) string {
+		return func(map[string]string) string { return key }
+	}
+
+	for i := 0; i < 10; i++ {
+		limiter.Allow("GET /v1/users", keyFn("alice"))
+	}
+
+	clock.now = clock.now.Add(sweepInterval - 5*time.Second)
+	for i := 0; i < 10; i++ {
+		limiter.Allow("GET /v1/users", keyFn("bob"))
+	}
+
+	clock.now = clock.now.Add(5 * time.Second)
+	limiter.Allow("GET /v1/users", keyFn("carol"))
+
+	// The bucket of alice is full again and removed, the one of bob is not.
+	require.Len(t, limiter.buckets, 2)
+	require.Contains(t, limiter.buckets, bucketKey{rule: defaultRule, key: "bob"})
+	require.Contains(t, limiter.buckets, bucketKey{rule: defaultRule, key: "carol"})
+}
+
+func TestNew(t *testing.T) {
+	_, err := New(Config{Default: "10:20", Routes: []string{"GET /v1/users=1:1"}})
+	require.NoError(t, err)
+
+	_, err = New(Config{Default: "10"})
+	require.Error(t, err)
+
+	_, err = New(Config{Routes: []string{"GET /v1/users"}})
+	require.Error(t, err)
+}
+
+func TestConcurrencyLimiter(t *testing.T) {
+	limiter := NewConcurrencyLimiter(2)
+
+	require.True(t, limiter.Acquire())
+	require.True(t, limiter.Acquire())
+	require.False(t, limiter.Acquire())
+
+	limiter.Release()
+	require.True(t, limiter.Acquire())
+}
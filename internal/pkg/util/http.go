@@ -3,7 +3,10 @@ package util
 import (
 	"encoding/json"
 	"io"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 // DecodeBody decodes request body.
@@ -18,6 +21,25 @@ func DecodeBody(r *http.Request, v interface{}) error {
 	return json.Unmarshal(b, &v)
 }
 
+// Accepts reports whether the request lists the media type in its Accept header.
+// Wildcards are not taken into account, so a client accepts the media type only if it asks for it explicitly.
+// Media types with zero quality are not accepted.
+func Accepts(r *http.Request, mediaType string) bool {
+	for _, header := range r.Header.Values("Accept") {
+		for _, value := range strings.Split(header, ",") {
+			t, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+			if err != nil || !strings.EqualFold(t, mediaType) {
+				continue
+			}
+			if q, err := strconv.ParseFloat(params["q"], 64); err == nil && q == 0 {
+				continue
+			}
+			return true
+		}
+	}
+	return false
+}
+
 // EncodeBody encodes response body.
 func EncodeBody(w http.ResponseWriter, v interface{}) error {
 	return json.NewEncoder(w).Encode(v)
@@ -0,0 +1,60 @@
+package util_test
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gitlab.com/spacewalker/geotracker/internal/pkg/util"
+)
+
+func TestAccepts(t *testing.T) {
+	testCases := []struct {
+		name     string
+		accept   []string
+		expected bool
+	}{
+		{
+			name:     "OK",
+			accept:   []string{"application/geo+json"},
+			expected: true,
+		},
+		{
+			name:     "OK_List",
+			accept:   []string{"application/json;q=0.9, application/geo+json"},
+			expected: true,
+		},
+		{
+			name:     "OK_SeveralHeaders",
+			accept:   []string{"application/json", "Application/Geo+JSON; q=0.5"},
+			expected: true,
+		},
+		{
+			name:   "NoHeader",
+			accept: nil,
+		},
+		{
+			name:   "OtherType",
+			accept: []string{"application/json"},
+		},
+		{
+			name:   "Wildcard",
+			accept: []string{"*/*"},
+		},
+		{
+			name:   "ZeroQuality",
+			accept: []string{"application/geo+json;q=0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			for _, accept := range tc.accept {
+				r.Header.Add("Accept", accept)
+			}
+			require.Equal(t, tc.expected, util.Accepts(r, "application/geo+json"))
+		})
+	}
+}
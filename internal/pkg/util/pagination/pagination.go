@@ -4,6 +4,8 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"math"
+	"strconv"
 )
 
 var (
@@ -31,3 +33,27 @@ func DecodeCursor(cursor string) (int, int, error) {
 
 	return pageToken, pageSize, nil
 }
+
+// EncodeDistanceCursor encodes a keyset of the last element of a page ordered by distance
+// and ID and page size into opaque page cursor.
+func EncodeDistanceCursor(distance float64, pageToken int, pageSize int) string {
+	rawStr := fmt.Sprintf("%s %d %d", strconv.FormatFloat(distance, 'g', -1, 64), pageToken, pageSize)
+	return base64.URLEncoding.EncodeToString([]byte(rawStr))
+}
+
+// DecodeDistanceCursor decodes opaque page cursor into distance, page token and page size.
+func DecodeDistanceCursor(cursor string) (float64, int, int, error) {
+	rawStr, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, 0, 0, ErrInvalidCursor
+	}
+
+	var distance float64
+	var pageToken, pageSize int
+	_, err = fmt.Sscanf(string(rawStr), "%g %d %d", &distance, &pageToken, &pageSize)
+	if err != nil || math.IsNaN(distance) || math.IsInf(distance, 0) {
+		return 0, 0, 0, ErrInvalidCursor
+	}
+
+	return distance, pageToken, pageSize, nil
+}
@@ -89,3 +89,92 @@ func TestDecodeCursor(t *testing.T) {
 		})
 	}
 }
+
+func TestDistanceCursor(t *testing.T) {
+	testCases := []struct {
+		name              string
+		distance          float64
+		pageToken         int
+		pageSize          int
+		expectedCursor    string
+		expectedDistance  float64
+		expectedPageToken int
+		expectedPageSize  int
+	}{
+		{
+			name:              "0 0 0",
+			distance:          0,
+			pageToken:         0,
+			pageSize:          0,
+			expectedCursor:    "MCAwIDA=",
+			expectedDistance:  0,
+			expectedPageToken: 0,
+			expectedPageSize:  0,
+		},
+		{
+			name:              "1234.5678 100 10",
+			distance:          1234.5678,
+			pageToken:         100,
+			pageSize:          10,
+			expectedCursor:    "MTIzNC41Njc4IDEwMCAxMA==",
+			expectedDistance:  1234.5678,
+			expectedPageToken: 100,
+			expectedPageSize:  10,
+		},
+		{
+			name:              "full precision",
+			distance:          0.30000000000000004,
+			pageToken:         1,
+			pageSize:          1,
+			expectedCursor:    "MC4zMDAwMDAwMDAwMDAwMDAwNCAxIDE=",
+			expectedDistance:  0.30000000000000004,
+			expectedPageToken: 1,
+			expectedPageSize:  1,
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			cursor := pagination.EncodeDistanceCursor(tc.distance, tc.pageToken, tc.pageSize)
+			require.Equal(t, tc.expectedCursor, cursor)
+
+			distance, pageToken, pageSize, err := pagination.DecodeDistanceCursor(cursor)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedDistance, distance)
+			require.Equal(t, tc.expectedPageToken, pageToken)
+			require.Equal(t, tc.expectedPageSize, pageSize)
+		})
+	}
+}
+
+func TestDecodeDistanceCursor_Invalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cursor string
+	}{
+		{
+			name:   "InvalidBase64",
+			cursor: "invalid",
+		},
+		{
+			name:   "InvalidFormat_IDCursor",
+			cursor: "MTAwIDEwMA==",
+		},
+		{
+			name:   "InvalidFormat_NaN",
+			cursor: "TmFOIDEgMQ==",
+		},
+	}
+
+	for _, tc := range testCases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			distance, pageToken, pageSize, err := pagination.DecodeDistanceCursor(tc.cursor)
+			require.ErrorIs(t, err, pagination.ErrInvalidCursor)
+			require.Zero(t, distance)
+			require.Zero(t, pageToken)
+			require.Zero(t, pageSize)
+		})
+	}
+}
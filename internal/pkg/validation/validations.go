@@ -5,6 +5,7 @@ import (
 	"regexp"
 
 	"github.com/go-playground/validator/v10"
+	"gitlab.com/spacewalker/geotracker/internal/app/location/core/domain"
 	"gitlab.com/spacewalker/geotracker/internal/app/location/core/port"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/geo"
 	"gitlab.com/spacewalker/geotracker/internal/pkg/util/pagination"
@@ -42,6 +43,34 @@ func ValidateGeoPoint(fl validator.FieldLevel) bool {
 	return false
 }
 
+func ValidateBBox(fl validator.FieldLevel) bool {
+	if value, ok := fl.Field().Interface().(geo.BBox); ok {
+		return value.Validate() == nil
+	}
+
+	return false
+}
+
+func ValidatePolygon(fl validator.FieldLevel) bool {
+	if value, ok := fl.Field().Interface().(geo.Polygon); ok {
+		return value.Validate() == nil
+	}
+
+	return false
+}
+
+func ValidateGeohash(fl validator.FieldLevel) bool {
+	return geo.ValidGeohash(fl.Field().String())
+}
+
+func ValidateBearing(fl validator.FieldLevel) bool {
+	return geo.ValidBearing(fl.Field().Float())
+}
+
+func ValidateFixSource(fl validator.FieldLevel) bool {
+	return geo.ValidFixSource(geo.FixSource(fl.Field().String()))
+}
+
 func ValidatePageToken(fl validator.FieldLevel) bool {
 	cursor := fl.Field().String()
 	if cursor == "" {
@@ -57,10 +86,85 @@ func ValidatePageToken(fl validator.FieldLevel) bool {
 func ValidateListMethod(sl validator.StructLevel) {
 	switch v := sl.Current().Interface().(type) {
 	case port.UserServiceListUsersInRadiusRequest:
-		if (v.PageToken == "" && v.PageSize == 0) ||
-			(v.PageToken != "" && v.PageSize != 0) {
-			sl.ReportError(v.PageToken, "page_token", "PageToken", "pagesize_or_pagetoken", v.PageToken)
-			sl.ReportError(v.PageSize, "page_size", "PageSize", "pagesize_or_pagetoken", fmt.Sprint(v.PageSize))
+		validatePageTokenOrPageSize(sl, v.PageToken, v.PageSize)
+	case port.UserServiceListUsersInBBoxRequest:
+		validatePageTokenOrPageSize(sl, v.PageToken, v.PageSize)
+	case port.UserServiceListUsersInPolygonRequest:
+		validatePageTokenOrPageSize(sl, v.PageToken, v.PageSize)
+	case port.UserServiceListUsersRequest:
+		validatePageTokenOrPageSize(sl, v.PageToken, v.PageSize)
+	case port.GeofenceServiceListGeofencesRequest:
+		validatePageTokenOrPageSize(sl, v.PageToken, v.PageSize)
+	case port.GeofenceServiceListGeofenceEventsRequest:
+		validatePageTokenOrPageSize(sl, v.PageToken, v.PageSize)
+	case port.ErasureServiceListErasureJobsRequest:
+		validatePageTokenOrPageSize(sl, v.PageToken, v.PageSize)
+	}
+}
+
+func ValidateGeofence(sl validator.StructLevel) {
+	switch v := sl.Current().Interface().(type) {
+	case port.GeofenceServiceCreateGeofenceRequest:
+		validateGeofenceShape(sl, v.Type, v.Center, v.Radius, v.Polygon)
+	case port.GeofenceServiceUpdateGeofenceRequest:
+		validateGeofenceShape(sl, v.Type, v.Center, v.Radius, v.Polygon)
+	}
+}
+
+// validateGeofenceShape reports an error unless a circle geofence has only a valid center and a positive radius,
+// or a polygon geofence has only a valid polygon.
+func validateGeofenceShape(sl validator.StructLevel, typ domain.GeofenceType, center *geo.Point, radius float64, polygon geo.Polygon) {
+	switch typ {
+	case domain.GeofenceTypeCircle:
+		if center == nil || !geo.ValidPoint(*center) {
+			sl.ReportError(center, "center", "Center", "validgeopoint", "")
+		}
+		if radius <= 0 {
+			sl.ReportError(radius, "radius", "Radius", "gt", "0")
+		}
+		if polygon != nil {
+			sl.ReportError(polygon, "polygon", "Polygon", "excluded_if", "type circle")
 		}
+	case domain.GeofenceTypePolygon:
+		if polygon.Validate() != nil {
+			sl.ReportError(polygon, "polygon", "Polygon", "validpolygon", "")
+		}
+		if center != nil {
+			sl.ReportError(center, "center", "Center", "excluded_if", "type polygon")
+		}
+		if radius != 0 {
+			sl.ReportError(radius, "radius", "Radius", "excluded_if", "type polygon")
+		}
+	}
+}
+
+// ValidatePrivacySettings reports an error unless exactly one of grid size and geohash precision
+// is provided for the coarse level and neither of them is provided for other levels.
+func ValidatePrivacySettings(sl validator.StructLevel) {
+	v, ok := sl.Current().Interface().(port.UserServiceSetPrivacySettingsRequest)
+	if !ok {
+		return
+	}
+
+	if v.Level == domain.PrivacyLevelCoarse {
+		if (v.GridSize == 0) == (v.GeohashPrecision == 0) {
+			sl.ReportError(v.GridSize, "grid_size", "GridSize", "grid_size_or_geohash_precision", "")
+		}
+		return
+	}
+	if v.GridSize != 0 {
+		sl.ReportError(v.GridSize, "grid_size", "GridSize", "excluded_unless", "level coarse")
+	}
+	if v.GeohashPrecision != 0 {
+		sl.ReportError(v.GeohashPrecision, "geohash_precision", "GeohashPrecision", "excluded_unless", "level coarse")
+	}
+}
+
+// validatePageTokenOrPageSize reports an error unless exactly one of page token and page size is provided.
+func validatePageTokenOrPageSize(sl validator.StructLevel, pageToken string, pageSize int) {
+	if (pageToken == "" && pageSize == 0) ||
+		(pageToken != "" && pageSize != 0) {
+		sl.ReportError(pageToken, "page_token", "PageToken", "pagesize_or_pagetoken", pageToken)
+		sl.ReportError(pageSize, "page_size", "PageSize", "pagesize_or_pagetoken", fmt.Sprint(pageSize))
 	}
 }
@@ -19,7 +19,10 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type HistoryClient interface {
 	AddRecord(ctx context.Context, in *AddRecordRequest, opts ...grpc.CallOption) (*AddRecordResponse, error)
+	AddRecords(ctx context.Context, in *AddRecordsRequest, opts ...grpc.CallOption) (*AddRecordsResponse, error)
 	GetDistance(ctx context.Context, in *GetDistanceRequest, opts ...grpc.CallOption) (*GetDistanceResponse, error)
+	// DeleteUserRecords deletes all the records of the user. It is idempotent.
+	DeleteUserRecords(ctx context.Context, in *DeleteUserRecordsRequest, opts ...grpc.CallOption) (*DeleteUserRecordsResponse, error)
 }
 
 type historyClient struct {
@@ -39,6 +42,15 @@ func (c *historyClient) AddRecord(ctx context.Context, in *AddRecordRequest, opt
 	return out, nil
 }
 
+func (c *historyClient) AddRecords(ctx context.Context, in *AddRecordsRequest, opts ...grpc.CallOption) (*AddRecordsResponse, error) {
+	out := new(AddRecordsResponse)
+	err := c.cc.Invoke(ctx, "/proto.History/AddRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *historyClient) GetDistance(ctx context.Context, in *GetDistanceRequest, opts ...grpc.CallOption) (*GetDistanceResponse, error) {
 	out := new(GetDistanceResponse)
 	err := c.cc.Invoke(ctx, "/proto.History/GetDistance", in, out, opts...)
@@ -48,12 +60,24 @@ func (c *historyClient) GetDistance(ctx context.Context, in *GetDistanceRequest,
 	return out, nil
 }
 
+func (c *historyClient) DeleteUserRecords(ctx context.Context, in *DeleteUserRecordsRequest, opts ...grpc.CallOption) (*DeleteUserRecordsResponse, error) {
+	out := new(DeleteUserRecordsResponse)
+	err := c.cc.Invoke(ctx, "/proto.History/DeleteUserRecords", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // HistoryServer is the server API for History service.
 // All implementations must embed UnimplementedHistoryServer
 // for forward compatibility
 type HistoryServer interface {
 	AddRecord(context.Context, *AddRecordRequest) (*AddRecordResponse, error)
+	AddRecords(context.Context, *AddRecordsRequest) (*AddRecordsResponse, error)
 	GetDistance(context.Context, *GetDistanceRequest) (*GetDistanceResponse, error)
+	// DeleteUserRecords deletes all the records of the user. It is idempotent.
+	DeleteUserRecords(context.Context, *DeleteUserRecordsRequest) (*DeleteUserRecordsResponse, error)
 	mustEmbedUnimplementedHistoryServer()
 }
 
@@ -64,9 +88,15 @@ type UnimplementedHistoryServer struct {
 func (UnimplementedHistoryServer) AddRecord(context.Context, *AddRecordRequest) (*AddRecordResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AddRecord not implemented")
 }
+func (UnimplementedHistoryServer) AddRecords(context.Context, *AddRecordsRequest) (*AddRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddRecords not implemented")
+}
 func (UnimplementedHistoryServer) GetDistance(context.Context, *GetDistanceRequest) (*GetDistanceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetDistance not implemented")
 }
+func (UnimplementedHistoryServer) DeleteUserRecords(context.Context, *DeleteUserRecordsRequest) (*DeleteUserRecordsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserRecords not implemented")
+}
 func (UnimplementedHistoryServer) mustEmbedUnimplementedHistoryServer() {}
 
 // UnsafeHistoryServer may be embedded to opt out of forward compatibility for this service.
@@ -98,6 +128,24 @@ func _History_AddRecord_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _History_AddRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HistoryServer).AddRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.History/AddRecords",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HistoryServer).AddRecords(ctx, req.(*AddRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _History_GetDistance_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetDistanceRequest)
 	if err := dec(in); err != nil {
@@ -116,6 +164,24 @@ func _History_GetDistance_Handler(srv interface{}, ctx context.Context, dec func
 	return interceptor(ctx, in, info, handler)
 }
 
+func _History_DeleteUserRecords_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserRecordsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HistoryServer).DeleteUserRecords(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.History/DeleteUserRecords",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HistoryServer).DeleteUserRecords(ctx, req.(*DeleteUserRecordsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // History_ServiceDesc is the grpc.ServiceDesc for History service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -127,10 +193,18 @@ var History_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AddRecord",
 			Handler:    _History_AddRecord_Handler,
 		},
+		{
+			MethodName: "AddRecords",
+			Handler:    _History_AddRecords_Handler,
+		},
 		{
 			MethodName: "GetDistance",
 			Handler:    _History_GetDistance_Handler,
 		},
+		{
+			MethodName: "DeleteUserRecords",
+			Handler:    _History_DeleteUserRecords_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "history.proto",
@@ -19,7 +19,31 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type LocationClient interface {
 	SetUserLocation(ctx context.Context, in *SetUserLocationRequest, opts ...grpc.CallOption) (*SetUserLocationResponse, error)
+	// SetUserLocations applies all the streamed fixes at once when the stream is closed.
+	SetUserLocations(ctx context.Context, opts ...grpc.CallOption) (Location_SetUserLocationsClient, error)
 	ListUsersInRadius(ctx context.Context, in *ListUsersInRadiusRequest, opts ...grpc.CallOption) (*ListUsersInRadiusResponse, error)
+	ListNearestUsers(ctx context.Context, in *ListNearestUsersRequest, opts ...grpc.CallOption) (*ListNearestUsersResponse, error)
+	// WatchUsersInRadius sends users inside the circle as ENTER events first,
+	// then ENTER, MOVE and EXIT events as locations of users change.
+	// It fails with RESOURCE_EXHAUSTED if the client falls too far behind, the client should watch again then.
+	WatchUsersInRadius(ctx context.Context, in *WatchUsersInRadiusRequest, opts ...grpc.CallOption) (Location_WatchUsersInRadiusClient, error)
+	CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*User, error)
+	GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error)
+	ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error)
+	RenameUser(ctx context.Context, in *RenameUserRequest, opts ...grpc.CallOption) (*User, error)
+	DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error)
+	// FollowUser creates a pending follow request, the followee becomes a connection of the user once it is accepted.
+	// It fails with FAILED_PRECONDITION if any of the users blocked the other one.
+	FollowUser(ctx context.Context, in *FollowUserRequest, opts ...grpc.CallOption) (*Follow, error)
+	AcceptFollower(ctx context.Context, in *AcceptFollowerRequest, opts ...grpc.CallOption) (*Follow, error)
+	// DeleteFollow unfollows, cancels, revokes or declines a follow request.
+	DeleteFollow(ctx context.Context, in *DeleteFollowRequest, opts ...grpc.CallOption) (*DeleteFollowResponse, error)
+	ListFollowing(ctx context.Context, in *ListFollowsRequest, opts ...grpc.CallOption) (*ListFollowsResponse, error)
+	ListFollowers(ctx context.Context, in *ListFollowsRequest, opts ...grpc.CallOption) (*ListFollowsResponse, error)
+	// BlockUser deletes follow requests between the users in both directions and rejects new ones.
+	BlockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*Block, error)
+	UnblockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*UnblockUserResponse, error)
+	ListBlocks(ctx context.Context, in *ListBlocksRequest, opts ...grpc.CallOption) (*ListBlocksResponse, error)
 }
 
 type locationClient struct {
@@ -39,6 +63,40 @@ func (c *locationClient) SetUserLocation(ctx context.Context, in *SetUserLocatio
 	return out, nil
 }
 
+func (c *locationClient) SetUserLocations(ctx context.Context, opts ...grpc.CallOption) (Location_SetUserLocationsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Location_ServiceDesc.Streams[0], "/proto.Location/SetUserLocations", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &locationSetUserLocationsClient{stream}
+	return x, nil
+}
+
+type Location_SetUserLocationsClient interface {
+	Send(*SetUserLocationsRequest) error
+	CloseAndRecv() (*SetUserLocationsResponse, error)
+	grpc.ClientStream
+}
+
+type locationSetUserLocationsClient struct {
+	grpc.ClientStream
+}
+
+func (x *locationSetUserLocationsClient) Send(m *SetUserLocationsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *locationSetUserLocationsClient) CloseAndRecv() (*SetUserLocationsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(SetUserLocationsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func (c *locationClient) ListUsersInRadius(ctx context.Context, in *ListUsersInRadiusRequest, opts ...grpc.CallOption) (*ListUsersInRadiusResponse, error) {
 	out := new(ListUsersInRadiusResponse)
 	err := c.cc.Invoke(ctx, "/proto.Location/ListUsersInRadius", in, out, opts...)
@@ -48,12 +106,194 @@ func (c *locationClient) ListUsersInRadius(ctx context.Context, in *ListUsersInR
 	return out, nil
 }
 
+func (c *locationClient) ListNearestUsers(ctx context.Context, in *ListNearestUsersRequest, opts ...grpc.CallOption) (*ListNearestUsersResponse, error) {
+	out := new(ListNearestUsersResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/ListNearestUsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) WatchUsersInRadius(ctx context.Context, in *WatchUsersInRadiusRequest, opts ...grpc.CallOption) (Location_WatchUsersInRadiusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Location_ServiceDesc.Streams[1], "/proto.Location/WatchUsersInRadius", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &locationWatchUsersInRadiusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Location_WatchUsersInRadiusClient interface {
+	Recv() (*WatchUsersInRadiusResponse, error)
+	grpc.ClientStream
+}
+
+type locationWatchUsersInRadiusClient struct {
+	grpc.ClientStream
+}
+
+func (x *locationWatchUsersInRadiusClient) Recv() (*WatchUsersInRadiusResponse, error) {
+	m := new(WatchUsersInRadiusResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *locationClient) CreateUser(ctx context.Context, in *CreateUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/proto.Location/CreateUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) GetUser(ctx context.Context, in *GetUserRequest, opts ...grpc.CallOption) (*GetUserResponse, error) {
+	out := new(GetUserResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/GetUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) ListUsers(ctx context.Context, in *ListUsersRequest, opts ...grpc.CallOption) (*ListUsersResponse, error) {
+	out := new(ListUsersResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/ListUsers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) RenameUser(ctx context.Context, in *RenameUserRequest, opts ...grpc.CallOption) (*User, error) {
+	out := new(User)
+	err := c.cc.Invoke(ctx, "/proto.Location/RenameUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) DeleteUser(ctx context.Context, in *DeleteUserRequest, opts ...grpc.CallOption) (*DeleteUserResponse, error) {
+	out := new(DeleteUserResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/DeleteUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) FollowUser(ctx context.Context, in *FollowUserRequest, opts ...grpc.CallOption) (*Follow, error) {
+	out := new(Follow)
+	err := c.cc.Invoke(ctx, "/proto.Location/FollowUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) AcceptFollower(ctx context.Context, in *AcceptFollowerRequest, opts ...grpc.CallOption) (*Follow, error) {
+	out := new(Follow)
+	err := c.cc.Invoke(ctx, "/proto.Location/AcceptFollower", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) DeleteFollow(ctx context.Context, in *DeleteFollowRequest, opts ...grpc.CallOption) (*DeleteFollowResponse, error) {
+	out := new(DeleteFollowResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/DeleteFollow", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) ListFollowing(ctx context.Context, in *ListFollowsRequest, opts ...grpc.CallOption) (*ListFollowsResponse, error) {
+	out := new(ListFollowsResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/ListFollowing", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) ListFollowers(ctx context.Context, in *ListFollowsRequest, opts ...grpc.CallOption) (*ListFollowsResponse, error) {
+	out := new(ListFollowsResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/ListFollowers", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) BlockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*Block, error) {
+	out := new(Block)
+	err := c.cc.Invoke(ctx, "/proto.Location/BlockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) UnblockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*UnblockUserResponse, error) {
+	out := new(UnblockUserResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/UnblockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *locationClient) ListBlocks(ctx context.Context, in *ListBlocksRequest, opts ...grpc.CallOption) (*ListBlocksResponse, error) {
+	out := new(ListBlocksResponse)
+	err := c.cc.Invoke(ctx, "/proto.Location/ListBlocks", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // LocationServer is the server API for Location service.
 // All implementations must embed UnimplementedLocationServer
 // for forward compatibility
 type LocationServer interface {
 	SetUserLocation(context.Context, *SetUserLocationRequest) (*SetUserLocationResponse, error)
+	// SetUserLocations applies all the streamed fixes at once when the stream is closed.
+	SetUserLocations(Location_SetUserLocationsServer) error
 	ListUsersInRadius(context.Context, *ListUsersInRadiusRequest) (*ListUsersInRadiusResponse, error)
+	ListNearestUsers(context.Context, *ListNearestUsersRequest) (*ListNearestUsersResponse, error)
+	// WatchUsersInRadius sends users inside the circle as ENTER events first,
+	// then ENTER, MOVE and EXIT events as locations of users change.
+	// It fails with RESOURCE_EXHAUSTED if the client falls too far behind, the client should watch again then.
+	WatchUsersInRadius(*WatchUsersInRadiusRequest, Location_WatchUsersInRadiusServer) error
+	CreateUser(context.Context, *CreateUserRequest) (*User, error)
+	GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error)
+	ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error)
+	RenameUser(context.Context, *RenameUserRequest) (*User, error)
+	DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error)
+	// FollowUser creates a pending follow request, the followee becomes a connection of the user once it is accepted.
+	// It fails with FAILED_PRECONDITION if any of the users blocked the other one.
+	FollowUser(context.Context, *FollowUserRequest) (*Follow, error)
+	AcceptFollower(context.Context, *AcceptFollowerRequest) (*Follow, error)
+	// DeleteFollow unfollows, cancels, revokes or declines a follow request.
+	DeleteFollow(context.Context, *DeleteFollowRequest) (*DeleteFollowResponse, error)
+	ListFollowing(context.Context, *ListFollowsRequest) (*ListFollowsResponse, error)
+	ListFollowers(context.Context, *ListFollowsRequest) (*ListFollowsResponse, error)
+	// BlockUser deletes follow requests between the users in both directions and rejects new ones.
+	BlockUser(context.Context, *BlockUserRequest) (*Block, error)
+	UnblockUser(context.Context, *BlockUserRequest) (*UnblockUserResponse, error)
+	ListBlocks(context.Context, *ListBlocksRequest) (*ListBlocksResponse, error)
 	mustEmbedUnimplementedLocationServer()
 }
 
@@ -64,9 +304,57 @@ type UnimplementedLocationServer struct {
 func (UnimplementedLocationServer) SetUserLocation(context.Context, *SetUserLocationRequest) (*SetUserLocationResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method SetUserLocation not implemented")
 }
+func (UnimplementedLocationServer) SetUserLocations(Location_SetUserLocationsServer) error {
+	return status.Errorf(codes.Unimplemented, "method SetUserLocations not implemented")
+}
 func (UnimplementedLocationServer) ListUsersInRadius(context.Context, *ListUsersInRadiusRequest) (*ListUsersInRadiusResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListUsersInRadius not implemented")
 }
+func (UnimplementedLocationServer) ListNearestUsers(context.Context, *ListNearestUsersRequest) (*ListNearestUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNearestUsers not implemented")
+}
+func (UnimplementedLocationServer) WatchUsersInRadius(*WatchUsersInRadiusRequest, Location_WatchUsersInRadiusServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchUsersInRadius not implemented")
+}
+func (UnimplementedLocationServer) CreateUser(context.Context, *CreateUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateUser not implemented")
+}
+func (UnimplementedLocationServer) GetUser(context.Context, *GetUserRequest) (*GetUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUser not implemented")
+}
+func (UnimplementedLocationServer) ListUsers(context.Context, *ListUsersRequest) (*ListUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListUsers not implemented")
+}
+func (UnimplementedLocationServer) RenameUser(context.Context, *RenameUserRequest) (*User, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameUser not implemented")
+}
+func (UnimplementedLocationServer) DeleteUser(context.Context, *DeleteUserRequest) (*DeleteUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUser not implemented")
+}
+func (UnimplementedLocationServer) FollowUser(context.Context, *FollowUserRequest) (*Follow, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FollowUser not implemented")
+}
+func (UnimplementedLocationServer) AcceptFollower(context.Context, *AcceptFollowerRequest) (*Follow, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptFollower not implemented")
+}
+func (UnimplementedLocationServer) DeleteFollow(context.Context, *DeleteFollowRequest) (*DeleteFollowResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteFollow not implemented")
+}
+func (UnimplementedLocationServer) ListFollowing(context.Context, *ListFollowsRequest) (*ListFollowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFollowing not implemented")
+}
+func (UnimplementedLocationServer) ListFollowers(context.Context, *ListFollowsRequest) (*ListFollowsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFollowers not implemented")
+}
+func (UnimplementedLocationServer) BlockUser(context.Context, *BlockUserRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockUser not implemented")
+}
+func (UnimplementedLocationServer) UnblockUser(context.Context, *BlockUserRequest) (*UnblockUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockUser not implemented")
+}
+func (UnimplementedLocationServer) ListBlocks(context.Context, *ListBlocksRequest) (*ListBlocksResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBlocks not implemented")
+}
 func (UnimplementedLocationServer) mustEmbedUnimplementedLocationServer() {}
 
 // UnsafeLocationServer may be embedded to opt out of forward compatibility for this service.
@@ -98,6 +386,32 @@ func _Location_SetUserLocation_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Location_SetUserLocations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LocationServer).SetUserLocations(&locationSetUserLocationsServer{stream})
+}
+
+type Location_SetUserLocationsServer interface {
+	SendAndClose(*SetUserLocationsResponse) error
+	Recv() (*SetUserLocationsRequest, error)
+	grpc.ServerStream
+}
+
+type locationSetUserLocationsServer struct {
+	grpc.ServerStream
+}
+
+func (x *locationSetUserLocationsServer) SendAndClose(m *SetUserLocationsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *locationSetUserLocationsServer) Recv() (*SetUserLocationsRequest, error) {
+	m := new(SetUserLocationsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 func _Location_ListUsersInRadius_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(ListUsersInRadiusRequest)
 	if err := dec(in); err != nil {
@@ -116,6 +430,279 @@ func _Location_ListUsersInRadius_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Location_ListNearestUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNearestUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).ListNearestUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/ListNearestUsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).ListNearestUsers(ctx, req.(*ListNearestUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_WatchUsersInRadius_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchUsersInRadiusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LocationServer).WatchUsersInRadius(m, &locationWatchUsersInRadiusServer{stream})
+}
+
+type Location_WatchUsersInRadiusServer interface {
+	Send(*WatchUsersInRadiusResponse) error
+	grpc.ServerStream
+}
+
+type locationWatchUsersInRadiusServer struct {
+	grpc.ServerStream
+}
+
+func (x *locationWatchUsersInRadiusServer) Send(m *WatchUsersInRadiusResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Location_CreateUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).CreateUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/CreateUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).CreateUser(ctx, req.(*CreateUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_GetUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).GetUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/GetUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).GetUser(ctx, req.(*GetUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_ListUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).ListUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/ListUsers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).ListUsers(ctx, req.(*ListUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_RenameUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).RenameUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/RenameUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).RenameUser(ctx, req.(*RenameUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_DeleteUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).DeleteUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/DeleteUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).DeleteUser(ctx, req.(*DeleteUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_FollowUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FollowUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).FollowUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/FollowUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).FollowUser(ctx, req.(*FollowUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_AcceptFollower_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcceptFollowerRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).AcceptFollower(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/AcceptFollower",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).AcceptFollower(ctx, req.(*AcceptFollowerRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_DeleteFollow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteFollowRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).DeleteFollow(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/DeleteFollow",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).DeleteFollow(ctx, req.(*DeleteFollowRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_ListFollowing_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFollowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).ListFollowing(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/ListFollowing",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).ListFollowing(ctx, req.(*ListFollowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_ListFollowers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListFollowsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).ListFollowers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/ListFollowers",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).ListFollowers(ctx, req.(*ListFollowsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_BlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).BlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/BlockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).BlockUser(ctx, req.(*BlockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_UnblockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).UnblockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/UnblockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).UnblockUser(ctx, req.(*BlockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Location_ListBlocks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBlocksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocationServer).ListBlocks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/proto.Location/ListBlocks",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocationServer).ListBlocks(ctx, req.(*ListBlocksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // Location_ServiceDesc is the grpc.ServiceDesc for Location service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -131,7 +718,74 @@ var Location_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListUsersInRadius",
 			Handler:    _Location_ListUsersInRadius_Handler,
 		},
+		{
+			MethodName: "ListNearestUsers",
+			Handler:    _Location_ListNearestUsers_Handler,
+		},
+		{
+			MethodName: "CreateUser",
+			Handler:    _Location_CreateUser_Handler,
+		},
+		{
+			MethodName: "GetUser",
+			Handler:    _Location_GetUser_Handler,
+		},
+		{
+			MethodName: "ListUsers",
+			Handler:    _Location_ListUsers_Handler,
+		},
+		{
+			MethodName: "RenameUser",
+			Handler:    _Location_RenameUser_Handler,
+		},
+		{
+			MethodName: "DeleteUser",
+			Handler:    _Location_DeleteUser_Handler,
+		},
+		{
+			MethodName: "FollowUser",
+			Handler:    _Location_FollowUser_Handler,
+		},
+		{
+			MethodName: "AcceptFollower",
+			Handler:    _Location_AcceptFollower_Handler,
+		},
+		{
+			MethodName: "DeleteFollow",
+			Handler:    _Location_DeleteFollow_Handler,
+		},
+		{
+			MethodName: "ListFollowing",
+			Handler:    _Location_ListFollowing_Handler,
+		},
+		{
+			MethodName: "ListFollowers",
+			Handler:    _Location_ListFollowers_Handler,
+		},
+		{
+			MethodName: "BlockUser",
+			Handler:    _Location_BlockUser_Handler,
+		},
+		{
+			MethodName: "UnblockUser",
+			Handler:    _Location_UnblockUser_Handler,
+		},
+		{
+			MethodName: "ListBlocks",
+			Handler:    _Location_ListBlocks_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SetUserLocations",
+			Handler:       _Location_SetUserLocations_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "WatchUsersInRadius",
+			Handler:       _Location_WatchUsersInRadius_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "location.proto",
 }